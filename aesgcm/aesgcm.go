@@ -0,0 +1,170 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package aesgcm implements the symmetric encryption used by XEP-0454: OMEMO
+// Media sharing.
+//
+// Files are encrypted using AES-256 in Galois/Counter Mode with a random key
+// and initialization vector (IV) before being uploaded, and the key and IV are
+// shared by sending a link with the "aesgcm" URL scheme that contains them in
+// the fragment.
+// Links received from other clients can be parsed with Parse and the
+// downloaded file decrypted with the resulting key.
+// The link should only ever be sent over an end-to-end encrypted channel such
+// as OMEMO since anyone that sees it can decrypt the file.
+package aesgcm // import "mellium.im/xmpp/aesgcm"
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Scheme is the URL scheme used for links to encrypted files.
+const Scheme = "aesgcm"
+
+// Sizes of the key, IV, and authentication tag in bytes.
+const (
+	KeySize = 32
+	IVSize  = 12
+	TagSize = 16
+
+	// LegacyIVSize is the size of the IV used by some older clients.
+	// It is accepted when parsing URLs but never generated.
+	LegacyIVSize = 16
+)
+
+var errShortCiphertext = errors.New("aesgcm: ciphertext too short")
+
+// Key is the key and initialization vector used to encrypt a single file.
+// A key must never be used to encrypt more than one file.
+type Key struct {
+	IV  []byte
+	Key []byte
+}
+
+// GenerateKey returns a new random key and IV.
+func GenerateKey() (Key, error) {
+	b := make([]byte, IVSize+KeySize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{IV: b[:IVSize:IVSize], Key: b[IVSize:]}, nil
+}
+
+func (k Key) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, len(k.IV))
+}
+
+// Seal encrypts and authenticates plaintext and returns the ciphertext
+// followed by the authentication tag.
+// The returned slice is TagSize bytes longer than plaintext.
+func (k Key) Seal(plaintext []byte) ([]byte, error) {
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, k.IV, plaintext, nil), nil
+}
+
+// Open authenticates and decrypts ciphertext that was encrypted using Seal.
+func (k Key) Open(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < TagSize {
+		return nil, errShortCiphertext
+	}
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, k.IV, ciphertext, nil)
+}
+
+// Encrypt reads plaintext from src until EOF and writes the ciphertext and
+// authentication tag to dst.
+// It returns the number of bytes written to dst.
+//
+// Because the authentication tag covers the entire file, src is read into
+// memory before anything is written to dst.
+func (k Key) Encrypt(dst io.Writer, src io.Reader) (int64, error) {
+	plaintext, err := io.ReadAll(src)
+	if err != nil {
+		return 0, err
+	}
+	ciphertext, err := k.Seal(plaintext)
+	if err != nil {
+		return 0, err
+	}
+	n, err := dst.Write(ciphertext)
+	return int64(n), err
+}
+
+// Decrypt reads ciphertext from src until EOF and writes the decrypted
+// plaintext to dst.
+// It returns the number of bytes written to dst.
+//
+// Because the authentication tag is at the end of the file, src is read into
+// memory and authenticated before anything is written to dst so that no
+// unauthenticated data is ever returned.
+func (k Key) Decrypt(dst io.Writer, src io.Reader) (int64, error) {
+	ciphertext, err := io.ReadAll(src)
+	if err != nil {
+		return 0, err
+	}
+	plaintext, err := k.Open(ciphertext)
+	if err != nil {
+		return 0, err
+	}
+	n, err := dst.Write(plaintext)
+	return int64(n), err
+}
+
+// Parse extracts the key and IV from an aesgcm URL and returns them along
+// with a copy of the URL that uses the "https" scheme and has no fragment,
+// which is where the encrypted file can be downloaded.
+func Parse(u *url.URL) (Key, *url.URL, error) {
+	if u.Scheme != Scheme {
+		return Key{}, nil, fmt.Errorf("aesgcm: expected scheme %s, got %q", Scheme, u.Scheme)
+	}
+	var ivSize int
+	switch len(u.Fragment) {
+	case 2 * (IVSize + KeySize):
+		ivSize = IVSize
+	case 2 * (LegacyIVSize + KeySize):
+		ivSize = LegacyIVSize
+	default:
+		return Key{}, nil, fmt.Errorf("aesgcm: fragment has wrong length %d", len(u.Fragment))
+	}
+	b, err := hex.DecodeString(u.Fragment)
+	if err != nil {
+		return Key{}, nil, fmt.Errorf("aesgcm: invalid key: %w", err)
+	}
+
+	httpsURL := *u
+	httpsURL.Scheme = "https"
+	httpsURL.Fragment = ""
+	httpsURL.RawFragment = ""
+	return Key{IV: b[:ivSize:ivSize], Key: b[ivSize:]}, &httpsURL, nil
+}
+
+// URL returns a copy of u with its scheme replaced by Scheme and the IV and
+// key encoded as hex in the fragment.
+// The URL u is normally the HTTPS URL where the encrypted file can be
+// downloaded.
+func (k Key) URL(u *url.URL) *url.URL {
+	aesURL := *u
+	aesURL.Scheme = Scheme
+	aesURL.Fragment = hex.EncodeToString(k.IV) + hex.EncodeToString(k.Key)
+	aesURL.RawFragment = ""
+	return &aesURL
+}
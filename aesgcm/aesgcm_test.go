@@ -0,0 +1,197 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package aesgcm_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/aesgcm"
+)
+
+func TestGenerateKey(t *testing.T) {
+	k1, err := aesgcm.GenerateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	if len(k1.IV) != aesgcm.IVSize || len(k1.Key) != aesgcm.KeySize {
+		t.Fatalf("wrong sizes: want=%d/%d, got=%d/%d", aesgcm.IVSize, aesgcm.KeySize, len(k1.IV), len(k1.Key))
+	}
+	k2, err := aesgcm.GenerateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	if bytes.Equal(k1.Key, k2.Key) || bytes.Equal(k1.IV, k2.IV) {
+		t.Errorf("generated the same key twice")
+	}
+}
+
+func TestSeal(t *testing.T) {
+	k, err := aesgcm.GenerateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	plaintext := []byte("Romeo, Romeo! wherefore art thou Romeo?")
+	ciphertext, err := k.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+	if len(ciphertext) != len(plaintext)+aesgcm.TagSize {
+		t.Errorf("wrong ciphertext length: want=%d, got=%d", len(plaintext)+aesgcm.TagSize, len(ciphertext))
+	}
+
+	block, err := aes.NewCipher(k.Key)
+	if err != nil {
+		t.Fatalf("error creating cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("error creating AEAD: %v", err)
+	}
+	out, err := aead.Open(nil, k.IV, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("error decrypting: %v", err)
+	}
+	if !bytes.Equal(out, plaintext) {
+		t.Errorf("wrong plaintext: want=%q, got=%q", plaintext, out)
+	}
+
+	_, err = aesgcm.Key{IV: k.IV, Key: k.Key[:5]}.Seal(plaintext)
+	if err == nil {
+		t.Errorf("expected error when using invalid key")
+	}
+}
+
+func TestURL(t *testing.T) {
+	k := aesgcm.Key{
+		IV:  bytes.Repeat([]byte{0xab}, aesgcm.IVSize),
+		Key: bytes.Repeat([]byte{0x01}, aesgcm.KeySize),
+	}
+	u, err := url.Parse("https://download.montague.tld/4a771ac1-f0b2-4a4a-9700-f2a26fa2bb67/tr%C3%A8s%20cool.jpg")
+	if err != nil {
+		t.Fatalf("error parsing URL: %v", err)
+	}
+	const expected = "aesgcm://download.montague.tld/4a771ac1-f0b2-4a4a-9700-f2a26fa2bb67/tr%C3%A8s%20cool.jpg#abababababababababababab0101010101010101010101010101010101010101010101010101010101010101"
+	if out := k.URL(u).String(); out != expected {
+		t.Errorf("wrong URL:\nwant=%s,\n got=%s", expected, out)
+	}
+	if u.Scheme != "https" {
+		t.Errorf("original URL was modified: %v", u)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	k, err := aesgcm.GenerateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	const plaintext = "But, soft! what light through yonder window breaks?"
+	var ciphertext bytes.Buffer
+	n, err := k.Encrypt(&ciphertext, strings.NewReader(plaintext))
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+	if n != int64(len(plaintext)+aesgcm.TagSize) {
+		t.Errorf("wrong number of bytes written: want=%d, got=%d", len(plaintext)+aesgcm.TagSize, n)
+	}
+
+	httpsURL, err := url.Parse("https://example.net/file.txt")
+	if err != nil {
+		t.Fatalf("error parsing URL: %v", err)
+	}
+	parsedKey, parsedURL, err := aesgcm.Parse(k.URL(httpsURL))
+	if err != nil {
+		t.Fatalf("error parsing aesgcm URL: %v", err)
+	}
+	if s := parsedURL.String(); s != httpsURL.String() {
+		t.Errorf("wrong download URL: want=%s, got=%s", httpsURL, s)
+	}
+	if !bytes.Equal(parsedKey.Key, k.Key) || !bytes.Equal(parsedKey.IV, k.IV) {
+		t.Errorf("wrong key after round trip: want=%x/%x, got=%x/%x", k.IV, k.Key, parsedKey.IV, parsedKey.Key)
+	}
+
+	var out strings.Builder
+	tampered := append([]byte(nil), ciphertext.Bytes()...)
+	tampered[0] ^= 0xff
+	_, err = parsedKey.Decrypt(&out, bytes.NewReader(tampered))
+	if err == nil {
+		t.Errorf("expected error decrypting tampered ciphertext")
+	}
+	if out.Len() != 0 {
+		t.Errorf("unauthenticated data was written: %q", out.String())
+	}
+
+	_, err = parsedKey.Decrypt(&out, &ciphertext)
+	if err != nil {
+		t.Fatalf("error decrypting: %v", err)
+	}
+	if out.String() != plaintext {
+		t.Errorf("wrong plaintext: want=%q, got=%q", plaintext, out.String())
+	}
+
+	_, err = k.Open(make([]byte, aesgcm.TagSize-1))
+	if err == nil {
+		t.Errorf("expected error opening short ciphertext")
+	}
+}
+
+var parseTestCases = [...]struct {
+	in    string
+	ivLen int
+	err   bool
+}{
+	0: {
+		in:    "aesgcm://example.net/a#" + strings.Repeat("00", aesgcm.IVSize+aesgcm.KeySize),
+		ivLen: aesgcm.IVSize,
+	},
+	1: {
+		in:    "aesgcm://example.net/a#" + strings.Repeat("00", aesgcm.LegacyIVSize+aesgcm.KeySize),
+		ivLen: aesgcm.LegacyIVSize,
+	},
+	2: {
+		in:  "https://example.net/a#" + strings.Repeat("00", aesgcm.IVSize+aesgcm.KeySize),
+		err: true,
+	},
+	3: {
+		in:  "aesgcm://example.net/a#0000",
+		err: true,
+	},
+	4: {
+		in:  "aesgcm://example.net/a#" + strings.Repeat("zz", aesgcm.IVSize+aesgcm.KeySize),
+		err: true,
+	},
+}
+
+func TestParse(t *testing.T) {
+	for i, tc := range parseTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			u, err := url.Parse(tc.in)
+			if err != nil {
+				t.Fatalf("error parsing URL: %v", err)
+			}
+			k, httpsURL, err := aesgcm.Parse(u)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(k.IV) != tc.ivLen || len(k.Key) != aesgcm.KeySize {
+				t.Errorf("wrong sizes: want=%d/%d, got=%d/%d", tc.ivLen, aesgcm.KeySize, len(k.IV), len(k.Key))
+			}
+			if s := httpsURL.String(); s != "https://example.net/a" {
+				t.Errorf("wrong download URL: %s", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar
+
+import (
+	/* #nosec */
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"strconv"
+
+	"mellium.im/xmlstream"
+)
+
+// ID returns the ID of an avatar, which is the hex encoded SHA-1 hash of the
+// image data.
+func ID(data []byte) string {
+	/* #nosec */
+	h := sha1.Sum(data)
+	return hex.EncodeToString(h[:])
+}
+
+// Info is information about a single version of an avatar.
+// An avatar may be available in several formats or sizes, but each of them
+// must represent the same image.
+type Info struct {
+	// ID is the hex encoded SHA-1 hash of the image data.
+	ID string
+	// Bytes is the size of the image data in bytes.
+	Bytes uint32
+	// Type is the media type of the image, for example "image/png".
+	Type string
+	// Width and Height are the dimensions of the image in pixels, if known.
+	Width, Height uint16
+	// URL is an optional HTTP or HTTPS URL from which the image can be fetched
+	// instead of from the data node.
+	URL string
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (i Info) TokenReader() xml.TokenReader {
+	attrs := []xml.Attr{
+		{Name: xml.Name{Local: "bytes"}, Value: strconv.FormatUint(uint64(i.Bytes), 10)},
+		{Name: xml.Name{Local: "id"}, Value: i.ID},
+		{Name: xml.Name{Local: "type"}, Value: i.Type},
+	}
+	if i.Width > 0 {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "width"}, Value: strconv.FormatUint(uint64(i.Width), 10)})
+	}
+	if i.Height > 0 {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "height"}, Value: strconv.FormatUint(uint64(i.Height), 10)})
+	}
+	if i.URL != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "url"}, Value: i.URL})
+	}
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NSMetadata, Local: "info"},
+		Attr: attrs,
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (i Info) WriteXML(w xmlstream.TokenWriter) (n int, err error) {
+	return xmlstream.Copy(w, i.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (i Info) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := i.WriteXML(e)
+	return err
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (i *Info) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	data := struct {
+		ID     string `xml:"id,attr"`
+		Bytes  uint32 `xml:"bytes,attr"`
+		Type   string `xml:"type,attr"`
+		Width  uint16 `xml:"width,attr"`
+		Height uint16 `xml:"height,attr"`
+		URL    string `xml:"url,attr"`
+	}{}
+	err := d.DecodeElement(&data, &start)
+	if err != nil {
+		return err
+	}
+	i.ID = data.ID
+	i.Bytes = data.Bytes
+	i.Type = data.Type
+	i.Width = data.Width
+	i.Height = data.Height
+	i.URL = data.URL
+	return nil
+}
+
+// Metadata is the contents of the avatar metadata node.
+// Metadata without any info elements indicates that the user has disabled
+// their avatar.
+type Metadata struct {
+	XMLName xml.Name `xml:"urn:xmpp:avatar:metadata metadata"`
+	Info    []Info   `xml:"info"`
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (m Metadata) TokenReader() xml.TokenReader {
+	var infos []xml.TokenReader
+	for _, info := range m.Info {
+		infos = append(infos, info.TokenReader())
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(infos...),
+		xml.StartElement{Name: xml.Name{Space: NSMetadata, Local: "metadata"}},
+	)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (m Metadata) WriteXML(w xmlstream.TokenWriter) (n int, err error) {
+	return xmlstream.Copy(w, m.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (m Metadata) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := m.WriteXML(e)
+	return err
+}
+
+// ID returns the ID of the avatar described by the metadata or the empty
+// string if the avatar is disabled.
+// If the metadata contains several versions of the avatar the ID of the first
+// one is returned since the item published to the metadata node uses the same
+// ID.
+func (m Metadata) ID() string {
+	if len(m.Info) == 0 {
+		return ""
+	}
+	return m.Info[0].ID
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar_test
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"mellium.im/xmpp/avatar"
+)
+
+var marshalTestCases = [...]struct {
+	in  interface{}
+	out string
+}{
+	0: {
+		in:  avatar.Metadata{},
+		out: `<metadata xmlns="urn:xmpp:avatar:metadata"></metadata>`,
+	},
+	1: {
+		in: avatar.Metadata{Info: []avatar.Info{{
+			ID:     "111f4b3c50d7b0df729d299bc6f8e9ef9066971f",
+			Bytes:  12345,
+			Type:   "image/png",
+			Width:  64,
+			Height: 64,
+		}, {
+			ID:    "e279f80c38f99c1e7e53e262b440993b2f7eea57",
+			Bytes: 60000,
+			Type:  "image/png",
+			URL:   "https://example.net/avatar.png",
+		}}},
+		out: `<metadata xmlns="urn:xmpp:avatar:metadata"><info xmlns="urn:xmpp:avatar:metadata" bytes="12345" id="111f4b3c50d7b0df729d299bc6f8e9ef9066971f" type="image/png" width="64" height="64"></info><info xmlns="urn:xmpp:avatar:metadata" bytes="60000" id="e279f80c38f99c1e7e53e262b440993b2f7eea57" type="image/png" url="https://example.net/avatar.png"></info></metadata>`,
+	},
+	2: {
+		in:  avatar.VCardUpdate{},
+		out: `<x xmlns="vcard-temp:x:update"><photo></photo></x>`,
+	},
+	3: {
+		in:  avatar.Metadata{Info: []avatar.Info{{ID: "111f4b3c50d7b0df729d299bc6f8e9ef9066971f"}}}.VCardUpdate(),
+		out: `<x xmlns="vcard-temp:x:update"><photo>111f4b3c50d7b0df729d299bc6f8e9ef9066971f</photo></x>`,
+	},
+}
+
+func TestMarshal(t *testing.T) {
+	for i, tc := range marshalTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := xml.Marshal(tc.in)
+			if err != nil {
+				t.Fatalf("error marshaling: %v", err)
+			}
+			if string(out) != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	want := marshalTestCases[1].in.(avatar.Metadata)
+	var got avatar.Metadata
+	err := xml.Unmarshal([]byte(marshalTestCases[1].out), &got)
+	if err != nil {
+		t.Fatalf("error unmarshaling: %v", err)
+	}
+	got.XMLName = xml.Name{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong metadata:\nwant=%+v,\n got=%+v", want, got)
+	}
+	if id := got.ID(); id != want.Info[0].ID {
+		t.Errorf("wrong ID: want=%s, got=%s", want.Info[0].ID, id)
+	}
+}
+
+func TestID(t *testing.T) {
+	const want = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	if id := avatar.ID(nil); id != want {
+		t.Errorf("wrong ID for empty data: want=%s, got=%s", want, id)
+	}
+}
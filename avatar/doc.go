@@ -0,0 +1,32 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h Handler" -vars "FeatureNotify:NSNotify"
+
+// Package avatar implements publishing and retrieving user avatars.
+//
+// Avatars are stored in two personal eventing (PEP) nodes as described in
+// XEP-0084: User Avatar.
+// The data node contains the image itself and the metadata node contains
+// information about the image such as its size and type.
+// Clients that are interested in avatars subscribe to the metadata node by
+// advertising the FeatureNotify feature and only fetch the data if they do not
+// already have a cached copy of the image.
+//
+// This package does not decode, validate, or scale images: it is up to the
+// caller to make sure that the data matches the advertised type and
+// dimensions.
+//
+// For compatibility with clients that use vCard based avatars, the VCardUpdate
+// type can be used to advertise the hash of the current avatar in presence as
+// described in XEP-0153: vCard-Based Avatars.
+package avatar // import "mellium.im/xmpp/avatar"
+
+// Namespaces used by this package.
+const (
+	NSData     = "urn:xmpp:avatar:data"
+	NSMetadata = "urn:xmpp:avatar:metadata"
+	NSNotify   = "urn:xmpp:avatar:metadata+notify"
+	NSVCard    = "vcard-temp:x:update"
+)
@@ -0,0 +1,99 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"strings"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+// Errors returned by this package.
+var (
+	ErrNotFound = errors.New("avatar: no avatar data found")
+	ErrMismatch = errors.New("avatar: data does not match ID")
+)
+
+// FetchMetadata requests the current avatar metadata of the given user.
+// If the user has not published any metadata, empty metadata is returned.
+func FetchMetadata(ctx context.Context, s *xmpp.Session, j jid.JID) (Metadata, error) {
+	return FetchMetadataIQ(ctx, stanza.IQ{To: j}, s)
+}
+
+// FetchMetadataIQ is like FetchMetadata except that it allows modifying the
+// IQ.
+// The IQ should be addressed to the bare JID of the user.
+// Changing the type of the provided IQ has no effect.
+func FetchMetadataIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session) (Metadata, error) {
+	var m Metadata
+	iter := pubsub.FetchIQ(ctx, iq, s, pubsub.Query{
+		Node:     NSMetadata,
+		MaxItems: 1,
+	})
+	/* #nosec */
+	defer iter.Close()
+	if iter.Next() {
+		_, r := iter.Item()
+		err := xml.NewTokenDecoder(r).Decode(&m)
+		if err != nil {
+			return m, err
+		}
+	}
+	return m, iter.Err()
+}
+
+// FetchData requests the image data with the given ID from the data node of
+// the given user.
+// If the data does not hash to the ID, ErrMismatch is returned.
+func FetchData(ctx context.Context, s *xmpp.Session, j jid.JID, id string) ([]byte, error) {
+	return FetchDataIQ(ctx, stanza.IQ{To: j}, s, id)
+}
+
+// FetchDataIQ is like FetchData except that it allows modifying the IQ.
+// The IQ should be addressed to the bare JID of the user.
+// Changing the type of the provided IQ has no effect.
+func FetchDataIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session, id string) ([]byte, error) {
+	iter := pubsub.FetchIQ(ctx, iq, s, pubsub.Query{
+		Node: NSData,
+		Item: id,
+	})
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		itemID, r := iter.Item()
+		if itemID != id {
+			continue
+		}
+		payload := struct {
+			XMLName xml.Name `xml:"urn:xmpp:avatar:data data"`
+			Data    string   `xml:",chardata"`
+		}{}
+		err := xml.NewTokenDecoder(r).Decode(&payload)
+		if err != nil {
+			return nil, err
+		}
+		// Base64 data may be split across lines, so remove any whitespace before
+		// decoding it.
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(payload.Data), ""))
+		if err != nil {
+			return nil, err
+		}
+		if ID(data) != id {
+			return nil, ErrMismatch
+		}
+		return data, nil
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle returns an option that registers a Handler for avatar metadata
+// notifications and advertises support for them.
+// Because only one handler can be registered for pubsub event notifications,
+// Handle cannot be used on the same multiplexer as pubsub.HandleEvents.
+// To receive notifications for other nodes as well, register the handler's
+// HandleEvent method with an existing pubsub.Events instead.
+func Handle(h Handler) mux.Option {
+	e := &pubsub.Events{}
+	e.HandleNode(NSMetadata, h.HandleEvent)
+	return func(m *mux.ServeMux) {
+		pubsub.HandleEvents(e)(m)
+		mux.Feature(h)(m)
+	}
+}
+
+// Handler receives notifications when the avatar of a contact changes.
+type Handler struct {
+	// Update is called with the bare JID of the user and their new avatar
+	// metadata.
+	// If the metadata does not contain any info the user disabled their avatar.
+	Update func(from jid.JID, m Metadata) error
+}
+
+// HandleEvent satisfies pubsub.EventFunc for the metadata node.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (h Handler) HandleEvent(msg stanza.Message, iter *pubsub.EventIter) error {
+	for iter.Next() {
+		if iter.Retracted() {
+			continue
+		}
+		_, r := iter.Item()
+		var m Metadata
+		err := xml.NewTokenDecoder(r).Decode(&m)
+		switch {
+		case errors.Is(err, io.EOF):
+			// The notification did not include a payload.
+			continue
+		case err != nil:
+			return err
+		}
+		if h.Update != nil {
+			err = h.Update(msg.From.Bare(), m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return iter.Err()
+}
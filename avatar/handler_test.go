@@ -0,0 +1,70 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/avatar"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+const eventMsg = `<message xmlns="jabber:client" from="juliet@example.com" to="romeo@example.net/orchard" type="headline">
+<event xmlns="http://jabber.org/protocol/pubsub#event">
+<items node="urn:xmpp:avatar:metadata">
+<item id="111f4b3c50d7b0df729d299bc6f8e9ef9066971f"><metadata xmlns="urn:xmpp:avatar:metadata"><info bytes="12345" id="111f4b3c50d7b0df729d299bc6f8e9ef9066971f" type="image/png"/></metadata></item>
+<item id="none"/>
+<item id="disabled"><metadata xmlns="urn:xmpp:avatar:metadata"/></item>
+</items>
+</event>
+</message>`
+
+func TestHandler(t *testing.T) {
+	var got []string
+	m := mux.New(stanza.NSClient, avatar.Handle(avatar.Handler{
+		Update: func(from jid.JID, m avatar.Metadata) error {
+			got = append(got, from.String()+":"+m.ID())
+			return nil
+		},
+	}))
+
+	d := xml.NewDecoder(strings.NewReader(eventMsg))
+	tok, err := d.Token()
+	if err != nil {
+		t.Fatalf("error popping start token: %v", err)
+	}
+	start := tok.(xml.StartElement)
+	err = m.HandleXMPP(struct {
+		xml.TokenReader
+		xmlstream.Encoder
+	}{
+		TokenReader: d,
+	}, &start)
+	if err != nil {
+		t.Fatalf("error handling event: %v", err)
+	}
+	const want = "juliet@example.com:111f4b3c50d7b0df729d299bc6f8e9ef9066971f,juliet@example.com:"
+	if s := strings.Join(got, ","); s != want {
+		t.Errorf("wrong updates: want=%s, got=%s", want, s)
+	}
+
+	var features []string
+	err = m.ForFeatures("", func(f info.Feature) error {
+		features = append(features, f.Var)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error iterating features: %v", err)
+	}
+	if s := strings.Join(features, ","); !strings.Contains(s, avatar.NSNotify) {
+		t.Errorf("notify feature not advertised: %s", s)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+// Publish stores the image data in the data node and then updates the
+// metadata node to point to it, notifying subscribers of the change.
+// If the ID or size in info are not set, they are computed from data.
+// The completed info is returned.
+func Publish(ctx context.Context, s *xmpp.Session, info Info, data []byte) (Info, error) {
+	return PublishIQ(ctx, s, stanza.IQ{}, info, data)
+}
+
+// PublishIQ is like Publish except that it allows modifying the IQs.
+// Changes to the IQ type will have no effect.
+func PublishIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, info Info, data []byte) (Info, error) {
+	if info.ID == "" {
+		info.ID = ID(data)
+	}
+	if info.Bytes == 0 {
+		info.Bytes = uint32(len(data))
+	}
+	iq.Type = stanza.SetIQ
+	_, err := pubsub.PublishIQ(ctx, s, iq, NSData, info.ID, xmlstream.Wrap(
+		xmlstream.Token(xml.CharData(base64.StdEncoding.EncodeToString(data))),
+		xml.StartElement{Name: xml.Name{Space: NSData, Local: "data"}},
+	))
+	if err != nil {
+		return info, err
+	}
+	return info, PublishMetadataIQ(ctx, s, iq, Metadata{Info: []Info{info}})
+}
+
+// PublishMetadata updates the metadata node without publishing any image data.
+// This can be used to advertise additional versions of an avatar that are
+// hosted elsewhere, or to disable the avatar by publishing metadata without
+// any info.
+func PublishMetadata(ctx context.Context, s *xmpp.Session, m Metadata) error {
+	return PublishMetadataIQ(ctx, s, stanza.IQ{}, m)
+}
+
+// PublishMetadataIQ is like PublishMetadata except that it allows modifying
+// the IQ.
+// Changes to the IQ type will have no effect.
+func PublishMetadataIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, m Metadata) error {
+	iq.Type = stanza.SetIQ
+	_, err := pubsub.PublishIQ(ctx, s, iq, NSMetadata, m.ID(), m.TokenReader())
+	return err
+}
+
+// Disable publishes empty metadata to indicate that the avatar should no
+// longer be shown.
+func Disable(ctx context.Context, s *xmpp.Session) error {
+	return PublishMetadata(ctx, s, Metadata{})
+}
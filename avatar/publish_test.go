@@ -0,0 +1,115 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/avatar"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/stanza"
+)
+
+var imgData = []byte("Wherefore art thou?")
+
+// replyWith returns a server handler that records the payload of each IQ and
+// responds with the provided payload.
+func replyWith(payload string, got chan<- string) xmpptest.Option {
+	return xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		var buf strings.Builder
+		e := xml.NewEncoder(&buf)
+		_, err = xmlstream.Copy(e, xmlstream.Inner(t))
+		if err != nil {
+			return err
+		}
+		if err = e.Flush(); err != nil {
+			return err
+		}
+		got <- buf.String()
+		var r xml.TokenReader
+		if payload != "" {
+			r = xml.NewDecoder(strings.NewReader(payload))
+		}
+		_, err = xmlstream.Copy(t, iq.Result(r))
+		return err
+	})
+}
+
+func TestPublish(t *testing.T) {
+	got := make(chan string, 2)
+	s := xmpptest.NewClientServer(replyWith("", got))
+
+	id := avatar.ID(imgData)
+	info, err := avatar.Publish(context.Background(), s.Client, avatar.Info{Type: "image/png"}, imgData)
+	if err != nil {
+		t.Fatalf("error publishing avatar: %v", err)
+	}
+	if info.ID != id || info.Bytes != uint32(len(imgData)) || info.Type != "image/png" {
+		t.Errorf("wrong info: %+v", info)
+	}
+
+	wantData := fmt.Sprintf(`<pubsub xmlns="http://jabber.org/protocol/pubsub" xmlns="http://jabber.org/protocol/pubsub"><publish xmlns="http://jabber.org/protocol/pubsub" node="urn:xmpp:avatar:data"><item xmlns="http://jabber.org/protocol/pubsub" id="%s"><data xmlns="urn:xmpp:avatar:data" xmlns="urn:xmpp:avatar:data">%s</data></item></publish></pubsub>`, id, base64.StdEncoding.EncodeToString(imgData))
+	if req := <-got; req != wantData {
+		t.Errorf("wrong data request:\nwant=%s,\n got=%s", wantData, req)
+	}
+	wantMeta := fmt.Sprintf(`<pubsub xmlns="http://jabber.org/protocol/pubsub" xmlns="http://jabber.org/protocol/pubsub"><publish xmlns="http://jabber.org/protocol/pubsub" node="urn:xmpp:avatar:metadata"><item xmlns="http://jabber.org/protocol/pubsub" id="%s"><metadata xmlns="urn:xmpp:avatar:metadata" xmlns="urn:xmpp:avatar:metadata"><info xmlns="urn:xmpp:avatar:metadata" xmlns="urn:xmpp:avatar:metadata" bytes="19" id="%[1]s" type="image/png"></info></metadata></item></publish></pubsub>`, id)
+	if req := <-got; req != wantMeta {
+		t.Errorf("wrong metadata request:\nwant=%s,\n got=%s", wantMeta, req)
+	}
+}
+
+func TestFetchData(t *testing.T) {
+	id := avatar.ID(imgData)
+	encoded := base64.StdEncoding.EncodeToString(imgData)
+	for _, tc := range []struct {
+		name string
+		data string
+		err  error
+	}{
+		{name: "valid", data: encoded},
+		{name: "whitespace", data: encoded[:10] + "\n  " + encoded[10:]},
+		{name: "mismatch", data: base64.StdEncoding.EncodeToString([]byte("bad")), err: avatar.ErrMismatch},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make(chan string, 1)
+			s := xmpptest.NewClientServer(replyWith(fmt.Sprintf(`<pubsub xmlns="http://jabber.org/protocol/pubsub"><items node="urn:xmpp:avatar:data"><item id="%s"><data xmlns="urn:xmpp:avatar:data">%s</data></item></items></pubsub>`, id, tc.data), got))
+			data, err := avatar.FetchData(context.Background(), s.Client, s.Server.LocalAddr(), id)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("wrong error: want=%v, got=%v", tc.err, err)
+			}
+			<-got
+			if tc.err == nil && string(data) != string(imgData) {
+				t.Errorf("wrong data: want=%q, got=%q", imgData, data)
+			}
+		})
+	}
+}
+
+func TestFetchMetadata(t *testing.T) {
+	got := make(chan string, 1)
+	s := xmpptest.NewClientServer(replyWith(`<pubsub xmlns="http://jabber.org/protocol/pubsub"><items node="urn:xmpp:avatar:metadata"><item id="111f4b3c50d7b0df729d299bc6f8e9ef9066971f"><metadata xmlns="urn:xmpp:avatar:metadata"><info bytes="12345" width="64" height="64" id="111f4b3c50d7b0df729d299bc6f8e9ef9066971f" type="image/png"/></metadata></item></items></pubsub>`, got))
+	m, err := avatar.FetchMetadata(context.Background(), s.Client, s.Server.LocalAddr())
+	if err != nil {
+		t.Fatalf("error fetching metadata: %v", err)
+	}
+	const wantReq = `<pubsub xmlns="http://jabber.org/protocol/pubsub" xmlns="http://jabber.org/protocol/pubsub"><items xmlns="http://jabber.org/protocol/pubsub" node="urn:xmpp:avatar:metadata" max_items="1"></items></pubsub>`
+	if req := <-got; req != wantReq {
+		t.Errorf("wrong request:\nwant=%s,\n got=%s", wantReq, req)
+	}
+	if len(m.Info) != 1 || m.ID() != "111f4b3c50d7b0df729d299bc6f8e9ef9066971f" || m.Info[0].Width != 64 || m.Info[0].Bytes != 12345 {
+		t.Errorf("wrong metadata: %+v", m)
+	}
+}
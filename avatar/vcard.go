@@ -0,0 +1,54 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package avatar
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+)
+
+// VCardUpdate is a presence payload used by clients that support vCard based
+// avatars to advertise the ID of the current avatar.
+// Avatar IDs are computed the same way for vCard and PEP based avatars, so a
+// client that publishes its avatar with this package can include the same ID
+// in its presence for compatibility with older clients.
+type VCardUpdate struct {
+	XMLName xml.Name `xml:"vcard-temp:x:update x"`
+
+	// Photo is the ID of the current avatar.
+	// If it is empty the user does not have an avatar.
+	Photo string `xml:"photo"`
+}
+
+// VCardUpdate returns a presence payload advertising the ID of the avatar
+// described by the metadata.
+func (m Metadata) VCardUpdate() VCardUpdate {
+	return VCardUpdate{Photo: m.ID()}
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (v VCardUpdate) TokenReader() xml.TokenReader {
+	var photo xml.TokenReader
+	if v.Photo != "" {
+		photo = xmlstream.Token(xml.CharData(v.Photo))
+	}
+	return xmlstream.Wrap(
+		xmlstream.Wrap(photo, xml.StartElement{Name: xml.Name{Local: "photo"}}),
+		xml.StartElement{Name: xml.Name{Space: NSVCard, Local: "x"}},
+	)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (v VCardUpdate) WriteXML(w xmlstream.TokenWriter) (n int, err error) {
+	return xmlstream.Copy(w, v.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (v VCardUpdate) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := v.WriteXML(e)
+	return err
+}
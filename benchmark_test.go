@@ -0,0 +1,295 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"io"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/websocket"
+)
+
+// benchTransport creates a connected pair of sessions over a transport.
+type benchTransport struct {
+	name string
+	dial func(b *testing.B) (client, server *xmpp.Session)
+}
+
+var benchTransports = []benchTransport{
+	{name: "pipe", dial: dialPipe},
+	{name: "tcp", dial: dialTCP},
+	{name: "tls", dial: dialTLS},
+	{name: "websocket", dial: dialWebSocket},
+	{name: "tcp-coalesce", dial: dialTCPCoalesce},
+	{name: "tls-coalesce", dial: dialTLSCoalesce},
+}
+
+var benchOrigin = jid.MustParse("test@example.net")
+
+func benchConfig(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+	return xmpp.StreamConfig{
+		Features: []xmpp.StreamFeature{xmpp.BindResource()},
+	}
+}
+
+// benchCoalesceConfig is like benchConfig except that writes are coalesced.
+func benchCoalesceConfig(s *xmpp.Session, cfg *xmpp.StreamConfig) xmpp.StreamConfig {
+	c := benchConfig(s, cfg)
+	c.Coalesce = &xmpp.WriteCoalescing{}
+	return c
+}
+
+// negotiate establishes a client session on clientConn and a server session on
+// serverConn concurrently.
+// To avoid measuring authentication the sessions are assumed to already be
+// secure and authenticated so that only resource binding is negotiated.
+func negotiate(b *testing.B, clientConn, serverConn io.ReadWriter, negotiator xmpp.Negotiator) (client, server *xmpp.Session) {
+	b.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		server, err = xmpp.ReceiveSession(ctx, serverConn, xmpp.Secure|xmpp.Authn, negotiator)
+		errs <- err
+	}()
+	client, err := xmpp.NewSession(ctx, benchOrigin.Domain(), benchOrigin, clientConn, xmpp.Secure|xmpp.Authn, negotiator)
+	if err != nil {
+		b.Fatalf("error negotiating client session: %v", err)
+	}
+	if err := <-errs; err != nil {
+		b.Fatalf("error negotiating server session: %v", err)
+	}
+	return client, server
+}
+
+func dialPipe(b *testing.B) (client, server *xmpp.Session) {
+	clientConn, serverConn := net.Pipe()
+	return negotiate(b, clientConn, serverConn, xmpp.NewNegotiator(benchConfig))
+}
+
+func tcpPair(b *testing.B) (client, server net.Conn) {
+	b.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatalf("error listening: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			b.Errorf("error accepting: %v", err)
+		}
+		accepted <- c
+	}()
+	client, err = net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		b.Fatalf("error dialing: %v", err)
+	}
+	return client, <-accepted
+}
+
+func dialTCP(b *testing.B) (client, server *xmpp.Session) {
+	clientConn, serverConn := tcpPair(b)
+	return negotiate(b, clientConn, serverConn, xmpp.NewNegotiator(benchConfig))
+}
+
+func dialTCPCoalesce(b *testing.B) (client, server *xmpp.Session) {
+	clientConn, serverConn := tcpPair(b)
+	return negotiate(b, clientConn, serverConn, xmpp.NewNegotiator(benchCoalesceConfig))
+}
+
+func benchCert(b *testing.B) tls.Certificate {
+	b.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		b.Fatalf("error generating key: %v", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"example.net"},
+	}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		b.Fatalf("error creating certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func dialTLS(b *testing.B) (client, server *xmpp.Session) {
+	clientConn, serverConn := tcpPair(b)
+	/* #nosec */
+	tlsClient := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+	tlsServer := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{benchCert(b)}})
+	return negotiate(b, tlsClient, tlsServer, xmpp.NewNegotiator(benchConfig))
+}
+
+func dialTLSCoalesce(b *testing.B) (client, server *xmpp.Session) {
+	clientConn, serverConn := tcpPair(b)
+	/* #nosec */
+	tlsClient := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+	tlsServer := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{benchCert(b)}})
+	return negotiate(b, tlsClient, tlsServer, xmpp.NewNegotiator(benchCoalesceConfig))
+}
+
+func dialWebSocket(b *testing.B) (client, server *xmpp.Session) {
+	b.Helper()
+	serverConns := make(chan net.Conn)
+	done := make(chan struct{})
+	srv := httptest.NewServer(websocket.NewHandler(func(conn net.Conn) {
+		serverConns <- conn
+		// The connection is closed when the handler returns.
+		<-done
+	}))
+	b.Cleanup(func() {
+		close(done)
+		srv.Close()
+	})
+	d := websocket.Dialer{
+		Origin:        srv.URL,
+		InsecureNoTLS: true,
+	}
+	clientConn := make(chan net.Conn, 1)
+	go func() {
+		c, err := d.DialDirect(context.Background(), strings.Replace(srv.URL, "http", "ws", 1))
+		if err != nil {
+			b.Errorf("error dialing websocket: %v", err)
+		}
+		clientConn <- c
+	}()
+	serverConn := <-serverConns
+	return negotiate(b, <-clientConn, serverConn, websocket.Negotiator(benchConfig))
+}
+
+// BenchmarkNegotiate measures how long it takes to negotiate a session that
+// only binds a resource over each transport.
+func BenchmarkNegotiate(b *testing.B) {
+	for _, tc := range benchTransports {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				client, server := tc.dial(b)
+				b.StopTimer()
+				closeSessions(client, server)
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// BenchmarkSendMessage measures the throughput of sending messages from a
+// client to a server over each transport.
+func BenchmarkSendMessage(b *testing.B) {
+	newMsg := func() xml.TokenReader {
+		return stanza.Message{
+			To:   jid.MustParse("romeo@example.net"),
+			Type: stanza.ChatMessage,
+		}.Wrap(xmlstream.Wrap(
+			xmlstream.Token(xml.CharData("Wherefore art thou?")),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		))
+	}
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	_, err := xmlstream.Copy(e, newMsg())
+	if err != nil {
+		b.Fatalf("error encoding message: %v", err)
+	}
+	err = e.Flush()
+	if err != nil {
+		b.Fatalf("error flushing message: %v", err)
+	}
+	size := int64(buf.Len())
+
+	for _, tc := range benchTransports {
+		b.Run(tc.name, func(b *testing.B) {
+			client, server := tc.dial(b)
+			defer closeSessions(client, server)
+
+			var received int64
+			all := make(chan struct{})
+			want := int64(b.N)
+			go func() {
+				/* #nosec */
+				server.Serve(xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+					if atomic.AddInt64(&received, 1) == want {
+						close(all)
+					}
+					return nil
+				}))
+			}()
+
+			ctx := context.Background()
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				err := client.Send(ctx, newMsg())
+				if err != nil {
+					b.Fatalf("error sending message: %v", err)
+				}
+			}
+			select {
+			case <-all:
+			case <-time.After(10 * time.Second):
+				b.Fatalf("timed out waiting for messages: got %d of %d", atomic.LoadInt64(&received), want)
+			}
+		})
+	}
+}
+
+func closeSessions(sessions ...*xmpp.Session) {
+	for _, s := range sessions {
+		/* #nosec */
+		s.Conn().Close()
+	}
+}
+
+// BenchmarkServeFrom measures how long it takes to handle messages from a
+// small number of contacts, where the "from" attribute must be compared with
+// the sessions own address.
+func BenchmarkServeFrom(b *testing.B) {
+	var in strings.Builder
+	for i := 0; i < 100; i++ {
+		in.WriteString(`<message from="romeo@example.net/orchard" type="chat"><body>Wherefore art thou?</body></message>`)
+	}
+	msgs := in.String()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s := xmpptest.NewClientSession(0, struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: strings.NewReader(msgs),
+			Writer: io.Discard,
+		})
+		err := s.Serve(nil)
+		if err != nil {
+			b.Fatalf("error serving: %v", err)
+		}
+	}
+}
@@ -64,35 +64,71 @@ func (h Handler) HandleIQ(iq stanza.IQ, r xmlstream.TokenReadEncoder, start *xml
 		return err
 	}
 
+	// Decode all items before calling any of the handlers so that an invalid
+	// request is rejected without partially applying it.
+	var (
+		found bool
+		items []Item
+		jids  []jid.JID
+	)
 	iter := xmlstream.NewIter(r)
-	var found bool
 	for iter.Next() {
+		itemStart, inner := iter.Current()
+		if itemStart == nil {
+			continue
+		}
 		found = true
-		itemStart, r := iter.Current()
-		jstr := itemStart.Attr[0].Value
-		j := jid.MustParse(jstr)
 		switch start.Name.Local {
 		case "block":
 			item := Item{}
-			d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*itemStart), r))
-			if err := d.Decode(&item); err != nil {
-				return err
-			}
-			if h.Block != nil {
-				h.Block(item)
+			d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*itemStart), inner))
+			if err := d.Decode(&item); err != nil || item.JID.Equal(jid.JID{}) {
+				return badRequest(iq, r)
 			}
+			items = append(items, item)
 		case "unblock":
-			if h.Unblock != nil {
-				h.Unblock(j)
+			var j jid.JID
+			for _, attr := range itemStart.Attr {
+				if attr.Name.Local == "jid" {
+					var err error
+					j, err = jid.Parse(attr.Value)
+					if err != nil {
+						return badRequest(iq, r)
+					}
+					break
+				}
 			}
+			if j.Equal(jid.JID{}) {
+				return badRequest(iq, r)
+			}
+			jids = append(jids, j)
 		}
 	}
 	err := iter.Err()
 	if err != nil {
 		return err
 	}
+	if h.Block != nil {
+		for _, item := range items {
+			h.Block(item)
+		}
+	}
+	if h.Unblock != nil {
+		for _, j := range jids {
+			h.Unblock(j)
+		}
+	}
 	if !found && start.Name.Local == "unblock" && h.UnblockAll != nil {
 		h.UnblockAll()
 	}
-	return nil
+	_, err = xmlstream.Copy(r, iq.Result(nil))
+	return err
+}
+
+func badRequest(iq stanza.IQ, w xmlstream.TokenWriter) error {
+	_, err := xmlstream.Copy(w, iq.Error(stanza.Error{
+		Type:      stanza.Modify,
+		Condition: stanza.BadRequest,
+	}))
+	return err
 }
@@ -0,0 +1,136 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package blocklist
+
+import (
+	"context"
+	"encoding/xml"
+	"sort"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// HandlePush returns an option that registers a Store on the mux to receive
+// blocklist pushes from the server.
+// Unlike Handle it does not respond to requests for the blocklist, making it
+// suitable for use by clients.
+func HandlePush(s *Store) mux.Option {
+	return func(m *mux.ServeMux) {
+		mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "block"}, s)(m)
+		mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "unblock"}, s)(m)
+	}
+}
+
+// Store is an in memory copy of the blocklist that can be kept in sync with the
+// server when another client changes the blocklist.
+// The zero value is ready to use.
+//
+// To keep the store up to date, register it with a multiplexer using
+// HandlePush and then populate it with Fetch.
+type Store struct {
+	// Changed, if set, is called after the store is modified by a push from
+	// the server.
+	Changed func()
+
+	mu   sync.RWMutex
+	jids map[string]jid.JID
+}
+
+// Fetch replaces the contents of the store with the blocklist fetched from
+// the server.
+func (s *Store) Fetch(ctx context.Context, session *xmpp.Session) error {
+	iter := Fetch(ctx, session)
+	/* #nosec */
+	defer iter.Close()
+	jids := make(map[string]jid.JID)
+	for iter.Next() {
+		j := iter.JID()
+		jids[j.String()] = j
+	}
+	err := iter.Err()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jids = jids
+	return nil
+}
+
+// Blocked reports whether j matches any JID on the blocklist.
+// For more information see Match.
+func (s *Store) Blocked(j jid.JID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, blocked := range s.jids {
+		if Match(j, blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+// JIDs returns the JIDs on the blocklist sorted by their string
+// representation.
+func (s *Store) JIDs() []jid.JID {
+	s.mu.RLock()
+	jids := make([]jid.JID, 0, len(s.jids))
+	for _, j := range s.jids {
+		jids = append(jids, j)
+	}
+	s.mu.RUnlock()
+	sort.Slice(jids, func(i, j int) bool {
+		return jids[i].String() < jids[j].String()
+	})
+	return jids
+}
+
+// HandleIQ satisfies mux.IQHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+//
+// Pushes that were not sent by the users own account or server are rejected.
+func (s *Store) HandleIQ(iq stanza.IQ, r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	if !iq.From.Equal(jid.JID{}) && !iq.From.Equal(iq.To.Bare()) && !iq.From.Equal(iq.To.Domain()) {
+		_, err := xmlstream.Copy(r, iq.Error(stanza.Error{
+			Type:      stanza.Cancel,
+			Condition: stanza.Forbidden,
+		}))
+		return err
+	}
+	var changed bool
+	err := Handler{
+		Block: func(item Item) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.jids == nil {
+				s.jids = make(map[string]jid.JID)
+			}
+			s.jids[item.JID.String()] = item.JID
+			changed = true
+		},
+		Unblock: func(j jid.JID) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.jids, j.String())
+			changed = true
+		},
+		UnblockAll: func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.jids = nil
+			changed = true
+		},
+	}.HandleIQ(iq, r, start)
+	if changed && s.Changed != nil {
+		s.Changed()
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package blocklist_test
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/blocklist"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var _ mux.IQHandler = (*blocklist.Store)(nil)
+
+func push(local string, from jid.JID, j ...jid.JID) (stanza.IQ, xml.TokenReader) {
+	var items []xml.TokenReader
+	for _, jj := range j {
+		items = append(items, xmlstream.Wrap(nil, xml.StartElement{
+			Name: xml.Name{Local: "item"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "jid"}, Value: jj.String()}},
+		}))
+	}
+	iq := stanza.IQ{
+		XMLName: xml.Name{Space: stanza.NSClient, Local: "iq"},
+		Type:    stanza.SetIQ,
+		To:      jid.MustParse("test@example.net/res"),
+		From:    from,
+	}
+	return iq, xmlstream.Wrap(xmlstream.MultiReader(items...), xml.StartElement{
+		Name: xml.Name{Space: blocklist.NS, Local: local},
+	})
+}
+
+func TestStore(t *testing.T) {
+	juliet := jid.MustParse("juliet@example.com")
+	benvolio := jid.MustParse("benvolio@example.org")
+	tybalt := jid.MustParse("tybalt@example.org/sword")
+
+	changed := make(chan struct{}, 10)
+	store := &blocklist.Store{
+		Changed: func() {
+			changed <- struct{}{}
+		},
+	}
+	cs := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSClient, blocklist.HandlePush(store))),
+		xmpptest.ServerHandler(mux.New(stanza.NSClient, blocklist.Handle(blocklist.Handler{
+			List: func(c chan<- jid.JID) {
+				c <- juliet
+				c <- benvolio
+			},
+		}))),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := store.Fetch(ctx, cs.Client)
+	if err != nil {
+		t.Fatalf("error fetching blocklist: %v", err)
+	}
+	if jids := store.JIDs(); !reflect.DeepEqual(jids, []jid.JID{benvolio, juliet}) {
+		t.Errorf("wrong blocklist after fetch: %v", jids)
+	}
+	if !store.Blocked(jid.MustParse("juliet@example.com/balcony")) {
+		t.Errorf("expected juliet's resource to be blocked")
+	}
+
+	sendPush := func(local string, from jid.JID, j ...jid.JID) stanza.IQType {
+		t.Helper()
+		iq, payload := push(local, from, j...)
+		resp, err := cs.Server.SendIQElement(ctx, payload, iq)
+		if err != nil {
+			t.Fatalf("error sending push: %v", err)
+		}
+		/* #nosec */
+		defer resp.Close()
+		tok, err := resp.Token()
+		if err != nil {
+			t.Fatalf("error reading response: %v", err)
+		}
+		start := tok.(xml.StartElement)
+		respIQ, err := stanza.NewIQ(start)
+		if err != nil {
+			t.Fatalf("error decoding response: %v", err)
+		}
+		return respIQ.Type
+	}
+
+	waitChanged := func() {
+		t.Helper()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for store to change")
+		}
+	}
+
+	if typ := sendPush("block", jid.JID{}, tybalt); typ != stanza.ResultIQ {
+		t.Errorf("wrong response to block push: want=%s, got=%s", stanza.ResultIQ, typ)
+	}
+	waitChanged()
+	if !store.Blocked(tybalt) {
+		t.Errorf("expected tybalt to be blocked after push")
+	}
+
+	if typ := sendPush("unblock", jid.JID{}, juliet); typ != stanza.ResultIQ {
+		t.Errorf("wrong response to unblock push: want=%s, got=%s", stanza.ResultIQ, typ)
+	}
+	waitChanged()
+	if jids := store.JIDs(); !reflect.DeepEqual(jids, []jid.JID{benvolio, tybalt}) {
+		t.Errorf("wrong blocklist after unblocking: %v", jids)
+	}
+
+	// Pushes from anyone other than our server are rejected.
+	if typ := sendPush("unblock", jid.MustParse("mercutio@example.net/mab")); typ != stanza.ErrorIQ {
+		t.Errorf("wrong response to spoofed push: want=%s, got=%s", stanza.ErrorIQ, typ)
+	}
+	if jids := store.JIDs(); len(jids) != 2 {
+		t.Errorf("spoofed push modified the blocklist: %v", jids)
+	}
+
+	// Requests with invalid items are rejected without modifying the blocklist.
+	if typ := sendPush("unblock", jid.JID{}, benvolio, jid.JID{}); typ != stanza.ErrorIQ {
+		t.Errorf("wrong response to push with invalid item: want=%s, got=%s", stanza.ErrorIQ, typ)
+	}
+	if jids := store.JIDs(); !reflect.DeepEqual(jids, []jid.JID{benvolio, tybalt}) {
+		t.Errorf("invalid push modified the blocklist: %v", jids)
+	}
+
+	if typ := sendPush("unblock", jid.JID{}); typ != stanza.ResultIQ {
+		t.Errorf("wrong response to unblock all push: want=%s, got=%s", stanza.ResultIQ, typ)
+	}
+	waitChanged()
+	if jids := store.JIDs(); len(jids) != 0 {
+		t.Errorf("expected empty blocklist after unblocking all, got %v", jids)
+	}
+}
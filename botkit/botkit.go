@@ -0,0 +1,352 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package botkit handles the boilerplate needed to run simple bots.
+//
+// Most bots need to read their address and password from the environment or
+// flags, configure logging, connect to a server and reconnect if the
+// connection is lost, and shut down cleanly when they receive an interrupt.
+// Run takes care of all of this so that the bot itself only has to provide an
+// xmpp.Handler (often a mux.ServeMux) to respond to incoming stanzas.
+//
+// A simple bot might look like this:
+//
+//	cfg := botkit.Config{}
+//	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+//	cfg.RegisterFlags(flags)
+//	flags.Parse(os.Args[1:])
+//
+//	err := botkit.Run(context.Background(), cfg, mux.New(
+//		stanza.NSClient,
+//		mux.MessageFunc(stanza.ChatMessage, xml.Name{Local: "body"}, handleMessage),
+//	))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+package botkit // import "mellium.im/xmpp/botkit"
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"mellium.im/sasl"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/dial"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/stream"
+)
+
+// Environment variables used by RegisterFlags.
+/* #nosec */
+const (
+	EnvAddr = "XMPP_ADDR"
+	EnvPass = "XMPP_PASS"
+)
+
+// Defaults used when the corresponding fields of Config are not set.
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 5 * time.Minute
+)
+
+// maxRedirects is the number of see-other-host stream errors that will be
+// followed when establishing a session before giving up.
+const maxRedirects = 2
+
+// Config configures a bot.
+type Config struct {
+	// Addr is the address of the bot and Password is used to authenticate.
+	// Identity is an optional identity to act as (the SASL authorization
+	// identity), if different from Addr.
+	Addr     string
+	Password string
+	Identity string
+
+	// Verbose turns on debug logging and LogXML turns on debug logging as well
+	// as logging of all sent and received XML.
+	Verbose bool
+	LogXML  bool
+
+	// Logger is used for errors and important events and Debug is used for
+	// verbose logging.
+	// If Logger is nil, messages are logged to stderr.
+	// If Debug is nil, verbose logging is written to stderr if Verbose or LogXML
+	// are set and discarded otherwise.
+	Logger *log.Logger
+	Debug  *log.Logger
+
+	// Reconnect causes a new session to be established whenever the session
+	// ends with an error.
+	// Attempts are delayed starting at MinBackoff and doubling after each
+	// consecutive failure up to MaxBackoff.
+	Reconnect  bool
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Signals are the signals that cause the bot to shut down gracefully.
+	// If Signals is nil, os.Interrupt is used.
+	Signals []os.Signal
+
+	// Online is called each time a session is established before stanzas are
+	// handled.
+	// If Online is nil, initial presence is sent so that the server will start
+	// routing messages to the bot.
+	Online func(context.Context, *xmpp.Session) error
+
+	// Session establishes a new session.
+	// If Session is nil, a client session is dialed for Addr using STARTTLS and
+	// SASL with Password.
+	Session func(context.Context, Config) (*xmpp.Session, error)
+}
+
+// RegisterFlags registers the "addr", "v", and "vv" flags on f to configure
+// the address and logging.
+// The default address and the password (which is never read from a flag) are
+// taken from $XMPP_ADDR and $XMPP_PASS if they are not already set.
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	if c.Addr == "" {
+		c.Addr = os.Getenv(EnvAddr)
+	}
+	if c.Password == "" {
+		c.Password = os.Getenv(EnvPass)
+	}
+	f.StringVar(&c.Addr, "addr", c.Addr, "The XMPP address of the bot, overrides $"+EnvAddr+".")
+	f.BoolVar(&c.Verbose, "v", c.Verbose, "Show verbose logging.")
+	f.BoolVar(&c.LogXML, "vv", c.LogXML, "Show verbose logging and sent and received XML.")
+}
+
+type logWriter struct {
+	logger *log.Logger
+}
+
+func (lw logWriter) Write(p []byte) (int, error) {
+	lw.logger.Printf("%s", p)
+	return len(p), nil
+}
+
+// Run establishes a session and handles incoming stanzas with h until ctx is
+// canceled or one of the configured signals is received.
+// If the session ends with an error and cfg.Reconnect is set a new session is
+// established, otherwise the error is returned.
+// A graceful shutdown returns nil.
+func Run(ctx context.Context, cfg Config, h xmpp.Handler) error {
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	if cfg.Debug == nil {
+		cfg.Debug = log.New(io.Discard, "DEBUG ", log.LstdFlags)
+		if cfg.Verbose || cfg.LogXML {
+			cfg.Debug.SetOutput(os.Stderr)
+		}
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = DefaultMinBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.Session == nil {
+		cfg.Session = dialSession
+	}
+	if cfg.Online == nil {
+		cfg.Online = func(ctx context.Context, s *xmpp.Session) error {
+			return s.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence}.Wrap(nil))
+		}
+	}
+	signals := cfg.Signals
+	if signals == nil {
+		signals = []os.Signal{os.Interrupt}
+	}
+	ctx, stop := signal.NotifyContext(ctx, signals...)
+	defer stop()
+
+	backoff := cfg.MinBackoff
+	for {
+		established, err := runSession(ctx, cfg, h)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil || !cfg.Reconnect {
+			return err
+		}
+		if established {
+			backoff = cfg.MinBackoff
+		}
+		cfg.Logger.Printf("Session ended: %v, reconnecting in %v…", err, backoff)
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+		backoff *= 2
+		if backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+}
+
+// Do is like Run except that instead of running until it is shut down it calls
+// f once the session is established and ends the session when f returns.
+// Stanzas are handled using h while f is running so that it can wait for
+// responses.
+// It is meant for commands that connect, do something, and exit.
+//
+// If cfg.Online is set it is called before f, but unlike Run initial presence
+// is not sent by default.
+// cfg.Reconnect is ignored.
+// If f returns an error it is returned, otherwise any error from Run is
+// returned.
+func Do(ctx context.Context, cfg Config, h xmpp.Handler, f func(context.Context, *xmpp.Session) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	online := cfg.Online
+	fErr := make(chan error, 1)
+	var wg sync.WaitGroup
+	cfg.Reconnect = false
+	cfg.Online = func(ctx context.Context, s *xmpp.Session) error {
+		if online != nil {
+			err := online(ctx, s)
+			if err != nil {
+				return err
+			}
+		}
+		// Stanzas are only handled once Online returns, so f is run in a
+		// goroutine where it can wait for responses.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fErr <- f(ctx, s)
+			cancel()
+		}()
+		return nil
+	}
+	err := Run(ctx, cfg, h)
+	cancel()
+	wg.Wait()
+	select {
+	case e := <-fErr:
+		if e != nil {
+			return e
+		}
+	default:
+	}
+	return err
+}
+
+// runSession establishes a single session and serves it until it ends.
+// It reports whether the session was established successfully so that Run
+// can reset its backoff.
+func runSession(ctx context.Context, cfg Config, h xmpp.Handler) (bool, error) {
+	s, err := cfg.Session(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+	cfg.Debug.Printf("Session established as %s", s.LocalAddr())
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cfg.Debug.Println("Closing session…")
+			if err := s.Close(); err != nil {
+				cfg.Logger.Printf("Error closing session: %v", err)
+			}
+		case <-done:
+		}
+	}()
+	defer func() {
+		if err := s.Conn().Close(); err != nil {
+			cfg.Debug.Printf("Error closing connection: %v", err)
+		}
+	}()
+
+	err = cfg.Online(ctx, s)
+	if err != nil {
+		return true, fmt.Errorf("botkit: error going online: %w", err)
+	}
+	return true, s.Serve(h)
+}
+
+func dialSession(ctx context.Context, cfg Config) (*xmpp.Session, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("botkit: no address specified, use the -addr flag or set $%s", EnvAddr)
+	}
+	j, err := jid.Parse(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("botkit: error parsing address %q: %w", cfg.Addr, err)
+	}
+	var xmlIn, xmlOut io.Writer
+	if cfg.LogXML {
+		xmlIn = logWriter{log.New(cfg.Debug.Writer(), "IN ", log.LstdFlags)}
+		xmlOut = logWriter{log.New(cfg.Debug.Writer(), "OUT ", log.LstdFlags)}
+	}
+
+	d := dial.Dialer{}
+	server := j.Domainpart()
+	var port string
+	for redirects := 0; ; redirects++ {
+		var conn net.Conn
+		if port == "" {
+			conn, err = d.DialServer(ctx, "tcp", j, server)
+		} else {
+			// If the redirect includes a port, dial it directly instead of looking
+			// up SRV records for the host.
+			conn, err = d.DialContext(ctx, "tcp", net.JoinHostPort(server, port))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("botkit: error dialing session: %w", err)
+		}
+		s, err := xmpp.NewSession(ctx, j.Domain(), j, conn, 0, xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+			return xmpp.StreamConfig{
+				Lang: "en",
+				Features: []xmpp.StreamFeature{
+					xmpp.BindResource(),
+					xmpp.StartTLS(&tls.Config{
+						ServerName: j.Domain().String(),
+						MinVersion: tls.VersionTLS12,
+					}),
+					xmpp.SASL(cfg.Identity, cfg.Password, sasl.ScramSha256Plus, sasl.ScramSha1Plus, sasl.ScramSha256, sasl.ScramSha1, sasl.Plain),
+				},
+				TeeIn:  xmlIn,
+				TeeOut: xmlOut,
+			}
+		}))
+		if err != nil {
+			/* #nosec */
+			conn.Close()
+			var streamErr stream.Error
+			if errors.As(err, &streamErr) {
+				if host, p, ok := streamErr.SeeOtherHost(); ok {
+					if redirects >= maxRedirects {
+						return nil, fmt.Errorf("botkit: too many see-other-host redirects: %w", err)
+					}
+					server, port = host, p
+					if port == "" {
+						cfg.Logger.Printf("see-other-host: %s", server)
+					} else {
+						cfg.Logger.Printf("see-other-host: %s", net.JoinHostPort(server, port))
+					}
+					continue
+				}
+			}
+			return nil, fmt.Errorf("botkit: error establishing a session: %w", err)
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package botkit_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/botkit"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/stanza"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+func TestRunShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var online bool
+	// The client side is served by Run, so only the server side is served here.
+	clientConn, serverConn := net.Pipe()
+	client := xmpptest.NewClientSession(0, clientConn)
+	server := xmpptest.NewServerSession(xmpp.Received, serverConn)
+	/* #nosec */
+	go server.Serve(xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		if start.Name.Local != "presence" {
+			return nil
+		}
+		// Reply to initial presence with a message so that we know the bot is
+		// online and handling stanzas.
+		return t.Encode(stanza.Message{Type: stanza.ChatMessage})
+	}))
+	defer server.Close()
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	var received bool
+	err := botkit.Run(runCtx, botkit.Config{
+		Logger: discard,
+		Debug:  discard,
+		Session: func(context.Context, botkit.Config) (*xmpp.Session, error) {
+			return client, nil
+		},
+		Online: func(ctx context.Context, s *xmpp.Session) error {
+			online = true
+			return s.Send(ctx, stanza.Presence{}.Wrap(nil))
+		},
+	}, xmpp.HandlerFunc(func(_ xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		if start.Name.Local == "message" {
+			received = true
+			stop()
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error on shutdown: %v", err)
+	}
+	if !online {
+		t.Errorf("online callback was not called")
+	}
+	if !received {
+		t.Errorf("handler did not receive the message")
+	}
+	if ctx.Err() != nil {
+		t.Errorf("bot was not shut down before the timeout")
+	}
+}
+
+func TestRunReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errDial := errors.New("dial failed")
+	var attempts int
+	err := botkit.Run(ctx, botkit.Config{
+		Logger:     discard,
+		Debug:      discard,
+		Reconnect:  true,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 2 * time.Millisecond,
+		Session: func(context.Context, botkit.Config) (*xmpp.Session, error) {
+			attempts++
+			if attempts == 3 {
+				cancel()
+			}
+			return nil, errDial
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on shutdown: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("wrong number of attempts: want=3, got=%d", attempts)
+	}
+}
+
+func TestRunNoReconnect(t *testing.T) {
+	errDial := errors.New("dial failed")
+	var attempts int
+	err := botkit.Run(context.Background(), botkit.Config{
+		Logger: discard,
+		Debug:  discard,
+		Session: func(context.Context, botkit.Config) (*xmpp.Session, error) {
+			attempts++
+			return nil, errDial
+		},
+	}, nil)
+	if !errors.Is(err, errDial) {
+		t.Errorf("wrong error: want=%v, got=%v", errDial, err)
+	}
+	if attempts != 1 {
+		t.Errorf("wrong number of attempts: want=1, got=%d", attempts)
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	t.Setenv(botkit.EnvAddr, "bot@example.net")
+	t.Setenv(botkit.EnvPass, "secret")
+
+	cfg := botkit.Config{}
+	flags := flag.NewFlagSet("bot", flag.ContinueOnError)
+	cfg.RegisterFlags(flags)
+	if cfg.Addr != "bot@example.net" || cfg.Password != "secret" {
+		t.Fatalf("environment not used: addr=%q, pass=%q", cfg.Addr, cfg.Password)
+	}
+	err := flags.Parse([]string{"-addr", "other@example.net", "-vv"})
+	if err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+	if cfg.Addr != "other@example.net" {
+		t.Errorf("wrong addr: want=other@example.net, got=%s", cfg.Addr)
+	}
+	if cfg.Verbose || !cfg.LogXML {
+		t.Errorf("wrong logging flags: verbose=%t, xml=%t", cfg.Verbose, cfg.LogXML)
+	}
+}
+
+func TestDo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clientConn, serverConn := net.Pipe()
+	client := xmpptest.NewClientSession(0, clientConn)
+	server := xmpptest.NewServerSession(xmpp.Received, serverConn)
+	/* #nosec */
+	go server.Serve(xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		if start.Name.Local != "presence" {
+			return nil
+		}
+		return t.Encode(stanza.Message{Type: stanza.ChatMessage})
+	}))
+	defer server.Close()
+
+	errDone := errors.New("done")
+	received := make(chan struct{})
+	err := botkit.Do(ctx, botkit.Config{
+		Logger: discard,
+		Debug:  discard,
+		Session: func(context.Context, botkit.Config) (*xmpp.Session, error) {
+			return client, nil
+		},
+	}, xmpp.HandlerFunc(func(_ xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		if start.Name.Local == "message" {
+			close(received)
+		}
+		return nil
+	}), func(ctx context.Context, s *xmpp.Session) error {
+		err := s.Send(ctx, stanza.Presence{}.Wrap(nil))
+		if err != nil {
+			return err
+		}
+		// The response can only be received if stanzas are handled while f runs.
+		select {
+		case <-received:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return errDone
+	})
+	if !errors.Is(err, errDone) {
+		t.Fatalf("wrong error: want=%v, got=%v", errDone, err)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("session was not ended before the timeout")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package bridge connects XMPP sessions to message queues.
+//
+// It is meant for integration platforms that treat XMPP as one of several
+// message buses, for example by running a component that publishes every
+// stanza it receives to a NATS subject or Kafka topic and sends every message
+// it consumes from the queue as a stanza.
+// This package does not depend on any particular message queue.
+// Instead the queue is abstracted by the small Publisher interface and
+// consumed messages are passed to Bridge.Deliver, so adapting a queue client
+// normally only takes a few lines:
+//
+//	b := &bridge.Bridge{
+//		Publisher: bridge.PublisherFunc(func(_ context.Context, subject string, data []byte) error {
+//			return nc.Publish(subject, data)
+//		}),
+//	}
+//	nc.Subscribe("xmpp.out", func(m *nats.Msg) {
+//		err := b.Deliver(ctx, session, m.Data)
+//		…
+//	})
+//	session.Serve(b)
+//
+// The format of messages on the queue is determined by a Codec.
+// Stanzas can either be passed through as XML or wrapped in a JSON container
+// that exposes the stanza attributes to consumers that do not speak XML.
+package bridge // import "mellium.im/xmpp/bridge"
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+)
+
+// Publisher publishes messages to a queue.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// PublisherFunc is an adapter that allows the use of ordinary functions as
+// publishers.
+type PublisherFunc func(ctx context.Context, subject string, data []byte) error
+
+// Publish calls f(ctx, subject, data).
+func (f PublisherFunc) Publish(ctx context.Context, subject string, data []byte) error {
+	return f(ctx, subject, data)
+}
+
+// DefaultSubject returns the subject used for stanzas when a Bridge has no
+// Subject function.
+// It is "xmpp." followed by the stanza name, for example "xmpp.message".
+func DefaultSubject(start xml.StartElement) string {
+	return "xmpp." + start.Name.Local
+}
+
+// Bridge publishes stanzas received on a session to a queue and sends messages
+// consumed from a queue over a session.
+type Bridge struct {
+	// Publisher is used to publish incoming stanzas.
+	Publisher Publisher
+
+	// Codec converts between stanzas and queue messages.
+	// If Codec is nil, XML is used.
+	Codec Codec
+
+	// Subject returns the subject or topic that a stanza should be published
+	// to.
+	// If Subject is nil, DefaultSubject is used.
+	Subject func(start xml.StartElement) string
+
+	// Context, if set, returns the context used when publishing stanzas.
+	// If Context is nil, context.Background is used.
+	Context func() context.Context
+}
+
+func (b *Bridge) codec() Codec {
+	if b.Codec == nil {
+		return XML
+	}
+	return b.Codec
+}
+
+// HandleXMPP satisfies xmpp.Handler by publishing every top level element
+// received on the session.
+func (b *Bridge) HandleXMPP(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	data, err := b.codec().Encode(xmlstream.MultiReader(xmlstream.Token(*start), t))
+	if err != nil {
+		return err
+	}
+	subject := DefaultSubject
+	if b.Subject != nil {
+		subject = b.Subject
+	}
+	ctx := context.Background()
+	if b.Context != nil {
+		ctx = b.Context()
+	}
+	return b.Publisher.Publish(ctx, subject(*start), data)
+}
+
+// Deliver decodes a message consumed from a queue and sends the resulting
+// stanza over the session.
+func (b *Bridge) Deliver(ctx context.Context, s *xmpp.Session, data []byte) error {
+	r, err := b.codec().Decode(data)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, r)
+}
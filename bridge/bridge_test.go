@@ -0,0 +1,203 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package bridge_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/bridge"
+	"mellium.im/xmpp/internal/xmpptest"
+)
+
+var (
+	_ xmpp.Handler     = (*bridge.Bridge)(nil)
+	_ bridge.Publisher = bridge.PublisherFunc(nil)
+)
+
+func encode(t *testing.T, r xml.TokenReader) string {
+	t.Helper()
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	_, err := xmlstream.Copy(e, r)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	err = e.Flush()
+	if err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	return buf.String()
+}
+
+var codecTestCases = [...]struct {
+	codec bridge.Codec
+	in    string
+	data  string
+	out   string
+}{
+	0: {
+		codec: bridge.XML,
+		in:    `<message xmlns="jabber:component:accept" to="a@example.net" id="123"><body>Hi</body></message>`,
+		data:  `<message xmlns="jabber:component:accept" to="a@example.net" id="123"><body xmlns="jabber:component:accept">Hi</body></message>`,
+		out:   `<message xmlns="jabber:component:accept" to="a@example.net" id="123"><body xmlns="jabber:component:accept">Hi</body></message>`,
+	},
+	1: {
+		codec: bridge.JSON,
+		in:    `<message xmlns="jabber:component:accept" to="a@example.net" from="b@example.com/r" id="123" type="chat" xml:lang="en"><body>Hi</body><x xmlns="urn:example"/></message>`,
+		data:  `{"stanza":"message","id":"123","type":"chat","to":"a@example.net","from":"b@example.com/r","lang":"en","payload":"<body xmlns=\"jabber:component:accept\">Hi</body><x xmlns=\"urn:example\"></x>"}`,
+		out:   `<message id="123" type="chat" to="a@example.net" from="b@example.com/r" xml:lang="en"><body xmlns="jabber:component:accept">Hi</body><x xmlns="urn:example"></x></message>`,
+	},
+	2: {
+		codec: bridge.JSON,
+		in:    `<iq xmlns="jabber:component:accept" type="get"/>`,
+		data:  `{"stanza":"iq","type":"get"}`,
+		out:   `<iq type="get"></iq>`,
+	},
+}
+
+func TestCodec(t *testing.T) {
+	for i, tc := range codecTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r := xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(xml.NewDecoder(strings.NewReader(tc.in)))
+			data, err := tc.codec.Encode(r)
+			if err != nil {
+				t.Fatalf("error encoding: %v", err)
+			}
+			if string(data) != tc.data {
+				t.Fatalf("wrong data: want=%s, got=%s", tc.data, data)
+			}
+			dec, err := tc.codec.Decode(data)
+			if err != nil {
+				t.Fatalf("error decoding: %v", err)
+			}
+			dec = xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(dec)
+			if out := encode(t, dec); out != tc.out {
+				t.Errorf("wrong output: want=%s, got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for i, tc := range []struct {
+		codec bridge.Codec
+		data  string
+	}{
+		0: {codec: bridge.XML, data: ``},
+		1: {codec: bridge.XML, data: `<message><body></message>`},
+		2: {codec: bridge.JSON, data: `{`},
+		3: {codec: bridge.JSON, data: `{"stanza":"foo"}`},
+		4: {codec: bridge.JSON, data: `{"stanza":"message","to":"@"}`},
+		5: {codec: bridge.JSON, data: `{"stanza":"message","payload":"<a>"}`},
+		6: {codec: bridge.XML, data: `<stream:error xmlns:stream="http://etherx.jabber.org/streams"/>`},
+		7: {codec: bridge.XML, data: `<foo xmlns="jabber:client"/>`},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := tc.codec.Decode([]byte(tc.data))
+			if err == nil {
+				t.Errorf("expected error decoding %q", tc.data)
+			}
+		})
+	}
+}
+
+type publication struct {
+	subject string
+	data    []byte
+}
+
+func TestPublish(t *testing.T) {
+	published := make(chan publication, 1)
+	b := &bridge.Bridge{
+		Codec: bridge.JSON,
+		Publisher: bridge.PublisherFunc(func(_ context.Context, subject string, data []byte) error {
+			published <- publication{subject: subject, data: data}
+			return nil
+		}),
+	}
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(b))
+	defer cs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := cs.Client.Send(ctx, xml.NewDecoder(strings.NewReader(`<message xmlns="jabber:client" id="123" to="a@example.com"><body>Hi</body></message>`)))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+
+	var p publication
+	select {
+	case p = <-published:
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for publication")
+	}
+	if p.subject != "xmpp.message" {
+		t.Errorf("wrong subject: want=xmpp.message, got=%s", p.subject)
+	}
+	var env bridge.Envelope
+	err = json.Unmarshal(p.data, &env)
+	if err != nil {
+		t.Fatalf("error unmarshaling envelope: %v", err)
+	}
+	if env.Stanza != "message" || env.ID != "123" || env.To != "a@example.com" {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+	if !strings.Contains(env.Payload, ">Hi</body>") {
+		t.Errorf("payload missing body: %s", env.Payload)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	received := make(chan string, 1)
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		var buf bytes.Buffer
+		e := xml.NewEncoder(&buf)
+		r := xmlstream.MultiReader(xmlstream.Token(*start), xmlstream.Inner(t), xmlstream.Token(start.End()))
+		// Prevent duplicate xmlns attributes. See https://mellium.im/issue/75
+		r = xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+			return attr.Name.Local == "xmlns"
+		})(r)
+		_, err := xmlstream.Copy(e, r)
+		if err != nil {
+			return err
+		}
+		err = e.Flush()
+		received <- buf.String()
+		return err
+	}))
+	defer cs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b := &bridge.Bridge{Codec: bridge.JSON}
+	err := b.Deliver(ctx, cs.Client, []byte(`{"stanza":"message","id":"123","to":"a@example.com","payload":"<body>Hi</body>"}`))
+	if err != nil {
+		t.Fatalf("error delivering message: %v", err)
+	}
+
+	var got string
+	select {
+	case got = <-received:
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for stanza")
+	}
+	const want = `<message xmlns="jabber:client" id="123" to="a@example.com"><body xmlns="jabber:client">Hi</body></message>`
+	if got != want {
+		t.Errorf("wrong stanza: want=%s, got=%s", want, got)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/ns"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Codec converts between stanzas and the data in queue messages.
+type Codec interface {
+	// Encode reads a single element from r and returns its encoded form.
+	Encode(r xml.TokenReader) ([]byte, error)
+
+	// Decode returns a token reader over the element encoded in data.
+	Decode(data []byte) (xml.TokenReader, error)
+}
+
+var errNoElement = errors.New("bridge: no element found in message")
+
+// Codecs that are provided by this package.
+var (
+	// XML passes stanzas through as XML.
+	XML Codec = xmlCodec{}
+
+	// JSON wraps stanzas in a JSON object with the name and common attributes
+	// of the stanza in their own fields and the children of the stanza as a
+	// string of XML.
+	// For more information see the Envelope type.
+	JSON Codec = jsonCodec{}
+)
+
+type xmlCodec struct{}
+
+func (xmlCodec) Encode(r xml.TokenReader) ([]byte, error) {
+	var buf bytes.Buffer
+	err := encode(&buf, r)
+	return buf.Bytes(), err
+}
+
+func (xmlCodec) Decode(data []byte) (xml.TokenReader, error) {
+	start, r, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if !stanza.Is(start.Name, "") {
+		return nil, fmt.Errorf("bridge: unknown stanza type %q", start.Name.Local)
+	}
+	return r, nil
+}
+
+// encode writes a single element to buf, removing any xmlns attributes that the
+// encoder would otherwise duplicate.
+func encode(buf *bytes.Buffer, r xml.TokenReader) error {
+	e := xml.NewEncoder(buf)
+	_, err := xmlstream.Copy(e, xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+		return attr.Name.Space == "" && attr.Name.Local == "xmlns"
+	})(r))
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// decode reads the first element in data and makes sure that it is
+// well-formed.
+// It returns the start element and a token reader over the entire element.
+func decode(data []byte) (xml.StartElement, xml.TokenReader, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, nil, errNoElement
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		toks, err := xmlstream.ReadAll(xmlstream.MultiReader(xmlstream.Token(start), xmlstream.Inner(d), xmlstream.Token(start.End())))
+		if err != nil {
+			return xml.StartElement{}, nil, fmt.Errorf("bridge: malformed element: %w", err)
+		}
+		return start, tokens.Reader(toks), nil
+	}
+}
+
+// Envelope is the JSON representation of a stanza used by the JSON codec.
+type Envelope struct {
+	// Stanza is the local name of the element, for example "message".
+	Stanza string `json:"stanza"`
+	ID     string `json:"id,omitempty"`
+	Type   string `json:"type,omitempty"`
+	To     string `json:"to,omitempty"`
+	From   string `json:"from,omitempty"`
+	Lang   string `json:"lang,omitempty"`
+
+	// Payload is the children of the stanza serialized as XML.
+	Payload string `json:"payload,omitempty"`
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(r xml.TokenReader) ([]byte, error) {
+	tok, err := r.Token()
+	if err != nil {
+		return nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, errNoElement
+	}
+	env := Envelope{Stanza: start.Name.Local}
+	for _, attr := range start.Attr {
+		if attr.Name.Space != "" && attr.Name.Space != start.Name.Space {
+			if attr.Name.Local == "lang" && attr.Name.Space == ns.XML {
+				env.Lang = attr.Value
+			}
+			continue
+		}
+		switch attr.Name.Local {
+		case "id":
+			env.ID = attr.Value
+		case "type":
+			env.Type = attr.Value
+		case "to":
+			env.To = attr.Value
+		case "from":
+			env.From = attr.Value
+		}
+	}
+
+	var buf bytes.Buffer
+	inner := xmlstream.Inner(r)
+	for {
+		tok, err := inner.Token()
+		if err != nil {
+			break
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			err = encode(&buf, xmlstream.MultiReader(xmlstream.Token(t), xmlstream.InnerElement(inner)))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	env.Payload = buf.String()
+
+	// Don't escape the payload XML, it is not meant to be embedded in HTML.
+	var out bytes.Buffer
+	e := json.NewEncoder(&out)
+	e.SetEscapeHTML(false)
+	err = e.Encode(env)
+	return bytes.TrimSuffix(out.Bytes(), []byte{'\n'}), err
+}
+
+func (jsonCodec) Decode(data []byte) (xml.TokenReader, error) {
+	var env Envelope
+	err := json.Unmarshal(data, &env)
+	if err != nil {
+		return nil, err
+	}
+	if !stanza.Is(xml.Name{Local: env.Stanza}, "") {
+		return nil, fmt.Errorf("bridge: unknown stanza type %q", env.Stanza)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: env.Stanza}}
+	if env.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: env.ID})
+	}
+	if env.Type != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "type"}, Value: env.Type})
+	}
+	for _, addr := range []struct {
+		name, value string
+	}{{"to", env.To}, {"from", env.From}} {
+		if addr.value == "" {
+			continue
+		}
+		j, err := jid.Parse(addr.value)
+		if err != nil {
+			return nil, err
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: addr.name}, Value: j.String()})
+	}
+	if env.Lang != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Space: ns.XML, Local: "lang"}, Value: env.Lang})
+	}
+
+	// Wrap the payload so that multiple children can be decoded at once.
+	_, payload, err := decode([]byte("<payload>" + env.Payload + "</payload>"))
+	if err != nil {
+		return nil, err
+	}
+	return xmlstream.Wrap(xmlstream.Inner(skipStart(payload)), start), nil
+}
+
+// skipStart pops the first token from r.
+func skipStart(r xml.TokenReader) xml.TokenReader {
+	/* #nosec */
+	r.Token()
+	return r
+}
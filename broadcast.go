@@ -0,0 +1,160 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/jid"
+)
+
+// BroadcastConfig contains options that change how a payload is broadcast.
+// The zero value is the behavior of Broadcast.
+type BroadcastConfig struct {
+	// Concurrency is the number of stanzas that may be in the process of being
+	// sent at the same time.
+	// If Concurrency is less than two, stanzas are sent one at a time in the
+	// order that the recipients were provided.
+	Concurrency int
+}
+
+// RecipientError is an error encountered while sending a broadcast to a single
+// recipient.
+type RecipientError struct {
+	To  jid.JID
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e RecipientError) Error() string {
+	return fmt.Sprintf("xmpp: error broadcasting to %s: %v", e.To, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e RecipientError) Unwrap() error {
+	return e.Err
+}
+
+// BroadcastError is returned by Broadcast if the stanza could not be sent to
+// one or more of the recipients.
+type BroadcastError []RecipientError
+
+// Error satisfies the error interface.
+func (e BroadcastError) Error() string {
+	errs := make([]string, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err.Error())
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Unwrap returns the errors for each recipient.
+func (e BroadcastError) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Broadcast sends a copy of the first element read from payload to each of the
+// recipients.
+// The payload is decoded and buffered once and only the "to" and "id"
+// attributes of the outermost element are changed for each recipient.
+// Every copy is given a new random ID.
+//
+// If sending fails for any recipient, Broadcast continues sending to the
+// others and returns a BroadcastError containing the error for each recipient
+// that failed.
+// If the context is canceled, the remaining recipients are reported as having
+// failed with the context's error.
+//
+// Broadcast is safe for concurrent use by multiple goroutines.
+func (s *Session) Broadcast(ctx context.Context, payload xml.TokenReader, recipients ...jid.JID) error {
+	return s.BroadcastWithConfig(ctx, payload, BroadcastConfig{}, recipients...)
+}
+
+// BroadcastWithConfig is like Broadcast except that it takes a config that can
+// be used to limit the number of concurrent sends.
+// See the documentation on Broadcast for more information.
+func (s *Session) BroadcastWithConfig(ctx context.Context, payload xml.TokenReader, cfg BroadcastConfig, recipients ...jid.JID) error {
+	tok, err := payload.Token()
+	if err != nil {
+		return err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return errNotStart
+	}
+	start = start.Copy()
+	inner, err := xmlstream.ReadAll(xmlstream.Inner(payload))
+	if err != nil {
+		return err
+	}
+	// Remove the attributes that will be rewritten so that they can be appended
+	// to each copy.
+	baseAttr := start.Attr[:0]
+	for _, a := range start.Attr {
+		if a.Name.Local == "to" || a.Name.Local == "id" {
+			continue
+		}
+		baseAttr = append(baseAttr, a)
+	}
+	start.Attr = baseAttr
+
+	concurrency := cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	errs := make([]error, len(recipients))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, to := range recipients {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int, to jid.JID) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			el := start
+			el.Attr = make([]xml.Attr, len(baseAttr), len(baseAttr)+2)
+			copy(el.Attr, baseAttr)
+			el.Attr = append(el.Attr,
+				xml.Attr{Name: xml.Name{Local: "to"}, Value: to.String()},
+				xml.Attr{Name: xml.Name{Local: "id"}, Value: attr.RandomID()},
+			)
+			errs[i] = s.SendElement(ctx, tokens.Reader(inner), el)
+		}(i, to)
+	}
+	wg.Wait()
+
+	var broadcastErr BroadcastError
+	for i, err := range errs {
+		if err != nil {
+			broadcastErr = append(broadcastErr, RecipientError{To: recipients[i], Err: err})
+		}
+	}
+	if broadcastErr != nil {
+		return broadcastErr
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestBroadcast(t *testing.T) {
+	recipients := []jid.JID{
+		jid.MustParse("juliet@example.com"),
+		jid.MustParse("nurse@example.com"),
+		jid.MustParse("tybalt@example.com"),
+	}
+	for _, concurrency := range []int{0, 2} {
+		var mu sync.Mutex
+		var to, ids, bodies []string
+		s := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			msg := struct {
+				stanza.Message
+				Body string `xml:"body"`
+			}{}
+			err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r)).Decode(&msg)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			to = append(to, msg.To.String())
+			ids = append(ids, msg.ID)
+			bodies = append(bodies, msg.Body)
+			return nil
+		}))
+
+		err := s.Client.BroadcastWithConfig(context.Background(), stanza.Message{
+			ID:   "original",
+			To:   jid.MustParse("romeo@example.net"),
+			Type: stanza.ChatMessage,
+		}.Wrap(xmlstream.Wrap(
+			xmlstream.Token(xml.CharData("Wherefore art thou?")),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		)), xmpp.BroadcastConfig{Concurrency: concurrency}, recipients...)
+		if err != nil {
+			t.Fatalf("error broadcasting with concurrency %d: %v", concurrency, err)
+		}
+		err = s.Close()
+		if err != nil {
+			t.Fatalf("error closing session: %v", err)
+		}
+
+		mu.Lock()
+		if concurrency > 1 {
+			sort.Strings(to)
+		}
+		if s := strings.Join(to, ","); s != "juliet@example.com,nurse@example.com,tybalt@example.com" {
+			t.Errorf("wrong recipients with concurrency %d: %s", concurrency, s)
+		}
+		seen := make(map[string]struct{})
+		for _, id := range ids {
+			if _, ok := seen[id]; ok || id == "" || id == "original" {
+				t.Errorf("bad ID with concurrency %d: %q", concurrency, id)
+			}
+			seen[id] = struct{}{}
+		}
+		for _, body := range bodies {
+			if body != "Wherefore art thou?" {
+				t.Errorf("wrong body with concurrency %d: %q", concurrency, body)
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+func TestBroadcastErrors(t *testing.T) {
+	recipients := []jid.JID{
+		jid.MustParse("juliet@example.com"),
+		jid.MustParse("nurse@example.com"),
+	}
+	s := xmpptest.NewClientServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := s.Client.Broadcast(ctx, stanza.Message{}.Wrap(nil), recipients...)
+	var broadcastErr xmpp.BroadcastError
+	if !errors.As(err, &broadcastErr) {
+		t.Fatalf("wrong error type: %T %[1]v", err)
+	}
+	if len(broadcastErr) != len(recipients) {
+		t.Fatalf("wrong number of errors: want=%d, got=%d", len(recipients), len(broadcastErr))
+	}
+	for i, e := range broadcastErr {
+		if !e.To.Equal(recipients[i]) {
+			t.Errorf("wrong recipient for error %d: want=%v, got=%v", i, recipients[i], e.To)
+		}
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error to wrap context.Canceled, got: %v", err)
+	}
+
+	err = s.Client.Broadcast(context.Background(), xmlstream.Token(xml.CharData("test")), recipients...)
+	if err == nil {
+		t.Errorf("expected error when broadcasting tokens that are not an element")
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h *Handler"
+
+// Package callinvite implements XEP-0482: Call Invites.
+//
+// Call invites are sent in messages to let the recipient know that they are
+// being invited to join a call.
+// The call itself is not carried over XMPP by this package: each invite lists
+// one or more methods of joining the call, such as an external URI (a SIP
+// address or a link to a video conferencing service) or a Jingle session, and
+// the recipient picks the one it supports when accepting the invite.
+//
+// Once an invite has been sent, events can be sent in reply to it to accept or
+// reject the call, to signal that a participant has left the call, or, by the
+// inviter, to retract the invite.
+// Events reference the invite using the ID of the message it was sent in.
+package callinvite // import "mellium.im/xmpp/callinvite"
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// NS is the namespace used by this package.
+const NS = "urn:xmpp:call-invites:0"
+
+// Method is a way of joining a call.
+// Exactly one of URI or SID should be set.
+type Method struct {
+	// URI is the address of a call that is hosted externally, for example a SIP
+	// URI or a link to a video conferencing service.
+	URI string
+
+	// SID is the ID of a Jingle session and JID is the address that the session
+	// should be initiated with.
+	SID string
+	JID jid.JID
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (m Method) TokenReader() xml.TokenReader {
+	if m.URI != "" {
+		return xmlstream.Wrap(nil, xml.StartElement{
+			Name: xml.Name{Space: NS, Local: "external"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "uri"}, Value: m.URI}},
+		})
+	}
+	attrs := []xml.Attr{{Name: xml.Name{Local: "sid"}, Value: m.SID}}
+	if !m.JID.Equal(jid.JID{}) {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "jid"}, Value: m.JID.String()})
+	}
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "jingle"},
+		Attr: attrs,
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (m Method) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, m.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (m Method) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := m.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+// Unknown methods are skipped and result in the zero value.
+func (m *Method) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*m = Method{}
+	if start.Name.Space == NS {
+		switch start.Name.Local {
+		case "external":
+			for _, a := range start.Attr {
+				if a.Name.Local == "uri" {
+					m.URI = a.Value
+				}
+			}
+		case "jingle":
+			for _, a := range start.Attr {
+				switch a.Name.Local {
+				case "sid":
+					m.SID = a.Value
+				case "jid":
+					j, err := jid.Parse(a.Value)
+					if err != nil {
+						return err
+					}
+					m.JID = j
+				}
+			}
+		}
+	}
+	return d.Skip()
+}
+
+func (m Method) zero() bool {
+	return m.URI == "" && m.SID == ""
+}
+
+// decodeMethods decodes the children of start as a list of methods, skipping
+// any that are unknown.
+func decodeMethods(d *xml.Decoder, start xml.StartElement) ([]Method, error) {
+	v := struct {
+		Methods []Method `xml:",any"`
+	}{}
+	err := d.DecodeElement(&v, &start)
+	if err != nil {
+		return nil, err
+	}
+	methods := v.Methods[:0]
+	for _, m := range v.Methods {
+		if !m.zero() {
+			methods = append(methods, m)
+		}
+	}
+	return methods, nil
+}
+
+// Invite is an invitation to join a call.
+type Invite struct {
+	// Video indicates that the call is a video call.
+	Video bool
+
+	// Methods are the ways that the call can be joined in order of preference.
+	Methods []Method
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (i Invite) TokenReader() xml.TokenReader {
+	start := xml.StartElement{Name: xml.Name{Space: NS, Local: "invite"}}
+	if i.Video {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "video"}, Value: "true"})
+	}
+	inner := make([]xml.TokenReader, 0, len(i.Methods))
+	for _, m := range i.Methods {
+		inner = append(inner, m.TokenReader())
+	}
+	return xmlstream.Wrap(xmlstream.MultiReader(inner...), start)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (i Invite) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, i.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (i Invite) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := i.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (i *Invite) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*i = Invite{}
+	for _, a := range start.Attr {
+		if a.Name.Local == "video" {
+			i.Video = a.Value == "true" || a.Value == "1"
+		}
+	}
+	methods, err := decodeMethods(d, start)
+	if err != nil {
+		return err
+	}
+	i.Methods = methods
+	return nil
+}
+
+// EventType is the type of an event sent in reply to an invite.
+type EventType string
+
+// A list of possible events.
+const (
+	// Accept is sent by an invitee that is joining the call.
+	Accept EventType = "accept"
+
+	// Reject is sent by an invitee that will not join the call.
+	Reject EventType = "reject"
+
+	// Left is sent by a participant that has left the call.
+	Left EventType = "left"
+
+	// Retract is sent by the inviter to cancel the invite.
+	Retract EventType = "retract"
+)
+
+func (t EventType) valid() bool {
+	switch t {
+	case Accept, Reject, Left, Retract:
+		return true
+	}
+	return false
+}
+
+// Event is sent in reply to an invite.
+type Event struct {
+	Type EventType
+
+	// ID is the ID of the message that the invite was sent in.
+	ID string
+
+	// Method is the method that was chosen to join the call.
+	// It is only used by Accept events.
+	Method Method
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (e Event) TokenReader() xml.TokenReader {
+	var inner xml.TokenReader
+	if e.Type == Accept && !e.Method.zero() {
+		inner = e.Method.TokenReader()
+	}
+	return xmlstream.Wrap(inner, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: string(e.Type)},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: e.ID}},
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (e Event) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, e.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (e Event) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	_, err := e.WriteXML(enc)
+	if err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (e *Event) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	typ := EventType(start.Name.Local)
+	if start.Name.Space != NS || !typ.valid() {
+		return fmt.Errorf("callinvite: unknown event %s", start.Name.Local)
+	}
+	*e = Event{Type: typ}
+	for _, a := range start.Attr {
+		if a.Name.Local == "id" {
+			e.ID = a.Value
+		}
+	}
+	methods, err := decodeMethods(d, start)
+	if err != nil {
+		return err
+	}
+	if typ == Accept && len(methods) > 0 {
+		e.Method = methods[0]
+	}
+	return nil
+}
+
+// Send sends an invite wrapped in the provided message and returns the ID of
+// the message, which is used to reference the invite in events.
+// If the message does not have an ID, one is generated.
+func Send(ctx context.Context, s *xmpp.Session, msg stanza.Message, inv Invite) (string, error) {
+	if msg.ID == "" {
+		msg.ID = attr.RandomID()
+	}
+	return msg.ID, s.Send(ctx, msg.Wrap(inv.TokenReader()))
+}
+
+// SendEvent sends an event wrapped in the provided message.
+func SendEvent(ctx context.Context, s *xmpp.Session, msg stanza.Message, e Event) error {
+	return s.Send(ctx, msg.Wrap(e.TokenReader()))
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package callinvite_test
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/callinvite"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xmlstream.Marshaler = callinvite.Invite{}
+	_ xmlstream.WriterTo  = callinvite.Invite{}
+	_ xmlstream.Marshaler = callinvite.Event{}
+	_ xmlstream.WriterTo  = callinvite.Event{}
+	_ xmlstream.Marshaler = callinvite.Method{}
+	_ xmlstream.WriterTo  = callinvite.Method{}
+)
+
+var (
+	sip   = callinvite.Method{URI: "sip:juliet@example.com"}
+	jitsi = callinvite.Method{URI: "https://meet.example.com/capulet"}
+	jngl  = callinvite.Method{SID: "a73sjjvkla37jfea", JID: jid.MustParse("juliet@example.com/balcony")}
+)
+
+var encodingTestCases = []xmpptest.EncodingTestCase{
+	0: {
+		Value: &callinvite.Invite{
+			Video:   true,
+			Methods: []callinvite.Method{jngl, jitsi},
+		},
+		XML: `<invite xmlns="urn:xmpp:call-invites:0" video="true"><jingle xmlns="urn:xmpp:call-invites:0" sid="a73sjjvkla37jfea" jid="juliet@example.com/balcony"></jingle><external xmlns="urn:xmpp:call-invites:0" uri="https://meet.example.com/capulet"></external></invite>`,
+	},
+	1: {
+		Value: &callinvite.Invite{
+			Methods: []callinvite.Method{sip},
+		},
+		XML: `<invite xmlns="urn:xmpp:call-invites:0"><external xmlns="urn:xmpp:call-invites:0" uri="sip:juliet@example.com"></external></invite>`,
+	},
+	2: {
+		Value: &callinvite.Event{Type: callinvite.Accept, ID: "1234", Method: jitsi},
+		XML:   `<accept xmlns="urn:xmpp:call-invites:0" id="1234"><external xmlns="urn:xmpp:call-invites:0" uri="https://meet.example.com/capulet"></external></accept>`,
+	},
+	3: {
+		Value: &callinvite.Event{Type: callinvite.Reject, ID: "1234"},
+		XML:   `<reject xmlns="urn:xmpp:call-invites:0" id="1234"></reject>`,
+	},
+	4: {
+		Value: &callinvite.Event{Type: callinvite.Left, ID: "1234"},
+		XML:   `<left xmlns="urn:xmpp:call-invites:0" id="1234"></left>`,
+	},
+	5: {
+		Value: &callinvite.Event{Type: callinvite.Retract, ID: "1234"},
+		XML:   `<retract xmlns="urn:xmpp:call-invites:0" id="1234"></retract>`,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	xmpptest.RunEncodingTests(t, encodingTestCases)
+}
+
+func TestUnknownMethod(t *testing.T) {
+	const in = `<invite xmlns="urn:xmpp:call-invites:0"><carrier-pigeon/><external uri="sip:juliet@example.com"/></invite>`
+	var inv callinvite.Invite
+	err := xml.Unmarshal([]byte(in), &inv)
+	if err != nil {
+		t.Fatalf("error unmarshaling: %v", err)
+	}
+	if want := []callinvite.Method{sip}; !reflect.DeepEqual(inv.Methods, want) {
+		t.Errorf("wrong methods: want=%+v, got=%+v", want, inv.Methods)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	invites := make(chan callinvite.Invite, 1)
+	events := make(chan callinvite.Event, 1)
+	h := &callinvite.Handler{
+		Invited: func(_ stanza.Message, inv callinvite.Invite) {
+			invites <- inv
+		},
+		Event: func(_ stanza.Message, e callinvite.Event) {
+			events <- e
+		},
+	}
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSClient, callinvite.Handle(h))),
+	)
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg := stanza.Message{
+		XMLName: xml.Name{Space: stanza.NSClient, Local: "message"},
+		Type:    stanza.ChatMessage,
+	}
+	want := callinvite.Invite{Methods: []callinvite.Method{jngl, sip}}
+	id, err := callinvite.Send(ctx, s.Server, msg, want)
+	if err != nil {
+		t.Fatalf("error sending invite: %v", err)
+	}
+	if id == "" {
+		t.Errorf("expected an ID to be generated for the invite")
+	}
+	select {
+	case inv := <-invites:
+		if !reflect.DeepEqual(inv, want) {
+			t.Errorf("wrong invite: want=%+v, got=%+v", want, inv)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for invite")
+	}
+
+	wantEvent := callinvite.Event{Type: callinvite.Retract, ID: id}
+	err = callinvite.SendEvent(ctx, s.Server, msg, wantEvent)
+	if err != nil {
+		t.Fatalf("error sending event: %v", err)
+	}
+	select {
+	case e := <-events:
+		if !reflect.DeepEqual(e, wantEvent) {
+			t.Errorf("wrong event: want=%+v, got=%+v", wantEvent, e)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for event")
+	}
+}
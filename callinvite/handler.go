@@ -0,0 +1,82 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package callinvite
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle returns an option that registers a Handler for invites and events in
+// chat and group chat messages.
+func Handle(h *Handler) mux.Option {
+	return func(m *mux.ServeMux) {
+		for _, local := range []string{"invite", string(Accept), string(Reject), string(Left), string(Retract)} {
+			name := xml.Name{Space: NS, Local: local}
+			mux.Message(stanza.ChatMessage, name, h)(m)
+			mux.Message(stanza.GroupChatMessage, name, h)(m)
+		}
+	}
+}
+
+// Handler reports invites and events received in messages.
+// The zero value is ready to use.
+type Handler struct {
+	// Invited, if set, is called when an invite is received.
+	// Events referencing the invite will use the ID of the message.
+	Invited func(msg stanza.Message, inv Invite)
+
+	// Event, if set, is called when an event is received in reply to an invite.
+	Event func(msg stanza.Message, e Event)
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+	// Pop the start message token.
+	_, err := t.Token()
+	if err != nil {
+		return err
+	}
+
+	iter := xmlstream.NewIter(t)
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		start, r := iter.Current()
+		if start == nil || start.Name.Space != NS {
+			continue
+		}
+		d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r))
+		if start.Name.Local == "invite" {
+			var inv Invite
+			err = d.Decode(&inv)
+			if err != nil {
+				return err
+			}
+			if h.Invited != nil {
+				h.Invited(msg, inv)
+			}
+			return nil
+		}
+		if !EventType(start.Name.Local).valid() {
+			continue
+		}
+		var e Event
+		err = d.Decode(&e)
+		if err != nil {
+			return err
+		}
+		if h.Event != nil {
+			h.Event(msg, e)
+		}
+		return nil
+	}
+	return iter.Err()
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package carbons
+
+import (
+	"encoding/xml"
+	"sync"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/delay"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Namespaces of payloads that make a message eligible for carbons delivery.
+const (
+	nsHints       = "urn:xmpp:hints"
+	nsChatStates  = "http://jabber.org/protocol/chatstates"
+	nsReceipts    = "urn:xmpp:receipts"
+	nsChatMarkers = "urn:xmpp:chat-markers:0"
+)
+
+// Eligible reports whether the message starting with start is eligible for
+// carbons delivery.
+// r should be positioned just after start, and is read until the end of the
+// message.
+//
+// A message is eligible if it does not contain a private element or a
+// no-copy hint, is not itself a carbon copy, and it is of type chat, it is of
+// type normal and has a body, or it contains a chat state, receipt, or chat
+// marker.
+// Groupchat messages and errors are never eligible.
+func Eligible(start xml.StartElement, r xml.TokenReader) (bool, error) {
+	_, typ := attr.Get(start.Attr, "type")
+	eligible := false
+	switch stanza.MessageType(typ) {
+	case stanza.ChatMessage:
+		eligible = true
+	case stanza.GroupChatMessage, stanza.ErrorMessage:
+		return false, nil
+	}
+
+	iter := xmlstream.NewIter(r)
+	for iter.Next() {
+		child, _ := iter.Current()
+		switch {
+		case child.Name.Space == NS:
+			// Private, or already a carbon copy.
+			return false, nil
+		case child.Name.Space == nsHints && child.Name.Local == "no-copy":
+			return false, nil
+		case child.Name.Local == "body" && (typ == "" || typ == string(stanza.NormalMessage)):
+			eligible = true
+		case child.Name.Space == nsChatStates,
+			child.Name.Space == nsReceipts,
+			child.Name.Space == nsChatMarkers:
+			eligible = true
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return false, err
+	}
+	return eligible, nil
+}
+
+// Server keeps track of the resources that have enabled carbons and sends
+// copies of their messages to one another.
+// It is meant to be used by servers and the zero value is ready to use.
+type Server struct {
+	mu      sync.Mutex
+	enabled map[string]map[string]struct{}
+}
+
+// Enable starts carbon copying messages to the provided full JID.
+func (s *Server) Enable(j jid.JID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.enabled == nil {
+		s.enabled = make(map[string]map[string]struct{})
+	}
+	bare := j.Bare().String()
+	resources, ok := s.enabled[bare]
+	if !ok {
+		resources = make(map[string]struct{})
+		s.enabled[bare] = resources
+	}
+	resources[j.Resourcepart()] = struct{}{}
+}
+
+// Disable stops carbon copying messages to the provided full JID.
+// It should also be called when the resource goes offline.
+func (s *Server) Disable(j jid.JID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bare := j.Bare().String()
+	resources := s.enabled[bare]
+	delete(resources, j.Resourcepart())
+	if len(resources) == 0 {
+		delete(s.enabled, bare)
+	}
+}
+
+// Enabled reports whether carbons are enabled for the provided full JID.
+func (s *Server) Enabled(j jid.JID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.enabled[j.Bare().String()][j.Resourcepart()]
+	return ok
+}
+
+// resources returns the full JIDs of the resources of j that have enabled
+// carbons.
+func (s *Server) resources(j jid.JID) []jid.JID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bare := j.Bare()
+	var out []jid.JID
+	for res := range s.enabled[bare.String()] {
+		full, err := bare.WithResource(res)
+		if err != nil {
+			continue
+		}
+		out = append(out, full)
+	}
+	return out
+}
+
+// Distribute sends carbon copies of the message read from r to every resource
+// of owner that has enabled carbons.
+// The owner and any resources listed in skip (normally the resources that the
+// message was already delivered to) do not receive a copy.
+// If sent is true, owner should be the full JID of the resource that sent the
+// message and copies are wrapped in a sent element, otherwise owner is the
+// recipient of the message and copies are wrapped in a received element.
+// Messages that are not eligible for carbons delivery are not copied.
+//
+// Each copy is passed to send along with the JID it is addressed to.
+func (s *Server) Distribute(owner jid.JID, sent bool, r xml.TokenReader, send func(to jid.JID, r xml.TokenReader) error, skip ...jid.JID) error {
+	toks, err := xmlstream.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(toks) == 0 {
+		return nil
+	}
+	start, ok := toks[0].(xml.StartElement)
+	if !ok || start.Name.Local != "message" {
+		return nil
+	}
+	eligible, err := Eligible(start, tokens.Reader(toks[1:]))
+	if err != nil || !eligible {
+		return err
+	}
+
+	_, typ := attr.Get(start.Attr, "type")
+	wrap := WrapReceived
+	if sent {
+		wrap = WrapSent
+	}
+	now := time.Now()
+outer:
+	for _, to := range s.resources(owner) {
+		for _, j := range skip {
+			if j.Equal(to) {
+				continue outer
+			}
+		}
+		if to.Equal(owner) {
+			continue
+		}
+		err = send(to, stanza.Message{
+			From: owner.Bare(),
+			To:   to,
+			Type: stanza.MessageType(typ),
+		}.Wrap(wrap(delay.Delay{Time: now}, tokens.Reader(toks))))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handle returns an option that registers the server to handle requests to
+// enable or disable carbons.
+// Requests must have a from attribute containing the full JID of the resource.
+func (s *Server) Handle() mux.Option {
+	return func(m *mux.ServeMux) {
+		mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "enable"}, s)(m)
+		mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "disable"}, s)(m)
+	}
+}
+
+// HandleIQ implements mux.IQHandler.
+func (s *Server) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	if iq.From.Resourcepart() == "" {
+		_, err := xmlstream.Copy(t, iq.Error(stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.BadRequest,
+		}))
+		return err
+	}
+	switch start.Name.Local {
+	case "enable":
+		s.Enable(iq.From)
+	case "disable":
+		s.Disable(iq.From)
+	}
+	_, err := xmlstream.Copy(t, iq.Result(nil))
+	return err
+}
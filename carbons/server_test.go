@@ -0,0 +1,169 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package carbons_test
+
+import (
+	"context"
+	"encoding/xml"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/carbons"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var eligibleTests = [...]struct {
+	in       string
+	eligible bool
+}{
+	0:  {in: `<message type="chat"/>`, eligible: true},
+	1:  {in: `<message type="normal"><body>foo</body></message>`, eligible: true},
+	2:  {in: `<message><body>foo</body></message>`, eligible: true},
+	3:  {in: `<message type="normal"/>`},
+	4:  {in: `<message type="groupchat"><body>foo</body></message>`},
+	5:  {in: `<message type="error"><body>foo</body></message>`},
+	6:  {in: `<message type="chat"><private xmlns="urn:xmpp:carbons:2"/></message>`},
+	7:  {in: `<message type="chat"><no-copy xmlns="urn:xmpp:hints"/></message>`},
+	8:  {in: `<message type="normal"><received xmlns="urn:xmpp:receipts" id="1"/></message>`, eligible: true},
+	9:  {in: `<message type="normal"><active xmlns="http://jabber.org/protocol/chatstates"/></message>`, eligible: true},
+	10: {in: `<message type="chat"><received xmlns="urn:xmpp:carbons:2"/></message>`},
+	11: {in: `<message type="headline"><body>foo</body></message>`},
+}
+
+func TestEligible(t *testing.T) {
+	for i, tc := range eligibleTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			d := xml.NewDecoder(strings.NewReader(tc.in))
+			tok, err := d.Token()
+			if err != nil {
+				t.Fatalf("error popping start token: %v", err)
+			}
+			eligible, err := carbons.Eligible(tok.(xml.StartElement), d)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if eligible != tc.eligible {
+				t.Errorf("wrong eligibility: want=%t, got=%t", tc.eligible, eligible)
+			}
+		})
+	}
+}
+
+func TestDistribute(t *testing.T) {
+	s := &carbons.Server{}
+	phone := jid.MustParse("romeo@example.net/phone")
+	laptop := jid.MustParse("romeo@example.net/laptop")
+	desktop := jid.MustParse("romeo@example.net/desktop")
+	s.Enable(phone)
+	s.Enable(laptop)
+	s.Enable(desktop)
+	s.Enable(jid.MustParse("juliet@example.com/balcony"))
+	s.Disable(desktop)
+	if s.Enabled(desktop) || !s.Enabled(laptop) {
+		t.Fatalf("carbons not enabled for the correct resources")
+	}
+
+	const msg = `<message xmlns="jabber:client" type="chat" from="romeo@example.net/phone" to="juliet@example.com"><body>foo</body></message>`
+	var sentTo []string
+	var out strings.Builder
+	err := s.Distribute(phone, true, xml.NewDecoder(strings.NewReader(msg)), func(to jid.JID, r xml.TokenReader) error {
+		sentTo = append(sentTo, to.String())
+		var v struct {
+			XMLName xml.Name `xml:"message"`
+			From    string   `xml:"from,attr"`
+			To      string   `xml:"to,attr"`
+			Sent    struct {
+				Forwarded struct {
+					Message struct {
+						Body string `xml:"body"`
+					} `xml:"jabber:client message"`
+				} `xml:"urn:xmpp:forward:0 forwarded"`
+			} `xml:"urn:xmpp:carbons:2 sent"`
+		}
+		err := xml.NewTokenDecoder(r).Decode(&v)
+		if err != nil {
+			return err
+		}
+		if v.From != "romeo@example.net" || v.To != to.String() {
+			t.Errorf("wrong addressing on carbon: from=%s, to=%s", v.From, v.To)
+		}
+		out.WriteString(v.Sent.Forwarded.Message.Body)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error distributing carbons: %v", err)
+	}
+	if s := strings.Join(sentTo, ","); s != "romeo@example.net/laptop" {
+		t.Errorf("wrong recipients: want=romeo@example.net/laptop, got=%s", s)
+	}
+	if s := out.String(); s != "foo" {
+		t.Errorf("wrong forwarded body: want=foo, got=%s", s)
+	}
+
+	// Private messages are not copied.
+	sentTo = sentTo[:0]
+	const private = `<message xmlns="jabber:client" type="chat"><body>foo</body><private xmlns="urn:xmpp:carbons:2"/></message>`
+	err = s.Distribute(jid.MustParse("romeo@example.net"), false, xml.NewDecoder(strings.NewReader(private)), func(to jid.JID, r xml.TokenReader) error {
+		sentTo = append(sentTo, to.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error distributing private message: %v", err)
+	}
+	if len(sentTo) != 0 {
+		t.Errorf("private message should not be copied, got %v", sentTo)
+	}
+
+	// Received messages are copied to all resources that were skipped.
+	err = s.Distribute(jid.MustParse("romeo@example.net"), false, xml.NewDecoder(strings.NewReader(msg)), func(to jid.JID, r xml.TokenReader) error {
+		sentTo = append(sentTo, to.String())
+		return nil
+	}, laptop)
+	if err != nil {
+		t.Fatalf("error distributing received message: %v", err)
+	}
+	sort.Strings(sentTo)
+	if s := strings.Join(sentTo, ","); s != "romeo@example.net/phone" {
+		t.Errorf("wrong recipients: want=romeo@example.net/phone, got=%s", s)
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	s := &carbons.Server{}
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(mux.New("", s.Handle())),
+	)
+	from := jid.MustParse("test@example.net/laptop")
+	iq := stanza.IQ{From: from}
+	err := carbons.EnableIQ(context.Background(), cs.Client, iq)
+	if err != nil {
+		t.Fatalf("error enabling carbons: %v", err)
+	}
+	if !s.Enabled(from) {
+		t.Errorf("expected carbons to be enabled for %v", from)
+	}
+	err = carbons.DisableIQ(context.Background(), cs.Client, iq)
+	if err != nil {
+		t.Fatalf("error disabling carbons: %v", err)
+	}
+	if s.Enabled(from) {
+		t.Errorf("expected carbons to be disabled for %v", from)
+	}
+
+	// Requests without a resource are rejected.
+	err = cs.Client.UnmarshalIQ(context.Background(), stanza.IQ{
+		Type: stanza.SetIQ,
+		From: from.Bare(),
+	}.Wrap(xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: carbons.NS, Local: "enable"}})), nil)
+	if err == nil {
+		t.Errorf("expected error enabling carbons for bare JID")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package cert provides certificates for TLS connections that can be replaced
+// without restarting.
+//
+// Long running servers and components often use certificates that are renewed
+// every few months (for example certificates from Let's Encrypt).
+// Instead of setting the Certificates field of a tls.Config, which can't be
+// changed once the config is in use, a Provider is consulted during every
+// handshake.
+// The Watcher provider loads a certificate and key from disk and reloads them
+// whenever the files change.
+// Sessions that have already completed their handshake are not affected when a
+// certificate is reloaded, only new connections use the new certificate.
+//
+//	w, err := cert.NewWatcher(certFile, keyFile)
+//	if err != nil {
+//		return err
+//	}
+//	go w.Watch(ctx, time.Hour)
+//	tlsConfig := cert.Config(&tls.Config{…}, w)
+package cert // import "mellium.im/xmpp/cert"
+
+import (
+	"crypto/tls"
+)
+
+// Provider returns the certificate to use for a TLS handshake.
+type Provider interface {
+	// GetCertificate is called when the connection is received by a server, it
+	// has the signature of the GetCertificate field of tls.Config.
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	// GetClientCertificate is called when a server requests a certificate from
+	// the client, it has the signature of the GetClientCertificate field of
+	// tls.Config.
+	GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+}
+
+// Config returns a copy of cfg that uses p for server and client certificates.
+// Any certificates set on cfg are removed so that p is always consulted.
+// If cfg is nil, a new config is returned.
+func Config(cfg *tls.Config, p Provider) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	} else {
+		cfg = cfg.Clone()
+	}
+	cfg.Certificates = nil
+	cfg.GetCertificate = p.GetCertificate
+	cfg.GetClientCertificate = p.GetClientCertificate
+	return cfg
+}
+
+// Static is a Provider that always returns the same certificate.
+type Static struct {
+	Certificate *tls.Certificate
+}
+
+// GetCertificate satisfies Provider.
+func (s Static) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.Certificate, nil
+}
+
+// GetClientCertificate satisfies Provider.
+func (s Static) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.Certificate, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package cert_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/cert"
+)
+
+var (
+	_ cert.Provider = (*cert.Watcher)(nil)
+	_ cert.Provider = cert.Static{}
+)
+
+// writeCert generates a self signed certificate with the provided serial
+// number and writes it and its key to the files.
+func writeCert(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		DNSNames:     []string{"example.net"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshaling key: %v", err)
+	}
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	// Make sure the modification time changes even on file systems with a
+	// coarse resolution.
+	mtime := time.Now().Add(time.Duration(serial) * time.Second)
+	for _, f := range []string{certFile, keyFile} {
+		err = os.Chtimes(f, mtime, mtime)
+		if err != nil {
+			t.Fatalf("error setting modification time: %v", err)
+		}
+	}
+}
+
+func serial(t *testing.T, p cert.Provider) int64 {
+	t.Helper()
+	c, err := p.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("error getting certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	if err != nil {
+		t.Fatalf("error parsing certificate: %v", err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, 1)
+
+	w, err := cert.NewWatcher(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("error creating watcher: %v", err)
+	}
+	if s := serial(t, w); s != 1 {
+		t.Fatalf("wrong initial certificate: want=1, got=%d", s)
+	}
+	reloaded, err := w.Reload()
+	if err != nil || reloaded {
+		t.Errorf("unchanged files should not be reloaded: reloaded=%t, err=%v", reloaded, err)
+	}
+
+	// A partially written renewal keeps the old certificate.
+	err = os.WriteFile(keyFile, []byte("garbage"), 0600)
+	if err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	_, err = w.Reload()
+	if err == nil {
+		t.Errorf("expected error reloading invalid key")
+	}
+	if s := serial(t, w); s != 1 {
+		t.Errorf("failed reload replaced certificate: want=1, got=%d", s)
+	}
+
+	writeCert(t, certFile, keyFile, 2)
+	reloaded, err = w.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("expected certificate to be reloaded: reloaded=%t, err=%v", reloaded, err)
+	}
+	if s := serial(t, w); s != 2 {
+		t.Errorf("wrong certificate after reload: want=2, got=%d", s)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, 1)
+
+	w, err := cert.NewWatcher(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("error creating watcher: %v", err)
+	}
+	reloads := make(chan error, 1)
+	w.Reloaded = func(err error) {
+		reloads <- err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		/* #nosec */
+		w.Watch(ctx, 10*time.Millisecond)
+	}()
+
+	writeCert(t, certFile, keyFile, 2)
+	// The watcher may see the new certificate before the new key is written, in
+	// which case reloading fails until the next check.
+	for err := errors.New("not reloaded"); err != nil; {
+		select {
+		case err = <-reloads:
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for reload")
+		}
+	}
+	if s := serial(t, w); s != 2 {
+		t.Errorf("wrong certificate after reload: want=2, got=%d", s)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, 1)
+	w, err := cert.NewWatcher(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("error creating watcher: %v", err)
+	}
+
+	orig := &tls.Config{ServerName: "example.net", Certificates: []tls.Certificate{{}}}
+	cfg := cert.Config(orig, w)
+	if cfg == orig || len(orig.Certificates) != 1 || orig.GetCertificate != nil {
+		t.Errorf("original config should not be modified")
+	}
+	if cfg.ServerName != "example.net" || cfg.Certificates != nil || cfg.GetCertificate == nil || cfg.GetClientCertificate == nil {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg = cert.Config(nil, w); cfg.GetCertificate == nil {
+		t.Errorf("expected new config to use the provider")
+	}
+
+	// Perform a handshake to make sure the provider is actually used.
+	state := handshake(t, cfg)
+	if s := state.PeerCertificates[0].SerialNumber.Int64(); s != 1 {
+		t.Errorf("wrong certificate used in handshake: want=1, got=%d", s)
+	}
+}
+
+// handshake performs a TLS handshake over an in memory connection using cfg
+// for the server and returns the clients connection state.
+func handshake(t *testing.T, cfg *tls.Config) tls.ConnectionState {
+	t.Helper()
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	server := tls.Server(c1, cfg)
+	client := tls.Client(c2, &tls.Config{
+		// This is a self signed test certificate.
+		/* #nosec */
+		InsecureSkipVerify: true,
+	})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Handshake()
+	}()
+	err := client.Handshake()
+	if err != nil {
+		t.Fatalf("error performing client handshake: %v", err)
+	}
+	if err = <-errs; err != nil {
+		t.Fatalf("error performing server handshake: %v", err)
+	}
+	return client.ConnectionState()
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package cert
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileInfo is the information used to detect changes to a file.
+type fileInfo struct {
+	modTime time.Time
+	size    int64
+}
+
+func stat(name string) (fileInfo, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fileInfo{}, err
+	}
+	return fileInfo{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// Watcher is a Provider that loads a PEM encoded certificate and key from
+// files and reloads them when they change.
+//
+// Files are compared by their modification time and size, symbolic links are
+// followed so renewals that replace a link (as certbot does) are detected.
+// If a reload fails, for example because the certificate has been written but
+// the matching key has not, the previous certificate continues to be used and
+// the reload is retried on the next check.
+type Watcher struct {
+	certFile string
+	keyFile  string
+
+	// Reloaded, if set, is called after each attempt to reload the certificate
+	// with the error that occurred, if any.
+	Reloaded func(error)
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certInfo fileInfo
+	keyInfo  fileInfo
+}
+
+// NewWatcher loads the certificate and key from the provided files.
+// Changes to the files are not picked up until Watch or Reload is called.
+func NewWatcher(certFile, keyFile string) (*Watcher, error) {
+	w := &Watcher{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	_, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// GetCertificate satisfies Provider by returning the most recently loaded
+// certificate.
+func (w *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cert, nil
+}
+
+// GetClientCertificate satisfies Provider by returning the most recently
+// loaded certificate.
+func (w *Watcher) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return w.GetCertificate(nil)
+}
+
+// Reload loads the certificate and key again if either of the files has
+// changed since they were last loaded and reports whether the certificate was
+// replaced.
+func (w *Watcher) Reload() (bool, error) {
+	certInfo, err := stat(w.certFile)
+	if err != nil {
+		return false, err
+	}
+	keyInfo, err := stat(w.keyFile)
+	if err != nil {
+		return false, err
+	}
+	w.mu.RLock()
+	changed := certInfo != w.certInfo || keyInfo != w.keyInfo
+	w.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+	return w.load()
+}
+
+func (w *Watcher) load() (bool, error) {
+	// Stat the files before reading them so that a change made while we're
+	// loading is picked up by the next reload.
+	certInfo, err := stat(w.certFile)
+	if err != nil {
+		return false, err
+	}
+	keyInfo, err := stat(w.keyFile)
+	if err != nil {
+		return false, err
+	}
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return false, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cert = &cert
+	w.certInfo = certInfo
+	w.keyInfo = keyInfo
+	return true, nil
+}
+
+// Watch checks the files for changes every interval and reloads the
+// certificate when they change until the context is canceled.
+// It always returns the context error.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		reloaded, err := w.Reload()
+		if (reloaded || err != nil) && w.Reloaded != nil {
+			w.Reloaded(err)
+		}
+	}
+}
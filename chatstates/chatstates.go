@@ -0,0 +1,167 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h *Handler"
+
+// Package chatstates implements XEP-0085: Chat State Notifications.
+//
+// Chat states let the participants in a conversation know whether the other
+// participants are actively engaged in the conversation, for example to show
+// a typing indicator when they are composing a message.
+package chatstates // import "mellium.im/xmpp/chatstates"
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// NS is the namespace used by this package.
+const NS = "http://jabber.org/protocol/chatstates"
+
+// State is a chat state that indicates the level of engagement of a user in a
+// conversation.
+// The zero value is not a valid state and marshals to nothing, which allows
+// it to be used to mean that no state was present.
+type State string
+
+// A list of possible chat states.
+const (
+	// Active means that the user is actively participating in the
+	// conversation.
+	Active State = "active"
+
+	// Composing means that the user is composing a message.
+	Composing State = "composing"
+
+	// Paused means that the user had been composing but has now stopped.
+	Paused State = "paused"
+
+	// Inactive means that the user has not been actively participating in the
+	// conversation.
+	Inactive State = "inactive"
+
+	// Gone means that the user has effectively ended their participation in
+	// the conversation.
+	Gone State = "gone"
+)
+
+func (s State) valid() bool {
+	switch s {
+	case Active, Composing, Paused, Inactive, Gone:
+		return true
+	}
+	return false
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (s State) TokenReader() xml.TokenReader {
+	if !s.valid() {
+		return tokens.Reader(nil)
+	}
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: string(s)},
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (s State) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, s.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (s State) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := s.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+// If the element is not a known chat state, s is set to the zero value.
+func (s *State) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*s = ""
+	if st := State(start.Name.Local); start.Name.Space == NS && st.valid() {
+		*s = st
+	}
+	return d.Skip()
+}
+
+// Insert returns a transformer that adds the chat state to any message read
+// through it that is not an error.
+// It is meant for attaching a state to outgoing messages with content, for
+// example the Active state to a message with a body.
+// Messages that already contain a chat state are not modified.
+func Insert(s State) xmlstream.Transformer {
+	return func(r xml.TokenReader) xml.TokenReader {
+		var (
+			depth int
+			isMsg bool
+			skip  bool
+			inner xml.TokenReader
+		)
+		return xmlstream.ReaderFunc(func() (xml.Token, error) {
+			if inner != nil {
+				tok, err := inner.Token()
+				if err == io.EOF {
+					inner = nil
+					err = nil
+				}
+				if tok != nil || err != nil {
+					return tok, err
+				}
+			}
+
+			tok, err := r.Token()
+			if tok == nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				depth++
+				switch {
+				case depth == 1:
+					isMsg = t.Name.Local == "message" && (t.Name.Space == stanza.NSClient || t.Name.Space == stanza.NSServer)
+					skip = false
+					for _, attr := range t.Attr {
+						if attr.Name.Local == "type" && attr.Value == string(stanza.ErrorMessage) {
+							skip = true
+						}
+					}
+				case depth == 2 && t.Name.Space == NS:
+					// The message already has a chat state.
+					skip = true
+				}
+			case xml.EndElement:
+				depth--
+				if depth == 0 && isMsg && !skip {
+					inner = xmlstream.MultiReader(s.TokenReader(), xmlstream.Token(t))
+					return inner.Token()
+				}
+			}
+			return tok, err
+		})
+	}
+}
+
+// Send sends a standalone chat state notification to the provided address.
+// Standalone notifications are always sent as chat messages.
+// To send a notification in a group chat use SendMessage.
+func Send(ctx context.Context, s *xmpp.Session, to jid.JID, state State) error {
+	return SendMessage(ctx, s, stanza.Message{To: to, Type: stanza.ChatMessage}, state)
+}
+
+// SendMessage is like Send except that the notification is wrapped in the
+// provided message.
+func SendMessage(ctx context.Context, s *xmpp.Session, msg stanza.Message, state State) error {
+	return s.Send(ctx, msg.Wrap(state.TokenReader()))
+}
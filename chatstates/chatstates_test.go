@@ -0,0 +1,160 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package chatstates_test
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/chatstates"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xml.Marshaler         = chatstates.Active
+	_ xml.Unmarshaler       = (*chatstates.State)(nil)
+	_ xmlstream.Marshaler   = chatstates.Active
+	_ xmlstream.WriterTo    = chatstates.Active
+	_ mux.MessageHandler    = (*chatstates.Handler)(nil)
+	_ info.FeatureIter      = (*chatstates.Handler)(nil)
+	_ xmlstream.Transformer = chatstates.Insert(chatstates.Active)
+)
+
+var encodingTestCases = []xmpptest.EncodingTestCase{
+	0: {
+		Value: func() *chatstates.State { s := chatstates.Composing; return &s }(),
+		XML:   `<composing xmlns="http://jabber.org/protocol/chatstates"></composing>`,
+	},
+	1: {
+		Value: func() *chatstates.State { s := chatstates.Gone; return &s }(),
+		XML:   `<gone xmlns="http://jabber.org/protocol/chatstates"></gone>`,
+	},
+	2: {
+		Value:     func() *chatstates.State { s := chatstates.State(""); return &s }(),
+		XML:       `<typing xmlns="http://jabber.org/protocol/chatstates"></typing>`,
+		NoMarshal: true,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	xmpptest.RunEncodingTests(t, encodingTestCases)
+}
+
+var insertTestCases = [...]struct {
+	in  string
+	out string
+}{
+	0: {},
+	1: {
+		in:  `<message xmlns="jabber:client" type="chat"><body>Hi</body></message>`,
+		out: `<message xmlns="jabber:client" type="chat"><body xmlns="jabber:client">Hi</body><active xmlns="http://jabber.org/protocol/chatstates"></active></message>`,
+	},
+	2: {
+		in:  `<message xmlns="jabber:client" type="chat"><paused xmlns="http://jabber.org/protocol/chatstates"/></message>`,
+		out: `<message xmlns="jabber:client" type="chat"><paused xmlns="http://jabber.org/protocol/chatstates"></paused></message>`,
+	},
+	3: {
+		in:  `<message xmlns="jabber:client" type="error"/><iq xmlns="jabber:client"/><message xmlns="jabber:server"><x><y/></x></message>`,
+		out: `<message xmlns="jabber:client" type="error"></message><iq xmlns="jabber:client"></iq><message xmlns="jabber:server"><x xmlns="jabber:server"><y xmlns="jabber:server"></y></x><active xmlns="http://jabber.org/protocol/chatstates"></active></message>`,
+	},
+}
+
+func TestInsert(t *testing.T) {
+	for i, tc := range insertTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r := chatstates.Insert(chatstates.Active)(xml.NewDecoder(strings.NewReader(tc.in)))
+			// Prevent duplicate xmlns attributes. See https://mellium.im/issue/75
+			r = xmlstream.RemoveAttr(func(start xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(r)
+			var buf strings.Builder
+			e := xml.NewEncoder(&buf)
+			_, err := xmlstream.Copy(e, r)
+			if err != nil {
+				t.Fatalf("error encoding: %v", err)
+			}
+			if err = e.Flush(); err != nil {
+				t.Fatalf("error flushing: %v", err)
+			}
+			if out := buf.String(); out != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+type change struct {
+	from  jid.JID
+	state chatstates.State
+}
+
+func TestHandler(t *testing.T) {
+	changes := make(chan change, 10)
+	h := &chatstates.Handler{
+		Changed: func(from jid.JID, state chatstates.State) {
+			changes <- change{from: from, state: state}
+		},
+	}
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSClient, chatstates.Handle(h))),
+	)
+
+	juliet := jid.MustParse("juliet@example.net/balcony")
+	romeo := jid.MustParse("romeo@example.net/orchard")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, c := range []change{
+		{from: juliet, state: chatstates.Composing},
+		{from: juliet, state: chatstates.Composing},
+		{from: romeo, state: chatstates.Composing},
+		{from: juliet, state: chatstates.Active},
+	} {
+		err := chatstates.SendMessage(ctx, s.Server, stanza.Message{
+			XMLName: xml.Name{Space: stanza.NSClient, Local: "message"},
+			From:    c.from,
+			Type:    stanza.ChatMessage,
+		}, c.state)
+		if err != nil {
+			t.Fatalf("error sending chat state: %v", err)
+		}
+	}
+
+	for _, want := range []change{
+		{from: juliet, state: chatstates.Composing},
+		{from: romeo, state: chatstates.Composing},
+		{from: juliet, state: chatstates.Active},
+	} {
+		var got change
+		select {
+		case got = <-changes:
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for state change")
+		}
+		if !got.from.Equal(want.from) || got.state != want.state {
+			t.Errorf("wrong change: want=%v/%s, got=%v/%s", want.from, want.state, got.from, got.state)
+		}
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("unexpected change: %v/%s", got.from, got.state)
+	default:
+	}
+	if state := h.State(romeo); state != chatstates.Composing {
+		t.Errorf("wrong state for romeo: want=%s, got=%s", chatstates.Composing, state)
+	}
+	h.Forget(romeo)
+	if state := h.State(romeo); state != "" {
+		t.Errorf("expected state to be forgotten, got %s", state)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package chatstates
+
+import (
+	"encoding/xml"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle returns an option that registers a Handler for chat state
+// notifications in chat and group chat messages.
+func Handle(h *Handler) mux.Option {
+	return func(m *mux.ServeMux) {
+		for _, state := range []State{Active, Composing, Paused, Inactive, Gone} {
+			name := xml.Name{Space: NS, Local: string(state)}
+			mux.Message(stanza.ChatMessage, name, h)(m)
+			mux.Message(stanza.GroupChatMessage, name, h)(m)
+		}
+	}
+}
+
+// Handler keeps track of the last chat state received from each entity and
+// reports changes.
+// The zero value is ready to use.
+type Handler struct {
+	// Changed, if set, is called when the chat state of a conversation changes.
+	// The JID is the address that the notification was received from, which
+	// for a group chat is the occupant JID of the participant.
+	Changed func(from jid.JID, state State)
+
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// State returns the last chat state received from the provided address or the
+// zero value if no state has been received.
+func (h *Handler) State(j jid.JID) State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.states[j.String()]
+}
+
+// Forget stops tracking the chat state of the provided address.
+// This can be used to free resources when a conversation is closed.
+func (h *Handler) Forget(j jid.JID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.states, j.String())
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+	// Pop the start message token.
+	_, err := t.Token()
+	if err != nil {
+		return err
+	}
+
+	iter := xmlstream.NewIter(t)
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		start, _ := iter.Current()
+		if start == nil || start.Name.Space != NS {
+			continue
+		}
+		state := State(start.Name.Local)
+		if !state.valid() {
+			continue
+		}
+		h.update(msg.From, state)
+		return nil
+	}
+	return iter.Err()
+}
+
+func (h *Handler) update(from jid.JID, state State) {
+	key := from.String()
+	h.mu.Lock()
+	if h.states[key] == state {
+		h.mu.Unlock()
+		return
+	}
+	if h.states == nil {
+		h.states = make(map[string]State)
+	}
+	h.states[key] = state
+	h.mu.Unlock()
+
+	if h.Changed != nil {
+		h.Changed(from, state)
+	}
+}
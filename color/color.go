@@ -47,8 +47,17 @@ type digest struct {
 func (d digest) Size() int { return Size }
 func (d digest) Sum(b []byte) []byte {
 	b = d.Hash.Sum(b)
-	i := binary.LittleEndian.Uint16(b[:2])
-	switch d.cvd {
+	i := correct(binary.LittleEndian.Uint16(b[:2]), d.cvd)
+	cb, cr := chroma(float64(i) / 65536 * 2 * math.Pi)
+	b[0], b[1] = cb, cr
+	return b[:Size]
+}
+
+// correct adjusts the first two bytes of the hash (interpreted as a little
+// endian uint16) to avoid colors that are confusable for a given color vision
+// deficiency.
+func correct(i uint16, cvd CVD) uint16 {
+	switch cvd {
 	case None:
 	case RedGreen:
 		i &= 0x7fff
@@ -57,14 +66,18 @@ func (d digest) Sum(b []byte) []byte {
 	default:
 		panic("color: invalid color vision deficiency")
 	}
-	angle := float64(i) / 65536 * 2 * math.Pi
-	cr, cb := math.Sincos(angle)
-	factor := 0.5 / math.Max(math.Abs(cr), math.Abs(cb))
-	cb, cr = cb*factor, cr*factor
+	return i
+}
 
-	b[0] = uint8(math.Min(math.Max(cb+0.5, 0)*255, 255))
-	b[1] = uint8(math.Min(math.Max(cr+0.5, 0)*255, 255))
-	return b[:Size]
+// chroma returns the Cb and Cr components of the color with the given hue
+// angle in radians.
+func chroma(angle float64) (cb, cr uint8) {
+	sin, cos := math.Sincos(angle)
+	factor := 0.5 / math.Max(math.Abs(sin), math.Abs(cos))
+	fcb, fcr := cos*factor, sin*factor
+	cb = uint8(math.Min(math.Max(fcb+0.5, 0)*255, 255))
+	cr = uint8(math.Min(math.Max(fcr+0.5, 0)*255, 255))
+	return cb, cr
 }
 
 // Sum returns a color in the Y'CbCr colorspace in the form [Cb, Cr] that is
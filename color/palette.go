@@ -0,0 +1,96 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package color
+
+import (
+	/* #nosec */
+	"crypto/sha1"
+	"encoding/binary"
+	"image/color"
+	"math"
+)
+
+// Angle returns the hue angle in degrees in the range [0, 360) that is
+// consistent for the same inputs.
+// It is the angle used by Sum before it is converted to a color and can be
+// used to generate colors in other color spaces or to map the input onto a
+// Palette.
+//
+// If a color vision deficiency constant is provided (other than None), the
+// angle is restricted to avoid confusable colors.
+func Angle(data []byte, cvd CVD) float64 {
+	/* #nosec */
+	h := sha1.Sum(data)
+	i := correct(binary.LittleEndian.Uint16(h[:2]), cvd)
+	return float64(i) / 65536 * 360
+}
+
+// FromAngle returns the color with the given hue angle in degrees and luma in
+// the Y'CbCr color space.
+// For any input String(s, luma, cvd) is the same as
+// FromAngle(Angle([]byte(s), cvd), luma).
+func FromAngle(angle float64, luma uint8) color.YCbCr {
+	cb, cr := chroma(angle / 360 * 2 * math.Pi)
+	return color.YCbCr{
+		Y:  luma,
+		Cb: cb,
+		Cr: cr,
+	}
+}
+
+// Palette is a list of colors that generated colors can be mapped onto for
+// clients that can only display a restricted set of colors.
+type Palette []color.Color
+
+// hue returns the hue angle of c in degrees and false if c is a shade of gray
+// and has no hue.
+func hue(c color.Color) (float64, bool) {
+	ycc := color.YCbCrModel.Convert(c).(color.YCbCr)
+	cb := float64(ycc.Cb) - 128
+	cr := float64(ycc.Cr) - 128
+	if cb == 0 && cr == 0 {
+		return 0, false
+	}
+	angle := math.Atan2(cr, cb) / (2 * math.Pi) * 360
+	if angle < 0 {
+		angle += 360
+	}
+	return angle, true
+}
+
+// Index returns the index of the color in the palette with the hue closest to
+// the given angle in degrees.
+// Shades of gray are never selected and if the palette does not contain any
+// other colors, Index returns -1.
+func (p Palette) Index(angle float64) int {
+	idx := -1
+	best := math.Inf(1)
+	for i, c := range p {
+		h, ok := hue(c)
+		if !ok {
+			continue
+		}
+		d := math.Abs(math.Mod(h-angle, 360))
+		if d > 180 {
+			d = 360 - d
+		}
+		if d < best {
+			idx, best = i, d
+		}
+	}
+	return idx
+}
+
+// Convert returns the color in the palette with the hue closest to the angle
+// generated from data.
+// If the palette does not contain any colors that are not shades of gray,
+// Convert returns nil.
+func (p Palette) Convert(data []byte, cvd CVD) color.Color {
+	idx := p.Index(Angle(data, cvd))
+	if idx == -1 {
+		return nil
+	}
+	return p[idx]
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package color_test
+
+import (
+	"image/color"
+	"strconv"
+	"testing"
+
+	xmppcolor "mellium.im/xmpp/color"
+)
+
+func TestFromAngle(t *testing.T) {
+	for _, cvd := range []xmppcolor.CVD{xmppcolor.None, xmppcolor.RedGreen, xmppcolor.Blue} {
+		for i := 0; i < 1000; i++ {
+			s := "user" + strconv.Itoa(i)
+			want := xmppcolor.String(s, 128, cvd)
+			angle := xmppcolor.Angle([]byte(s), cvd)
+			if angle < 0 || angle >= 360 {
+				t.Fatalf("angle out of range for %q: %f", s, angle)
+			}
+			if got := xmppcolor.FromAngle(angle, 128); got != want {
+				t.Fatalf("wrong color for %q with CVD %v: want=%v, got=%v", s, cvd, want, got)
+			}
+		}
+	}
+}
+
+var angleTests = [...]struct {
+	cvd      xmppcolor.CVD
+	min, max float64
+}{
+	0: {cvd: xmppcolor.None, min: 0, max: 360},
+	1: {cvd: xmppcolor.RedGreen, min: 0, max: 180},
+	2: {cvd: xmppcolor.Blue, min: 90, max: 270},
+}
+
+func TestAngleCVD(t *testing.T) {
+	for i, tc := range angleTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for n := 0; n < 1000; n++ {
+				angle := xmppcolor.Angle([]byte(strconv.Itoa(n)), tc.cvd)
+				if angle < tc.min || angle >= tc.max {
+					t.Fatalf("angle %f out of range [%f, %f)", angle, tc.min, tc.max)
+				}
+			}
+		})
+	}
+}
+
+var (
+	red   = color.RGBA{R: 255, A: 255}
+	green = color.RGBA{G: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+	gray  = color.Gray{Y: 100}
+)
+
+var paletteTests = [...]struct {
+	p     xmppcolor.Palette
+	angle float64
+	idx   int
+}{
+	0: {p: nil, idx: -1},
+	1: {p: xmppcolor.Palette{color.Black, gray, color.White}, idx: -1},
+	// In Y'CbCr red, green, and blue have hue angles of approximately 109°, 232°,
+	// and 351° respectively.
+	2: {p: xmppcolor.Palette{gray, red, green, blue}, angle: 100, idx: 1},
+	3: {p: xmppcolor.Palette{gray, red, green, blue}, angle: 240, idx: 2},
+	4: {p: xmppcolor.Palette{gray, red, green, blue}, angle: 10, idx: 3},
+	5: {
+		// Angles wrap around at 360.
+		p:     xmppcolor.Palette{color.YCbCr{Y: 128, Cb: 255, Cr: 127}, color.YCbCr{Y: 128, Cb: 0, Cr: 128}},
+		angle: 359,
+		idx:   0,
+	},
+}
+
+func TestPaletteIndex(t *testing.T) {
+	for i, tc := range paletteTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if idx := tc.p.Index(tc.angle); idx != tc.idx {
+				t.Errorf("wrong index: want=%d, got=%d", tc.idx, idx)
+			}
+		})
+	}
+}
+
+func TestPaletteConvert(t *testing.T) {
+	p := xmppcolor.Palette{red, green, blue}
+	c := p.Convert([]byte("Romeo"), xmppcolor.None)
+	if c == nil {
+		t.Fatalf("expected a color from the palette")
+	}
+	if c != p[p.Index(xmppcolor.Angle([]byte("Romeo"), xmppcolor.None))] {
+		t.Errorf("palette color did not match index")
+	}
+	if c := (xmppcolor.Palette{gray}).Convert([]byte("Romeo"), xmppcolor.None); c != nil {
+		t.Errorf("expected no color from a gray palette, got %v", c)
+	}
+}
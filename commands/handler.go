@@ -0,0 +1,445 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"encoding/xml"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/text/message"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/disco/items"
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/l10n"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Status values used in responses to commands.
+const (
+	StatusExecuting = "executing"
+	StatusCompleted = "completed"
+	StatusCanceled  = "canceled"
+)
+
+// DefaultTimeout is the default amount of time after which an idle
+// multi-stage command session is forgotten.
+const DefaultTimeout = 10 * time.Minute
+
+// Request is a single stage of a command being executed by a remote entity.
+type Request struct {
+	// IQ is the IQ that the request was received in.
+	IQ stanza.IQ
+
+	// Node is the node of the command being executed.
+	Node string
+
+	// SID is the session ID of the command.
+	// It is generated by the handler when a command is first executed and stays
+	// the same for all stages of a multi-stage command.
+	SID string
+
+	// Action is the action requested by the remote entity.
+	// It is "execute" when a command is first started and may be "next",
+	// "prev", "complete", or "cancel" for later stages.
+	Action string
+
+	// Payload contains the children of the command element (such as a submitted
+	// data form).
+	Payload xml.TokenReader
+
+	// Lang is the language of the remote entity.
+	// It is the xml:lang attribute of the IQ, or the Handler's Lang if the IQ
+	// does not have one.
+	Lang string
+}
+
+// Printer returns a printer that can be used to translate text in the
+// response, such as the labels of a data form, into the language of the remote
+// entity.
+// For more information see the l10n package.
+func (r Request) Printer() *message.Printer {
+	return l10n.Printer(r.Lang)
+}
+
+// Result is the outcome of executing a single stage of a command.
+type Result struct {
+	// Status is the status of the command.
+	// If Status is empty, StatusCompleted is used unless the request was to
+	// cancel the command in which case StatusCanceled is used.
+	Status string
+
+	// Actions are the actions that the remote entity may take next.
+	// It is only used if Status is StatusExecuting and the remote entity may
+	// only request one of these actions (or cancel the command) in its next
+	// request.
+	// If Actions is zero, Complete is allowed.
+	Actions Actions
+
+	// Notes are included in the response before the payload.
+	Notes []Note
+
+	// Payload is included in the response and is normally a data form to be
+	// filled out by the remote entity or the results of the command.
+	Payload xml.TokenReader
+}
+
+// Executor executes commands.
+type Executor interface {
+	ExecuteCommand(Request) (Result, error)
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as
+// Executors.
+type ExecutorFunc func(Request) (Result, error)
+
+// ExecuteCommand calls f(req).
+func (f ExecutorFunc) ExecuteCommand(req Request) (Result, error) {
+	return f(req)
+}
+
+// Handle returns an option that registers a Handler for command execution
+// requests and advertises its commands to service discovery queries.
+func Handle(h *Handler) mux.Option {
+	return mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "command"}, h)
+}
+
+type registration struct {
+	name  string
+	exec  Executor
+	allow func(jid.JID) bool
+}
+
+type session struct {
+	node     string
+	from     jid.JID
+	actions  Actions
+	lastUsed time.Time
+}
+
+// Handler responds to requests to execute commands registered on it.
+// Each command is identified by a node and is executed by an Executor.
+//
+// For multi-stage commands, Handler generates the session ID and makes sure
+// that later stages are only accepted from the same entity, for the same
+// command, and with one of the actions allowed by the previous stage.
+// Executors that need to keep state between stages should key it by the
+// session ID and clean it up when the command completes or is canceled.
+//
+// The zero value is a Handler with no commands that is ready to use.
+type Handler struct {
+	// JID is the address advertised for the commands in response to service
+	// discovery requests.
+	// It should normally be set to the local address of the session.
+	JID jid.JID
+
+	// Timeout is the amount of time after which idle multi-stage command
+	// sessions are forgotten.
+	// If Timeout is zero, DefaultTimeout is used.
+	Timeout time.Duration
+
+	// Lang is the language used for the text of error responses and made
+	// available to executors if a request does not have an xml:lang attribute.
+	// It should normally be set to the language of the input stream (see the
+	// In method on xmpp.Session).
+	Lang string
+
+	mu       sync.Mutex
+	commands map[string]registration
+	sessions map[string]session
+}
+
+// Register adds a command with the provided node and human readable name.
+// If allow is not nil, only entities for which it returns true may execute the
+// command.
+// Registering a node that already exists replaces the previous command.
+func (h *Handler) Register(node, name string, allow func(jid.JID) bool, e Executor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.commands == nil {
+		h.commands = make(map[string]registration)
+	}
+	h.commands[node] = registration{name: name, exec: e, allow: allow}
+}
+
+// Unregister removes the command with the provided node.
+func (h *Handler) Unregister(node string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.commands, node)
+	for sid, sess := range h.sessions {
+		if sess.node == node {
+			delete(h.sessions, sid)
+		}
+	}
+}
+
+func cmdError(req *Request, typ stanza.ErrorType, cond stanza.Condition, appCond, text string) stanza.Error {
+	e := stanza.Error{Type: typ, Condition: cond, Text: l10n.Text(text, req.Lang)}
+	if appCond != "" {
+		e.AppCondition = stanza.AppCondition{XMLName: xml.Name{Space: NS, Local: appCond}}
+	}
+	return e
+}
+
+// HandleIQ implements mux.IQHandler.
+func (h *Handler) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	req := Request{
+		IQ:     iq,
+		Action: "execute",
+		Lang:   iq.Lang,
+	}
+	if req.Lang == "" {
+		req.Lang = h.Lang
+	}
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "node":
+			req.Node = a.Value
+		case "sessionid":
+			req.SID = a.Value
+		case "action":
+			if a.Value != "" {
+				req.Action = a.Value
+			}
+		}
+	}
+	payload, err := xmlstream.ReadAll(xmlstream.Inner(t))
+	if err != nil {
+		return err
+	}
+	req.Payload = tokens.Reader(payload)
+
+	reg, sessErr := h.start(&req)
+	if sessErr != nil {
+		_, err = xmlstream.Copy(t, iq.Error(*sessErr))
+		return err
+	}
+
+	res, err := reg.exec.ExecuteCommand(req)
+	if err != nil {
+		h.end(req.SID)
+		var se stanza.Error
+		if !errors.As(err, &se) {
+			se = cmdError(&req, stanza.Cancel, stanza.InternalServerError, "", "The command could not be executed")
+		}
+		_, err = xmlstream.Copy(t, iq.Error(se))
+		return err
+	}
+	if res.Status == "" {
+		res.Status = StatusCompleted
+		if req.Action == "cancel" {
+			res.Status = StatusCanceled
+		}
+	}
+	if res.Status == StatusExecuting {
+		if res.Actions == 0 {
+			res.Actions = Complete
+		}
+		h.update(req, res.Actions)
+	} else {
+		h.end(req.SID)
+	}
+
+	var inner []xml.TokenReader
+	if res.Status == StatusExecuting {
+		inner = append(inner, res.Actions.TokenReader())
+	}
+	for _, note := range res.Notes {
+		inner = append(inner, note.TokenReader())
+	}
+	if res.Payload != nil {
+		inner = append(inner, res.Payload)
+	}
+	_, err = xmlstream.Copy(t, iq.Result(xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{
+			Name: xml.Name{Space: NS, Local: "command"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "node"}, Value: req.Node},
+				{Name: xml.Name{Local: "sessionid"}, Value: req.SID},
+				{Name: xml.Name{Local: "status"}, Value: res.Status},
+			},
+		},
+	)))
+	return err
+}
+
+// start looks up the command and session for a request, generating a new
+// session ID if this is the first stage of the command.
+func (h *Handler) start(req *Request) (registration, *stanza.Error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	reg, ok := h.commands[req.Node]
+	if !ok {
+		e := cmdError(req, stanza.Cancel, stanza.ItemNotFound, "", "The command does not exist")
+		return reg, &e
+	}
+	if reg.allow != nil && !reg.allow(req.IQ.From) {
+		e := cmdError(req, stanza.Auth, stanza.Forbidden, "", "You are not allowed to execute this command")
+		return reg, &e
+	}
+
+	var action Actions
+	switch req.Action {
+	case "execute", "cancel":
+	case "prev":
+		action = Prev
+	case "next":
+		action = Next
+	case "complete":
+		action = Complete
+	default:
+		e := cmdError(req, stanza.Modify, stanza.BadRequest, "malformed-action", "The requested action is not valid")
+		return reg, &e
+	}
+
+	h.expire()
+	if req.SID == "" {
+		if req.Action != "execute" {
+			e := cmdError(req, stanza.Modify, stanza.BadRequest, "bad-sessionid", "The command session does not exist or has expired")
+			return reg, &e
+		}
+		req.SID = attr.RandomID()
+		return reg, nil
+	}
+	sess, ok := h.sessions[req.SID]
+	if !ok || sess.node != req.Node || !sess.from.Equal(req.IQ.From) {
+		e := cmdError(req, stanza.Modify, stanza.BadRequest, "bad-sessionid", "The command session does not exist or has expired")
+		return reg, &e
+	}
+	if req.Action == "execute" {
+		// The default action of a multi-stage command is the one advertised by
+		// the previous stage or next if there was none.
+		action = (sess.actions & Execute) >> 3
+		if action == 0 {
+			action = Next
+		}
+		req.Action = action.String()
+	}
+	if action != 0 && sess.actions&action == 0 {
+		e := cmdError(req, stanza.Modify, stanza.BadRequest, "bad-action", "The requested action is not allowed at this stage of the command")
+		return reg, &e
+	}
+	sess.lastUsed = time.Now()
+	h.sessions[req.SID] = sess
+	return reg, nil
+}
+
+// update records that a command is still executing and which actions are
+// allowed next.
+func (h *Handler) update(req Request, actions Actions) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sessions == nil {
+		h.sessions = make(map[string]session)
+	}
+	h.sessions[req.SID] = session{
+		node:     req.Node,
+		from:     req.IQ.From,
+		actions:  actions,
+		lastUsed: time.Now(),
+	}
+}
+
+func (h *Handler) end(sid string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, sid)
+}
+
+// expire removes idle sessions.
+// It must be called with the lock held.
+func (h *Handler) expire() {
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	for sid, sess := range h.sessions {
+		if time.Since(sess.lastUsed) > timeout {
+			delete(h.sessions, sid)
+		}
+	}
+}
+
+// ForItems implements items.Iter by listing the registered commands when
+// queried for the commands node.
+func (h *Handler) ForItems(node string, f func(items.Item) error) error {
+	if node != NS {
+		return nil
+	}
+	h.mu.Lock()
+	nodes := make([]string, 0, len(h.commands))
+	for n := range h.commands {
+		nodes = append(nodes, n)
+	}
+	regs := make(map[string]string, len(h.commands))
+	for n, reg := range h.commands {
+		regs[n] = reg.name
+	}
+	h.mu.Unlock()
+	sort.Strings(nodes)
+
+	for _, n := range nodes {
+		err := f(items.Item{
+			JID:  h.JID,
+			Node: n,
+			Name: regs[n],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) isCommand(node string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.commands[node]
+	return ok
+}
+
+// ForFeatures implements info.FeatureIter.
+// Commands are advertised on the root node and on the node of each command
+// along with support for data forms.
+func (h *Handler) ForFeatures(node string, f func(info.Feature) error) error {
+	switch {
+	case node == "":
+		return f(Feature)
+	case h.isCommand(node):
+		err := f(Feature)
+		if err != nil {
+			return err
+		}
+		return f(form.Feature)
+	}
+	return nil
+}
+
+// ForIdentities implements info.IdentityIter by returning the command node
+// identity for the node of each registered command.
+func (h *Handler) ForIdentities(node string, f func(info.Identity) error) error {
+	if node == "" || !h.isCommand(node) {
+		return nil
+	}
+	h.mu.Lock()
+	name := h.commands[node].name
+	h.mu.Unlock()
+	return f(info.Identity{
+		Category: "automation",
+		Type:     "command-node",
+		Name:     name,
+	})
+}
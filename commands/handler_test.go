@@ -0,0 +1,287 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package commands_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/commands"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/disco/items"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ mux.IQHandler     = (*commands.Handler)(nil)
+	_ items.Iter        = (*commands.Handler)(nil)
+	_ info.FeatureIter  = (*commands.Handler)(nil)
+	_ info.IdentityIter = (*commands.Handler)(nil)
+)
+
+// wizard is a multi-stage command that asks for a name and then confirms it.
+func wizard(req commands.Request) (commands.Result, error) {
+	switch req.Action {
+	case "execute":
+		return commands.Result{
+			Status:  commands.StatusExecuting,
+			Actions: commands.Next | commands.Complete | commands.Next<<3,
+			Payload: xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "stage1"}}),
+		}, nil
+	case "next":
+		return commands.Result{
+			Status:  commands.StatusExecuting,
+			Actions: commands.Prev | commands.Complete,
+			Payload: xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "stage2"}}),
+		}, nil
+	case "complete":
+		return commands.Result{
+			Notes: []commands.Note{{Type: commands.NoteInfo, Value: "Done"}},
+		}, nil
+	}
+	return commands.Result{}, nil
+}
+
+func newHandler() *commands.Handler {
+	h := &commands.Handler{JID: jid.MustParse("example.net")}
+	h.Register("wizard", "Wizard", nil, commands.ExecutorFunc(wizard))
+	h.Register("admin", "Admin", func(j jid.JID) bool {
+		return j.Bare().String() == "admin@example.net"
+	}, commands.ExecutorFunc(wizard))
+	h.Register("fail", "Fail", nil, commands.ExecutorFunc(func(commands.Request) (commands.Result, error) {
+		return commands.Result{}, errors.New("failed")
+	}))
+	return h
+}
+
+func TestExecuteMultiStage(t *testing.T) {
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(mux.New(stanza.NSClient, commands.Handle(newHandler()))))
+	defer cs.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	type stage struct {
+		status  string
+		payload string
+	}
+	var stages []stage
+	err := commands.Command{Node: "wizard"}.ForEach(ctx, nil, cs.Client, func(resp commands.Response, r xml.TokenReader) (commands.Command, xml.TokenReader, error) {
+		if resp.SID == "" {
+			t.Errorf("expected session ID in response")
+		}
+		var names string
+		iter := xmlstream.NewIter(r)
+		for iter.Next() {
+			start, _ := iter.Current()
+			if start != nil {
+				names += start.Name.Local + " "
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return commands.Command{}, nil, err
+		}
+		stages = append(stages, stage{status: resp.Status, payload: names})
+		switch len(stages) {
+		case 1:
+			return resp.Next(), nil, nil
+		case 2:
+			return resp.Complete(), nil, nil
+		}
+		return commands.Command{}, nil, nil
+	})
+	if err != nil {
+		t.Fatalf("error executing command: %v", err)
+	}
+	want := []stage{
+		{status: "executing", payload: "actions stage1 "},
+		{status: "executing", payload: "actions stage2 "},
+		{status: "completed", payload: "note "},
+	}
+	if len(stages) != len(want) {
+		t.Fatalf("wrong number of stages: want=%d, got=%d (%v)", len(want), len(stages), stages)
+	}
+	for i, s := range stages {
+		if s != want[i] {
+			t.Errorf("wrong stage %d: want=%v, got=%v", i, want[i], s)
+		}
+	}
+}
+
+var errorTestCases = [...]struct {
+	cmd     commands.Command
+	first   bool
+	cond    stanza.Condition
+	appCond string
+	lang    string
+	text    string
+}{
+	0: {
+		cmd:  commands.Command{Node: "unknown"},
+		cond: stanza.ItemNotFound,
+		lang: "en",
+		text: "The command does not exist",
+	},
+	1: {
+		cmd:  commands.Command{Node: "admin"},
+		cond: stanza.Forbidden,
+		lang: "en",
+		text: "You are not allowed to execute this command",
+	},
+	2: {
+		cmd:  commands.Command{Node: "fail"},
+		cond: stanza.InternalServerError,
+	},
+	3: {
+		cmd:     commands.Command{Node: "wizard", SID: "unknown", Action: "next"},
+		cond:    stanza.BadRequest,
+		appCond: "bad-sessionid",
+		lang:    "en",
+		text:    "The command session does not exist or has expired",
+	},
+	4: {
+		cmd:     commands.Command{Node: "wizard", Action: "jump"},
+		cond:    stanza.BadRequest,
+		appCond: "malformed-action",
+		lang:    "de",
+		text:    "Die angeforderte Aktion ist ungültig",
+	},
+	5: {
+		// The first stage of the wizard does not allow going back.
+		cmd:     commands.Command{Node: "wizard", Action: "prev"},
+		first:   true,
+		cond:    stanza.BadRequest,
+		appCond: "bad-action",
+		lang:    "fr",
+		text:    "L'action demandée n'est pas autorisée à cette étape de la commande",
+	},
+}
+
+func TestExecuteErrors(t *testing.T) {
+	for i, tc := range errorTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := newHandler()
+			h.Lang = tc.lang
+			cs := xmpptest.NewClientServer(xmpptest.ServerHandler(mux.New(stanza.NSClient, commands.Handle(h))))
+			defer cs.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			cmd := tc.cmd
+			if tc.first {
+				resp, r, err := commands.Command{Node: cmd.Node}.Execute(ctx, nil, cs.Client)
+				if err != nil {
+					t.Fatalf("error executing first stage: %v", err)
+				}
+				/* #nosec */
+				r.Close()
+				cmd.SID = resp.SID
+			}
+			_, r, err := cmd.Execute(ctx, nil, cs.Client)
+			if r != nil {
+				/* #nosec */
+				r.Close()
+			}
+			var se stanza.Error
+			if !errors.As(err, &se) {
+				t.Fatalf("expected stanza error, got %v", err)
+			}
+			if se.Condition != tc.cond {
+				t.Errorf("wrong condition: want=%s, got=%s", tc.cond, se.Condition)
+			}
+			if se.AppCondition.XMLName.Local != tc.appCond {
+				t.Errorf("wrong app condition: want=%q, got=%q", tc.appCond, se.AppCondition.XMLName.Local)
+			}
+			if tc.text != "" && se.Text[tc.lang] != tc.text {
+				t.Errorf("wrong text: want=%q, got=%v", tc.text, se.Text)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(mux.New(stanza.NSClient, commands.Handle(newHandler()))))
+	defer cs.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, r, err := commands.Command{Node: "wizard"}.Execute(ctx, nil, cs.Client)
+	if err != nil {
+		t.Fatalf("error executing command: %v", err)
+	}
+	/* #nosec */
+	r.Close()
+	resp, r, err = resp.Cancel().Execute(ctx, nil, cs.Client)
+	if err != nil {
+		t.Fatalf("error canceling command: %v", err)
+	}
+	/* #nosec */
+	r.Close()
+	if resp.Status != commands.StatusCanceled {
+		t.Errorf("wrong status: want=%s, got=%s", commands.StatusCanceled, resp.Status)
+	}
+
+	// The session should be gone after it was canceled.
+	_, r, err = resp.Next().Execute(ctx, nil, cs.Client)
+	if r != nil {
+		/* #nosec */
+		r.Close()
+	}
+	if !errors.Is(err, stanza.Error{Condition: stanza.BadRequest}) {
+		t.Errorf("expected bad request after cancel, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	m := mux.New(stanza.NSClient, commands.Handle(newHandler()), disco.Handle())
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(m))
+	defer cs.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	iter := commands.Fetch(ctx, jid.MustParse("example.net"), cs.Client)
+	var nodes []string
+	for iter.Next() {
+		cmd := iter.Command()
+		nodes = append(nodes, cmd.Node+":"+cmd.Name)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("error listing commands: %v", err)
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatalf("error closing iter: %v", err)
+	}
+	want := "[admin:Admin fail:Fail wizard:Wizard]"
+	if s := fmtNodes(nodes); s != want {
+		t.Errorf("wrong commands: want=%s, got=%s", want, s)
+	}
+
+	info, err := disco.MuxInfo(m, "wizard")
+	if err != nil {
+		t.Fatalf("error getting command info: %v", err)
+	}
+	if len(info.Identity) != 1 || info.Identity[0].Type != "command-node" {
+		t.Errorf("wrong identities for command node: %+v", info.Identity)
+	}
+}
+
+func fmtNodes(nodes []string) string {
+	s := "["
+	for i, n := range nodes {
+		if i > 0 {
+			s += " "
+		}
+		s += n
+	}
+	return s + "]"
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package component
+
+import (
+	"encoding/xml"
+	"strings"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/marshal"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Router is an xmpp.Handler for components that host more than one domain,
+// such as gateways that use a subdomain for each legacy network.
+// Stanzas are dispatched to the handler registered for the domain of their
+// "to" address, falling back to the handler for the closest parent domain and
+// then to the default handler.
+//
+// Because the server does not add a "from" attribute to stanzas sent by
+// components, stanzas written by handlers that do not have one are sent from
+// the address that the stanza being handled was sent to.
+//
+// Stanzas of type "error" are never passed to the domain handlers since they
+// are normally the server bouncing a stanza that the component sent.
+// Instead they are passed to Bounce so that the component does not respond
+// and start an error loop.
+//
+// The zero value is a Router with no handlers that drops all stanzas and
+// replies to IQs with an error.
+type Router struct {
+	// Bounce is called for each error stanza that was not a response to an IQ
+	// sent with the sessions SendIQ method.
+	// If Bounce is nil, error stanzas are ignored.
+	Bounce func(start xml.StartElement, err stanza.Error)
+
+	mu       sync.RWMutex
+	domains  map[string]xmpp.Handler
+	fallback xmpp.Handler
+}
+
+// Handle registers the handler for stanzas sent to the domainpart of addr or
+// any of its subdomains that do not have their own handler.
+// If addr is the zero JID, h becomes the default handler that is used when no
+// other handler matches.
+// If a handler already exists for the domain, it is replaced.
+func (r *Router) Handle(addr jid.JID, h xmpp.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	domain := addr.Domainpart()
+	if domain == "" {
+		r.fallback = h
+		return
+	}
+	if r.domains == nil {
+		r.domains = make(map[string]xmpp.Handler)
+	}
+	r.domains[domain] = h
+}
+
+// HandleFunc registers a function to handle stanzas sent to the domainpart of
+// addr.
+// See Handle for more information.
+func (r *Router) HandleFunc(addr jid.JID, h xmpp.HandlerFunc) {
+	r.Handle(addr, h)
+}
+
+// Remove unregisters the handler for the domainpart of addr.
+func (r *Router) Remove(addr jid.JID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	domain := addr.Domainpart()
+	if domain == "" {
+		r.fallback = nil
+		return
+	}
+	delete(r.domains, domain)
+}
+
+// Handler returns the handler that will be used for stanzas sent to addr.
+func (r *Router) Handler(addr jid.JID) (h xmpp.Handler, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	domain := addr.Domainpart()
+	for domain != "" {
+		if h, ok := r.domains[domain]; ok {
+			return h, true
+		}
+		idx := strings.IndexByte(domain, '.')
+		if idx == -1 {
+			break
+		}
+		domain = domain[idx+1:]
+	}
+	if r.fallback != nil {
+		return r.fallback, true
+	}
+	return nil, false
+}
+
+// HandleXMPP satisfies the xmpp.Handler interface.
+func (r *Router) HandleXMPP(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	_, typ := attr.Get(start.Attr, "type")
+	if typ == "error" {
+		if r.Bounce == nil {
+			return nil
+		}
+		stanzaErr, err := stanza.UnmarshalError(t)
+		if err != nil {
+			return err
+		}
+		r.Bounce(*start, stanzaErr)
+		return nil
+	}
+
+	_, to := attr.Get(start.Attr, "to")
+	addr, err := jid.Parse(to)
+	if err != nil {
+		addr = jid.JID{}
+	}
+	h, ok := r.Handler(addr)
+	if !ok {
+		// Let the session respond to IQs with the default error.
+		return nil
+	}
+	return h.HandleXMPP(&fromEncoder{TokenReadEncoder: t, from: to}, start)
+}
+
+// fromEncoder adds a "from" attribute to top level elements written by a
+// handler.
+type fromEncoder struct {
+	xmlstream.TokenReadEncoder
+	from  string
+	depth int
+}
+
+func (e *fromEncoder) EncodeToken(t xml.Token) error {
+	switch tok := t.(type) {
+	case xml.StartElement:
+		if e.depth == 0 {
+			t = setFrom(tok, e.from)
+		}
+		e.depth++
+	case xml.EndElement:
+		e.depth--
+	}
+	return e.TokenReadEncoder.EncodeToken(t)
+}
+
+func (e *fromEncoder) EncodeElement(v interface{}, start xml.StartElement) error {
+	return marshal.EncodeXMLElement(e, v, start)
+}
+
+func (e *fromEncoder) Encode(v interface{}) error {
+	return marshal.EncodeXML(e, v)
+}
+
+// setFrom adds a "from" attribute to start if it does not already have one.
+func setFrom(start xml.StartElement, from string) xml.StartElement {
+	if from == "" || !stanza.Is(start.Name, "") {
+		return start
+	}
+	if idx, _ := attr.Get(start.Attr, "from"); idx != -1 {
+		return start
+	}
+	start = start.Copy()
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "from"}, Value: from})
+	return start
+}
+
+// DefaultFrom returns a transformer that adds a "from" attribute to stanzas
+// that do not already have one.
+// It is meant to be used as an outgoing filter on component sessions so that
+// stanzas sent outside of a handler are sent from the component.
+func DefaultFrom(from jid.JID) xmlstream.Transformer {
+	fromStr := from.String()
+	return func(r xml.TokenReader) xml.TokenReader {
+		first := true
+		return xmlstream.ReaderFunc(func() (xml.Token, error) {
+			tok, err := r.Token()
+			if first && tok != nil {
+				first = false
+				if start, ok := tok.(xml.StartElement); ok {
+					tok = setFrom(start, fromStr)
+				}
+			}
+			return tok, err
+		})
+	}
+}
+
+// Serve adds a DefaultFrom filter for the sessions local address to s and
+// then serves it using r as the handler.
+func (r *Router) Serve(s *xmpp.Session) error {
+	s.AddOutgoingFilter(DefaultFrom(s.LocalAddr()))
+	return s.Serve(r)
+}
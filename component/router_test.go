@@ -0,0 +1,93 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package component_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/component"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestRouter(t *testing.T) {
+	const in = `<iq xmlns="jabber:client" type="get" id="1" to="irc.gw.example.net" from="me@example.net"><ping xmlns="urn:xmpp:ping"/></iq>` +
+		`<message xmlns="jabber:client" type="error" to="gw.example.net" from="example.net"><error type="cancel"><item-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error></message>` +
+		`<iq xmlns="jabber:client" type="get" id="2" to="other.example.org" from="me@example.net"><ping xmlns="urn:xmpp:ping"/></iq>`
+
+	out := &bytes.Buffer{}
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(in),
+		Writer: out,
+	})
+
+	var handled []string
+	var bounced []stanza.Error
+	r := &component.Router{
+		Bounce: func(start xml.StartElement, err stanza.Error) {
+			bounced = append(bounced, err)
+		},
+	}
+	r.HandleFunc(jid.MustParse("gw.example.net"), func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		handled = append(handled, iq.To.String())
+		_, err = xmlstream.Copy(t, iq.Result(nil))
+		return err
+	})
+	r.HandleFunc(jid.MustParse("unused.example.net"), func(xmlstream.TokenReadEncoder, *xml.StartElement) error {
+		t.Errorf("wrong handler called")
+		return nil
+	})
+
+	err := r.Serve(s)
+	if err != nil {
+		t.Fatalf("error serving: %v", err)
+	}
+	if len(handled) != 1 || handled[0] != "irc.gw.example.net" {
+		t.Errorf("wrong stanzas handled: %v", handled)
+	}
+	if len(bounced) != 1 || bounced[0].Condition != stanza.ItemNotFound {
+		t.Errorf("wrong bounced errors: %+v", bounced)
+	}
+	const want = `<iq xmlns="jabber:client" type="result" to="me@example.net" from="irc.gw.example.net" id="1"></iq>` +
+		`<iq xmlns="jabber:client" type="error" to="me@example.net" id="2" from="test@example.net"><error type="cancel"><service-unavailable xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></service-unavailable></error></iq>` +
+		`</stream:stream>`
+	if output := out.String(); output != want {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", want, output)
+	}
+}
+
+func TestRouterHandler(t *testing.T) {
+	r := &component.Router{}
+	if _, ok := r.Handler(jid.MustParse("example.net")); ok {
+		t.Errorf("empty router should not have handlers")
+	}
+	fallback := xmpp.HandlerFunc(func(xmlstream.TokenReadEncoder, *xml.StartElement) error { return nil })
+	r.Handle(jid.JID{}, fallback)
+	r.Handle(jid.MustParse("gw.example.net"), fallback)
+	if _, ok := r.Handler(jid.MustParse("a.b.gw.example.net")); !ok {
+		t.Errorf("expected subdomain to match parent handler")
+	}
+	if _, ok := r.Handler(jid.MustParse("example.org")); !ok {
+		t.Errorf("expected fallback handler to match")
+	}
+	r.Remove(jid.JID{})
+	if _, ok := r.Handler(jid.MustParse("example.org")); ok {
+		t.Errorf("expected fallback handler to be removed")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package compress implements stream compression and compression of XMPP
+// payloads.
+//
+// Stream compression is defined in XEP-0138: Stream Compression and
+// compresses everything sent over the stream after authentication.
+// It is negotiated using the stream feature returned by StreamFeature, for
+// example with the zlib method that all implementations support:
+//
+//	session, err := xmpp.DialClientSession(
+//		ctx, addr,
+//		xmpp.StartTLS(nil),
+//		xmpp.SASL("", pass, sasl.ScramSha256),
+//		compress.StreamFeature(compress.Zlib),
+//		xmpp.BindResource(),
+//	)
+//
+// Payload compression is meant for applications that send large custom
+// payloads (such as JSON containers) and want to keep the size of stanzas
+// down without compressing the entire stream.
+// Because it is not a standard protocol, both sides must support it.
+// Entities advertise the methods they support using service discovery and the
+// sender picks one that is supported by the recipient using Select.
+package compress // import "mellium.im/xmpp/compress"
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+)
+
+// NSPayload is the namespace used for compressed payloads.
+// Support for individual methods is advertised using features made up of the
+// namespace, a "#", and the name of the method.
+const NSPayload = "https://mellium.im/xmpp/compress"
+
+// Method is a compression algorithm.
+type Method interface {
+	// Name is the name of the method as it appears on the wire.
+	Name() string
+
+	// Compress returns a writer that compresses data written to it and writes
+	// it to w.
+	// Closing the returned writer must flush any buffered data but must not
+	// close w.
+	Compress(w io.Writer) (io.WriteCloser, error)
+
+	// Decompress returns a reader that decompresses data read from r.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+// A list of compression methods provided by this package.
+var (
+	// Gzip compresses data using the gzip file format from RFC 1952.
+	Gzip Method = gzipMethod{}
+
+	// Deflate compresses data using the raw DEFLATE format from RFC 1951.
+	Deflate Method = deflateMethod{}
+)
+
+type gzipMethod struct{}
+
+func (gzipMethod) Name() string { return "gzip" }
+
+func (gzipMethod) Compress(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipMethod) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type deflateMethod struct{}
+
+func (deflateMethod) Name() string { return "deflate" }
+
+func (deflateMethod) Compress(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+
+func (deflateMethod) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
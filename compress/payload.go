@@ -0,0 +1,187 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package compress
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/mux"
+)
+
+// Errors returned when reading compressed payloads.
+var (
+	ErrTooLarge      = errors.New("compress: decompressed payload exceeds limit")
+	ErrUnknownMethod = errors.New("compress: unknown compression method")
+)
+
+// Feature returns the service discovery feature that advertises support for
+// the provided method.
+func Feature(m Method) info.Feature {
+	return info.Feature{Var: NSPayload + "#" + m.Name()}
+}
+
+type features []Method
+
+func (f features) ForFeatures(_ string, iter func(info.Feature) error) error {
+	for _, m := range f {
+		err := iter(Feature(m))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Advertise returns an option that advertises support for receiving payloads
+// compressed using methods over service discovery.
+func Advertise(methods ...Method) mux.Option {
+	return mux.Feature(features(methods))
+}
+
+// Select returns the first of methods that is advertised in the info of the
+// entity that a payload will be sent to.
+// If the entity does not support any of the methods, ok is false and payloads
+// should be sent uncompressed.
+func Select(i disco.Info, methods ...Method) (m Method, ok bool) {
+	for _, m := range methods {
+		v := Feature(m).Var
+		for _, f := range i.Features {
+			if f.Var == v {
+				return m, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Payload returns an element with the provided name containing data.
+// If m is not nil, data is compressed using m and placed in a child element
+// qualified by NSPayload, otherwise data is included as character data.
+// This allows the recipient to match on the name of the payload as usual and
+// receive it using ReadPayload regardless of whether it was compressed or not.
+func Payload(name xml.Name, m Method, data []byte) (xml.TokenReader, error) {
+	start := xml.StartElement{Name: name}
+	if m == nil {
+		return xmlstream.Wrap(xmlstream.Token(xml.CharData(data)), start), nil
+	}
+
+	var buf bytes.Buffer
+	w, err := m.Compress(&buf)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return xmlstream.Wrap(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(base64.StdEncoding.EncodeToString(buf.Bytes()))),
+			xml.StartElement{
+				Name: xml.Name{Space: NSPayload, Local: "compressed"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "method"}, Value: m.Name()}},
+			},
+		),
+		start,
+	), nil
+}
+
+// ReadPayload reads the data from a payload created with Payload.
+// r should be positioned just after the start element of the payload and is
+// read until the end of the payload.
+// If the payload was compressed, it is decompressed using the method with the
+// same name from methods.
+// If the decompressed data would be larger than limit bytes, ErrTooLarge is
+// returned.
+func ReadPayload(r xml.TokenReader, limit int64, methods ...Method) ([]byte, error) {
+	var data []byte
+	iter := xmlstream.NewIter(r)
+	for iter.Next() {
+		start, inner := iter.Current()
+		if start == nil {
+			tok, err := inner.Token()
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if cdata, ok := tok.(xml.CharData); ok {
+				data = append(data, cdata...)
+				if int64(len(data)) > limit {
+					return nil, ErrTooLarge
+				}
+			}
+			continue
+		}
+		if start.Name.Space != NSPayload || start.Name.Local != "compressed" {
+			continue
+		}
+		_, name := attr.Get(start.Attr, "method")
+		var m Method
+		for _, method := range methods {
+			if method.Name() == name {
+				m = method
+				break
+			}
+		}
+		if m == nil {
+			return nil, ErrUnknownMethod
+		}
+		var encoded []byte
+		for {
+			tok, err := inner.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if cdata, ok := tok.(xml.CharData); ok {
+				encoded = append(encoded, cdata...)
+			}
+		}
+		decompressed, err := decompress(m, encoded, limit)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data[:0], decompressed...)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decompress(m Method, encoded []byte, limit int64) ([]byte, error) {
+	compressed := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(compressed, bytes.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	rc, err := m.Decompress(bytes.NewReader(compressed[:n]))
+	if err != nil {
+		return nil, err
+	}
+	/* #nosec */
+	defer rc.Close()
+	out, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
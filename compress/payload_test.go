@@ -0,0 +1,154 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package compress_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/compress"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var payloadTestCases = []compress.Method{
+	nil,
+	compress.Gzip,
+	compress.Deflate,
+}
+
+func TestRoundTrip(t *testing.T) {
+	name := xml.Name{Space: "urn:example", Local: "json"}
+	data := []byte(`{"payload":"` + strings.Repeat("abc", 1000) + `"}`)
+	for i, m := range payloadTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r, err := compress.Payload(name, m, data)
+			if err != nil {
+				t.Fatalf("error creating payload: %v", err)
+			}
+			var buf strings.Builder
+			e := xml.NewEncoder(&buf)
+			_, err = xmlstream.Copy(e, r)
+			if err != nil {
+				t.Fatalf("error encoding payload: %v", err)
+			}
+			err = e.Flush()
+			if err != nil {
+				t.Fatalf("error flushing payload: %v", err)
+			}
+			if m != nil && buf.Len() >= len(data) {
+				t.Errorf("payload was not compressed: got %d bytes from %d", buf.Len(), len(data))
+			}
+
+			d := xml.NewDecoder(strings.NewReader(buf.String()))
+			tok, err := d.Token()
+			if err != nil {
+				t.Fatalf("error popping start token: %v", err)
+			}
+			if start := tok.(xml.StartElement); start.Name != name {
+				t.Errorf("wrong payload name: want=%v, got=%v", name, start.Name)
+			}
+			out, err := compress.ReadPayload(d, int64(len(data)), compress.Gzip, compress.Deflate)
+			if err != nil {
+				t.Fatalf("error reading payload: %v", err)
+			}
+			if !bytes.Equal(out, data) {
+				t.Errorf("wrong data: want=%q, got=%q", data, out)
+			}
+		})
+	}
+}
+
+func readPayload(t *testing.T, s string, limit int64, methods ...compress.Method) ([]byte, error) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(s))
+	_, err := d.Token()
+	if err != nil {
+		t.Fatalf("error popping start token: %v", err)
+	}
+	return compress.ReadPayload(d, limit, methods...)
+}
+
+func TestReadPayloadErrors(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 100)
+	r, err := compress.Payload(xml.Name{Local: "data"}, compress.Gzip, data)
+	if err != nil {
+		t.Fatalf("error creating payload: %v", err)
+	}
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	_, err = xmlstream.Copy(e, r)
+	if err != nil {
+		t.Fatalf("error encoding payload: %v", err)
+	}
+	err = e.Flush()
+	if err != nil {
+		t.Fatalf("error flushing payload: %v", err)
+	}
+
+	_, err = readPayload(t, buf.String(), 10, compress.Gzip)
+	if !errors.Is(err, compress.ErrTooLarge) {
+		t.Errorf("wrong error for large compressed payload: want=%v, got=%v", compress.ErrTooLarge, err)
+	}
+	_, err = readPayload(t, `<data>`+string(data)+`</data>`, 10)
+	if !errors.Is(err, compress.ErrTooLarge) {
+		t.Errorf("wrong error for large plain payload: want=%v, got=%v", compress.ErrTooLarge, err)
+	}
+	_, err = readPayload(t, buf.String(), 1000, compress.Deflate)
+	if !errors.Is(err, compress.ErrUnknownMethod) {
+		t.Errorf("wrong error for unknown method: want=%v, got=%v", compress.ErrUnknownMethod, err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	i := disco.Info{
+		Features: []info.Feature{
+			{Var: "urn:example"},
+			compress.Feature(compress.Deflate),
+		},
+	}
+	m, ok := compress.Select(i, compress.Gzip, compress.Deflate)
+	if !ok || m != compress.Deflate {
+		t.Errorf("wrong method selected: want=%v, got=%v (%t)", compress.Deflate, m, ok)
+	}
+	_, ok = compress.Select(disco.Info{}, compress.Gzip, compress.Deflate)
+	if ok {
+		t.Errorf("did not expect method to be selected without features")
+	}
+}
+
+func TestAdvertise(t *testing.T) {
+	m := mux.New(stanza.NSClient, compress.Advertise(compress.Gzip, compress.Deflate))
+	var features []string
+	err := m.ForFeatures("", func(f info.Feature) error {
+		features = append(features, f.Var)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error iterating over features: %v", err)
+	}
+	for _, want := range []string{
+		compress.NSPayload + "#gzip",
+		compress.NSPayload + "#deflate",
+	} {
+		var found bool
+		for _, f := range features {
+			if f == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("feature %q not advertised, got %v", want, features)
+		}
+	}
+}
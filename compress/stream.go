@@ -0,0 +1,281 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package compress
+
+import (
+	"compress/zlib"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+)
+
+// Namespaces used by stream compression.
+const (
+	NSFeatures = "http://jabber.org/features/compress"
+	NSProtocol = "http://jabber.org/protocol/compress"
+)
+
+// Zlib compresses data using the zlib format from RFC 1950.
+// It is the method that all entities that support stream compression are
+// required to implement.
+var Zlib Method = zlibMethod{}
+
+type zlibMethod struct{}
+
+func (zlibMethod) Name() string { return "zlib" }
+
+func (zlibMethod) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+func (zlibMethod) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+// Errors returned when negotiating stream compression.
+var (
+	ErrNoFlush = errors.New("compress: method does not support flushing")
+)
+
+// flusher is implemented by the writers returned by methods that can be used
+// for stream compression.
+type flusher interface {
+	Flush() error
+}
+
+// StreamFeature returns a stream feature that negotiates stream compression as
+// defined in XEP-0138: Stream Compression using one of methods, in order of
+// preference.
+// The writers returned by the methods must have a "Flush() error" method that
+// writes any buffered data without ending the compressed stream, as the
+// writers in the standard library compression packages do.
+//
+// The feature is only advertised after the stream has been authenticated and
+// before resource binding, and once negotiated the stream is restarted over
+// the compressed connection and the Compressed state bit is set.
+// If the receiving entity does not support any of the methods or the attempt
+// to set up compression fails, the session continues uncompressed.
+func StreamFeature(methods ...Method) xmpp.StreamFeature {
+	return xmpp.StreamFeature{
+		Name:       xml.Name{Space: NSFeatures, Local: "compression"},
+		SelectNS:   NSProtocol,
+		Necessary:  xmpp.Authn,
+		Prohibited: xmpp.Compressed | xmpp.Ready,
+		List: func(_ context.Context, e xmlstream.TokenWriter, start xml.StartElement) (bool, error) {
+			err := e.EncodeToken(start)
+			if err != nil {
+				return false, err
+			}
+			for _, m := range methods {
+				_, err = xmlstream.Copy(e, xmlstream.Wrap(
+					xmlstream.Token(xml.CharData(m.Name())),
+					xml.StartElement{Name: xml.Name{Local: "method"}},
+				))
+				if err != nil {
+					return false, err
+				}
+			}
+			return false, e.EncodeToken(start.End())
+		},
+		Parse: func(_ context.Context, d *xml.Decoder, start *xml.StartElement) (bool, interface{}, error) {
+			parsed := struct {
+				XMLName xml.Name `xml:"http://jabber.org/features/compress compression"`
+				Methods []string `xml:"http://jabber.org/features/compress method"`
+			}{}
+			err := d.DecodeElement(&parsed, start)
+			return false, parsed.Methods, err
+		},
+		Negotiate: func(ctx context.Context, session *xmpp.Session, data interface{}) (xmpp.SessionState, io.ReadWriter, error) {
+			if session.State()&xmpp.Received == xmpp.Received {
+				return negotiateServer(session, methods)
+			}
+			remote, _ := data.([]string)
+			return negotiateClient(session, methods, remote)
+		},
+	}
+}
+
+func negotiateServer(session *xmpp.Session, methods []Method) (xmpp.SessionState, io.ReadWriter, error) {
+	r := session.TokenReader()
+	/* #nosec */
+	defer r.Close()
+	d := xml.NewTokenDecoder(r)
+	tok, err := d.Token()
+	if err != nil {
+		return 0, nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok || start.Name.Space != NSProtocol || start.Name.Local != "compress" {
+		return 0, nil, fmt.Errorf("compress: unexpected payload %T during negotiation", tok)
+	}
+	req := struct {
+		Method string `xml:"method"`
+	}{}
+	err = d.DecodeElement(&req, &start)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var selected Method
+	for _, m := range methods {
+		if m.Name() == req.Method {
+			selected = m
+			break
+		}
+	}
+	if selected == nil {
+		return 0, nil, sendFailure(session, "unsupported-method")
+	}
+	conn, err := newConn(session.Conn(), selected)
+	if err != nil {
+		return 0, nil, sendFailure(session, "setup-failed")
+	}
+
+	w := session.TokenWriter()
+	/* #nosec */
+	defer w.Close()
+	_, err = xmlstream.Copy(w, xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NSProtocol, Local: "compressed"},
+	}))
+	if err != nil {
+		return 0, nil, err
+	}
+	err = w.Flush()
+	if err != nil {
+		return 0, nil, err
+	}
+	return xmpp.Compressed, conn, nil
+}
+
+func negotiateClient(session *xmpp.Session, methods []Method, remote []string) (xmpp.SessionState, io.ReadWriter, error) {
+	var selected Method
+selectmethod:
+	for _, m := range methods {
+		for _, name := range remote {
+			if m.Name() == name {
+				selected = m
+				break selectmethod
+			}
+		}
+	}
+	// If the receiving entity does not support any of our methods, continue
+	// without compression.
+	if selected == nil {
+		return 0, nil, nil
+	}
+	conn, err := newConn(session.Conn(), selected)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	w := session.TokenWriter()
+	/* #nosec */
+	defer w.Close()
+	_, err = xmlstream.Copy(w, xmlstream.Wrap(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(selected.Name())),
+			xml.StartElement{Name: xml.Name{Local: "method"}},
+		),
+		xml.StartElement{Name: xml.Name{Space: NSProtocol, Local: "compress"}},
+	))
+	if err != nil {
+		return 0, nil, err
+	}
+	err = w.Flush()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r := session.TokenReader()
+	/* #nosec */
+	defer r.Close()
+	d := xml.NewTokenDecoder(r)
+	tok, err := d.Token()
+	if err != nil {
+		return 0, nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok || start.Name.Space != NSProtocol {
+		return 0, nil, fmt.Errorf("compress: unexpected payload %T during negotiation", tok)
+	}
+	err = d.Skip()
+	if err != nil {
+		return 0, nil, err
+	}
+	switch start.Name.Local {
+	case "compressed":
+		return xmpp.Compressed, conn, nil
+	case "failure":
+		// Compression is optional so if it fails we continue without it.
+		return 0, nil, nil
+	}
+	return 0, nil, fmt.Errorf("compress: unexpected element %s during negotiation", start.Name.Local)
+}
+
+func sendFailure(session *xmpp.Session, condition string) error {
+	w := session.TokenWriter()
+	/* #nosec */
+	defer w.Close()
+	_, err := xmlstream.Copy(w, xmlstream.Wrap(
+		xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Local: condition}}),
+		xml.StartElement{Name: xml.Name{Space: NSProtocol, Local: "failure"}},
+	))
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// conn compresses data written to and decompresses data read from an
+// underlying connection.
+type conn struct {
+	rw io.ReadWriter
+	m  Method
+	r  io.ReadCloser
+	w  io.WriteCloser
+	f  flusher
+}
+
+func newConn(rw io.ReadWriter, m Method) (*conn, error) {
+	w, err := m.Compress(rw)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := w.(flusher)
+	if !ok {
+		return nil, ErrNoFlush
+	}
+	return &conn{rw: rw, m: m, w: w, f: f}, nil
+}
+
+// Read decompresses data from the underlying connection.
+// The decompressor is created on the first read because some formats read a
+// header when they are created and the remote entity will not send anything
+// until the stream has been restarted.
+func (c *conn) Read(p []byte) (int, error) {
+	if c.r == nil {
+		r, err := c.m.Decompress(c.rw)
+		if err != nil {
+			return 0, err
+		}
+		c.r = r
+	}
+	return c.r.Read(p)
+}
+
+// Write compresses p and flushes it to the underlying connection so that the
+// remote entity can process it immediately.
+func (c *conn) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, c.f.Flush()
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package compress_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/compress"
+	"mellium.im/xmpp/jid"
+)
+
+var streamTestCases = [...]struct {
+	client     []compress.Method
+	server     []compress.Method
+	compressed bool
+}{
+	0: {
+		client:     []compress.Method{compress.Zlib},
+		server:     []compress.Method{compress.Zlib},
+		compressed: true,
+	},
+	1: {
+		client:     []compress.Method{compress.Deflate, compress.Zlib},
+		server:     []compress.Method{compress.Zlib, compress.Deflate},
+		compressed: true,
+	},
+	2: {
+		client: []compress.Method{compress.Deflate},
+		server: []compress.Method{compress.Zlib},
+	},
+}
+
+func TestStreamFeature(t *testing.T) {
+	for i, tc := range streamTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			clientConn, serverConn := net.Pipe()
+			defer clientConn.Close()
+			defer serverConn.Close()
+
+			negotiator := func(methods []compress.Method) xmpp.Negotiator {
+				return xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+					return xmpp.StreamConfig{
+						Features: []xmpp.StreamFeature{
+							xmpp.BindResource(),
+							compress.StreamFeature(methods...),
+						},
+					}
+				})
+			}
+			serverC := make(chan *xmpp.Session, 1)
+			go func() {
+				s, err := xmpp.ReceiveSession(ctx, serverConn, xmpp.Secure|xmpp.Authn, negotiator(tc.server))
+				if err != nil {
+					t.Errorf("error receiving session: %v", err)
+				}
+				serverC <- s
+			}()
+			clientJID := jid.MustParse("me@example.net")
+			s, err := xmpp.NewSession(ctx, clientJID.Domain(), clientJID, clientConn, xmpp.Secure|xmpp.Authn, negotiator(tc.client))
+			if err != nil {
+				t.Fatalf("error negotiating session: %v", err)
+			}
+			serverSession := <-serverC
+			if serverSession == nil {
+				t.FailNow()
+			}
+
+			for _, sess := range []*xmpp.Session{s, serverSession} {
+				if compressed := sess.State()&xmpp.Compressed == xmpp.Compressed; compressed != tc.compressed {
+					t.Errorf("wrong compressed state: want=%t, got=%t", tc.compressed, compressed)
+				}
+				if restarts := sess.StreamRestarts(); (restarts == 1) != tc.compressed {
+					t.Errorf("unexpected number of stream restarts: %d", restarts)
+				}
+			}
+		})
+	}
+}
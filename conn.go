@@ -9,11 +9,13 @@ import (
 	"crypto/tls"
 	"io"
 	"net"
+	"sync"
 	"time"
 )
 
 var _ tlsConn = (*teeConn)(nil)
 var _ tlsConn = (*conn)(nil)
+var _ tlsConn = (*coalesceConn)(nil)
 
 type tlsConn interface {
 	ConnectionState() tls.ConnectionState
@@ -201,3 +203,174 @@ func (tc teeConn) Read(p []byte) (int, error) {
 	}
 	return tc.teeReader.Read(p)
 }
+
+// Default thresholds used by WriteCoalescing if none are set.
+const (
+	DefaultCoalesceSize  = 4096
+	DefaultCoalesceDelay = time.Millisecond
+)
+
+// WriteCoalescing configures a session to buffer small writes and send them to
+// the underlying connection together, reducing the number of system calls made
+// by chatty sessions at the cost of a small amount of latency.
+//
+// Buffered data is written before the connection is read from, closed, or has
+// its deadlines changed.
+// Because writes are delayed, a write deadline applies to buffered data when it
+// is written to the underlying connection and not when Write is called.
+type WriteCoalescing struct {
+	// Size is the number of buffered bytes after which the buffer is written
+	// immediately.
+	// If Size is zero, DefaultCoalesceSize is used.
+	Size int
+
+	// Delay is the longest amount of time that a write will be buffered before
+	// it is sent.
+	// If Delay is zero, DefaultCoalesceDelay is used.
+	Delay time.Duration
+}
+
+// coalesceConn is a net.Conn that buffers writes until enough data has been
+// written or a delay has passed and then writes them to the underlying
+// connection in a single call.
+type coalesceConn struct {
+	net.Conn
+	tlsConn *tls.Conn
+	size    int
+	delay   time.Duration
+
+	mu      sync.Mutex
+	buf     []byte
+	timer   *time.Timer
+	err     error
+	stopped bool
+}
+
+func newCoalesceConn(c net.Conn, cfg WriteCoalescing) *coalesceConn {
+	cc := &coalesceConn{
+		Conn:  c,
+		size:  cfg.Size,
+		delay: cfg.Delay,
+	}
+	if cc.size <= 0 {
+		cc.size = DefaultCoalesceSize
+	}
+	if cc.delay <= 0 {
+		cc.delay = DefaultCoalesceDelay
+	}
+	cc.tlsConn, _ = c.(*tls.Conn)
+	return cc
+}
+
+func (cc *coalesceConn) ConnectionState() tls.ConnectionState {
+	if cc.tlsConn == nil {
+		return tls.ConnectionState{}
+	}
+	return cc.tlsConn.ConnectionState()
+}
+
+// flush writes any buffered data.
+// The lock must be held when calling flush.
+func (cc *coalesceConn) flush() error {
+	if cc.timer != nil {
+		cc.timer.Stop()
+		cc.timer = nil
+	}
+	if cc.err != nil || len(cc.buf) == 0 {
+		return cc.err
+	}
+	_, cc.err = cc.Conn.Write(cc.buf)
+	cc.buf = cc.buf[:0]
+	return cc.err
+}
+
+// stop flushes any buffered data and causes future writes to be passed through
+// to the underlying connection without buffering.
+// This is used when a new layer (eg. TLS) is negotiated on top of the
+// connection so that its writes are not delayed twice.
+func (cc *coalesceConn) stop() error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.stopped = true
+	return cc.flush()
+}
+
+func (cc *coalesceConn) Write(p []byte) (int, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.err != nil {
+		return 0, cc.err
+	}
+	if cc.stopped {
+		return cc.Conn.Write(p)
+	}
+	cc.buf = append(cc.buf, p...)
+	if len(cc.buf) >= cc.size {
+		err := cc.flush()
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cc.timer == nil {
+		cc.timer = time.AfterFunc(cc.delay, func() {
+			cc.mu.Lock()
+			defer cc.mu.Unlock()
+			/* #nosec */
+			cc.flush()
+		})
+	}
+	return len(p), nil
+}
+
+// Read writes any buffered data before reading from the underlying connection
+// since the remote entity is often waiting for it before it will respond (eg.
+// during stream negotiation).
+func (cc *coalesceConn) Read(p []byte) (int, error) {
+	cc.mu.Lock()
+	err := cc.flush()
+	cc.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return cc.Conn.Read(p)
+}
+
+// SetDeadline writes any buffered data before setting the read and write
+// deadlines on the underlying connection so that data buffered before the
+// deadline is changed is not written under the new deadline.
+func (cc *coalesceConn) SetDeadline(t time.Time) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	err := cc.flush()
+	if err != nil {
+		return err
+	}
+	return cc.Conn.SetDeadline(t)
+}
+
+// SetWriteDeadline writes any buffered data before setting the write deadline
+// on the underlying connection.
+// Writes that are buffered after the deadline is set are subject to it when
+// they are eventually flushed, not when they are written.
+func (cc *coalesceConn) SetWriteDeadline(t time.Time) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	err := cc.flush()
+	if err != nil {
+		return err
+	}
+	return cc.Conn.SetWriteDeadline(t)
+}
+
+// Close writes any buffered data and closes the underlying connection.
+func (cc *coalesceConn) Close() error {
+	cc.mu.Lock()
+	err := cc.flush()
+	cc.mu.Unlock()
+	closeErr := cc.Conn.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
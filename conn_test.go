@@ -0,0 +1,235 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// countWriter records the number of calls to Write.
+type countWriter struct {
+	sync.Mutex
+	strings.Builder
+	writes int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.writes++
+	return w.Builder.Write(p)
+}
+
+func (w *countWriter) stats() (int, string) {
+	w.Lock()
+	defer w.Unlock()
+	return w.writes, w.String()
+}
+
+var coalesceTestCases = [...]struct {
+	cfg        xmpp.WriteCoalescing
+	tee        bool
+	sentWrites int
+	wantWrites int
+}{
+	0: {
+		// The stream header is written before reading the response, but the
+		// messages are not written until the connection is closed.
+		cfg:        xmpp.WriteCoalescing{Delay: time.Hour, Size: 1 << 20},
+		sentWrites: 1,
+		wantWrites: 2,
+	},
+	1: {
+		cfg:        xmpp.WriteCoalescing{Delay: time.Hour, Size: 1 << 20},
+		tee:        true,
+		sentWrites: 1,
+		wantWrites: 2,
+	},
+	2: {
+		// Every flush exceeds the size so it is written immediately.
+		cfg:        xmpp.WriteCoalescing{Delay: time.Hour, Size: 1},
+		sentWrites: 6,
+		wantWrites: 6,
+	},
+}
+
+func TestCoalesce(t *testing.T) {
+	for i, tc := range coalesceTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			w := &countWriter{}
+			var tee strings.Builder
+			cfg := tc.cfg
+			negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+				sc := xmpp.StreamConfig{
+					Features: []xmpp.StreamFeature{readyFeature},
+					Coalesce: &cfg,
+				}
+				if tc.tee {
+					sc.TeeOut = &tee
+				}
+				return sc
+			})
+			rw := struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(`<stream:stream id='316732270768047465' version='1.0' xml:lang='en' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:server'><stream:features><ready xmlns='urn:example'/></stream:features>`),
+				Writer: w,
+			}
+			session, err := xmpp.NewSession(context.Background(), jid.JID{}, jid.JID{}, rw, xmpp.S2S, negotiator)
+			if err != nil {
+				t.Fatalf("error negotiating session: %v", err)
+			}
+
+			const msgs = 5
+			for n := 0; n < msgs; n++ {
+				err = session.Send(context.Background(), stanza.Message{
+					To:   jid.MustParse("romeo@example.net"),
+					Type: stanza.ChatMessage,
+				}.Wrap(xmlstream.Wrap(
+					xmlstream.Token(xml.CharData("Wherefore art thou?")),
+					xml.StartElement{Name: xml.Name{Local: "body"}},
+				)))
+				if err != nil {
+					t.Fatalf("error sending message %d: %v", n, err)
+				}
+			}
+			if writes, _ := w.stats(); writes != tc.sentWrites {
+				t.Errorf("wrong number of writes before close: want=%d, got=%d", tc.sentWrites, writes)
+			}
+
+			err = session.Conn().Close()
+			if err != nil {
+				t.Fatalf("error closing conn: %v", err)
+			}
+			writes, out := w.stats()
+			if writes != tc.wantWrites {
+				t.Errorf("wrong number of writes: want=%d, got=%d", tc.wantWrites, writes)
+			}
+			if n := strings.Count(out, "Wherefore art thou?"); n != msgs {
+				t.Errorf("wrong number of messages written: want=%d, got=%d", msgs, n)
+			}
+			if !strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?><stream:stream`) {
+				t.Errorf("stream header was not written first: %q", out)
+			}
+			if tc.tee && tee.String() != out {
+				t.Errorf("tee did not match output:\nwant=%q,\n got=%q", out, tee.String())
+			}
+		})
+	}
+}
+
+func TestCoalesceDelay(t *testing.T) {
+	w := &countWriter{}
+	negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+		return xmpp.StreamConfig{
+			Features: []xmpp.StreamFeature{readyFeature},
+			Coalesce: &xmpp.WriteCoalescing{Delay: 10 * time.Millisecond},
+		}
+	})
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(`<stream:stream id='316732270768047465' version='1.0' xml:lang='en' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:server'><stream:features><ready xmlns='urn:example'/></stream:features>`),
+		Writer: w,
+	}
+	session, err := xmpp.NewSession(context.Background(), jid.JID{}, jid.JID{}, rw, xmpp.S2S, negotiator)
+	if err != nil {
+		t.Fatalf("error negotiating session: %v", err)
+	}
+	err = session.Send(context.Background(), stanza.Message{Type: stanza.ChatMessage}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		writes, out := w.stats()
+		if writes == 2 && strings.HasSuffix(out, `</message>`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for delayed write: writes=%d, out=%q", writes, out)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// deadlineConn is a net.Conn that records the number of writes made before
+// each call to SetWriteDeadline.
+type deadlineConn struct {
+	net.Conn
+	r         io.Reader
+	w         *countWriter
+	deadlines []int
+}
+
+func (c *deadlineConn) Read(p []byte) (int, error)  { return c.r.Read(p) }
+func (c *deadlineConn) Write(p []byte) (int, error) { return c.w.Write(p) }
+func (c *deadlineConn) Close() error                { return nil }
+
+func (c *deadlineConn) SetDeadline(time.Time) error {
+	return c.SetWriteDeadline(time.Time{})
+}
+
+func (c *deadlineConn) SetWriteDeadline(time.Time) error {
+	writes, _ := c.w.stats()
+	c.deadlines = append(c.deadlines, writes)
+	return nil
+}
+
+func TestCoalesceDeadline(t *testing.T) {
+	for _, setDeadline := range []string{"SetDeadline", "SetWriteDeadline"} {
+		t.Run(setDeadline, func(t *testing.T) {
+			conn := &deadlineConn{
+				r: strings.NewReader(`<stream:stream id='316732270768047465' version='1.0' xml:lang='en' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:server'><stream:features><ready xmlns='urn:example'/></stream:features>`),
+				w: &countWriter{},
+			}
+			negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+				return xmpp.StreamConfig{
+					Features: []xmpp.StreamFeature{readyFeature},
+					Coalesce: &xmpp.WriteCoalescing{Delay: time.Hour, Size: 1 << 20},
+				}
+			})
+			session, err := xmpp.NewSession(context.Background(), jid.JID{}, jid.JID{}, conn, xmpp.S2S, negotiator)
+			if err != nil {
+				t.Fatalf("error negotiating session: %v", err)
+			}
+			err = session.Send(context.Background(), stanza.Message{Type: stanza.ChatMessage}.Wrap(nil))
+			if err != nil {
+				t.Fatalf("error sending message: %v", err)
+			}
+			if writes, _ := conn.w.stats(); writes != 1 {
+				t.Fatalf("message was not buffered: writes=%d", writes)
+			}
+
+			conn.deadlines = nil
+			if setDeadline == "SetDeadline" {
+				err = session.Conn().SetDeadline(time.Now().Add(time.Minute))
+			} else {
+				err = session.Conn().SetWriteDeadline(time.Now().Add(time.Minute))
+			}
+			if err != nil {
+				t.Fatalf("error setting deadline: %v", err)
+			}
+			if len(conn.deadlines) != 1 || conn.deadlines[0] != 2 {
+				t.Errorf("buffered message was not written before the deadline was set: %v", conn.deadlines)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h *Handler"
+
+// Package correction implements XEP-0308: Last Message Correction.
+//
+// A correction is a message that replaces the body of a previously sent
+// message.
+// The replaced message is referenced by its ID, so applications that support
+// corrections should store the IDs of the messages in their history.
+package correction // import "mellium.im/xmpp/correction"
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/stanza"
+)
+
+// NS is the namespace used by this package.
+const NS = "urn:xmpp:message-correct:0"
+
+// Replace indicates that the message containing it is a correction of the
+// message with the given ID.
+// The zero value marshals to nothing, which allows it to be used to mean that
+// the message is not a correction.
+type Replace struct {
+	ID string
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (r Replace) TokenReader() xml.TokenReader {
+	if r.ID == "" {
+		return tokens.Reader(nil)
+	}
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "replace"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: r.ID}},
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (r Replace) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, r.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (r Replace) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := r.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (r *Replace) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	r.ID = ""
+	for _, a := range start.Attr {
+		if a.Name.Local == "id" {
+			r.ID = a.Value
+			break
+		}
+	}
+	return d.Skip()
+}
+
+// Insert returns a transformer that marks any message read through it that
+// is not an error as a correction of the message with the given ID.
+// Messages that are already corrections are not modified.
+func Insert(id string) xmlstream.Transformer {
+	return stanza.AppendToMessage("", xml.Name{Space: NS, Local: "replace"}, Replace{ID: id}.TokenReader)
+}
+
+// Send sends a message with the provided body that corrects the message with
+// the given ID.
+// The message should be sent to the same address as the original message.
+func Send(ctx context.Context, s *xmpp.Session, msg stanza.Message, id, body string) error {
+	return s.Send(ctx, msg.Wrap(xmlstream.MultiReader(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(body)),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		),
+		Replace{ID: id}.TokenReader(),
+	)))
+}
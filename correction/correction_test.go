@@ -0,0 +1,122 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package correction_test
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/correction"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xmlstream.Marshaler = correction.Replace{}
+	_ xmlstream.WriterTo  = correction.Replace{}
+)
+
+var encodingTestCases = []xmpptest.EncodingTestCase{
+	0: {
+		Value: &correction.Replace{ID: "bad1"},
+		XML:   `<replace xmlns="urn:xmpp:message-correct:0" id="bad1"></replace>`,
+	},
+	1: {
+		Value:       &correction.Replace{},
+		XML:         ``,
+		NoUnmarshal: true,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	xmpptest.RunEncodingTests(t, encodingTestCases)
+}
+
+var insertTestCases = [...]struct {
+	in  string
+	out string
+}{
+	0: {
+		in:  `<message xmlns="jabber:client"><body>But soft!</body></message>`,
+		out: `<message xmlns="jabber:client"><body xmlns="jabber:client">But soft!</body><replace xmlns="urn:xmpp:message-correct:0" id="bad1"></replace></message>`,
+	},
+	1: {
+		in:  `<message xmlns="jabber:client" type="error"><body>But soft!</body></message>`,
+		out: `<message xmlns="jabber:client" type="error"><body xmlns="jabber:client">But soft!</body></message>`,
+	},
+	2: {
+		in:  `<message xmlns="jabber:client"><replace xmlns="urn:xmpp:message-correct:0" id="other"/></message>`,
+		out: `<message xmlns="jabber:client"><replace xmlns="urn:xmpp:message-correct:0" id="other"></replace></message>`,
+	},
+	3: {
+		in:  `<iq xmlns="jabber:client"/>`,
+		out: `<iq xmlns="jabber:client"></iq>`,
+	},
+}
+
+func TestInsert(t *testing.T) {
+	for i, tc := range insertTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r := correction.Insert("bad1")(xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(xml.NewDecoder(strings.NewReader(tc.in))))
+			var b strings.Builder
+			e := xml.NewEncoder(&b)
+			_, err := xmlstream.Copy(e, r)
+			if err != nil {
+				t.Fatalf("error copying tokens: %v", err)
+			}
+			if err = e.Flush(); err != nil {
+				t.Fatalf("error flushing: %v", err)
+			}
+			if out := b.String(); out != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	corrections := make(chan correction.Correction, 1)
+	h := &correction.Handler{
+		Corrected: func(_ stanza.Message, c correction.Correction) {
+			corrections <- c
+		},
+	}
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSClient, correction.Handle(h))),
+	)
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := correction.Send(ctx, s.Server, stanza.Message{
+		XMLName: xml.Name{Space: stanza.NSClient, Local: "message"},
+		To:      jid.MustParse("juliet@capulet.net/balcony"),
+		Type:    stanza.ChatMessage,
+	}, "bad1", "But soft, what light through yonder window breaks?")
+	if err != nil {
+		t.Fatalf("error sending correction: %v", err)
+	}
+	want := correction.Correction{
+		ID:   "bad1",
+		Body: "But soft, what light through yonder window breaks?",
+	}
+	select {
+	case c := <-corrections:
+		if c != want {
+			t.Errorf("wrong correction: want=%+v, got=%+v", want, c)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for correction")
+	}
+}
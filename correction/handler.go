@@ -0,0 +1,88 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package correction
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle returns an option that registers a Handler for corrections in chat
+// and group chat messages.
+func Handle(h *Handler) mux.Option {
+	return func(m *mux.ServeMux) {
+		name := xml.Name{Space: NS, Local: "replace"}
+		mux.Message(stanza.ChatMessage, name, h)(m)
+		mux.Message(stanza.GroupChatMessage, name, h)(m)
+	}
+}
+
+// Correction is a correction received in a message.
+type Correction struct {
+	// ID is the ID of the message being replaced.
+	ID string
+
+	// Body is the corrected body.
+	Body string
+}
+
+// Handler reports corrections received in messages.
+//
+// Because the multiplexer passes the message to the handlers for each of its
+// children, handlers that display message bodies will also see the corrected
+// body and should skip messages that contain a replace element.
+type Handler struct {
+	// Corrected, if set, is called when a correction is received.
+	// Applications should only replace the original message if the correction
+	// was sent by the same entity as the original message.
+	Corrected func(msg stanza.Message, c Correction)
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+	// Pop the start message token.
+	_, err := t.Token()
+	if err != nil {
+		return err
+	}
+
+	var c Correction
+	iter := xmlstream.NewIter(t)
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		start, r := iter.Current()
+		if start == nil {
+			continue
+		}
+		d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r))
+		switch {
+		case start.Name.Space == NS && start.Name.Local == "replace":
+			var r Replace
+			err = d.Decode(&r)
+			if err != nil {
+				return err
+			}
+			c.ID = r.ID
+		case start.Name.Local == "body" && (start.Name.Space == stanza.NSClient || start.Name.Space == stanza.NSServer) && c.Body == "":
+			err = d.Decode(&c.Body)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if c.ID != "" && h.Corrected != nil {
+		h.Corrected(msg, c)
+	}
+	return nil
+}
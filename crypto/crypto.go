@@ -31,6 +31,8 @@ var (
 	ErrMissingAlgo  = errors.New("crypto: no algo attr found")
 	ErrUnknownAlgo  = errors.New("crypto: unknown hash value")
 	ErrUnlinkedAlgo = errors.New("crypto: attempted to use a hash function without an implementation linked in")
+	ErrMismatch     = errors.New("crypto: hash output does not match")
+	ErrWeakAlgo     = errors.New("crypto: hash function is too weak to verify data")
 )
 
 // Hash identifies a cryptographic hash function that is implemented in another
@@ -0,0 +1,122 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package crypto
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+)
+
+func checkAvailable(h Hash) error {
+	_, err := h.Namespace()
+	if err != nil {
+		return err
+	}
+	if !h.Available() {
+		return fmt.Errorf("%w: %s", ErrUnlinkedAlgo, h)
+	}
+	return nil
+}
+
+// Sum reads r until EOF and returns its hash.
+// If the hash function is unknown or has not been linked into the binary an
+// error is returned.
+func (h Hash) Sum(r io.Reader) (HashOutput, error) {
+	out, err := SumAll(r, h)
+	if err != nil {
+		return HashOutput{}, err
+	}
+	return out[0], nil
+}
+
+// SumAll reads r until EOF and returns its hash using each of the provided
+// hash functions in the same order.
+// This is useful when offering several hashes of the same data (eg. when
+// sending a file) since r only has to be read once.
+// If any of the hash functions are unknown or have not been linked into the
+// binary an error is returned.
+func SumAll(r io.Reader, hashes ...Hash) ([]HashOutput, error) {
+	hs := make([]hash.Hash, 0, len(hashes))
+	ws := make([]io.Writer, 0, len(hashes))
+	for _, h := range hashes {
+		err := checkAvailable(h)
+		if err != nil {
+			return nil, err
+		}
+		hh := h.New()
+		hs = append(hs, hh)
+		ws = append(ws, hh)
+	}
+	_, err := io.Copy(io.MultiWriter(ws...), r)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HashOutput, 0, len(hashes))
+	for i, h := range hashes {
+		out = append(out, HashOutput{
+			Hash: h,
+			Out:  hs[i].Sum(nil),
+		})
+	}
+	return out, nil
+}
+
+// Verify reads r until EOF and checks that its hash matches the output.
+// For more information see the Verify function.
+func (h HashOutput) Verify(r io.Reader) error {
+	return Verify(r, h)
+}
+
+// weak reports whether h is too weak to be used for verifying data.
+// SHA-1 is only supported for compatibility with older protocols and is
+// vulnerable to collision attacks.
+func weak(h Hash) bool {
+	return h == SHA1
+}
+
+// Verify reads r until EOF and checks that it matches every hash output
+// whose hash function has been linked into the binary, ignoring the others.
+// This lets an entity verify data when the sender offered several hashes, only
+// some of which it supports.
+// Weak hash functions such as SHA-1 are also ignored so that a match is never
+// accepted based on them alone.
+// If any hash does not match, an error wrapping ErrMismatch is returned.
+// If only weak hash functions are available, an error wrapping ErrWeakAlgo is
+// returned, and if none of the hash functions are available, an error wrapping
+// ErrUnlinkedAlgo is returned, both without reading from r.
+func Verify(r io.Reader, outputs ...HashOutput) error {
+	var hashes []Hash
+	var want [][]byte
+	var sawWeak bool
+	for _, o := range outputs {
+		if checkAvailable(o.Hash) != nil {
+			continue
+		}
+		if weak(o.Hash) {
+			sawWeak = true
+			continue
+		}
+		hashes = append(hashes, o.Hash)
+		want = append(want, o.Out)
+	}
+	if len(hashes) == 0 {
+		if sawWeak {
+			return fmt.Errorf("%w: no strong hash functions", ErrWeakAlgo)
+		}
+		return fmt.Errorf("%w: no supported hash functions", ErrUnlinkedAlgo)
+	}
+	got, err := SumAll(r, hashes...)
+	if err != nil {
+		return err
+	}
+	for i, o := range got {
+		if subtle.ConstantTimeCompare(o.Out, want[i]) != 1 {
+			return fmt.Errorf("%w: %s", ErrMismatch, o.Hash)
+		}
+	}
+	return nil
+}
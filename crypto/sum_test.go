@@ -0,0 +1,117 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package crypto_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/crypto"
+)
+
+const sumInput = "Wherefore art thou?"
+
+var (
+	sumInputSHA256 = sha256.Sum256([]byte(sumInput))
+	/* #nosec */
+	sumInputSHA1 = sha1.Sum([]byte(sumInput))
+)
+
+func TestSum(t *testing.T) {
+	out, err := crypto.SHA256.Sum(strings.NewReader(sumInput))
+	if err != nil {
+		t.Fatalf("error computing hash: %v", err)
+	}
+	if out.Hash != crypto.SHA256 {
+		t.Errorf("wrong hash: want=%v, got=%v", crypto.SHA256, out.Hash)
+	}
+	if string(out.Out) != string(sumInputSHA256[:]) {
+		t.Errorf("wrong output: want=%x, got=%x", sumInputSHA256, out.Out)
+	}
+
+	_, err = badHash.Sum(strings.NewReader(sumInput))
+	if !errors.Is(err, crypto.ErrUnknownAlgo) {
+		t.Errorf("wrong error for bad hash: want=%v, got=%v", crypto.ErrUnknownAlgo, err)
+	}
+}
+
+func TestSumAll(t *testing.T) {
+	outs, err := crypto.SumAll(strings.NewReader(sumInput), crypto.SHA256, crypto.SHA224)
+	if err != nil {
+		t.Fatalf("error computing hashes: %v", err)
+	}
+	sum224 := sha256.Sum224([]byte(sumInput))
+	if len(outs) != 2 || string(outs[0].Out) != string(sumInputSHA256[:]) || string(outs[1].Out) != string(sum224[:]) {
+		t.Errorf("wrong outputs: %+v", outs)
+	}
+}
+
+var verifyTestCases = [...]struct {
+	outputs []crypto.HashOutput
+	err     error
+}{
+	0: {
+		outputs: []crypto.HashOutput{{Hash: crypto.SHA256, Out: sumInputSHA256[:]}},
+	},
+	1: {
+		outputs: []crypto.HashOutput{{Hash: crypto.SHA256, Out: []byte("bad")}},
+		err:     crypto.ErrMismatch,
+	},
+	2: {
+		// Unsupported hashes are ignored.
+		outputs: []crypto.HashOutput{
+			{Hash: badHash, Out: []byte("bad")},
+			{Hash: crypto.SHA256, Out: sumInputSHA256[:]},
+		},
+	},
+	3: {
+		outputs: []crypto.HashOutput{{Hash: badHash, Out: []byte("bad")}},
+		err:     crypto.ErrUnlinkedAlgo,
+	},
+	4: {
+		err: crypto.ErrUnlinkedAlgo,
+	},
+	5: {
+		// Weak hashes are never enough on their own.
+		outputs: []crypto.HashOutput{{Hash: crypto.SHA1, Out: sumInputSHA1[:]}},
+		err:     crypto.ErrWeakAlgo,
+	},
+	6: {
+		// Weak hashes are ignored, so a matching weak hash does not hide a
+		// mismatched strong hash.
+		outputs: []crypto.HashOutput{
+			{Hash: crypto.SHA1, Out: sumInputSHA1[:]},
+			{Hash: crypto.SHA256, Out: []byte("bad")},
+		},
+		err: crypto.ErrMismatch,
+	},
+	7: {
+		outputs: []crypto.HashOutput{
+			{Hash: crypto.SHA1, Out: []byte("bad")},
+			{Hash: crypto.SHA256, Out: sumInputSHA256[:]},
+		},
+	},
+}
+
+func TestVerify(t *testing.T) {
+	for i, tc := range verifyTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := crypto.Verify(strings.NewReader(sumInput), tc.outputs...)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error: want=%v, got=%v", tc.err, err)
+			}
+			if len(tc.outputs) == 1 {
+				err = tc.outputs[0].Verify(strings.NewReader(sumInput))
+				if !errors.Is(err, tc.err) {
+					t.Errorf("wrong error from method: want=%v, got=%v", tc.err, err)
+				}
+			}
+		})
+	}
+}
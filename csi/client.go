@@ -0,0 +1,113 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package csi
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+)
+
+// ErrNotSupported is returned when attempting to indicate the client state on
+// a session where the server did not advertise support for client state
+// indication.
+var ErrNotSupported = errors.New("csi: client state indication not supported by the server")
+
+// StreamFeature is an informational stream feature that advertises support
+// for client state indication on servers and records whether it was
+// advertised on clients.
+// Clients do not need to use StreamFeature since features that are not
+// handled are still recorded, but it is provided so that servers can
+// advertise support.
+func StreamFeature() xmpp.StreamFeature {
+	return xmpp.StreamFeature{
+		Name:      xml.Name{Space: NS, Local: "csi"},
+		Necessary: xmpp.Authn,
+		List: func(ctx context.Context, e xmlstream.TokenWriter, start xml.StartElement) (bool, error) {
+			_, err := xmlstream.Copy(e, xmlstream.Wrap(nil, start))
+			return false, err
+		},
+		Parse: func(ctx context.Context, d *xml.Decoder, start *xml.StartElement) (bool, interface{}, error) {
+			return false, nil, d.Skip()
+		},
+	}
+}
+
+// Available reports whether the server advertised support for client state
+// indication on the session.
+func Available(s *xmpp.Session) bool {
+	_, ok := s.Feature(NS)
+	return ok
+}
+
+// Active tells the server that the user is actively using the client.
+// If the server did not advertise support for client state indication,
+// ErrNotSupported is returned and nothing is sent.
+func Active(ctx context.Context, s *xmpp.Session) error {
+	return send(ctx, s, true)
+}
+
+// Inactive tells the server that the user is not actively using the client.
+// If the server did not advertise support for client state indication,
+// ErrNotSupported is returned and nothing is sent.
+func Inactive(ctx context.Context, s *xmpp.Session) error {
+	return send(ctx, s, false)
+}
+
+func send(ctx context.Context, s *xmpp.Session, active bool) error {
+	if !Available(s) {
+		return ErrNotSupported
+	}
+	local := "inactive"
+	if active {
+		local = "active"
+	}
+	return s.Send(ctx, xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: NS, Local: local}}))
+}
+
+// Indicator remembers the state of a client so that it can be restored on new
+// sessions, for example after reconnecting while an app is in the background.
+// The zero value is an active client and is safe for concurrent use by
+// multiple goroutines.
+type Indicator struct {
+	mu       sync.Mutex
+	inactive bool
+}
+
+// Active reports whether the client is currently active.
+func (i *Indicator) Active() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return !i.inactive
+}
+
+// Set records the state of the client and, if it has changed and the server
+// supports client state indication, sends it on s.
+// The state is recorded even if sending it fails so that it can be sent on the
+// next session using Restore.
+func (i *Indicator) Set(ctx context.Context, s *xmpp.Session, active bool) error {
+	i.mu.Lock()
+	changed := i.inactive == active
+	i.inactive = !active
+	i.mu.Unlock()
+	if !changed || !Available(s) {
+		return nil
+	}
+	return send(ctx, s, active)
+}
+
+// Restore sends the current state on a newly established session.
+// Because servers treat new sessions as active, nothing is sent if the client
+// is active or if the server does not support client state indication.
+func (i *Indicator) Restore(ctx context.Context, s *xmpp.Session) error {
+	if i.Active() || !Available(s) {
+		return nil
+	}
+	return send(ctx, s, false)
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package csi_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/csi"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+)
+
+func TestNotSupported(t *testing.T) {
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(""),
+		Writer: io.Discard,
+	})
+	if csi.Available(s) {
+		t.Errorf("expected CSI to not be available")
+	}
+	err := csi.Inactive(context.Background(), s)
+	if !errors.Is(err, csi.ErrNotSupported) {
+		t.Errorf("wrong error: want=%v, got=%v", csi.ErrNotSupported, err)
+	}
+	var i csi.Indicator
+	err = i.Set(context.Background(), s, false)
+	if err != nil {
+		t.Errorf("unexpected error setting state: %v", err)
+	}
+	if i.Active() {
+		t.Errorf("state was not recorded")
+	}
+}
+
+func TestIndicator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	negotiator := func(features ...xmpp.StreamFeature) xmpp.Negotiator {
+		return xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+			return xmpp.StreamConfig{
+				Features: append([]xmpp.StreamFeature{xmpp.BindResource()}, features...),
+			}
+		})
+	}
+	serverC := make(chan *xmpp.Session, 1)
+	go func() {
+		s, err := xmpp.ReceiveSession(ctx, serverConn, xmpp.Secure|xmpp.Authn, negotiator(csi.StreamFeature()))
+		if err != nil {
+			t.Errorf("error receiving session: %v", err)
+		}
+		serverC <- s
+	}()
+	clientJID := jid.MustParse("me@example.net")
+	s, err := xmpp.NewSession(ctx, clientJID.Domain(), clientJID, clientConn, xmpp.Secure|xmpp.Authn, negotiator())
+	if err != nil {
+		t.Fatalf("error negotiating session: %v", err)
+	}
+	serverSession := <-serverC
+	if serverSession == nil {
+		t.FailNow()
+	}
+	if !csi.Available(s) {
+		t.Fatalf("expected CSI to be available")
+	}
+
+	changed := make(chan bool, 2)
+	q := csi.NewQueue(nil, 0, func(xml.TokenReader) error { return nil })
+	go func() {
+		/* #nosec */
+		serverSession.Serve(xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			err := q.HandleXMPP(r, start)
+			changed <- q.Active()
+			return err
+		}))
+	}()
+
+	var i csi.Indicator
+	for _, active := range []bool{false, false, true} {
+		err = i.Set(ctx, s, active)
+		if err != nil {
+			t.Fatalf("error setting state: %v", err)
+		}
+	}
+	for _, want := range []bool{false, true} {
+		select {
+		case active := <-changed:
+			if active != want {
+				t.Errorf("wrong state on server: want=%t, got=%t", want, active)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for state change")
+		}
+	}
+	select {
+	case <-changed:
+		t.Errorf("unchanged state should not be sent")
+	default:
+	}
+}
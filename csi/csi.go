@@ -0,0 +1,16 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package csi implements XEP-0352: Client State Indication.
+//
+// Client state indication lets a client tell the server whether the user is
+// actively using it.
+// While a client is inactive, servers may hold back traffic that is not
+// urgent, such as presence updates and chat state notifications, and deliver
+// it all at once when the client becomes active again to save bandwidth and
+// battery.
+package csi // import "mellium.im/xmpp/csi"
+
+// NS is the namespace used by this package.
+const NS = "urn:xmpp:csi:0"
@@ -0,0 +1,252 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package csi
+
+import (
+	"encoding/xml"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+const (
+	nsHints      = "urn:xmpp:hints"
+	nsChatStates = "http://jabber.org/protocol/chatstates"
+)
+
+// Policy decides which stanzas a Queue holds back while the client is
+// inactive.
+type Policy interface {
+	// Hold is called for each stanza that is sent while the client is
+	// inactive.
+	// r is positioned just after start and contains the rest of the stanza.
+	//
+	// If hold is false, any stanzas being held are flushed and the stanza is
+	// sent immediately.
+	// If hold is true, the stanza is held until the queue is flushed.
+	// If key is not empty, the stanza replaces any stanza being held with the
+	// same key.
+	Hold(start xml.StartElement, r xml.TokenReader) (hold bool, key string, err error)
+}
+
+// The PolicyFunc type is an adapter to allow the use of ordinary functions as
+// policies.
+type PolicyFunc func(start xml.StartElement, r xml.TokenReader) (hold bool, key string, err error)
+
+// Hold calls f(start, r).
+func (f PolicyFunc) Hold(start xml.StartElement, r xml.TokenReader) (bool, string, error) {
+	return f(start, r)
+}
+
+// DefaultPolicy holds available and unavailable presence and messages that
+// contain only chat state notifications.
+// Only the latest presence and the latest chat state notification from each
+// JID are kept.
+// All other stanzas, including presence subscription requests and messages
+// with a body, are sent immediately.
+var DefaultPolicy Policy = PolicyFunc(defaultHold)
+
+func defaultHold(start xml.StartElement, r xml.TokenReader) (bool, string, error) {
+	_, from := attr.Get(start.Attr, "from")
+	_, typ := attr.Get(start.Attr, "type")
+	switch start.Name.Local {
+	case "presence":
+		switch stanza.PresenceType(typ) {
+		case stanza.AvailablePresence, stanza.UnavailablePresence:
+			return true, "presence " + from, nil
+		}
+	case "message":
+		if stanza.MessageType(typ) == stanza.ErrorMessage {
+			return false, "", nil
+		}
+		var chatState bool
+		iter := xmlstream.NewIter(r)
+		for iter.Next() {
+			child, _ := iter.Current()
+			switch {
+			case child.Name.Space == nsChatStates:
+				chatState = true
+			case child.Name.Space == nsHints, child.Name.Local == "thread":
+			default:
+				return false, "", nil
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return false, "", err
+		}
+		if chatState {
+			return true, "chatstate " + from, nil
+		}
+	}
+	return false, "", nil
+}
+
+type heldStanza struct {
+	key  string
+	toks []xml.Token
+}
+
+// Queue holds back stanzas sent to a client while the client is inactive.
+// It is meant to be used by servers, one per client session.
+type Queue struct {
+	policy Policy
+	maxLen int
+	send   func(r xml.TokenReader) error
+
+	mu       sync.Mutex
+	inactive bool
+	held     []*heldStanza
+	n        int
+	keys     map[string]*heldStanza
+}
+
+// NewQueue creates a queue that passes stanzas to send when they should be
+// delivered to the client.
+// If p is nil, DefaultPolicy is used.
+// If maxLen is greater than zero, the queue is flushed whenever more than
+// maxLen stanzas are being held.
+func NewQueue(p Policy, maxLen int, send func(r xml.TokenReader) error) *Queue {
+	if p == nil {
+		p = DefaultPolicy
+	}
+	return &Queue{
+		policy: p,
+		maxLen: maxLen,
+		send:   send,
+		keys:   make(map[string]*heldStanza),
+	}
+}
+
+// Active reports whether the client is active.
+// Clients are active until they indicate otherwise.
+func (q *Queue) Active() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return !q.inactive
+}
+
+// SetActive sets the state of the client.
+// If the client becomes active, any stanzas being held are flushed.
+func (q *Queue) SetActive(active bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inactive = !active
+	if active {
+		return q.flush()
+	}
+	return nil
+}
+
+// Len returns the number of stanzas being held.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// Flush sends all stanzas that are being held in the order in which they were
+// queued.
+// If sending a stanza fails, it and any stanzas after it remain in the queue
+// and are sent again on the next flush.
+func (q *Queue) Flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.flush()
+}
+
+func (q *Queue) flush() error {
+	for i, h := range q.held {
+		if h == nil {
+			continue
+		}
+		// Reading the tokens does not consume them, so the stanza can be sent
+		// again if this attempt fails.
+		err := q.send(tokens.Reader(h.toks))
+		if err != nil {
+			// Keep the stanza that failed and the stanzas after it so that they can
+			// be retried on the next flush.
+			q.held = q.held[i:]
+			return err
+		}
+		q.held[i] = nil
+		q.n--
+		if h.key != "" {
+			delete(q.keys, h.key)
+		}
+	}
+	q.held = nil
+	return nil
+}
+
+// Send sends the stanza read from r to the client or holds it if the client is
+// inactive and the policy says that it may be held.
+func (q *Queue) Send(r xml.TokenReader) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.inactive {
+		return q.send(r)
+	}
+
+	toks, err := xmlstream.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(toks) == 0 {
+		return nil
+	}
+	start, ok := toks[0].(xml.StartElement)
+	if !ok {
+		return q.send(tokens.Reader(toks))
+	}
+	hold, key, err := q.policy.Hold(start, tokens.Reader(toks[1:]))
+	if err != nil {
+		return err
+	}
+	if !hold {
+		err = q.flush()
+		if err != nil {
+			return err
+		}
+		return q.send(tokens.Reader(toks))
+	}
+
+	h := &heldStanza{key: key, toks: toks}
+	if key != "" {
+		if old, ok := q.keys[key]; ok {
+			for i, oh := range q.held {
+				if oh == old {
+					q.held[i] = nil
+					q.n--
+					break
+				}
+			}
+		}
+		q.keys[key] = h
+	}
+	q.held = append(q.held, h)
+	q.n++
+	if q.maxLen > 0 && q.n > q.maxLen {
+		return q.flush()
+	}
+	return nil
+}
+
+// Handle returns an option that registers the queue to handle active and
+// inactive nonzas sent by the client.
+func (q *Queue) Handle() mux.Option {
+	return func(m *mux.ServeMux) {
+		mux.Handle(xml.Name{Space: NS, Local: "active"}, q)(m)
+		mux.Handle(xml.Name{Space: NS, Local: "inactive"}, q)(m)
+	}
+}
+
+// HandleXMPP implements xmpp.Handler.
+func (q *Queue) HandleXMPP(_ xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	return q.SetActive(start.Name.Local == "active")
+}
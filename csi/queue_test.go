@@ -0,0 +1,234 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package csi_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/csi"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+var _ xmpp.Handler = (*csi.Queue)(nil)
+
+var (
+	alice = jid.MustParse("alice@example.net/a")
+	bob   = jid.MustParse("bob@example.net/b")
+)
+
+func presence(id string, from jid.JID) xml.TokenReader {
+	return stanza.Presence{ID: id, From: from}.Wrap(nil)
+}
+
+func chatState(id string, from jid.JID) xml.TokenReader {
+	return stanza.Message{ID: id, From: from, Type: stanza.ChatMessage}.Wrap(xmlstream.Wrap(
+		nil,
+		xml.StartElement{Name: xml.Name{Space: "http://jabber.org/protocol/chatstates", Local: "composing"}},
+	))
+}
+
+func body(id string, from jid.JID) xml.TokenReader {
+	return stanza.Message{ID: id, From: from, Type: stanza.ChatMessage}.Wrap(xmlstream.Wrap(
+		xmlstream.Token(xml.CharData("hi")),
+		xml.StartElement{Name: xml.Name{Local: "body"}},
+	))
+}
+
+// recorder returns a send function that records the IDs of the stanzas that
+// are sent.
+func recorder(ids *[]string) func(xml.TokenReader) error {
+	return func(r xml.TokenReader) error {
+		toks, err := xmlstream.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		start := toks[0].(xml.StartElement)
+		_, id := attr.Get(start.Attr, "id")
+		*ids = append(*ids, id)
+		return nil
+	}
+}
+
+func TestQueue(t *testing.T) {
+	var sent []string
+	q := csi.NewQueue(nil, 0, recorder(&sent))
+
+	if !q.Active() {
+		t.Fatalf("expected new queue to be active")
+	}
+	err := q.Send(presence("0", alice))
+	if err != nil {
+		t.Fatalf("error sending while active: %v", err)
+	}
+	if !reflect.DeepEqual(sent, []string{"0"}) {
+		t.Fatalf("expected presence to be sent immediately while active, got %v", sent)
+	}
+
+	err = q.HandleXMPP(nil, &xml.StartElement{Name: xml.Name{Space: csi.NS, Local: "inactive"}})
+	if err != nil {
+		t.Fatalf("error handling inactive: %v", err)
+	}
+	if q.Active() {
+		t.Fatalf("expected queue to be inactive")
+	}
+	sent = nil
+	for _, r := range []xml.TokenReader{
+		presence("1", alice),
+		chatState("2", bob),
+		presence("3", alice),
+		chatState("4", alice),
+		stanza.Presence{ID: "5", From: bob, Type: stanza.SubscribePresence}.Wrap(nil),
+	} {
+		err = q.Send(r)
+		if err != nil {
+			t.Fatalf("error sending while inactive: %v", err)
+		}
+	}
+	// The subscription request cannot be held so it flushes the queue.
+	if want := []string{"2", "3", "4", "5"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("wrong stanzas sent: want=%v, got=%v", want, sent)
+	}
+	if l := q.Len(); l != 0 {
+		t.Fatalf("expected queue to be empty after flush, got %d", l)
+	}
+
+	sent = nil
+	for _, r := range []xml.TokenReader{
+		chatState("6", bob),
+		chatState("7", bob),
+		presence("8", bob),
+	} {
+		err = q.Send(r)
+		if err != nil {
+			t.Fatalf("error sending while inactive: %v", err)
+		}
+	}
+	if len(sent) != 0 {
+		t.Fatalf("did not expect anything to be sent, got %v", sent)
+	}
+	if l := q.Len(); l != 2 {
+		t.Fatalf("wrong number of held stanzas: want=2, got=%d", l)
+	}
+	err = q.HandleXMPP(nil, &xml.StartElement{Name: xml.Name{Space: csi.NS, Local: "active"}})
+	if err != nil {
+		t.Fatalf("error handling active: %v", err)
+	}
+	if want := []string{"7", "8"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("wrong stanzas flushed on activation: want=%v, got=%v", want, sent)
+	}
+}
+
+func TestQueueBody(t *testing.T) {
+	var sent []string
+	q := csi.NewQueue(nil, 0, recorder(&sent))
+	err := q.SetActive(false)
+	if err != nil {
+		t.Fatalf("error setting inactive: %v", err)
+	}
+	for _, r := range []xml.TokenReader{presence("0", alice), body("1", bob)} {
+		err = q.Send(r)
+		if err != nil {
+			t.Fatalf("error sending: %v", err)
+		}
+	}
+	if want := []string{"0", "1"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("wrong stanzas sent: want=%v, got=%v", want, sent)
+	}
+}
+
+func TestQueueMaxLen(t *testing.T) {
+	var sent []string
+	q := csi.NewQueue(nil, 2, recorder(&sent))
+	err := q.SetActive(false)
+	if err != nil {
+		t.Fatalf("error setting inactive: %v", err)
+	}
+	for _, r := range []xml.TokenReader{
+		presence("0", alice),
+		presence("1", bob),
+		presence("2", jid.MustParse("carol@example.net")),
+	} {
+		err = q.Send(r)
+		if err != nil {
+			t.Fatalf("error sending: %v", err)
+		}
+	}
+	if want := []string{"0", "1", "2"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("wrong stanzas sent: want=%v, got=%v", want, sent)
+	}
+}
+
+func TestQueuePolicy(t *testing.T) {
+	var sent []string
+	q := csi.NewQueue(csi.PolicyFunc(func(start xml.StartElement, _ xml.TokenReader) (bool, string, error) {
+		// Hold everything without coalescing.
+		return true, "", nil
+	}), 0, recorder(&sent))
+	err := q.SetActive(false)
+	if err != nil {
+		t.Fatalf("error setting inactive: %v", err)
+	}
+	for _, r := range []xml.TokenReader{presence("0", alice), presence("1", alice), body("2", bob)} {
+		err = q.Send(r)
+		if err != nil {
+			t.Fatalf("error sending: %v", err)
+		}
+	}
+	if l := q.Len(); l != 3 {
+		t.Fatalf("wrong number of held stanzas: want=3, got=%d", l)
+	}
+	err = q.Flush()
+	if err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	if want := []string{"0", "1", "2"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("wrong stanzas flushed: want=%v, got=%v", want, sent)
+	}
+}
+
+func TestQueueSendError(t *testing.T) {
+	errSend := errors.New("send failed")
+	var sent []string
+	rec := recorder(&sent)
+	fail := true
+	q := csi.NewQueue(nil, 0, func(r xml.TokenReader) error {
+		if fail {
+			fail = false
+			return errSend
+		}
+		return rec(r)
+	})
+	err := q.SetActive(false)
+	if err != nil {
+		t.Fatalf("error setting inactive: %v", err)
+	}
+	for _, r := range []xml.TokenReader{presence("0", alice), presence("1", bob)} {
+		err = q.Send(r)
+		if err != nil {
+			t.Fatalf("error sending: %v", err)
+		}
+	}
+	err = q.Flush()
+	if err != errSend {
+		t.Fatalf("wrong error: want=%v, got=%v", errSend, err)
+	}
+	if l := q.Len(); l != 2 {
+		t.Fatalf("wrong number of held stanzas after failure: want=2, got=%d", l)
+	}
+	err = q.Flush()
+	if err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	if want := []string{"0", "1"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("wrong stanzas flushed: want=%v, got=%v", want, sent)
+	}
+}
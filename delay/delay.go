@@ -12,6 +12,7 @@ import (
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/schema"
 	"mellium.im/xmpp/stanza"
 	"mellium.im/xmpp/xtime"
 )
@@ -19,6 +20,13 @@ import (
 // NS is the namespace used by this package.
 const NS = "urn:xmpp:delay"
 
+func init() {
+	schema.Register(schema.Shape{
+		Name:  xml.Name{Space: NS, Local: "delay"},
+		Attrs: []string{"stamp"},
+	})
+}
+
 // Delay is a type that can be added to stanzas to indicate that they have been
 // delivered with a delay.
 type Delay struct {
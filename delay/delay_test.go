@@ -163,3 +163,91 @@ func TestMarshal(t *testing.T) {
 		})
 	}
 }
+
+var findTestCases = [...]struct {
+	in    string
+	out   delay.Delay
+	found bool
+	err   bool
+}{
+	0: {
+		in: `<message xmlns="jabber:client"><body>test</body></message>`,
+	},
+	1: {
+		in:    `<message xmlns="jabber:client"><body>test</body><delay xmlns="urn:xmpp:delay" from="capulet.com" stamp="2002-09-10T23:08:25Z">Offline Storage</delay></message>`,
+		out:   delay.Delay{From: jid.MustParse("capulet.com"), Time: time.Date(2002, 9, 10, 23, 8, 25, 0, time.UTC), Reason: "Offline Storage"},
+		found: true,
+	},
+	2: {
+		in:    `<presence xmlns="jabber:client"><delay xmlns="urn:xmpp:delay" stamp="2002-09-10T23:08:25.123+02:00"/></presence>`,
+		out:   delay.Delay{Time: time.Date(2002, 9, 10, 21, 8, 25, 123000000, time.UTC)},
+		found: true,
+	},
+	3: {
+		in:    `<message xmlns="jabber:client"><x xmlns="jabber:x:delay" from="capulet.com" stamp="20020910T23:08:25">Offline Storage</x></message>`,
+		out:   delay.Delay{From: jid.MustParse("capulet.com"), Time: time.Date(2002, 9, 10, 23, 8, 25, 0, time.UTC), Reason: "Offline Storage"},
+		found: true,
+	},
+	4: {
+		in:    `<message xmlns="jabber:client"><x xmlns="jabber:x:delay" stamp="20020910T23:08:25"/><delay xmlns="urn:xmpp:delay" stamp="2002-09-10T23:08:26Z"/></message>`,
+		out:   delay.Delay{Time: time.Date(2002, 9, 10, 23, 8, 26, 0, time.UTC)},
+		found: true,
+	},
+	5: {
+		// Delays in nested payloads do not apply to the stanza.
+		in: `<message xmlns="jabber:client"><result xmlns="urn:xmpp:mam:2"><delay xmlns="urn:xmpp:delay" stamp="2002-09-10T23:08:26Z"/></result></message>`,
+	},
+	6: {
+		in:  `<message xmlns="jabber:client"><delay xmlns="urn:xmpp:delay" stamp="yesterday"/></message>`,
+		err: true,
+	},
+	7: {
+		in:  `<message xmlns="jabber:client"><x xmlns="jabber:x:delay" stamp="2002-09-10T23:08:26Z"/></message>`,
+		err: true,
+	},
+}
+
+func TestFind(t *testing.T) {
+	for i, tc := range findTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			d, ok, err := delay.Find(xml.NewDecoder(strings.NewReader(tc.in)))
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected error")
+			case !tc.err && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tc.found {
+				t.Errorf("wrong value for found: want=%t, got=%t", tc.found, ok)
+			}
+			if !d.From.Equal(tc.out.From) {
+				t.Errorf("wrong from JID: want=%v, got=%v", tc.out.From, d.From)
+			}
+			if !d.Time.Equal(tc.out.Time) {
+				t.Errorf("wrong timestamp: want=%v, got=%v", tc.out.Time, d.Time)
+			}
+			if d.Reason != tc.out.Reason {
+				t.Errorf("wrong reason: want=%q, got=%q", tc.out.Reason, d.Reason)
+			}
+		})
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	stamp, err := delay.Timestamp(xml.NewDecoder(strings.NewReader(findTestCases[1].in)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stamp.Equal(findTestCases[1].out.Time) {
+		t.Errorf("wrong timestamp: want=%v, got=%v", findTestCases[1].out.Time, stamp)
+	}
+
+	before := time.Now()
+	stamp, err = delay.Timestamp(xml.NewDecoder(strings.NewReader(findTestCases[0].in)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stamp.Before(before) || stamp.After(time.Now()) {
+		t.Errorf("expected current time for stanza without delay, got %v", stamp)
+	}
+}
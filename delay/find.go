@@ -0,0 +1,113 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"mellium.im/xmpp/jid"
+)
+
+// NSLegacy is the namespace used by the obsolete legacy delayed delivery
+// format from XEP-0091.
+// It is only ever used when reading delays.
+const NSLegacy = "jabber:x:delay"
+
+// legacyLayout is the format of timestamps in XEP-0091 which are always in
+// UTC.
+const legacyLayout = "20060102T15:04:05"
+
+// Find reads a stanza (or any other element) from r and returns the first
+// delay that is a direct child of the element.
+// If no delay is present but a legacy XEP-0091 delay is found, it is returned
+// instead since some older servers still include them in history.
+// If no delay is found, ok will be false.
+//
+// Find consumes the element from r.
+func Find(r xml.TokenReader) (d Delay, ok bool, err error) {
+	dec := xml.NewTokenDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return d, false, err
+	}
+	if _, isStart := tok.(xml.StartElement); !isStart {
+		return d, false, fmt.Errorf("delay: expected start element, got %T", tok)
+	}
+
+	var legacy Delay
+	var foundLegacy bool
+	for {
+		tok, err = dec.Token()
+		if err != nil {
+			return d, false, err
+		}
+		var start xml.StartElement
+		switch t := tok.(type) {
+		case xml.StartElement:
+			start = t
+		case xml.EndElement:
+			return legacy, foundLegacy, nil
+		default:
+			continue
+		}
+
+		switch {
+		case start.Name.Space == NS && start.Name.Local == "delay":
+			err = dec.DecodeElement(&d, &start)
+			return d, err == nil, err
+		case start.Name.Space == NSLegacy && start.Name.Local == "x" && !foundLegacy:
+			legacy, err = decodeLegacy(dec, start)
+			if err != nil {
+				return d, false, err
+			}
+			foundLegacy = true
+		default:
+			err = dec.Skip()
+			if err != nil {
+				return d, false, err
+			}
+		}
+	}
+}
+
+func decodeLegacy(dec *xml.Decoder, start xml.StartElement) (Delay, error) {
+	var x struct {
+		From   string `xml:"from,attr"`
+		Stamp  string `xml:"stamp,attr"`
+		Reason string `xml:",chardata"`
+	}
+	err := dec.DecodeElement(&x, &start)
+	if err != nil {
+		return Delay{}, err
+	}
+	d := Delay{Reason: x.Reason}
+	d.Time, err = time.Parse(legacyLayout, x.Stamp)
+	if err != nil {
+		return d, err
+	}
+	if x.From != "" {
+		d.From, err = jid.Parse(x.From)
+	}
+	return d, err
+}
+
+// Timestamp reads a stanza from r and returns the time at which it was
+// originally sent.
+// This is the time from the first delay found by Find if one exists, or the
+// current time otherwise.
+//
+// Timestamp consumes the element from r.
+func Timestamp(r xml.TokenReader) (time.Time, error) {
+	d, ok, err := Find(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		return time.Now(), nil
+	}
+	return d.Time, nil
+}
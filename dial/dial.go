@@ -81,8 +81,54 @@
 //		TLSConfig: &tls.Config{…},
 //	}
 //
+// # Parallel Connections
+//
+// Trying each endpoint in turn can be slow when some of them are unreachable,
+// for example when a dual-stack server has a broken IPv6 address.
+// Setting AttemptDelay on a [Dialer] resolves the addresses of all endpoints and
+// races connections to them as described in [RFC 8305] ("Happy Eyeballs"),
+// starting a new attempt each time the delay elapses and returning the first
+// connection that succeeds.
+// Endpoints are still tried in the order described above, and the IPv6 and
+// IPv4 addresses of each endpoint are interleaved.
+//
+// # Proxies
+//
+// Clients on restrictive networks, or that want to connect over Tor, may need
+// to make their connection through a proxy.
+// Setting Proxy on a [Dialer] to any dialer that follows the conventions of
+// [golang.org/x/net/proxy] routes connections through it while keeping the
+// service discovery and implicit TLS behavior described above.
+// [ProxyFromURL] creates a dialer for SOCKS5 proxies and HTTP proxies that
+// support the CONNECT method, and [ProxyFromEnvironment] creates one using the
+// same environment variables as most other tools:
+//
+//	p, err := dial.ProxyFromEnvironment()
+//	if err != nil {
+//		return err
+//	}
+//	d := dial.Dialer{Proxy: p}
+//
+// # Load Distribution
+//
+// Servers that open many connections to the same remote domain may want to
+// spread them across all of the targets that the domain advertises and to stop
+// trying targets that are down.
+// Setting Strategy on a [Dialer] lets it choose the order in which SRV targets
+// are dialed and informs it of the result of every connection attempt.
+// The [Balancer] strategy picks targets by their priority and weight as
+// described in [RFC 2782] and avoids targets that failed repeatedly for a
+// while.
+// [Dialer.Probe] can be used to check the health of all targets in the
+// background:
+//
+//	d := dial.Dialer{S2S: true, Strategy: &dial.Balancer{}}
+//	err := d.Probe(ctx, "tcp", domain)
+//
 // [RFC6120 §3.2.1]: https://datatracker.ietf.org/doc/html/rfc6120#section-3.2.1
 // [XEP-0368]: https://xmpp.org/extensions/xep-0368.html
+// [RFC 8305]: https://datatracker.ietf.org/doc/html/rfc8305
+// [RFC 2782]: https://datatracker.ietf.org/doc/html/rfc2782
 package dial // import "mellium.im/xmpp/dial"
 
 import (
@@ -93,6 +139,9 @@ import (
 	"net"
 	"strconv"
 	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
 
 	"mellium.im/xmpp/internal/discover"
 	"mellium.im/xmpp/jid"
@@ -144,6 +193,30 @@ type Dialer struct {
 	// The default value is interpreted as a tls.Config with the expected host set
 	// to that of the connection addresses domain part.
 	TLSConfig *tls.Config
+
+	// AttemptDelay enables parallel connection attempts.
+	// If it is greater than zero, the addresses of all targets are resolved and
+	// a new connection attempt is started each time AttemptDelay elapses
+	// without the previous attempts completing (or immediately if an attempt
+	// fails).
+	// RFC 8305 recommends a delay of 250ms.
+	// If AttemptDelay is zero, targets are dialed one at a time.
+	AttemptDelay time.Duration
+
+	// Proxy, if set, is used to make connections instead of the embedded
+	// net.Dialer.
+	// SRV records are still looked up locally, but the hostnames of the targets
+	// are passed to the proxy to be resolved and parallel connection attempts
+	// are made to each target instead of each of its addresses.
+	// For more information see the Proxies section of the package
+	// documentation.
+	Proxy proxy.ContextDialer
+
+	// Strategy, if set, controls the order in which the targets of SRV records
+	// are dialed and is informed of the result of every connection attempt.
+	// Targets that provide implicit TLS are still tried first.
+	// For more information see the Strategy and Balancer types.
+	Strategy Strategy
 }
 
 // Dial discovers and connects to the address on the named network.
@@ -183,6 +256,14 @@ func (d *Dialer) dial(ctx context.Context, network string, addr jid.JID, server
 	}
 	// If we're not looking up SRV records, use the A/AAAA fallback.
 	if d.NoLookup {
+		if d.AttemptDelay > 0 {
+			var targets []target
+			if !d.NoTLS {
+				targets = append(targets, target{host: server, port: 5223, tls: true})
+			}
+			targets = append(targets, target{host: server, port: 5222})
+			return d.dialParallel(ctx, network, targets, cfg)
+		}
 		return d.legacy(ctx, network, server, cfg)
 	}
 
@@ -218,6 +299,11 @@ func (d *Dialer) dial(ctx context.Context, network string, addr jid.JID, server
 		xmppAddrs = discover.FallbackRecords(xmppService, server)
 	}
 
+	if d.Strategy != nil {
+		xmppsAddrs = d.Strategy.Order(xmppsService, xmppsAddrs)
+		xmppAddrs = d.Strategy.Order(xmppService, xmppAddrs)
+	}
+
 	// If both lookups failed, return the errors.
 	if xmppsErr != nil && xmppErr != nil {
 		return nil, errors.Join(xmppsErr, xmppErr)
@@ -229,29 +315,36 @@ func (d *Dialer) dial(ctx context.Context, network string, addr jid.JID, server
 		return nil, fmt.Errorf("no xmpp service found at address %s", server)
 	}
 
+	if d.AttemptDelay > 0 {
+		targets := make([]target, 0, len(addrs))
+		for i, addr := range addrs {
+			targets = append(targets, target{
+				host: addr.Target,
+				port: addr.Port,
+				tls:  !d.NoTLS && i < len(xmppsAddrs),
+			})
+		}
+		return d.dialParallel(ctx, network, targets, cfg)
+	}
+
 	// Try dialing all of the SRV records we know about, breaking as soon as the
 	// connection is established.
 	var err error
 	for i, addr := range addrs {
 		var c net.Conn
 		var e error
+		hostport := net.JoinHostPort(
+			addr.Target,
+			strconv.FormatUint(uint64(addr.Port), 10),
+		)
 		// Do not dial expecting a TLS connection if we're trying addreses that we
 		// expect starttls on or if we have implicit TLS disabled.
 		if d.NoTLS || i >= len(xmppsAddrs) {
-			c, e = d.Dialer.DialContext(ctx, network, net.JoinHostPort(
-				addr.Target,
-				strconv.FormatUint(uint64(addr.Port), 10),
-			))
+			c, e = d.dialConn(ctx, network, hostport)
 		} else {
-			tlsDialer := &tls.Dialer{
-				NetDialer: &d.Dialer,
-				Config:    cfg,
-			}
-			c, e = tlsDialer.DialContext(ctx, network, net.JoinHostPort(
-				addr.Target,
-				strconv.FormatUint(uint64(addr.Port), 10),
-			))
+			c, e = d.dialTLS(ctx, network, hostport, cfg)
 		}
+		d.report(hostport, e)
 		if e != nil {
 			err = e
 			continue
@@ -264,18 +357,55 @@ func (d *Dialer) dial(ctx context.Context, network string, addr jid.JID, server
 
 func (d *Dialer) legacy(ctx context.Context, network string, domain string, cfg *tls.Config) (net.Conn, error) {
 	if !d.NoTLS {
+		conn, err := d.dialTLS(ctx, network, net.JoinHostPort(domain, "5223"), cfg)
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	return d.dialConn(ctx, network, net.JoinHostPort(domain, "5222"))
+}
+
+// dialConn dials a connection to addr through the proxy if one is configured,
+// or directly otherwise.
+func (d *Dialer) dialConn(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.Proxy != nil {
+		return d.Proxy.DialContext(ctx, network, addr)
+	}
+	return d.Dialer.DialContext(ctx, network, addr)
+}
+
+// dialTLS dials a connection to addr using dialConn and performs a TLS
+// handshake on it.
+func (d *Dialer) dialTLS(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+	if d.Proxy == nil {
 		tlsDialer := &tls.Dialer{
 			NetDialer: &d.Dialer,
 			Config:    cfg,
 		}
-		conn, err := tlsDialer.DialContext(ctx, network,
-			net.JoinHostPort(domain, "5223"))
-		if err == nil {
-			return conn, nil
-		}
+		return tlsDialer.DialContext(ctx, network, addr)
 	}
 
-	return d.Dialer.DialContext(ctx, network, net.JoinHostPort(domain, "5222"))
+	if cfg.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg = cfg.Clone()
+		cfg.ServerName = host
+	}
+	conn, err := d.Proxy.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, cfg)
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		/* #nosec */
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
 }
 
 func connType(useTLS, s2s bool) string {
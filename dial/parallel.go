@@ -0,0 +1,209 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package dial
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// target is a single host and port that a connection may be attempted to.
+type target struct {
+	host string
+	port uint16
+	tls  bool
+}
+
+// attempt is a single address that a connection is attempted to.
+type attempt struct {
+	addr       string
+	serverName string
+	tls        bool
+
+	// target is the host and port of the target that addr was resolved from.
+	target string
+}
+
+// dialParallel resolves the targets to IP addresses and races connections to
+// them as described in RFC 8305.
+func (d *Dialer) dialParallel(ctx context.Context, network string, targets []target, cfg *tls.Config) (net.Conn, error) {
+	var attempts []attempt
+	var err error
+	if d.Proxy != nil {
+		// The proxy resolves hostnames itself so race the targets instead of
+		// their addresses.
+		for _, t := range targets {
+			addr := net.JoinHostPort(t.host, strconv.FormatUint(uint64(t.port), 10))
+			attempts = append(attempts, attempt{
+				addr:       addr,
+				serverName: strings.TrimSuffix(t.host, "."),
+				tls:        t.tls,
+				target:     addr,
+			})
+		}
+	} else {
+		attempts, err = d.resolveTargets(ctx, network, targets)
+	}
+	if len(attempts) == 0 {
+		return nil, err
+	}
+	return race(ctx, d.AttemptDelay, attempts, func(ctx context.Context, a attempt) (net.Conn, error) {
+		conn, err := d.dialAttempt(ctx, network, a, cfg)
+		// Don't penalize attempts that lost the race.
+		if ctx.Err() == nil {
+			d.report(a.target, err)
+		}
+		return conn, err
+	})
+}
+
+func (d *Dialer) dialAttempt(ctx context.Context, network string, a attempt, cfg *tls.Config) (net.Conn, error) {
+	if !a.tls {
+		return d.dialConn(ctx, network, a.addr)
+	}
+	// We may be dialing an IP address so the host can't be inferred by the
+	// TLS dialer.
+	tlsCfg := cfg
+	if tlsCfg.ServerName == "" {
+		tlsCfg = cfg.Clone()
+		tlsCfg.ServerName = a.serverName
+	}
+	return d.dialTLS(ctx, network, a.addr, tlsCfg)
+}
+
+// resolveTargets looks up the IP addresses of all targets concurrently and
+// returns a list of attempts that preserves the order of the targets.
+// The addresses of each target are interleaved by address family, starting
+// with IPv6.
+func (d *Dialer) resolveTargets(ctx context.Context, network string, targets []target) ([]attempt, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs := make([][]net.IPAddr, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			addrs[i], errs[i] = resolver.LookupIPAddr(ctx, host)
+		}(i, t.host)
+	}
+	wg.Wait()
+
+	var attempts []attempt
+	for i, t := range targets {
+		port := strconv.FormatUint(uint64(t.port), 10)
+		serverName := strings.TrimSuffix(t.host, ".")
+		for _, ip := range interleave(network, addrs[i]) {
+			attempts = append(attempts, attempt{
+				addr:       net.JoinHostPort(ip.String(), port),
+				serverName: serverName,
+				tls:        t.tls,
+				target:     net.JoinHostPort(t.host, port),
+			})
+		}
+	}
+	return attempts, errors.Join(errs...)
+}
+
+// interleave sorts addresses so that IPv6 and IPv4 addresses alternate,
+// starting with IPv6, and removes any that cannot be used on network.
+func interleave(network string, addrs []net.IPAddr) []net.IP {
+	var v4, v6 []net.IP
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			if !strings.HasSuffix(network, "6") {
+				v4 = append(v4, addr.IP)
+			}
+			continue
+		}
+		if !strings.HasSuffix(network, "4") {
+			v6 = append(v6, addr.IP)
+		}
+	}
+	ips := make([]net.IP, 0, len(v4)+len(v6))
+	for len(v4) > 0 || len(v6) > 0 {
+		if len(v6) > 0 {
+			ips = append(ips, v6[0])
+			v6 = v6[1:]
+		}
+		if len(v4) > 0 {
+			ips = append(ips, v4[0])
+			v4 = v4[1:]
+		}
+	}
+	return ips
+}
+
+type raceResult struct {
+	conn net.Conn
+	err  error
+}
+
+// race starts a new connection attempt every time delay elapses or the
+// previous attempt fails, whichever comes first, and returns the first
+// connection that succeeds.
+// Any other attempts are canceled and connections that complete after the
+// first one are closed.
+func race(ctx context.Context, delay time.Duration, attempts []attempt, dial func(context.Context, attempt) (net.Conn, error)) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan raceResult, len(attempts))
+
+	var next, inflight int
+	var timeout <-chan time.Time
+	start := func() {
+		a := attempts[next]
+		next++
+		inflight++
+		go func() {
+			conn, err := dial(ctx, a)
+			results <- raceResult{conn: conn, err: err}
+		}()
+		if next < len(attempts) {
+			timeout = time.After(delay)
+		} else {
+			timeout = nil
+		}
+	}
+	start()
+
+	var errs []error
+	for inflight > 0 {
+		select {
+		case <-timeout:
+			start()
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				cancel()
+				// Close any connections that were established while we were busy.
+				go func(inflight int) {
+					for ; inflight > 0; inflight-- {
+						if r := <-results; r.conn != nil {
+							/* #nosec */
+							r.conn.Close()
+						}
+					}
+				}(inflight)
+				return r.conn, nil
+			}
+			errs = append(errs, r.err)
+			if next < len(attempts) {
+				start()
+			}
+		}
+	}
+	cancel()
+	return nil, errors.Join(errs...)
+}
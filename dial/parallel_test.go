@@ -0,0 +1,201 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package dial
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var interleaveTestCases = []struct {
+	network string
+	addrs   []string
+	out     []string
+}{
+	0: {
+		network: "tcp",
+		addrs:   []string{"192.0.2.1", "192.0.2.2", "2001:db8::1", "2001:db8::2", "192.0.2.3"},
+		out:     []string{"2001:db8::1", "192.0.2.1", "2001:db8::2", "192.0.2.2", "192.0.2.3"},
+	},
+	1: {
+		network: "tcp4",
+		addrs:   []string{"2001:db8::1", "192.0.2.1"},
+		out:     []string{"192.0.2.1"},
+	},
+	2: {
+		network: "tcp6",
+		addrs:   []string{"2001:db8::1", "192.0.2.1"},
+		out:     []string{"2001:db8::1"},
+	},
+}
+
+func TestInterleave(t *testing.T) {
+	for i, tc := range interleaveTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var addrs []net.IPAddr
+			for _, a := range tc.addrs {
+				addrs = append(addrs, net.IPAddr{IP: net.ParseIP(a)})
+			}
+			out := []string{}
+			for _, ip := range interleave(tc.network, addrs) {
+				out = append(out, ip.String())
+			}
+			if tc.out == nil {
+				tc.out = []string{}
+			}
+			if !reflect.DeepEqual(out, tc.out) {
+				t.Errorf("wrong order: want=%v, got=%v", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestRaceDelay(t *testing.T) {
+	canceled := make(chan struct{})
+	attempts := []attempt{{addr: "slow"}, {addr: "fast"}}
+	conn, err := race(context.Background(), 10*time.Millisecond, attempts, func(ctx context.Context, a attempt) (net.Conn, error) {
+		if a.addr == "slow" {
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		}
+		c, _ := net.Pipe()
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conn == nil {
+		t.Fatalf("expected a connection")
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Errorf("slow attempt was not canceled")
+	}
+}
+
+func TestRaceFailureStartsNext(t *testing.T) {
+	attempts := []attempt{{addr: "fail"}, {addr: "ok"}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := race(context.Background(), time.Hour, attempts, func(ctx context.Context, a attempt) (net.Conn, error) {
+			if a.addr == "fail" {
+				return nil, errors.New("refused")
+			}
+			c, _ := net.Pipe()
+			return c, nil
+		})
+		if err != nil || conn == nil {
+			t.Errorf("expected connection after failed attempt, got err=%v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("next attempt was not started when the previous one failed")
+	}
+}
+
+func TestRaceAllFail(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	attempts := []attempt{{addr: "a"}, {addr: "b"}}
+	_, err := race(context.Background(), time.Millisecond, attempts, func(ctx context.Context, a attempt) (net.Conn, error) {
+		if a.addr == "a" {
+			return nil, errA
+		}
+		return nil, errB
+	})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("expected all errors to be returned, got %v", err)
+	}
+}
+
+func TestDialParallel(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Close()
+		}
+	}()
+
+	// Find a port that nothing is listening on.
+	closed, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	d := Dialer{AttemptDelay: 50 * time.Millisecond}
+	conn, err := d.dialParallel(context.Background(), "tcp4", []target{
+		{host: "127.0.0.1", port: uint16(closedPort)},
+		{host: "127.0.0.1", port: uint16(ln.Addr().(*net.TCPAddr).Port)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	defer conn.Close()
+	if addr := conn.RemoteAddr().String(); addr != ln.Addr().String() {
+		t.Errorf("connected to wrong address: want=%s, got=%s", ln.Addr(), addr)
+	}
+}
+
+type recordStrategy chan string
+
+func (recordStrategy) Order(_ string, addrs []*net.SRV) []*net.SRV { return addrs }
+
+func (s recordStrategy) Report(target string, err error) {
+	s <- target + " " + strconv.FormatBool(err == nil)
+}
+
+func TestDialParallelReport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer ln.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	closedAddr := closed.Addr().(*net.TCPAddr)
+	/* #nosec */
+	closed.Close()
+	openAddr := ln.Addr().(*net.TCPAddr)
+
+	reports := make(recordStrategy, 2)
+	d := &Dialer{AttemptDelay: time.Second, Strategy: reports}
+	conn, err := d.dialParallel(context.Background(), "tcp", []target{
+		{host: "127.0.0.1", port: uint16(closedAddr.Port)},
+		{host: "127.0.0.1", port: uint16(openAddr.Port)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	/* #nosec */
+	conn.Close()
+
+	for _, want := range []string{
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(closedAddr.Port)) + " false",
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(openAddr.Port)) + " true",
+	} {
+		if got := <-reports; got != want {
+			t.Errorf("wrong report: want=%q, got=%q", want, got)
+		}
+	}
+}
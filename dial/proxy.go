@@ -0,0 +1,219 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package dial
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ProxyFromURL returns a dialer that makes connections through the proxy at
+// u.
+//
+// The "socks5" and "socks5h" schemes create a SOCKS5 proxy dialer and the
+// "http" and "https" schemes create a dialer for HTTP proxies that support the
+// CONNECT method.
+// Any other scheme registered with [proxy.RegisterDialerType] is also
+// supported.
+// If u contains a username and password they are used to authenticate to the
+// proxy.
+//
+// Connections to the proxy itself are made using forward.
+// If forward is nil, [proxy.Direct] is used.
+func ProxyFromURL(u *url.URL, forward proxy.Dialer) (proxy.ContextDialer, error) {
+	d, err := proxyFromURL(u, forward)
+	if err != nil {
+		return nil, err
+	}
+	return contextDialer(d), nil
+}
+
+func proxyFromURL(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+	if forward == nil {
+		forward = proxy.Direct
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &httpProxy{u: u, forward: forward}, nil
+	}
+	return proxy.FromURL(u, forward)
+}
+
+// ProxyFromEnvironment returns a dialer that makes connections through the
+// proxy configured in the environment.
+//
+// The proxy URL is taken from the first of the HTTPS_PROXY and ALL_PROXY
+// environment variables (or their lowercase versions) that is set.
+// If the URL has no scheme it is assumed to be an HTTP proxy.
+// Hosts listed in NO_PROXY (or no_proxy) are dialed directly instead of using
+// the proxy.
+// For the supported schemes see [ProxyFromURL].
+//
+// If no proxy is configured, ProxyFromEnvironment returns nil and no error.
+func ProxyFromEnvironment() (proxy.ContextDialer, error) {
+	rawURL := getEnv("HTTPS_PROXY", "https_proxy", "ALL_PROXY", "all_proxy")
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		u, err = url.Parse("http://" + rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("dial: invalid proxy URL %q: %w", rawURL, err)
+		}
+	}
+	d, err := proxyFromURL(u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if noProxy := getEnv("NO_PROXY", "no_proxy"); noProxy != "" {
+		perHost := proxy.NewPerHost(d, proxy.Direct)
+		perHost.AddFromString(noProxy)
+		return perHost, nil
+	}
+	return contextDialer(d), nil
+}
+
+func getEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// contextDialer returns d if it supports contexts, or wraps it so that the
+// context is checked before dialing otherwise.
+func contextDialer(d proxy.Dialer) proxy.ContextDialer {
+	if cd, ok := d.(proxy.ContextDialer); ok {
+		return cd
+	}
+	return noContextDialer{d}
+}
+
+type noContextDialer struct {
+	proxy.Dialer
+}
+
+func (d noContextDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return d.Dial(network, addr)
+}
+
+// httpProxy dials connections using the HTTP CONNECT method.
+type httpProxy struct {
+	u       *url.URL
+	forward proxy.Dialer
+}
+
+func (p *httpProxy) Dial(network, addr string) (net.Conn, error) {
+	return p.DialContext(context.Background(), network, addr)
+}
+
+func (p *httpProxy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("dial: network %q not supported by HTTP proxy", network)
+	}
+
+	proxyAddr := p.u.Host
+	if p.u.Port() == "" {
+		port := "80"
+		if p.u.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(p.u.Hostname(), port)
+	}
+	conn, err := contextDialer(p.forward).DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Abort the handshake if the context is canceled.
+	stop := context.AfterFunc(ctx, func() {
+		/* #nosec */
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	c, err := p.connect(ctx, conn, addr)
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err != nil {
+		/* #nosec */
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *httpProxy) connect(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	if p.u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName: p.u.Hostname(),
+			MinVersion: tls.VersionTLS12,
+		})
+		err := tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := p.u.User; user != nil {
+		pass, _ := user.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+pass)))
+	}
+	err := req.Write(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	/* #nosec */
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("dial: proxy refused connection to %s: %s", addr, strings.TrimSpace(resp.Status))
+	}
+	if br.Buffered() > 0 {
+		// The server started sending before we finished reading the response,
+		// don't lose the data it sent.
+		return bufConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+type bufConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c bufConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
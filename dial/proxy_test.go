@@ -0,0 +1,214 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package dial_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"mellium.im/xmpp/dial"
+	"mellium.im/xmpp/jid"
+)
+
+// connectProxy starts an HTTP proxy that accepts CONNECT requests and forwards
+// every connection to target regardless of the requested address.
+// Requests are sent to the returned channel.
+func connectProxy(t *testing.T, status int, target string) (string, <-chan *http.Request) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	t.Cleanup(func() {
+		/* #nosec */
+		ln.Close()
+	})
+	reqs := make(chan *http.Request, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				/* #nosec */
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				reqs <- req
+				if status != http.StatusOK {
+					/* #nosec */
+					io.WriteString(conn, "HTTP/1.1 403 Forbidden\r\n\r\n")
+					return
+				}
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				/* #nosec */
+				defer upstream.Close()
+				// Send the first data from the server immediately after the response
+				// to make sure it isn't lost.
+				/* #nosec */
+				io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go func() {
+					/* #nosec */
+					io.Copy(upstream, conn)
+				}()
+				/* #nosec */
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return ln.Addr().String(), reqs
+}
+
+// greeter starts a server that writes a greeting to each connection and then
+// closes it.
+func greeter(t *testing.T, greeting string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	t.Cleanup(func() {
+		/* #nosec */
+		ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			/* #nosec */
+			io.WriteString(conn, greeting)
+			/* #nosec */
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestHTTPProxy(t *testing.T) {
+	const greeting = "<stream:stream>"
+	addr, reqs := connectProxy(t, http.StatusOK, greeter(t, greeting))
+	p, err := dial.ProxyFromURL(&url.URL{
+		Scheme: "http",
+		Host:   addr,
+		User:   url.UserPassword("juliet", "capulet"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("error creating proxy dialer: %v", err)
+	}
+
+	d := dial.Dialer{
+		NoLookup: true,
+		NoTLS:    true,
+		Proxy:    p,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := d.Dial(ctx, "tcp", jid.MustParse("juliet@example.net"))
+	if err != nil {
+		t.Fatalf("error dialing through proxy: %v", err)
+	}
+	/* #nosec */
+	defer conn.Close()
+
+	req := <-reqs
+	if req.Method != http.MethodConnect {
+		t.Errorf("wrong method: want=%s, got=%s", http.MethodConnect, req.Method)
+	}
+	if req.Host != "example.net:5222" {
+		t.Errorf("wrong address requested: want=example.net:5222, got=%s", req.Host)
+	}
+	if auth := req.Header.Get("Proxy-Authorization"); auth != "Basic anVsaWV0OmNhcHVsZXQ=" {
+		t.Errorf("wrong authorization: %q", auth)
+	}
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("error reading from connection: %v", err)
+	}
+	if string(b) != greeting {
+		t.Errorf("wrong data read through proxy: want=%q, got=%q", greeting, b)
+	}
+}
+
+func TestHTTPProxyRefused(t *testing.T) {
+	addr, _ := connectProxy(t, http.StatusForbidden, "")
+	p, err := dial.ProxyFromURL(&url.URL{Scheme: "http", Host: addr}, nil)
+	if err != nil {
+		t.Fatalf("error creating proxy dialer: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = p.DialContext(ctx, "tcp", "example.net:5222")
+	if err == nil {
+		t.Fatalf("expected error when proxy refuses connection")
+	}
+}
+
+func TestProxyFromURLSOCKS5(t *testing.T) {
+	p, err := dial.ProxyFromURL(&url.URL{Scheme: "socks5h", Host: "127.0.0.1:9050"}, nil)
+	if err != nil {
+		t.Fatalf("error creating SOCKS5 dialer: %v", err)
+	}
+	if p == nil {
+		t.Fatalf("expected non-nil dialer")
+	}
+	_, err = dial.ProxyFromURL(&url.URL{Scheme: "gopher", Host: "127.0.0.1:70"}, nil)
+	if err == nil {
+		t.Fatalf("expected error for unknown scheme")
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "ALL_PROXY", "all_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+	p, err := dial.ProxyFromEnvironment()
+	if err != nil || p != nil {
+		t.Fatalf("expected no proxy when environment is empty, got %v, %v", p, err)
+	}
+
+	const greeting = "<stream:stream>"
+	addr, reqs := connectProxy(t, http.StatusOK, greeter(t, greeting))
+	t.Setenv("HTTPS_PROXY", addr)
+	p, err = dial.ProxyFromEnvironment()
+	if err != nil {
+		t.Fatalf("error creating proxy from environment: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := p.DialContext(ctx, "tcp", "example.net:5222")
+	if err != nil {
+		t.Fatalf("error dialing through proxy: %v", err)
+	}
+	/* #nosec */
+	conn.Close()
+	if req := <-reqs; req.Host != "example.net:5222" {
+		t.Errorf("wrong address requested: want=example.net:5222, got=%s", req.Host)
+	}
+
+	t.Setenv("NO_PROXY", "example.net")
+	p, err = dial.ProxyFromEnvironment()
+	if err != nil {
+		t.Fatalf("error creating proxy from environment: %v", err)
+	}
+	if _, ok := p.(*proxy.PerHost); !ok {
+		t.Errorf("expected NO_PROXY to result in a per host dialer, got %T", p)
+	}
+}
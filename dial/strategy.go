@@ -0,0 +1,296 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package dial
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mellium.im/xmpp/internal/discover"
+	"mellium.im/xmpp/jid"
+)
+
+// Strategy controls the order in which the targets of SRV records are dialed
+// and is informed of the result of each connection attempt.
+// This allows connections to be distributed across targets and unhealthy
+// targets to be avoided, which is mostly useful for servers that maintain
+// pools of server-to-server connections.
+//
+// Implementations must be safe for concurrent use.
+type Strategy interface {
+	// Order returns the records in the order that they should be dialed.
+	// It is called once for each service that was looked up (for example
+	// "xmpps-server" and "xmpp-server") and may drop records that should not be
+	// dialed at all.
+	Order(service string, addrs []*net.SRV) []*net.SRV
+
+	// Report is called with the target (as a host:port pair taken from the SRV
+	// record) after each connection attempt along with the error that occurred,
+	// if any.
+	// Attempts that are canceled because another connection was made first are
+	// not reported.
+	Report(target string, err error)
+}
+
+// Defaults used by the Balancer if no value is configured.
+const (
+	DefaultMaxFailures = 3
+	DefaultCooldown    = time.Minute
+	DefaultMaxTargets  = 1024
+)
+
+// Balancer is a Strategy that distributes connections across targets by their
+// priority and weight as described in RFC 2782 and temporarily avoids targets
+// that have failed repeatedly.
+//
+// Targets that are being avoided are not dropped, instead they are moved after
+// all healthy targets so that they are only tried as a last resort.
+// A target is considered healthy again when the cooldown elapses or when a
+// connection to it succeeds.
+//
+// The zero value is a Balancer that is ready to use.
+type Balancer struct {
+	// MaxFailures is the number of consecutive failed attempts after which a
+	// target is avoided.
+	// If MaxFailures is zero, DefaultMaxFailures is used.
+	MaxFailures int
+
+	// Cooldown is how long a target is avoided.
+	// If Cooldown is zero, DefaultCooldown is used.
+	Cooldown time.Duration
+
+	// MaxTargets is the number of targets for which statistics are kept.
+	// When the limit is reached, targets that are not being avoided are
+	// forgotten first to make room for new ones.
+	// If MaxTargets is zero, DefaultMaxTargets is used.
+	MaxTargets int
+
+	// Rand returns a non-negative pseudo-random number in [0,n) and is used to
+	// select between targets with the same priority.
+	// If Rand is nil, the functions from math/rand are used.
+	Rand func(n int) int
+
+	mu      sync.Mutex
+	targets map[string]*TargetHealth
+}
+
+// TargetHealth contains the connection statistics of a single target.
+type TargetHealth struct {
+	// Attempts and Failures are the total number of connection attempts and
+	// failed attempts that have been reported.
+	Attempts int
+	Failures int
+
+	// ConsecutiveFailures is the number of failed attempts since the last
+	// successful one.
+	ConsecutiveFailures int
+
+	// AvoidUntil is the time until which the target is avoided or the zero
+	// time if it is healthy.
+	AvoidUntil time.Time
+}
+
+// FailureRate returns the fraction of attempts that have failed.
+func (h TargetHealth) FailureRate() float64 {
+	if h.Attempts == 0 {
+		return 0
+	}
+	return float64(h.Failures) / float64(h.Attempts)
+}
+
+// Health returns the statistics collected for target.
+func (b *Balancer) Health(target string) TargetHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if h, ok := b.targets[target]; ok {
+		return *h
+	}
+	return TargetHealth{}
+}
+
+// Report satisfies Strategy.
+func (b *Balancer) Report(target string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.targets == nil {
+		b.targets = make(map[string]*TargetHealth)
+	}
+	h, ok := b.targets[target]
+	if !ok {
+		b.evict()
+		h = &TargetHealth{}
+		b.targets[target] = h
+	}
+	h.Attempts++
+	if err == nil {
+		h.ConsecutiveFailures = 0
+		h.AvoidUntil = time.Time{}
+		return
+	}
+	h.Failures++
+	h.ConsecutiveFailures++
+	maxFailures := b.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+	if h.ConsecutiveFailures >= maxFailures {
+		cooldown := b.Cooldown
+		if cooldown <= 0 {
+			cooldown = DefaultCooldown
+		}
+		h.AvoidUntil = time.Now().Add(cooldown)
+	}
+}
+
+// evict removes targets until there is room for a new one.
+// Healthy targets are removed before ones that are being avoided.
+// b.mu must be held.
+func (b *Balancer) evict() {
+	maxTargets := b.MaxTargets
+	if maxTargets <= 0 {
+		maxTargets = DefaultMaxTargets
+	}
+	if len(b.targets) < maxTargets {
+		return
+	}
+	now := time.Now()
+	for target, h := range b.targets {
+		if !now.Before(h.AvoidUntil) {
+			delete(b.targets, target)
+		}
+	}
+	for target := range b.targets {
+		if len(b.targets) < maxTargets {
+			break
+		}
+		delete(b.targets, target)
+	}
+}
+
+// Order satisfies Strategy.
+func (b *Balancer) Order(_ string, addrs []*net.SRV) []*net.SRV {
+	intn := b.Rand
+	if intn == nil {
+		intn = rand.Intn
+	}
+
+	sorted := make([]*net.SRV, len(addrs))
+	copy(sorted, addrs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && sorted[j].Priority == sorted[i].Priority {
+			j++
+		}
+		byWeight(sorted[i:j], intn)
+		i = j
+	}
+
+	now := time.Now()
+	ordered := make([]*net.SRV, 0, len(sorted))
+	var avoided []*net.SRV
+	b.mu.Lock()
+	for _, addr := range sorted {
+		if h, ok := b.targets[targetAddr(addr)]; ok && now.Before(h.AvoidUntil) {
+			avoided = append(avoided, addr)
+			continue
+		}
+		ordered = append(ordered, addr)
+	}
+	b.mu.Unlock()
+	return append(ordered, avoided...)
+}
+
+// byWeight shuffles records with the same priority using the weighted
+// selection algorithm from RFC 2782.
+func byWeight(addrs []*net.SRV, intn func(int) int) {
+	sum := 0
+	for _, addr := range addrs {
+		sum += int(addr.Weight)
+	}
+	for sum > 0 && len(addrs) > 1 {
+		s := 0
+		n := intn(sum)
+		for i := range addrs {
+			s += int(addrs[i].Weight)
+			if s > n {
+				if i > 0 {
+					addrs[0], addrs[i] = addrs[i], addrs[0]
+				}
+				break
+			}
+		}
+		sum -= int(addrs[0].Weight)
+		addrs = addrs[1:]
+	}
+}
+
+func targetAddr(addr *net.SRV) string {
+	return net.JoinHostPort(addr.Target, strconv.FormatUint(uint64(addr.Port), 10))
+}
+
+// report passes the result of a connection attempt to the strategy, if any.
+func (d *Dialer) report(target string, err error) {
+	if d.Strategy != nil {
+		d.Strategy.Report(target, err)
+	}
+}
+
+// Probe looks up the SRV records for addr, dials every target and reports the
+// results to the dialer's Strategy so that unhealthy targets can be avoided
+// before a connection to them is needed.
+// Each connection is closed as soon as it is established and no TLS handshake
+// or XMPP session negotiation is performed.
+// It is meant to be called periodically, for example by servers that maintain
+// a pool of connections to a remote domain.
+//
+// Probe only returns an error if none of the SRV records could be looked up.
+// If the dialer does not have a Strategy, Probe does nothing.
+func (d *Dialer) Probe(ctx context.Context, network string, addr jid.JID) error {
+	if d.Strategy == nil {
+		return nil
+	}
+	services := []string{connType(false, d.S2S)}
+	if !d.NoTLS {
+		services = append(services, connType(true, d.S2S))
+	}
+	var errs []error
+	var wg sync.WaitGroup
+	for _, service := range services {
+		addrs, _, err := discover.LookupServiceByDomain(ctx, d.Resolver, service, addr.Domainpart())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, srv := range addrs {
+			target := targetAddr(srv)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn, err := d.dialConn(ctx, network, target)
+				if conn != nil {
+					/* #nosec */
+					conn.Close()
+				}
+				if ctx.Err() == nil {
+					d.report(target, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if len(errs) < len(services) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package dial_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/dial"
+)
+
+var _ dial.Strategy = (*dial.Balancer)(nil)
+
+func targets(addrs []*net.SRV) []string {
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		out = append(out, addr.Target)
+	}
+	return out
+}
+
+var records = []*net.SRV{
+	{Target: "c", Port: 5269, Priority: 20, Weight: 0},
+	{Target: "a", Port: 5269, Priority: 10, Weight: 0},
+	{Target: "b1", Port: 5269, Priority: 10, Weight: 10},
+	{Target: "b2", Port: 5269, Priority: 10, Weight: 30},
+}
+
+var orderTestCases = [...]struct {
+	rand func(int) int
+	out  []string
+}{
+	0: {
+		rand: func(int) int { return 0 },
+		out:  []string{"b1", "b2", "a", "c"},
+	},
+	1: {
+		rand: func(n int) int { return n - 1 },
+		out:  []string{"b2", "b1", "a", "c"},
+	},
+	2: {
+		// Just enough to skip past b1.
+		rand: func(int) int { return 10 },
+		out:  []string{"b2", "b1", "a", "c"},
+	},
+}
+
+func TestBalancerOrder(t *testing.T) {
+	for i, tc := range orderTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			b := &dial.Balancer{Rand: tc.rand}
+			out := targets(b.Order("xmpp-server", records))
+			if !reflect.DeepEqual(out, tc.out) {
+				t.Errorf("wrong order: want=%v, got=%v", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestBalancerOrderDistribution(t *testing.T) {
+	b := &dial.Balancer{}
+	first := make(map[string]int)
+	const n = 4000
+	for i := 0; i < n; i++ {
+		first[b.Order("xmpp-server", records)[0].Target]++
+	}
+	if first["a"] != 0 || first["c"] != 0 {
+		t.Errorf("targets with zero weight or lower priority should never be first: %v", first)
+	}
+	// b2 has three times the weight of b1, allow for a generous margin.
+	if ratio := float64(first["b2"]) / float64(first["b1"]); ratio < 2 || ratio > 4 {
+		t.Errorf("unexpected distribution: %v", first)
+	}
+}
+
+func TestBalancerHealth(t *testing.T) {
+	b := &dial.Balancer{
+		MaxFailures: 2,
+		Cooldown:    time.Hour,
+		Rand:        func(int) int { return 0 },
+	}
+	errFailed := errors.New("failed")
+	b.Report("b1:5269", errFailed)
+	if out := targets(b.Order("xmpp-server", records)); out[0] != "b1" {
+		t.Errorf("target should not be avoided after a single failure, got order %v", out)
+	}
+	b.Report("b1:5269", errFailed)
+	want := []string{"b2", "a", "c", "b1"}
+	if out := targets(b.Order("xmpp-server", records)); !reflect.DeepEqual(out, want) {
+		t.Errorf("wrong order for unhealthy target: want=%v, got=%v", want, out)
+	}
+
+	h := b.Health("b1:5269")
+	if h.Attempts != 2 || h.Failures != 2 || h.ConsecutiveFailures != 2 || h.AvoidUntil.IsZero() {
+		t.Errorf("unexpected health: %+v", h)
+	}
+
+	b.Report("b1:5269", nil)
+	h = b.Health("b1:5269")
+	if h.ConsecutiveFailures != 0 || !h.AvoidUntil.IsZero() {
+		t.Errorf("success should reset the target health, got %+v", h)
+	}
+	if rate := h.FailureRate(); rate < 0.66 || rate > 0.67 {
+		t.Errorf("wrong failure rate: want=0.66…, got=%f", rate)
+	}
+	if out := targets(b.Order("xmpp-server", records)); out[0] != "b1" {
+		t.Errorf("target should be healthy after a success, got order %v", out)
+	}
+}
+
+func TestBalancerCooldown(t *testing.T) {
+	b := &dial.Balancer{
+		MaxFailures: 1,
+		Cooldown:    time.Millisecond,
+		Rand:        func(int) int { return 0 },
+	}
+	b.Report("b1:5269", errors.New("failed"))
+	time.Sleep(5 * time.Millisecond)
+	if out := targets(b.Order("xmpp-server", records)); out[0] != "b1" {
+		t.Errorf("target should be healthy after the cooldown, got order %v", out)
+	}
+}
+
+func TestBalancerMaxTargets(t *testing.T) {
+	b := &dial.Balancer{
+		MaxFailures: 1,
+		MaxTargets:  2,
+		Cooldown:    time.Hour,
+	}
+	errFailed := errors.New("failed")
+	b.Report("a:5269", errFailed)
+	b.Report("b:5269", nil)
+	b.Report("c:5269", nil)
+	if h := b.Health("b:5269"); h.Attempts != 0 {
+		t.Errorf("expected healthy target to be forgotten, got %+v", h)
+	}
+	if h := b.Health("a:5269"); h.AvoidUntil.IsZero() {
+		t.Errorf("expected avoided target to be kept, got %+v", h)
+	}
+	if h := b.Health("c:5269"); h.Attempts != 1 {
+		t.Errorf("expected new target to be recorded, got %+v", h)
+	}
+}
@@ -7,14 +7,31 @@ package disco
 import (
 	"context"
 	"encoding/xml"
+	"strings"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/crypto"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/form"
 	"mellium.im/xmpp/mux"
 	"mellium.im/xmpp/stanza"
 )
 
+// capsHashes is the list of hashes that are checked when a query for a caps
+// node is received.
+var capsHashes = []crypto.Hash{
+	crypto.SHA1,
+	crypto.SHA224,
+	crypto.SHA256,
+	crypto.SHA384,
+	crypto.SHA512,
+	crypto.SHA3_256,
+	crypto.SHA3_512,
+	crypto.BLAKE2b_256,
+	crypto.BLAKE2b_512,
+}
+
 // HandleCaps calls f for each incoming presence containing entity capabilities
 // information.
 func HandleCaps(f func(stanza.Presence, Caps)) mux.Option {
@@ -32,6 +49,106 @@ func HandleCaps(f func(stanza.Presence, Caps)) mux.Option {
 	})
 }
 
+// MuxInfo returns the identities, features, and forms advertised by the
+// handlers registered on the multiplexer for the given node.
+// It is the same information that is returned in response to service discovery
+// queries by the handler registered with Handle.
+func MuxInfo(m *mux.ServeMux, node string) (Info, error) {
+	i := Info{InfoQuery: InfoQuery{Node: node}}
+	seen := make(map[string]struct{})
+	err := m.ForFeatures(node, func(f info.Feature) error {
+		if _, ok := seen[f.Var]; ok {
+			return nil
+		}
+		seen[f.Var] = struct{}{}
+		i.Features = append(i.Features, f)
+		return nil
+	})
+	if err != nil {
+		return i, err
+	}
+	seen = make(map[string]struct{})
+	err = m.ForIdentities(node, func(ident info.Identity) error {
+		key := ident.Category + ":" + ident.Type + ":" + ident.Name + ":" + ident.Lang
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		i.Identity = append(i.Identity, ident)
+		return nil
+	})
+	if err != nil {
+		return i, err
+	}
+	err = m.ForForms(node, func(f *form.Data) error {
+		i.Form = append(i.Form, *f)
+		return nil
+	})
+	return i, err
+}
+
+// NewCaps returns entity capabilities for the handlers registered on the
+// multiplexer.
+// The node should be a URI that uniquely identifies the software (eg.
+// https://example.com/myclient) and h is the hash used to calculate the
+// verification string.
+//
+// Queries for the caps node are answered by the handler registered with Handle
+// so that other entities can resolve the returned caps.
+func NewCaps(node string, h crypto.Hash, m *mux.ServeMux) (Caps, error) {
+	i, err := MuxInfo(m, "")
+	if err != nil {
+		return Caps{}, err
+	}
+	return Caps{
+		Hash: h,
+		Node: node,
+		Ver:  i.Hash(h.New()),
+	}, nil
+}
+
+// InsertCaps returns a transformer that adds the provided entity capabilities
+// to all top level presence stanzas that are not of type "unavailable".
+func InsertCaps(c Caps) xmlstream.Transformer {
+	return xmlstream.InsertFunc(func(start xml.StartElement, level uint64, w xmlstream.TokenWriter) error {
+		if level != 1 || start.Name.Local != "presence" ||
+			(start.Name.Space != stanza.NSClient && start.Name.Space != stanza.NSServer && start.Name.Space != "") {
+			return nil
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "type" && attr.Value != "" {
+				return nil
+			}
+		}
+		_, err := c.WriteXML(w)
+		return err
+	})
+}
+
+// isCapsNode reports whether the node is of the form "node#ver" where ver is
+// the verification string of the multiplexers root info.
+func isCapsNode(m *mux.ServeMux, node string) bool {
+	idx := strings.LastIndexByte(node, '#')
+	if idx == -1 {
+		return false
+	}
+	ver := node[idx+1:]
+	i, err := MuxInfo(m, "")
+	if err != nil {
+		return false
+	}
+	for _, h := range capsHashes {
+		if h.Available() && len(ver) == base64Len(h.Size()) && i.Hash(h.New()) == ver {
+			return true
+		}
+	}
+	return false
+}
+
+func base64Len(n int) int {
+	return (n + 2) / 3 * 4
+}
+
 // StreamFeature is an informational stream feature that saves any entity caps
 // information that was published by the server during session negotiation.
 // StreamFeature should not be used on the server side.
@@ -0,0 +1,156 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package disco
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"sync"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// defTTL is the default amount of time that discovered information is cached.
+const defTTL = 5 * time.Minute
+
+// ErrFeatureUnsupported is returned by a FeatureGate when the entity that an
+// IQ would be sent to does not advertise the required feature.
+// Checking for it should always be done with errors.Is since it will normally
+// be wrapped in a FeatureError.
+var ErrFeatureUnsupported = errors.New("disco: feature not supported by entity")
+
+// FeatureError contains information about the entity and feature that caused
+// a FeatureGate to return ErrFeatureUnsupported.
+type FeatureError struct {
+	JID     jid.JID
+	Feature string
+}
+
+// Error satisfies the error interface.
+func (e FeatureError) Error() string {
+	return "disco: feature " + e.Feature + " not supported by " + e.JID.String()
+}
+
+// Unwrap returns ErrFeatureUnsupported.
+func (e FeatureError) Unwrap() error {
+	return ErrFeatureUnsupported
+}
+
+// FeatureGate checks that entities advertise a feature before IQs that depend
+// on it are sent, returning ErrFeatureUnsupported early instead of waiting for
+// an error from the remote entity.
+// The zero value is ready to use.
+//
+// Info is fetched the first time an entity is checked and then cached for TTL.
+// If the entity does not support service discovery at all, it is assumed to
+// support every feature and this is cached as well.
+type FeatureGate struct {
+	// TTL is how long the info of an entity is cached before it is fetched
+	// again.
+	// If it is less than or equal to zero, 5 minutes is used.
+	TTL time.Duration
+
+	// Disabled turns off all checks so that IQs are always sent.
+	Disabled bool
+
+	mu    sync.Mutex
+	cache map[string]gateEntry
+}
+
+// gateEntry is the cached result of fetching the info of an entity.
+// If noDisco is set the entity did not support service discovery.
+type gateEntry struct {
+	info    Info
+	noDisco bool
+	expires time.Time
+}
+
+func (g *FeatureGate) get(key string) (gateEntry, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok := g.cache[key]
+	if !ok || time.Now().After(e.expires) {
+		return gateEntry{}, false
+	}
+	return e, true
+}
+
+func (g *FeatureGate) put(key string, e gateEntry) {
+	ttl := g.TTL
+	if ttl <= 0 {
+		ttl = defTTL
+	}
+	now := time.Now()
+	e.expires = now.Add(ttl)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cache == nil {
+		g.cache = make(map[string]gateEntry)
+	}
+	// Remove expired entries so that the cache does not grow without bound as
+	// new entities are checked.
+	for k, v := range g.cache {
+		if now.After(v.expires) {
+			delete(g.cache, k)
+		}
+	}
+	g.cache[key] = e
+}
+
+// Check returns a FeatureError if the entity to does not advertise feature.
+func (g *FeatureGate) Check(ctx context.Context, feature string, to jid.JID, s *xmpp.Session) error {
+	if g.Disabled {
+		return nil
+	}
+	key := to.String()
+	e, ok := g.get(key)
+	if !ok {
+		i, err := GetInfo(ctx, "", to, s)
+		switch {
+		case err == nil:
+			e = gateEntry{info: i}
+		case ignoredErr(err):
+			e = gateEntry{noDisco: true}
+		default:
+			return err
+		}
+		g.put(key, e)
+	}
+	if e.noDisco {
+		return nil
+	}
+	for _, f := range e.info.Features {
+		if f.Var == feature {
+			return nil
+		}
+	}
+	return FeatureError{JID: to, Feature: feature}
+}
+
+// SendIQElement is like the method on Session of the same name except that it
+// first checks that the recipient of the IQ advertises feature.
+func (g *FeatureGate) SendIQElement(ctx context.Context, feature string, payload xml.TokenReader, iq stanza.IQ, s *xmpp.Session) (xmlstream.TokenReadCloser, error) {
+	err := g.Check(ctx, feature, iq.To, s)
+	if err != nil {
+		return nil, err
+	}
+	return s.SendIQElement(ctx, payload, iq)
+}
+
+// UnmarshalIQElement is like the method on Session of the same name except
+// that it first checks that the recipient of the IQ advertises feature.
+func (g *FeatureGate) UnmarshalIQElement(ctx context.Context, feature string, payload xml.TokenReader, iq stanza.IQ, v interface{}, s *xmpp.Session) error {
+	err := g.Check(ctx, feature, iq.To, s)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalIQElement(ctx, payload, iq, v)
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package disco_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/ping"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestFeatureGate(t *testing.T) {
+	m := mux.New(stanza.NSClient, disco.Handle(), ping.Handle())
+	var iqs int32
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			atomic.AddInt32(&iqs, 1)
+			return m.HandleXMPP(t, start)
+		}),
+	)
+	to := jid.MustParse("example.net")
+	g := &disco.FeatureGate{}
+
+	err := g.Check(context.Background(), ping.NS, to, cs.Client)
+	if err != nil {
+		t.Fatalf("unexpected error checking for advertised feature: %v", err)
+	}
+	resp, err := g.SendIQElement(context.Background(), ping.NS, xmlstream.Wrap(
+		nil,
+		xml.StartElement{Name: xml.Name{Space: ping.NS, Local: "ping"}},
+	), stanza.IQ{To: to, Type: stanza.GetIQ}, cs.Client)
+	if err != nil {
+		t.Fatalf("error sending ping: %v", err)
+	}
+	if err = resp.Close(); err != nil {
+		t.Fatalf("error closing response: %v", err)
+	}
+	// One info query and the ping.
+	if n := atomic.LoadInt32(&iqs); n != 2 {
+		t.Errorf("wrong number of IQs: want=2, got=%d", n)
+	}
+
+	const unsupported = "urn:example:unsupported"
+	resp, err = g.SendIQElement(context.Background(), unsupported, nil, stanza.IQ{To: to, Type: stanza.GetIQ}, cs.Client)
+	if !errors.Is(err, disco.ErrFeatureUnsupported) {
+		t.Fatalf("wrong error: want=%v, got=%v", disco.ErrFeatureUnsupported, err)
+	}
+	if resp != nil {
+		t.Errorf("expected no response when feature is unsupported")
+	}
+	var featureErr disco.FeatureError
+	if !errors.As(err, &featureErr) || featureErr.Feature != unsupported || !featureErr.JID.Equal(to) {
+		t.Errorf("wrong feature error: %+v", featureErr)
+	}
+	if n := atomic.LoadInt32(&iqs); n != 2 {
+		t.Errorf("expected cached info to be used and nothing sent, got %d IQs", n)
+	}
+
+	g.Disabled = true
+	if err = g.Check(context.Background(), unsupported, to, cs.Client); err != nil {
+		t.Errorf("unexpected error with checks disabled: %v", err)
+	}
+}
+
+func TestFeatureGateNoDisco(t *testing.T) {
+	m := mux.New(stanza.NSClient)
+	var iqs int32
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			atomic.AddInt32(&iqs, 1)
+			return m.HandleXMPP(t, start)
+		}),
+	)
+	g := &disco.FeatureGate{}
+	for i := 0; i < 2; i++ {
+		err := g.Check(context.Background(), ping.NS, jid.MustParse("example.net"), cs.Client)
+		if err != nil {
+			t.Errorf("expected entities without disco support to pass, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&iqs); n != 1 {
+		t.Errorf("expected lack of disco support to be cached, got %d IQs", n)
+	}
+}
+
+func TestFeatureGateTTL(t *testing.T) {
+	m := mux.New(stanza.NSClient, disco.Handle(), ping.Handle())
+	var iqs int32
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			atomic.AddInt32(&iqs, 1)
+			return m.HandleXMPP(t, start)
+		}),
+	)
+	to := jid.MustParse("example.net")
+	g := &disco.FeatureGate{TTL: time.Millisecond}
+	for i := 0; i < 2; i++ {
+		err := g.Check(context.Background(), ping.NS, to, cs.Client)
+		if err != nil {
+			t.Fatalf("unexpected error checking for advertised feature: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&iqs); n != 2 {
+		t.Errorf("expected info to be fetched again after it expired, got %d IQs", n)
+	}
+}
@@ -18,6 +18,11 @@ import (
 // Handle returns an option that configures a multiplexer to handle service
 // discovery requests by iterating over its own handlers and checking if they
 // implement info.FeatureIter, info.IdentityIter, form.Iter, or items.Iter.
+// Features, identities, and items registered directly on the multiplexer with
+// mux.Feature, mux.Ident, and mux.Items are also included.
+//
+// The node from the request is passed to each iterator so that handlers can
+// respond differently to queries for specific nodes.
 func Handle() mux.Option {
 	return func(m *mux.ServeMux) {
 		h := &discoHandler{ServeMux: m}
@@ -44,6 +49,10 @@ func (h *discoHandler) HandleIQ(iq stanza.IQ, r xmlstream.TokenReadEncoder, star
 			break
 		}
 	}
+	// Queries for our own entity caps node are answered with the root info.
+	if start.Name.Space == NSInfo && isCapsNode(h.ServeMux, node) {
+		node = ""
+	}
 
 	go func() {
 		switch start.Name.Space {
@@ -85,11 +94,12 @@ func (h *discoHandler) HandleIQ(iq stanza.IQ, r xmlstream.TokenReadEncoder, star
 			}))
 		case NSItems:
 			pw.CloseWithError(h.ServeMux.ForItems(node, func(i items.Item) error {
-				_, ok := seen[i.Node]
+				loopKey := i.JID.String() + ":" + i.Node
+				_, ok := seen[loopKey]
 				if ok {
 					return nil
 				}
-				seen[i.Node] = struct{}{}
+				seen[loopKey] = struct{}{}
 				_, err := xmlstream.Copy(pw, i.TokenReader())
 				return err
 			}))
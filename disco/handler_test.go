@@ -11,8 +11,10 @@ import (
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/disco/info"
 	"mellium.im/xmpp/disco/items"
 	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/mux"
 	"mellium.im/xmpp/stanza"
 )
@@ -94,3 +96,83 @@ func TestItemsRoundTrip(t *testing.T) {
 		t.Fatalf("error closing empty iter: %v", err)
 	}
 }
+
+const testNode = "music"
+
+type nodeIter struct{}
+
+func (nodeIter) ForFeatures(node string, f func(info.Feature) error) error {
+	if node != testNode {
+		return nil
+	}
+	return f(info.Feature{Var: "urn:example:music"})
+}
+
+func (nodeIter) ForIdentities(node string, f func(info.Identity) error) error {
+	if node != testNode {
+		return nil
+	}
+	return f(info.Identity{Category: "hierarchy", Type: "leaf"})
+}
+
+func (nodeIter) ForItems(node string, f func(items.Item) error) error {
+	if node != testNode {
+		return nil
+	}
+	// Items without a node but with different JIDs must not be deduplicated.
+	for _, j := range []string{"a@example.net", "b@example.net", "a@example.net"} {
+		err := f(items.Item{JID: jid.MustParse(j)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestNodeRoundTrip(t *testing.T) {
+	m := mux.New(
+		stanza.NSClient,
+		disco.Handle(),
+		mux.Feature(nodeIter{}),
+		mux.Ident(nodeIter{}),
+		mux.Items(nodeIter{}),
+	)
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(m),
+	)
+
+	nodeInfo, err := disco.GetInfoIQ(context.Background(), testNode, stanza.IQ{ID: "123"}, cs.Client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodeInfo.Features) != 1 || nodeInfo.Features[0].Var != "urn:example:music" {
+		t.Errorf("wrong features for node: %v", nodeInfo.Features)
+	}
+	if len(nodeInfo.Identity) != 1 || nodeInfo.Identity[0].Category != "hierarchy" {
+		t.Errorf("wrong identities for node: %v", nodeInfo.Identity)
+	}
+
+	rootInfo, err := disco.GetInfoIQ(context.Background(), "", stanza.IQ{ID: "123"}, cs.Client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rootInfo.Features) != 1 || rootInfo.Features[0].Var != disco.NSInfo {
+		t.Errorf("wrong features for root: %v", rootInfo.Features)
+	}
+
+	iter := disco.FetchItemsIQ(context.Background(), testNode, stanza.IQ{ID: "123"}, cs.Client)
+	var allItems []items.Item
+	for iter.Next() {
+		allItems = append(allItems, iter.Item())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("error iterating over items: %v", err)
+	}
+	err = iter.Close()
+	if err != nil {
+		t.Fatalf("error closing iterator: %v", err)
+	}
+	if len(allItems) != 2 {
+		t.Errorf("wrong number of items: want=2, got=%v", allItems)
+	}
+}
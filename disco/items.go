@@ -199,11 +199,8 @@ func walkItem(ctx context.Context, level, itemIdx int, items []items.Item, s *xm
 		return err
 	}
 
-	// Look for loops and duplicates.
-	for n, oldItem := range items {
-		if n == itemIdx {
-			continue
-		}
+	// Look for loops and duplicates of items that have already been visited.
+	for _, oldItem := range items[:itemIdx] {
 		if oldItem.Node == item.Node && oldItem.JID.Equal(item.JID) {
 			return nil
 		}
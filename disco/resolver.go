@@ -0,0 +1,135 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package disco
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+)
+
+// ErrVerMismatch is returned by a CapsResolver when the info returned by an
+// entity does not match the verification string that it advertised.
+var ErrVerMismatch = errors.New("disco: caps verification string does not match info")
+
+// CapsCache stores the results of service discovery queries by their entity
+// caps verification string.
+// The key is the name of the hash followed by a colon and the verification
+// string (eg. "sha-1:QgayPKawpkPSDYmwT/WM94uAlu0=").
+//
+// Implementations must be safe for concurrent use by multiple goroutines.
+type CapsCache interface {
+	Get(key string) (Info, bool)
+	Put(key string, info Info)
+}
+
+// NewCapsCache returns a CapsCache that stores info in memory.
+func NewCapsCache() CapsCache {
+	return &memCache{m: make(map[string]Info)}
+}
+
+type memCache struct {
+	sync.RWMutex
+	m map[string]Info
+}
+
+func (c *memCache) Get(key string) (Info, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	i, ok := c.m[key]
+	return i, ok
+}
+
+func (c *memCache) Put(key string, info Info) {
+	c.Lock()
+	defer c.Unlock()
+	c.m[key] = info
+}
+
+// CapsResolver resolves entity caps advertised by other entities to the
+// service discovery info that they represent.
+// Results are cached so that only one query is ever made for any given
+// verification string, even when many entities advertise the same caps at the
+// same time.
+type CapsResolver struct {
+	// Cache is used to store resolved info.
+	// If nil, an in memory cache is created on first use.
+	Cache CapsCache
+
+	mu       sync.Mutex
+	inflight map[string]*capsCall
+}
+
+type capsCall struct {
+	done chan struct{}
+	info Info
+	err  error
+}
+
+// Resolve returns the info represented by caps advertised by the entity from.
+// If the info is not cached, it is queried from the entity and, if it matches
+// the verification string, added to the cache.
+// If the info returned by the entity does not match the verification string,
+// the info is returned without being cached along with ErrVerMismatch.
+func (r *CapsResolver) Resolve(ctx context.Context, c Caps, from jid.JID, s *xmpp.Session) (Info, error) {
+	key := c.Hash.String() + ":" + c.Ver
+
+	r.mu.Lock()
+	if r.Cache == nil {
+		r.Cache = NewCapsCache()
+	}
+	cache := r.Cache
+	if i, ok := cache.Get(key); ok {
+		r.mu.Unlock()
+		return i, nil
+	}
+	if r.inflight == nil {
+		r.inflight = make(map[string]*capsCall)
+	}
+	if call, ok := r.inflight[key]; ok {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return Info{}, ctx.Err()
+		}
+		// If the other query failed, try again ourselves since it may have been
+		// the other entity that was at fault.
+		if call.err != nil {
+			return r.Resolve(ctx, c, from, s)
+		}
+		return call.info, nil
+	}
+	call := &capsCall{done: make(chan struct{})}
+	r.inflight[key] = call
+	r.mu.Unlock()
+
+	call.info, call.err = r.query(ctx, c, from, s)
+	if call.err == nil {
+		cache.Put(key, call.info)
+	}
+	r.mu.Lock()
+	delete(r.inflight, key)
+	r.mu.Unlock()
+	close(call.done)
+	return call.info, call.err
+}
+
+func (r *CapsResolver) query(ctx context.Context, c Caps, from jid.JID, s *xmpp.Session) (Info, error) {
+	if !c.Hash.Available() {
+		return Info{}, errors.New("disco: caps hash " + c.Hash.String() + " is not available")
+	}
+	i, err := GetInfo(ctx, c.Node+"#"+c.Ver, from, s)
+	if err != nil {
+		return i, err
+	}
+	if i.Hash(c.Hash.New()) != c.Ver {
+		return i, ErrVerMismatch
+	}
+	return i, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package disco_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/crypto"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/ping"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestCapsResolver(t *testing.T) {
+	m := mux.New(stanza.NSClient, disco.Handle(), ping.Handle())
+	var queries int32
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			atomic.AddInt32(&queries, 1)
+			return m.HandleXMPP(t, start)
+		}),
+	)
+	caps, err := disco.NewCaps("https://mellium.im", crypto.SHA1, m)
+	if err != nil {
+		t.Fatalf("error creating caps: %v", err)
+	}
+
+	r := &disco.CapsResolver{}
+	to := jid.MustParse("example.net")
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, err := r.Resolve(context.Background(), caps, to, cs.Client)
+			if err != nil {
+				t.Errorf("error resolving caps: %v", err)
+				return
+			}
+			var found bool
+			for _, f := range info.Features {
+				if f.Var == ping.NS {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected resolved info to contain ping feature, got %v", info.Features)
+			}
+		}()
+	}
+	wg.Wait()
+	if q := atomic.LoadInt32(&queries); q != 1 {
+		t.Errorf("expected one query, got %d", q)
+	}
+	if _, ok := r.Cache.Get("sha-1:" + caps.Ver); !ok {
+		t.Errorf("expected info to be cached")
+	}
+
+	bad := caps
+	bad.Ver = "QgayPKawpkPSDYmwT/WM94uAlu0="
+	_, err = r.Resolve(context.Background(), bad, to, cs.Client)
+	if !errors.Is(err, disco.ErrVerMismatch) {
+		t.Errorf("wrong error: want=%v, got=%v", disco.ErrVerMismatch, err)
+	}
+	if _, ok := r.Cache.Get("sha-1:" + bad.Ver); ok {
+		t.Errorf("did not expect mismatched info to be cached")
+	}
+}
+
+func TestInsertCaps(t *testing.T) {
+	const in = `<presence xmlns="jabber:client"><show>away</show></presence><presence xmlns="jabber:client" type="unavailable"></presence><message xmlns="jabber:client"></message>`
+	const expected = `<presence xmlns="jabber:client"><c xmlns="http://jabber.org/protocol/caps" hash="sha-1" node="https://mellium.im" ver="123"></c><show xmlns="jabber:client">away</show></presence><presence xmlns="jabber:client" type="unavailable"></presence><message xmlns="jabber:client"></message>`
+	// Prevent duplicate xmlns attributes. See https://mellium.im/issue/75
+	// The attributes are removed before the caps are inserted because
+	// RemoveAttr modifies the start elements in place.
+	r := xmlstream.RemoveAttr(func(start xml.StartElement, attr xml.Attr) bool {
+		return (start.Name.Local == "presence" || start.Name.Local == "message") && attr.Name.Local == "xmlns"
+	})(xml.NewDecoder(strings.NewReader(in)))
+	r = disco.InsertCaps(disco.Caps{
+		Hash: crypto.SHA1,
+		Node: "https://mellium.im",
+		Ver:  "123",
+	})(r)
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	_, err := xmlstream.Copy(e, r)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	if err = e.Flush(); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	if s := buf.String(); s != expected {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", expected, s)
+	}
+}
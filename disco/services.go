@@ -0,0 +1,266 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package disco
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/disco/items"
+	"mellium.im/xmpp/jid"
+)
+
+const defConcurrency = 4
+
+// Service is an entity discovered by a ServiceFinder along with the identities
+// and features that it advertised.
+type Service struct {
+	items.Item
+	Info Info
+}
+
+// HasIdentity reports whether the service advertised an identity with the same
+// category and type as ident.
+// The name and language of the identity are ignored.
+func (s Service) HasIdentity(ident info.Identity) bool {
+	for _, i := range s.Info.Identity {
+		if i.Category == ident.Category && i.Type == ident.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFeature reports whether the service advertised the feature.
+func (s Service) HasFeature(feature string) bool {
+	for _, f := range s.Info.Features {
+		if f.Var == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceFinder walks the items of a server and queries each of them for their
+// identities and features so that services such as the servers multi-user chat
+// or file upload components can be found without writing the discovery logic
+// by hand.
+//
+// Results are cached per server and depth for TTL or until Purge is called.
+// The zero value is a ServiceFinder that walks the servers direct items and
+// makes up to 4 info queries at a time.
+// A ServiceFinder is safe for concurrent use by multiple goroutines.
+type ServiceFinder struct {
+	// Depth is the number of levels of items below the server to walk.
+	// If it is less than one, only the servers direct items are queried, which
+	// is where services are normally advertised.
+	Depth int
+
+	// Concurrency is the maximum number of info queries that may be in flight at
+	// once.
+	// If it is less than one, 4 is used.
+	Concurrency int
+
+	// TTL is how long the services found on a server are cached before the
+	// server is walked again.
+	// If it is less than or equal to zero, 5 minutes is used.
+	TTL time.Duration
+
+	mu    sync.Mutex
+	cache map[serviceKey]serviceEntry
+}
+
+// serviceKey identifies the results of walking a server to a given depth.
+type serviceKey struct {
+	server string
+	depth  int
+}
+
+type serviceEntry struct {
+	services []Service
+	expires  time.Time
+}
+
+func (f *ServiceFinder) get(key serviceKey) ([]Service, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.cache[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.services, true
+}
+
+func (f *ServiceFinder) put(key serviceKey, services []Service) {
+	ttl := f.TTL
+	if ttl <= 0 {
+		ttl = defTTL
+	}
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cache == nil {
+		f.cache = make(map[serviceKey]serviceEntry)
+	}
+	// Remove expired entries so that the cache does not grow without bound as
+	// new servers are walked.
+	for k, v := range f.cache {
+		if now.After(v.expires) {
+			delete(f.cache, k)
+		}
+	}
+	f.cache[key] = serviceEntry{services: services, expires: now.Add(ttl)}
+}
+
+func (f *ServiceFinder) depth() int {
+	if f.Depth < 1 {
+		return 1
+	}
+	return f.Depth
+}
+
+// Services returns the server itself and every service found by walking its
+// items, in the order they were discovered.
+// Items that do not respond to info queries are omitted.
+func (f *ServiceFinder) Services(ctx context.Context, server jid.JID, s *xmpp.Session) ([]Service, error) {
+	key := serviceKey{server: server.String(), depth: f.depth()}
+	services, ok := f.get(key)
+	if ok {
+		return services, nil
+	}
+
+	found, err := f.walk(ctx, server, s)
+	if err != nil {
+		return nil, err
+	}
+	services, err = f.query(ctx, found, s)
+	if err != nil {
+		return nil, err
+	}
+	f.put(key, services)
+	return services, nil
+}
+
+// Find returns the services on the server that advertise an identity with the
+// same category and type as ident.
+// For example, to find a servers multi-user chat service:
+//
+//	services, err := finder.Find(ctx, server, disco.ConferenceText, session)
+func (f *ServiceFinder) Find(ctx context.Context, server jid.JID, ident info.Identity, s *xmpp.Session) ([]Service, error) {
+	return f.filter(ctx, server, s, func(svc Service) bool {
+		return svc.HasIdentity(ident)
+	})
+}
+
+// FindFeature returns the services on the server that advertise the feature.
+func (f *ServiceFinder) FindFeature(ctx context.Context, server jid.JID, feature string, s *xmpp.Session) ([]Service, error) {
+	return f.filter(ctx, server, s, func(svc Service) bool {
+		return svc.HasFeature(feature)
+	})
+}
+
+// Purge removes the cached services for server at every depth.
+// If server is the zero JID, all cached services are removed.
+func (f *ServiceFinder) Purge(server jid.JID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if server.Equal(jid.JID{}) {
+		f.cache = nil
+		return
+	}
+	s := server.String()
+	for k := range f.cache {
+		if k.server == s {
+			delete(f.cache, k)
+		}
+	}
+}
+
+func (f *ServiceFinder) filter(ctx context.Context, server jid.JID, s *xmpp.Session, match func(Service) bool) ([]Service, error) {
+	services, err := f.Services(ctx, server, s)
+	if err != nil {
+		return nil, err
+	}
+	var matched []Service
+	for _, svc := range services {
+		if match(svc) {
+			matched = append(matched, svc)
+		}
+	}
+	return matched, nil
+}
+
+func (f *ServiceFinder) walk(ctx context.Context, server jid.JID, s *xmpp.Session) ([]items.Item, error) {
+	depth := f.depth()
+	var found []items.Item
+	seen := make(map[string]struct{})
+	err := WalkItem(ctx, items.Item{JID: server}, s, func(level int, item items.Item, err error) error {
+		if err != nil {
+			// Failing to list the servers items is fatal, but any other item that
+			// does not have items of its own may still be a service.
+			if level == 0 {
+				return err
+			}
+			return nil
+		}
+		key := item.JID.String() + ":" + item.Node
+		if _, ok := seen[key]; ok {
+			return ErrSkipItem
+		}
+		seen[key] = struct{}{}
+		found = append(found, item)
+		if level >= depth {
+			return ErrSkipItem
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (f *ServiceFinder) query(ctx context.Context, found []items.Item, s *xmpp.Session) ([]Service, error) {
+	concurrency := f.Concurrency
+	if concurrency < 1 {
+		concurrency = defConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	results := make([]*Service, len(found))
+	var wg sync.WaitGroup
+	for i, item := range found {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int, item items.Item) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			itemInfo, err := GetInfo(ctx, item.Node, item.JID, s)
+			if err != nil {
+				return
+			}
+			results[i] = &Service{Item: item, Info: itemInfo}
+		}(i, item)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	services := make([]Service, 0, len(results))
+	for _, svc := range results {
+		if svc != nil {
+			services = append(services, *svc)
+		}
+	}
+	return services, nil
+}
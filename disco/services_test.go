@@ -0,0 +1,155 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package disco_test
+
+import (
+	"context"
+	"encoding/xml"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/disco/items"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	testServer = jid.MustParse("example.net")
+	testMUC    = jid.MustParse("conference.example.net")
+	testUpload = jid.MustParse("upload.example.net")
+	testBroken = jid.MustParse("broken.example.net")
+)
+
+func serviceHandler(queries *int32) xmpptest.Option {
+	serviceItems := map[string][]items.Item{
+		testServer.String(): {{JID: testMUC}, {JID: testUpload}, {JID: testBroken}, {JID: testMUC}},
+		testMUC.String():    {{JID: jid.MustParse("room@conference.example.net")}},
+	}
+	serviceInfo := map[string]disco.Info{
+		testServer.String(): {Identity: []info.Identity{disco.ServerIM}},
+		testMUC.String():    {Identity: []info.Identity{disco.ConferenceText}},
+		testUpload.String(): {
+			Identity: []info.Identity{disco.StoreFile},
+			Features: []info.Feature{{Var: "urn:xmpp:http:upload:0"}},
+		},
+	}
+	return xmpptest.ServerHandlerFunc(func(e xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		tok, err := e.Token()
+		if err != nil {
+			return err
+		}
+		payload := tok.(xml.StartElement)
+		switch payload.Name.Space {
+		case disco.NSItems:
+			var list []xml.TokenReader
+			for _, item := range serviceItems[iq.To.String()] {
+				list = append(list, item.TokenReader())
+			}
+			_, err = xmlstream.Copy(e, iq.Result(xmlstream.Wrap(
+				xmlstream.MultiReader(list...),
+				xml.StartElement{Name: xml.Name{Space: disco.NSItems, Local: "query"}},
+			)))
+			return err
+		case disco.NSInfo:
+			atomic.AddInt32(queries, 1)
+			i, ok := serviceInfo[iq.To.String()]
+			if !ok {
+				_, err = xmlstream.Copy(e, iq.Error(stanza.Error{Type: stanza.Cancel, Condition: stanza.ItemNotFound}))
+				return err
+			}
+			_, err = xmlstream.Copy(e, iq.Result(i.TokenReader()))
+			return err
+		}
+		return nil
+	})
+}
+
+func TestServiceFinder(t *testing.T) {
+	var queries int32
+	cs := xmpptest.NewClientServer(serviceHandler(&queries))
+	finder := &disco.ServiceFinder{Concurrency: 2}
+
+	services, err := finder.Services(context.Background(), testServer, cs.Client)
+	if err != nil {
+		t.Fatalf("error finding services: %v", err)
+	}
+	if len(services) != 3 {
+		t.Fatalf("wrong number of services: want=3, got=%+v", services)
+	}
+	// The room is below the walk depth and the broken item is duplicated and
+	// does not respond to info queries.
+	if q := atomic.LoadInt32(&queries); q != 4 {
+		t.Errorf("wrong number of info queries: want=4, got=%d", q)
+	}
+
+	muc, err := finder.Find(context.Background(), testServer, disco.ConferenceText, cs.Client)
+	if err != nil {
+		t.Fatalf("error finding MUC service: %v", err)
+	}
+	if len(muc) != 1 || !muc[0].JID.Equal(testMUC) {
+		t.Errorf("wrong MUC services: %+v", muc)
+	}
+	upload, err := finder.FindFeature(context.Background(), testServer, "urn:xmpp:http:upload:0", cs.Client)
+	if err != nil {
+		t.Fatalf("error finding upload service: %v", err)
+	}
+	if len(upload) != 1 || !upload[0].JID.Equal(testUpload) {
+		t.Errorf("wrong upload services: %+v", upload)
+	}
+	if q := atomic.LoadInt32(&queries); q != 4 {
+		t.Errorf("results were not cached, got %d info queries", q)
+	}
+
+	// Changing the depth must not return the results cached for the old depth.
+	finder.Depth = 2
+	services, err = finder.Services(context.Background(), testServer, cs.Client)
+	if err != nil {
+		t.Fatalf("error finding services at depth 2: %v", err)
+	}
+	if len(services) != 3 {
+		t.Errorf("wrong number of services at depth 2: want=3, got=%+v", services)
+	}
+	if q := atomic.LoadInt32(&queries); q != 9 {
+		t.Errorf("wrong number of info queries at depth 2: want=9, got=%d", q)
+	}
+
+	finder.Purge(testServer)
+	finder.Depth = 0
+	_, err = finder.Services(context.Background(), testServer, cs.Client)
+	if err != nil {
+		t.Fatalf("error finding services after purge: %v", err)
+	}
+	if q := atomic.LoadInt32(&queries); q != 13 {
+		t.Errorf("wrong number of info queries after purge: want=13, got=%d", q)
+	}
+}
+
+func TestServiceFinderTTL(t *testing.T) {
+	var queries int32
+	cs := xmpptest.NewClientServer(serviceHandler(&queries))
+	finder := &disco.ServiceFinder{TTL: time.Millisecond}
+
+	_, err := finder.Services(context.Background(), testServer, cs.Client)
+	if err != nil {
+		t.Fatalf("error finding services: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	_, err = finder.Services(context.Background(), testServer, cs.Client)
+	if err != nil {
+		t.Fatalf("error finding services after TTL: %v", err)
+	}
+	if q := atomic.LoadInt32(&queries); q != 8 {
+		t.Errorf("expected services to be queried again after the TTL, got %d info queries", q)
+	}
+}
@@ -8,17 +8,17 @@ package main
 import (
 	"bufio"
 	"context"
-	"crypto/tls"
 	"encoding/xml"
+	"flag"
 	"fmt"
 	"log"
-	"mellium.im/xmlstream"
 	"os"
 	"strings"
 	"text/tabwriter"
 
-	"mellium.im/sasl"
+	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
+	"mellium.im/xmpp/botkit"
 	"mellium.im/xmpp/commands"
 	"mellium.im/xmpp/form"
 	"mellium.im/xmpp/jid"
@@ -28,58 +28,42 @@ import (
 )
 
 func main() {
-	addr := os.Getenv("XMPP_ADDR")
-	pass := os.Getenv("XMPP_PASS")
-
-	myJID, err := jid.Parse(addr)
-	if err != nil {
-		log.Fatalf("error parsing XMPP_ADDR %q as a JID: %v", addr, err)
-	}
+	cfg := botkit.Config{}
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cfg.RegisterFlags(flags)
+	/* #nosec */
+	flags.Parse(os.Args[1:])
 
-	if len(os.Args) < 2 {
+	args := flags.Args()
+	if len(args) < 1 {
 		log.Fatalf("not enough arguments, missing JID to query")
 	}
 
-	theirAddr := os.Args[1]
+	theirAddr := args[0]
 	theirJID, err := jid.Parse(theirAddr)
 	if err != nil {
 		log.Fatalf("error parsing argument %q as a JID: %v", theirAddr, err)
 	}
 
-	session, err := xmpp.DialClientSession(
-		context.TODO(),
-		myJID,
-		xmpp.StartTLS(&tls.Config{
-			ServerName: myJID.Domain().String(),
-			MinVersion: tls.VersionTLS12,
-		}),
-		xmpp.SASL("", pass, sasl.ScramSha256Plus, sasl.ScramSha1Plus, sasl.ScramSha256, sasl.ScramSha1, sasl.Plain),
-		xmpp.BindResource(),
-	)
-	if err != nil {
-		log.Fatalf("error logging in as %s: %v", myJID, err)
-	}
+	err = botkit.Do(context.Background(), cfg, nil, func(ctx context.Context, session *xmpp.Session) error {
+		cmdIter := commands.Fetch(ctx, theirJID, session)
 
-	go func() {
-		err := session.Serve(nil)
-		if err != nil {
-			log.Fatalf("session ended with error: %v", err)
+		if len(args) > 1 {
+			err := executeCommand(ctx, args[1], cmdIter, theirJID, session)
+			if err != nil {
+				return fmt.Errorf("error executing %s: %w", args[1], err)
+			}
+			return nil
 		}
-	}()
-
-	cmdIter := commands.Fetch(context.TODO(), theirJID, session)
 
-	if len(os.Args) > 2 {
-		err = executeCommand(context.TODO(), os.Args[2], cmdIter, theirJID, session)
+		err := listCommands(cmdIter, theirJID, session)
 		if err != nil {
-			log.Fatalf("error executing %s: %v", os.Args[2], err)
+			return fmt.Errorf("error listing ad-hoc commands: %w", err)
 		}
-		return
-	}
-
-	err = listCommands(cmdIter, theirJID, session)
+		return nil
+	})
 	if err != nil {
-		log.Fatalf("error listing ad-hoc commands: %v", err)
+		log.Fatal(err)
 	}
 }
 
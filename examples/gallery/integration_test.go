@@ -0,0 +1,126 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mellium.im/sasl"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/botkit"
+	"mellium.im/xmpp/internal/integration"
+	"mellium.im/xmpp/internal/integration/prosody"
+)
+
+const uploadDomain = "upload.localhost"
+
+// testEnv returns an environment that dials sessions using cmd and writes
+// command output to out.
+func testEnv(t *testing.T, cmd *integration.Cmd, out io.Writer) env {
+	j, pass := cmd.User()
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+	return env{
+		Config: botkit.Config{
+			Addr:     j.String(),
+			Password: pass,
+			Logger:   log.New(io.Discard, "", 0),
+			Session: func(ctx context.Context, cfg botkit.Config) (*xmpp.Session, error) {
+				return cmd.DialClient(ctx, j, t,
+					xmpp.StartTLS(&tls.Config{
+						InsecureSkipVerify: true,
+					}),
+					xmpp.SASL("", cfg.Password, sasl.Plain),
+					xmpp.BindResource(),
+				)
+			},
+		},
+		Out:        out,
+		HTTPClient: client,
+	}
+}
+
+func TestIntegrationUpload(t *testing.T) {
+	prosodyRun := prosody.Test(context.TODO(), t,
+		integration.Log(),
+		prosody.Upload(uploadDomain),
+		prosody.ListenC2S(),
+	)
+	prosodyRun(integrationUpload)
+}
+
+func integrationUpload(ctx context.Context, t *testing.T, cmd *integration.Cmd) {
+	file := []byte("Old One was he	and his medicine was strong.")
+	fPath := filepath.Join(t.TempDir(), "incipit.txt")
+	err := os.WriteFile(fPath, file, 0600)
+	if err != nil {
+		t.Fatalf("error writing test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	e := testEnv(t, cmd, &out)
+	err = uploadCmd.Run(ctx, e, flag.NewFlagSet(uploadCmd.Name, flag.ContinueOnError), []string{fPath})
+	if err != nil {
+		t.Fatalf("error running upload: %v", err)
+	}
+
+	link := strings.TrimSpace(out.String())
+	resp, err := e.HTTPClient.Get(link)
+	if err != nil {
+		t.Fatalf("error fetching uploaded file: %v", err)
+	}
+	/* #nosec */
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("file download failed with code %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	fileOut, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error downloading file: %v", err)
+	}
+	if !bytes.Equal(fileOut, file) {
+		t.Fatalf("file contents do not match: want=%q, got=%q", file, fileOut)
+	}
+}
+
+func TestIntegrationMAMDump(t *testing.T) {
+	prosodyRun := prosody.Test(context.TODO(), t,
+		integration.Log(),
+		prosody.Modules("mam"),
+		prosody.ListenC2S(),
+	)
+	prosodyRun(integrationMAMDump)
+}
+
+func integrationMAMDump(ctx context.Context, t *testing.T, cmd *integration.Cmd) {
+	var out bytes.Buffer
+	e := testEnv(t, cmd, &out)
+	err := mamdumpCmd.Run(ctx, e, flag.NewFlagSet(mamdumpCmd.Name, flag.ContinueOnError), []string{"-format", "jsonl"})
+	if err != nil {
+		t.Fatalf("error running mamdump: %v", err)
+	}
+	// The archive of a new account is empty.
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output from empty archive: %q", out.String())
+	}
+}
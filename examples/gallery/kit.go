@@ -0,0 +1,116 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/botkit"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/disco/items"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var errNoService = errors.New("no service found, try specifying one")
+
+// runOnce establishes a session, calls f once the session is online, and ends
+// the session when f returns.
+// Stanzas are handled using a multiplexer configured with opts.
+func runOnce(ctx context.Context, e env, f func(context.Context, *xmpp.Session) error, opts ...mux.Option) error {
+	return run(ctx, e.Config, true, f, opts...)
+}
+
+// runBot is like runOnce except that it continues to handle stanzas after f
+// returns until the session ends, reconnecting (and calling f again) if
+// configured.
+// Initial presence is sent before f is called.
+func runBot(ctx context.Context, e env, f func(context.Context, *xmpp.Session) error, opts ...mux.Option) error {
+	return run(ctx, e.Config, false, func(ctx context.Context, s *xmpp.Session) error {
+		err := s.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence}.Wrap(nil))
+		if err != nil {
+			return err
+		}
+		return f(ctx, s)
+	}, opts...)
+}
+
+func run(ctx context.Context, cfg botkit.Config, once bool, f func(context.Context, *xmpp.Session) error, opts ...mux.Option) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The session is only handled after going online, so f is run in a
+	// goroutine where it can wait on responses from the server.
+	var wg sync.WaitGroup
+	errs := make(chan error, 1)
+	cfg.Reconnect = cfg.Reconnect && !once
+	cfg.Online = func(ctx context.Context, s *xmpp.Session) error {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := f(ctx, s)
+			if err == nil && !once {
+				return
+			}
+			if err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+			cancel()
+		}()
+		return nil
+	}
+	err := botkit.Run(ctx, cfg, mux.New(stanza.NSClient, opts...))
+	cancel()
+	wg.Wait()
+	select {
+	case fErr := <-errs:
+		return fErr
+	default:
+	}
+	return err
+}
+
+// findService returns the first item of the server that supports feature.
+func findService(ctx context.Context, s *xmpp.Session, feature info.Feature) (jid.JID, error) {
+	server := s.LocalAddr().Domain()
+	var found jid.JID
+	iter := disco.FetchItems(ctx, items.Item{JID: server}, s)
+	for iter.Next() {
+		item := iter.Item()
+		discoInfo, err := disco.GetInfo(ctx, item.Node, item.JID, s)
+		if err != nil {
+			continue
+		}
+		for _, f := range discoInfo.Features {
+			if f.Var == feature.Var {
+				found = item.JID
+				break
+			}
+		}
+		if !found.Equal(jid.JID{}) {
+			break
+		}
+	}
+	err := iter.Err()
+	if e := iter.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return found, fmt.Errorf("error discovering services: %w", err)
+	}
+	if found.Equal(jid.JID{}) {
+		return found, errNoService
+	}
+	return found, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// The gallery command is a collection of small programs that show how the
+// higher level packages in this module fit together.
+// Each program is a subcommand that connects to the server using the address
+// in $XMPP_ADDR (or the -addr flag) and the password in $XMPP_PASS.
+//
+// The available commands are:
+//
+//	upload      upload a file using HTTP File Upload and print or send the link
+//	mamdump     export messages from a message archive
+//	muclog      join a group chat and log its messages
+//	pubsubwatch subscribe to a publish-subscribe node and print new items
+//
+// For more information try running:
+//
+//	gallery -help
+//	gallery <command> -help
+//
+// The commands double as integration tests for the packages they use.
+// To run them against a real server use the "integration" build tag:
+//
+//	go test -tags integration -run Integration
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"mellium.im/xmpp/botkit"
+)
+
+// env is the environment shared by all commands.
+type env struct {
+	botkit.Config
+
+	// Out is where command output is written.
+	Out io.Writer
+
+	// HTTPClient is used by commands that make HTTP requests.
+	HTTPClient *http.Client
+}
+
+// command is a single example program.
+type command struct {
+	Name        string
+	Args        string
+	Description string
+
+	// Run registers any flags used by the command on flags, parses args, and
+	// runs the command.
+	Run func(ctx context.Context, e env, flags *flag.FlagSet, args []string) error
+}
+
+// flagSet returns a new flag set for the command that reports errors instead
+// of exiting.
+func (c command) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n\n\t%s %s\n\n%s\n\n", c.Name, c.Name, c.Args, c.Description)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+var commands = []command{
+	uploadCmd,
+	mamdumpCmd,
+	muclogCmd,
+	pubsubwatchCmd,
+}
+
+func main() {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	e := env{
+		Config: botkit.Config{
+			Logger: logger,
+		},
+		Out:        os.Stdout,
+		HTTPClient: http.DefaultClient,
+	}
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	e.RegisterFlags(flags)
+	flags.Usage = func() {
+		w := flags.Output()
+		fmt.Fprintf(w, "Usage of %s:\n\n\t%s [options] <command> [arguments]\n\nCommands:\n\n", os.Args[0], os.Args[0])
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, c := range commands {
+			fmt.Fprintf(tw, "\t%s\t%s\n", c.Name, c.Description)
+		}
+		/* #nosec */
+		tw.Flush()
+		fmt.Fprintf(w, "\nOptions:\n\n")
+		flags.PrintDefaults()
+	}
+	/* #nosec */
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.Name != args[0] {
+			continue
+		}
+		err := c.Run(context.Background(), e, c.flagSet(), args[1:])
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+		case err != nil:
+			logger.Fatal(err)
+		}
+		return
+	}
+	logger.Printf("unknown command %q", args[0])
+	flags.Usage()
+	os.Exit(2)
+}
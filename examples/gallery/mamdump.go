@@ -0,0 +1,96 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/jid"
+)
+
+var mamdumpCmd = command{
+	Name:        "mamdump",
+	Args:        "[options]",
+	Description: "Export messages from a message archive.",
+	Run:         runMAMDump,
+}
+
+func runMAMDump(ctx context.Context, e env, flags *flag.FlagSet, args []string) error {
+	var (
+		archive string
+		with    string
+		format  = "text"
+		since   time.Duration
+		max     uint64
+		page    uint64 = 50
+	)
+	flags.StringVar(&archive, "archive", "", "The archive to query, the user's own archive if not set.")
+	flags.StringVar(&with, "with", "", "Only export messages exchanged with this address.")
+	flags.StringVar(&format, "format", format, `The output format, one of "text", "jsonl", or "html".`)
+	flags.DurationVar(&since, "since", since, "Only export messages newer than this.")
+	flags.Uint64Var(&max, "max", max, "The maximum number of messages to export, or 0 for no limit.")
+	flags.Uint64Var(&page, "page", page, "The number of messages to request at once.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	switch format {
+	case "text", "jsonl", "html":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	var archiveJID jid.JID
+	if archive != "" {
+		archiveJID, err = jid.Parse(archive)
+		if err != nil {
+			return fmt.Errorf("error parsing archive address: %w", err)
+		}
+	}
+	q := history.Query{Limit: page}
+	if with != "" {
+		q.With, err = jid.Parse(with)
+		if err != nil {
+			return fmt.Errorf("error parsing address to filter by: %w", err)
+		}
+	}
+	if since > 0 {
+		q.Start = time.Now().Add(-since)
+	}
+
+	h := history.NewHandler(nil)
+	return runOnce(ctx, e, func(ctx context.Context, s *xmpp.Session) error {
+		iter := h.FetchAll(ctx, q, max, archiveJID, s)
+		var exporter history.Exporter
+		switch format {
+		case "jsonl":
+			err = exporter.JSONL(e.Out, iter)
+		case "html":
+			title := "Archive of " + s.LocalAddr().Bare().String()
+			if !q.With.Equal(jid.JID{}) {
+				title = "Conversation with " + q.With.String()
+			}
+			err = exporter.HTML(e.Out, title, iter)
+		default:
+			err = exporter.Text(e.Out, iter)
+		}
+		if e := iter.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			return fmt.Errorf("error exporting archive: %w", err)
+		}
+		return nil
+	}, history.Handle(h))
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/delay"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/muc"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var muclogCmd = command{
+	Name:        "muclog",
+	Args:        "[options] <room>",
+	Description: "Join a group chat and log its messages.",
+	Run:         runMUCLog,
+}
+
+// groupMessage is a group chat message that may have been delayed because it
+// was sent as part of the room history.
+type groupMessage struct {
+	stanza.Message
+	Body  string      `xml:"body"`
+	Delay delay.Delay `xml:"urn:xmpp:delay delay"`
+}
+
+func runMUCLog(ctx context.Context, e env, flags *flag.FlagSet, args []string) error {
+	var (
+		nick    string
+		history uint64
+	)
+	flags.StringVar(&nick, "nick", "", "The nickname to use in the room, the localpart of the bot's address if not set.")
+	flags.Uint64Var(&history, "history", 0, "The number of messages from the room history to log on join.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	room, err := jid.Parse(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error parsing room address: %w", err)
+	}
+	room = room.Bare()
+
+	var outM sync.Mutex
+	logMsg := func(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+		if !msg.From.Bare().Equal(room) {
+			return nil
+		}
+		m := groupMessage{}
+		err := xml.NewTokenDecoder(t).Decode(&m)
+		if err != nil {
+			return err
+		}
+		if m.Body == "" {
+			return nil
+		}
+		stamp := m.Delay.Time
+		if stamp.IsZero() {
+			stamp = time.Now()
+		}
+		outM.Lock()
+		defer outM.Unlock()
+		_, err = fmt.Fprintf(e.Out, "%s <%s> %s\n", stamp.UTC().Format(time.RFC3339), m.From.Resourcepart(), m.Body)
+		return err
+	}
+
+	mucClient := &muc.Client{}
+	return runBot(ctx, e, func(ctx context.Context, s *xmpp.Session) error {
+		n := nick
+		if n == "" {
+			n = s.LocalAddr().Localpart()
+		}
+		roomJID, err := room.WithResource(n)
+		if err != nil {
+			return fmt.Errorf("error creating room address: %w", err)
+		}
+		channel, err := mucClient.Join(ctx, roomJID, s, muc.MaxHistory(history))
+		if err != nil {
+			return fmt.Errorf("error joining room: %w", err)
+		}
+		e.Logger.Printf("Joined %s as %s", channel.Addr(), channel.Me().Resourcepart())
+		return nil
+	},
+		muc.HandleClient(mucClient),
+		mux.MessageFunc(stanza.GroupChatMessage, xml.Name{Local: "body"}, logMsg),
+	)
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+var pubsubwatchCmd = command{
+	Name:        "pubsubwatch",
+	Args:        "[options] <node>",
+	Description: "Subscribe to a publish-subscribe node and print new items.",
+	Run:         runPubsubWatch,
+}
+
+func runPubsubWatch(ctx context.Context, e env, flags *flag.FlagSet, args []string) error {
+	var (
+		service     string
+		unsubscribe bool
+	)
+	flags.StringVar(&service, "service", "", "The pubsub service hosting the node, the user's own PEP service if not set.")
+	flags.BoolVar(&unsubscribe, "unsubscribe", true, "Unsubscribe from the node when the command exits.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	node := flags.Arg(0)
+	var serviceJID jid.JID
+	if service != "" {
+		serviceJID, err = jid.Parse(service)
+		if err != nil {
+			return fmt.Errorf("error parsing service address: %w", err)
+		}
+	}
+
+	var outM sync.Mutex
+	events := &pubsub.Events{}
+	events.HandleNode(node, func(msg stanza.Message, iter *pubsub.EventIter) error {
+		outM.Lock()
+		defer outM.Unlock()
+		for iter.Next() {
+			id, r := iter.Item()
+			if iter.Retracted() {
+				fmt.Fprintf(e.Out, "retracted %s\n", id)
+				continue
+			}
+			fmt.Fprintf(e.Out, "published %s: ", id)
+			if r != nil {
+				enc := xml.NewEncoder(e.Out)
+				_, err := xmlstream.Copy(enc, r)
+				if err != nil {
+					return err
+				}
+				err = enc.Flush()
+				if err != nil {
+					return err
+				}
+			}
+			fmt.Fprintln(e.Out)
+		}
+		return iter.Err()
+	})
+
+	var subs []pubsub.Subscription
+	var subsM sync.Mutex
+	err = runBot(ctx, e, func(ctx context.Context, s *xmpp.Session) error {
+		sub, err := pubsub.SubscribeIQ(ctx, s, stanza.IQ{To: serviceJID}, node)
+		if err != nil {
+			return fmt.Errorf("error subscribing to node: %w", err)
+		}
+		subsM.Lock()
+		subs = append(subs, sub)
+		subsM.Unlock()
+		e.Logger.Printf("Subscribed to %s (%s)", node, sub.Type)
+		return nil
+	}, pubsub.HandleEvents(events))
+	if err != nil || !unsubscribe {
+		return err
+	}
+
+	// Subscriptions are tied to the bare JID, so a new session can be used to
+	// clean them up after the one that created them has ended.
+	subsM.Lock()
+	defer subsM.Unlock()
+	if len(subs) == 0 {
+		return nil
+	}
+	return runOnce(context.Background(), e, func(ctx context.Context, s *xmpp.Session) error {
+		for _, sub := range subs {
+			err := pubsub.UnsubscribeIQ(ctx, s, stanza.IQ{To: serviceJID}, node, sub.SubID)
+			if err != nil {
+				return fmt.Errorf("error unsubscribing from node: %w", err)
+			}
+		}
+		return nil
+	})
+}
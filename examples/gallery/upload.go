@@ -0,0 +1,131 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/oob"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/upload"
+)
+
+var uploadCmd = command{
+	Name:        "upload",
+	Args:        "[options] <file>",
+	Description: "Upload a file using HTTP File Upload and print or send the link.",
+	Run:         runUpload,
+}
+
+func runUpload(ctx context.Context, e env, flags *flag.FlagSet, args []string) error {
+	var (
+		service  string
+		to       string
+		fileType string
+		retries  int
+		progress bool
+	)
+	flags.StringVar(&service, "service", "", "The upload service to use, discovered if not set.")
+	flags.StringVar(&to, "to", "", "Send the link to this address instead of printing it.")
+	flags.StringVar(&fileType, "type", "", "The content type of the file, guessed from the extension if not set.")
+	flags.IntVar(&retries, "retries", 3, "The number of times to retry a failed upload.")
+	flags.BoolVar(&progress, "progress", false, "Show upload progress.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	var serviceJID, toJID jid.JID
+	if service != "" {
+		serviceJID, err = jid.Parse(service)
+		if err != nil {
+			return fmt.Errorf("error parsing service address: %w", err)
+		}
+	}
+	if to != "" {
+		toJID, err = jid.Parse(to)
+		if err != nil {
+			return fmt.Errorf("error parsing recipient address: %w", err)
+		}
+	}
+
+	fPath := flags.Arg(0)
+	fd, err := os.Open(fPath)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	/* #nosec */
+	defer fd.Close()
+	stat, err := fd.Stat()
+	if err != nil {
+		return fmt.Errorf("error getting file size: %w", err)
+	}
+	if fileType == "" {
+		fileType = mime.TypeByExtension(filepath.Ext(fPath))
+	}
+	file := upload.File{
+		Name: filepath.Base(fPath),
+		Size: int(stat.Size()),
+		Type: fileType,
+	}
+
+	return runOnce(ctx, e, func(ctx context.Context, s *xmpp.Session) error {
+		if serviceJID.Equal(jid.JID{}) {
+			serviceJID, err = findService(ctx, s, upload.Feature)
+			if err != nil {
+				return err
+			}
+		}
+		slot, err := upload.GetSlot(ctx, file, serviceJID, s)
+		if err != nil {
+			return fmt.Errorf("error requesting upload slot: %w", err)
+		}
+		u := upload.Uploader{
+			Client:  e.HTTPClient,
+			Retries: retries,
+		}
+		if progress {
+			u.Progress = func(sent, total int64) {
+				e.Logger.Printf("Uploaded %d/%d bytes…", sent, total)
+			}
+		}
+		err = u.Upload(ctx, slot, fd)
+		if err != nil {
+			return fmt.Errorf("error uploading file: %w", err)
+		}
+
+		link := slot.GetURL.String()
+		if toJID.Equal(jid.JID{}) {
+			_, err = fmt.Fprintln(e.Out, link)
+			return err
+		}
+		// The link is sent as the body for clients that do not support out of
+		// band data and as OOB data so that clients that do can display the file
+		// inline.
+		return s.Send(ctx, stanza.Message{
+			To:   toJID,
+			Type: stanza.ChatMessage,
+		}.Wrap(xmlstream.MultiReader(
+			xmlstream.Wrap(
+				xmlstream.Token(xml.CharData(link)),
+				xml.StartElement{Name: xml.Name{Local: "body"}},
+			),
+			oob.Data{URL: link}.TokenReader(),
+		)))
+	})
+}
@@ -11,7 +11,6 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/xml"
 	"flag"
 	"fmt"
@@ -19,11 +18,9 @@ import (
 	"log"
 	"os"
 	"strings"
-	"time"
 
-	"mellium.im/sasl"
 	"mellium.im/xmpp"
-	"mellium.im/xmpp/dial"
+	"mellium.im/xmpp/botkit"
 	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/muc"
 	"mellium.im/xmpp/mux"
@@ -32,20 +29,6 @@ import (
 	"mellium.im/xmpp/version"
 )
 
-const (
-	envAddr = "XMPP_ADDR"
-	envPass = "XMPP_PASS"
-)
-
-type logWriter struct {
-	logger *log.Logger
-}
-
-func (w logWriter) Write(p []byte) (int, error) {
-	w.logger.Printf("%s", p)
-	return len(p), nil
-}
-
 // messageBody is a message stanza that contains a body. It is normally used for
 // chat messages.
 type messageBody struct {
@@ -58,24 +41,9 @@ type messageBody struct {
 func main() {
 	logger := log.New(os.Stderr, "", log.LstdFlags)
 	debug := log.New(io.Discard, "DEBUG ", log.LstdFlags)
-	sentXML := log.New(io.Discard, "SENT ", log.LstdFlags)
-	recvXML := log.New(io.Discard, "RECV ", log.LstdFlags)
-
-	// Get and parse the XMPP address to send from.
-	addr := os.Getenv(envAddr)
-	if addr == "" {
-		logger.Fatalf("environment variable $%s unset", envAddr)
-	}
-
-	parsedAddr, err := jid.Parse(addr)
-	if err != nil {
-		logger.Fatalf("error parsing address %q: %v", addr, err)
-	}
-
-	// Get the password to use when logging in.
-	pass := os.Getenv(envPass)
-	if pass == "" {
-		logger.Fatalf("environment variable $%s unset", envPass)
+	cfg := botkit.Config{
+		Logger: logger,
+		Debug:  debug,
 	}
 
 	var (
@@ -83,28 +51,24 @@ func main() {
 		rawXML   bool
 		room     bool
 		isURI    bool
-		verbose  bool
 		verReq   bool
-		logXML   bool
 		subject  string
 		nick     string
 		roomPass string
 	)
 	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	cfg.RegisterFlags(flags)
 	flags.BoolVar(&help, "help", help, "Show this help message")
 	flags.BoolVar(&help, "h", help, "")
 	flags.BoolVar(&rawXML, "xml", rawXML, "Treat the input as raw XML to be sent on the stream.")
 	flags.BoolVar(&room, "room", room, "The provided JID is a multi-user chat (MUC) room.")
 	flags.BoolVar(&isURI, "uri", isURI, "Parse the recipient as an XMPP URI instead of a JID.")
-	flags.BoolVar(&verbose, "v", verbose, "Show verbose logging.")
-	flags.BoolVar(&logXML, "vv", logXML, "Show verbose logging and sent and received XML.")
 	flags.BoolVar(&verReq, "ver", verReq, "Request the software version of the remote entity instead of sending messages.")
-	flags.StringVar(&addr, "addr", addr, "The XMPP address to connect to, overrides $XMPP_ADDR.")
 	flags.StringVar(&subject, "subject", subject, "Set the subject of the message or chat room.")
 	flags.StringVar(&nick, "nick", nick, "A nickname to set when joining a chat room.")
 	flags.StringVar(&roomPass, "pass", roomPass, "A password to use when joining protected rooms.")
 
-	err = flags.Parse(os.Args[1:])
+	err := flags.Parse(os.Args[1:])
 	switch err {
 	case flag.ErrHelp:
 		// The -h and -help flags are special cased by flags for some reason and
@@ -122,13 +86,11 @@ func main() {
 		os.Exit(0)
 	}
 
-	if verbose {
+	if cfg.Verbose || cfg.LogXML {
 		debug.SetOutput(os.Stderr)
 	}
-	if logXML {
-		debug.SetOutput(os.Stderr)
-		sentXML.SetOutput(os.Stderr)
-		recvXML.SetOutput(os.Stderr)
+	if cfg.Password == "" {
+		logger.Fatalf("environment variable $%s unset", botkit.EnvPass)
 	}
 
 	args := flags.Args()
@@ -137,15 +99,17 @@ func main() {
 		os.Exit(1)
 	}
 
-	var parsedToAddr, parsedAuthAddr jid.JID
-	var rawMsg, thread, msgID, msgType, msgFrom string
+	var parsedToAddr jid.JID
+	var rawMsg, thread, msgID, msgType string
 	if isURI {
 		parsedURI, err := uri.Parse(args[0])
 		if err != nil {
 			logger.Fatalf("error parsing %q as a URI: %v", args[0], err)
 		}
 		parsedToAddr = parsedURI.ToAddr
-		parsedAuthAddr = parsedURI.AuthAddr
+		if !parsedURI.AuthAddr.Equal(jid.JID{}) {
+			cfg.Identity = parsedURI.AuthAddr.String()
+		}
 		switch parsedURI.Action {
 		case "":
 		case "join":
@@ -158,12 +122,8 @@ func main() {
 			thread = query.Get("thread")
 			msgID = query.Get("id")
 			msgType = query.Get("type")
-			msgFrom = query.Get("from")
-			if msgFrom != "" {
-				parsedAddr, err = jid.Parse(msgFrom)
-				if err != nil {
-					logger.Fatalf("error parsing %q as JID: %v", msgFrom, err)
-				}
+			if msgFrom := query.Get("from"); msgFrom != "" {
+				cfg.Addr = msgFrom
 			}
 		default:
 			logger.Fatalf("unknown or unsupported URI action %v", parsedURI.Action)
@@ -176,117 +136,65 @@ func main() {
 		}
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Login to the XMPP server.
-	debug.Println("logging in…")
-	dialCtx, dialCtxCancel := context.WithTimeout(ctx, 30*time.Second)
-	conn, err := dial.Client(dialCtx, "tcp", parsedAddr)
-	if err != nil {
-		logger.Fatalf("error dialing connection: %v", err)
+	if verReq && parsedToAddr.Equal(jid.JID{}) {
+		logger.Fatalf("requested software version but no address provided")
 	}
-	negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
-		return xmpp.StreamConfig{
-			Features: []xmpp.StreamFeature{
-				xmpp.BindResource(),
-				xmpp.StartTLS(&tls.Config{
-					ServerName: parsedAddr.Domain().String(),
-					MinVersion: tls.VersionTLS12,
-				}),
-				xmpp.SASL(parsedAuthAddr.String(), pass, sasl.ScramSha256Plus, sasl.ScramSha1Plus, sasl.ScramSha256, sasl.ScramSha1, sasl.Plain),
-			},
-			TeeIn:  logWriter{logger: recvXML},
-			TeeOut: logWriter{logger: sentXML},
+	if !verReq && rawMsg == "" {
+		rawMsgBuf, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logger.Fatalf("error reading message from stdin: %v", err)
 		}
-	})
-	session, err := xmpp.NewSession(dialCtx, parsedAddr.Domain(), parsedAddr, conn, 0, negotiator)
-	dialCtxCancel()
-	if err != nil {
-		logger.Fatalf("error logging in: %v", err)
+		rawMsg = string(rawMsgBuf)
 	}
+	msg := strings.ToValidUTF8(rawMsg, "")
 
 	mucClient := &muc.Client{}
-	mux := mux.New("jabber:client", muc.HandleClient(mucClient))
-	go func() {
-		err := session.Serve(mux)
-		if err != nil {
-			logger.Printf("error handling session responses: %v", err)
+	err = botkit.Do(context.Background(), cfg, mux.New(stanza.NSClient, muc.HandleClient(mucClient)), func(ctx context.Context, session *xmpp.Session) error {
+		if verReq {
+			verResp, err := version.Get(ctx, session, parsedToAddr)
+			if err != nil {
+				return fmt.Errorf("error requesting software version: %w", err)
+			}
+			logger.Printf("got version response:\n\tName: %s\n\tVersion: %s\n\tOS: %s", verResp.Name, verResp.Version, verResp.OS)
+			return nil
 		}
-	}()
-
-	originJID := session.LocalAddr()
 
-	defer func() {
 		if room {
-			debug.Printf("leaving the chat room %s…", addr)
-			err = session.Encode(ctx, stanza.Presence{
-				From: originJID,
-				To:   parsedToAddr,
-				Type: stanza.UnavailablePresence,
-			})
+			debug.Printf("joining the chat room %s…", parsedToAddr)
+			roomJID, _ := parsedToAddr.WithResource(nick)
+			opts := []muc.Option{muc.MaxBytes(0)}
+			if roomPass != "" {
+				opts = append(opts, muc.Password(roomPass))
+			}
+			channel, err := mucClient.Join(ctx, roomJID, session, opts...)
 			if err != nil {
-				logger.Fatalf("error leaving the chat room %s: %v", addr, err)
+				return fmt.Errorf("error joining MUC %s: %w", parsedToAddr, err)
 			}
+			defer func() {
+				debug.Printf("leaving the chat room %s…", parsedToAddr)
+				if err := channel.Leave(ctx, ""); err != nil {
+					logger.Printf("error leaving the chat room %s: %v", parsedToAddr, err)
+				}
+			}()
 		}
-		if err := session.Close(); err != nil {
-			logger.Fatalf("error ending session: %v", err)
-		}
-		if err := session.Conn().Close(); err != nil {
-			logger.Fatalf("error closing connection: %v", err)
-		}
-	}()
-
-	if verReq {
-		if parsedToAddr.Equal(jid.JID{}) {
-			logger.Fatalf("requested software version but no address provided")
-		}
-		verResp, err := version.Get(ctx, session, parsedToAddr)
-		if err != nil {
-			logger.Fatalf("error requesting software version: %v", err)
-		}
-		logger.Printf("got version response:\n\tName: %s\n\tVersion: %s\n\tOS: %s", verResp.Name, verResp.Version, verResp.OS)
-		return
-	}
-
-	if rawMsg == "" {
-		rawMsgBuf, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			logger.Fatalf("error reading message from stdin: %v", err)
-		}
-		rawMsg = string(rawMsgBuf)
-	}
-	msg := strings.ToValidUTF8(string(rawMsg), "")
-
-	if room {
-		debug.Printf("joining the chat room %s…", addr)
-		roomJID, _ := parsedToAddr.WithResource(nick)
-		opts := []muc.Option{muc.MaxBytes(0)}
-		if roomPass != "" {
-			opts = append(opts, muc.Password(roomPass))
-		}
-		_, err = mucClient.Join(ctx, roomJID, session, opts...)
-		if err != nil {
-			log.Fatalf("error joining MUC %s: %v", addr, err)
-		}
-	}
 
-	// Send message
-	if rawXML {
-		err = session.Send(ctx, xml.NewDecoder(strings.NewReader(msg)))
-		if err != nil {
-			logger.Fatalf("error sending raw XML: %v", err)
+		// Send message
+		if rawXML {
+			err := session.Send(ctx, xml.NewDecoder(strings.NewReader(msg)))
+			if err != nil {
+				return fmt.Errorf("error sending raw XML: %w", err)
+			}
+			return nil
 		}
-	} else {
 		typ := stanza.ChatMessage
 		if msgType != "" {
 			typ = stanza.MessageType(msgType)
 		}
-		err = session.Encode(ctx, messageBody{
+		err := session.Encode(ctx, messageBody{
 			Message: stanza.Message{
 				ID:   msgID,
 				To:   parsedToAddr,
-				From: parsedAddr,
+				From: session.LocalAddr(),
 				Type: typ,
 			},
 			Body:    msg,
@@ -294,8 +202,12 @@ func main() {
 			Thread:  thread,
 		})
 		if err != nil {
-			logger.Fatalf("error sending message: %v", err)
+			return fmt.Errorf("error sending message: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		logger.Fatal(err)
 	}
 }
 
@@ -315,5 +227,5 @@ current value) may be set:
 
     XMPP_ADDR=%s
     XMPP_PASS=<not shown>
-`, os.Getenv(envAddr))
+`, os.Getenv(botkit.EnvAddr))
 }
@@ -10,30 +10,24 @@ package main
 import (
 	"bufio"
 	"context"
-	"crypto/tls"
 	"encoding/xml"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
-	"sync"
-	"time"
 
-	"mellium.im/sasl"
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
+	"mellium.im/xmpp/botkit"
 	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/muc"
 	"mellium.im/xmpp/mux"
 	"mellium.im/xmpp/stanza"
 )
 
-const (
-	prompt  = "> "
-	envAddr = "XMPP_ADDR"
-	envPass = "XMPP_PASS"
-)
+const prompt = "> "
 
 // messageBody is a message stanza that contains a body. It is normally used for
 // chat messages.
@@ -45,88 +39,69 @@ type messageBody struct {
 
 func main() {
 	logger := log.New(os.Stderr, "", log.LstdFlags)
-
-	// Get and parse the XMPP address to send from.
-	addr := os.Getenv(envAddr)
-	if addr == "" {
-		logger.Fatalf("Environment variable $%s unset", envAddr)
-	}
-
-	parsedAddr, err := jid.Parse(addr)
-	if err != nil {
-		logger.Fatalf("Error parsing address %q: %v", addr, err)
-	}
-
-	// Get the password to use when logging in.
-	pass := os.Getenv(envPass)
-	if pass == "" {
-		logger.Fatalf("Environment variable $%s unset", envPass)
+	cfg := botkit.Config{
+		Logger: logger,
+		// Send initial presence to let the server know we want to receive
+		// messages.
+		Online: func(ctx context.Context, session *xmpp.Session) error {
+			return session.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence}.Wrap(nil))
+		},
 	}
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cfg.RegisterFlags(flags)
+	/* #nosec */
+	flags.Parse(os.Args[1:])
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Login to the XMPP server.
-	logger.Println("Logging in…")
-	dialCtx, dialCtxCancel := context.WithTimeout(ctx, 30*time.Second)
-	session, err := xmpp.DialClientSession(
-		dialCtx, parsedAddr,
-		xmpp.BindResource(),
-		xmpp.StartTLS(&tls.Config{
-			ServerName: parsedAddr.Domain().String(),
-			MinVersion: tls.VersionTLS12,
-		}),
-		xmpp.SASL("", pass, sasl.ScramSha1Plus, sasl.ScramSha1, sasl.Plain),
+	mucClient := &muc.Client{}
+	m := mux.New(
+		stanza.NSClient,
+		mux.Message(stanza.ChatMessage, xml.Name{Local: "body"}, receiveMessage(logger)),
+		mux.Message(stanza.GroupChatMessage, xml.Name{Local: "body"}, receiveMessage(logger)),
+		muc.HandleClient(mucClient),
 	)
-	dialCtxCancel()
+	err := botkit.Do(context.Background(), cfg, m, func(ctx context.Context, session *xmpp.Session) error {
+		return repl(ctx, logger, session, mucClient)
+	})
 	if err != nil {
-		logger.Fatalf("Error loging in: %v", err)
+		logger.Fatal(err)
 	}
-	defer func() {
-		if err := session.Close(); err != nil {
-			logger.Fatalf("Error ending session: %v", err)
-		}
-		if err := session.Conn().Close(); err != nil {
-			logger.Fatalf("Error closing connection: %v", err)
-		}
-	}()
-
-	// Send initial presence to let the server know we want to receive messages.
-	err = session.Send(ctx, stanza.Presence{Type: stanza.AvailablePresence}.Wrap(nil))
-	if err != nil {
-		logger.Fatalf("Error sending initial presence: %v", err)
-	}
-
-	mucClient := &muc.Client{}
+}
 
-	// Handle incoming messages.
+// repl reads messages from stdin and sends them until the input ends or ctx is
+// canceled.
+func repl(ctx context.Context, logger *log.Logger, session *xmpp.Session, mucClient *muc.Client) error {
+	// Lines are read in a separate goroutine so that reading does not block
+	// shutting down.
+	lines := make(chan string)
+	var inputErr error
 	go func() {
-		m := mux.New(
-			stanza.NSClient,
-			mux.Message(stanza.ChatMessage, xml.Name{Local: "body"}, receiveMessage(logger)),
-			mux.Message(stanza.GroupChatMessage, xml.Name{Local: "body"}, receiveMessage(logger)),
-			muc.HandleClient(mucClient),
-		)
-		err := session.Serve(m)
-		if err != nil {
-			logger.Fatalf("Error handling incoming messages: %v", err)
+		defer close(lines)
+		userInput := bufio.NewScanner(os.Stdin)
+		for userInput.Scan() {
+			lines <- userInput.Text()
 		}
+		inputErr = userInput.Err()
 	}()
 
 	printHelp()
 
-	userInput := bufio.NewScanner(os.Stdin)
 	mucs := make(map[string]*muc.Channel)
-	var mucsM sync.Mutex
 	var parsedToAddr jid.JID
 	for {
 		fmt.Print(prompt)
-		scanned := userInput.Scan()
-		if !scanned {
-			break
+		var msg string
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				if inputErr != nil {
+					return fmt.Errorf("error reading user input: %w", inputErr)
+				}
+				return nil
+			}
+			msg = line
 		}
-
-		msg := userInput.Text()
 		if msg == "" {
 			continue
 		}
@@ -145,14 +120,12 @@ func main() {
 				logger.Printf("error parsing MUC address to join: %v", err)
 				continue
 			}
-			c, err := mucClient.Join(context.TODO(), joinJID, session, muc.MaxHistory(0))
+			c, err := mucClient.Join(ctx, joinJID, session, muc.MaxHistory(0))
 			if err != nil {
 				logger.Printf("error joining %s: %v", joinJID, err)
 				continue
 			}
-			mucsM.Lock()
 			mucs[joinJID.Bare().String()] = c
-			mucsM.Unlock()
 
 			parsedToAddr = joinJID.Bare()
 			continue
@@ -163,21 +136,17 @@ func main() {
 				continue
 			}
 			bare := partJID.Bare().String()
-			mucsM.Lock()
 			c, ok := mucs[bare]
-			mucsM.Unlock()
 			if !ok {
 				logger.Printf("channel %s is not joined", partJID)
 				continue
 			}
-			err = c.Leave(context.TODO(), "")
+			err = c.Leave(ctx, "")
 			if err != nil {
 				logger.Printf("failed to leave channel %s: %v", partJID, err)
 				continue
 			}
-			mucsM.Lock()
 			delete(mucs, bare)
-			mucsM.Unlock()
 
 			if partJID.Equal(parsedToAddr) {
 				parsedToAddr = jid.JID{}
@@ -187,6 +156,7 @@ func main() {
 
 		idx := strings.IndexByte(msg, ':')
 		if idx != -1 {
+			var err error
 			parsedToAddr, err = jid.Parse(msg[:idx])
 			if err != nil {
 				logger.Printf("error parsing address: %v", err)
@@ -201,32 +171,26 @@ func main() {
 
 		msg = strings.TrimSpace(msg[idx+1:])
 
-		mucsM.Lock()
-		_, ok := mucs[parsedToAddr.Bare().String()]
-		mucsM.Unlock()
 		stanzaType := stanza.ChatMessage
-		if ok {
+		if _, ok := mucs[parsedToAddr.Bare().String()]; ok {
 			stanzaType = stanza.GroupChatMessage
 		}
-		err = session.Encode(ctx, messageBody{
+		err := session.Encode(ctx, messageBody{
 			Message: stanza.Message{
 				To:   parsedToAddr,
-				From: parsedAddr,
+				From: session.LocalAddr(),
 				Type: stanzaType,
 			},
 			Body: msg,
 		})
 		if err != nil {
-			logger.Fatalf("error sending message: %v", err)
+			return fmt.Errorf("error sending message: %w", err)
 		}
 	}
-	if err := userInput.Err(); err != nil {
-		logger.Fatalf("error reading user input: %v", err)
-	}
 }
 
 func printHelp() {
-	fmt.Println(`Enter a JID, a colon, and a message to send. For example:
+	fmt.Print(`Enter a JID, a colon, and a message to send. For example:
 
 	me@example.net: Test message
 
@@ -7,9 +7,9 @@ package xmpp
 import (
 	"context"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/internal/decl"
@@ -36,6 +36,13 @@ type StreamFeature struct {
 	// an IQ for negotiation, this should be the name of the IQ payload.
 	Name xml.Name
 
+	// The namespace of the element sent by the initiating entity to select this
+	// feature for negotiation if it differs from the namespace of Name.
+	// For instance, stream compression is advertised in one namespace but the
+	// initiating entity selects it by sending an element in another.
+	// If SelectNS is empty, the namespace of Name is used.
+	SelectNS string
+
 	// Bits that are required before this feature is advertised. For instance, if
 	// this feature should only be advertised after the user is authenticated we
 	// might set this to "Authn" or if it should be advertised only after the
@@ -102,13 +109,16 @@ func decodeStreamErr(start xml.StartElement, r xml.TokenReader) error {
 	return e
 }
 
-func negotiateFeatures(ctx context.Context, s *Session, first, ws bool, features []StreamFeature) (mask SessionState, rw io.ReadWriter, err error) {
+func negotiateFeatures(ctx context.Context, s *Session, first, ws bool, features []StreamFeature, hook func(context.Context, *Session, RawFeature) error) (mask SessionState, rw io.ReadWriter, err error) {
 	server := (s.state & Received) == Received
 
 	// If we're the server, write the initial stream features.
 	var list *streamFeaturesList
 	if server {
 		list, err = writeStreamFeatures(ctx, s, ws, features)
+		if list != nil {
+			s.recordFeatures(list.trace)
+		}
 		if err != nil {
 			return mask, nil, err
 		}
@@ -137,10 +147,11 @@ func negotiateFeatures(ctx context.Context, s *Session, first, ws bool, features
 		}
 
 		// If we're the client read the rest of the stream features list.
-		list, err = readStreamFeatures(ctx, s, start, features)
+		list, err = readStreamFeatures(ctx, s, start, features, hook)
 		if err != nil {
 			return mask, nil, err
 		}
+		s.recordFeatures(list.trace)
 
 		startTLS, doStartTLS = containsStartTLS(features)
 		_, advertisedStartTLS := list.cache[ns.StartTLS]
@@ -161,9 +172,11 @@ func negotiateFeatures(ctx context.Context, s *Session, first, ws bool, features
 		case len(list.cache) == 0:
 			// If we received a list with features we support but where none of them
 			// could be negotiated (eg. they were advertised in the wrong order), this
-			// is an error:
-			// TODO: This error isn't very good.
-			return mask, nil, errors.New("xmpp: features advertised out of order")
+			// is an error.
+			return mask, nil, &NegotiationError{
+				List:     list.trace,
+				Timeline: s.NegotiationTimeline(),
+			}
 		}
 	}
 
@@ -205,8 +218,15 @@ func negotiateFeatures(ctx context.Context, s *Session, first, ws bool, features
 
 			// If the feature was not sent, was already negotiated, or is
 			// informational only and not meant to be negotiated: error.
-			_, negotiated := s.negotiated[start.Name.Space]
-			data, sent = list.cache[start.Name.Space]
+			space := start.Name.Space
+			for k, v := range list.cache {
+				if v.feature.SelectNS != "" && v.feature.SelectNS == space {
+					space = k
+					break
+				}
+			}
+			_, negotiated := s.negotiated[space]
+			data, sent = list.cache[space]
 			if !sent || negotiated || data.feature.Negotiate == nil {
 				// TODO: What should we return here?
 				return mask, rw, stream.PolicyViolation
@@ -264,16 +284,26 @@ func negotiateFeatures(ctx context.Context, s *Session, first, ws bool, features
 			s.in.d = intstream.Reader(oldDecoder, ws)
 		}
 
+		negotiateStart := time.Now()
 		mask, rw, err = data.feature.Negotiate(ctx, s, s.features[data.feature.Name.Space])
 		s.in.d = oldDecoder
+		s.recordStep(NegotiationStep{
+			Feature:  data.feature.Name,
+			Start:    negotiateStart,
+			Duration: time.Since(negotiateStart),
+			Mask:     mask,
+			Restart:  rw != nil,
+			Err:      err,
+		})
 		if err == nil {
 			s.state |= mask
 		}
 		s.negotiated[data.feature.Name.Space] = struct{}{}
 
-		// If we negotiated a required feature or a stream restart is required
-		// we're done with this feature set.
-		if rw != nil || data.req {
+		// If we negotiated a required feature, a stream restart is required, or the
+		// feature completed negotiation on its own (eg. by resuming a previous
+		// session) we're done with this feature set.
+		if rw != nil || data.req || mask&Ready == Ready {
 			break
 		}
 	}
@@ -298,6 +328,9 @@ type streamFeaturesList struct {
 
 	// Namespace to sfData
 	cache map[string]sfData
+
+	// The features in the list, in order, for debugging.
+	trace FeatureList
 }
 
 func getFeature(name xml.Name, features []StreamFeature) (feature StreamFeature, ok bool) {
@@ -327,6 +360,7 @@ func writeStreamFeatures(ctx context.Context, s *Session, ws bool, features []St
 	// Lock the connection features list.
 	list = &streamFeaturesList{
 		cache: make(map[string]sfData),
+		trace: FeatureList{State: s.state},
 	}
 
 	for _, feature := range features {
@@ -345,6 +379,10 @@ func writeStreamFeatures(ctx context.Context, s *Session, ws bool, features []St
 				req:     r,
 				feature: feature,
 			}
+			list.trace.Features = append(list.trace.Features, AdvertisedFeature{
+				Name:     feature.Name,
+				Required: r,
+			})
 			if r {
 				list.req = true
 			}
@@ -360,6 +398,44 @@ func writeStreamFeatures(ctx context.Context, s *Session, ws bool, features []St
 	return list, err
 }
 
+// RawFeature is the data recorded for a stream feature that was advertised by
+// the remote entity but that is not handled by any of the configured stream
+// features.
+// It is returned by the session's Feature method and passed to
+// StreamConfig.FeatureHook.
+type RawFeature struct {
+	Start  xml.StartElement
+	tokens []xml.Token
+}
+
+// TokenReader returns a reader over the entire feature element, including the
+// start and end elements.
+func (f RawFeature) TokenReader() xml.TokenReader {
+	toks := f.tokens
+	return xmlstream.MultiReader(
+		xmlstream.Token(f.Start.Copy()),
+		xmlstream.ReaderFunc(func() (xml.Token, error) {
+			if len(toks) == 0 {
+				return nil, io.EOF
+			}
+			t := toks[0]
+			toks = toks[1:]
+			return xml.CopyToken(t), nil
+		}),
+	)
+}
+
+func readRawFeature(start xml.StartElement, d *xml.Decoder) (RawFeature, error) {
+	toks, err := xmlstream.ReadAll(d)
+	if err != nil {
+		return RawFeature{}, err
+	}
+	return RawFeature{
+		Start:  start.Copy(),
+		tokens: toks,
+	}, nil
+}
+
 func nextElementDecoder(r xml.TokenReader, start xml.StartElement) *xml.Decoder {
 	d := xml.NewTokenDecoder(xmlstream.MultiReader(
 		xmlstream.Token(start),
@@ -376,7 +452,7 @@ func nextElementDecoder(r xml.TokenReader, start xml.StartElement) *xml.Decoder
 	return d
 }
 
-func readStreamFeatures(ctx context.Context, s *Session, start xml.StartElement, features []StreamFeature) (*streamFeaturesList, error) {
+func readStreamFeatures(ctx context.Context, s *Session, start xml.StartElement, features []StreamFeature, hook func(context.Context, *Session, RawFeature) error) (*streamFeaturesList, error) {
 	switch {
 	case start.Name.Local != featuresLocal:
 		return nil, stream.InvalidXML
@@ -386,6 +462,7 @@ func readStreamFeatures(ctx context.Context, s *Session, start xml.StartElement,
 
 	sf := &streamFeaturesList{
 		cache: make(map[string]sfData),
+		trace: FeatureList{Received: true, State: s.state},
 	}
 
 parsefeatures:
@@ -402,36 +479,60 @@ parsefeatures:
 			// it. Increment the total features count regardless.
 			sf.total++
 
-			// Always add the feature to the list of features, even if we don't
-			// support it, it just won't contain any parse output.
+			// Always add the feature to the list of features, even if it can't be
+			// negotiated in the current state, it just won't contain any parse
+			// output.
 			s.features[tok.Name.Space] = nil
 
 			feature, ok := getFeature(tok.Name, features)
-			if ok {
-				req, data, err := feature.Parse(ctx, limitDecoder, &tok)
+			if !ok {
+				// If we don't support the feature, record its raw XML so that the user
+				// can inspect it later or react to it using the hook.
+				raw, err := readRawFeature(tok, limitDecoder)
 				if err != nil {
 					return nil, err
 				}
-				sf.req = sf.req || req
-
-				if s.state&feature.Necessary == feature.Necessary &&
-					s.state&feature.Prohibited == 0 {
-
-					sf.cache[tok.Name.Space] = sfData{
-						req:     req,
-						feature: feature,
+				sf.trace.Features = append(sf.trace.Features, AdvertisedFeature{
+					Name:    tok.Name,
+					Skipped: "not supported",
+				})
+				s.features[tok.Name.Space] = raw
+				if hook != nil {
+					err = hook(ctx, s, raw)
+					if err != nil {
+						return nil, err
 					}
+				}
+				continue parsefeatures
+			}
+			req, data, err := feature.Parse(ctx, limitDecoder, &tok)
+			if err != nil {
+				return nil, err
+			}
+			sf.req = sf.req || req
+			_, negotiated := s.negotiated[tok.Name.Space]
+			sf.trace.Features = append(sf.trace.Features, AdvertisedFeature{
+				Name:     tok.Name,
+				Required: req,
+				Skipped:  skipReason(feature, s.state, negotiated),
+			})
+
+			if s.state&feature.Necessary == feature.Necessary &&
+				s.state&feature.Prohibited == 0 {
 
-					// Since we do support the feature, add it to the connections list
-					// along with any data returned from Parse.
-					s.features[tok.Name.Space] = data
-					continue parsefeatures
+				sf.cache[tok.Name.Space] = sfData{
+					req:     req,
+					feature: feature,
 				}
+
+				// Since we do support the feature, add it to the connections list
+				// along with any data returned from Parse.
+				s.features[tok.Name.Space] = data
+				continue parsefeatures
 			}
-			// Advance to the end of the feature element (in case the parse function
-			// didn't consume the entire feature or we did not support the feature and
-			// need to skip it).
-			_, err := xmlstream.Copy(xmlstream.Discard(), limitDecoder)
+			// Advance to the end of the feature element in case the parse function
+			// didn't consume the entire feature.
+			_, err = xmlstream.Copy(xmlstream.Discard(), limitDecoder)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,62 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+
+	"mellium.im/xmpp/internal/ns"
+)
+
+const (
+	nsCompressFeature = "http://jabber.org/features/compress"
+	nsSessionFeature  = "urn:ietf:params:xml:ns:xmpp-session"
+)
+
+// featureRank is the relative order of stream features recommended by
+// XEP-0170: Recommended Order of Stream Feature Negotiation.
+// Features that are not listed have no recommended order.
+var featureRank = map[string]int{
+	ns.StartTLS:       1,
+	ns.SASL:           2,
+	nsCompressFeature: 3,
+	ns.Bind:           4,
+	nsSessionFeature:  5,
+}
+
+// orderFeatures returns a copy of features in which the features with a
+// recommended order have been sorted into that order.
+// Features without a recommended order keep their position in the list so that
+// the relative order chosen by the user is otherwise left alone.
+// If the same feature is listed more than once an error is returned.
+func orderFeatures(features []StreamFeature) ([]StreamFeature, error) {
+	seen := make(map[xml.Name]struct{}, len(features))
+	var slots []int
+	for i, f := range features {
+		if _, ok := seen[f.Name]; ok {
+			return nil, fmt.Errorf("xmpp: stream feature %s configured more than once", f.Name.Space)
+		}
+		seen[f.Name] = struct{}{}
+		if _, ok := featureRank[f.Name.Space]; ok {
+			slots = append(slots, i)
+		}
+	}
+
+	ordered := make([]StreamFeature, len(features))
+	copy(ordered, features)
+	known := make([]StreamFeature, 0, len(slots))
+	for _, i := range slots {
+		known = append(known, features[i])
+	}
+	sort.SliceStable(known, func(i, j int) bool {
+		return featureRank[known[i].Name.Space] < featureRank[known[j].Name.Space]
+	})
+	for n, i := range slots {
+		ordered[i] = known[n]
+	}
+	return ordered, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+)
+
+func listFeature(space string) xmpp.StreamFeature {
+	return xmpp.StreamFeature{
+		Name: xml.Name{Space: space, Local: "feature"},
+		List: func(_ context.Context, e xmlstream.TokenWriter, start xml.StartElement) (bool, error) {
+			err := e.EncodeToken(start)
+			if err != nil {
+				return false, err
+			}
+			return false, e.EncodeToken(start.End())
+		},
+	}
+}
+
+var featureOrderTestCases = [...]struct {
+	features []string
+	keep     bool
+	want     []string
+	err      bool
+}{
+	0: {
+		features: []string{
+			"urn:ietf:params:xml:ns:xmpp-bind",
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:example",
+			"http://jabber.org/features/compress",
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+		},
+		want: []string{
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+			"urn:example",
+			"http://jabber.org/features/compress",
+			"urn:ietf:params:xml:ns:xmpp-bind",
+		},
+	},
+	1: {
+		features: []string{
+			"urn:ietf:params:xml:ns:xmpp-bind",
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:example",
+		},
+		keep: true,
+		want: []string{
+			"urn:ietf:params:xml:ns:xmpp-bind",
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:example",
+		},
+	},
+	2: {
+		features: []string{
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+		},
+		err: true,
+	},
+	3: {
+		// Duplicates are only rejected when ordering features.
+		features: []string{
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+		},
+		keep: true,
+		want: []string{
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+			"urn:ietf:params:xml:ns:xmpp-tls",
+			"urn:ietf:params:xml:ns:xmpp-sasl",
+		},
+	},
+}
+
+func TestFeatureOrder(t *testing.T) {
+	for i, tc := range featureOrderTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var features []xmpp.StreamFeature
+			for _, space := range tc.features {
+				features = append(features, listFeature(space))
+			}
+			negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+				return xmpp.StreamConfig{
+					Features:         features,
+					KeepFeatureOrder: tc.keep,
+				}
+			})
+			var out strings.Builder
+			rw := struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(`<stream:stream to='example.net' version='1.0' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:client'>`),
+				Writer: &out,
+			}
+			_, err := xmpp.NewSession(context.Background(), jid.MustParse("example.net"), jid.JID{}, rw, xmpp.Received, negotiator)
+			switch {
+			case err == nil:
+				t.Fatalf("expected negotiation to end early")
+			case tc.err && !strings.Contains(err.Error(), "more than once"):
+				t.Fatalf("wrong error: %v", err)
+			case tc.err:
+				if strings.Contains(out.String(), "features") {
+					t.Errorf("features should not be advertised with an invalid config: %q", out.String())
+				}
+				return
+			}
+
+			var got []string
+			d := xml.NewDecoder(strings.NewReader(out.String()))
+			for {
+				tok, err := d.Token()
+				if err != nil {
+					break
+				}
+				if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "feature" {
+					got = append(got, start.Name.Space)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("wrong feature order:\nwant=%v,\n got=%v", tc.want, got)
+			}
+		})
+	}
+}
@@ -2,7 +2,7 @@
 // Use of this source code is governed by the BSD 2-clause
 // license that can be found in the LICENSE file.
 
-//go:generate go run ../internal/genfeature
+//go:generate go run ../internal/genfeature -vars "Feature:NS,FeatureValidate:NSValidate"
 
 // Package form implements sending and submitting data forms.
 package form // import "mellium.im/xmpp/form"
@@ -71,6 +71,10 @@ type FieldData struct {
 	Desc     string
 	Required bool
 
+	// Validation contains the validation rules for the field or nil if the field
+	// has none.
+	Validation *Validation
+
 	// Raw is the value of the field as it came over the wire with no type
 	// information.
 	// Generally speaking, Get methods on form should be used along with the field
@@ -88,17 +92,19 @@ type field struct {
 	value    []string
 	option   []FieldOpt
 	required bool
+	validate *Validation
 }
 
 func (f *field) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	s := struct {
-		Type     FieldType  `xml:"type,attr"`
-		Label    string     `xml:"label,attr"`
-		Var      string     `xml:"var,attr"`
-		Desc     string     `xml:"desc"`
-		Required *string    `xml:"required"`
-		Value    []string   `xml:"value"`
-		Option   []FieldOpt `xml:"option"`
+		Type     FieldType    `xml:"type,attr"`
+		Label    string       `xml:"label,attr"`
+		Var      string       `xml:"var,attr"`
+		Desc     string       `xml:"desc"`
+		Required *string      `xml:"required"`
+		Value    []string     `xml:"value"`
+		Option   []FieldOpt   `xml:"option"`
+		Validate *validateXML `xml:"http://jabber.org/protocol/xdata-validate validate"`
 	}{}
 
 	err := d.DecodeElement(&s, &start)
@@ -109,6 +115,9 @@ func (f *field) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	f.required = s.Required != nil
 	f.value = s.Value
 	f.option = s.Option
+	if s.Validate != nil {
+		f.validate = s.Validate.validation()
+	}
 	return err
 }
 
@@ -182,6 +191,9 @@ func (f *field) TokenReader() xml.TokenReader {
 			))
 		}
 	}
+	if f.validate != nil {
+		child = append(child, f.validate.TokenReader())
+	}
 
 	return xmlstream.Wrap(
 		xmlstream.MultiReader(child...),
@@ -74,12 +74,13 @@ func (d *Data) Instructions() string {
 func (d *Data) ForFields(f func(FieldData)) {
 	for _, field := range d.fields {
 		f(FieldData{
-			Type:     field.typ,
-			Var:      field.varName,
-			Label:    field.label,
-			Desc:     field.desc,
-			Required: field.required,
-			Raw:      field.value,
+			Type:       field.typ,
+			Var:        field.varName,
+			Label:      field.label,
+			Desc:       field.desc,
+			Required:   field.required,
+			Validation: field.validate,
+			Raw:        field.value,
 		})
 	}
 }
@@ -448,6 +449,8 @@ func (d *Data) TokenReader() xml.TokenReader {
 			if f.typ == TypeFixed {
 				continue
 			}
+			// Validation rules are only meaningful to the submitter.
+			f.validate = nil
 			vv, isSet := d.Get(f.varName)
 			if !f.required && !isSet {
 				continue
@@ -0,0 +1,370 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package form
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"mellium.im/xmpp/jid"
+)
+
+var (
+	jidType      = reflect.TypeOf(jid.JID{})
+	jidSliceType = reflect.TypeOf([]jid.JID(nil))
+)
+
+var errNotStruct = errors.New("form: expected a struct or pointer to a struct")
+
+// structField is a struct field that has been mapped to a form field.
+type structField struct {
+	index    []int
+	varName  string
+	typ      FieldType
+	required bool
+	label    string
+	desc     string
+}
+
+// fieldsOf returns the fields of a struct type that should be mapped to form
+// fields.
+// Only fields with a "form" tag are mapped, and anonymous struct fields without
+// a tag are flattened into the parent.
+func fieldsOf(t reflect.Type, index []int) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		idx := make([]int, len(index), len(index)+1)
+		copy(idx, index)
+		idx = append(idx, i)
+
+		tag, ok := sf.Tag.Lookup("form")
+		if !ok {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct && sf.IsExported() {
+				embedded, err := fieldsOf(sf.Type, idx)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, embedded...)
+			}
+			continue
+		}
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		f := structField{
+			index: idx,
+			label: sf.Tag.Get("label"),
+			desc:  sf.Tag.Get("desc"),
+		}
+		opts := strings.Split(tag, ",")
+		f.varName = opts[0]
+		if f.varName == "" {
+			f.varName = sf.Name
+		}
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "":
+			case "required":
+				f.required = true
+			default:
+				f.typ = FieldType(opt)
+			}
+		}
+		// Even if a field type is provided, the Go type must be one that can be
+		// converted to and from the values of a form field.
+		typ := inferType(sf.Type)
+		if typ == "" {
+			return nil, fmt.Errorf("form: unsupported type %s for field %s", sf.Type, sf.Name)
+		}
+		if f.typ == "" {
+			f.typ = typ
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// inferType returns the default form field type for a Go type or the empty
+// string if the type cannot be mapped to a form field.
+func inferType(t reflect.Type) FieldType {
+	switch t {
+	case jidType:
+		return TypeJID
+	case jidSliceType:
+		return TypeJIDMulti
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return TypeBoolean
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return TypeText
+	case reflect.Slice:
+		switch inferType(t.Elem()) {
+		case TypeBoolean, TypeText:
+			return TypeListMulti
+		}
+	}
+	return ""
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return rv, errNotStruct
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return rv, errNotStruct
+	}
+	return rv, nil
+}
+
+// Marshal returns a form with a field for each field of the struct v that has
+// a "form" tag.
+// The current values of the struct fields are used as the default values of
+// the form fields and any provided options are applied to the form before the
+// fields are added.
+//
+// The tag contains the field variable name (the Go field name is used if it is
+// empty) optionally followed by a comma separated list containing the field
+// type and "required", for example:
+//
+//	type Config struct {
+//		Name     string    `form:"muc#roomconfig_roomname" label:"Room name"`
+//		Password string    `form:"muc#roomconfig_roomsecret,text-private"`
+//		Public   bool      `form:"muc#roomconfig_publicroom,required"`
+//		Admins   []jid.JID `form:"muc#roomconfig_roomadmins"`
+//		Internal string    `form:"-"`
+//	}
+//
+// If no type is provided it is inferred from the Go type:
+// bool fields become boolean fields, jid.JID and []jid.JID fields become
+// jid-single and jid-multi fields, slices of strings, numbers, or bools become
+// list-multi fields, and strings and numbers become text-single fields.
+// Fields of any other type result in an error, even if a field type is
+// provided.
+// The "label" and "desc" tags may be used to set a label and description on
+// the field.
+// Untagged anonymous struct fields are treated as if their fields were part of
+// the outer struct.
+func Marshal(v interface{}, o ...Field) (*Data, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := fieldsOf(rv.Type(), nil)
+	if err != nil {
+		return nil, err
+	}
+	d := New(o...)
+	for _, sf := range fields {
+		f := field{
+			typ:      sf.typ,
+			varName:  sf.varName,
+			label:    sf.label,
+			desc:     sf.desc,
+			required: sf.required,
+			value:    valueStrings(rv.FieldByIndex(sf.index)),
+		}
+		d.fields = append(d.fields, f)
+	}
+	return d, nil
+}
+
+// valueStrings converts the value of a struct field to the string values used
+// in a form field.
+func valueStrings(v reflect.Value) []string {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Type() {
+	case jidType:
+		j := v.Interface().(jid.JID)
+		if j.Equal(jid.JID{}) {
+			return nil
+		}
+		return []string{j.String()}
+	case jidSliceType:
+		jids := v.Interface().([]jid.JID)
+		s := make([]string, 0, len(jids))
+		for _, j := range jids {
+			s = append(s, j.String())
+		}
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}
+	case reflect.String:
+		if v.String() == "" {
+			return nil
+		}
+		return strings.Split(v.String(), "\n")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())}
+	case reflect.Slice:
+		s := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			switch elem.Kind() {
+			case reflect.String:
+				s = append(s, elem.String())
+			case reflect.Bool,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				s = append(s, valueStrings(elem)...)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+// Unmarshal sets the fields of the struct pointed to by v that have a "form"
+// tag to the values of the corresponding form fields.
+// Values set on the form using Set take precedence over the values that were
+// received in the form.
+// Struct fields for which the form does not contain a value are left
+// unchanged.
+// For more information about the tag format see Marshal.
+func Unmarshal(d *Data, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errNotStruct
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	fields, err := fieldsOf(rv.Type(), nil)
+	if err != nil {
+		return err
+	}
+	for _, sf := range fields {
+		var raw []string
+		// Values set to nil are ignored and the value received in the form is used
+		// instead.
+		if val, ok := d.values[sf.varName]; ok && val != nil {
+			raw = valueStrings(reflect.ValueOf(val))
+		} else {
+			var ok bool
+			raw, ok = d.Raw(sf.varName)
+			if !ok {
+				continue
+			}
+		}
+		err = setValue(rv.FieldByIndex(sf.index), raw)
+		if err != nil {
+			return fmt.Errorf("form: error decoding field %q: %w", sf.varName, err)
+		}
+	}
+	return nil
+}
+
+// setValue sets a struct field from the string values of a form field.
+func setValue(v reflect.Value, raw []string) error {
+	if !v.IsValid() || !v.CanSet() {
+		return nil
+	}
+	switch v.Type() {
+	case jidType:
+		if len(raw) == 0 {
+			v.Set(reflect.ValueOf(jid.JID{}))
+			return nil
+		}
+		j, err := jid.Parse(raw[0])
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(j))
+		return nil
+	case jidSliceType:
+		jids := make([]jid.JID, 0, len(raw))
+		for _, s := range raw {
+			j, err := jid.Parse(s)
+			if err != nil {
+				return err
+			}
+			jids = append(jids, j)
+		}
+		v.Set(reflect.ValueOf(jids))
+		return nil
+	}
+
+	var first string
+	if len(raw) > 0 {
+		first = raw[0]
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		switch first {
+		case "1", "true":
+			v.SetBool(true)
+		case "", "0", "false":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid boolean %q", first)
+		}
+	case reflect.String:
+		v.SetString(strings.Join(raw, "\n"))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if first == "" {
+			v.SetInt(0)
+			return nil
+		}
+		i, err := strconv.ParseInt(first, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if first == "" {
+			v.SetUint(0)
+			return nil
+		}
+		i, err := strconv.ParseUint(first, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		if first == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(first, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, val := range raw {
+			err := setValue(s.Index(i), []string{val})
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
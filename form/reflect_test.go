@@ -0,0 +1,231 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package form_test
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/jid"
+)
+
+type Common struct {
+	Desc string `form:"desc,text-multi"`
+}
+
+type roomConfig struct {
+	Common
+	Name     string    `form:"muc#roomconfig_roomname" label:"Room name"`
+	Secret   string    `form:"muc#roomconfig_roomsecret,text-private,required"`
+	Public   bool      `form:"muc#roomconfig_publicroom"`
+	MaxUsers int       `form:"muc#roomconfig_maxusers,list-single"`
+	Owner    jid.JID   `form:"owner"`
+	Admins   []jid.JID `form:"muc#roomconfig_roomadmins"`
+	Langs    []string  `form:"langs"`
+	Internal string    `form:"-"`
+	Untagged string
+}
+
+func TestMarshalStruct(t *testing.T) {
+	cfg := roomConfig{
+		Common:   Common{Desc: "line one\nline two"},
+		Name:     "Room",
+		Public:   true,
+		MaxUsers: 20,
+		Owner:    jid.MustParse("owner@example.net"),
+		Admins:   []jid.JID{jid.MustParse("a@example.net"), jid.MustParse("b@example.net")},
+		Internal: "secret",
+	}
+	d, err := form.Marshal(&cfg, form.Title("Config"))
+	if err != nil {
+		t.Fatalf("error marshaling form: %v", err)
+	}
+	if d.Title() != "Config" {
+		t.Errorf("wrong title: want=Config, got=%q", d.Title())
+	}
+
+	type fieldInfo struct {
+		typ      form.FieldType
+		label    string
+		required bool
+		raw      string
+	}
+	got := make(map[string]fieldInfo)
+	d.ForFields(func(f form.FieldData) {
+		got[f.Var] = fieldInfo{typ: f.Type, label: f.Label, required: f.Required, raw: strings.Join(f.Raw, "|")}
+	})
+	want := map[string]fieldInfo{
+		"desc":                      {typ: form.TypeTextMulti, raw: "line one|line two"},
+		"muc#roomconfig_roomname":   {typ: form.TypeText, label: "Room name", raw: "Room"},
+		"muc#roomconfig_roomsecret": {typ: form.TypeTextPrivate, required: true},
+		"muc#roomconfig_publicroom": {typ: form.TypeBoolean, raw: "true"},
+		"muc#roomconfig_maxusers":   {typ: form.TypeList, raw: "20"},
+		"owner":                     {typ: form.TypeJID, raw: "owner@example.net"},
+		"muc#roomconfig_roomadmins": {typ: form.TypeJIDMulti, raw: "a@example.net|b@example.net"},
+		"langs":                     {typ: form.TypeListMulti},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong fields:\nwant=%+v,\n got=%+v", want, got)
+	}
+}
+
+func TestMarshalInvalid(t *testing.T) {
+	_, err := form.Marshal("not a struct")
+	if err == nil {
+		t.Errorf("expected error marshaling non-struct")
+	}
+	_, err = form.Marshal(struct {
+		C chan int `form:"c"`
+	}{})
+	if err == nil {
+		t.Errorf("expected error marshaling unsupported field type")
+	}
+	_, err = form.Marshal(struct {
+		C chan int `form:"c,text-single"`
+	}{})
+	if err == nil {
+		t.Errorf("expected error marshaling unsupported field type with explicit field type")
+	}
+	err = form.Unmarshal(form.New(), &struct {
+		M map[string]string `form:"m,list-multi"`
+	}{})
+	if err == nil {
+		t.Errorf("expected error unmarshaling unsupported field type")
+	}
+}
+
+func TestNumberSlice(t *testing.T) {
+	type ports struct {
+		Ports []int `form:"ports,list-multi"`
+	}
+	d, err := form.Marshal(ports{Ports: []int{5222, 5269}})
+	if err != nil {
+		t.Fatalf("error marshaling form: %v", err)
+	}
+	raw, _ := d.Raw("ports")
+	if s := strings.Join(raw, "|"); s != "5222|5269" {
+		t.Errorf("wrong values: want=5222|5269, got=%s", s)
+	}
+	var out ports
+	err = form.Unmarshal(d, &out)
+	if err != nil {
+		t.Fatalf("error unmarshaling form: %v", err)
+	}
+	if !reflect.DeepEqual(out.Ports, []int{5222, 5269}) {
+		t.Errorf("wrong ports: want=[5222 5269], got=%v", out.Ports)
+	}
+
+	d = &form.Data{}
+	err = xml.NewDecoder(strings.NewReader(`<x xmlns="jabber:x:data" type="submit"><field var="ports"><value>many</value></field></x>`)).Decode(d)
+	if err != nil {
+		t.Fatalf("error decoding form: %v", err)
+	}
+	err = form.Unmarshal(d, &out)
+	if err == nil {
+		t.Errorf("expected error unmarshaling invalid int in slice")
+	}
+}
+
+func TestUnmarshalNilValue(t *testing.T) {
+	d, err := form.Marshal(roomConfig{Name: "Room"})
+	if err != nil {
+		t.Fatalf("error marshaling form: %v", err)
+	}
+	_, err = d.Set("x", nil)
+	if err != nil {
+		t.Fatalf("error setting nil value: %v", err)
+	}
+	cfg := struct {
+		X    string `form:"x"`
+		Name string `form:"muc#roomconfig_roomname"`
+	}{X: "unchanged"}
+	err = form.Unmarshal(d, &cfg)
+	if err != nil {
+		t.Fatalf("error unmarshaling form: %v", err)
+	}
+	if cfg.X != "unchanged" || cfg.Name != "Room" {
+		t.Errorf("wrong values: %+v", cfg)
+	}
+}
+
+const roomConfigForm = `<x xmlns="jabber:x:data" type="submit">
+<field var="desc"><value>line one</value><value>line two</value></field>
+<field var="muc#roomconfig_roomname"><value>Room</value></field>
+<field var="muc#roomconfig_publicroom"><value>1</value></field>
+<field var="muc#roomconfig_maxusers"><value>20</value></field>
+<field var="owner"><value>owner@example.net</value></field>
+<field var="muc#roomconfig_roomadmins"><value>a@example.net</value><value>b@example.net</value></field>
+<field var="langs"><value>en</value><value>de</value></field>
+</x>`
+
+func TestUnmarshalStruct(t *testing.T) {
+	d := &form.Data{}
+	err := xml.NewDecoder(strings.NewReader(roomConfigForm)).Decode(d)
+	if err != nil {
+		t.Fatalf("error decoding form: %v", err)
+	}
+	cfg := roomConfig{Secret: "unchanged", Internal: "unchanged"}
+	err = form.Unmarshal(d, &cfg)
+	if err != nil {
+		t.Fatalf("error unmarshaling form: %v", err)
+	}
+	want := roomConfig{
+		Common:   Common{Desc: "line one\nline two"},
+		Name:     "Room",
+		Secret:   "unchanged",
+		Public:   true,
+		MaxUsers: 20,
+		Owner:    jid.MustParse("owner@example.net"),
+		Admins:   []jid.JID{jid.MustParse("a@example.net"), jid.MustParse("b@example.net")},
+		Langs:    []string{"en", "de"},
+		Internal: "unchanged",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("wrong struct:\nwant=%+v,\n got=%+v", want, cfg)
+	}
+}
+
+func TestRoundTripSet(t *testing.T) {
+	d, err := form.Marshal(roomConfig{})
+	if err != nil {
+		t.Fatalf("error marshaling form: %v", err)
+	}
+	_, err = d.Set("muc#roomconfig_publicroom", true)
+	if err != nil {
+		t.Fatalf("error setting bool: %v", err)
+	}
+	_, err = d.Set("muc#roomconfig_roomname", "New name")
+	if err != nil {
+		t.Fatalf("error setting string: %v", err)
+	}
+	var cfg roomConfig
+	err = form.Unmarshal(d, &cfg)
+	if err != nil {
+		t.Fatalf("error unmarshaling form: %v", err)
+	}
+	if !cfg.Public || cfg.Name != "New name" || cfg.MaxUsers != 0 {
+		t.Errorf("wrong values after round trip: %+v", cfg)
+	}
+}
+
+func TestUnmarshalInvalidValue(t *testing.T) {
+	d := &form.Data{}
+	err := xml.NewDecoder(strings.NewReader(`<x xmlns="jabber:x:data" type="submit"><field var="muc#roomconfig_maxusers"><value>many</value></field></x>`)).Decode(d)
+	if err != nil {
+		t.Fatalf("error decoding form: %v", err)
+	}
+	var cfg roomConfig
+	err = form.Unmarshal(d, &cfg)
+	if err == nil {
+		t.Errorf("expected error unmarshaling invalid int")
+	}
+	err = form.Unmarshal(d, cfg)
+	if err == nil {
+		t.Errorf("expected error unmarshaling into non-pointer")
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package form
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mellium.im/xmlstream"
+)
+
+// NSValidate is the namespace used by data form validation.
+const NSValidate = "http://jabber.org/protocol/xdata-validate"
+
+// Errors wrapped by FieldError when a value is invalid.
+var (
+	ErrRequired  = errors.New("missing value for required field")
+	ErrDatatype  = errors.New("value does not match datatype")
+	ErrRange     = errors.New("value out of range")
+	ErrRegex     = errors.New("value does not match regular expression")
+	ErrListRange = errors.New("wrong number of values")
+	ErrOption    = errors.New("value is not one of the options")
+)
+
+// FieldError is returned when validating a form for each field with an
+// invalid value.
+type FieldError struct {
+	Var string
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("form: invalid field %q: %v", e.Var, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Validation describes the validation rules for a field (XEP-0122).
+//
+// Datatype is a datatype such as "xs:integer" or "xs:date".
+// If it is empty, "xs:string" is assumed.
+// If Min or Max are set the value must be within the (inclusive) range,
+// compared numerically or chronologically if the datatype allows it and
+// lexically otherwise.
+// If Regex is set the entire value must match it.
+// Regular expressions use the syntax of the regexp package which is similar to,
+// but not exactly the same as, the syntax used by XML Schema.
+// If Open is set, values of list fields need not be one of the options.
+// If ListMin or ListMax are non-zero, the number of values of multi-valued
+// fields must be within the range.
+type Validation struct {
+	Datatype string
+	Min      string
+	Max      string
+	Regex    string
+	Open     bool
+	ListMin  int
+	ListMax  int
+}
+
+// Validate sets the validation rules for a field.
+func Validate(v Validation) Option {
+	return func(f *field) {
+		f.validate = &v
+	}
+}
+
+type validateXML struct {
+	Datatype string    `xml:"datatype,attr,omitempty"`
+	Basic    *struct{} `xml:"basic"`
+	Open     *struct{} `xml:"open"`
+	Range    *struct {
+		Min string `xml:"min,attr"`
+		Max string `xml:"max,attr"`
+	} `xml:"range"`
+	Regex     *string `xml:"regex"`
+	ListRange *struct {
+		Min int `xml:"min,attr"`
+		Max int `xml:"max,attr"`
+	} `xml:"list-range"`
+}
+
+func (v validateXML) validation() *Validation {
+	val := &Validation{
+		Datatype: v.Datatype,
+		Open:     v.Open != nil,
+	}
+	if v.Range != nil {
+		val.Min = v.Range.Min
+		val.Max = v.Range.Max
+	}
+	if v.Regex != nil {
+		val.Regex = *v.Regex
+	}
+	if v.ListRange != nil {
+		val.ListMin = v.ListRange.Min
+		val.ListMax = v.ListRange.Max
+	}
+	return val
+}
+
+// TokenReader returns the validate element.
+// Only a single validation method can be transmitted, if more than one is set
+// the first of open, range, and regex is used.
+func (v *Validation) TokenReader() xml.TokenReader {
+	var attr []xml.Attr
+	if v.Datatype != "" {
+		attr = append(attr, xml.Attr{Name: xml.Name{Local: "datatype"}, Value: v.Datatype})
+	}
+
+	var method xml.TokenReader
+	switch {
+	case v.Open:
+		method = xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Local: "open"}})
+	case v.Min != "" || v.Max != "":
+		var rangeAttr []xml.Attr
+		if v.Min != "" {
+			rangeAttr = append(rangeAttr, xml.Attr{Name: xml.Name{Local: "min"}, Value: v.Min})
+		}
+		if v.Max != "" {
+			rangeAttr = append(rangeAttr, xml.Attr{Name: xml.Name{Local: "max"}, Value: v.Max})
+		}
+		method = xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Local: "range"}, Attr: rangeAttr})
+	case v.Regex != "":
+		method = xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(v.Regex)),
+			xml.StartElement{Name: xml.Name{Local: "regex"}},
+		)
+	default:
+		method = xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Local: "basic"}})
+	}
+
+	var listRange xml.TokenReader
+	if v.ListMin != 0 || v.ListMax != 0 {
+		var rangeAttr []xml.Attr
+		if v.ListMin != 0 {
+			rangeAttr = append(rangeAttr, xml.Attr{Name: xml.Name{Local: "min"}, Value: strconv.Itoa(v.ListMin)})
+		}
+		if v.ListMax != 0 {
+			rangeAttr = append(rangeAttr, xml.Attr{Name: xml.Name{Local: "max"}, Value: strconv.Itoa(v.ListMax)})
+		}
+		listRange = xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Local: "list-range"}, Attr: rangeAttr})
+	}
+
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(method, listRange),
+		xml.StartElement{Name: xml.Name{Space: NSValidate, Local: "validate"}, Attr: attr},
+	)
+}
+
+// Validate checks the values set on the form (or the default values of fields
+// that have not been set) against the validation rules of each field.
+// Fields that are required must also have a value.
+// If any values are invalid, the returned error wraps a *FieldError for each
+// invalid field.
+func (d *Data) Validate() error {
+	return d.ValidateSubmission(d)
+}
+
+// ValidateSubmission is like Validate except that it checks the values of the
+// submitted form against the fields and validation rules of d.
+// It is normally used by the entity that created the form to check the
+// submission that it receives.
+func (d *Data) ValidateSubmission(submission *Data) error {
+	if d == nil {
+		return nil
+	}
+	var errs []error
+	for _, f := range d.fields {
+		if f.typ == TypeFixed || f.varName == "" {
+			continue
+		}
+		values := submission.rawValues(f.varName)
+		err := f.validateValues(values)
+		if err != nil {
+			errs = append(errs, &FieldError{Var: f.varName, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rawValues returns the values set on the form for a field if any, or the raw
+// values of the field otherwise.
+func (d *Data) rawValues(id string) []string {
+	if d == nil {
+		return nil
+	}
+	if v, ok := d.values[id]; ok {
+		return valueStrings(reflect.ValueOf(v))
+	}
+	raw, _ := d.Raw(id)
+	return raw
+}
+
+func (f field) validateValues(values []string) error {
+	nonEmpty := values[:0:0]
+	for _, v := range values {
+		if v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		if f.required {
+			return ErrRequired
+		}
+		return nil
+	}
+	v := f.validate
+	if v == nil {
+		return nil
+	}
+
+	if v.ListMin != 0 && len(nonEmpty) < v.ListMin || v.ListMax != 0 && len(nonEmpty) > v.ListMax {
+		return ErrListRange
+	}
+	var re *regexp.Regexp
+	if v.Regex != "" {
+		var err error
+		re, err = regexp.Compile(`^(?:` + v.Regex + `)$`)
+		if err != nil {
+			return err
+		}
+	}
+	for _, val := range nonEmpty {
+		if !validDatatype(v.Datatype, val) {
+			return ErrDatatype
+		}
+		if (f.typ == TypeList || f.typ == TypeListMulti) && !v.Open && len(f.option) > 0 && !hasOption(f.option, val) {
+			return ErrOption
+		}
+		if v.Min != "" && compareDatatype(v.Datatype, val, v.Min) < 0 {
+			return ErrRange
+		}
+		if v.Max != "" && compareDatatype(v.Datatype, val, v.Max) > 0 {
+			return ErrRange
+		}
+		if re != nil && !re.MatchString(val) {
+			return ErrRegex
+		}
+	}
+	return nil
+}
+
+func hasOption(opts []FieldOpt, val string) bool {
+	for _, opt := range opts {
+		if opt.Value == val {
+			return true
+		}
+	}
+	return false
+}
+
+var langRegexp = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+
+var intBits = map[string]int{
+	"xs:long":  64,
+	"xs:int":   32,
+	"xs:short": 16,
+	"xs:byte":  8,
+}
+
+var timeLayouts = map[string]string{
+	"xs:date":     "2006-01-02",
+	"xs:dateTime": time.RFC3339Nano,
+	"xs:time":     "15:04:05.999999999",
+}
+
+// validDatatype reports whether val is valid for the datatype.
+// Unknown datatypes are assumed to be valid.
+func validDatatype(datatype, val string) bool {
+	switch datatype {
+	case "", "xs:string":
+		return true
+	case "xs:boolean":
+		return val == "true" || val == "false" || val == "1" || val == "0"
+	case "xs:integer":
+		_, ok := new(big.Int).SetString(val, 10)
+		return ok
+	case "xs:long", "xs:int", "xs:short", "xs:byte":
+		_, err := strconv.ParseInt(val, 10, intBits[datatype])
+		return err == nil
+	case "xs:decimal", "xs:double", "xs:float":
+		_, err := strconv.ParseFloat(val, 64)
+		return err == nil
+	case "xs:date", "xs:dateTime", "xs:time":
+		_, err := parseTime(datatype, val)
+		return err == nil
+	case "xs:anyURI":
+		_, err := url.Parse(val)
+		return err == nil
+	case "xs:language":
+		return langRegexp.MatchString(val)
+	}
+	return true
+}
+
+func parseTime(datatype, val string) (time.Time, error) {
+	if datatype == "xs:time" {
+		// Times may optionally have a timezone.
+		t, err := time.Parse(timeLayouts[datatype]+"Z07:00", val)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse(timeLayouts[datatype], val)
+}
+
+// compareDatatype compares two values of the datatype returning -1, 0, or 1 if
+// a is less than, equal to, or greater than b.
+func compareDatatype(datatype, a, b string) int {
+	switch datatype {
+	case "xs:integer", "xs:long", "xs:int", "xs:short", "xs:byte":
+		aInt, okA := new(big.Int).SetString(a, 10)
+		bInt, okB := new(big.Int).SetString(b, 10)
+		if okA && okB {
+			return aInt.Cmp(bInt)
+		}
+	case "xs:decimal", "xs:double", "xs:float":
+		aFloat, errA := strconv.ParseFloat(a, 64)
+		bFloat, errB := strconv.ParseFloat(b, 64)
+		if errA == nil && errB == nil {
+			switch {
+			case aFloat < bFloat:
+				return -1
+			case aFloat > bFloat:
+				return 1
+			}
+			return 0
+		}
+	case "xs:date", "xs:dateTime", "xs:time":
+		aTime, errA := parseTime(datatype, a)
+		bTime, errB := parseTime(datatype, b)
+		if errA == nil && errB == nil {
+			return aTime.Compare(bTime)
+		}
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,205 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package form_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/form"
+)
+
+var validateTestCases = [...]struct {
+	field form.Field
+	value interface{}
+	err   error
+}{
+	0: {
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:integer", Min: "1", Max: "10"})),
+		value: "5",
+	},
+	1: {
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:integer", Min: "1", Max: "10"})),
+		value: "11",
+		err:   form.ErrRange,
+	},
+	2: {
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:integer"})),
+		value: "five",
+		err:   form.ErrDatatype,
+	},
+	3: {
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:byte"})),
+		value: "300",
+		err:   form.ErrDatatype,
+	},
+	4: {
+		field: form.Text("v", form.Validate(form.Validation{Regex: "[a-z]+"})),
+		value: "abc1",
+		err:   form.ErrRegex,
+	},
+	5: {
+		field: form.Text("v", form.Validate(form.Validation{Regex: "[a-z]+"})),
+		value: "abc",
+	},
+	6: {
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:date", Min: "2020-01-01"})),
+		value: "2019-12-31",
+		err:   form.ErrRange,
+	},
+	7: {
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:decimal", Max: "1.5"})),
+		value: "1.25",
+	},
+	8: {
+		field: form.Text("v", form.Required),
+		err:   form.ErrRequired,
+	},
+	9: {
+		field: form.List("v", form.ListItem("A", "a"), form.Validate(form.Validation{})),
+		value: "b",
+		err:   form.ErrOption,
+	},
+	10: {
+		field: form.List("v", form.ListItem("A", "a"), form.Validate(form.Validation{Open: true})),
+		value: "b",
+	},
+	11: {
+		field: form.ListMulti("v", form.Validate(form.Validation{ListMin: 2, ListMax: 3})),
+		value: []string{"a"},
+		err:   form.ErrListRange,
+	},
+	12: {
+		field: form.ListMulti("v", form.Validate(form.Validation{ListMin: 2, ListMax: 3})),
+		value: []string{"a", "b"},
+	},
+	13: {
+		// Unset fields that are not required are not validated.
+		field: form.Text("v", form.Validate(form.Validation{Datatype: "xs:integer"})),
+	},
+	14: {
+		field: form.Boolean("v", form.Validate(form.Validation{Datatype: "xs:boolean"})),
+		value: true,
+	},
+}
+
+func TestValidate(t *testing.T) {
+	for i, tc := range validateTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			data := form.New(tc.field)
+			if tc.value != nil {
+				_, err := data.Set("v", tc.value)
+				if err != nil {
+					t.Fatalf("error setting value: %v", err)
+				}
+			}
+			err := data.Validate()
+			if !errors.Is(err, tc.err) || (err == nil) != (tc.err == nil) {
+				t.Fatalf("wrong error: want=%v, got=%v", tc.err, err)
+			}
+			if err == nil {
+				return
+			}
+			var fieldErr *form.FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected field error, got %T", err)
+			}
+			if fieldErr.Var != "v" {
+				t.Errorf("wrong var: want=v, got=%s", fieldErr.Var)
+			}
+		})
+	}
+}
+
+func TestValidateMarshal(t *testing.T) {
+	data := form.New(
+		form.Text("age", form.Validate(form.Validation{Datatype: "xs:integer", Min: "18"})),
+		form.TextMulti("tags", form.Validate(form.Validation{Regex: "[a-z]+", ListMax: 2})),
+		form.List("color", form.ListItem("Red", "red"), form.Validate(form.Validation{Open: true})),
+	)
+	const want = `<x xmlns="jabber:x:data" type="form">` +
+		`<field type="text-single" var="age"><validate xmlns="http://jabber.org/protocol/xdata-validate" datatype="xs:integer"><range min="18"></range></validate></field>` +
+		`<field type="text-multi" var="tags"><validate xmlns="http://jabber.org/protocol/xdata-validate"><regex>[a-z]+</regex><list-range max="2"></list-range></validate></field>` +
+		`<field type="list-single" var="color"><option label="Red"><value>red</value></option><validate xmlns="http://jabber.org/protocol/xdata-validate"><open></open></validate></field>` +
+		`</x>`
+	b, err := xml.Marshal(data)
+	if err != nil {
+		t.Fatalf("error marshaling form: %v", err)
+	}
+	if string(b) != want {
+		t.Fatalf("wrong XML:\nwant=%s,\n got=%s", want, b)
+	}
+
+	parsed := &form.Data{}
+	err = xml.Unmarshal(b, parsed)
+	if err != nil {
+		t.Fatalf("error unmarshaling form: %v", err)
+	}
+	var rules []form.Validation
+	parsed.ForFields(func(f form.FieldData) {
+		if f.Validation == nil {
+			t.Errorf("missing validation for field %s", f.Var)
+			return
+		}
+		rules = append(rules, *f.Validation)
+	})
+	wantRules := []form.Validation{
+		{Datatype: "xs:integer", Min: "18"},
+		{Regex: "[a-z]+", ListMax: 2},
+		{Open: true},
+	}
+	if len(rules) != len(wantRules) {
+		t.Fatalf("wrong number of rules: want=%d, got=%d", len(wantRules), len(rules))
+	}
+	for i, r := range rules {
+		if r != wantRules[i] {
+			t.Errorf("wrong rule %d: want=%+v, got=%+v", i, wantRules[i], r)
+		}
+	}
+
+	// Validation rules are not included in submissions.
+	_, err = data.Set("age", "21")
+	if err != nil {
+		t.Fatalf("error setting age: %v", err)
+	}
+	r, _ := data.Submit()
+	d := xml.NewTokenDecoder(r)
+	submission := &form.Data{}
+	err = d.Decode(submission)
+	if err != nil {
+		t.Fatalf("error decoding submission: %v", err)
+	}
+	submission.ForFields(func(f form.FieldData) {
+		if f.Validation != nil {
+			t.Errorf("unexpected validation in submission for field %s", f.Var)
+		}
+	})
+}
+
+func TestValidateSubmission(t *testing.T) {
+	data := form.New(
+		form.Text("age", form.Required, form.Validate(form.Validation{Datatype: "xs:integer", Min: "18"})),
+		form.Text("name", form.Required),
+		form.Text("lang", form.Validate(form.Validation{Datatype: "xs:language"})),
+	)
+	submission := &form.Data{}
+	err := xml.Unmarshal([]byte(`<x xmlns="jabber:x:data" type="submit"><field var="age"><value>12</value></field><field var="lang"><value>en-US</value></field></x>`), submission)
+	if err != nil {
+		t.Fatalf("error unmarshaling submission: %v", err)
+	}
+	err = data.ValidateSubmission(submission)
+	if !errors.Is(err, form.ErrRange) {
+		t.Errorf("expected range error, got %v", err)
+	}
+	if !errors.Is(err, form.ErrRequired) {
+		t.Errorf("expected required error, got %v", err)
+	}
+	if s := err.Error(); !strings.Contains(s, `"age"`) || !strings.Contains(s, `"name"`) || strings.Contains(s, `"lang"`) {
+		t.Errorf("wrong fields in error: %s", s)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"encoding/xml"
+	"errors"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/delay"
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/forward"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Archived is a message that has been stored in an archive.
+type Archived struct {
+	// ID is the unique and stable ID assigned to the message by the archive.
+	ID string
+
+	// Delay records when the message was originally received by the archive.
+	Delay delay.Delay
+
+	// Message is the original message stanza.
+	Message xml.TokenReader
+}
+
+// Wrap returns the archived message wrapped in a result element for the
+// provided query ID.
+// The result is not wrapped in a message stanza.
+func (a Archived) Wrap(queryID string) xml.TokenReader {
+	attrs := []xml.Attr{{Name: xml.Name{Local: "id"}, Value: a.ID}}
+	if queryID != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "queryid"}, Value: queryID})
+	}
+	return xmlstream.Wrap(
+		forward.Forwarded{Delay: a.Delay}.Wrap(a.Message),
+		xml.StartElement{
+			Name: xml.Name{Space: NS, Local: "result"},
+			Attr: attrs,
+		},
+	)
+}
+
+// Archive is a message store that can be queried using HandleArchive.
+//
+// Query is called for each incoming history query with the IQ that contained
+// it and should call f once for each message that matches the query, in order.
+// If f returns an error, Query should stop iterating and return the error.
+// The returned Result is sent back to the requesting entity once all messages
+// have been transmitted.
+// If the requested page cannot be found, Query should return a stanza.Error
+// with the ItemNotFound condition.
+// Any stanza.Error returned by Query is transmitted to the requesting entity,
+// other errors are returned from the handler.
+type Archive interface {
+	Query(iq stanza.IQ, q Query, f func(Archived) error) (Result, error)
+}
+
+// The ArchiveFunc type is an adapter to allow the use of ordinary functions as
+// an Archive.
+type ArchiveFunc func(iq stanza.IQ, q Query, f func(Archived) error) (Result, error)
+
+// Query calls f(iq, q, msgs).
+func (f ArchiveFunc) Query(iq stanza.IQ, q Query, msgs func(Archived) error) (Result, error) {
+	return f(iq, q, msgs)
+}
+
+// Fields returns the form that is sent when a requesting entity asks what
+// fields are supported by the archive.
+func Fields() *form.Data {
+	return form.New(
+		form.Hidden("FORM_TYPE", form.Value(NS)),
+		form.JID(fieldWith),
+		form.Text(fieldStart),
+		form.Text(fieldEnd),
+		form.Text(fieldAfter),
+		form.Text(fieldBefore),
+		form.ListMulti(fieldIDs),
+	)
+}
+
+// HandleArchive returns an option that registers a handler that answers
+// history queries using the provided archive.
+// Queries for the supported form fields are also answered.
+func HandleArchive(a Archive) mux.Option {
+	return func(m *mux.ServeMux) {
+		h := archiveHandler{a: a}
+		mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "query"}, h)(m)
+		mux.IQ(stanza.GetIQ, xml.Name{Space: NS, Local: "query"}, h)(m)
+	}
+}
+
+type archiveHandler struct {
+	a Archive
+}
+
+// HandleIQ implements mux.IQHandler.
+func (h archiveHandler) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	if iq.Type == stanza.GetIQ {
+		_, err := xmlstream.Copy(t, iq.Result(xmlstream.Wrap(
+			Fields().TokenReader(),
+			xml.StartElement{Name: xml.Name{Space: NS, Local: "query"}},
+		)))
+		return err
+	}
+
+	var q Query
+	err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), t)).Decode(&q)
+	if err != nil {
+		_, err = xmlstream.Copy(t, iq.Error(stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.BadRequest,
+		}))
+		return err
+	}
+
+	// Results are sent to the requesting entity from the archive.
+	msg := stanza.Message{
+		To:   iq.From,
+		From: iq.To,
+	}
+	res, err := h.a.Query(iq, q, func(a Archived) error {
+		_, err := xmlstream.Copy(t, msg.Wrap(a.Wrap(q.ID)))
+		return err
+	})
+	var stanzaErr stanza.Error
+	switch {
+	case errors.As(err, &stanzaErr):
+		_, err = xmlstream.Copy(t, iq.Error(stanzaErr))
+		return err
+	case err != nil:
+		return err
+	}
+	_, err = xmlstream.Copy(t, iq.Result(res.TokenReader()))
+	return err
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/delay"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestArchive(t *testing.T) {
+	var stored = []string{"1", "2", "3"}
+	var gotQuery history.Query
+	archive := history.ArchiveFunc(func(iq stanza.IQ, q history.Query, f func(history.Archived) error) (history.Result, error) {
+		gotQuery = q
+		if q.PageID == "missing" {
+			return history.Result{}, stanza.Error{Type: stanza.Cancel, Condition: stanza.ItemNotFound}
+		}
+		for _, id := range stored {
+			err := f(history.Archived{
+				ID:    id,
+				Delay: delay.Delay{Time: time.Unix(1, 0)},
+				Message: stanza.Message{Type: stanza.ChatMessage}.Wrap(xmlstream.Wrap(
+					xmlstream.Token(xml.CharData(id)),
+					xml.StartElement{Name: xml.Name{Local: "body"}},
+				)),
+			})
+			if err != nil {
+				return history.Result{}, err
+			}
+		}
+		count := uint64(len(stored))
+		res := history.Result{Complete: true}
+		res.Set.First.ID = stored[0]
+		res.Set.Last = stored[len(stored)-1]
+		res.Set.Count = &count
+		return res, nil
+	})
+
+	h := history.NewHandler(nil)
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(mux.New("", history.HandleArchive(archive))),
+		xmpptest.ClientHandler(mux.New("", history.Handle(h))),
+	)
+	to := jid.MustParse("example.net")
+
+	var bodies []string
+	iter := h.Fetch(context.Background(), history.Query{
+		ID:     "abc",
+		With:   jid.MustParse("juliet@example.com"),
+		Limit:  10,
+		PageID: "0",
+	}, to, cs.Client)
+	for iter.Next() {
+		d := xml.NewTokenDecoder(iter.Current())
+		var v struct {
+			Result struct {
+				ID        string `xml:"id,attr"`
+				QueryID   string `xml:"queryid,attr"`
+				Forwarded struct {
+					Delay   delay.Delay `xml:"urn:xmpp:delay delay"`
+					Message struct {
+						Body string `xml:"body"`
+					} `xml:"message"`
+				} `xml:"urn:xmpp:forward:0 forwarded"`
+			} `xml:"urn:xmpp:mam:2 result"`
+		}
+		err := d.Decode(&v)
+		if err != nil {
+			t.Fatalf("error decoding result: %v", err)
+		}
+		if v.Result.QueryID != "abc" {
+			t.Errorf("wrong query ID: want=abc, got=%s", v.Result.QueryID)
+		}
+		if id := iter.ID(); id != v.Result.ID {
+			t.Errorf("wrong iterator ID: want=%s, got=%s", v.Result.ID, id)
+		}
+		if v.Result.ID != v.Result.Forwarded.Message.Body {
+			t.Errorf("archive ID and message do not match: %s, %s", v.Result.ID, v.Result.Forwarded.Message.Body)
+		}
+		if !v.Result.Forwarded.Delay.Time.Equal(time.Unix(1, 0)) {
+			t.Errorf("wrong delay: %v", v.Result.Forwarded.Delay.Time)
+		}
+		bodies = append(bodies, v.Result.Forwarded.Message.Body)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("error iterating over results: %v", err)
+	}
+	if s := strings.Join(bodies, ","); s != "1,2,3" {
+		t.Errorf("wrong messages: want=1,2,3, got=%s", s)
+	}
+	if gotQuery.ID != "abc" || gotQuery.Limit != 10 || gotQuery.PageID != "0" || gotQuery.With.String() != "juliet@example.com" {
+		t.Errorf("query was not decoded correctly: %+v", gotQuery)
+	}
+
+	res, err := history.Fetch(context.Background(), history.Query{PageID: "missing"}, to, cs.Client)
+	if !errors.Is(err, stanza.Error{Condition: stanza.ItemNotFound}) {
+		t.Errorf("wrong error: want=%v, got=%v", stanza.ItemNotFound, err)
+	}
+	if res.Complete {
+		t.Errorf("did not expect complete result on error")
+	}
+
+	res, err = history.Fetch(context.Background(), history.Query{}, to, cs.Client)
+	if err != nil {
+		t.Fatalf("error fetching history: %v", err)
+	}
+	if !res.Complete || res.Set.Count == nil || *res.Set.Count != 3 || res.Set.First.ID != "1" || res.Set.Last != "3" {
+		t.Errorf("wrong result: %+v", res)
+	}
+}
+
+func TestArchiveFields(t *testing.T) {
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(mux.New("", history.HandleArchive(history.ArchiveFunc(func(stanza.IQ, history.Query, func(history.Archived) error) (history.Result, error) {
+			return history.Result{}, nil
+		})))),
+	)
+	var v struct {
+		Form struct {
+			Fields []struct {
+				Var string `xml:"var,attr"`
+			} `xml:"field"`
+		} `xml:"jabber:x:data x"`
+	}
+	err := cs.Client.UnmarshalIQ(context.Background(), stanza.IQ{Type: stanza.GetIQ}.Wrap(xmlstream.Wrap(
+		nil,
+		xml.StartElement{Name: xml.Name{Space: history.NS, Local: "query"}},
+	)), &v)
+	if err != nil {
+		t.Fatalf("error fetching fields: %v", err)
+	}
+	var fields []string
+	for _, f := range v.Form.Fields {
+		fields = append(fields, f.Var)
+	}
+	const expected = "FORM_TYPE,with,start,end,after-id,before-id,ids"
+	if s := strings.Join(fields, ","); s != expected {
+		t.Errorf("wrong fields: want=%s, got=%s", expected, s)
+	}
+}
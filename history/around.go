@@ -0,0 +1,144 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Anchor is a point in the archive around which messages are fetched.
+// If ID is set it is the stable ID assigned to a message by the archive (the
+// stanza-id) and Time is ignored.
+type Anchor struct {
+	ID   string
+	Time time.Time
+}
+
+// Window is the set of messages surrounding an anchor.
+type Window struct {
+	// Messages contains the messages in the window from oldest to newest.
+	// Each message may only be read once.
+	Messages []xml.TokenReader
+
+	// Index is the index in Messages of the anchored message or, if the window
+	// was anchored to a time, of the first message at or after that time.
+	// If there are no such messages, Index is len(Messages).
+	Index int
+
+	// Earliest and Latest report whether the window reaches the start or end of
+	// the archive respectively.
+	Earliest bool
+	Latest   bool
+
+	// First and Last are the archive IDs of the first and last messages in the
+	// window and can be used to continue paging in either direction by setting
+	// them as the PageID of a Query.
+	First string
+	Last  string
+}
+
+// Around fetches up to n messages before and n messages after the anchor to
+// let clients jump to a specific date or message in the history without
+// paging through everything that comes after it.
+// If the anchor is a message ID, the anchored message is also fetched (using
+// the ids filter) and is the message at Window.Index.
+// Archive times only have second precision, so messages received in the same
+// second as a time anchor are considered to be after it.
+//
+// The filter can be used to limit the messages (eg. by setting With), but its
+// paging fields and any time or ID filters are replaced.
+func (h *Handler) Around(ctx context.Context, filter Query, anchor Anchor, n uint64, to jid.JID, s *xmpp.Session) (Window, error) {
+	return h.AroundIQ(ctx, filter, anchor, n, stanza.IQ{
+		To: to,
+	}, s)
+}
+
+// AroundIQ is like Around but it allows modifying the underlying IQ.
+// Changing the type of the IQ has no effect.
+func (h *Handler) AroundIQ(ctx context.Context, filter Query, anchor Anchor, n uint64, iq stanza.IQ, s *xmpp.Session) (Window, error) {
+	filter.ID = ""
+	filter.Start = time.Time{}
+	filter.End = time.Time{}
+	filter.AfterID = ""
+	filter.BeforeID = ""
+	filter.IDs = nil
+	filter.Limit = n
+	filter.Reverse = false
+	before, after := filter, filter
+	before.Last = true
+	after.Last = false
+	if anchor.ID != "" {
+		before.PageID = anchor.ID
+		after.PageID = anchor.ID
+	} else {
+		before.PageID = ""
+		after.PageID = ""
+		start := anchor.Time.Truncate(time.Second)
+		before.End = start.Add(-time.Second)
+		after.Start = start
+	}
+
+	var w Window
+	msgs, res, err := h.collect(ctx, before, iq, s)
+	if err != nil {
+		return w, err
+	}
+	w.Messages = msgs
+	w.Earliest = res.Complete || len(msgs) == 0
+	w.Index = len(w.Messages)
+	w.First = res.Set.First.ID
+	w.Last = res.Set.Last
+
+	if anchor.ID != "" {
+		target := filter
+		target.Last = false
+		target.PageID = ""
+		target.Limit = 1
+		target.IDs = []string{anchor.ID}
+		msgs, _, err = h.collect(ctx, target, iq, s)
+		if err != nil {
+			return w, err
+		}
+		w.Messages = append(w.Messages, msgs...)
+		if w.First == "" && len(msgs) > 0 {
+			w.First = anchor.ID
+		}
+		w.Last = anchor.ID
+	}
+
+	msgs, res, err = h.collect(ctx, after, iq, s)
+	if err != nil {
+		return w, err
+	}
+	w.Messages = append(w.Messages, msgs...)
+	w.Latest = res.Complete || len(msgs) == 0
+	if res.Set.Last != "" {
+		w.Last = res.Set.Last
+	}
+	if w.First == "" {
+		w.First = res.Set.First.ID
+	}
+	return w, nil
+}
+
+// collect runs a single query and buffers all of the messages it returns.
+func (h *Handler) collect(ctx context.Context, filter Query, iq stanza.IQ, s *xmpp.Session) ([]xml.TokenReader, Result, error) {
+	iter := h.FetchIQ(ctx, filter, iq, s)
+	var msgs []xml.TokenReader
+	for iter.Next() {
+		msgs = append(msgs, iter.Current())
+	}
+	err := iter.Err()
+	if err != nil {
+		return nil, Result{}, err
+	}
+	return msgs, iter.Result(), nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history_test
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/delay"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+var aroundEpoch = time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// timedArchive returns an archive containing messages with IDs 1 through n,
+// each sent one minute after the previous one, that supports time and ID
+// filters as well as paging in both directions.
+func timedArchive(n int) history.Archive {
+	return history.ArchiveFunc(func(iq stanza.IQ, q history.Query, f func(history.Archived) error) (history.Result, error) {
+		var ids []int
+		for id := 1; id <= n; id++ {
+			stamp := aroundEpoch.Add(time.Duration(id) * time.Minute)
+			if !q.Start.IsZero() && stamp.Before(q.Start) || !q.End.IsZero() && stamp.After(q.End) {
+				continue
+			}
+			if len(q.IDs) > 0 {
+				var found bool
+				for _, qid := range q.IDs {
+					found = found || qid == strconv.Itoa(id)
+				}
+				if !found {
+					continue
+				}
+			}
+			ids = append(ids, id)
+		}
+		start, end := 0, len(ids)
+		if q.PageID != "" {
+			page, _ := strconv.Atoi(q.PageID)
+			for i, id := range ids {
+				if id == page {
+					if q.Last {
+						end = i
+					} else {
+						start = i + 1
+					}
+				}
+			}
+		}
+		if q.Limit > 0 {
+			if q.Last && end-int(q.Limit) > start {
+				start = end - int(q.Limit)
+			} else if !q.Last && start+int(q.Limit) < end {
+				end = start + int(q.Limit)
+			}
+		}
+		res := history.Result{Complete: start == 0 && q.Last || end == len(ids) && !q.Last}
+		ids = ids[start:end]
+		for _, id := range ids {
+			err := f(history.Archived{
+				ID:    strconv.Itoa(id),
+				Delay: delay.Delay{Time: aroundEpoch.Add(time.Duration(id) * time.Minute)},
+				Message: stanza.Message{Type: stanza.ChatMessage}.Wrap(xmlstream.Wrap(
+					xmlstream.Token(xml.CharData(strconv.Itoa(id))),
+					xml.StartElement{Name: xml.Name{Local: "body"}},
+				)),
+			})
+			if err != nil {
+				return history.Result{}, err
+			}
+		}
+		if len(ids) > 0 {
+			res.Set.First.ID = strconv.Itoa(ids[0])
+			res.Set.Last = strconv.Itoa(ids[len(ids)-1])
+		}
+		return res, nil
+	})
+}
+
+var aroundTestCases = [...]struct {
+	anchor   history.Anchor
+	n        uint64
+	ids      string
+	index    int
+	earliest bool
+	latest   bool
+}{
+	0: {
+		anchor: history.Anchor{ID: "5"},
+		n:      2,
+		ids:    "3,4,5,6,7",
+		index:  2,
+	},
+	1: {
+		anchor:   history.Anchor{ID: "2"},
+		n:        3,
+		ids:      "1,2,3,4,5",
+		index:    1,
+		earliest: true,
+	},
+	2: {
+		anchor: history.Anchor{ID: "9"},
+		n:      2,
+		ids:    "7,8,9,10",
+		index:  2,
+		latest: true,
+	},
+	3: {
+		anchor: history.Anchor{Time: aroundEpoch.Add(5*time.Minute + 30*time.Second)},
+		n:      2,
+		ids:    "4,5,6,7",
+		index:  2,
+	},
+	4: {
+		// A time on the exact second of a message is considered before it.
+		anchor: history.Anchor{Time: aroundEpoch.Add(5 * time.Minute)},
+		n:      1,
+		ids:    "4,5",
+		index:  1,
+	},
+	5: {
+		anchor:   history.Anchor{Time: aroundEpoch.Add(time.Hour)},
+		n:        2,
+		ids:      "9,10",
+		index:    2,
+		latest:   true,
+		earliest: false,
+	},
+}
+
+func TestAround(t *testing.T) {
+	h := history.NewHandler(nil)
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(mux.New("", history.HandleArchive(timedArchive(10)))),
+		xmpptest.ClientHandler(mux.New("", history.Handle(h))),
+	)
+	to := jid.MustParse("example.net")
+
+	for i, tc := range aroundTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			w, err := h.Around(context.Background(), history.Query{Limit: 100, Last: true}, tc.anchor, tc.n, to, cs.Client)
+			if err != nil {
+				t.Fatalf("error fetching window: %v", err)
+			}
+			var ids []string
+			for _, msg := range w.Messages {
+				entry, err := history.DecodeEntry(msg)
+				if err != nil {
+					t.Fatalf("error decoding message: %v", err)
+				}
+				ids = append(ids, entry.ID)
+			}
+			if s := strings.Join(ids, ","); s != tc.ids {
+				t.Errorf("wrong messages: want=%s, got=%s", tc.ids, s)
+			}
+			if w.Index != tc.index {
+				t.Errorf("wrong index: want=%d, got=%d", tc.index, w.Index)
+			}
+			if w.Earliest != tc.earliest || w.Latest != tc.latest {
+				t.Errorf("wrong bounds: want=(%t, %t), got=(%t, %t)", tc.earliest, tc.latest, w.Earliest, w.Latest)
+			}
+			if len(ids) > 0 && (w.First != ids[0] || w.Last != ids[len(ids)-1]) {
+				t.Errorf("wrong first and last IDs: want=(%s, %s), got=(%s, %s)", ids[0], ids[len(ids)-1], w.First, w.Last)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
 	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/mux"
 	"mellium.im/xmpp/stanza"
@@ -67,11 +68,13 @@ func (h *Handler) HandleMessage(msg stanza.Message, r xmlstream.TokenReadEncoder
 		return err
 	}
 	start := tok.(xml.StartElement)
-	var queryID string
+	var queryID, id string
 	for _, attr := range start.Attr {
-		if attr.Name.Local == "queryid" {
+		switch attr.Name.Local {
+		case "queryid":
 			queryID = attr.Value
-			break
+		case "id":
+			id = attr.Value
 		}
 	}
 	h.trackedM.Lock()
@@ -90,7 +93,13 @@ func (h *Handler) HandleMessage(msg stanza.Message, r xmlstream.TokenReadEncoder
 		return nil
 	}
 
-	iter.msgC <- xmlstream.MultiReader(xmlstream.Token(msgTok), xmlstream.Token(tok), r)
+	// Buffer the message so that it remains valid after the handler returns and
+	// the session moves on to the next stanza.
+	toks, err := xmlstream.ReadAll(xmlstream.MultiReader(xmlstream.Token(msgTok), xmlstream.Token(tok), r))
+	if err != nil {
+		return err
+	}
+	iter.msgC <- archivedMsg{r: tokens.Reader(toks), id: id}
 	return nil
 }
 
@@ -117,7 +126,7 @@ func (h *Handler) FetchIQ(ctx context.Context, filter Query, iq stanza.IQ, s *xm
 		}
 	}
 	iq.Type = stanza.SetIQ
-	msgC := make(chan xml.TokenReader)
+	msgC := make(chan archivedMsg)
 	iter := &Iter{
 		msgC: msgC,
 		h:    h,
@@ -131,7 +140,7 @@ func (h *Handler) FetchIQ(ctx context.Context, filter Query, iq stanza.IQ, s *xm
 			iq.Wrap(filter.TokenReader()),
 			&result,
 		)
-		if err != nil && iter.err != nil {
+		if err != nil && iter.err == nil {
 			// Technically this is racey. I'm not sure that we care though as long as
 			// an error is set?
 			iter.err = err
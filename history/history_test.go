@@ -7,6 +7,7 @@ package history_test
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"testing"
 
 	"mellium.im/xmlstream"
@@ -101,3 +102,31 @@ func TestRoundTrip(t *testing.T) {
 		t.Fatalf("noop close returned error somehow: %v", err)
 	}
 }
+
+func TestFetchError(t *testing.T) {
+	h := history.NewHandler(nil)
+	m := mux.New(
+		"",
+		mux.IQFunc(stanza.SetIQ, xml.Name{Space: history.NS, Local: "query"}, func(iq stanza.IQ, e xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			_, err := xmlstream.Copy(e, iq.Error(stanza.Error{
+				Type:      stanza.Cancel,
+				Condition: stanza.ItemNotFound,
+			}))
+			return err
+		}),
+		history.Handle(h),
+	)
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(m),
+		xmpptest.ClientHandler(m),
+	)
+
+	iter := h.Fetch(context.Background(), history.Query{}, jid.MustParse("example.net"), cs.Client)
+	for iter.Next() {
+		t.Errorf("did not expect any messages")
+	}
+	var stanzaErr stanza.Error
+	if err := iter.Err(); !errors.As(err, &stanzaErr) || stanzaErr.Condition != stanza.ItemNotFound {
+		t.Errorf("wrong error: want=%v, got=%v", stanza.ItemNotFound, err)
+	}
+}
@@ -11,8 +11,8 @@ import (
 // Iter is an iterator over message history.
 type Iter struct {
 	err  error
-	msgC chan xml.TokenReader
-	cur  xml.TokenReader
+	msgC chan archivedMsg
+	cur  archivedMsg
 	h    *Handler
 	id   string
 	res  Result
@@ -27,7 +27,16 @@ func (i *Iter) Next() bool {
 
 // Current returns the current message stream read from the iterator.
 func (i *Iter) Current() xml.TokenReader {
-	return i.cur
+	return i.cur.r
+}
+
+// ID returns the unique and stable ID assigned to the current message by the
+// archive.
+// This is the same as the stanza ID added to the message by the archive when
+// it was first received, and can be used to reference the message in later
+// queries.
+func (i *Iter) ID() string {
+	return i.cur.id
 }
 
 // Err returns any error encountered by the iterator.
@@ -48,3 +57,8 @@ func (i *Iter) Close() error {
 	i.h.remove(i.id)
 	return nil
 }
+
+type archivedMsg struct {
+	r  xml.TokenReader
+	id string
+}
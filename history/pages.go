@@ -0,0 +1,136 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// PageIter is an iterator over message history that automatically queries for
+// the next page of results when the current page is exhausted.
+type PageIter struct {
+	ctx    context.Context
+	h      *Handler
+	filter Query
+	iq     stanza.IQ
+	s      *xmpp.Session
+	max    uint64
+
+	n    uint64
+	page *Iter
+	res  Result
+	err  error
+	done bool
+}
+
+// FetchAll is like Fetch except that the returned iterator continues to
+// request pages until the archive indicates that the results are complete or
+// max messages have been returned.
+// If max is 0, there is no limit.
+//
+// The Limit field of the filter is used as the size of each page.
+// If the filter's Last field is set, pages are fetched from newest to oldest.
+func (h *Handler) FetchAll(ctx context.Context, filter Query, max uint64, to jid.JID, s *xmpp.Session) *PageIter {
+	return h.FetchAllIQ(ctx, filter, max, stanza.IQ{
+		To: to,
+	}, s)
+}
+
+// FetchAllIQ is like FetchAll but it allows modifying the underlying IQ.
+// Changing the type of the IQ has no effect.
+func (h *Handler) FetchAllIQ(ctx context.Context, filter Query, max uint64, iq stanza.IQ, s *xmpp.Session) *PageIter {
+	return &PageIter{
+		ctx:    ctx,
+		h:      h,
+		filter: filter,
+		iq:     iq,
+		s:      s,
+		max:    max,
+	}
+}
+
+// Next advances the iterator, fetching the next page if necessary.
+func (i *PageIter) Next() bool {
+	for !i.done {
+		if i.max > 0 && i.n >= i.max {
+			i.finish()
+			return false
+		}
+		if i.page == nil {
+			i.page = i.h.FetchIQ(i.ctx, i.filter, i.iq, i.s)
+		}
+		if i.page.Next() {
+			i.n++
+			return true
+		}
+		if err := i.page.Err(); err != nil {
+			i.err = err
+			i.finish()
+			return false
+		}
+
+		// The page is complete, so figure out where the next one starts.
+		i.res = i.page.Result()
+		i.page = nil
+		next := i.res.Set.Last
+		if i.filter.Last {
+			next = i.res.Set.First.ID
+		}
+		if i.res.Complete || next == "" || next == i.filter.PageID {
+			i.done = true
+			return false
+		}
+		i.filter.PageID = next
+		// Each page is a separate query and needs its own ID so that results are
+		// not confused with any stragglers from the previous page.
+		i.filter.ID = ""
+	}
+	return false
+}
+
+// finish stops iteration and discards the rest of the current page.
+// The page is drained instead of closed because the handler blocks while
+// delivering a message to the page.
+func (i *PageIter) finish() {
+	i.done = true
+	if i.page != nil {
+		for i.page.Next() {
+		}
+		if i.page.Err() == nil {
+			i.res = i.page.Result()
+		}
+		i.page = nil
+	}
+}
+
+// Current returns the current message stream read from the iterator.
+func (i *PageIter) Current() xml.TokenReader {
+	if i.page == nil {
+		return nil
+	}
+	return i.page.Current()
+}
+
+// Err returns any error encountered by the iterator.
+func (i *PageIter) Err() error {
+	return i.err
+}
+
+// Result returns the result of the last page that was fetched.
+func (i *PageIter) Result() Result {
+	return i.res
+}
+
+// Close stops iterating.
+// Any messages remaining in the current page are discarded.
+func (i *PageIter) Close() error {
+	i.finish()
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history_test
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// pagedArchive returns an archive containing messages with IDs 1 through n
+// that supports paging forward and backward.
+func pagedArchive(n int, queries *[]history.Query) history.Archive {
+	return history.ArchiveFunc(func(iq stanza.IQ, q history.Query, f func(history.Archived) error) (history.Result, error) {
+		*queries = append(*queries, q)
+		var ids []int
+		for id := 1; id <= n; id++ {
+			ids = append(ids, id)
+		}
+		page, _ := strconv.Atoi(q.PageID)
+		start, end := 0, len(ids)
+		switch {
+		case q.Last && page > 0:
+			end = page - 1
+		case page > 0:
+			start = page
+		}
+		if q.Limit > 0 {
+			if q.Last && end-int(q.Limit) > start {
+				start = end - int(q.Limit)
+			} else if !q.Last && start+int(q.Limit) < end {
+				end = start + int(q.Limit)
+			}
+		}
+		ids = ids[start:end]
+		for _, id := range ids {
+			err := f(history.Archived{
+				ID: strconv.Itoa(id),
+				Message: stanza.Message{Type: stanza.ChatMessage}.Wrap(xmlstream.Wrap(
+					xmlstream.Token(xml.CharData(strconv.Itoa(id))),
+					xml.StartElement{Name: xml.Name{Local: "body"}},
+				)),
+			})
+			if err != nil {
+				return history.Result{}, err
+			}
+		}
+		res := history.Result{}
+		if len(ids) > 0 {
+			res.Set.First.ID = strconv.Itoa(ids[0])
+			res.Set.Last = strconv.Itoa(ids[len(ids)-1])
+		}
+		if q.Last {
+			res.Complete = start == 0
+		} else {
+			res.Complete = end == n
+		}
+		return res, nil
+	})
+}
+
+func readPages(t *testing.T, iter *history.PageIter) string {
+	var bodies []string
+	for iter.Next() {
+		d := xml.NewTokenDecoder(iter.Current())
+		var v struct {
+			Result struct {
+				ID string `xml:"id,attr"`
+			} `xml:"urn:xmpp:mam:2 result"`
+		}
+		err := d.Decode(&v)
+		if err != nil {
+			t.Fatalf("error decoding result: %v", err)
+		}
+		bodies = append(bodies, v.Result.ID)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatalf("error iterating over pages: %v", err)
+	}
+	return strings.Join(bodies, ",")
+}
+
+func TestFetchAll(t *testing.T) {
+	var queries []history.Query
+	h := history.NewHandler(nil)
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(mux.New("", history.HandleArchive(pagedArchive(7, &queries)))),
+		xmpptest.ClientHandler(mux.New("", history.Handle(h))),
+	)
+	to := jid.MustParse("example.net")
+
+	iter := h.FetchAll(context.Background(), history.Query{Limit: 3}, 0, to, cs.Client)
+	if ids := readPages(t, iter); ids != "1,2,3,4,5,6,7" {
+		t.Errorf("wrong messages: want=1,2,3,4,5,6,7, got=%s", ids)
+	}
+	if len(queries) != 3 {
+		t.Errorf("wrong number of queries: want=3, got=%d", len(queries))
+	}
+	if res := iter.Result(); !res.Complete || res.Set.Last != "7" {
+		t.Errorf("wrong final result: %+v", res)
+	}
+	ids := make(map[string]struct{})
+	for _, q := range queries {
+		ids[q.ID] = struct{}{}
+	}
+	if len(ids) != len(queries) {
+		t.Errorf("expected each page to use a new query ID, got %+v", queries)
+	}
+
+	queries = nil
+	iter = h.FetchAll(context.Background(), history.Query{Limit: 3, Last: true}, 0, to, cs.Client)
+	if ids := readPages(t, iter); ids != "5,6,7,2,3,4,1" {
+		t.Errorf("wrong messages paging backwards: want=5,6,7,2,3,4,1, got=%s", ids)
+	}
+
+	queries = nil
+	iter = h.FetchAll(context.Background(), history.Query{Limit: 3}, 4, to, cs.Client)
+	if ids := readPages(t, iter); ids != "1,2,3,4" {
+		t.Errorf("wrong messages with max: want=1,2,3,4, got=%s", ids)
+	}
+	if len(queries) != 2 {
+		t.Errorf("wrong number of queries with max: want=2, got=%d", len(queries))
+	}
+	if err := iter.Close(); err != nil {
+		t.Errorf("error closing iter: %v", err)
+	}
+}
@@ -147,6 +147,7 @@ func (f *Query) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			After   string   `xml:"after"`
 			Before  struct {
 				XMLName xml.Name `xml:"before"`
+				ID      string   `xml:",chardata"`
 			}
 		}
 	}{}
@@ -156,28 +157,41 @@ func (f *Query) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	}
 
 	f.ID = s.ID
-	f.With, _ = s.Form.GetJID(fieldWith)
-	startTime, ok := s.Form.GetString(fieldStart)
-	if ok {
-		//panic(startTime)
-		f.Start, err = time.Parse(time.RFC3339, startTime)
-		if err != nil {
-			return err
+	if s.Form != nil {
+		// Not all clients include the type on submitted fields, so parse the JID
+		// from the raw value instead of relying on GetJID.
+		if with, ok := s.Form.Raw(fieldWith); ok && len(with) > 0 {
+			f.With, err = jid.Parse(with[0])
+			if err != nil {
+				return err
+			}
 		}
-	}
-	endTime, ok := s.Form.GetString(fieldEnd)
-	if ok {
-		f.End, err = time.Parse(time.RFC3339, endTime)
-		if err != nil {
-			return err
+		startTime, ok := s.Form.GetString(fieldStart)
+		if ok {
+			f.Start, err = time.Parse(time.RFC3339, startTime)
+			if err != nil {
+				return err
+			}
+		}
+		endTime, ok := s.Form.GetString(fieldEnd)
+		if ok {
+			f.End, err = time.Parse(time.RFC3339, endTime)
+			if err != nil {
+				return err
+			}
 		}
+		f.BeforeID, _ = s.Form.GetString(fieldBefore)
+		f.AfterID, _ = s.Form.GetString(fieldAfter)
+		f.IDs, _ = s.Form.GetStrings(fieldIDs)
 	}
-	f.BeforeID, _ = s.Form.GetString(fieldBefore)
-	f.AfterID, _ = s.Form.GetString(fieldAfter)
-	f.IDs, _ = s.Form.GetStrings(fieldIDs)
 	f.Limit = s.Set.Max
 
 	f.Last = s.Set.Before.XMLName.Local == "before"
+	if f.Last {
+		f.PageID = s.Set.Before.ID
+	} else {
+		f.PageID = s.Set.After
+	}
 	f.Reverse = s.Flip.XMLName.Local == "flip-page"
 	return nil
 }
@@ -0,0 +1,211 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// MessageIter is an iterator over messages returned from an archive.
+// It is implemented by Iter and PageIter.
+type MessageIter interface {
+	Next() bool
+	Current() xml.TokenReader
+	Err() error
+}
+
+// Entry is a simplified representation of an archived message that is used
+// when exporting history.
+type Entry struct {
+	ID    string             `json:"id"`
+	Time  time.Time          `json:"time"`
+	From  string             `json:"from"`
+	To    string             `json:"to"`
+	Type  stanza.MessageType `json:"type,omitempty"`
+	Body  string             `json:"body,omitempty"`
+	Links []string           `json:"links,omitempty"`
+}
+
+// DecodeEntry decodes a message returned from an archive query into an entry.
+// If the forwarded message does not have a from or to address, the address of
+// the archive message itself is used.
+func DecodeEntry(r xml.TokenReader) (Entry, error) {
+	var v struct {
+		From   jid.JID `xml:"from,attr"`
+		To     jid.JID `xml:"to,attr"`
+		Result struct {
+			ID        string `xml:"id,attr"`
+			Forwarded struct {
+				Delay struct {
+					Time time.Time `xml:"stamp,attr"`
+				} `xml:"urn:xmpp:delay delay"`
+				Message struct {
+					From jid.JID            `xml:"from,attr"`
+					To   jid.JID            `xml:"to,attr"`
+					Type stanza.MessageType `xml:"type,attr"`
+					Body string             `xml:"body"`
+					OOB  []struct {
+						URL string `xml:"url"`
+					} `xml:"jabber:x:oob x"`
+				} `xml:"message"`
+			} `xml:"urn:xmpp:forward:0 forwarded"`
+		} `xml:"urn:xmpp:mam:2 result"`
+	}
+	err := xml.NewTokenDecoder(r).Decode(&v)
+	if err != nil {
+		return Entry{}, err
+	}
+	msg := v.Result.Forwarded.Message
+	from, to := msg.From, msg.To
+	if from.Equal(jid.JID{}) {
+		from = v.From
+	}
+	if to.Equal(jid.JID{}) {
+		to = v.To
+	}
+	e := Entry{
+		ID:   v.Result.ID,
+		Time: v.Result.Forwarded.Delay.Time,
+		From: from.String(),
+		To:   to.String(),
+		Type: msg.Type,
+		Body: msg.Body,
+	}
+	for _, oob := range msg.OOB {
+		if oob.URL != "" {
+			e.Links = append(e.Links, oob.URL)
+		}
+	}
+	return e, nil
+}
+
+// Exporter writes message history in formats suitable for archiving or for
+// use by other programs.
+// The zero value is ready to use.
+type Exporter struct {
+	// ResolveLink is called for each media link in a message (such as those
+	// shared using XEP-0066: Out of Band Data) and returns the link that will be
+	// used in the export.
+	// It may be used to download media and refer to a local copy.
+	// If ResolveLink is nil, links are exported unchanged.
+	ResolveLink func(link string) (string, error)
+}
+
+// each calls f for every entry read from iter.
+func (e Exporter) each(iter MessageIter, f func(Entry) error) error {
+	for iter.Next() {
+		entry, err := DecodeEntry(iter.Current())
+		if err != nil {
+			return err
+		}
+		if e.ResolveLink != nil {
+			for i, link := range entry.Links {
+				entry.Links[i], err = e.ResolveLink(link)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		err = f(entry)
+		if err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// JSONL writes each message as a JSON object on its own line.
+func (e Exporter) JSONL(w io.Writer, iter MessageIter) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return e.each(iter, func(entry Entry) error {
+		return enc.Encode(entry)
+	})
+}
+
+// Text writes messages as plain text in a format similar to mbox.
+// Each message begins with a "From " line followed by headers, a blank line,
+// and the body.
+// Lines in the body that begin with "From " are escaped with ">".
+func (e Exporter) Text(w io.Writer, iter MessageIter) error {
+	bw := bufio.NewWriter(w)
+	err := e.each(iter, func(entry Entry) error {
+		from := entry.From
+		if from == "" {
+			from = "-"
+		}
+		bw.WriteString("From " + from + " " + entry.Time.UTC().Format(time.ANSIC) + "\n")
+		bw.WriteString("Date: " + entry.Time.UTC().Format(time.RFC1123Z) + "\n")
+		bw.WriteString("From: " + entry.From + "\n")
+		bw.WriteString("To: " + entry.To + "\n")
+		if entry.ID != "" {
+			bw.WriteString("Message-ID: <" + entry.ID + ">\n")
+		}
+		if entry.Type != "" {
+			bw.WriteString("X-XMPP-Type: " + string(entry.Type) + "\n")
+		}
+		for _, link := range entry.Links {
+			bw.WriteString("X-XMPP-Link: " + link + "\n")
+		}
+		bw.WriteString("\n")
+		if entry.Body != "" {
+			for _, line := range strings.Split(entry.Body, "\n") {
+				if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+					bw.WriteString(">")
+				}
+				bw.WriteString(line + "\n")
+			}
+		}
+		_, err := bw.WriteString("\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+var transcriptTmpl = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<ol>
+{{- range .Entries}}
+<li id="{{.ID}}"><time datetime="{{.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}">{{.Time.UTC.Format "2006-01-02 15:04:05"}}</time> <b>{{.From}}</b>: {{.Body}}{{range .Links}} <a href="{{.}}">{{.}}</a>{{end}}</li>
+{{- end}}
+</ol>
+</body>
+</html>
+`))
+
+// HTML writes messages as an HTML transcript with the provided title.
+// Unlike the other formats, all entries are read before any output is written.
+func (e Exporter) HTML(w io.Writer, title string, iter MessageIter) error {
+	var entries []Entry
+	err := e.each(iter, func(entry Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return transcriptTmpl.Execute(w, struct {
+		Title   string
+		Entries []Entry
+	}{
+		Title:   title,
+		Entries: entries,
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package history_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/delay"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+type sliceIter struct {
+	msgs []history.Archived
+	cur  xml.TokenReader
+}
+
+func (i *sliceIter) Next() bool {
+	if len(i.msgs) == 0 {
+		return false
+	}
+	i.cur = stanza.Message{
+		To:   jid.MustParse("romeo@example.net"),
+		From: jid.MustParse("romeo@example.net"),
+	}.Wrap(i.msgs[0].Wrap("q"))
+	i.msgs = i.msgs[1:]
+	return true
+}
+
+func (i *sliceIter) Current() xml.TokenReader { return i.cur }
+func (i *sliceIter) Err() error               { return nil }
+
+func archived(id, from, body string, link string) history.Archived {
+	payload := []xml.TokenReader{xmlstream.Wrap(
+		xmlstream.Token(xml.CharData(body)),
+		xml.StartElement{Name: xml.Name{Local: "body"}},
+	)}
+	if link != "" {
+		payload = append(payload, xmlstream.Wrap(
+			xmlstream.Wrap(
+				xmlstream.Token(xml.CharData(link)),
+				xml.StartElement{Name: xml.Name{Local: "url"}},
+			),
+			xml.StartElement{Name: xml.Name{Space: "jabber:x:oob", Local: "x"}},
+		))
+	}
+	return history.Archived{
+		ID:    id,
+		Delay: delay.Delay{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		Message: stanza.Message{
+			From: jid.MustParse(from),
+			Type: stanza.ChatMessage,
+		}.Wrap(xmlstream.MultiReader(payload...)),
+	}
+}
+
+func testIter() *sliceIter {
+	return &sliceIter{msgs: []history.Archived{
+		archived("1", "juliet@example.com/balcony", "Art thou not Romeo,\nFrom the house of Montague?", ""),
+		archived("2", "romeo@example.net/orchard", "<3", "https://example.net/rose.jpg"),
+	}}
+}
+
+func TestExportJSONL(t *testing.T) {
+	var buf strings.Builder
+	err := history.Exporter{}.JSONL(&buf, testIter())
+	if err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	const expected = `{"id":"1","time":"2026-01-02T03:04:05Z","from":"juliet@example.com/balcony","to":"romeo@example.net","type":"chat","body":"Art thou not Romeo,\nFrom the house of Montague?"}
+{"id":"2","time":"2026-01-02T03:04:05Z","from":"romeo@example.net/orchard","to":"romeo@example.net","type":"chat","body":"<3","links":["https://example.net/rose.jpg"]}
+`
+	if out := buf.String(); out != expected {
+		t.Errorf("wrong output:\nwant=%s\n got=%s", expected, out)
+	}
+}
+
+func TestExportText(t *testing.T) {
+	var buf strings.Builder
+	err := history.Exporter{}.Text(&buf, testIter())
+	if err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	const expected = `From juliet@example.com/balcony Fri Jan  2 03:04:05 2026
+Date: Fri, 02 Jan 2026 03:04:05 +0000
+From: juliet@example.com/balcony
+To: romeo@example.net
+Message-ID: <1>
+X-XMPP-Type: chat
+
+Art thou not Romeo,
+>From the house of Montague?
+
+From romeo@example.net/orchard Fri Jan  2 03:04:05 2026
+Date: Fri, 02 Jan 2026 03:04:05 +0000
+From: romeo@example.net/orchard
+To: romeo@example.net
+Message-ID: <2>
+X-XMPP-Type: chat
+X-XMPP-Link: https://example.net/rose.jpg
+
+<3
+
+`
+	if out := buf.String(); out != expected {
+		t.Errorf("wrong output:\nwant=%s\n got=%s", expected, out)
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	var buf strings.Builder
+	err := history.Exporter{
+		ResolveLink: func(link string) (string, error) {
+			return "media/" + link[strings.LastIndex(link, "/")+1:], nil
+		},
+	}.HTML(&buf, "Balcony <scene>", testIter())
+	if err != nil {
+		t.Fatalf("error exporting: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<title>Balcony &lt;scene&gt;</title>`,
+		`<li id="2"><time datetime="2026-01-02T03:04:05Z">2026-01-02 03:04:05</time> <b>romeo@example.net/orchard</b>: &lt;3 <a href="media/rose.jpg">media/rose.jpg</a></li>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %s, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportResolveError(t *testing.T) {
+	errResolve := errors.New("resolve failed")
+	err := history.Exporter{
+		ResolveLink: func(string) (string, error) {
+			return "", errResolve
+		},
+	}.JSONL(&strings.Builder{}, testIter())
+	if err != errResolve {
+		t.Errorf("wrong error: want=%v, got=%v", errResolve, err)
+	}
+}
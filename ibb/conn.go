@@ -36,14 +36,35 @@ type stanzaWriter struct {
 	seq           uint16
 	to            jid.JID
 	writeDeadline time.Time
+	blockSize     int
 }
 
+// Write base64 encodes p and transmits it, splitting it into as many stanzas
+// as necessary to keep each payload within the block size.
 func (w *stanzaWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > w.blockSize {
+			chunk = chunk[:w.blockSize]
+		}
+		err := w.writeChunk(chunk)
+		if err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (w *stanzaWriter) writeChunk(p []byte) error {
 	data := dataPayload{
 		Seq:  w.seq,
 		SID:  w.sid,
-		Data: p,
+		Data: make([]byte, base64.StdEncoding.EncodedLen(len(p))),
 	}
+	base64.StdEncoding.Encode(data.Data, p)
 
 	ctx := context.Background()
 	if !w.writeDeadline.IsZero() {
@@ -87,29 +108,29 @@ func (w *stanzaWriter) Write(p []byte) (int, error) {
 		})
 	}
 	if err != nil {
-		return 0, err
+		return err
 	}
 	w.seq++
-	return len(p), nil
+	return nil
 }
 
 // Conn is an IBB stream.
 // Writes to the stream are buffered up to the blocksize before being
 // transmitted.
 type Conn struct {
-	closeFlushFunc func() error
-	handler        *Handler
-	readBuf        *bytes.Buffer
-	readLock       sync.Mutex
-	readReady      chan struct{}
-	writeLock      sync.Mutex
-	readDeadline   time.Time
-	s              *xmpp.Session
-	writeBuf       *bufio.Writer
-	seq            uint16
-	closed         bool
-	stanzaWriter   *stanzaWriter
-	maxBufSize     int
+	handler      *Handler
+	readBuf      *bytes.Buffer
+	readLock     sync.Mutex
+	readReady    chan struct{}
+	writeLock    sync.Mutex
+	readDeadline time.Time
+	s            *xmpp.Session
+	writeBuf     *bufio.Writer
+	seq          uint16
+	closed       bool
+	stanzaWriter *stanzaWriter
+	maxBufSize   int
+	blockSize    uint16
 }
 
 func newConn(h *Handler, s *xmpp.Session, iq openIQ, recv bool, maxBufSize int) *Conn {
@@ -122,29 +143,29 @@ func newConn(h *Handler, s *xmpp.Session, iq openIQ, recv bool, maxBufSize int)
 	} else {
 		to = iq.IQ.To
 	}
-	// Setup a buffered writer to write data to the remote entity using stanzas as
-	// a carrier.
-	stanzaWrite := &stanzaWriter{
-		sid:   iq.Open.SID,
-		acked: iq.Open.Stanza == "iq" || iq.Open.Stanza == "",
-		to:    to,
-		s:     s,
-	}
-	b64Writer := base64.NewEncoder(base64.StdEncoding, stanzaWrite)
 	blockSize := iq.Open.BlockSize
 	if blockSize == 0 {
 		blockSize = BlockSize
 	}
+	// Setup a buffered writer to write data to the remote entity using stanzas as
+	// a carrier.
+	stanzaWrite := &stanzaWriter{
+		sid:       iq.Open.SID,
+		acked:     iq.Open.Stanza == "iq" || iq.Open.Stanza == "",
+		to:        to,
+		s:         s,
+		blockSize: int(blockSize),
+	}
 
 	return &Conn{
-		readBuf:        bytes.NewBuffer(make([]byte, 0, blockSize)),
-		readReady:      make(chan struct{}),
-		s:              s,
-		writeBuf:       bufio.NewWriterSize(b64Writer, int(blockSize)),
-		closeFlushFunc: b64Writer.Close,
-		handler:        h,
-		stanzaWriter:   stanzaWrite,
-		maxBufSize:     maxBufSize,
+		readBuf:      bytes.NewBuffer(make([]byte, 0, blockSize)),
+		readReady:    make(chan struct{}),
+		s:            s,
+		writeBuf:     bufio.NewWriterSize(stanzaWrite, int(blockSize)),
+		handler:      h,
+		stanzaWriter: stanzaWrite,
+		maxBufSize:   maxBufSize,
+		blockSize:    blockSize,
 	}
 }
 
@@ -244,10 +265,6 @@ func (c *Conn) Close() error {
 	if err != nil {
 		return err
 	}
-	err = c.closeFlushFunc()
-	if err != nil {
-		return err
-	}
 
 	ctx := context.Background()
 	if !c.stanzaWriter.writeDeadline.IsZero() {
@@ -281,7 +298,7 @@ func (c *Conn) closeNoNotify(t xmlstream.Encoder) error {
 	}
 
 	close(c.readReady)
-	return c.closeFlushFunc()
+	return nil
 }
 
 // SetReadBuffer sets the maximum size the internal buffer will be allowed to
@@ -59,6 +59,14 @@ func Handle(h *Handler) mux.Option {
 // Handler is an xmpp.Handler that handles multiplexing of bidirectional IBB
 // streams.
 type Handler struct {
+	// MaxBlockSize is the largest block size that will be accepted when the
+	// remote entity opens a stream.
+	// Requests to open a stream with a larger block size are rejected with a
+	// resource-constraint error, after which the remote entity may try again
+	// with a smaller block size.
+	// If MaxBlockSize is zero any block size is accepted.
+	MaxBlockSize uint16
+
 	mu      sync.Mutex
 	streams map[string]*Conn
 	l       map[string]*Listener
@@ -171,6 +179,13 @@ func handleOpen(h *Handler, iq openIQ, e xmlstream.Encoder) error {
 		}))
 		return err
 	}
+	if h.MaxBlockSize > 0 && iq.Open.BlockSize > h.MaxBlockSize {
+		_, err := xmlstream.Copy(e, iq.Error(stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.ResourceConstraint,
+		}))
+		return err
+	}
 	_, err := xmlstream.Copy(e, iq.Result(nil))
 	if err != nil {
 		return err
@@ -205,6 +220,15 @@ func handlePayload(h *Handler, errResp errorResponder, p dataPayload, e xmlstrea
 		return err
 	}
 
+	// Chunks larger than the negotiated block size are not allowed.
+	if decodedLen(p.Data) > int(conn.blockSize) {
+		_, err := xmlstream.Copy(e, errResp.Error(stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.BadRequest,
+		}))
+		return err
+	}
+
 	if p.Seq != conn.seq {
 		_, err := xmlstream.Copy(e, errResp.Error(stanza.Error{
 			Type:      stanza.Cancel,
@@ -257,6 +281,12 @@ func handlePayload(h *Handler, errResp errorResponder, p dataPayload, e xmlstrea
 	return nil
 }
 
+// decodedLen returns the length of the base64 encoded data once decoded.
+func decodedLen(data []byte) int {
+	data = bytes.TrimRight(data, "=")
+	return len(data) * 6 / 8
+}
+
 // Open attempts to create a new IBB stream on the provided session.
 func (h *Handler) Open(ctx context.Context, s *xmpp.Session, to jid.JID) (*Conn, error) {
 	sid := attr.RandomID()
@@ -287,17 +317,12 @@ func open(ctx context.Context, h *Handler, acked bool, s *xmpp.Session, start st
 		iq.Open.BlockSize = blockSize
 	}
 
-	resp, err := s.SendIQ(ctx, iq.TokenReader())
+	err := s.UnmarshalIQ(ctx, iq.TokenReader(), nil)
 	if err != nil {
 		return nil, err
 	}
-	/* #nosec */
-	defer resp.Close()
 
-	conn, err := newConn(h, s, iq, false, MaxBufferSize), nil
-	if err != nil {
-		return nil, err
-	}
+	conn := newConn(h, s, iq, false, MaxBufferSize)
 	h.addStream(sid, conn)
 	return conn, nil
 }
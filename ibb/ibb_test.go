@@ -6,12 +6,15 @@ package ibb_test
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"testing"
 
+	"mellium.im/xmlstream"
 	"mellium.im/xmpp/ibb"
 	"mellium.im/xmpp/internal/xmpptest"
 	"mellium.im/xmpp/jid"
@@ -213,3 +216,58 @@ func TestExpect(t *testing.T) {
 		t.Fatalf("error accepting connection: %v", err)
 	}
 }
+
+func TestBlockSize(t *testing.T) {
+	clientIBB := &ibb.Handler{}
+	serverIBB := &ibb.Handler{MaxBlockSize: 10}
+	clientM := mux.New(
+		stanza.NSClient,
+		ibb.Handle(clientIBB),
+	)
+	serverM := mux.New(
+		stanza.NSClient,
+		ibb.Handle(serverIBB),
+	)
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(clientM),
+		xmpptest.ServerHandler(serverM),
+	)
+
+	accept := make(chan error)
+	ln := serverIBB.Listen(s.Server)
+	go func() {
+		_, err := ln.Accept()
+		accept <- err
+	}()
+
+	_, err := clientIBB.OpenIQ(context.Background(), stanza.IQ{To: s.Server.LocalAddr()}, s.Client, true, 20, "1234")
+	if !errors.Is(err, stanza.Error{Type: stanza.Modify, Condition: stanza.ResourceConstraint}) {
+		t.Fatalf("open expected resource-constraint error, got: %v", err)
+	}
+	_, err = clientIBB.OpenIQ(context.Background(), stanza.IQ{To: s.Server.LocalAddr()}, s.Client, true, 10, "1234")
+	if err != nil {
+		t.Fatalf("error opening connection: %v", err)
+	}
+	if err := <-accept; err != nil {
+		t.Fatalf("error accepting connection: %v", err)
+	}
+
+	// Send a chunk that is larger than the negotiated block size.
+	data := xmlstream.Wrap(
+		xmlstream.Token(xml.CharData(base64.StdEncoding.EncodeToString(make([]byte, 11)))),
+		xml.StartElement{
+			Name: xml.Name{Space: ibb.NS, Local: "data"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "seq"}, Value: "0"},
+				{Name: xml.Name{Local: "sid"}, Value: "1234"},
+			},
+		},
+	)
+	err = s.Client.UnmarshalIQElement(context.Background(), data, stanza.IQ{
+		To:   s.Server.LocalAddr(),
+		Type: stanza.SetIQ,
+	}, nil)
+	if !errors.Is(err, stanza.Error{Type: stanza.Modify, Condition: stanza.BadRequest}) {
+		t.Fatalf("oversized chunk expected bad-request error, got: %v", err)
+	}
+}
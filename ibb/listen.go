@@ -47,6 +47,14 @@ func (l *Listener) Accept() (net.Conn, error) {
 // If Expect is called twice for the same session the original call will be
 // canceled and return a context error and the new Expect call will take over.
 func (l *Listener) Expect(ctx context.Context, from jid.JID, sid string) (net.Conn, error) {
+	return l.Prepare(from, sid)(ctx)
+}
+
+// Prepare is like Expect except that the session is registered as expected
+// before Prepare returns and the returned function blocks until it is opened.
+// This lets a session be expected before negotiating it out-of-band without
+// racing the remote entity that opens it.
+func (l *Listener) Prepare(from jid.JID, sid string) func(context.Context) (net.Conn, error) {
 	l.eLock.Lock()
 	if l.expected == nil {
 		l.expected = make(map[string]expected)
@@ -57,19 +65,23 @@ func (l *Listener) Expect(ctx context.Context, from jid.JID, sid string) (net.Co
 		e.cancel()
 	}
 	e.c = make(chan *Conn)
-	ctx, cancel := context.WithCancel(ctx)
+	replaced, cancel := context.WithCancel(context.Background())
 	e.cancel = cancel
 	l.expected[key] = e
 	l.eLock.Unlock()
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case conn, ok := <-e.c:
-		if !ok {
-			return nil, errors.New("ibb: accept on closed listener")
+	return func(ctx context.Context) (net.Conn, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-replaced.Done():
+			return nil, replaced.Err()
+		case conn, ok := <-e.c:
+			if !ok {
+				return nil, errors.New("ibb: accept on closed listener")
+			}
+			return conn, nil
 		}
-		return conn, nil
 	}
 }
 
@@ -36,3 +36,23 @@ func randomID(n int, r io.Reader) string {
 
 	return fmt.Sprintf("%x", b)[:n]
 }
+
+// UUID generates a new random (version 4) UUID in its canonical string form.
+// If the OS's entropy pool isn't initialized, or we can't generate random
+// numbers for some other reason, panic.
+func UUID() string {
+	return uuid(rand.Reader)
+}
+
+func uuid(r io.Reader) string {
+	var b [16]byte
+	switch n, err := io.ReadFull(r, b[:]); {
+	case err != nil:
+		panic(err)
+	case n != len(b):
+		panic("Could not read enough randomness")
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -62,3 +62,13 @@ func TestRandomPanicsIfRandReadWrongLen(t *testing.T) {
 	}()
 	randomID(16, nopReader{})
 }
+
+func TestUUID(t *testing.T) {
+	const want = "00000000-0000-4000-8000-000000000000"
+	if s := uuid(zeroReader{}); s != want {
+		t.Errorf("wrong UUID: want=%s, got=%s", want, s)
+	}
+	if s := UUID(); len(s) != len(want) || s[14] != '4' {
+		t.Errorf("invalid random UUID %q", s)
+	}
+}
@@ -239,6 +239,12 @@ func CreateUser(ctx context.Context, addr, pass string) integration.Option {
 	}
 }
 
+// createUser creates a user on a running server for the integration.Cmd
+// NewUser method.
+func createUser(ctx context.Context, cmd *integration.Cmd, j jid.JID, pass string) error {
+	return ctlFunc(ctx, "register", j.Localpart(), j.Domainpart(), pass)(cmd)
+}
+
 // Test starts an Ejabberd instance and returns a function that runs f as a
 // subtest using t.Run.
 // Multiple calls to the returned function will result in uniquely named
@@ -249,5 +255,6 @@ func Test(ctx context.Context, t *testing.T, opts ...integration.Option) integra
 		integration.Shutdown(ctlFunc(ctx, "stop")),
 		integration.Shutdown(ctlFunc(ctx, "stopped")),
 	)
+	opts = append(opts, integration.UserCreator(createUser))
 	return integration.Test(ctx, cmdName, t, opts...)
 }
@@ -46,6 +46,18 @@
 // For more information see the SubtestRunner type and the various Option
 // functions.
 //
+// Starting a server is slow, so subtests that do not depend on one another can
+// opt into running in parallel against a single instance of the server using
+// the Parallel option.
+// Servers that support it will provision a new user for each subtest:
+//
+//	prosodyRun := prosody.Test(context.TODO(), t,
+//		integration.Parallel(),
+//		prosody.ListenC2S(),
+//	)
+//	prosodyRun(integrationSendPing)
+//	prosodyRun(integrationRecvPing)
+//
 // The SubtestRunner passes each call a Cmd.
 // This is a representation of the command that is being run (ie. the server or
 // client we're testing against) and provides you with the information you'll
@@ -87,6 +99,7 @@ import (
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -99,6 +112,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -144,6 +158,10 @@ type Cmd struct {
 	stdinPipe     io.WriteCloser
 	closed        chan error
 	skip          bool
+	parallel      bool
+	createUser    func(context.Context, *Cmd, jid.JID, string) error
+	userCount     *uint64
+	proxy         *Proxy
 
 	// Config is meant to be used by internal packages like prosody and ejabberd
 	// to store their internal representation of the config before writing it out.
@@ -164,6 +182,7 @@ func New(ctx context.Context, name string, opts ...Option) (*Cmd, error) {
 		kill:         cancel,
 		closed:       make(chan error),
 		stdoutWriter: &testWriter{},
+		userCount:    new(uint64),
 	}
 	var err error
 	cmd.stdinPipe, err = cmd.Cmd.StdinPipe()
@@ -312,6 +331,11 @@ func (cmd *Cmd) ConfigDir() string {
 func (cmd *Cmd) Close() error {
 	defer cmd.kill()
 
+	if cmd.proxy != nil {
+		/* #nosec */
+		cmd.proxy.Close()
+	}
+
 	err := cmd.stdinPipe.Close()
 	if err != nil {
 		return nil
@@ -344,12 +368,48 @@ func (cmd *Cmd) Close() error {
 	return e
 }
 
+// Proxy returns the fault injection proxy configured by the FaultProxy option
+// (if any).
+func (cmd *Cmd) Proxy() *Proxy {
+	return cmd.proxy
+}
+
 // User returns the address and password of a user created on the server (if
 // any).
 func (cmd *Cmd) User() (jid.JID, string) {
 	return cmd.user, cmd.pass
 }
 
+// NewUser creates a new user on the server with a unique address and random
+// password using the function configured by the UserCreator option.
+// The user is created on the same domain as the user returned by User, or on
+// "localhost" if no user has been configured.
+func (cmd *Cmd) NewUser(ctx context.Context) (jid.JID, string, error) {
+	if cmd.createUser == nil {
+		return jid.JID{}, "", errors.New("integration: no UserCreator option configured")
+	}
+	domain := cmd.user.Domainpart()
+	if domain == "" {
+		domain = "localhost"
+	}
+	n := atomic.AddUint64(cmd.userCount, 1)
+	j, err := jid.New(fmt.Sprintf("user%d", n), domain, "")
+	if err != nil {
+		return jid.JID{}, "", err
+	}
+	var buf [16]byte
+	_, err = rand.Read(buf[:])
+	if err != nil {
+		return jid.JID{}, "", err
+	}
+	pass := hex.EncodeToString(buf[:])
+	err = cmd.createUser(ctx, cmd, j, pass)
+	if err != nil {
+		return jid.JID{}, "", fmt.Errorf("error creating user %s: %w", j, err)
+	}
+	return j, pass, nil
+}
+
 // DialClient attempts to connect to the server with a client-to-server (c2s)
 // connection by dialing the address reserved by C2SListen and then negotiating
 // a stream with the location set to the domainpart of j and the origin set to
@@ -436,6 +496,10 @@ func (cmd *Cmd) Conn(ctx context.Context, s2s bool) (net.Conn, error) {
 	} else {
 		addr = cmd.c2sListener.Addr().String()
 		network = cmd.c2sNetwork
+		if cmd.proxy != nil {
+			addr = cmd.proxy.Addr().String()
+			network = cmd.proxy.Addr().Network()
+		}
 	}
 
 	conn, err := net.Dial(network, addr)
@@ -513,6 +577,49 @@ func User(user jid.JID, pass string) Option {
 	}
 }
 
+// UserCreator sets the function used by NewUser to create users on a running
+// server.
+// Server packages normally configure this automatically.
+func UserCreator(f func(ctx context.Context, cmd *Cmd, j jid.JID, pass string) error) Option {
+	return func(cmd *Cmd) error {
+		cmd.createUser = f
+		return nil
+	}
+}
+
+// FaultProxy inserts a Proxy between client-to-server (c2s) connections
+// created by the Cmd and the command once it is started.
+// The proxy can be retrieved with the Cmd's Proxy method and used to inject
+// faults into the connection, for example to test reconnection or stream
+// management.
+// Because the proxy is shared, it should not be combined with the Parallel
+// option unless the faults are safe to apply to every subtest.
+func FaultProxy() Option {
+	return Defer(func(cmd *Cmd) error {
+		if cmd.c2sListener == nil {
+			return errors.New("fault proxy requires a c2s listener")
+		}
+		var err error
+		cmd.proxy, err = NewProxy(cmd.c2sNetwork, cmd.c2sListener.Addr().String())
+		return err
+	})
+}
+
+// Parallel configures the SubtestRunner to run its subtests in parallel with
+// one another against a single instance of the command.
+// If a UserCreator option has been configured, each subtest is given its own
+// user (as returned by the User method of the Cmd passed to the subtest) so
+// that the subtests do not interfere with one another.
+//
+// Because the subtests run concurrently, the output of the command is logged
+// to the parent test instead of to the individual subtests.
+func Parallel() Option {
+	return func(cmd *Cmd) error {
+		cmd.parallel = true
+		return nil
+	}
+}
+
 // Shutdown is run before the configuration is removed and is meant to
 // gracefully shutdown the application in case it does not handle the kill
 // signal correctly.
@@ -851,12 +958,31 @@ func Test(ctx context.Context, name string, t *testing.T, opts ...Option) Subtes
 	return func(f func(context.Context, *testing.T, *Cmd)) bool {
 		i++
 		return t.Run(fmt.Sprintf("%s/%d", filepath.Base(cmd.name), i), func(t *testing.T) {
-			if tw, ok := cmd.Cmd.Stdout.(*testWriter); ok {
-				tw.Update(t)
+			if !cmd.parallel {
+				if tw, ok := cmd.Cmd.Stdout.(*testWriter); ok {
+					tw.Update(t)
+				}
+				cmd.in.Update(t)
+				cmd.out.Update(t)
+				f(ctx, t, cmd)
+				return
+			}
+
+			t.Parallel()
+			if cmd.createUser == nil {
+				f(ctx, t, cmd)
+				return
+			}
+			j, pass, err := cmd.NewUser(ctx)
+			if err != nil {
+				t.Fatal(err)
 			}
-			cmd.in.Update(t)
-			cmd.out.Update(t)
-			f(ctx, t, cmd)
+			// Each subtest gets a shallow copy of the command so that User returns
+			// the user created for that subtest.
+			subCmd := *cmd
+			subCmd.user = j
+			subCmd.pass = pass
+			f(ctx, t, &subCmd)
 		})
 	}
 }
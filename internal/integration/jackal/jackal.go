@@ -229,11 +229,18 @@ func defaultConfig(cmd *integration.Cmd) error {
 	return ConfigFile(cfg)(cmd)
 }
 
+// createUser creates a user on a running server for the integration.Cmd
+// NewUser method.
+func createUser(ctx context.Context, cmd *integration.Cmd, j jid.JID, pass string) error {
+	return ctlFunc(ctx, "user", "add", fmt.Sprintf("%s:%s", j.Localpart(), pass))(cmd)
+}
+
 // Test starts a Jackal instance and returns a function that runs subtests.
 // Multiple calls to the returned function will result in uniquely named
 // subtests.
 // When all subtests have completed, the daemon is stopped.
 func Test(ctx context.Context, t *testing.T, opts ...integration.Option) integration.SubtestRunner {
 	opts = append(opts, defaultConfig)
+	opts = append(opts, integration.UserCreator(createUser))
 	return integration.Test(ctx, cmdName, t, opts...)
 }
@@ -354,6 +354,12 @@ func Bidi() integration.Option {
 	return Modules("s2s_bidi")
 }
 
+// createUser creates a user on a running server for the integration.Cmd
+// NewUser method.
+func createUser(ctx context.Context, cmd *integration.Cmd, j jid.JID, pass string) error {
+	return ctlFunc(ctx, "register", j.Localpart(), j.Domainpart(), pass)(cmd)
+}
+
 // Test starts a Prosody instance and returns a function that runs subtests
 // using t.Run.
 // Multiple calls to the returned function will result in uniquely named
@@ -363,5 +369,6 @@ func Test(ctx context.Context, t *testing.T, opts ...integration.Option) integra
 	opts = append(opts, defaultConfig,
 		integration.Args("-F"),
 		integration.Shutdown(ctlFunc(ctx, "stop")))
+	opts = append(opts, integration.UserCreator(createUser))
 	return integration.Test(ctx, cmdName, t, opts...)
 }
@@ -0,0 +1,265 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package integration
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Direction is the direction that data is flowing through a Proxy.
+type Direction uint8
+
+// A list of directions.
+const (
+	// Upstream is data sent from the client to the server.
+	Upstream Direction = iota
+
+	// Downstream is data sent from the server to the client.
+	Downstream
+)
+
+// Proxy is a TCP proxy that forwards connections to a target address and can
+// inject faults such as latency, rate limits, resets, and corruption into the
+// forwarded data.
+// Faults can be changed at any time and apply to data forwarded after the
+// change, which makes it possible to script failures at a specific point in a
+// test.
+type Proxy struct {
+	ln      net.Listener
+	network string
+	target  string
+
+	mu         sync.Mutex
+	latency    time.Duration
+	rate       int
+	corrupt    func(Direction, []byte)
+	resetAfter map[Direction]int64
+	conns      map[*proxyConn]struct{}
+	closed     bool
+}
+
+// NewProxy starts a proxy listening on a random local TCP port that forwards
+// connections to target on the named network.
+func NewProxy(network, target string) (*Proxy, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &Proxy{
+		ln:         ln,
+		network:    network,
+		target:     target,
+		resetAfter: make(map[Direction]int64),
+		conns:      make(map[*proxyConn]struct{}),
+	}
+	go p.serve()
+	return p, nil
+}
+
+// Addr returns the TCP address that the proxy is listening on.
+func (p *Proxy) Addr() net.Addr {
+	return p.ln.Addr()
+}
+
+// SetLatency delays each chunk of data forwarded in either direction by d.
+func (p *Proxy) SetLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+}
+
+// SetRateLimit limits the data forwarded in each direction of each connection
+// to bytesPerSecond.
+// If bytesPerSecond is zero or less, the rate is not limited.
+func (p *Proxy) SetRateLimit(bytesPerSecond int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rate = bytesPerSecond
+}
+
+// SetCorrupt sets a function that may modify each chunk of data before it is
+// forwarded.
+// If f is nil, data is forwarded unmodified.
+func (p *Proxy) SetCorrupt(f func(dir Direction, b []byte)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.corrupt = f
+}
+
+// ResetAfter resets connections after n more bytes have been forwarded in the
+// given direction.
+// The limit is tracked for each connection individually: connections that are
+// already open are reset after forwarding n more bytes and connections that
+// are opened later are reset after forwarding their first n bytes.
+// If n is less than zero, the limit is removed from all connections.
+func (p *Proxy) ResetAfter(dir Direction, n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n < 0 {
+		delete(p.resetAfter, dir)
+		for c := range p.conns {
+			delete(c.limit, dir)
+		}
+		return
+	}
+	p.resetAfter[dir] = n
+	for c := range p.conns {
+		c.limit[dir] = c.count[dir] + n
+	}
+}
+
+// Reset abruptly closes all connections that are currently open.
+// New connections are still accepted.
+func (p *Proxy) Reset() {
+	p.mu.Lock()
+	conns := make([]*proxyConn, 0, len(p.conns))
+	for c := range p.conns {
+		conns = append(conns, c)
+	}
+	p.mu.Unlock()
+	for _, c := range conns {
+		c.reset()
+	}
+}
+
+// Close stops the proxy and closes all connections.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	err := p.ln.Close()
+	p.Reset()
+	return err
+}
+
+func (p *Proxy) serve() {
+	for {
+		client, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(client)
+	}
+}
+
+func (p *Proxy) handle(client net.Conn) {
+	server, err := net.Dial(p.network, p.target)
+	if err != nil {
+		/* #nosec */
+		client.Close()
+		return
+	}
+	c := &proxyConn{
+		client: client,
+		server: server,
+		count:  make(map[Direction]int64),
+		limit:  make(map[Direction]int64),
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		c.reset()
+		return
+	}
+	for dir, n := range p.resetAfter {
+		c.limit[dir] = n
+	}
+	p.conns[c] = struct{}{}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.pipe(c, Upstream, server, client)
+	}()
+	go func() {
+		defer wg.Done()
+		p.pipe(c, Downstream, client, server)
+	}()
+	wg.Wait()
+
+	p.mu.Lock()
+	delete(p.conns, c)
+	p.mu.Unlock()
+}
+
+func (p *Proxy) pipe(c *proxyConn, dir Direction, dst, src net.Conn) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.mu.Lock()
+			latency, rate, corrupt := p.latency, p.rate, p.corrupt
+			chunk := buf[:n]
+			reset := false
+			if limit, ok := c.limit[dir]; ok && c.count[dir]+int64(n) >= limit {
+				chunk = chunk[:limit-c.count[dir]]
+				reset = true
+				delete(c.limit, dir)
+			}
+			c.count[dir] += int64(len(chunk))
+			p.mu.Unlock()
+
+			if latency > 0 {
+				time.Sleep(latency)
+			}
+			if corrupt != nil {
+				corrupt(dir, chunk)
+			}
+			if _, werr := dst.Write(chunk); werr != nil {
+				c.reset()
+				return
+			}
+			if rate > 0 {
+				time.Sleep(time.Duration(len(chunk)) * time.Second / time.Duration(rate))
+			}
+			if reset {
+				c.reset()
+				return
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// Half close so that the other direction can finish.
+				if tcp, ok := dst.(*net.TCPConn); ok {
+					/* #nosec */
+					tcp.CloseWrite()
+					return
+				}
+			}
+			c.reset()
+			return
+		}
+	}
+}
+
+type proxyConn struct {
+	client, server net.Conn
+	// count and limit are guarded by the proxies mutex.
+	count map[Direction]int64
+	limit map[Direction]int64
+	once  sync.Once
+}
+
+// reset closes both sides of the connection without lingering so that a TCP
+// reset is sent instead of a graceful close.
+func (c *proxyConn) reset() {
+	c.once.Do(func() {
+		for _, conn := range []net.Conn{c.client, c.server} {
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				/* #nosec */
+				tcp.SetLinger(0)
+			}
+			/* #nosec */
+			conn.Close()
+		}
+	})
+}
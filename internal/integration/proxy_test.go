@@ -0,0 +1,164 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package integration_test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/internal/integration"
+)
+
+// echoServer starts a server that echos everything it receives.
+func echoServer(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	t.Cleanup(func() {
+		ln.Close()
+	})
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				/* #nosec */
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln
+}
+
+func dialProxy(t *testing.T) (*integration.Proxy, net.Conn) {
+	t.Helper()
+	ln := echoServer(t)
+	p, err := integration.NewProxy("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("error starting proxy: %v", err)
+	}
+	t.Cleanup(func() {
+		p.Close()
+	})
+	conn, err := net.Dial("tcp", p.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing proxy: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	err = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatalf("error setting deadline: %v", err)
+	}
+	return p, conn
+}
+
+func echo(t *testing.T, conn net.Conn, msg string) string {
+	t.Helper()
+	_, err := conn.Write([]byte(msg))
+	if err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, buf)
+	if err != nil {
+		t.Fatalf("error reading: %v", err)
+	}
+	return string(buf)
+}
+
+func TestProxyForwards(t *testing.T) {
+	_, conn := dialProxy(t)
+	if out := echo(t, conn, "<presence/>"); out != "<presence/>" {
+		t.Errorf("wrong echo: want=%q, got=%q", "<presence/>", out)
+	}
+}
+
+func TestProxyCorrupt(t *testing.T) {
+	p, conn := dialProxy(t)
+	p.SetCorrupt(func(dir integration.Direction, b []byte) {
+		if dir == integration.Upstream {
+			copy(b, bytes.ToUpper(b))
+		}
+	})
+	if out := echo(t, conn, "<presence/>"); out != "<PRESENCE/>" {
+		t.Errorf("wrong echo: want=%q, got=%q", "<PRESENCE/>", out)
+	}
+}
+
+func TestProxyLatency(t *testing.T) {
+	p, conn := dialProxy(t)
+	const latency = 50 * time.Millisecond
+	p.SetLatency(latency)
+	start := time.Now()
+	echo(t, conn, "a")
+	// The latency is applied once in each direction.
+	if d := time.Since(start); d < 2*latency {
+		t.Errorf("expected round trip to take at least %v, took %v", 2*latency, d)
+	}
+}
+
+func TestProxyResetAfter(t *testing.T) {
+	p, conn := dialProxy(t)
+	p.ResetAfter(integration.Upstream, 3)
+	_, err := conn.Write([]byte("abcdef"))
+	if err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	out, _ := io.ReadAll(conn)
+	if len(out) > 3 {
+		t.Errorf("expected connection to be reset after 3 bytes, got %q", out)
+	}
+
+	// The limit applies to new connections as well until it is removed.
+	conn, err = net.Dial("tcp", p.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing proxy: %v", err)
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatalf("error setting deadline: %v", err)
+	}
+	_, err = conn.Write([]byte("abcdef"))
+	if err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	out, _ = io.ReadAll(conn)
+	if len(out) > 3 {
+		t.Errorf("expected new connection to be reset after 3 bytes, got %q", out)
+	}
+
+	p.ResetAfter(integration.Upstream, -1)
+	conn, err = net.Dial("tcp", p.Addr().String())
+	if err != nil {
+		t.Fatalf("error dialing proxy: %v", err)
+	}
+	defer conn.Close()
+	if out := echo(t, conn, "abcdef"); out != "abcdef" {
+		t.Errorf("wrong echo after removing limit: want=%q, got=%q", "abcdef", out)
+	}
+}
+
+func TestProxyReset(t *testing.T) {
+	p, conn := dialProxy(t)
+	echo(t, conn, "a")
+	p.Reset()
+	// A reset may be reported as an error or as EOF depending on timing, but
+	// the connection must not stay open until the deadline.
+	_, err := io.ReadAll(conn)
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		t.Errorf("connection was not reset")
+	}
+}
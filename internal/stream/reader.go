@@ -48,6 +48,10 @@ func (r *reader) Token() (xml.Token, error) {
 	case xml.StartElement:
 		r.depth++
 		if r.ws && t.Name.Space == wsNamespace && !r.negotiating {
+			// The WebSocket equivalent of </stream:stream>.
+			if t.Name.Local == "close" && r.depth == 1 {
+				return nil, io.EOF
+			}
 			return nil, ErrUnexpectedRestart
 		}
 		if t.Name.Space != stream.NS {
@@ -165,3 +165,21 @@ func TestDisallowedTokenType(t *testing.T) {
 		}
 	}
 }
+
+func TestWebSocketClose(t *testing.T) {
+	d := xml.NewDecoder(strings.NewReader(`<message xmlns="jabber:client"/><close xmlns="urn:ietf:params:xml:ns:xmpp-framing"/><message xmlns="jabber:client"/>`))
+	r := stream.Reader(d, true)
+	toks, err := xmlstream.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(toks) != 2 {
+		t.Errorf("expected reading to stop at close element, got %d tokens", len(toks))
+	}
+
+	d = xml.NewDecoder(strings.NewReader(`<open xmlns="urn:ietf:params:xml:ns:xmpp-framing"/>`))
+	_, err = xmlstream.ReadAll(stream.Reader(d, true))
+	if !errors.Is(err, stream.ErrUnexpectedRestart) {
+		t.Errorf("unexpected error: want=%v, got=%v", stream.ErrUnexpectedRestart, err)
+	}
+}
@@ -33,6 +33,9 @@ func Send(rw io.ReadWriter, streamData *stream.Info, ws bool, version stream.Ver
 	b := bufio.NewWriter(rw)
 	var err error
 	if ws {
+		// Record the framing namespace so that the stream is later closed with a
+		// close element instead of a stream end tag.
+		streamData.Name = xml.Name{Space: wsNamespace, Local: "open"}
 		_, err = fmt.Fprintf(b,
 			`<open xmlns="urn:ietf:params:xml:ns:xmpp-framing" version='%s'`,
 			version,
@@ -0,0 +1,28 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package tokens contains functionality for working with buffered XML tokens.
+package tokens // import "mellium.im/xmpp/internal/tokens"
+
+import (
+	"encoding/xml"
+	"io"
+
+	"mellium.im/xmlstream"
+)
+
+// Reader returns a token reader that reads toks in order and then returns
+// io.EOF.
+// The tokens are not copied and the underlying array of toks is not modified,
+// so multiple readers over the same tokens may be used concurrently.
+func Reader(toks []xml.Token) xml.TokenReader {
+	return xmlstream.ReaderFunc(func() (xml.Token, error) {
+		if len(toks) == 0 {
+			return nil, io.EOF
+		}
+		tok := toks[0]
+		toks = toks[1:]
+		return tok, nil
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package tokens_test
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/tokens"
+)
+
+func TestReader(t *testing.T) {
+	toks := []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "a"}, Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: "1"}}},
+		xml.CharData("b"),
+		xml.EndElement{Name: xml.Name{Local: "a"}},
+	}
+	for i := 0; i < 2; i++ {
+		out, err := xmlstream.ReadAll(tokens.Reader(toks))
+		if err != nil {
+			t.Fatalf("error reading tokens: %v", err)
+		}
+		if !reflect.DeepEqual(out, toks) {
+			t.Errorf("wrong tokens on read %d: want=%v, got=%v", i, toks, out)
+		}
+	}
+	out, err := xmlstream.ReadAll(tokens.Reader(nil))
+	if err != nil || len(out) != 0 {
+		t.Errorf("expected empty reader to return no tokens, got %v, %v", out, err)
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpptest
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"mellium.im/xmlstream"
+)
+
+// Element is a generic XML element received by a Script.
+type Element struct {
+	XMLName  xml.Name
+	Attr     []xml.Attr `xml:",any,attr"`
+	Children []Element  `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// Get returns the value of the first attribute with the provided local name.
+func (e Element) Get(local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local && a.Name.Space != "xmlns" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// String returns the element re-encoded as XML for use in test failures.
+func (e Element) String() string {
+	var buf bytes.Buffer
+	/* #nosec */
+	xml.NewEncoder(&buf).Encode(e)
+	return buf.String()
+}
+
+// Script is a mock server that expects the elements it receives to match a
+// list of patterns in order and responds to them with canned replies.
+// Any element that does not match the next expectation, or that is received
+// after all expectations have been met, fails the test.
+// Expectations that are still unmet when the test completes also fail the
+// test.
+//
+// Patterns are a small subset of XPath: a list of steps separated by "/"
+// where the first step matches the element that was received and each
+// subsequent step matches a direct child of the element matched by the
+// previous step.
+// Each step is a local name or "*" followed by any number of predicates:
+//
+//	[@attr]          the attribute is present
+//	[@attr='value']  the attribute has the given value
+//	[text()='value'] the character data of the element is the given value
+//
+// The special attribute "xmlns" matches the namespace of the element.
+// For example, a ping sent by a client could be matched with:
+//
+//	iq[@type='get']/ping[@xmlns='urn:xmpp:ping']
+type Script struct {
+	t testing.TB
+
+	mu      sync.Mutex
+	expect  []*Expectation
+	pending int
+	done    chan struct{}
+}
+
+// NewScript returns a new script that reports failures to t.
+func NewScript(t testing.TB) *Script {
+	s := &Script{
+		t:    t,
+		done: make(chan struct{}),
+	}
+	close(s.done)
+	t.Cleanup(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, e := range s.expect[len(s.expect)-s.pending:] {
+			t.Errorf("expected element matching %q was never received", e.pattern)
+		}
+	})
+	return s
+}
+
+// Expect adds an expectation that the next element that has not already been
+// matched by an earlier expectation matches pattern.
+// If the pattern is invalid the test fails immediately.
+func (s *Script) Expect(pattern string) *Expectation {
+	s.t.Helper()
+	steps, err := parsePattern(pattern)
+	if err != nil {
+		s.t.Fatalf("invalid pattern %q: %v", pattern, err)
+	}
+	e := &Expectation{pattern: pattern, steps: steps}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == 0 {
+		s.done = make(chan struct{})
+	}
+	s.expect = append(s.expect, e)
+	s.pending++
+	return e
+}
+
+// Wait blocks until all expectations have been met or the context is
+// canceled.
+func (s *Script) Wait(ctx context.Context) error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ClientServer returns a ClientServer that uses the script as the server
+// handler.
+// Any ServerHandler option in opts is overridden.
+func (s *Script) ClientServer(opts ...Option) *ClientServer {
+	return NewClientServer(append(opts, ServerHandler(s))...)
+}
+
+// HandleXMPP satisfies xmpp.Handler.
+func (s *Script) HandleXMPP(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	var el Element
+	err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r)).Decode(&el)
+	if err != nil {
+		s.t.Errorf("error decoding element: %v", err)
+		return err
+	}
+
+	s.mu.Lock()
+	if s.pending == 0 {
+		s.mu.Unlock()
+		s.t.Errorf("unexpected element received after all expectations were met: %s", el)
+		return nil
+	}
+	e := s.expect[len(s.expect)-s.pending]
+	if !matchSteps(el, e.steps) {
+		s.mu.Unlock()
+		s.t.Errorf("element did not match expected pattern %q: %s", e.pattern, el)
+		return nil
+	}
+	s.pending--
+	if s.pending == 0 {
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	if e.reply == nil {
+		return nil
+	}
+	reply := e.reply(el)
+	if reply == "" {
+		return nil
+	}
+	_, err = xmlstream.Copy(r, xml.NewDecoder(strings.NewReader(reply)))
+	if err != nil {
+		s.t.Errorf("error sending reply to %q: %v", e.pattern, err)
+	}
+	return err
+}
+
+// Expectation is a single expected element and the response to send when it
+// is received.
+type Expectation struct {
+	pattern string
+	steps   []step
+	reply   func(Element) string
+}
+
+// Reply sets the XML sent in response to the expected element.
+// The strings "{id}", "{from}", and "{to}" are replaced with the id of the
+// element and the from and to attributes swapped so that the reply is
+// addressed to the sender.
+func (e *Expectation) Reply(s string) *Expectation {
+	return e.ReplyFunc(func(el Element) string {
+		return strings.NewReplacer(
+			"{id}", el.Get("id"),
+			"{from}", el.Get("to"),
+			"{to}", el.Get("from"),
+		).Replace(s)
+	})
+}
+
+// ReplyResult responds to an IQ with an empty result.
+func (e *Expectation) ReplyResult() *Expectation {
+	return e.Reply(`<iq type="result" id="{id}" from="{from}" to="{to}"/>`)
+}
+
+// ReplyFunc sets a function that returns the XML sent in response to the
+// expected element.
+// If f returns an empty string no response is sent.
+func (e *Expectation) ReplyFunc(f func(Element) string) *Expectation {
+	e.reply = f
+	return e
+}
+
+type predicate struct {
+	attr  string
+	text  bool
+	any   bool
+	value string
+}
+
+type step struct {
+	name  string
+	preds []predicate
+}
+
+func parsePattern(pattern string) ([]step, error) {
+	var steps []step
+	for _, part := range splitSteps(pattern) {
+		idx := strings.IndexByte(part, '[')
+		name := part
+		if idx != -1 {
+			name = part[:idx]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("empty step")
+		}
+		st := step{name: name}
+		rest := part[len(name):]
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end == -1 {
+				return nil, fmt.Errorf("malformed predicate %q", rest)
+			}
+			pred, err := parsePredicate(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+			st.preds = append(st.preds, pred)
+			rest = rest[end+1:]
+		}
+		steps = append(steps, st)
+	}
+	return steps, nil
+}
+
+// splitSteps splits the pattern on any "/" that is not inside of a predicate.
+func splitSteps(pattern string) []string {
+	var parts []string
+	var depth int
+	var last int
+	for i, c := range pattern {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '/':
+			if depth == 0 {
+				parts = append(parts, pattern[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, pattern[last:])
+}
+
+func parsePredicate(s string) (predicate, error) {
+	var p predicate
+	key, value, hasValue := strings.Cut(s, "=")
+	switch {
+	case key == "text()":
+		p.text = true
+	case strings.HasPrefix(key, "@") && len(key) > 1:
+		p.attr = key[1:]
+	default:
+		return p, fmt.Errorf("unsupported predicate %q", s)
+	}
+	if !hasValue {
+		if p.text {
+			return p, fmt.Errorf("text() predicate requires a value")
+		}
+		p.any = true
+		return p, nil
+	}
+	if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+		return p, fmt.Errorf("value in predicate %q must be quoted", s)
+	}
+	p.value = value[1 : len(value)-1]
+	return p, nil
+}
+
+func matchSteps(el Element, steps []step) bool {
+	if !matchStep(el, steps[0]) {
+		return false
+	}
+	if len(steps) == 1 {
+		return true
+	}
+	for _, child := range el.Children {
+		if matchSteps(child, steps[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchStep(el Element, st step) bool {
+	if st.name != "*" && st.name != el.XMLName.Local {
+		return false
+	}
+	for _, p := range st.preds {
+		switch {
+		case p.text:
+			if el.Text != p.value {
+				return false
+			}
+		case p.attr == "xmlns":
+			if (p.any && el.XMLName.Space == "") || (!p.any && el.XMLName.Space != p.value) {
+				return false
+			}
+		default:
+			var found bool
+			for _, a := range el.Attr {
+				if a.Name.Local == p.attr && a.Name.Space != "xmlns" && (p.any || a.Value == p.value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
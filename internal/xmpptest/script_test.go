@@ -0,0 +1,166 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpptest_test
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/stanza"
+)
+
+var _ xmpp.Handler = (*xmpptest.Script)(nil)
+
+// recordTB records failures instead of failing the test.
+type recordTB struct {
+	testing.TB
+
+	mu       sync.Mutex
+	failures []string
+	cleanup  []func()
+}
+
+func (r *recordTB) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recordTB) Cleanup(f func()) {
+	r.cleanup = append(r.cleanup, f)
+}
+
+func (r *recordTB) finish() []string {
+	for _, f := range r.cleanup {
+		f()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures
+}
+
+func ping(id string) xml.TokenReader {
+	return stanza.IQ{ID: id, Type: stanza.GetIQ}.Wrap(xmlstream.Wrap(
+		nil,
+		xml.StartElement{Name: xml.Name{Space: "urn:xmpp:ping", Local: "ping"}},
+	))
+}
+
+func TestScript(t *testing.T) {
+	script := xmpptest.NewScript(t)
+	script.Expect(`iq[@type='get'][@id]/ping[@xmlns='urn:xmpp:ping']`).ReplyResult()
+	script.Expect(`message/body[text()='Hello']`)
+	s := script.ClientServer()
+
+	resp, err := s.Client.SendIQ(context.Background(), ping("123"))
+	if err != nil {
+		t.Fatalf("error sending ping: %v", err)
+	}
+	iq := stanza.IQ{}
+	err = xml.NewTokenDecoder(resp).Decode(&iq)
+	if err != nil {
+		t.Fatalf("error unmarshaling response: %v", err)
+	}
+	if iq.Type != stanza.ResultIQ || iq.ID != "123" {
+		t.Errorf("wrong response: %+v", iq)
+	}
+	err = resp.Close()
+	if err != nil {
+		t.Fatalf("error closing response: %v", err)
+	}
+
+	err = s.Client.Send(context.Background(), stanza.Message{Type: stanza.ChatMessage}.Wrap(xmlstream.Wrap(
+		xmlstream.Token(xml.CharData("Hello")),
+		xml.StartElement{Name: xml.Name{Local: "body"}},
+	)))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	err = script.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("error waiting for script: %v", err)
+	}
+}
+
+var scriptFailureTestCases = []struct {
+	patterns []string
+	send     []string
+	failures []string
+}{
+	0: {
+		patterns: []string{`presence`},
+		send:     []string{`<message/>`},
+		failures: []string{
+			`element did not match expected pattern "presence"`,
+			`expected element matching "presence" was never received`,
+		},
+	},
+	1: {
+		patterns: []string{`message[@type='chat']`},
+		send:     []string{`<message type="chat"/>`, `<message type="chat"/>`},
+		failures: []string{`unexpected element received after all expectations were met`},
+	},
+	2: {
+		patterns: []string{`message/*[@xmlns]`},
+		send:     []string{`<message><body>hi</body></message>`},
+		failures: []string{
+			`element did not match expected pattern "message/*[@xmlns]"`,
+			`expected element matching "message/*[@xmlns]" was never received`,
+		},
+	},
+	3: {
+		patterns: []string{`message/active[@xmlns='http://jabber.org/protocol/chatstates']`, `message/x[@xmlns='urn:example']/y[text()="z"]`},
+		send: []string{
+			`<message><active xmlns="http://jabber.org/protocol/chatstates"/></message>`,
+			`<message><x xmlns="urn:example"><y/><y>z</y></x></message>`,
+		},
+	},
+}
+
+func TestScriptFailures(t *testing.T) {
+	for i, tc := range scriptFailureTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tb := &recordTB{TB: t}
+			script := xmpptest.NewScript(tb)
+			for _, p := range tc.patterns {
+				script.Expect(p)
+			}
+			for _, s := range tc.send {
+				d := xml.NewDecoder(strings.NewReader(s))
+				tok, err := d.Token()
+				if err != nil {
+					t.Fatalf("error popping start token: %v", err)
+				}
+				start := tok.(xml.StartElement)
+				err = script.HandleXMPP(struct {
+					xml.TokenReader
+					xmlstream.Encoder
+				}{
+					TokenReader: d,
+				}, &start)
+				if err != nil {
+					t.Fatalf("error handling element: %v", err)
+				}
+			}
+			failures := tb.finish()
+			if len(failures) != len(tc.failures) {
+				t.Fatalf("wrong number of failures: want=%d, got=%d: %q", len(tc.failures), len(failures), failures)
+			}
+			for i, f := range tc.failures {
+				if !strings.HasPrefix(failures[i], f) {
+					t.Errorf("wrong failure: want prefix %q, got %q", f, failures[i])
+				}
+			}
+		})
+	}
+}
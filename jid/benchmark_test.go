@@ -146,3 +146,16 @@ func BenchmarkUnescapeBytes(b *testing.B) {
 		_ = Unescape.Bytes(src)
 	}
 }
+
+func BenchmarkCacheParse(b *testing.B) {
+	c := NewCache(0)
+	for i := 0; i < b.N; i++ {
+		_, _ = c.Parse("user@example.com/resource")
+	}
+}
+
+func BenchmarkParseLevelSyntax(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseLevel("user@example.com/resource", Syntax)
+	}
+}
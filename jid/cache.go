@@ -0,0 +1,100 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jid
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheSize is the number of JIDs held by a Cache if no other size is
+// provided.
+const DefaultCacheSize = 1024
+
+// Cache remembers the result of parsing recently used JIDs so that addresses
+// that are seen repeatedly, such as the "from" attribute of every stanza from
+// a contact, do not have to be normalized each time.
+// Once the cache is full the least recently used JID is evicted.
+//
+// Only JIDs that parse successfully are cached.
+// Because JIDs are never modified after they are created, the same JID may be
+// returned to many callers.
+//
+// A Cache is safe for concurrent use by multiple goroutines.
+type Cache struct {
+	size  int
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
+	j   JID
+}
+
+// NewCache returns a cache that holds up to size JIDs.
+// If size is less than one, DefaultCacheSize is used.
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = DefaultCacheSize
+	}
+	return &Cache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Parse is like the Parse function except that if s has been parsed recently
+// the previous result is returned.
+func (c *Cache) Parse(s string) (JID, error) {
+	c.mu.Lock()
+	if e, ok := c.items[s]; ok {
+		c.ll.MoveToFront(e)
+		j := e.Value.(*cacheEntry).j
+		c.mu.Unlock()
+		return j, nil
+	}
+	c.mu.Unlock()
+
+	// Parse without holding the lock since normalization is the expensive part.
+	// If two goroutines parse the same string at once, the result is the same
+	// and the second one to finish is used.
+	j, err := Parse(s)
+	if err != nil {
+		return j, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[s]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*cacheEntry).j = j
+		return j, nil
+	}
+	c.items[s] = c.ll.PushFront(&cacheEntry{key: s, j: j})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+	return j, nil
+}
+
+// Len returns the number of JIDs in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Purge removes all JIDs from the cache.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jid_test
+
+import (
+	"strconv"
+	"testing"
+
+	"mellium.im/xmpp/jid"
+)
+
+func TestCache(t *testing.T) {
+	c := jid.NewCache(2)
+
+	j, err := c.Parse("Juliet@Example.com/balcony")
+	if err != nil {
+		t.Fatalf("error parsing JID: %v", err)
+	}
+	if want := jid.MustParse("juliet@example.com/balcony"); !j.Equal(want) {
+		t.Errorf("wrong JID: want=%v, got=%v", want, j)
+	}
+	if _, err = c.Parse("@example.com"); err == nil {
+		t.Errorf("expected error parsing invalid JID")
+	}
+	if l := c.Len(); l != 1 {
+		t.Errorf("invalid JIDs should not be cached, got length %d", l)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = c.Parse("user" + strconv.Itoa(i) + "@example.com")
+		if err != nil {
+			t.Fatalf("error parsing JID %d: %v", i, err)
+		}
+	}
+	if l := c.Len(); l != 2 {
+		t.Errorf("cache grew beyond its size, got length %d", l)
+	}
+
+	c.Purge()
+	if l := c.Len(); l != 0 {
+		t.Errorf("expected empty cache after purge, got length %d", l)
+	}
+}
@@ -6,8 +6,10 @@ package jid
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
@@ -306,6 +308,68 @@ func (j *JID) UnmarshalXMLAttr(attr xml.Attr) error {
 	return err
 }
 
+// MarshalText satisfies the encoding.TextMarshaler interface and marshals the
+// JID as its string representation.
+// This lets JIDs be used in JSON, as map keys in encoded formats, and anywhere
+// else text is expected.
+func (j JID) MarshalText() ([]byte, error) {
+	return []byte(j.String()), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface and unmarshals
+// text into a valid JID (or returns an error).
+// Empty text results in the zero JID.
+func (j *JID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*j = JID{}
+		return nil
+	}
+	jid, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*j = jid
+	return nil
+}
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface.
+// The binary form of a JID is the same as its text form.
+func (j JID) MarshalBinary() ([]byte, error) {
+	return j.MarshalText()
+}
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.
+// The binary form of a JID is the same as its text form.
+func (j *JID) UnmarshalBinary(data []byte) error {
+	return j.UnmarshalText(data)
+}
+
+// Value satisfies the driver.Valuer interface and stores the JID in a database
+// as its string representation.
+// The zero JID is stored as NULL.
+func (j JID) Value() (driver.Value, error) {
+	if len(j.data) == 0 {
+		return nil, nil
+	}
+	return j.String(), nil
+}
+
+// Scan satisfies the sql.Scanner interface and parses a JID stored in a
+// database as a string or byte slice.
+// NULL results in the zero JID.
+func (j *JID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = JID{}
+		return nil
+	case string:
+		return j.UnmarshalText([]byte(v))
+	case []byte:
+		return j.UnmarshalText(v)
+	}
+	return fmt.Errorf("jid: cannot scan %T into JID", src)
+}
+
 // SplitString splits out the localpart, domainpart, and resourcepart from a
 // string representation of a JID. The parts are not guaranteed to be valid, and
 // each part must be 1023 bytes or less.
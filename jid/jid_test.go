@@ -6,6 +6,10 @@ package jid_test
 
 import (
 	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"net"
@@ -17,12 +21,18 @@ import (
 
 // Compile time checks to make sure that JID and *jid.JID match several interfaces.
 var (
-	_ fmt.Stringer        = jid.JID{}
-	_ xml.MarshalerAttr   = jid.JID{}
-	_ xml.UnmarshalerAttr = (*jid.JID)(nil)
-	_ xml.Marshaler       = jid.JID{}
-	_ xml.Unmarshaler     = (*jid.JID)(nil)
-	_ net.Addr            = jid.JID{}
+	_ fmt.Stringer               = jid.JID{}
+	_ xml.MarshalerAttr          = jid.JID{}
+	_ xml.UnmarshalerAttr        = (*jid.JID)(nil)
+	_ xml.Marshaler              = jid.JID{}
+	_ xml.Unmarshaler            = (*jid.JID)(nil)
+	_ net.Addr                   = jid.JID{}
+	_ encoding.TextMarshaler     = jid.JID{}
+	_ encoding.TextUnmarshaler   = (*jid.JID)(nil)
+	_ encoding.BinaryMarshaler   = jid.JID{}
+	_ encoding.BinaryUnmarshaler = (*jid.JID)(nil)
+	_ driver.Valuer              = jid.JID{}
+	_ sql.Scanner                = (*jid.JID)(nil)
 )
 
 func TestValidJIDs(t *testing.T) {
@@ -133,6 +143,83 @@ func TestMarshalAttrEmpty(t *testing.T) {
 	}
 }
 
+func TestMarshalText(t *testing.T) {
+	type config struct {
+		Admin jid.JID
+		Owner jid.JID
+	}
+	in := config{
+		Admin: jid.MustParse("juliet@example.com/balcony"),
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("error marshaling JSON: %v", err)
+	}
+	const want = `{"Admin":"juliet@example.com/balcony","Owner":""}`
+	if string(b) != want {
+		t.Errorf("wrong JSON: want=%s, got=%s", want, b)
+	}
+	var out config
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		t.Fatalf("error unmarshaling JSON: %v", err)
+	}
+	if !out.Admin.Equal(in.Admin) || !out.Owner.Equal(jid.JID{}) {
+		t.Errorf("wrong value after round trip: %+v", out)
+	}
+
+	var j jid.JID
+	if err = j.UnmarshalText([]byte("@example.com")); err == nil {
+		t.Errorf("expected error unmarshaling invalid JID")
+	}
+	if err = j.UnmarshalBinary(nil); err != nil || !j.Equal(jid.JID{}) {
+		t.Errorf("expected empty data to result in the zero JID, got %v (%v)", j, err)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	for i, tc := range [...]struct {
+		src interface{}
+		out jid.JID
+		err bool
+	}{
+		0: {src: nil},
+		1: {src: "romeo@example.net", out: jid.MustParse("romeo@example.net")},
+		2: {src: []byte("example.net/res"), out: jid.MustParse("example.net/res")},
+		3: {src: 123, err: true},
+		4: {src: "@example.net", err: true},
+	} {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var j jid.JID
+			err := j.Scan(tc.src)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected error scanning %v", tc.src)
+			case !tc.err && err != nil:
+				t.Fatalf("unexpected error scanning %v: %v", tc.src, err)
+			case tc.err:
+				return
+			}
+			if !j.Equal(tc.out) {
+				t.Errorf("wrong JID: want=%v, got=%v", tc.out, j)
+			}
+			v, err := j.Value()
+			if err != nil {
+				t.Fatalf("unexpected error getting value: %v", err)
+			}
+			if tc.src == nil {
+				if v != nil {
+					t.Errorf("expected zero JID to be stored as NULL, got %v", v)
+				}
+				return
+			}
+			if v != tc.out.String() {
+				t.Errorf("wrong value: want=%v, got=%v", tc.out.String(), v)
+			}
+		})
+	}
+}
+
 func TestMustParsePanics(t *testing.T) {
 	for i, tc := range [...]struct {
 		jid         string
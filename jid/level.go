@@ -0,0 +1,95 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jid
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+var errNotCanonical = errors.New("the JID is not in its canonical form")
+
+// Level is the amount of validation performed by ParseLevel.
+//
+// Full enforcement normalizes each part of the JID so that two addresses that
+// refer to the same entity always compare as equal.
+// Skipping it is considerably faster, but if the input is not already in
+// canonical form the resulting JID may not be equal to another JID for the same
+// entity (for example, one that differs only in case).
+// Code that makes security decisions by comparing JIDs, such as checking a
+// block list or an access control list, may then be bypassed.
+// Lower levels should only be used for data from a source that has already
+// enforced the rules, such as addresses stamped on stanzas by the users own
+// server, and JIDs created this way can be checked later with Validate.
+type Level uint8
+
+// A list of validation levels.
+const (
+	// Enforce performs full validation and normalization and is the same as
+	// Parse.
+	Enforce Level = iota
+
+	// Syntax checks that the JID is made up of the correct parts, that each part
+	// is valid UTF-8 within the allowed length, and that the localpart does not
+	// contain forbidden characters, but does not normalize the parts.
+	Syntax
+
+	// Trusted performs no validation at all and is the same as ParseUnsafe.
+	// Using Trusted with untrusted input is a security risk.
+	Trusted
+)
+
+// ParseLevel constructs a JID from the given string representation performing
+// the amount of validation specified by l.
+// For more information, including the security implications of skipping
+// validation, see the Level type.
+func ParseLevel(s string, l Level) (JID, error) {
+	switch l {
+	case Syntax:
+		return parseSyntax(s)
+	case Trusted:
+		j, err := ParseUnsafe(s)
+		return j.JID, err
+	}
+	return Parse(s)
+}
+
+func parseSyntax(s string) (JID, error) {
+	localpart, domainpart, resourcepart, err := SplitString(s)
+	if err != nil {
+		return JID{}, err
+	}
+	if !utf8.ValidString(localpart) || !utf8.ValidString(domainpart) || !utf8.ValidString(resourcepart) {
+		return JID{}, errInvalidUTF8
+	}
+	if l := len(domainpart); l < 1 || l > 1023 {
+		return JID{}, errInvalidDomainLen
+	}
+	j := NewUnsafe(localpart, domainpart, resourcepart).JID
+	err = localChecks(j.data[:j.locallen])
+	if err != nil {
+		return JID{}, err
+	}
+	err = resourceChecks(j.data[j.locallen+j.domainlen:])
+	if err != nil {
+		return JID{}, err
+	}
+	return j, nil
+}
+
+// Validate reports whether j is a valid JID in canonical form, that is, whether
+// it is identical to the result of parsing it with full enforcement.
+// It is meant for checking JIDs that were created with ParseLevel, NewUnsafe,
+// or ParseUnsafe before using them for anything security sensitive.
+func (j JID) Validate() error {
+	canonical, err := New(j.Localpart(), j.Domainpart(), j.Resourcepart())
+	if err != nil {
+		return err
+	}
+	if !canonical.Equal(j) {
+		return errNotCanonical
+	}
+	return nil
+}
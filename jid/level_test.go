@@ -0,0 +1,56 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jid_test
+
+import (
+	"strconv"
+	"testing"
+
+	"mellium.im/xmpp/jid"
+)
+
+var levelTests = [...]struct {
+	in       string
+	level    jid.Level
+	err      bool
+	validErr bool
+}{
+	0:  {in: "juliet@example.com/balcony", level: jid.Enforce},
+	1:  {in: "juliet@example.com/balcony", level: jid.Syntax},
+	2:  {in: "juliet@example.com/balcony", level: jid.Trusted},
+	3:  {in: "Juliet@Example.com", level: jid.Enforce},
+	4:  {in: "Juliet@Example.com", level: jid.Syntax, validErr: true},
+	5:  {in: "Juliet@Example.com", level: jid.Trusted, validErr: true},
+	6:  {in: "@example.com", level: jid.Enforce, err: true},
+	7:  {in: "@example.com", level: jid.Syntax, err: true},
+	8:  {in: "jul<iet@example.com", level: jid.Trusted, validErr: true},
+	9:  {in: "example.com/", level: jid.Syntax, err: true},
+	10: {in: "jul<iet@example.com", level: jid.Syntax, err: true},
+	11: {in: "juliet@", level: jid.Syntax, err: true},
+	12: {in: "juliet@example.com/\xff", level: jid.Syntax, err: true},
+}
+
+func TestParseLevel(t *testing.T) {
+	for i, tc := range levelTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			j, err := jid.ParseLevel(tc.in, tc.level)
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected error parsing %q", tc.in)
+			case !tc.err && err != nil:
+				t.Fatalf("unexpected error parsing %q: %v", tc.in, err)
+			case tc.err:
+				return
+			}
+			err = j.Validate()
+			switch {
+			case tc.validErr && err == nil:
+				t.Errorf("expected %q to fail validation", tc.in)
+			case !tc.validErr && err != nil:
+				t.Errorf("unexpected validation error for %q: %v", tc.in, err)
+			}
+		})
+	}
+}
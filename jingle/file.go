@@ -0,0 +1,71 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/crypto"
+)
+
+// NSFile is the namespace of the file transfer application format.
+const NSFile = "urn:xmpp:jingle:apps:file-transfer:5"
+
+// File is metadata about a file being offered for transfer.
+// Any zero fields are omitted when offering the file.
+type File struct {
+	MediaType string              `xml:"media-type"`
+	Name      string              `xml:"name"`
+	Desc      string              `xml:"desc"`
+	Date      time.Time           `xml:"date"`
+	Size      uint64              `xml:"size"`
+	Hash      []crypto.HashOutput `xml:"urn:xmpp:hashes:2 hash"`
+}
+
+// TokenReader implements xmlstream.Marshaler.
+func (f File) TokenReader() xml.TokenReader {
+	var inner []xml.TokenReader
+	text := func(local, v string) {
+		if v == "" {
+			return
+		}
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(v)),
+			xml.StartElement{Name: xml.Name{Local: local}},
+		))
+	}
+	text("media-type", f.MediaType)
+	text("name", f.Name)
+	text("desc", f.Desc)
+	if !f.Date.IsZero() {
+		text("date", f.Date.UTC().Format(time.RFC3339))
+	}
+	if f.Size != 0 {
+		text("size", strconv.FormatUint(f.Size, 10))
+	}
+	for _, h := range f.Hash {
+		inner = append(inner, h.TokenReader())
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{Name: xml.Name{Local: "file"}},
+	)
+}
+
+// Description is the file transfer application format used in a Content.
+type Description struct {
+	File File `xml:"file"`
+}
+
+// TokenReader implements xmlstream.Marshaler.
+func (d Description) TokenReader() xml.TokenReader {
+	return xmlstream.Wrap(
+		d.File.TokenReader(),
+		xml.StartElement{Name: xml.Name{Space: NSFile, Local: "description"}},
+	)
+}
@@ -0,0 +1,284 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle is an option that registers a handler for Jingle requests.
+func Handle(h *Handler) mux.Option {
+	return mux.IQ(stanza.SetIQ, xml.Name{Space: NS, Local: "jingle"}, h)
+}
+
+// acceptQueueLen is the number of offered sessions that may be waiting for a
+// call to Accept before further offers are rejected.
+const acceptQueueLen = 16
+
+// sessionKey identifies a session.
+// Session IDs are only unique for a given peer, and requests for a session
+// that come from any other entity must not be able to affect it.
+type sessionKey struct {
+	peer string
+	sid  string
+}
+
+func keyOf(peer jid.JID, sid string) sessionKey {
+	return sessionKey{peer: peer.String(), sid: sid}
+}
+
+// Handler is an xmpp.Handler that tracks Jingle sessions and dispatches
+// requests from the remote entity to them.
+type Handler struct {
+	mu       sync.Mutex
+	sessions map[sessionKey]*Session
+	l        map[string]*Listener
+	lM       sync.Mutex
+}
+
+// Listen creates a listener that accepts incoming Jingle sessions.
+// Offers are queued until they are accepted, and if too many offers are
+// waiting for a call to Accept, new offers are rejected.
+//
+// If a listener has already been created for the given session it is returned
+// unaltered.
+func (h *Handler) Listen(s *xmpp.Session) *Listener {
+	addrStr := s.LocalAddr().String()
+	h.lM.Lock()
+	defer h.lM.Unlock()
+	if h.l == nil {
+		h.l = make(map[string]*Listener)
+	}
+	l, ok := h.l[addrStr]
+	if ok {
+		return l
+	}
+	l = &Listener{
+		s:    s,
+		h:    h,
+		c:    make(chan *Session, acceptQueueLen),
+		done: make(chan struct{}),
+	}
+	h.l[addrStr] = l
+	return l
+}
+
+// Initiate offers a new session to the provided JID.
+// The remote entity acknowledges the offer before Initiate returns, but may
+// not have accepted it yet; to wait for the session to be accepted use the
+// sessions Wait method.
+func (h *Handler) Initiate(ctx context.Context, s *xmpp.Session, to jid.JID, contents ...Content) (*Session, error) {
+	sess := newSession(h, s, Jingle{
+		SID:       attr.RandomID(),
+		Initiator: s.LocalAddr(),
+		Contents:  contents,
+	}, to, true)
+	h.addSession(sess)
+	err := sess.send(ctx, Jingle{
+		Action:    SessionInitiate,
+		SID:       sess.SID,
+		Initiator: sess.Initiator,
+		Contents:  contents,
+	})
+	if err != nil {
+		h.rmSession(sess)
+		return nil, err
+	}
+	return sess, nil
+}
+
+// HandleIQ implements mux.IQHandler.
+func (h *Handler) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	var j Jingle
+	d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), t))
+	err := d.Decode(&j)
+	if err != nil {
+		return err
+	}
+
+	if j.Action == SessionInitiate {
+		return h.handleInitiate(iq, j, t)
+	}
+
+	// Only the peer that the session was negotiated with may make requests for
+	// it, so look up the session using the address the request came from.
+	h.mu.Lock()
+	sess, ok := h.sessions[keyOf(iq.From, j.SID)]
+	h.mu.Unlock()
+	if !ok {
+		_, err := xmlstream.Copy(t, iq.Error(stanza.Error{
+			Type:      stanza.Cancel,
+			Condition: stanza.ItemNotFound,
+		}))
+		return err
+	}
+
+	switch j.Action {
+	case SessionAccept:
+		if !sess.initiator {
+			break
+		}
+		if !j.Responder.Equal(jid.JID{}) {
+			sess.Responder = j.Responder
+		}
+		if len(j.Contents) > 0 {
+			sess.Contents = j.Contents
+		}
+		sess.accept()
+		_, err = xmlstream.Copy(t, iq.Result(nil))
+		return err
+	case SessionTerminate:
+		var reason Reason
+		if j.Reason != nil {
+			reason = *j.Reason
+		}
+		h.rmSession(sess)
+		sess.terminate(reason)
+		_, err = xmlstream.Copy(t, iq.Result(nil))
+		return err
+	case SessionInfo:
+		// Empty session info requests are used as pings, and we don't support any
+		// informational payloads so there is nothing else to do.
+		_, err = xmlstream.Copy(t, iq.Result(nil))
+		return err
+	}
+
+	_, err = xmlstream.Copy(t, iq.Error(stanza.Error{
+		Type:      stanza.Cancel,
+		Condition: stanza.FeatureNotImplemented,
+	}))
+	return err
+}
+
+func (h *Handler) handleInitiate(iq stanza.IQ, j Jingle, e xmlstream.Encoder) error {
+	to := iq.To.String()
+	h.lM.Lock()
+	l, ok := h.l[to]
+	if !ok {
+		l, ok = h.l[""]
+	}
+	h.lM.Unlock()
+	if !ok {
+		_, err := xmlstream.Copy(e, iq.Error(stanza.Error{
+			Type:      stanza.Cancel,
+			Condition: stanza.ServiceUnavailable,
+		}))
+		return err
+	}
+	h.mu.Lock()
+	_, exists := h.sessions[keyOf(iq.From, j.SID)]
+	h.mu.Unlock()
+	if exists {
+		_, err := xmlstream.Copy(e, iq.Error(stanza.Error{
+			Type:      stanza.Cancel,
+			Condition: stanza.Conflict,
+		}))
+		return err
+	}
+
+	// The initiator attribute is chosen by the remote entity, so ignore it and
+	// use the address that the offer actually came from.
+	j.Initiator = iq.From
+	j.Responder = l.s.LocalAddr()
+	sess := newSession(h, l.s, j, iq.From, false)
+	h.addSession(sess)
+
+	// Never block the goroutine that is handling stanzas waiting for the user to
+	// call Accept.
+	var stanzaErr stanza.Error
+	select {
+	case <-l.done:
+		stanzaErr = stanza.Error{Type: stanza.Cancel, Condition: stanza.ServiceUnavailable}
+	default:
+		select {
+		case l.c <- sess:
+		default:
+			stanzaErr = stanza.Error{Type: stanza.Wait, Condition: stanza.ResourceConstraint}
+		}
+	}
+	if stanzaErr.Condition != "" {
+		h.rmSession(sess)
+		_, err := xmlstream.Copy(e, iq.Error(stanzaErr))
+		return err
+	}
+	_, err := xmlstream.Copy(e, iq.Result(nil))
+	return err
+}
+
+func (h *Handler) addSession(sess *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.sessions == nil {
+		h.sessions = make(map[sessionKey]*Session)
+	}
+	h.sessions[keyOf(sess.peer, sess.SID)] = sess
+}
+
+func (h *Handler) rmSession(sess *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := keyOf(sess.peer, sess.SID)
+	if h.sessions[key] == sess {
+		delete(h.sessions, key)
+	}
+}
+
+// Listener accepts incoming Jingle sessions.
+type Listener struct {
+	s         *xmpp.Session
+	h         *Handler
+	c         chan *Session
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for the next incoming session offer and returns it.
+// The session must then be accepted or terminated.
+// If the listener is closed pending Accept calls unblock and return an error.
+func (l *Listener) Accept(ctx context.Context) (*Session, error) {
+	select {
+	case <-l.done:
+		return nil, errors.New("jingle: accept on closed listener")
+	default:
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, errors.New("jingle: accept on closed listener")
+	case sess := <-l.c:
+		return sess, nil
+	}
+}
+
+// Close stops listening and causes any pending Accept calls to unblock and
+// return an error.
+// Already accepted sessions are not terminated.
+func (l *Listener) Close() error {
+	l.h.lM.Lock()
+	defer l.h.lM.Unlock()
+	addrStr := l.s.LocalAddr().String()
+	if l.h.l[addrStr] == l {
+		delete(l.h.l, addrStr)
+	}
+	// The channel of offered sessions is never closed because offers may still
+	// be sent by a handler that looked up the listener before it was closed.
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"mellium.im/xmlstream"
+)
+
+// NSIBB is the namespace of the in-band bytestream transport method.
+const NSIBB = "urn:xmpp:jingle:transports:ibb:1"
+
+// IBBTransport is a transport that uses an in-band bytestream to carry the
+// session data.
+type IBBTransport struct {
+	BlockSize uint16 `xml:"block-size,attr"`
+	SID       string `xml:"sid,attr"`
+}
+
+// TokenReader implements xmlstream.Marshaler.
+func (t IBBTransport) TokenReader() xml.TokenReader {
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NSIBB, Local: "transport"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "block-size"}, Value: strconv.FormatUint(uint64(t.BlockSize), 10)},
+			{Name: xml.Name{Local: "sid"}, Value: t.SID},
+		},
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h *Handler" -vars "Feature:NS,FeatureFile:NSFile,FeatureIBB:NSIBB"
+
+// Package jingle implements peer-to-peer session negotiation using Jingle.
+//
+// Jingle (XEP-0166) is a signaling protocol used to set up sessions between two
+// entities out-of-band of the XMPP stream.
+// Each session is described by one or more contents, each of which pairs an
+// application format (the description) with a transport method that carries
+// the data.
+// This package currently supports the file transfer application format
+// (XEP-0234) using in-band bytestreams as the transport (XEP-0261), which
+// allows files to be transferred when HTTP upload is not available.
+package jingle // import "mellium.im/xmpp/jingle"
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+)
+
+// Namespaces used by this package, provided as a convenience.
+const (
+	NS      = "urn:xmpp:jingle:1"
+	NSError = "urn:xmpp:jingle:errors:1"
+)
+
+// Action is the type of a Jingle request.
+type Action string
+
+// A list of actions supported by this package.
+const (
+	SessionInitiate  Action = "session-initiate"
+	SessionAccept    Action = "session-accept"
+	SessionInfo      Action = "session-info"
+	SessionTerminate Action = "session-terminate"
+)
+
+// Creator indicates which party originally generated a content.
+type Creator string
+
+// A list of possible creators.
+const (
+	CreatorInitiator Creator = "initiator"
+	CreatorResponder Creator = "responder"
+)
+
+// Content pairs an application format with the transport used to exchange
+// data for that application.
+// Description and Transport are nil if they use an application format or
+// transport method not supported by this package.
+type Content struct {
+	Creator     Creator       `xml:"creator,attr"`
+	Name        string        `xml:"name,attr"`
+	Senders     string        `xml:"senders,attr,omitempty"`
+	Description *Description  `xml:"urn:xmpp:jingle:apps:file-transfer:5 description"`
+	Transport   *IBBTransport `xml:"urn:xmpp:jingle:transports:ibb:1 transport"`
+}
+
+// TokenReader implements xmlstream.Marshaler.
+func (c Content) TokenReader() xml.TokenReader {
+	attrs := []xml.Attr{
+		{Name: xml.Name{Local: "creator"}, Value: string(c.Creator)},
+		{Name: xml.Name{Local: "name"}, Value: c.Name},
+	}
+	if c.Senders != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "senders"}, Value: c.Senders})
+	}
+	var inner []xml.TokenReader
+	if c.Description != nil {
+		inner = append(inner, c.Description.TokenReader())
+	}
+	if c.Transport != nil {
+		inner = append(inner, c.Transport.TokenReader())
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{Name: xml.Name{Local: "content"}, Attr: attrs},
+	)
+}
+
+// Jingle is the payload of a Jingle request.
+type Jingle struct {
+	XMLName   xml.Name  `xml:"urn:xmpp:jingle:1 jingle"`
+	Action    Action    `xml:"action,attr"`
+	SID       string    `xml:"sid,attr"`
+	Initiator jid.JID   `xml:"initiator,attr"`
+	Responder jid.JID   `xml:"responder,attr"`
+	Contents  []Content `xml:"content"`
+	Reason    *Reason   `xml:"reason"`
+}
+
+// TokenReader implements xmlstream.Marshaler.
+func (j Jingle) TokenReader() xml.TokenReader {
+	attrs := []xml.Attr{
+		{Name: xml.Name{Local: "action"}, Value: string(j.Action)},
+		{Name: xml.Name{Local: "sid"}, Value: j.SID},
+	}
+	if !j.Initiator.Equal(jid.JID{}) {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "initiator"}, Value: j.Initiator.String()})
+	}
+	if !j.Responder.Equal(jid.JID{}) {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "responder"}, Value: j.Responder.String()})
+	}
+	inner := make([]xml.TokenReader, 0, len(j.Contents)+1)
+	for _, c := range j.Contents {
+		inner = append(inner, c.TokenReader())
+	}
+	if j.Reason != nil {
+		inner = append(inner, j.Reason.TokenReader())
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{Name: xml.Name{Space: NS, Local: "jingle"}, Attr: attrs},
+	)
+}
+
+// WriteXML implements xmlstream.WriterTo.
+func (j Jingle) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, j.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (j Jingle) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := j.WriteXML(e)
+	return err
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle_test
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/crypto"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/jingle"
+)
+
+var encodingTestCases = []xmpptest.EncodingTestCase{
+	0: {
+		Value: &jingle.Jingle{
+			XMLName:   xml.Name{Space: jingle.NS, Local: "jingle"},
+			Action:    jingle.SessionInitiate,
+			SID:       "851ba2",
+			Initiator: jid.MustParse("romeo@montague.example/dr4hcr0st3lup4c"),
+			Contents: []jingle.Content{{
+				Creator: jingle.CreatorInitiator,
+				Name:    "a-file-offer",
+				Senders: "initiator",
+				Description: &jingle.Description{File: jingle.File{
+					MediaType: "text/plain",
+					Name:      "test.txt",
+					Date:      time.Date(2015, 7, 26, 21, 46, 0, 0, time.UTC),
+					Size:      6144,
+					Hash: []crypto.HashOutput{{
+						Hash: crypto.SHA256,
+						Out:  []byte("sum"),
+					}},
+				}},
+				Transport: &jingle.IBBTransport{BlockSize: 4096, SID: "ch3d9s71"},
+			}},
+		},
+		XML: `<jingle xmlns="urn:xmpp:jingle:1" action="session-initiate" sid="851ba2" initiator="romeo@montague.example/dr4hcr0st3lup4c"><content creator="initiator" name="a-file-offer" senders="initiator"><description xmlns="urn:xmpp:jingle:apps:file-transfer:5"><file><media-type>text/plain</media-type><name>test.txt</name><date>2015-07-26T21:46:00Z</date><size>6144</size><hash xmlns="urn:xmpp:hashes:2" algo="sha-256">c3Vt</hash></file></description><transport xmlns="urn:xmpp:jingle:transports:ibb:1" block-size="4096" sid="ch3d9s71"></transport></content></jingle>`,
+	},
+	1: {
+		Value: &jingle.Jingle{
+			XMLName: xml.Name{Space: jingle.NS, Local: "jingle"},
+			Action:  jingle.SessionTerminate,
+			SID:     "851ba2",
+			Reason: &jingle.Reason{
+				Condition: jingle.Decline,
+				Text:      "No thanks",
+			},
+		},
+		XML: `<jingle xmlns="urn:xmpp:jingle:1" action="session-terminate" sid="851ba2"><reason><decline></decline><text>No thanks</text></reason></jingle>`,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	xmpptest.RunEncodingTests(t, encodingTestCases)
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+)
+
+// Condition is the machine readable reason that a session was terminated.
+type Condition string
+
+// A list of reason conditions.
+const (
+	AlternativeSession      Condition = "alternative-session"
+	Busy                    Condition = "busy"
+	Cancel                  Condition = "cancel"
+	ConnectivityError       Condition = "connectivity-error"
+	Decline                 Condition = "decline"
+	Expired                 Condition = "expired"
+	FailedApplication       Condition = "failed-application"
+	FailedTransport         Condition = "failed-transport"
+	GeneralError            Condition = "general-error"
+	Gone                    Condition = "gone"
+	IncompatibleParameters  Condition = "incompatible-parameters"
+	MediaError              Condition = "media-error"
+	SecurityError           Condition = "security-error"
+	Success                 Condition = "success"
+	Timeout                 Condition = "timeout"
+	UnsupportedApplications Condition = "unsupported-applications"
+	UnsupportedTransports   Condition = "unsupported-transports"
+)
+
+// Reason explains why a session was terminated.
+// It can be returned as an error when a session ends unexpectedly.
+type Reason struct {
+	Condition Condition
+	Text      string
+}
+
+// Error satisfies the error interface.
+func (r Reason) Error() string {
+	if r.Text != "" {
+		return "jingle: session terminated: " + string(r.Condition) + ": " + r.Text
+	}
+	return "jingle: session terminated: " + string(r.Condition)
+}
+
+// TokenReader implements xmlstream.Marshaler.
+func (r Reason) TokenReader() xml.TokenReader {
+	inner := []xml.TokenReader{
+		xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Local: string(r.Condition)}}),
+	}
+	if r.Text != "" {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(r.Text)),
+			xml.StartElement{Name: xml.Name{Local: "text"}},
+		))
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{Name: xml.Name{Local: "reason"}},
+	)
+}
+
+// WriteXML implements xmlstream.WriterTo.
+func (r Reason) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, r.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (r Reason) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := r.WriteXML(e)
+	return err
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (r *Reason) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	in := struct {
+		Text      string `xml:"text"`
+		Condition []struct {
+			XMLName xml.Name
+		} `xml:",any"`
+	}{}
+	err := d.DecodeElement(&in, &start)
+	if err != nil {
+		return err
+	}
+	r.Text = in.Text
+	if len(in.Condition) > 0 {
+		r.Condition = Condition(in.Condition[0].XMLName.Local)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// ErrNotResponder is returned when attempting to accept a session that was
+// initiated locally.
+var ErrNotResponder = errors.New("jingle: only the responder can accept a session")
+
+// Session is a Jingle session between two entities.
+type Session struct {
+	SID       string
+	Initiator jid.JID
+	Responder jid.JID
+	Contents  []Content
+
+	h         *Handler
+	s         *xmpp.Session
+	peer      jid.JID
+	initiator bool
+
+	acceptOnce sync.Once
+	accepted   chan struct{}
+	doneOnce   sync.Once
+	done       chan struct{}
+	reason     Reason
+}
+
+func newSession(h *Handler, s *xmpp.Session, j Jingle, peer jid.JID, initiator bool) *Session {
+	return &Session{
+		SID:       j.SID,
+		Initiator: j.Initiator,
+		Responder: j.Responder,
+		Contents:  j.Contents,
+		h:         h,
+		s:         s,
+		peer:      peer,
+		initiator: initiator,
+		accepted:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Peer returns the address of the remote entity.
+func (sess *Session) Peer() jid.JID {
+	return sess.peer
+}
+
+// XMPPSession returns the XMPP session over which the Jingle session was
+// negotiated.
+func (sess *Session) XMPPSession() *xmpp.Session {
+	return sess.s
+}
+
+// Accept accepts a session that was offered by the remote entity.
+// If no contents are provided, the offered contents are accepted as is.
+func (sess *Session) Accept(ctx context.Context, contents ...Content) error {
+	if sess.initiator {
+		return ErrNotResponder
+	}
+	if len(contents) > 0 {
+		sess.Contents = contents
+	}
+	err := sess.send(ctx, Jingle{
+		Action:    SessionAccept,
+		SID:       sess.SID,
+		Initiator: sess.Initiator,
+		Responder: sess.Responder,
+		Contents:  sess.Contents,
+	})
+	if err != nil {
+		return err
+	}
+	sess.accept()
+	return nil
+}
+
+// Terminate ends the session, or declines it if it has not yet been accepted.
+func (sess *Session) Terminate(ctx context.Context, reason Reason) error {
+	sess.h.rmSession(sess)
+	sess.terminate(reason)
+	return sess.send(ctx, Jingle{
+		Action: SessionTerminate,
+		SID:    sess.SID,
+		Reason: &reason,
+	})
+}
+
+// Wait blocks until the session is accepted by the remote entity.
+// If the session is terminated before it is accepted the reason is returned
+// as an error.
+func (sess *Session) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-sess.accepted:
+		return nil
+	case <-sess.done:
+		return sess.reason
+	}
+}
+
+// Done returns a channel that is closed when the session is terminated by
+// either entity.
+func (sess *Session) Done() <-chan struct{} {
+	return sess.done
+}
+
+// Reason returns the reason the session was terminated.
+// It is only valid after the channel returned by Done is closed.
+func (sess *Session) Reason() Reason {
+	<-sess.done
+	return sess.reason
+}
+
+func (sess *Session) accept() {
+	sess.acceptOnce.Do(func() {
+		close(sess.accepted)
+	})
+}
+
+func (sess *Session) terminate(reason Reason) {
+	sess.doneOnce.Do(func() {
+		sess.reason = reason
+		close(sess.done)
+	})
+}
+
+func (sess *Session) send(ctx context.Context, j Jingle) error {
+	return sess.s.UnmarshalIQElement(ctx, j.TokenReader(), stanza.IQ{
+		To:   sess.peer,
+		Type: stanza.SetIQ,
+	}, nil)
+}
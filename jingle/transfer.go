@@ -0,0 +1,170 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/crypto"
+	"mellium.im/xmpp/ibb"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/l10n"
+	"mellium.im/xmpp/stanza"
+)
+
+// ErrNoFile is returned when receiving a file from a session that does not
+// offer one using a supported transport.
+var ErrNoFile = errors.New("jingle: session does not offer a supported file transfer")
+
+// File returns the file offered by the session (if any).
+// It only returns true if the file is offered using a transport that is
+// supported by this package.
+func (sess *Session) File() (File, bool) {
+	c, ok := fileContent(sess.Contents)
+	if !ok {
+		return File{}, false
+	}
+	return c.Description.File, true
+}
+
+func fileContent(contents []Content) (Content, bool) {
+	for _, c := range contents {
+		if c.Description != nil && c.Transport != nil {
+			return c, true
+		}
+	}
+	return Content{}, false
+}
+
+// SendFile offers a file to the remote entity and once the offer is accepted
+// sends the contents of r over an in-band bytestream.
+// SendFile blocks until the remote entity terminates the session after
+// receiving the file, and if it terminates the session for any reason other
+// than success (eg. because the offer was declined), the reason is returned as
+// an error.
+// The IBB handler must be registered with the sessions mux so that the
+// transfer can be acknowledged.
+func SendFile(ctx context.Context, h *Handler, ibbHandler *ibb.Handler, s *xmpp.Session, to jid.JID, f File, r io.Reader) error {
+	transport := &IBBTransport{
+		BlockSize: ibb.BlockSize,
+		SID:       attr.RandomID(),
+	}
+	sess, err := h.Initiate(ctx, s, to, Content{
+		Creator:     CreatorInitiator,
+		Name:        "file",
+		Senders:     string(CreatorInitiator),
+		Description: &Description{File: f},
+		Transport:   transport,
+	})
+	if err != nil {
+		return err
+	}
+	err = sess.Wait(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			/* #nosec */
+			sess.Terminate(context.Background(), Reason{Condition: Cancel})
+		}
+		return err
+	}
+
+	conn, err := ibbHandler.OpenIQ(ctx, stanza.IQ{To: to}, s, true, transport.BlockSize, transport.SID)
+	if err != nil {
+		/* #nosec */
+		sess.Terminate(ctx, Reason{Condition: FailedTransport})
+		return err
+	}
+	_, err = io.Copy(conn, r)
+	if err != nil {
+		/* #nosec */
+		conn.Close()
+		/* #nosec */
+		sess.Terminate(ctx, Reason{Condition: Cancel})
+		return err
+	}
+	err = conn.Close()
+	if err != nil {
+		/* #nosec */
+		sess.Terminate(ctx, Reason{Condition: FailedTransport})
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-sess.Done():
+	}
+	if reason := sess.Reason(); reason.Condition != Success {
+		return reason
+	}
+	return nil
+}
+
+// ReceiveFile accepts a file transfer offered by the remote entity and writes
+// the file to w.
+// Once the file has been received it is checked against any hashes in the
+// offer that are supported and the session is terminated.
+// If the hashes do not match the error wraps crypto.ErrMismatch.
+// If the session does not offer a file, it is terminated and ErrNoFile is
+// returned.
+// The IBB handler must be registered with the sessions mux so that the
+// transfer can be received.
+func ReceiveFile(ctx context.Context, sess *Session, ibbHandler *ibb.Handler, w io.Writer) error {
+	content, ok := fileContent(sess.Contents)
+	if !ok {
+		/* #nosec */
+		sess.Terminate(ctx, Reason{Condition: UnsupportedTransports})
+		return ErrNoFile
+	}
+
+	wait := ibbHandler.Listen(sess.s).Prepare(sess.peer, content.Transport.SID)
+	err := sess.Accept(ctx, content)
+	if err != nil {
+		return err
+	}
+	conn, err := wait(ctx)
+	if err != nil {
+		/* #nosec */
+		sess.Terminate(context.Background(), Reason{Condition: FailedTransport})
+		return err
+	}
+	/* #nosec */
+	defer conn.Close()
+
+	var hashes []crypto.HashOutput
+	var sums []hash.Hash
+	writers := []io.Writer{w}
+	for _, h := range content.Description.File.Hash {
+		if !h.Hash.Available() {
+			continue
+		}
+		sum := h.Hash.New()
+		hashes = append(hashes, h)
+		sums = append(sums, sum)
+		writers = append(writers, sum)
+	}
+	_, err = io.Copy(io.MultiWriter(writers...), conn)
+	if err != nil {
+		/* #nosec */
+		sess.Terminate(ctx, Reason{Condition: FailedTransport})
+		return err
+	}
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare(sums[i].Sum(nil), h.Out) != 1 {
+			text := l10n.Printer(sess.XMPPSession().In().Lang).Sprintf("The file hash does not match")
+			/* #nosec */
+			sess.Terminate(ctx, Reason{Condition: MediaError, Text: text})
+			return fmt.Errorf("jingle: %w: %s", crypto.ErrMismatch, h.Hash)
+		}
+	}
+	return sess.Terminate(ctx, Reason{Condition: Success})
+}
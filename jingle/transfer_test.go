@@ -0,0 +1,233 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package jingle_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/crypto"
+	"mellium.im/xmpp/ibb"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/jingle"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+const fileData = "Thus with a kiss I die."
+
+func newTransfer() (cs *xmpptest.ClientServer, client, server *jingle.Handler, clientIBB, serverIBB *ibb.Handler) {
+	client, server = &jingle.Handler{}, &jingle.Handler{}
+	clientIBB, serverIBB = &ibb.Handler{}, &ibb.Handler{}
+	cs = xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSServer, jingle.Handle(client), ibb.Handle(clientIBB))),
+		xmpptest.ServerHandler(mux.New(stanza.NSClient, jingle.Handle(server), ibb.Handle(serverIBB))),
+	)
+	return cs, client, server, clientIBB, serverIBB
+}
+
+func TestTransfer(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		sum  []byte
+		err  error
+	}{
+		{name: "ok", sum: func() []byte { s := sha256.Sum256([]byte(fileData)); return s[:] }()},
+		{name: "mismatch", sum: []byte("bad"), err: crypto.ErrMismatch},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			cs, client, server, clientIBB, serverIBB := newTransfer()
+
+			ln := server.Listen(cs.Server)
+			type result struct {
+				file jingle.File
+				data string
+				err  error
+			}
+			recv := make(chan result, 1)
+			go func() {
+				sess, err := ln.Accept(ctx)
+				if err != nil {
+					recv <- result{err: err}
+					return
+				}
+				f, _ := sess.File()
+				var buf bytes.Buffer
+				err = jingle.ReceiveFile(ctx, sess, serverIBB, &buf)
+				recv <- result{file: f, data: buf.String(), err: err}
+			}()
+
+			err := jingle.SendFile(ctx, client, clientIBB, cs.Client, cs.Server.LocalAddr(), jingle.File{
+				Name: "poison.txt",
+				Size: uint64(len(fileData)),
+				Hash: []crypto.HashOutput{{Hash: crypto.SHA256, Out: tc.sum}},
+			}, strings.NewReader(fileData))
+			got := <-recv
+			if !errors.Is(got.err, tc.err) {
+				t.Fatalf("wrong error receiving file: want=%v, got=%v", tc.err, got.err)
+			}
+			if got.file.Name != "poison.txt" || got.file.Size != uint64(len(fileData)) {
+				t.Errorf("wrong file offered: %+v", got.file)
+			}
+			if got.data != fileData {
+				t.Errorf("wrong data: want=%q, got=%q", fileData, got.data)
+			}
+			var reason jingle.Reason
+			switch {
+			case tc.err == nil && err != nil:
+				t.Errorf("unexpected error sending file: %v", err)
+			case tc.err != nil && (!errors.As(err, &reason) || reason.Condition != jingle.MediaError):
+				t.Errorf("expected media-error sending file, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cs, client, server, _, _ := newTransfer()
+
+	ln := server.Listen(cs.Server)
+	errs := make(chan error, 1)
+	go func() {
+		sess, err := ln.Accept(ctx)
+		if err != nil {
+			errs <- err
+			return
+		}
+		errs <- sess.Terminate(ctx, jingle.Reason{Condition: jingle.Decline})
+	}()
+
+	sess, err := client.Initiate(ctx, cs.Client, cs.Server.LocalAddr(), jingle.Content{
+		Creator: jingle.CreatorInitiator,
+		Name:    "file",
+	})
+	if err != nil {
+		t.Fatalf("error initiating session: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("error declining session: %v", err)
+	}
+	err = sess.Wait(ctx)
+	var reason jingle.Reason
+	if !errors.As(err, &reason) || reason.Condition != jingle.Decline {
+		t.Fatalf("wrong error waiting on session: %v", err)
+	}
+	if _, ok := sess.File(); ok {
+		t.Errorf("did not expect a file offer")
+	}
+}
+
+func TestNoListener(t *testing.T) {
+	cs, client, _, _, _ := newTransfer()
+	_, err := client.Initiate(context.Background(), cs.Client, cs.Server.LocalAddr())
+	if !errors.Is(err, stanza.Error{Condition: stanza.ServiceUnavailable}) {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
+
+func TestOtherPeer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cs, _, server, _, _ := newTransfer()
+
+	ln := server.Listen(cs.Server)
+	offered := make(chan *jingle.Session, 1)
+	go func() {
+		sess, err := ln.Accept(ctx)
+		if err != nil {
+			t.Errorf("error accepting session: %v", err)
+		}
+		offered <- sess
+	}()
+
+	// The initiator attribute is ignored in favor of the sender of the offer.
+	mallory := jid.MustParse("mallory@example.net/evil")
+	sid := "abc"
+	err := cs.Client.UnmarshalIQElement(ctx, jingle.Jingle{
+		Action:    jingle.SessionInitiate,
+		SID:       sid,
+		Initiator: mallory,
+	}.TokenReader(), stanza.IQ{
+		To:   cs.Server.LocalAddr(),
+		From: cs.Client.LocalAddr(),
+		Type: stanza.SetIQ,
+	}, nil)
+	if err != nil {
+		t.Fatalf("error initiating session: %v", err)
+	}
+	sess := <-offered
+	if sess == nil {
+		t.FailNow()
+	}
+	if !sess.Initiator.Equal(cs.Client.LocalAddr()) {
+		t.Errorf("wrong initiator: want=%v, got=%v", cs.Client.LocalAddr(), sess.Initiator)
+	}
+
+	// Requests for the session from any entity other than the peer are
+	// rejected.
+	err = cs.Client.UnmarshalIQElement(ctx, jingle.Jingle{
+		Action: jingle.SessionTerminate,
+		SID:    sid,
+	}.TokenReader(), stanza.IQ{
+		To:   cs.Server.LocalAddr(),
+		From: mallory,
+		Type: stanza.SetIQ,
+	}, nil)
+	if !errors.Is(err, stanza.Error{Condition: stanza.ItemNotFound}) {
+		t.Errorf("wrong error terminating session from another entity: %v", err)
+	}
+	select {
+	case <-sess.Done():
+		t.Fatalf("session was terminated by another entity")
+	default:
+	}
+}
+
+func TestListenerQueue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cs, client, server, _, _ := newTransfer()
+
+	// Offers are queued without blocking until the queue is full.
+	ln := server.Listen(cs.Server)
+	var err error
+	for i := 0; err == nil; i++ {
+		if i > 100 {
+			t.Fatalf("offers were never rejected")
+		}
+		_, err = client.Initiate(ctx, cs.Client, cs.Server.LocalAddr())
+	}
+	if !errors.Is(err, stanza.Error{Condition: stanza.ResourceConstraint}) {
+		t.Fatalf("wrong error when queue is full: %v", err)
+	}
+	sess, err := ln.Accept(ctx)
+	if err != nil || sess == nil {
+		t.Fatalf("error accepting queued session: %v", err)
+	}
+
+	// Closing the listener does not panic if offers are received afterwards.
+	err = ln.Close()
+	if err != nil {
+		t.Fatalf("error closing listener: %v", err)
+	}
+	_, err = ln.Accept(ctx)
+	if err == nil {
+		t.Errorf("expected error accepting on closed listener")
+	}
+	_, err = client.Initiate(ctx, cs.Client, cs.Server.LocalAddr())
+	if !errors.Is(err, stanza.Error{Condition: stanza.ServiceUnavailable}) {
+		t.Fatalf("wrong error after closing listener: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package l10n
+
+import (
+	"golang.org/x/text/language"
+)
+
+// translations are the translations of text generated by the library, keyed
+// by the English text.
+var translations = map[string]map[language.Tag]string{
+	// Command errors.
+	"The command does not exist": {
+		language.German:  "Der Befehl existiert nicht",
+		language.Spanish: "El comando no existe",
+		language.French:  "La commande n'existe pas",
+	},
+	"You are not allowed to execute this command": {
+		language.German:  "Sie dürfen diesen Befehl nicht ausführen",
+		language.Spanish: "No tiene permiso para ejecutar este comando",
+		language.French:  "Vous n'êtes pas autorisé à exécuter cette commande",
+	},
+	"The requested action is not valid": {
+		language.German:  "Die angeforderte Aktion ist ungültig",
+		language.Spanish: "La acción solicitada no es válida",
+		language.French:  "L'action demandée n'est pas valide",
+	},
+	"The command session does not exist or has expired": {
+		language.German:  "Die Befehlssitzung existiert nicht oder ist abgelaufen",
+		language.Spanish: "La sesión del comando no existe o ha caducado",
+		language.French:  "La session de commande n'existe pas ou a expiré",
+	},
+	"The requested action is not allowed at this stage of the command": {
+		language.German:  "Die angeforderte Aktion ist in diesem Schritt des Befehls nicht erlaubt",
+		language.Spanish: "La acción solicitada no está permitida en esta etapa del comando",
+		language.French:  "L'action demandée n'est pas autorisée à cette étape de la commande",
+	},
+	"The command could not be executed": {
+		language.German:  "Der Befehl konnte nicht ausgeführt werden",
+		language.Spanish: "No se pudo ejecutar el comando",
+		language.French:  "La commande n'a pas pu être exécutée",
+	},
+
+	// Presence statuses.
+	"Away": {
+		language.German:  "Abwesend",
+		language.Spanish: "Ausente",
+		language.French:  "Absent",
+	},
+	"Extended away": {
+		language.German:  "Länger abwesend",
+		language.Spanish: "Ausente por un tiempo prolongado",
+		language.French:  "Absent pour une longue durée",
+	},
+
+	// File transfer.
+	"The file hash does not match": {
+		language.German:  "Die Prüfsumme der Datei stimmt nicht überein",
+		language.Spanish: "El hash del archivo no coincide",
+		language.French:  "Le hachage du fichier ne correspond pas",
+	},
+}
+
+func init() {
+	for key, msgs := range translations {
+		for tag, msg := range msgs {
+			err := Catalog.SetString(tag, key, msg)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
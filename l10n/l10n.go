@@ -0,0 +1,60 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package l10n translates human readable text generated by the library.
+//
+// Text that is sent to the remote entity, such as the text of stanza errors,
+// is translated into the language of the peer as indicated by the xml:lang
+// attribute of the stanza being responded to or the stream it was received on.
+// Translations are looked up in Catalog which contains the translations
+// provided by this module and may be extended by applications with their own
+// translations.
+// Messages are identified by their English text, so applications providing a
+// translation of a message do so by calling Catalog.SetString with the English
+// text as the key:
+//
+//	l10n.Catalog.SetString(language.Dutch, "The command does not exist", "Het commando bestaat niet")
+//
+// English is used if no better match is found.
+package l10n // import "mellium.im/xmpp/l10n"
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Catalog contains translations of the text generated by the library.
+// Translations should be added before the catalog is first used.
+var Catalog = catalog.NewBuilder(catalog.Fallback(language.English))
+
+// Match returns the language in Catalog that best matches the provided
+// language tags.
+// Tags are in order of preference, for example the xml:lang attribute of a
+// stanza followed by the xml:lang attribute of the stream, and empty or
+// invalid tags are ignored.
+// If no tag matches a language in the catalog, English is returned.
+func Match(langs ...string) language.Tag {
+	supported := append([]language.Tag{language.English}, Catalog.Languages()...)
+	_, idx := language.MatchStrings(language.NewMatcher(supported), langs...)
+	return supported[idx]
+}
+
+// Printer returns a printer that translates messages into the language that
+// best matches the provided language tags.
+// For more information see Match.
+func Printer(langs ...string) *message.Printer {
+	return message.NewPrinter(Match(langs...), message.Catalog(Catalog))
+}
+
+// Text translates msg into the language that best matches the provided
+// language tags and returns it in a form suitable for use as the Text field of
+// a stanza.Error.
+// For more information see Match.
+func Text(msg string, langs ...string) map[string]string {
+	tag := Match(langs...)
+	return map[string]string{
+		tag.String(): message.NewPrinter(tag, message.Catalog(Catalog)).Sprintf(msg),
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package l10n_test
+
+import (
+	"strconv"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"mellium.im/xmpp/l10n"
+)
+
+var matchTestCases = [...]struct {
+	langs []string
+	want  language.Tag
+}{
+	0: {want: language.English},
+	1: {langs: []string{""}, want: language.English},
+	2: {langs: []string{"de"}, want: language.German},
+	3: {langs: []string{"de-CH"}, want: language.German},
+	4: {langs: []string{"", "fr"}, want: language.French},
+	5: {langs: []string{"es", "de"}, want: language.Spanish},
+	6: {langs: []string{"not a tag!", "es"}, want: language.Spanish},
+	7: {langs: []string{"tlh"}, want: language.English},
+}
+
+func TestMatch(t *testing.T) {
+	for i, tc := range matchTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := l10n.Match(tc.langs...); got != tc.want {
+				t.Errorf("wrong language: want=%v, got=%v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	const msg = "The command does not exist"
+	text := l10n.Text(msg, "de-AT")
+	if len(text) != 1 || text["de"] != "Der Befehl existiert nicht" {
+		t.Errorf("wrong German text: %v", text)
+	}
+	text = l10n.Text(msg, "tlh")
+	if len(text) != 1 || text["en"] != msg {
+		t.Errorf("wrong fallback text: %v", text)
+	}
+
+	err := l10n.Catalog.SetString(language.Dutch, msg, "Het commando bestaat niet")
+	if err != nil {
+		t.Fatalf("error adding translation: %v", err)
+	}
+	if s := l10n.Printer("nl").Sprintf(msg); s != "Het commando bestaat niet" {
+		t.Errorf("wrong translation from application catalog: %q", s)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stream"
+)
+
+// Direction indicates whether an element was sent or received.
+type Direction uint8
+
+// A list of possible directions.
+const (
+	Inbound Direction = iota
+	Outbound
+)
+
+// String returns "in" or "out" depending on the direction.
+func (d Direction) String() string {
+	if d == Outbound {
+		return "out"
+	}
+	return "in"
+}
+
+// LogEntry is a complete top level element that was sent or received over a
+// session.
+type LogEntry struct {
+	Direction Direction
+	Time      time.Time
+
+	// The local address of the session at the time the element was logged.
+	JID jid.JID
+
+	// The element, indented for readability.
+	XML string
+}
+
+// XMLLogger receives complete elements sent and received over a session.
+// Unlike TeeIn and TeeOut, which are copies of whatever bytes happen to be read
+// from or written to the connection, each call to LogXML receives exactly one
+// element, for example a stanza or a stream feature negotiation element.
+// Stream headers are not logged.
+//
+// LogXML is called synchronously while the element is being read or written
+// and must not block or use the session.
+type XMLLogger interface {
+	LogXML(LogEntry)
+}
+
+// The XMLLoggerFunc type is an adapter to allow the use of ordinary functions
+// as XML loggers.
+// If f is a function with the appropriate signature, XMLLoggerFunc(f) is an
+// XMLLogger that calls f.
+type XMLLoggerFunc func(LogEntry)
+
+// LogXML calls f(entry).
+func (f XMLLoggerFunc) LogXML(entry LogEntry) {
+	f(entry)
+}
+
+// wrapLogger wraps the sessions current input and output with the logger.
+// It must be called again whenever they are recreated.
+func (s *Session) wrapLogger() {
+	if s.logger == nil {
+		return
+	}
+	s.in.d = &logReader{r: s.in.d, log: elementLog{s: s, dir: Inbound}}
+	s.out.e = &logWriter{
+		TokenWriteFlusher: s.out.e,
+		log:               elementLog{s: s, dir: Outbound},
+	}
+}
+
+// elementLog collects the tokens of a single top level element and sends it
+// to the logger once it is complete.
+type elementLog struct {
+	s     *Session
+	dir   Direction
+	depth int
+	buf   []xml.Token
+}
+
+func isStreamHeader(name xml.Name) bool {
+	return name.Space == stream.NS && name.Local == "stream"
+}
+
+func (l *elementLog) token(t xml.Token) {
+	switch tok := t.(type) {
+	case xml.StartElement:
+		if l.depth == 0 && isStreamHeader(tok.Name) {
+			return
+		}
+		// Tokens read from a decoder include the namespace declaration as an
+		// attribute as well as in the name, remove it so that it is not duplicated
+		// when the element is encoded.
+		attrs := make([]xml.Attr, 0, len(tok.Attr))
+		for _, attr := range tok.Attr {
+			if attr.Name.Space == "" && attr.Name.Local == "xmlns" && attr.Value == tok.Name.Space {
+				continue
+			}
+			attrs = append(attrs, attr)
+		}
+		tok = tok.Copy()
+		tok.Attr = attrs
+		l.depth++
+		l.buf = append(l.buf, tok)
+		return
+	case xml.EndElement:
+		if l.depth == 0 {
+			return
+		}
+		l.depth--
+		l.buf = append(l.buf, tok)
+		if l.depth == 0 {
+			l.flush()
+		}
+		return
+	case xml.CharData:
+		if l.depth == 0 || len(bytes.TrimSpace(tok)) == 0 {
+			return
+		}
+	}
+	if l.depth > 0 {
+		l.buf = append(l.buf, xml.CopyToken(t))
+	}
+}
+
+func (l *elementLog) flush() {
+	var b bytes.Buffer
+	e := xml.NewEncoder(&b)
+	e.Indent("", "  ")
+	for _, tok := range l.buf {
+		if err := e.EncodeToken(tok); err != nil {
+			break
+		}
+	}
+	/* #nosec */
+	e.Flush()
+	l.buf = l.buf[:0]
+	l.s.logger.LogXML(LogEntry{
+		Direction: l.dir,
+		Time:      time.Now(),
+		JID:       l.s.LocalAddr(),
+		XML:       b.String(),
+	})
+}
+
+type logReader struct {
+	r   xml.TokenReader
+	log elementLog
+}
+
+func (r *logReader) Token() (xml.Token, error) {
+	tok, err := r.r.Token()
+	if tok != nil {
+		r.log.token(tok)
+	}
+	return tok, err
+}
+
+type logWriter struct {
+	xmlstream.TokenWriteFlusher
+	log elementLog
+}
+
+func (w *logWriter) EncodeToken(t xml.Token) error {
+	err := w.TokenWriteFlusher.EncodeToken(t)
+	if err == nil {
+		w.log.token(t)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestXMLLogger(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	var (
+		mu      sync.Mutex
+		entries []xmpp.LogEntry
+	)
+	logger := xmpp.XMLLoggerFunc(func(entry xmpp.LogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, entry)
+	})
+
+	serverC := make(chan *xmpp.Session, 1)
+	go func() {
+		s, err := xmpp.ReceiveSession(ctx, serverConn, xmpp.Secure|xmpp.Authn, xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+			return xmpp.StreamConfig{
+				Features: []xmpp.StreamFeature{xmpp.BindResource()},
+			}
+		}))
+		if err != nil {
+			t.Errorf("error receiving session: %v", err)
+		}
+		serverC <- s
+	}()
+	clientJID := jid.MustParse("me@example.net")
+	s, err := xmpp.NewSession(ctx, clientJID.Domain(), clientJID, clientConn, xmpp.Secure|xmpp.Authn, xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+		return xmpp.StreamConfig{
+			Features: []xmpp.StreamFeature{xmpp.BindResource()},
+			Logger:   logger,
+		}
+	}))
+	if err != nil {
+		t.Fatalf("error negotiating session: %v", err)
+	}
+	serverSession := <-serverC
+	if serverSession == nil {
+		t.FailNow()
+	}
+	go func() {
+		/* #nosec */
+		serverSession.Serve(nil)
+	}()
+
+	err = s.Send(ctx, stanza.Message{To: jid.MustParse("juliet@example.com")}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []struct {
+		dir    xmpp.Direction
+		prefix string
+	}{
+		{dir: xmpp.Inbound, prefix: `<features xmlns="http://etherx.jabber.org/streams">`},
+		{dir: xmpp.Outbound, prefix: `<iq xmlns="jabber:client" type="set"`},
+		{dir: xmpp.Inbound, prefix: `<iq xmlns="jabber:client" type="result"`},
+		{dir: xmpp.Outbound, prefix: `<message xmlns="jabber:client"`},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("wrong number of log entries: want=%d, got=%d: %+v", len(want), len(entries), entries)
+	}
+	for i, w := range want {
+		entry := entries[i]
+		if entry.Direction != w.dir {
+			t.Errorf("%d: wrong direction: want=%v, got=%v", i, w.dir, entry.Direction)
+		}
+		if !strings.HasPrefix(entry.XML, w.prefix) {
+			t.Errorf("%d: wrong XML: want prefix %s, got:\n%s", i, w.prefix, entry.XML)
+		}
+		if entry.Time.IsZero() {
+			t.Errorf("%d: expected time to be set", i)
+		}
+	}
+	if !strings.Contains(entries[1].XML, "\n  <bind") {
+		t.Errorf("expected indented XML, got:\n%s", entries[1].XML)
+	}
+	if last := entries[len(entries)-1]; last.JID.Resourcepart() == "" {
+		t.Errorf("expected bound JID on entries after resource binding, got %v", last.JID)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package muc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/jid"
+)
+
+// NSRoomConfig is the form type of room configuration forms.
+const NSRoomConfig = `http://jabber.org/protocol/muc#roomconfig`
+
+// Field names used by room configuration forms.
+const (
+	fieldName              = "muc#roomconfig_roomname"
+	fieldDesc              = "muc#roomconfig_roomdesc"
+	fieldLang              = "muc#roomconfig_lang"
+	fieldPersistent        = "muc#roomconfig_persistentroom"
+	fieldPublic            = "muc#roomconfig_publicroom"
+	fieldMembersOnly       = "muc#roomconfig_membersonly"
+	fieldModerated         = "muc#roomconfig_moderatedroom"
+	fieldPasswordProtected = "muc#roomconfig_passwordprotectedroom"
+	fieldPassword          = "muc#roomconfig_roomsecret"
+	fieldChangeSubject     = "muc#roomconfig_changesubject"
+	fieldAllowInvites      = "muc#roomconfig_allowinvites"
+	fieldLogging           = "muc#roomconfig_enablelogging"
+	fieldWhoIs             = "muc#roomconfig_whois"
+	fieldMaxUsers          = "muc#roomconfig_maxusers"
+	fieldAdmins            = "muc#roomconfig_roomadmins"
+	fieldOwners            = "muc#roomconfig_roomowners"
+	fieldMaxHistoryFetch   = "muc#maxhistoryfetch"
+)
+
+// Possible values of the WhoIs field of a room config.
+const (
+	WhoIsModerators = "moderators"
+	WhoIsAnyone     = "anyone"
+)
+
+// RoomConfig is a typed representation of the room configuration form defined
+// in XEP-0045: Multi-User Chat.
+//
+// Rooms are not required to support every option.
+// Changing a field that is not present in the form returned by the room
+// results in an error when the config is submitted.
+type RoomConfig struct {
+	Name              string
+	Description       string
+	Lang              string
+	Persistent        bool
+	Public            bool
+	MembersOnly       bool
+	Moderated         bool
+	PasswordProtected bool
+	Password          string
+	ChangeSubject     bool
+	AllowInvites      bool
+	Logging           bool
+
+	// WhoIs is the set of roles that may discover the real JIDs of occupants,
+	// normally WhoIsModerators or WhoIsAnyone.
+	WhoIs string
+
+	// MaxUsers is the maximum number of occupants, or 0 if there is no limit.
+	MaxUsers int
+
+	// MaxHistoryFetch is the maximum number of history messages that are sent
+	// to an occupant when they join the room.
+	MaxHistoryFetch int
+
+	Admins []jid.JID
+	Owners []jid.JID
+
+	// Form is the form that the config was created from.
+	// It may be used to access fields that are not represented by the config,
+	// however, any values set on the form for fields that are represented by the
+	// config will be overridden when the config is submitted if the
+	// corresponding field of the config was changed.
+	// Submitting the config does not modify the form.
+	Form *form.Data
+}
+
+// NewRoomConfig creates a room config from the values of a room configuration
+// form such as the one returned by GetConfig.
+func NewRoomConfig(data *form.Data) RoomConfig {
+	rc := RoomConfig{Form: data}
+	rc.Name, _ = data.GetString(fieldName)
+	rc.Description, _ = data.GetString(fieldDesc)
+	rc.Lang, _ = data.GetString(fieldLang)
+	rc.Persistent, _ = data.GetBool(fieldPersistent)
+	rc.Public, _ = data.GetBool(fieldPublic)
+	rc.MembersOnly, _ = data.GetBool(fieldMembersOnly)
+	rc.Moderated, _ = data.GetBool(fieldModerated)
+	rc.PasswordProtected, _ = data.GetBool(fieldPasswordProtected)
+	rc.Password, _ = data.GetString(fieldPassword)
+	rc.ChangeSubject, _ = data.GetBool(fieldChangeSubject)
+	rc.AllowInvites, _ = data.GetBool(fieldAllowInvites)
+	rc.Logging, _ = data.GetBool(fieldLogging)
+	rc.WhoIs, _ = data.GetString(fieldWhoIs)
+	rc.MaxUsers = getInt(data, fieldMaxUsers)
+	rc.MaxHistoryFetch = getInt(data, fieldMaxHistoryFetch)
+	rc.Admins, _ = data.GetJIDs(fieldAdmins)
+	rc.Owners, _ = data.GetJIDs(fieldOwners)
+	return rc
+}
+
+// getInt returns the value of a numeric field or 0 if the field is not set or
+// is not numeric (eg. the value "none" used by the maxusers field).
+func getInt(data *form.Data, id string) int {
+	s, ok := data.GetString(id)
+	if !ok {
+		return 0
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// apply returns a copy of the config's form with any fields of the config that
+// differ from the values in the form set.
+// The config's form is never modified so that it is left unchanged if an error
+// is returned part of the way through.
+func (rc RoomConfig) apply() (*form.Data, error) {
+	if rc.Form == nil {
+		return nil, errors.New("muc: room config has no form, it must be created with GetConfig or NewRoomConfig")
+	}
+	cur := NewRoomConfig(rc.Form)
+	data, err := copyForm(rc.Form)
+	if err != nil {
+		return nil, err
+	}
+	set := func(id string, changed bool, v interface{}) {
+		if err != nil || !changed {
+			return
+		}
+		if _, ok := data.Raw(id); !ok {
+			err = fmt.Errorf("muc: room does not support configuring %s", id)
+			return
+		}
+		_, err = data.Set(id, v)
+	}
+	set(fieldName, cur.Name != rc.Name, rc.Name)
+	set(fieldDesc, cur.Description != rc.Description, rc.Description)
+	set(fieldLang, cur.Lang != rc.Lang, rc.Lang)
+	set(fieldPersistent, cur.Persistent != rc.Persistent, rc.Persistent)
+	set(fieldPublic, cur.Public != rc.Public, rc.Public)
+	set(fieldMembersOnly, cur.MembersOnly != rc.MembersOnly, rc.MembersOnly)
+	set(fieldModerated, cur.Moderated != rc.Moderated, rc.Moderated)
+	set(fieldPasswordProtected, cur.PasswordProtected != rc.PasswordProtected, rc.PasswordProtected)
+	set(fieldPassword, cur.Password != rc.Password, rc.Password)
+	set(fieldChangeSubject, cur.ChangeSubject != rc.ChangeSubject, rc.ChangeSubject)
+	set(fieldAllowInvites, cur.AllowInvites != rc.AllowInvites, rc.AllowInvites)
+	set(fieldLogging, cur.Logging != rc.Logging, rc.Logging)
+	set(fieldWhoIs, cur.WhoIs != rc.WhoIs, rc.WhoIs)
+	maxUsers := "none"
+	if rc.MaxUsers > 0 {
+		maxUsers = strconv.Itoa(rc.MaxUsers)
+	}
+	set(fieldMaxUsers, cur.MaxUsers != rc.MaxUsers, maxUsers)
+	// A history fetch of 0 means that no history is sent, not that there is no
+	// limit.
+	set(fieldMaxHistoryFetch, cur.MaxHistoryFetch != rc.MaxHistoryFetch, strconv.Itoa(rc.MaxHistoryFetch))
+	set(fieldAdmins, !jidsEqual(cur.Admins, rc.Admins), rc.Admins)
+	set(fieldOwners, !jidsEqual(cur.Owners, rc.Owners), rc.Owners)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// copyForm returns a deep copy of data by encoding it and decoding the result.
+func copyForm(data *form.Data) (*form.Data, error) {
+	c := &form.Data{}
+	err := xml.NewTokenDecoder(data.TokenReader()).Decode(c)
+	if err != nil {
+		return nil, fmt.Errorf("muc: error copying room config form: %w", err)
+	}
+	return c, nil
+}
+
+func jidsEqual(a, b []jid.JID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetConfig requests the channel's configuration.
+// The user must be an owner of the channel.
+func (c *Channel) GetConfig(ctx context.Context) (RoomConfig, error) {
+	data, err := GetConfig(ctx, c.addr.Bare(), c.session)
+	if err != nil {
+		return RoomConfig{}, err
+	}
+	return NewRoomConfig(data), nil
+}
+
+// SetConfig submits a room configuration to the channel.
+// The config should be one returned by GetConfig with various fields changed.
+func (c *Channel) SetConfig(ctx context.Context, rc RoomConfig) error {
+	data, err := rc.apply()
+	if err != nil {
+		return err
+	}
+	return SetConfig(ctx, c.addr.Bare(), data, c.session)
+}
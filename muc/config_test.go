@@ -0,0 +1,137 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package muc_test
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/muc"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestRoomConfig(t *testing.T) {
+	j := jid.MustParse("room@example.net/me")
+	owner := jid.MustParse("owner@example.net")
+	h := &muc.Client{}
+	submitted := make(chan *form.Data, 1)
+	m := mux.New(stanza.NSClient, muc.HandleClient(h))
+	server := mux.New(
+		stanza.NSClient,
+		mux.PresenceFunc("", xml.Name{Local: "x"}, func(p stanza.Presence, r xmlstream.TokenReadEncoder) error {
+			// Send back a self presence, indicating that the join is complete.
+			p.To, p.From = p.From, p.To
+			_, err := xmlstream.Copy(r, p.Wrap(xmlstream.Wrap(
+				nil,
+				xml.StartElement{Name: xml.Name{Space: muc.NSUser, Local: "x"}},
+			)))
+			return err
+		}),
+		mux.IQFunc(stanza.GetIQ, xml.Name{Space: muc.NSOwner, Local: "query"}, func(iq stanza.IQ, r xmlstream.TokenReadEncoder, _ *xml.StartElement) error {
+			data := form.New(
+				form.Hidden("FORM_TYPE", form.Value(muc.NSRoomConfig)),
+				form.Text("muc#roomconfig_roomname", form.Value("Bridge")),
+				form.Boolean("muc#roomconfig_persistentroom", form.Value("1")),
+				form.Boolean("muc#roomconfig_moderatedroom", form.Value("0")),
+				form.List("muc#roomconfig_maxusers", form.Value("none"),
+					form.ListItem("10", "10"),
+					form.ListItem("none", "none"),
+				),
+				form.Text("muc#maxhistoryfetch", form.Value("50")),
+				form.JIDMulti("muc#roomconfig_roomowners", form.Value(owner.String())),
+			)
+			_, err := xmlstream.Copy(r, iq.Result(xmlstream.Wrap(
+				data.TokenReader(),
+				xml.StartElement{Name: xml.Name{Space: muc.NSOwner, Local: "query"}},
+			)))
+			return err
+		}),
+		mux.IQFunc(stanza.SetIQ, xml.Name{Space: muc.NSOwner, Local: "query"}, func(iq stanza.IQ, r xmlstream.TokenReadEncoder, _ *xml.StartElement) error {
+			d := xml.NewTokenDecoder(r)
+			data := &form.Data{}
+			err := d.Decode(data)
+			if err != nil {
+				return err
+			}
+			submitted <- data
+			_, err = xmlstream.Copy(r, iq.Result(nil))
+			return err
+		}),
+	)
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(m),
+		xmpptest.ServerHandler(server),
+	)
+
+	channel, err := h.Join(context.Background(), j, s.Client)
+	if err != nil {
+		t.Fatalf("error joining: %v", err)
+	}
+
+	cfg, err := channel.GetConfig(context.Background())
+	if err != nil {
+		t.Fatalf("error fetching config: %v", err)
+	}
+	if cfg.Name != "Bridge" {
+		t.Errorf("wrong name: want=%q, got=%q", "Bridge", cfg.Name)
+	}
+	if !cfg.Persistent {
+		t.Errorf("expected room to be persistent")
+	}
+	if cfg.Moderated {
+		t.Errorf("did not expect room to be moderated")
+	}
+	if cfg.MaxUsers != 0 {
+		t.Errorf("wrong max users: want=0, got=%d", cfg.MaxUsers)
+	}
+	if cfg.MaxHistoryFetch != 50 {
+		t.Errorf("wrong max history fetch: want=50, got=%d", cfg.MaxHistoryFetch)
+	}
+	if len(cfg.Owners) != 1 || !cfg.Owners[0].Equal(owner) {
+		t.Errorf("wrong owners: want=[%v], got=%v", owner, cfg.Owners)
+	}
+
+	cfg.Moderated = true
+	cfg.MaxUsers = 10
+	cfg.MaxHistoryFetch = 0
+	err = channel.SetConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("error setting config: %v", err)
+	}
+	sub := <-submitted
+	if moderated, _ := sub.GetBool("muc#roomconfig_moderatedroom"); !moderated {
+		t.Errorf("expected moderated to be submitted as true")
+	}
+	if maxUsers, _ := sub.GetString("muc#roomconfig_maxusers"); maxUsers != "10" {
+		t.Errorf("wrong max users submitted: want=10, got=%q", maxUsers)
+	}
+	if maxFetch, _ := sub.GetString("muc#maxhistoryfetch"); maxFetch != "0" {
+		t.Errorf("wrong max history fetch submitted: want=0, got=%q", maxFetch)
+	}
+	if name, _ := sub.GetString("muc#roomconfig_roomname"); name != "Bridge" {
+		t.Errorf("unchanged name not submitted: want=%q, got=%q", "Bridge", name)
+	}
+
+	cfg.Name = "Balcony"
+	cfg.Logging = true
+	err = channel.SetConfig(context.Background(), cfg)
+	if err == nil {
+		t.Errorf("expected error setting unsupported field")
+	}
+	// Submitting the config must not modify its form, even if some fields were
+	// set before the error.
+	if name, _ := cfg.Form.GetString("muc#roomconfig_roomname"); name != "Bridge" {
+		t.Errorf("form was modified: want name=%q, got=%q", "Bridge", name)
+	}
+	if moderated, _ := cfg.Form.GetBool("muc#roomconfig_moderatedroom"); moderated {
+		t.Errorf("form was modified: expected moderated to still be false")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package muc
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/stanza"
+)
+
+// Namespaces used by message moderation.
+const (
+	NSModerate = `urn:xmpp:message-moderate:1`
+	NSRetract  = `urn:xmpp:message-retract:1`
+)
+
+// Moderate asks the channel to retract the message with the provided stanza ID
+// (the ID assigned to the message by the channel, not the origin ID) on behalf
+// of all occupants.
+// The user must be a moderator of the channel.
+// If the request succeeds the channel replaces the message in its history and
+// notifies the occupants that it was retracted.
+func (c *Channel) Moderate(ctx context.Context, id, reason string) error {
+	var reasonEl xml.TokenReader
+	if reason != "" {
+		reasonEl = xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(reason)),
+			xml.StartElement{Name: xml.Name{Local: "reason"}},
+		)
+	}
+	payload := xmlstream.Wrap(
+		xmlstream.MultiReader(
+			xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: NSRetract, Local: "retract"}}),
+			reasonEl,
+		),
+		xml.StartElement{
+			Name: xml.Name{Space: NSModerate, Local: "moderate"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: id}},
+		},
+	)
+	return c.session.UnmarshalIQElement(ctx, payload, stanza.IQ{
+		Type: stanza.SetIQ,
+		To:   c.addr.Bare(),
+	}, nil)
+}
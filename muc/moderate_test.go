@@ -0,0 +1,238 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package muc_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/muc"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/retract"
+	"mellium.im/xmpp/stanza"
+)
+
+var moderateTestCases = [...]struct {
+	id     string
+	reason string
+	x      string
+}{
+	0: {
+		id: "abc",
+		x:  `<moderate xmlns="urn:xmpp:message-moderate:1" id="abc"><retract xmlns="urn:xmpp:message-retract:1"></retract></moderate>`,
+	},
+	1: {
+		id:     "123",
+		reason: "Spam",
+		x:      `<moderate xmlns="urn:xmpp:message-moderate:1" id="123"><retract xmlns="urn:xmpp:message-retract:1"></retract><reason xmlns="urn:xmpp:message-moderate:1">Spam</reason></moderate>`,
+	},
+}
+
+func TestModerate(t *testing.T) {
+	j := jid.MustParse("room@example.net/me")
+	h := &muc.Client{}
+	handled := make(chan string, 1)
+	m := mux.New(stanza.NSClient, muc.HandleClient(h))
+	server := mux.New(
+		stanza.NSClient,
+		mux.PresenceFunc("", xml.Name{Local: "x"}, func(p stanza.Presence, r xmlstream.TokenReadEncoder) error {
+			// Send back a self presence, indicating that the join is complete.
+			p.To, p.From = p.From, p.To
+			_, err := xmlstream.Copy(r, p.Wrap(xmlstream.Wrap(
+				nil,
+				xml.StartElement{Name: xml.Name{Space: muc.NSUser, Local: "x"}},
+			)))
+			return err
+		}),
+		mux.IQFunc(stanza.SetIQ, xml.Name{Space: muc.NSModerate, Local: "moderate"}, func(iq stanza.IQ, r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			var buf strings.Builder
+			defer func() {
+				handled <- buf.String()
+			}()
+			e := xml.NewEncoder(&buf)
+			// The encoder adds its own namespace attributes so remove the ones
+			// that were decoded.
+			removeNS := xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})
+			_, err := xmlstream.Copy(e, removeNS(xmlstream.MultiReader(xmlstream.Token(*start), xmlstream.Inner(r), xmlstream.Token(start.End()))))
+			if err != nil {
+				return err
+			}
+			err = e.Flush()
+			if err != nil {
+				return err
+			}
+			_, err = xmlstream.Copy(r, iq.Result(nil))
+			return err
+		}),
+	)
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(m),
+		xmpptest.ServerHandler(server),
+	)
+
+	channel, err := h.Join(context.Background(), j, s.Client)
+	if err != nil {
+		t.Fatalf("error joining: %v", err)
+	}
+
+	for i, tc := range moderateTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err = channel.Moderate(context.Background(), tc.id, tc.reason)
+			if err != nil {
+				t.Fatalf("error moderating message: %v", err)
+			}
+			x := <-handled
+			if x != tc.x {
+				t.Fatalf("wrong output:\nwant=%s,\n got=%s", tc.x, x)
+			}
+		})
+	}
+}
+
+type memoryStore struct {
+	history.ArchiveFunc
+	msgs map[string]string
+}
+
+func (s memoryStore) Retract(_ jid.JID, id string, tombstone xmlstream.Transformer) error {
+	if id == "broken" {
+		return errors.New("storage failure")
+	}
+	msg, ok := s.msgs[id]
+	if !ok {
+		return stanza.Error{Type: stanza.Cancel, Condition: stanza.ItemNotFound}
+	}
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	// Prevent duplicate xmlns attributes. See https://mellium.im/issue/75
+	r := xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+		return attr.Name.Local == "xmlns"
+	})(xml.NewDecoder(strings.NewReader(msg)))
+	_, err := xmlstream.Copy(e, tombstone(r))
+	if err != nil {
+		return err
+	}
+	err = e.Flush()
+	if err != nil {
+		return err
+	}
+	s.msgs[id] = buf.String()
+	return nil
+}
+
+func TestHandleModeration(t *testing.T) {
+	moderator := jid.MustParse("mod@example.net/laptop")
+	occupant := jid.MustParse("room@muc.example.net/mod")
+	store := memoryStore{msgs: map[string]string{
+		"stanza-1": `<message xmlns="jabber:client" type="groupchat" from="room@muc.example.net/spammer"><body>Spam</body><stanza-id xmlns="urn:xmpp:sid:0" id="stanza-1" by="room@muc.example.net"/></message>`,
+	}}
+	retractions := make(chan retract.Retraction, 1)
+	server := mux.New(
+		stanza.NSClient,
+		muc.HandleModeration(&muc.Moderation{
+			Moderator: func(channel, from jid.JID) (retract.Moderated, bool) {
+				return retract.Moderated{By: occupant, OccupantID: "abc"}, from.Equal(moderator)
+			},
+			Occupants: func(jid.JID) []jid.JID {
+				return []jid.JID{moderator}
+			},
+			Store: store,
+		}),
+	)
+	client := mux.New(
+		"",
+		retract.Handle(&retract.Handler{
+			Retracted: func(_ stanza.Message, r retract.Retraction) {
+				retractions <- r
+			},
+		}),
+	)
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(client),
+		xmpptest.ServerHandler(server),
+	)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	moderate := func(from jid.JID, id string) error {
+		return s.Client.UnmarshalIQElement(ctx, xmlstream.Wrap(
+			xmlstream.MultiReader(
+				xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: muc.NSRetract, Local: "retract"}}),
+				xmlstream.Wrap(
+					xmlstream.Token(xml.CharData("Spam")),
+					xml.StartElement{Name: xml.Name{Local: "reason"}},
+				),
+			),
+			xml.StartElement{
+				Name: xml.Name{Space: muc.NSModerate, Local: "moderate"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: id}},
+			},
+		), stanza.IQ{
+			Type: stanza.SetIQ,
+			From: from,
+			To:   jid.MustParse("room@muc.example.net"),
+		}, nil)
+	}
+
+	var stanzaErr stanza.Error
+	err := moderate(jid.MustParse("user@example.net/phone"), "stanza-1")
+	if !errors.As(err, &stanzaErr) || stanzaErr.Condition != stanza.Forbidden {
+		t.Errorf("wrong error for a user that is not a moderator: want=%v, got=%v", stanza.Forbidden, err)
+	}
+	err = moderate(moderator, "unknown")
+	if !errors.As(err, &stanzaErr) || stanzaErr.Condition != stanza.ItemNotFound {
+		t.Errorf("wrong error for an unknown message: want=%v, got=%v", stanza.ItemNotFound, err)
+	}
+	// A failure to store the tombstone is reported without ending the session.
+	err = moderate(moderator, "broken")
+	if !errors.As(err, &stanzaErr) || stanzaErr.Condition != stanza.InternalServerError {
+		t.Errorf("wrong error for a storage failure: want=%v, got=%v", stanza.InternalServerError, err)
+	}
+	err = moderate(moderator, "stanza-1")
+	if err != nil {
+		t.Fatalf("error moderating message: %v", err)
+	}
+
+	select {
+	case r := <-retractions:
+		want := retract.Retraction{
+			ID:        "stanza-1",
+			Reason:    "Spam",
+			Moderated: &retract.Moderated{By: occupant, OccupantID: "abc"},
+		}
+		if r.ID != want.ID || r.Reason != want.Reason || r.Moderated == nil ||
+			!r.Moderated.By.Equal(want.Moderated.By) || r.Moderated.OccupantID != want.Moderated.OccupantID {
+			t.Errorf("wrong retraction sent to occupants: want=%+v, got=%+v", want, r)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for retraction")
+	}
+
+	tombstone := store.msgs["stanza-1"]
+	for _, s := range []string{
+		`<retracted xmlns="urn:xmpp:message-retract:1" stamp="`,
+		`<moderated xmlns="urn:xmpp:message-moderate:1" by="room@muc.example.net/mod"><occupant-id xmlns="urn:xmpp:occupant-id:0" id="abc"></occupant-id></moderated>`,
+		`<stanza-id xmlns="urn:xmpp:sid:0" id="stanza-1" by="room@muc.example.net"></stanza-id>`,
+	} {
+		if !strings.Contains(tombstone, s) {
+			t.Errorf("tombstone %s does not contain %s", tombstone, s)
+		}
+	}
+	if strings.Contains(tombstone, "<body") {
+		t.Errorf("tombstone still contains the original body: %s", tombstone)
+	}
+}
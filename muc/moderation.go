@@ -0,0 +1,233 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package muc
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/history"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/retract"
+	"mellium.im/xmpp/stanza"
+)
+
+// ModerationStore is a message archive that supports retracting messages.
+//
+// Retract is called with the address of a channel and the stanza ID of a
+// message in its history.
+// It should read the stored message through tombstone and store the result in
+// place of the original message so that history queries return the tombstone
+// instead.
+// If no message with the provided ID exists, Retract should return a
+// stanza.Error with the ItemNotFound condition.
+type ModerationStore interface {
+	history.Archive
+	Retract(channel jid.JID, id string, tombstone xmlstream.Transformer) error
+}
+
+// Moderation handles requests from moderators to retract messages sent to
+// channels hosted by a component as described in XEP-0425: Moderated Message
+// Retraction.
+//
+// When a request is accepted the message is replaced with a tombstone in the
+// channel history and a retraction is sent to every occupant of the channel.
+type Moderation struct {
+	// Moderator is called with the address of a channel and the real address
+	// of the user making a request and reports whether the user is a moderator
+	// of the channel.
+	// If they are, it returns information about the moderators occupant that is
+	// included in retractions.
+	// If Moderator is nil, all requests are rejected.
+	Moderator func(channel, from jid.JID) (retract.Moderated, bool)
+
+	// Occupants returns the real addresses of the occupants of a channel that
+	// retractions are sent to.
+	// If Occupants is nil, retractions are not sent.
+	Occupants func(channel jid.JID) []jid.JID
+
+	// Store is the history of the channels.
+	// If Store is nil, messages are not replaced with tombstones and
+	// retractions are only sent to the occupants.
+	Store ModerationStore
+}
+
+// HandleModeration returns an option that registers a handler for moderation
+// requests.
+func HandleModeration(m *Moderation) mux.Option {
+	return mux.IQ(stanza.SetIQ, xml.Name{Space: NSModerate, Local: "moderate"}, m)
+}
+
+// ForFeatures implements info.FeatureIter.
+func (m *Moderation) ForFeatures(node string, f func(info.Feature) error) error {
+	if node != "" {
+		return nil
+	}
+	return f(info.Feature{Var: NSModerate})
+}
+
+// HandleIQ implements mux.IQHandler.
+func (m *Moderation) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	req := struct {
+		ID      string    `xml:"id,attr"`
+		Retract *struct{} `xml:"urn:xmpp:message-retract:1 retract"`
+		Reason  string    `xml:"reason"`
+	}{}
+	err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), t)).Decode(&req)
+	if err != nil || req.ID == "" || req.Retract == nil {
+		_, err = xmlstream.Copy(t, iq.Error(stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.BadRequest,
+		}))
+		return err
+	}
+
+	channel := iq.To.Bare()
+	var moderator retract.Moderated
+	ok := false
+	if m.Moderator != nil {
+		moderator, ok = m.Moderator(channel, iq.From)
+	}
+	if !ok {
+		_, err = xmlstream.Copy(t, iq.Error(stanza.Error{
+			Type:      stanza.Auth,
+			Condition: stanza.Forbidden,
+		}))
+		return err
+	}
+
+	retraction := retract.Retraction{
+		ID:        req.ID,
+		Reason:    req.Reason,
+		Moderated: &moderator,
+	}
+	if m.Store != nil {
+		err = m.Store.Retract(channel, req.ID, tombstone(retraction, time.Now()))
+		var stanzaErr stanza.Error
+		switch {
+		case errors.As(err, &stanzaErr):
+			_, err = xmlstream.Copy(t, iq.Error(stanzaErr))
+			return err
+		case err != nil:
+			// The failure only affects this request, so report it to the moderator
+			// without ending the session.
+			_, err = xmlstream.Copy(t, iq.Error(stanza.Error{
+				Type:      stanza.Wait,
+				Condition: stanza.InternalServerError,
+			}))
+			return err
+		}
+	}
+	_, err = xmlstream.Copy(t, iq.Result(nil))
+	if err != nil {
+		return err
+	}
+
+	if m.Occupants == nil {
+		return nil
+	}
+	for _, occupant := range m.Occupants(channel) {
+		_, err = xmlstream.Copy(t, stanza.Message{
+			ID:   attr.RandomID(),
+			To:   occupant,
+			From: channel,
+			Type: stanza.GroupChatMessage,
+		}.Wrap(retraction.TokenReader()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tombstone returns a transformer that replaces the payload of a message with
+// a tombstone for the retraction.
+// The stanza ID and occupant ID of the message are kept so that the tombstone
+// can still be referenced.
+func tombstone(r retract.Retraction, stamp time.Time) xmlstream.Transformer {
+	return func(msg xml.TokenReader) xml.TokenReader {
+		var (
+			toks []xml.Token
+			err  error
+			read bool
+		)
+		return xmlstream.ReaderFunc(func() (xml.Token, error) {
+			if !read {
+				read = true
+				toks, err = tombstoneTokens(msg, r, stamp)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(toks) == 0 {
+				return nil, io.EOF
+			}
+			tok := toks[0]
+			toks = toks[1:]
+			return tok, nil
+		})
+	}
+}
+
+func tombstoneTokens(msg xml.TokenReader, r retract.Retraction, stamp time.Time) ([]xml.Token, error) {
+	tok, err := msg.Token()
+	if err != nil {
+		return nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, errors.New("muc: expected message start element")
+	}
+
+	// Use the retraction without its outer retract element as the payload of the
+	// retracted element.
+	retraction := r.TokenReader()
+	_, err = retraction.Token()
+	if err != nil {
+		return nil, err
+	}
+	toks, err := xmlstream.ReadAll(xmlstream.Wrap(
+		xmlstream.Inner(retraction),
+		xml.StartElement{
+			Name: xml.Name{Space: NSRetract, Local: "retracted"},
+			Attr: []xml.Attr{{
+				Name:  xml.Name{Local: "stamp"},
+				Value: stamp.UTC().Format(time.RFC3339),
+			}},
+		},
+	))
+	if err != nil {
+		return nil, err
+	}
+	toks = append([]xml.Token{start.Copy()}, toks...)
+
+	iter := xmlstream.NewIter(msg)
+	for iter.Next() {
+		child, inner := iter.Current()
+		if child == nil {
+			continue
+		}
+		keep := (child.Name.Space == stanza.NSSid && child.Name.Local == "stanza-id") ||
+			(child.Name.Space == retract.NSOccupant && child.Name.Local == "occupant-id")
+		if !keep {
+			continue
+		}
+		childToks, err := xmlstream.ReadAll(xmlstream.MultiReader(xmlstream.Token(*child), inner))
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, childToks...)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return append(toks, start.End()), nil
+}
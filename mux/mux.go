@@ -14,6 +14,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"runtime/debug"
 	"strings"
 
 	"mellium.im/xmlstream"
@@ -22,6 +23,7 @@ import (
 	"mellium.im/xmpp/disco/items"
 	"mellium.im/xmpp/form"
 	"mellium.im/xmpp/internal/decl"
+	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -35,10 +37,37 @@ type pattern struct {
 	Payload xml.Name
 	Stanza  string
 	Type    string
+	// From is the sender that the pattern is restricted to or the empty string
+	// to match stanzas from any sender.
+	From string
 }
 
 func (p pattern) String() string {
-	return fmt.Sprintf("%s %s with payload {%s}%s", p.Type, p.Stanza, p.Payload.Space, p.Payload.Local)
+	s := fmt.Sprintf("%s %s with payload {%s}%s", p.Type, p.Stanza, p.Payload.Space, p.Payload.Local)
+	if p.From != "" {
+		s += " from " + p.From
+	}
+	return s
+}
+
+// senders returns the values of pattern.From that match stanzas sent by from in
+// order of precedence: the full JID, the bare JID, the domain, and finally any
+// sender.
+func senders(from jid.JID) []string {
+	candidates := []string{
+		from.String(),
+		from.Bare().String(),
+		from.Domain().String(),
+		"",
+	}
+	out := candidates[:0]
+	for _, c := range candidates {
+		if len(out) > 0 && out[len(out)-1] == c {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
 }
 
 // ServeMux is an XMPP stream multiplexer.
@@ -51,6 +80,14 @@ func (p pattern) String() string {
 // localname will be matched.
 // Full XML names take precedence, followed by wildcard localnames, followed by
 // wildcard namespaces.
+//
+// Stanza handlers may also be restricted to a sender using the From option.
+// Handlers registered for the full JID of the sender take precedence, followed
+// by handlers for its bare JID, then its domain, and finally handlers that match
+// any sender.
+// Within each of these the payload of the stanza is matched as described above,
+// so a handler with a wildcard payload that is restricted to a sender is used
+// instead of a handler for the exact payload that matches any sender.
 type ServeMux struct {
 	patterns         map[xml.Name]xmpp.Handler
 	iqPatterns       map[pattern]IQHandler
@@ -58,6 +95,10 @@ type ServeMux struct {
 	presencePatterns map[pattern]PresenceHandler
 	features         []info.FeatureIter
 	idents           []info.IdentityIter
+	items            []items.Iter
+	middleware       []func(next xmpp.Handler) xmpp.Handler
+	errHandler       func(err error, start *xml.StartElement) error
+	recover          bool
 	stanzaNS         string
 }
 
@@ -110,112 +151,191 @@ func (m *ServeMux) Handler(name xml.Name) (h xmpp.Handler, ok bool) {
 
 // IQHandler returns the handler to use for an IQ payload with the given type
 // and payload name.
+// Only handlers that match stanzas from any sender are considered.
 // If no handler exists, a default handler is returned (h is always non-nil).
 func (m *ServeMux) IQHandler(typ stanza.IQType, payload xml.Name) (h IQHandler, ok bool) {
-	pattern := pattern{Stanza: iqStanza, Payload: payload, Type: string(typ)}
-	h = m.iqPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+	return m.iqHandler(jid.JID{}, typ, payload)
+}
 
-	pattern.Payload.Space = ""
-	pattern.Payload.Local = payload.Local
-	h = m.iqPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+func (m *ServeMux) iqHandler(from jid.JID, typ stanza.IQType, payload xml.Name) (h IQHandler, ok bool) {
+	for _, sender := range senders(from) {
+		pattern := pattern{Stanza: iqStanza, Payload: payload, Type: string(typ), From: sender}
+		h = m.iqPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
-	pattern.Payload.Space = payload.Space
-	pattern.Payload.Local = ""
-	h = m.iqPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+		pattern.Payload.Space = ""
+		pattern.Payload.Local = payload.Local
+		h = m.iqPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
-	pattern.Payload.Space = ""
-	pattern.Payload.Local = ""
-	h = m.iqPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+		pattern.Payload.Space = payload.Space
+		pattern.Payload.Local = ""
+		h = m.iqPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
+		pattern.Payload.Space = ""
+		pattern.Payload.Local = ""
+		h = m.iqPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
+	}
 	return IQHandlerFunc(iqFallback), false
 }
 
 // MessageHandler returns the handler to use for a message with the given type
 // and payload.
+// Only handlers that match stanzas from any sender are considered.
 // If no handler exists, a default handler is returned (h is always non-nil).
 func (m *ServeMux) MessageHandler(typ stanza.MessageType, payload xml.Name) (h MessageHandler, ok bool) {
-	pattern := pattern{Stanza: msgStanza, Payload: payload, Type: string(typ)}
-	h = m.msgPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+	return m.messageHandler(jid.JID{}, typ, payload)
+}
 
-	pattern.Payload.Space = ""
-	pattern.Payload.Local = payload.Local
-	h = m.msgPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+func (m *ServeMux) messageHandler(from jid.JID, typ stanza.MessageType, payload xml.Name) (h MessageHandler, ok bool) {
+	for _, sender := range senders(from) {
+		pattern := pattern{Stanza: msgStanza, Payload: payload, Type: string(typ), From: sender}
+		h = m.msgPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
-	pattern.Payload.Space = payload.Space
-	pattern.Payload.Local = ""
-	h = m.msgPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+		pattern.Payload.Space = ""
+		pattern.Payload.Local = payload.Local
+		h = m.msgPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
-	pattern.Payload.Space = ""
-	pattern.Payload.Local = ""
-	h = m.msgPatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+		pattern.Payload.Space = payload.Space
+		pattern.Payload.Local = ""
+		h = m.msgPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
+		pattern.Payload.Space = ""
+		pattern.Payload.Local = ""
+		h = m.msgPatterns[pattern]
+		if h != nil {
+			return h, true
+		}
+	}
 	return nopHandler{}, false
 }
 
 // PresenceHandler returns the handler to use for a presence payload with the
 // given type.
+// Only handlers that match stanzas from any sender are considered.
 // If no handler exists, a default handler is returned (h is always non-nil).
 func (m *ServeMux) PresenceHandler(typ stanza.PresenceType, payload xml.Name) (h PresenceHandler, ok bool) {
-	pattern := pattern{Stanza: presStanza, Payload: payload, Type: string(typ)}
-	h = m.presencePatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+	return m.presenceHandler(jid.JID{}, typ, payload)
+}
 
-	pattern.Payload.Space = ""
-	pattern.Payload.Local = payload.Local
-	h = m.presencePatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+func (m *ServeMux) presenceHandler(from jid.JID, typ stanza.PresenceType, payload xml.Name) (h PresenceHandler, ok bool) {
+	for _, sender := range senders(from) {
+		pattern := pattern{Stanza: presStanza, Payload: payload, Type: string(typ), From: sender}
+		h = m.presencePatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
-	pattern.Payload.Space = payload.Space
-	pattern.Payload.Local = ""
-	h = m.presencePatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+		pattern.Payload.Space = ""
+		pattern.Payload.Local = payload.Local
+		h = m.presencePatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
-	pattern.Payload.Space = ""
-	pattern.Payload.Local = ""
-	h = m.presencePatterns[pattern]
-	if h != nil {
-		return h, true
-	}
+		pattern.Payload.Space = payload.Space
+		pattern.Payload.Local = ""
+		h = m.presencePatterns[pattern]
+		if h != nil {
+			return h, true
+		}
 
+		pattern.Payload.Space = ""
+		pattern.Payload.Local = ""
+		h = m.presencePatterns[pattern]
+		if h != nil {
+			return h, true
+		}
+	}
 	return nopHandler{}, false
 }
 
 // HandleXMPP dispatches the request to the handler that most closely matches.
+// The handler is wrapped in any middleware registered with Use.
+// If an error handler was registered with ErrorHandler, any error returned by
+// the handler is passed to it and its result is returned instead.
 func (m *ServeMux) HandleXMPP(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
 	h, _ := m.Handler(start.Name)
+	for i := len(m.middleware) - 1; i >= 0; i-- {
+		h = m.middleware[i](h)
+	}
+	err := m.handle(h, t, start)
+	if err != nil && m.errHandler != nil {
+		return m.errHandler(err, start)
+	}
+	// Recovered panics only end the session if an error handler asks for it.
+	if _, ok := err.(*PanicError); ok {
+		return nil
+	}
+	return err
+}
+
+// handle calls h, recovering from any panic if the mux is configured to do so.
+func (m *ServeMux) handle(h xmpp.Handler, t xmlstream.TokenReadEncoder, start *xml.StartElement) (err error) {
+	if m.recover {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+			if !isIQ(start.Name) {
+				return
+			}
+			iq, iqErr := stanza.NewIQ(*start)
+			if iqErr != nil || (iq.Type != stanza.GetIQ && iq.Type != stanza.SetIQ) {
+				return
+			}
+			_, iqErr = xmlstream.Copy(t, iq.Error(stanza.Error{
+				Type:      stanza.Cancel,
+				Condition: stanza.InternalServerError,
+			}))
+			if iqErr != nil {
+				err = multiErr{err, iqErr}
+			}
+		}()
+	}
 	return h.HandleXMPP(t, start)
 }
 
+func isIQ(name xml.Name) bool {
+	return name.Local == iqStanza && (name.Space == stanza.NSClient || name.Space == stanza.NSServer)
+}
+
+// PanicError is the error passed to the error handler when a handler panics and
+// the mux was configured to recover from panics.
+type PanicError struct {
+	// Value is the value that was passed to panic.
+	Value interface{}
+
+	// Stack is a stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error satisfies the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("mux: handler panicked: %v", e.Value)
+}
+
 // ForItems implements items.Iter for the mux by iterating over all child items.
 func (m *ServeMux) ForItems(node string, f func(items.Item) error) error {
 	for _, h := range m.patterns {
@@ -250,6 +370,12 @@ func (m *ServeMux) ForItems(node string, f func(items.Item) error) error {
 			}
 		}
 	}
+	for _, iter := range m.items {
+		err := iter.ForItems(node, f)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -411,7 +537,7 @@ func (m *ServeMux) iqRouter(t xmlstream.TokenReadEncoder, start *xml.StartElemen
 	if tok != nil && !ok {
 		return fmt.Errorf("xmpp: received IQ with invalid payload of type %T", tok)
 	}
-	h, _ := m.IQHandler(iq.Type, payloadStart.Name)
+	h, _ := m.iqHandler(iq.From, iq.Type, payloadStart.Name)
 	return h.HandleIQ(iq, t, &payloadStart)
 }
 
@@ -441,6 +567,12 @@ func (r *bufReader) Token() (xml.Token, error) {
 // multiple errors that should be turned into a single stanza error.
 type multiErr []error
 
+// Unwrap returns the individual errors so that they can be inspected with
+// errors.Is and errors.As.
+func (e multiErr) Unwrap() []error {
+	return e
+}
+
 func (e multiErr) Error() string {
 	var buf strings.Builder
 	for i, err := range e {
@@ -497,7 +629,7 @@ func forChildren(m *ServeMux, stanzaVal interface{}, t xmlstream.TokenReadEncode
 		switch s := stanzaVal.(type) {
 		case stanza.Presence:
 			br := &bufReader{r: t, buf: r.buf}
-			h, _ := m.PresenceHandler(s.Type, start.Name)
+			h, _ := m.presenceHandler(s.From, s.Type, start.Name)
 			err = h.HandlePresence(s, struct {
 				xml.TokenReader
 				xmlstream.Encoder
@@ -508,7 +640,7 @@ func forChildren(m *ServeMux, stanzaVal interface{}, t xmlstream.TokenReadEncode
 			r.buf = br.buf
 		case stanza.Message:
 			br := &bufReader{r: t, buf: r.buf}
-			h, _ := m.MessageHandler(s.Type, start.Name)
+			h, _ := m.messageHandler(s.From, s.Type, start.Name)
 			err = h.HandleMessage(s, struct {
 				xml.TokenReader
 				xmlstream.Encoder
@@ -534,7 +666,7 @@ func forChildren(m *ServeMux, stanzaVal interface{}, t xmlstream.TokenReadEncode
 		r.offset = 0
 		switch s := stanzaVal.(type) {
 		case stanza.Presence:
-			h, _ := m.PresenceHandler(s.Type, xml.Name{})
+			h, _ := m.presenceHandler(s.From, s.Type, xml.Name{})
 			return h.HandlePresence(s, struct {
 				xml.TokenReader
 				xmlstream.Encoder
@@ -543,7 +675,7 @@ func forChildren(m *ServeMux, stanzaVal interface{}, t xmlstream.TokenReadEncode
 				Encoder:     t,
 			})
 		case stanza.Message:
-			h, _ := m.MessageHandler(s.Type, xml.Name{})
+			h, _ := m.messageHandler(s.From, s.Type, xml.Name{})
 			return h.HandleMessage(s, struct {
 				xml.TokenReader
 				xmlstream.Encoder
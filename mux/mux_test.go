@@ -10,15 +10,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
 
 	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
 	"mellium.im/xmpp/disco/info"
 	"mellium.im/xmpp/disco/items"
 	"mellium.im/xmpp/internal/marshal"
 	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/mux"
 	"mellium.im/xmpp/stanza"
 )
@@ -513,6 +516,87 @@ var testCases = [...]struct {
 		x:   `<iq xml:lang="en-us" type="get" xmlns="jabber:client">  <a/></iq>`,
 		err: errPassTest,
 	},
+	46: {
+		// Handlers restricted to a bare JID take precedence over handlers for any
+		// sender, even with a less specific payload.
+		m: []mux.Option{
+			mux.IQ(stanza.GetIQ, xml.Name{Space: exampleNS, Local: "a"}, failHandler{}),
+			mux.From(jid.MustParse("romeo@example.net"),
+				mux.IQ(stanza.GetIQ, xml.Name{}, passHandler{}),
+			),
+		},
+		x:   `<iq type="get" from="romeo@example.net/orchard" xmlns="jabber:client"><a xmlns="com.example"/></iq>`,
+		err: errPassTest,
+	},
+	47: {
+		// Handlers for a domain match any sender at that domain, but handlers for
+		// the bare JID take precedence.
+		m: []mux.Option{
+			mux.From(jid.MustParse("muc.example.net"),
+				mux.Message(stanza.GroupChatMessage, xml.Name{}, passHandler{}),
+			),
+			mux.From(jid.MustParse("other@muc.example.net"),
+				mux.Message(stanza.GroupChatMessage, xml.Name{}, failHandler{}),
+			),
+			mux.Message(stanza.GroupChatMessage, xml.Name{}, failHandler{}),
+		},
+		x:   `<message type="groupchat" from="room@muc.example.net/nick" xmlns="jabber:client"><body>hi</body></message>`,
+		err: errPassTest,
+	},
+	48: {
+		// Handlers for the full JID take precedence over the bare JID.
+		m: []mux.Option{
+			mux.From(jid.MustParse("juliet@example.com"),
+				mux.Presence(stanza.AvailablePresence, xml.Name{}, failHandler{}),
+			),
+			mux.From(jid.MustParse("juliet@example.com/balcony"),
+				mux.Presence(stanza.AvailablePresence, xml.Name{}, passHandler{}),
+			),
+		},
+		x:   `<presence from="juliet@example.com/balcony" xmlns="jabber:client"/>`,
+		err: errPassTest,
+	},
+	49: {
+		// Stanzas from other senders fall back to handlers for any sender.
+		m: []mux.Option{
+			mux.From(jid.MustParse("example.net"),
+				mux.IQ(stanza.GetIQ, xml.Name{}, failHandler{}),
+			),
+			mux.IQ(stanza.GetIQ, xml.Name{}, passHandler{}),
+		},
+		x:   `<iq type="get" from="juliet@example.com" xmlns="jabber:client"><a/></iq>`,
+		err: errPassTest,
+	},
+	50: {
+		// Registering the same pattern twice for a sender panics.
+		m: []mux.Option{
+			mux.From(jid.MustParse("example.net"), mux.IQ(stanza.GetIQ, xml.Name{}, failHandler{})),
+			mux.From(jid.MustParse("example.net"), mux.IQ(stanza.GetIQ, xml.Name{}, failHandler{})),
+		},
+		expectPanic: true,
+	},
+	51: {
+		// Only stanza handlers can be restricted to a sender.
+		m: []mux.Option{
+			mux.From(jid.MustParse("example.net"), mux.Handle(xml.Name{Local: "a"}, failHandler{})),
+		},
+		expectPanic: true,
+	},
+	52: {
+		// Options that configure the entire mux cannot be restricted to a sender.
+		m: []mux.Option{
+			mux.From(jid.MustParse("example.net"), mux.Recover()),
+		},
+		expectPanic: true,
+	},
+	53: {
+		m: []mux.Option{
+			mux.From(jid.MustParse("example.net"), mux.ErrorHandler(func(error, *xml.StartElement) error {
+				return nil
+			})),
+		},
+		expectPanic: true,
+	},
 }
 
 type nopEncoder struct {
@@ -637,6 +721,92 @@ func TestFallback(t *testing.T) {
 	}
 }
 
+var iqResultTestCases = [...]struct {
+	f   mux.IQResultFunc
+	in  string
+	out string
+	err error
+}{
+	0: {
+		f: func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) (interface{}, error) {
+			return struct {
+				XMLName xml.Name `xml:"com.example test"`
+				Val     string   `xml:"val"`
+			}{Val: "ok"}, nil
+		},
+		in:  `<iq xmlns="jabber:client" type="get" to="romeo@example.com" from="juliet@example.com" id="123"><test xmlns="com.example"/></iq>`,
+		out: `<iq xmlns="jabber:client" type="result" to="juliet@example.com" from="romeo@example.com" id="123"><test xmlns="com.example"><val xmlns="com.example">ok</val></test></iq>`,
+	},
+	1: {
+		f: func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) (interface{}, error) {
+			return nil, nil
+		},
+		in:  `<iq xmlns="jabber:client" type="set" to="romeo@example.com" from="juliet@example.com" id="123"><test xmlns="com.example"/></iq>`,
+		out: `<iq xmlns="jabber:client" type="result" to="juliet@example.com" from="romeo@example.com" id="123"></iq>`,
+	},
+	2: {
+		f: func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) (interface{}, error) {
+			return nil, fmt.Errorf("wrapped: %w", stanza.Error{Type: stanza.Modify, Condition: stanza.BadRequest})
+		},
+		in:  `<iq xmlns="jabber:client" type="get" to="romeo@example.com" from="juliet@example.com" id="123"><test xmlns="com.example"/></iq>`,
+		out: `<iq xmlns="jabber:client" type="error" to="juliet@example.com" from="romeo@example.com" id="123"><error type="modify"><bad-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></bad-request></error></iq>`,
+	},
+	3: {
+		f: func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) (interface{}, error) {
+			return nil, errFailTest
+		},
+		in:  `<iq xmlns="jabber:client" type="get" to="romeo@example.com" from="juliet@example.com" id="123"><test xmlns="com.example"/></iq>`,
+		out: `<iq xmlns="jabber:client" type="error" to="juliet@example.com" from="romeo@example.com" id="123"><error type="cancel"><internal-server-error xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></internal-server-error></error></iq>`,
+		err: errFailTest,
+	},
+	4: {
+		f: func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) (interface{}, error) {
+			return "unused", nil
+		},
+		in: `<iq xmlns="jabber:client" type="result" to="romeo@example.com" from="juliet@example.com" id="123"><test xmlns="com.example"/></iq>`,
+	},
+}
+
+func TestIQResult(t *testing.T) {
+	for i, tc := range iqResultTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			s := xmpptest.NewClientSession(0, struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(tc.in),
+				Writer: buf,
+			})
+			r := s.TokenReader()
+			defer r.Close()
+			tok, err := r.Token()
+			if err != nil {
+				t.Fatalf("bad start token read: %v", err)
+			}
+			start := tok.(xml.StartElement)
+			w := s.TokenWriter()
+			defer w.Close()
+			payload := xml.Name{Space: exampleNS, Local: "test"}
+			m := mux.New(stanza.NSClient,
+				mux.IQResult(stanza.GetIQ, payload, tc.f),
+				mux.IQResult(stanza.SetIQ, payload, tc.f),
+				mux.IQResult(stanza.ResultIQ, payload, tc.f),
+			)
+			err = m.HandleXMPP(testEncoder{TokenReader: r, TokenWriter: w}, &start)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error: want=%v, got=%v", tc.err, err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("error flushing token writer: %v", err)
+			}
+			if out := buf.String(); out != tc.out {
+				t.Errorf("bad output:\nwant=%s\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
 func TestLazyServeMuxMapInitialization(t *testing.T) {
 	m := &mux.ServeMux{}
 
@@ -647,6 +817,162 @@ func TestLazyServeMuxMapInitialization(t *testing.T) {
 	mux.Presence(stanza.SubscribePresence, xml.Name{}, failHandler{})(m)
 }
 
+func TestUse(t *testing.T) {
+	var order []string
+	mw := func(name string) func(xmpp.Handler) xmpp.Handler {
+		return func(next xmpp.Handler) xmpp.Handler {
+			return xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+				order = append(order, name+" "+start.Name.Local)
+				return next.HandleXMPP(t, start)
+			})
+		}
+	}
+	m := mux.New(stanza.NSClient,
+		mux.Use(mw("first")),
+		mux.Message(stanza.NormalMessage, xml.Name{Local: "test"}, passHandler{}),
+		mux.Use(mw("second")),
+	)
+	for _, x := range []string{
+		`<message xmlns="jabber:client" type="normal"><test/></message>`,
+		`<unknown xmlns="com.example"/>`,
+	} {
+		d := xml.NewDecoder(strings.NewReader(x))
+		tok, _ := d.Token()
+		start := tok.(xml.StartElement)
+		err := m.HandleXMPP(nopEncoder{TokenReader: d}, &start)
+		if start.Name.Local == "message" && (err == nil || err.Error() != errPassTest.Error()) {
+			t.Errorf("middleware did not call the registered handler, got error: %v", err)
+		}
+	}
+	want := []string{"first message", "second message", "first unknown", "second unknown"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware called in wrong order: want=%v, got=%v", want, order)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	panicHandler := mux.IQFunc(stanza.GetIQ, xml.Name{}, func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) error {
+		panic("test panic")
+	})
+	panicMsgHandler := mux.MessageFunc(stanza.ChatMessage, xml.Name{}, func(stanza.Message, xmlstream.TokenReadEncoder) error {
+		panic("test panic")
+	})
+	for i, tc := range []struct {
+		in  string
+		out string
+	}{
+		0: {
+			in:  `<iq xmlns="jabber:client" type="get" to="romeo@example.com" from="juliet@example.com" id="123"><test/></iq>`,
+			out: `<iq xmlns="jabber:client" type="error" to="juliet@example.com" from="romeo@example.com" id="123"><error type="cancel"><internal-server-error xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></internal-server-error></error></iq>`,
+		},
+		1: {
+			in: `<message xmlns="jabber:client" type="chat" from="juliet@example.com" id="123"><body>hi</body></message>`,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			s := xmpptest.NewClientSession(0, struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(tc.in),
+				Writer: buf,
+			})
+			r := s.TokenReader()
+			defer r.Close()
+			tok, err := r.Token()
+			if err != nil {
+				t.Fatalf("bad start token read: %v", err)
+			}
+			start := tok.(xml.StartElement)
+			w := s.TokenWriter()
+			defer w.Close()
+
+			var handled error
+			m := mux.New(stanza.NSClient,
+				mux.Recover(),
+				mux.ErrorHandler(func(err error, _ *xml.StartElement) error {
+					handled = err
+					return nil
+				}),
+				panicHandler,
+				panicMsgHandler,
+			)
+			err = m.HandleXMPP(testEncoder{TokenReader: r, TokenWriter: w}, &start)
+			if err != nil {
+				t.Errorf("error handler did not replace error: %v", err)
+			}
+			var panicErr *mux.PanicError
+			if !errors.As(handled, &panicErr) || panicErr.Value != "test panic" {
+				t.Errorf("wrong error passed to error handler: %v", handled)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("error flushing token writer: %v", err)
+			}
+			if out := buf.String(); out != tc.out {
+				t.Errorf("bad output:\nwant=%s\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestRecoverDefault(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(`<iq xmlns="jabber:client" type="get" id="123"><ping xmlns="urn:xmpp:ping"/></iq>`),
+		Writer: buf,
+	})
+	r := s.TokenReader()
+	defer r.Close()
+	tok, err := r.Token()
+	if err != nil {
+		t.Fatalf("bad start token read: %v", err)
+	}
+	start := tok.(xml.StartElement)
+	w := s.TokenWriter()
+	defer w.Close()
+
+	// Without an error handler the recovered panic does not end the session.
+	m := mux.New(stanza.NSClient,
+		mux.Recover(),
+		mux.IQFunc(stanza.GetIQ, xml.Name{Space: "urn:xmpp:ping", Local: "ping"}, func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) error {
+			panic("test panic")
+		}),
+	)
+	err = m.HandleXMPP(testEncoder{TokenReader: r, TokenWriter: w}, &start)
+	if err != nil {
+		t.Errorf("unexpected error after recovering: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("error flushing token writer: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "internal-server-error") {
+		t.Errorf("expected an internal-server-error response, got: %s", out)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	m := mux.New(stanza.NSClient,
+		mux.Message(stanza.NormalMessage, xml.Name{}, failHandler{}),
+		mux.ErrorHandler(func(err error, start *xml.StartElement) error {
+			if !errors.Is(err, errFailTest) {
+				t.Errorf("wrong error: want=%v, got=%v", errFailTest, err)
+			}
+			return errPassTest
+		}),
+	)
+	d := xml.NewDecoder(strings.NewReader(`<message xmlns="jabber:client" type="normal"><a/><b/></message>`))
+	tok, _ := d.Token()
+	start := tok.(xml.StartElement)
+	err := m.HandleXMPP(nopEncoder{TokenReader: d}, &start)
+	if err != errPassTest {
+		t.Errorf("error handler result not returned, got: %v", err)
+	}
+}
+
 const (
 	testFeature         = "urn:example"
 	iqTestFeature       = "urn:example:iq"
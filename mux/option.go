@@ -9,6 +9,8 @@ import (
 
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/disco/info"
+	"mellium.im/xmpp/disco/items"
+	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -39,6 +41,13 @@ func IQFunc(typ stanza.IQType, payload xml.Name, h IQHandlerFunc) Option {
 	return IQ(typ, payload, h)
 }
 
+// IQResult returns an option that matches IQ stanzas and responds to them with
+// the result of calling f.
+// For more information see IQ and IQResultFunc.
+func IQResult(typ stanza.IQType, payload xml.Name, f IQResultFunc) Option {
+	return IQ(typ, payload, f)
+}
+
 // Message returns an option that matches message stanzas by type.
 func Message(typ stanza.MessageType, payload xml.Name, h MessageHandler) Option {
 	return func(m *ServeMux) {
@@ -85,6 +94,65 @@ func PresenceFunc(typ stanza.PresenceType, payload xml.Name, h PresenceHandlerFu
 	return Presence(typ, payload, h)
 }
 
+// From returns an option that registers the stanza handlers from the provided
+// options so that they only match stanzas sent by from.
+// If from is a domain, stanzas sent by any entity at that domain are matched.
+// If it is a bare JID, stanzas sent by any resource of that JID are matched.
+//
+// This is useful for routing all stanzas from a peer, such as a multi-user chat
+// service or the users of a legacy network behind a gateway, to a single
+// handler.
+// For the precedence rules used when several handlers match a stanza see
+// ServeMux.
+// If any of the options register a handler that is not for a stanza, or
+// configure the entire mux (such as Use, ErrorHandler, and Recover), the option
+// panics.
+func From(from jid.JID, opt ...Option) Option {
+	return func(m *ServeMux) {
+		sub := &ServeMux{}
+		for _, o := range opt {
+			o(sub)
+		}
+		if len(sub.patterns) > 0 || len(sub.middleware) > 0 || sub.errHandler != nil || sub.recover {
+			panic("mux: only stanza handlers can be restricted to a sender")
+		}
+		sender := from.String()
+		for pat, h := range sub.iqPatterns {
+			pat.From = sender
+			if _, ok := m.iqPatterns[pat]; ok {
+				panic("mux: multiple registrations for " + pat.String())
+			}
+			if m.iqPatterns == nil {
+				m.iqPatterns = make(map[pattern]IQHandler)
+			}
+			m.iqPatterns[pat] = h
+		}
+		for pat, h := range sub.msgPatterns {
+			pat.From = sender
+			if _, ok := m.msgPatterns[pat]; ok {
+				panic("mux: multiple registrations for " + pat.String())
+			}
+			if m.msgPatterns == nil {
+				m.msgPatterns = make(map[pattern]MessageHandler)
+			}
+			m.msgPatterns[pat] = h
+		}
+		for pat, h := range sub.presencePatterns {
+			pat.From = sender
+			if _, ok := m.presencePatterns[pat]; ok {
+				panic("mux: multiple registrations for " + pat.String())
+			}
+			if m.presencePatterns == nil {
+				m.presencePatterns = make(map[pattern]PresenceHandler)
+			}
+			m.presencePatterns[pat] = h
+		}
+		m.features = append(m.features, sub.features...)
+		m.idents = append(m.idents, sub.idents...)
+		m.items = append(m.items, sub.items...)
+	}
+}
+
 // Feature registers the provided features for service discovery.
 //
 // Most features will be implemented by Handlers and do not need to be
@@ -113,6 +181,68 @@ func Ident(iter info.IdentityIter) Option {
 	}
 }
 
+// Items registers the provided items for service discovery.
+//
+// Most items will be implemented by Handlers and do not need to be registered
+// again, Items is just for items that should be advertised but do not have any
+// corresponding handler.
+func Items(iter items.Iter) Option {
+	if iter == nil {
+		panic("mux: nil items.Iter")
+	}
+	return func(m *ServeMux) {
+		m.items = append(m.items, iter)
+	}
+}
+
+// Use returns an option that wraps the handler chosen for every top level
+// element handled by the mux, including elements for which no handler was
+// registered, in the provided middleware.
+// This can be used for concerns that apply to all handlers such as logging,
+// metrics, or recovering from panics.
+//
+// Middleware is applied in the order it is registered, so the first middleware
+// registered is the outermost and sees each element first.
+func Use(mw func(next xmpp.Handler) xmpp.Handler) Option {
+	if mw == nil {
+		panic("mux: nil middleware")
+	}
+	return func(m *ServeMux) {
+		m.middleware = append(m.middleware, mw)
+	}
+}
+
+// ErrorHandler returns an option that calls f with any error returned while
+// handling a top level element, including errors returned by handlers for any
+// of the payloads of a message or presence and panics recovered because of the
+// Recover option.
+// The error returned by f is returned from the mux in place of the original
+// error.
+// Because returning an error from a handler normally ends the session, f can
+// return nil to log errors and keep the session running.
+func ErrorHandler(f func(err error, start *xml.StartElement) error) Option {
+	if f == nil {
+		panic("mux: nil error handler")
+	}
+	return func(m *ServeMux) {
+		m.errHandler = f
+	}
+}
+
+// Recover returns an option that recovers from panics in handlers and
+// middleware so that a single misbehaving handler does not end the session.
+// If the panic occurred while handling an IQ of type get or set, an
+// internal-server-error is sent in response.
+//
+// If an ErrorHandler is also used, the panic is passed to it as an error of
+// type *PanicError and its result is returned from the mux as usual.
+// Otherwise the panic is discarded and the mux returns nil.
+func Recover() Option {
+	return func(m *ServeMux) {
+		m.recover = true
+	}
+}
+
 // Handle returns an option that matches on the provided XML name.
 // If a handler already exists for n when the option is applied, the option
 // panics.
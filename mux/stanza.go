@@ -6,8 +6,10 @@ package mux
 
 import (
 	"encoding/xml"
+	"errors"
 
 	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/marshal"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -27,6 +29,50 @@ func (f IQHandlerFunc) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, star
 	return f(iq, t, start)
 }
 
+// The IQResultFunc type is an adapter to allow the use of ordinary functions
+// that return the payload of a response as IQ handlers.
+// If f is a function with the appropriate signature, IQResultFunc(f) is an
+// IQHandler that calls f and sends the response to the entity that sent the
+// IQ.
+//
+// If f returns a nil error, the value it returns is marshaled and sent as the
+// payload of a result IQ, or an empty result IQ is sent if the value is nil.
+// If f returns an error that is or wraps a stanza.Error, the stanza error is
+// sent in an error IQ and HandleIQ returns nil.
+// Any other error, including an error marshaling the value, is returned from
+// HandleIQ after sending an internal-server-error.
+// Responses are only sent for IQs of type get or set.
+type IQResultFunc func(stanza.IQ, xmlstream.TokenReadEncoder, *xml.StartElement) (interface{}, error)
+
+// HandleIQ calls f(iq, t, start) and writes the response.
+func (f IQResultFunc) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	v, err := f(iq, t, start)
+	if iq.Type != stanza.GetIQ && iq.Type != stanza.SetIQ {
+		return err
+	}
+	var payload xml.TokenReader
+	if err == nil && v != nil {
+		payload, err = marshal.TokenReader(v)
+	}
+	if err != nil {
+		var stanzaErr stanza.Error
+		if errors.As(err, &stanzaErr) {
+			_, err = xmlstream.Copy(t, iq.Error(stanzaErr))
+			return err
+		}
+		_, copyErr := xmlstream.Copy(t, iq.Error(stanza.Error{
+			Type:      stanza.Cancel,
+			Condition: stanza.InternalServerError,
+		}))
+		if copyErr != nil {
+			return multiErr{err, copyErr}
+		}
+		return err
+	}
+	_, err = xmlstream.Copy(t, iq.Result(payload))
+	return err
+}
+
 // MessageHandler responds to message stanzas.
 type MessageHandler interface {
 	HandleMessage(stanza.Message, xmlstream.TokenReadEncoder) error
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 
 	"mellium.im/xmpp/internal/attr"
 	intstream "mellium.im/xmpp/internal/stream"
@@ -51,6 +52,44 @@ type StreamConfig struct {
 	// since this bypasses TLS and could expose passwords and other sensitive
 	// data.
 	TeeIn, TeeOut io.Writer
+
+	// If set, Logger receives each complete element sent or received on the
+	// session, including those used during stream negotiation.
+	// Like TeeIn and TeeOut this bypasses TLS and logs passwords and other
+	// sensitive data.
+	Logger XMLLogger
+
+	// If set, writes to the session are buffered and sent to the underlying
+	// connection together once enough data has been written or a short delay
+	// has passed.
+	// Coalescing is not used on WebSocket connections where each write must
+	// contain a complete stanza.
+	Coalesce *WriteCoalescing
+
+	// If set, FeatureHook is called for each stream feature advertised by the
+	// remote entity that is not handled by one of the stream features in
+	// Features.
+	// This lets applications react to features that do not need to be
+	// negotiated (eg. roster versioning) without implementing a StreamFeature.
+	// If FeatureHook returns an error negotiation is aborted.
+	// The feature is also available from the sessions Feature method after it
+	// has been advertised, regardless of whether FeatureHook is set.
+	FeatureHook func(ctx context.Context, s *Session, f RawFeature) error
+
+	// If OriginID is set, outgoing messages that do not already have an origin
+	// ID are given one containing a random UUID.
+	// Origin IDs let the sender recognize its own messages when they are
+	// reflected by a multi-user chat or returned from an archive, even if the
+	// "id" attribute was changed.
+	OriginID bool
+
+	// By default when receiving a stream, features with a recommended order
+	// (STARTTLS, then SASL, then stream compression, then resource binding) are
+	// advertised and negotiated in that order regardless of their position in
+	// Features and listing the same feature twice is an error.
+	// Features without a recommended order keep their position.
+	// If KeepFeatureOrder is set, Features is used exactly as provided.
+	KeepFeatureOrder bool
 }
 
 // NewNegotiator creates a Negotiator that uses a collection of StreamFeatures
@@ -81,9 +120,23 @@ func NewNegotiator(cfg func(*Session, *StreamConfig) StreamConfig) Negotiator {
 	return negotiator(cfg)
 }
 
+func isCoalesced(c net.Conn) bool {
+	if tc, ok := c.(teeConn); ok {
+		c = tc.Conn
+	}
+	_, ok := c.(*coalesceConn)
+	return ok
+}
+
 type negotiatorState struct {
 	doRestart bool
 	cancelTee context.CancelFunc
+	coalesce  *coalesceConn
+
+	// Whether a features list has already been negotiated.
+	// This can't be determined by whether data is nil because wrapping the
+	// connection (eg. with a teeConn or coalesceConn) also returns state.
+	negotiated bool
 }
 
 func negotiator(f func(*Session, *StreamConfig) StreamConfig) Negotiator {
@@ -97,6 +150,9 @@ func negotiator(f func(*Session, *StreamConfig) StreamConfig) Negotiator {
 				doRestart: true,
 				cancelTee: nil,
 			}
+			if cfg.OriginID {
+				s.AddOutgoingFilter(stanza.InsertOriginID(""))
+			}
 		}
 
 		// This is a secret internal API that lets us use this same negotiator
@@ -105,8 +161,34 @@ func negotiator(f func(*Session, *StreamConfig) StreamConfig) Negotiator {
 		// For more information see the internal/wskey package.
 		wsCtx := ctx.Value(wskey.Key{})
 		websocket := wsCtx != nil
+		if websocket {
+			// Make sure that stream level elements such as <close/> are handled once
+			// the session is established.
+			s.ws = true
+		}
+
+		if cfg.Logger != nil && s.logger == nil {
+			s.logger = cfg.Logger
+			s.wrapLogger()
+		}
 
 		c := s.Conn()
+		// If the session is not already coalescing writes but we're configured to,
+		// return a new coalesceConn and don't set any state bits.
+		// If the connection is also being tee'd, the tee wraps the coalesceConn.
+		if cfg.Coalesce != nil && !websocket && !isCoalesced(c) {
+			// Stop any previous coalesceConn's (eg. underneath a newly negotiated
+			// TLS layer) so that writes are not delayed twice.
+			if nState.coalesce != nil {
+				err = nState.coalesce.stop()
+				if err != nil {
+					return mask, nil, nState, err
+				}
+			}
+			nState.coalesce = newCoalesceConn(c, *cfg.Coalesce)
+			return mask, nState.coalesce, nState, err
+		}
+
 		// If the session is not already using a tee conn, but we're configured to
 		// use one, return the new teeConn and don't set any state bits.
 		if _, ok := c.(teeConn); !ok && (cfg.TeeIn != nil || cfg.TeeOut != nil) {
@@ -204,8 +286,17 @@ func negotiator(f func(*Session, *StreamConfig) StreamConfig) Negotiator {
 		}
 
 		cfg = f(s, &cfg)
-		mask, rw, err = negotiateFeatures(ctx, s, data == nil, websocket, cfg.Features)
+		features := cfg.Features
+		if s.State()&Received == Received && !cfg.KeepFeatureOrder {
+			features, err = orderFeatures(features)
+			if err != nil {
+				nState.doRestart = false
+				return mask, nil, nState, err
+			}
+		}
+		mask, rw, err = negotiateFeatures(ctx, s, !nState.negotiated, websocket, features, cfg.FeatureHook)
 		nState.doRestart = rw != nil
+		nState.negotiated = true
 		return mask, rw, nState, err
 	}
 }
@@ -0,0 +1,105 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package nickname
+
+import (
+	"encoding/xml"
+	"io"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/muc"
+	"mellium.im/xmpp/stanza"
+)
+
+// Insert adds a nickname into any element read through the transformer at the
+// current nesting level.
+//
+// For example, to include a nickname in a presence subscription request:
+//
+//	nickname.Insert("Romeo")(stanza.Presence{
+//		To:   j,
+//		Type: stanza.SubscribePresence,
+//	}.Wrap(nil))
+func Insert(name string) xmlstream.Transformer {
+	return xmlstream.InsertFunc(func(start xml.StartElement, level uint64, w xmlstream.TokenWriter) error {
+		if level != 1 {
+			return nil
+		}
+		_, err := Nick{Name: name}.WriteXML(w)
+		return err
+	})
+}
+
+// Filter returns a transformer that adds the nickname to presence subscription
+// requests and to presence used to join multi-user chat rooms.
+// It is meant to be used with the session's AddOutgoingFilter method so that
+// nicknames are added to stanzas sent by other packages such as muc.
+//
+// Stanzas that already contain a nickname are not modified.
+func Filter(name string) xmlstream.Transformer {
+	return func(r xml.TokenReader) xml.TokenReader {
+		var buf []xml.Token
+		var err error
+		return xmlstream.ReaderFunc(func() (xml.Token, error) {
+			if buf == nil && err == nil {
+				buf, err = addNick(r, name)
+			}
+			if len(buf) == 0 {
+				if err == nil {
+					err = io.EOF
+				}
+				return nil, err
+			}
+			tok := buf[0]
+			buf = buf[1:]
+			return tok, nil
+		})
+	}
+}
+
+// addNick buffers a single stanza and inserts the nickname if required.
+func addNick(r xml.TokenReader, name string) ([]xml.Token, error) {
+	toks, err := xmlstream.ReadAll(r)
+	if err != nil || len(toks) < 2 {
+		return toks, err
+	}
+	start, ok := toks[0].(xml.StartElement)
+	if !ok || start.Name.Local != "presence" {
+		return toks, nil
+	}
+	_, typ := attr.Get(start.Attr, "type")
+	insert := typ == string(stanza.SubscribePresence)
+	depth := 0
+	for _, tok := range toks {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth != 2 {
+				continue
+			}
+			switch t.Name {
+			case xml.Name{Space: NS, Local: "nick"}:
+				return toks, nil
+			case xml.Name{Space: muc.NS, Local: "x"}:
+				insert = insert || typ == ""
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if !insert {
+		return toks, nil
+	}
+	nick, err := xmlstream.ReadAll(Nick{Name: name}.TokenReader())
+	if err != nil {
+		return nil, err
+	}
+	end := len(toks) - 1
+	out := make([]xml.Token, 0, len(toks)+len(nick))
+	out = append(out, toks[:end]...)
+	out = append(out, nick...)
+	return append(out, toks[end]), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h Handler" -vars "FeatureNotify:NSNotify"
+
+// Package nickname implements publishing and retrieving user nicknames.
+//
+// Nicknames are stored in a personal eventing (PEP) node and may also be
+// included in presence subscription requests and other stanzas as described
+// in XEP-0172: User Nickname.
+package nickname // import "mellium.im/xmpp/nickname"
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+// Namespaces used by this package.
+const (
+	NS       = "http://jabber.org/protocol/nick"
+	NSNotify = "http://jabber.org/protocol/nick+notify"
+)
+
+// ItemID is the ID of the pubsub item containing the current nickname.
+const ItemID = "current"
+
+// Nick is a nickname element.
+type Nick struct {
+	XMLName xml.Name `xml:"http://jabber.org/protocol/nick nick"`
+	Name    string   `xml:",chardata"`
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (n Nick) TokenReader() xml.TokenReader {
+	var inner xml.TokenReader
+	if n.Name != "" {
+		inner = xmlstream.Token(xml.CharData(n.Name))
+	}
+	return xmlstream.Wrap(
+		inner,
+		xml.StartElement{Name: xml.Name{Space: NS, Local: "nick"}},
+	)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (n Nick) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, n.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (n Nick) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := n.WriteXML(e)
+	return err
+}
+
+// Publish sets the user's nickname, notifying subscribers of the change.
+// Publishing an empty nickname indicates that the user no longer wants to use
+// a nickname.
+func Publish(ctx context.Context, s *xmpp.Session, name string) error {
+	return PublishIQ(ctx, s, stanza.IQ{}, name)
+}
+
+// PublishIQ is like Publish except that it allows modifying the IQ.
+// Changes to the IQ type will have no effect.
+func PublishIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, name string) error {
+	iq.Type = stanza.SetIQ
+	_, err := pubsub.PublishIQ(ctx, s, iq, NS, ItemID, Nick{Name: name}.TokenReader())
+	return err
+}
+
+// Fetch requests the nickname of the given user.
+// If the user has not published a nickname, the empty string is returned.
+func Fetch(ctx context.Context, s *xmpp.Session, j jid.JID) (string, error) {
+	return FetchIQ(ctx, stanza.IQ{To: j}, s)
+}
+
+// FetchIQ is like Fetch except that it allows modifying the IQ.
+// The IQ should be addressed to the bare JID of the user.
+// Changing the type of the provided IQ has no effect.
+func FetchIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session) (string, error) {
+	iter := pubsub.FetchIQ(ctx, iq, s, pubsub.Query{
+		Node:     NS,
+		MaxItems: 1,
+	})
+	/* #nosec */
+	defer iter.Close()
+	var n Nick
+	if iter.Next() {
+		_, r := iter.Item()
+		err := xml.NewTokenDecoder(r).Decode(&n)
+		if err != nil {
+			return "", err
+		}
+	}
+	return n.Name, iter.Err()
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package nickname_test
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/nickname"
+	"mellium.im/xmpp/stanza"
+)
+
+func encode(t *testing.T, r xml.TokenReader) string {
+	t.Helper()
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	_, err := xmlstream.Copy(e, r)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	if err = e.Flush(); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	return buf.String()
+}
+
+func TestInsert(t *testing.T) {
+	out := encode(t, nickname.Insert("Romeo")(stanza.Presence{
+		Type: stanza.SubscribePresence,
+	}.Wrap(nil)))
+	const want = `<presence type="subscribe"><nick xmlns="http://jabber.org/protocol/nick">Romeo</nick></presence>`
+	if out != want {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", want, out)
+	}
+}
+
+var filterTestCases = [...]struct {
+	in  string
+	out string
+}{
+	0: {
+		in:  `<presence type="subscribe" to="juliet@example.com"></presence>`,
+		out: `<presence type="subscribe" to="juliet@example.com"><nick xmlns="http://jabber.org/protocol/nick">Romeo</nick></presence>`,
+	},
+	1: {
+		in:  `<presence to="room@muc.example.com/Romeo"><x xmlns="http://jabber.org/protocol/muc"></x></presence>`,
+		out: `<presence to="room@muc.example.com/Romeo"><x xmlns="http://jabber.org/protocol/muc"></x><nick xmlns="http://jabber.org/protocol/nick">Romeo</nick></presence>`,
+	},
+	2: {
+		// Regular presence is not modified.
+		in:  `<presence><show>away</show></presence>`,
+		out: `<presence><show>away</show></presence>`,
+	},
+	3: {
+		in:  `<presence type="unavailable" to="room@muc.example.com/Romeo"><x xmlns="http://jabber.org/protocol/muc"></x></presence>`,
+		out: `<presence type="unavailable" to="room@muc.example.com/Romeo"><x xmlns="http://jabber.org/protocol/muc"></x></presence>`,
+	},
+	4: {
+		// Existing nicknames are not replaced.
+		in:  `<presence type="subscribe"><nick xmlns="http://jabber.org/protocol/nick">Montague</nick></presence>`,
+		out: `<presence type="subscribe"><nick xmlns="http://jabber.org/protocol/nick">Montague</nick></presence>`,
+	},
+	5: {
+		in:  `<message type="chat"><body>Hi</body></message>`,
+		out: `<message type="chat"><body>Hi</body></message>`,
+	},
+}
+
+func TestFilter(t *testing.T) {
+	for i, tc := range filterTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			d := xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(xml.NewDecoder(strings.NewReader(tc.in)))
+			out := encode(t, nickname.Filter("Romeo")(d))
+			if out != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	s := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		_, err = xmlstream.Copy(r, iq.Result(xml.NewDecoder(strings.NewReader(`<pubsub xmlns="http://jabber.org/protocol/pubsub"><items node="http://jabber.org/protocol/nick"><item id="current"><nick xmlns="http://jabber.org/protocol/nick">Juliet</nick></item></items></pubsub>`))))
+		return err
+	}))
+	name, err := nickname.Fetch(context.Background(), s.Client, s.Server.LocalAddr())
+	if err != nil {
+		t.Fatalf("error fetching nickname: %v", err)
+	}
+	if name != "Juliet" {
+		t.Errorf("wrong nickname: want=Juliet, got=%s", name)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	got := make(chan string, 1)
+	s := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		var buf strings.Builder
+		e := xml.NewEncoder(&buf)
+		_, err = xmlstream.Copy(e, xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+			return attr.Name.Local == "xmlns"
+		})(xmlstream.Inner(r)))
+		if err != nil {
+			return err
+		}
+		if err = e.Flush(); err != nil {
+			return err
+		}
+		got <- buf.String()
+		_, err = xmlstream.Copy(r, iq.Result(nil))
+		return err
+	}))
+	err := nickname.Publish(context.Background(), s.Client, "Romeo")
+	if err != nil {
+		t.Fatalf("error publishing nickname: %v", err)
+	}
+	const want = `<pubsub xmlns="http://jabber.org/protocol/pubsub"><publish xmlns="http://jabber.org/protocol/pubsub" node="http://jabber.org/protocol/nick"><item xmlns="http://jabber.org/protocol/pubsub" id="current"><nick xmlns="http://jabber.org/protocol/nick">Romeo</nick></item></publish></pubsub>`
+	if req := <-got; req != want {
+		t.Errorf("wrong request:\nwant=%s,\n got=%s", want, req)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	const msg = `<message xmlns="jabber:client" from="juliet@example.com/balcony" type="headline"><event xmlns="http://jabber.org/protocol/pubsub#event"><items node="http://jabber.org/protocol/nick"><item id="current"><nick xmlns="http://jabber.org/protocol/nick">Jules</nick></item><retract id="old"/></items></event></message>`
+	var got []string
+	m := mux.New(stanza.NSClient, nickname.Handle(nickname.Handler{
+		Update: func(from jid.JID, name string) error {
+			got = append(got, from.String()+":"+name)
+			return nil
+		},
+	}))
+	d := xml.NewDecoder(strings.NewReader(msg))
+	tok, err := d.Token()
+	if err != nil {
+		t.Fatalf("error popping start token: %v", err)
+	}
+	start := tok.(xml.StartElement)
+	err = m.HandleXMPP(struct {
+		xml.TokenReader
+		xmlstream.Encoder
+	}{
+		TokenReader: d,
+	}, &start)
+	if err != nil {
+		t.Fatalf("error handling event: %v", err)
+	}
+	const want = "juliet@example.com:Jules,juliet@example.com:"
+	if s := strings.Join(got, ","); s != want {
+		t.Errorf("wrong updates: want=%s, got=%s", want, s)
+	}
+}
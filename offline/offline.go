@@ -0,0 +1,117 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature
+//go:generate go run -tags=tools golang.org/x/tools/cmd/stringer -type=Action -linecomment
+
+// Package offline implements the rules for handling messages sent to users
+// that have no available resources.
+//
+// The rules are taken from RFC 6121 §8.5.2, XEP-0160: Best Practices for
+// Handling Offline Messages, and XEP-0334: Message Processing Hints and are
+// meant to be shared by servers that store messages offline and components
+// that need to decide what to do with a message when the recipient cannot be
+// reached.
+package offline // import "mellium.im/xmpp/offline"
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/stanza"
+)
+
+// NS is the service discovery feature advertised by servers that support
+// storing messages offline.
+const NS = "msgoffline"
+
+const (
+	nsHints      = "urn:xmpp:hints"
+	nsChatStates = "http://jabber.org/protocol/chatstates"
+)
+
+// Action is the action that should be taken with a message that cannot be
+// delivered because the recipient has no available resources.
+type Action uint8
+
+// A list of possible actions.
+const (
+	// Store indicates that the message should be stored and delivered when the
+	// recipient next becomes available.
+	Store Action = iota // store
+
+	// Bounce indicates that the message should not be stored and that an error
+	// should be returned to the sender (see Bounce).
+	Bounce // bounce
+
+	// Discard indicates that the message should not be stored and should be
+	// silently ignored.
+	Discard // discard
+)
+
+// Classify determines what to do with the message starting with start if it
+// is addressed to a user that has no available resources.
+// r should be positioned just after start, and is read until the end of the
+// message.
+//
+// Messages of type chat or normal are stored unless they contain a no-store
+// hint or have no payload other than chat state notifications, in which case
+// they are discarded.
+// A store hint always results in the message being stored.
+// Messages of type groupchat are bounced, and messages of type headline or
+// error are discarded.
+func Classify(start xml.StartElement, r xml.TokenReader) (Action, error) {
+	_, typ := attr.Get(start.Attr, "type")
+	switch stanza.MessageType(typ) {
+	case stanza.GroupChatMessage:
+		return Bounce, nil
+	case stanza.HeadlineMessage, stanza.ErrorMessage:
+		return Discard, nil
+	case "", stanza.NormalMessage, stanza.ChatMessage:
+	default:
+		// Unknown message types are treated as normal, see RFC 6121 §5.2.2.
+	}
+
+	var noStore, storeHint, onlyChatState bool
+	onlyChatState = true
+	iter := xmlstream.NewIter(r)
+	for iter.Next() {
+		child, _ := iter.Current()
+		switch {
+		case child.Name.Space == nsHints && child.Name.Local == "store":
+			storeHint = true
+		case child.Name.Space == nsHints && child.Name.Local == "no-store":
+			noStore = true
+		case child.Name.Space == nsHints, child.Name.Space == nsChatStates:
+		case child.Name.Local == "thread":
+		default:
+			onlyChatState = false
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return Discard, err
+	}
+
+	switch {
+	case storeHint:
+		return Store, nil
+	case noStore, onlyChatState:
+		return Discard, nil
+	}
+	return Store, nil
+}
+
+// Error is the error that is returned to the sender of a message that has
+// been bounced.
+var Error = stanza.Error{
+	Type:      stanza.Cancel,
+	Condition: stanza.ServiceUnavailable,
+}
+
+// BounceMessage returns an error reply to msg that indicates that it could not
+// be delivered or stored.
+func BounceMessage(msg stanza.Message) xml.TokenReader {
+	return msg.Error(Error)
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package offline_test
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/offline"
+	"mellium.im/xmpp/stanza"
+)
+
+var classifyTests = [...]struct {
+	in     string
+	action offline.Action
+}{
+	0:  {in: `<message type="chat"><body>foo</body></message>`, action: offline.Store},
+	1:  {in: `<message><body>foo</body></message>`, action: offline.Store},
+	2:  {in: `<message type="normal"><x xmlns="jabber:x:oob"/></message>`, action: offline.Store},
+	3:  {in: `<message type="groupchat"><body>foo</body></message>`, action: offline.Bounce},
+	4:  {in: `<message type="headline"><body>foo</body></message>`, action: offline.Discard},
+	5:  {in: `<message type="error"><body>foo</body></message>`, action: offline.Discard},
+	6:  {in: `<message type="chat"><composing xmlns="http://jabber.org/protocol/chatstates"/><thread>123</thread></message>`, action: offline.Discard},
+	7:  {in: `<message type="chat"><body>foo</body><no-store xmlns="urn:xmpp:hints"/></message>`, action: offline.Discard},
+	8:  {in: `<message type="chat"><active xmlns="http://jabber.org/protocol/chatstates"/><store xmlns="urn:xmpp:hints"/></message>`, action: offline.Store},
+	9:  {in: `<message type="chat"><body>foo</body><no-permanent-store xmlns="urn:xmpp:hints"/></message>`, action: offline.Store},
+	10: {in: `<message type="chat"></message>`, action: offline.Discard},
+	11: {in: `<message type="unknown"><body>foo</body></message>`, action: offline.Store},
+}
+
+func TestClassify(t *testing.T) {
+	for i, tc := range classifyTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			d := xml.NewDecoder(strings.NewReader(tc.in))
+			tok, err := d.Token()
+			if err != nil {
+				t.Fatalf("error popping start token: %v", err)
+			}
+			action, err := offline.Classify(tok.(xml.StartElement), d)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if action != tc.action {
+				t.Errorf("wrong action: want=%v, got=%v", tc.action, action)
+			}
+		})
+	}
+}
+
+func TestBounceMessage(t *testing.T) {
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	_, err := xmlstream.Copy(e, offline.BounceMessage(stanza.Message{
+		ID:   "123",
+		To:   jid.MustParse("juliet@example.com"),
+		From: jid.MustParse("romeo@example.net/garden"),
+		Type: stanza.GroupChatMessage,
+	}))
+	if err != nil {
+		t.Fatalf("error encoding bounce: %v", err)
+	}
+	if err = e.Flush(); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	const expected = `<message type="error" to="romeo@example.net/garden" from="juliet@example.com" id="123"><error type="cancel"><service-unavailable xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></service-unavailable></error></message>`
+	if s := buf.String(); s != expected {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", expected, s)
+	}
+}
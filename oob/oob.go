@@ -8,9 +8,12 @@
 package oob // import "mellium.im/xmpp/oob"
 
 import (
+	"context"
 	"encoding/xml"
 
 	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/schema"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -20,6 +23,14 @@ const (
 	NSQuery = `jabber:iq:oob`
 )
 
+func init() {
+	stanza.RegisterExtension(xml.Name{Space: NS, Local: "x"}, func() interface{} { return &Data{} })
+	stanza.RegisterExtension(xml.Name{Space: NSQuery, Local: "query"}, func() interface{} { return &Query{} })
+	url := schema.Shape{Name: xml.Name{Local: "url"}, Text: true}
+	schema.Register(schema.Shape{Name: xml.Name{Space: NS, Local: "x"}, Children: []schema.Shape{url}})
+	schema.Register(schema.Shape{Name: xml.Name{Space: NSQuery, Local: "query"}, Children: []schema.Shape{url}})
+}
+
 // IQ represents an OOB data query; for instance:
 //
 //	<iq type='set'
@@ -86,6 +97,24 @@ func (d Data) TokenReader() xml.TokenReader {
 	return xmlstream.Wrap(getPayload(d.URL, d.Desc), start)
 }
 
+// Message returns msg with the URL as its body, for clients that do not support
+// out of band data, followed by d.
+func (d Data) Message(msg stanza.Message) xml.TokenReader {
+	return msg.Wrap(xmlstream.MultiReader(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(d.URL)),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		),
+		d.TokenReader(),
+	))
+}
+
+// Send sends msg with d attached and the URL in the body.
+// For more information see Data.Message.
+func Send(ctx context.Context, s *xmpp.Session, msg stanza.Message, d Data) error {
+	return s.Send(ctx, d.Message(msg))
+}
+
 func getPayload(url, desc string) xml.TokenReader {
 	// Create the payload and add the URL element.
 	payload := xmlstream.Wrap(xmlstream.Token(xml.CharData(url)), xml.StartElement{
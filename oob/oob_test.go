@@ -6,12 +6,14 @@ package oob_test
 
 import (
 	"encoding/xml"
+	"errors"
 	"strings"
 	"testing"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/oob"
+	"mellium.im/xmpp/schema"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -136,3 +138,41 @@ func TestEncodeQuery(t *testing.T) {
 		}
 	})
 }
+
+func TestMessage(t *testing.T) {
+	data := oob.Data{
+		URL:  "https://example.net/a.png",
+		Desc: "A picture",
+	}
+	var b strings.Builder
+	e := xml.NewEncoder(&b)
+	_, err := xmlstream.Copy(e, data.Message(stanza.Message{
+		To:   jid.MustParse("feste@example.net"),
+		Type: stanza.ChatMessage,
+	}))
+	if err != nil {
+		t.Fatalf("error encoding message: %v", err)
+	}
+	err = e.Flush()
+	if err != nil {
+		t.Fatalf("error flushing token stream: %v", err)
+	}
+	const expected = `<message type="chat" to="feste@example.net"><body>https://example.net/a.png</body><x xmlns="jabber:x:oob"><url>https://example.net/a.png</url><desc>A picture</desc></x></message>`
+	if out := b.String(); out != expected {
+		t.Errorf("wrong encoding:\nwant=%s,\n got=%s", expected, out)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	const valid = `<message xmlns="jabber:client"><x xmlns="jabber:x:oob"><url>https://example.net/a.png</url></x></message>`
+	err := schema.Default.Validate(xml.NewDecoder(strings.NewReader(valid)))
+	if err != nil {
+		t.Errorf("unexpected error validating data: %v", err)
+	}
+	const missingURL = `<message xmlns="jabber:client"><x xmlns="jabber:x:oob"><desc>A picture</desc></x></message>`
+	err = schema.Default.Validate(xml.NewDecoder(strings.NewReader(missingURL)))
+	var schemaErr *schema.Error
+	if !errors.As(err, &schemaErr) {
+		t.Errorf("expected schema error for data without URL, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package outbox implements a queue of outgoing messages that are sent again
+// once a session is re-established.
+//
+// Messages sent through a Queue while there is no session, or that cannot be
+// written because the connection was lost, are kept in a Store and sent in the
+// order they were queued the next time Resume is called with a new session.
+// Each message is given an origin ID (see stanza.InsertOriginID) when it is
+// queued and the same serialized message is sent on every attempt, so that
+// recipients and archives can recognize messages that are delivered more than
+// once.
+//
+// The queue does not depend on stream management.
+// If the application has a way to learn that a message was delivered, such as
+// a stream management acknowledgement or a delivery receipt (see the receipts
+// package), it can set WaitAck so that messages stay queued until they are
+// acknowledged and are sent again if the connection drops before then.
+package outbox // import "mellium.im/xmpp/outbox"
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
+	"mellium.im/xmpp/stanza"
+)
+
+// ErrNotQueueable is returned when queuing something that is not a message or
+// that is an error message.
+var ErrNotQueueable = errors.New("outbox: only messages that are not errors can be queued")
+
+// Queue is an outgoing message queue.
+// The zero value is a queue that keeps messages in memory and has no session.
+type Queue struct {
+	// Store persists the queued messages.
+	// If Store is nil, a MemoryStore is used.
+	Store Store
+
+	// WaitAck keeps messages in the store after they are written until Ack is
+	// called with their origin ID.
+	// If WaitAck is false messages are removed from the store as soon as they
+	// have been written to a session.
+	WaitAck bool
+
+	once sync.Once
+	mu   sync.Mutex
+	s    *xmpp.Session
+}
+
+func (q *Queue) store() Store {
+	q.once.Do(func() {
+		if q.Store == nil {
+			q.Store = &MemoryStore{}
+		}
+	})
+	return q.Store
+}
+
+// Send queues the first element read from r, which must be a message, and
+// writes it to the current session if there is one.
+// It returns the origin ID of the message, which is added if the message does
+// not already have one.
+//
+// Errors writing to the session are not returned.
+// Instead the session is detached and the message remains in the queue until
+// Resume is called with a new session.
+func (q *Queue) Send(ctx context.Context, r xml.TokenReader) (string, error) {
+	tok, err := r.Token()
+	if err != nil {
+		return "", err
+	}
+	start, ok := tok.(xml.StartElement)
+	if _, typ := attr.Get(start.Attr, "type"); !ok || start.Name.Local != "message" || typ == string(stanza.ErrorMessage) {
+		return "", ErrNotQueueable
+	}
+	toks, err := xmlstream.ReadAll(stanza.InsertOriginID("")(xmlstream.Wrap(xmlstream.Inner(r), start)))
+	if err != nil {
+		return "", err
+	}
+	_, origin, err := stanza.UnmarshalIDs(tokens.Reader(toks))
+	if err != nil {
+		return "", err
+	}
+	b, err := encode(toks)
+	if err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := Entry{ID: origin.ID, XML: b}
+	err = q.store().Push(ctx, e)
+	if err != nil {
+		return "", err
+	}
+	if q.s != nil {
+		err = q.write(ctx, e)
+	}
+	return origin.ID, err
+}
+
+// Resume attaches the queue to s and sends every queued message to it in the
+// order they were queued.
+// It should be called each time a new session has been established, after any
+// stream management resumption has completed.
+func (q *Queue) Resume(ctx context.Context, s *xmpp.Session) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.s = s
+	entries, err := q.store().List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err = q.write(ctx, e)
+		if err != nil {
+			return err
+		}
+		if q.s == nil {
+			// The session was lost while replaying the queue so leave the rest of
+			// the messages for the next session.
+			return nil
+		}
+	}
+	return nil
+}
+
+// Detach stops messages from being sent to the current session.
+// Messages sent after Detach is called are queued until Resume is called.
+func (q *Queue) Detach() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.s = nil
+}
+
+// Ack removes the message with the provided origin ID from the queue.
+// It is only needed if WaitAck is set.
+func (q *Queue) Ack(ctx context.Context, id string) error {
+	return q.store().Remove(ctx, id)
+}
+
+// Pending returns the messages that are currently queued.
+func (q *Queue) Pending(ctx context.Context) ([]Entry, error) {
+	return q.store().List(ctx)
+}
+
+// write sends e to the current session and removes it from the store if
+// necessary.
+// It must be called with the lock held.
+func (q *Queue) write(ctx context.Context, e Entry) error {
+	d := xml.NewDecoder(bytes.NewReader(e.XML))
+	err := q.s.Send(ctx, xmlstream.RemoveAttr(func(_ xml.StartElement, a xml.Attr) bool {
+		// The decoder reports the namespace declarations as attributes in
+		// addition to setting the namespace of each element, and the session
+		// adds them again itself.
+		return a.Name.Space == "" && a.Name.Local == "xmlns"
+	})(d))
+	if err != nil {
+		q.s = nil
+		return nil
+	}
+	if q.WaitAck {
+		return nil
+	}
+	return q.store().Remove(ctx, e.ID)
+}
+
+// encode serializes the tokens of a message so that it can be stored.
+func encode(toks []xml.Token) ([]byte, error) {
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+	for _, tok := range toks {
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Space != "" {
+			// The encoder adds the xmlns attribute itself, so remove any existing
+			// one to avoid duplicating it.
+			attrs := make([]xml.Attr, 0, len(start.Attr))
+			for _, a := range start.Attr {
+				if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					continue
+				}
+				attrs = append(attrs, a)
+			}
+			start.Attr = attrs
+			tok = start
+		}
+		err := e.EncodeToken(tok)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := e.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
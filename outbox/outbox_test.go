@@ -0,0 +1,217 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package outbox_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/outbox"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ outbox.Store = (*outbox.MemoryStore)(nil)
+	_ outbox.Store = (*outbox.DirStore)(nil)
+)
+
+func testStore(ctx context.Context, t *testing.T, store outbox.Store) {
+	for _, e := range []outbox.Entry{
+		{ID: "1", XML: []byte("<message/>")},
+		{ID: "a/b", XML: []byte("<message><body>a</body></message>")},
+		{ID: "3", XML: []byte("<message/>")},
+	} {
+		err := store.Push(ctx, e)
+		if err != nil {
+			t.Fatalf("error pushing %q: %v", e.ID, err)
+		}
+	}
+	err := store.Remove(ctx, "1")
+	if err != nil {
+		t.Fatalf("error removing entry: %v", err)
+	}
+	err = store.Remove(ctx, "unknown")
+	if err != nil {
+		t.Fatalf("error removing unknown entry: %v", err)
+	}
+	entries, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("error listing entries: %v", err)
+	}
+	want := []outbox.Entry{
+		{ID: "a/b", XML: []byte("<message><body>a</body></message>")},
+		{ID: "3", XML: []byte("<message/>")},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("wrong entries:\nwant=%q,\n got=%q", want, entries)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(context.Background(), t, &outbox.MemoryStore{})
+}
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	testStore(ctx, t, &outbox.DirStore{Dir: dir})
+
+	// A new store using the same directory must see the existing messages and
+	// add new ones after them.
+	store := &outbox.DirStore{Dir: dir}
+	err := store.Push(ctx, outbox.Entry{ID: "4", XML: []byte("<message/>")})
+	if err != nil {
+		t.Fatalf("error pushing to new store: %v", err)
+	}
+	entries, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("error listing entries: %v", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"a/b", "3", "4"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("wrong order after reopening store: want=%v, got=%v", want, ids)
+	}
+}
+
+func TestNotQueueable(t *testing.T) {
+	q := &outbox.Queue{}
+	for _, r := range []xml.TokenReader{
+		stanza.IQ{Type: stanza.GetIQ}.Wrap(nil),
+		stanza.Message{Type: stanza.ErrorMessage}.Wrap(nil),
+	} {
+		_, err := q.Send(context.Background(), r)
+		if !errors.Is(err, outbox.ErrNotQueueable) {
+			t.Errorf("wrong error: want=%v, got=%v", outbox.ErrNotQueueable, err)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	type received struct {
+		id, origin, body string
+	}
+	recv := make(chan received, 3)
+	s := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			msg := struct {
+				stanza.Message
+				Body   string          `xml:"body"`
+				Origin stanza.OriginID `xml:"urn:xmpp:sid:0 origin-id"`
+			}{}
+			err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), t)).Decode(&msg)
+			if err != nil {
+				return err
+			}
+			recv <- received{id: msg.ID, origin: msg.Origin.ID, body: msg.Body}
+			return nil
+		}),
+	)
+	defer s.Close()
+
+	q := &outbox.Queue{Store: &outbox.DirStore{Dir: t.TempDir()}}
+	to := jid.MustParse("juliet@example.com")
+	var want []received
+	for i, body := range []string{"one", "two"} {
+		msg := stanza.Message{
+			XMLName: xml.Name{Space: stanza.NSClient, Local: "message"},
+			ID:      string(rune('a' + i)),
+			To:      to,
+			Type:    stanza.ChatMessage,
+		}
+		id, err := q.Send(ctx, msg.Wrap(xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(body)),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		)))
+		if err != nil {
+			t.Fatalf("error queuing message: %v", err)
+		}
+		if id == "" {
+			t.Fatalf("no origin ID was added to the message")
+		}
+		want = append(want, received{id: msg.ID, origin: id, body: body})
+	}
+	pending, err := q.Pending(ctx)
+	if err != nil {
+		t.Fatalf("error listing pending messages: %v", err)
+	}
+	if len(pending) != len(want) {
+		t.Fatalf("wrong number of messages queued while detached: want=%d, got=%d", len(want), len(pending))
+	}
+
+	err = q.Resume(ctx, s.Client)
+	if err != nil {
+		t.Fatalf("error resuming queue: %v", err)
+	}
+	for _, w := range want {
+		select {
+		case r := <-recv:
+			if r != w {
+				t.Errorf("wrong message: want=%+v, got=%+v", w, r)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for message")
+		}
+	}
+	pending, err = q.Pending(ctx)
+	if err != nil {
+		t.Fatalf("error listing pending messages: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected queue to be empty after resuming, got %d messages", len(pending))
+	}
+}
+
+func TestWaitAck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(xmlstream.TokenReadEncoder, *xml.StartElement) error {
+			return nil
+		}),
+	)
+	defer s.Close()
+
+	q := &outbox.Queue{WaitAck: true}
+	err := q.Resume(ctx, s.Client)
+	if err != nil {
+		t.Fatalf("error resuming queue: %v", err)
+	}
+	id, err := q.Send(ctx, stanza.Message{Type: stanza.ChatMessage}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	pending, err := q.Pending(ctx)
+	if err != nil {
+		t.Fatalf("error listing pending messages: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != id {
+		t.Fatalf("expected unacknowledged message %q to stay queued, got %q", id, pending)
+	}
+	err = q.Ack(ctx, id)
+	if err != nil {
+		t.Fatalf("error acknowledging message: %v", err)
+	}
+	pending, err = q.Pending(ctx)
+	if err != nil {
+		t.Fatalf("error listing pending messages: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected acknowledged message to be removed, got %q", pending)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Entry is a message waiting in the queue.
+type Entry struct {
+	// ID is the origin ID of the message.
+	ID string
+
+	// XML is the serialized message.
+	XML []byte
+}
+
+// Store persists the messages in a queue.
+//
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Store interface {
+	// Push adds a message to the end of the queue.
+	Push(ctx context.Context, e Entry) error
+
+	// List returns every message in the queue in the order they were pushed.
+	List(ctx context.Context) ([]Entry, error)
+
+	// Remove removes the message with the given ID from the queue.
+	// Removing a message that does not exist is not an error.
+	Remove(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store that keeps messages in memory.
+// The zero value is an empty store ready to use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Push implements Store.
+func (m *MemoryStore) Push(_ context.Context, e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+// List implements Store.
+func (m *MemoryStore) List(context.Context) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Entry(nil), m.entries...), nil
+}
+
+// Remove implements Store.
+func (m *MemoryStore) Remove(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, e := range m.entries {
+		if e.ID == id {
+			m.entries = append(m.entries[:i:i], m.entries[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+const fileExt = ".xml"
+
+// DirStore is a Store that keeps each message in its own file in a directory
+// so that the queue survives restarts of the application.
+// The directory must already exist and should not be used for anything else.
+//
+// Files are written to a temporary name and then renamed so that a crash while
+// pushing a message never leaves a partially written message in the queue.
+type DirStore struct {
+	Dir string
+
+	mu   sync.Mutex
+	next uint64
+}
+
+// filename returns the name of the file that stores the message with the given
+// sequence number and ID.
+// Sequence numbers are zero padded so that sorting the names of the files
+// sorts the messages in the order they were pushed.
+func filename(seq uint64, id string) string {
+	return fmt.Sprintf("%020d-%s%s", seq, hex.EncodeToString([]byte(id)), fileExt)
+}
+
+// parseFilename reports the sequence number of the file with the given name and
+// whether the file stores a message.
+func parseFilename(name string) (uint64, bool) {
+	if !strings.HasSuffix(name, fileExt) {
+		return 0, false
+	}
+	seq, _, ok := strings.Cut(name, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// files returns the names of the files that store messages sorted in the order
+// they were pushed.
+// It must be called with the lock held.
+func (d *DirStore) files() ([]string, error) {
+	dirEntries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			continue
+		}
+		name := de.Name()
+		seq, ok := parseFilename(name)
+		if !ok {
+			continue
+		}
+		if seq >= d.next {
+			d.next = seq + 1
+		}
+		names = append(names, name)
+	}
+	// os.ReadDir returns entries sorted by filename.
+	return names, nil
+}
+
+// Push implements Store.
+func (d *DirStore) Push(_ context.Context, e Entry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Make sure that the next sequence number is after any files left by a
+	// previous instance of the store.
+	_, err := d.files()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(d.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(e.XML)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		/* #nosec */
+		os.Remove(f.Name())
+		return err
+	}
+	err = os.Rename(f.Name(), filepath.Join(d.Dir, filename(d.next, e.ID)))
+	if err != nil {
+		/* #nosec */
+		os.Remove(f.Name())
+		return err
+	}
+	d.next++
+	return nil
+}
+
+// List implements Store.
+func (d *DirStore) List(context.Context) ([]Entry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(names))
+	for _, name := range names {
+		_, encodedID, _ := strings.Cut(strings.TrimSuffix(name, fileExt), "-")
+		id, err := hex.DecodeString(encodedID)
+		if err != nil {
+			return nil, fmt.Errorf("outbox: invalid file name %q: %w", name, err)
+		}
+		b, err := os.ReadFile(filepath.Join(d.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{ID: string(id), XML: b})
+	}
+	return entries, nil
+}
+
+// Remove implements Store.
+func (d *DirStore) Remove(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names, err := d.files()
+	if err != nil {
+		return err
+	}
+	suffix := "-" + hex.EncodeToString([]byte(id)) + fileExt
+	for _, name := range names {
+		if strings.HasSuffix(name, suffix) {
+			err = os.Remove(filepath.Join(d.Dir, name))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package presence
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/l10n"
+	"mellium.im/xmpp/stanza"
+)
+
+// Default thresholds used by AutoAway if none are configured.
+const (
+	DefaultAwayAfter = 5 * time.Minute
+	DefaultXAAfter   = 15 * time.Minute
+)
+
+// AutoAway changes our presence to away or extended away while the user is
+// idle and restores the previous presence when they become active again.
+// The time of the last user interaction is included in the presence as
+// described in XEP-0319: Last User Interaction in Presence.
+//
+// The application is responsible for detecting user interaction and reports it
+// by calling Idle and Active.
+// Presence chosen by the user should be sent using Set so that it can be
+// restored after the user was idle.
+// Automatic changes are only made if the presence set by the user is available
+// and has no show value (or has the show value "chat"), so a user that has
+// explicitly set their presence to do not disturb, for example, will never be
+// marked as away.
+//
+// The zero value is ready to use with the default thresholds.
+// An AutoAway should only be used with a single session.
+type AutoAway struct {
+	// AwayAfter and XAAfter are how long the user must be idle before their
+	// presence is changed to away or extended away respectively.
+	// If either is zero the default is used and if either is negative the
+	// transition is disabled.
+	AwayAfter time.Duration
+	XAAfter   time.Duration
+
+	// AwayStatus and XAStatus, if set, replace the status message while the
+	// user is away or extended away.
+	AwayStatus string
+	XAStatus   string
+
+	// Lang, if set, is the language of the user.
+	// If Lang is set and AwayStatus or XAStatus are empty, a default status
+	// message translated into Lang is used instead.
+	// For more information see the l10n package.
+	Lang string
+
+	mu     sync.Mutex
+	status Status
+	show   Show
+	since  time.Time
+}
+
+// Status returns the presence that is currently being broadcast.
+func (a *AutoAway) Status() Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.current()
+}
+
+// Set broadcasts the presence chosen by the user and remembers it so that it
+// can be restored when the user is no longer idle.
+// Any automatic away status is cleared since setting the presence is assumed to
+// be a user interaction.
+func (a *AutoAway) Set(ctx context.Context, s *xmpp.Session, st Status) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err := Send(ctx, s, st)
+	if err != nil {
+		return err
+	}
+	a.status = st
+	a.show = ""
+	a.since = time.Time{}
+	return nil
+}
+
+// Idle reports that the last user interaction was at since.
+// It may be called as often as the application checks for user interaction and
+// only broadcasts presence if it results in a transition to away or extended
+// away.
+func (a *AutoAway) Idle(ctx context.Context, s *xmpp.Session, since time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var show Show
+	if a.automatic() {
+		idle := time.Since(since)
+		switch {
+		case idle >= threshold(a.XAAfter, DefaultXAAfter):
+			show = XA
+		case idle >= threshold(a.AwayAfter, DefaultAwayAfter):
+			show = Away
+		}
+	}
+	if show == a.show {
+		return nil
+	}
+	return a.transition(ctx, s, show, since)
+}
+
+// Active reports that the user has interacted with the application and
+// restores the presence that they had before becoming idle, if any.
+func (a *AutoAway) Active(ctx context.Context, s *xmpp.Session) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.show == "" {
+		return nil
+	}
+	return a.transition(ctx, s, "", time.Time{})
+}
+
+func (a *AutoAway) transition(ctx context.Context, s *xmpp.Session, show Show, since time.Time) error {
+	prevShow, prevSince := a.show, a.since
+	a.show, a.since = show, since
+	err := Send(ctx, s, a.current())
+	if err != nil {
+		a.show, a.since = prevShow, prevSince
+	}
+	return err
+}
+
+// automatic reports whether the presence chosen by the user may be replaced
+// automatically.
+func (a *AutoAway) automatic() bool {
+	return a.status.Type == stanza.AvailablePresence && (a.status.Show == "" || a.status.Show == Chat)
+}
+
+func (a *AutoAway) current() Status {
+	st := a.status
+	switch a.show {
+	case Away:
+		st.Show = Away
+		switch {
+		case a.AwayStatus != "":
+			st.Status = a.AwayStatus
+		case a.Lang != "":
+			st.Status = l10n.Printer(a.Lang).Sprintf("Away")
+		}
+	case XA:
+		st.Show = XA
+		switch {
+		case a.XAStatus != "":
+			st.Status = a.XAStatus
+		case a.Lang != "":
+			st.Status = l10n.Printer(a.Lang).Sprintf("Extended away")
+		}
+	default:
+		return st
+	}
+	st.Idle = a.since
+	return st
+}
+
+func threshold(d, def time.Duration) time.Duration {
+	switch {
+	case d < 0:
+		// A threshold so large that it is never reached.
+		return 1<<63 - 1
+	case d == 0:
+		return def
+	}
+	return d
+}
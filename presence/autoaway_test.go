@@ -0,0 +1,146 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package presence_test
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/presence"
+)
+
+func TestAutoAway(t *testing.T) {
+	sent := make(chan presence.Status, 10)
+	s := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			var st presence.Status
+			err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r)).Decode(&st)
+			if err != nil {
+				return err
+			}
+			sent <- st
+			return nil
+		}),
+	)
+	ctx := context.Background()
+	expect := func(show presence.Show, status string, idle time.Time) {
+		t.Helper()
+		var st presence.Status
+		select {
+		case st = <-sent:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for presence")
+		}
+		if st.Show != show || st.Status != status || !st.Idle.Equal(idle) {
+			t.Errorf("wrong presence sent: want=%q/%q/%v, got=%q/%q/%v", show, status, idle, st.Show, st.Status, st.Idle)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case st := <-sent:
+			t.Errorf("unexpected presence sent: %+v", st)
+		default:
+		}
+	}
+	// The server handles stanzas asynchronously, so send a marker that we can
+	// wait on to make sure nothing else was sent before it.
+	flush := func() {
+		t.Helper()
+		err := presence.Send(ctx, s.Client, presence.Status{Status: "sync"})
+		if err != nil {
+			t.Fatalf("error sending sync presence: %v", err)
+		}
+		expect("", "sync", time.Time{})
+	}
+
+	aa := &presence.AutoAway{
+		AwayAfter: time.Minute,
+		XAAfter:   time.Hour,
+		XAStatus:  "Gone fishing",
+	}
+	err := aa.Set(ctx, s.Client, presence.Status{Status: "Working"})
+	if err != nil {
+		t.Fatalf("error setting presence: %v", err)
+	}
+	expect("", "Working", time.Time{})
+
+	// Not idle long enough.
+	err = aa.Idle(ctx, s.Client, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("error reporting idle: %v", err)
+	}
+	flush()
+	expectNone()
+
+	since := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	err = aa.Idle(ctx, s.Client, since)
+	if err != nil {
+		t.Fatalf("error reporting idle: %v", err)
+	}
+	expect(presence.Away, "Working", since)
+	if st := aa.Status(); st.Show != presence.Away {
+		t.Errorf("wrong current status: want=away, got=%q", st.Show)
+	}
+
+	// Reporting the same state again should not send presence.
+	err = aa.Idle(ctx, s.Client, since)
+	if err != nil {
+		t.Fatalf("error reporting idle: %v", err)
+	}
+	flush()
+	expectNone()
+
+	since = time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	err = aa.Idle(ctx, s.Client, since)
+	if err != nil {
+		t.Fatalf("error reporting idle: %v", err)
+	}
+	expect(presence.XA, "Gone fishing", since)
+
+	err = aa.Active(ctx, s.Client)
+	if err != nil {
+		t.Fatalf("error reporting activity: %v", err)
+	}
+	expect("", "Working", time.Time{})
+
+	err = aa.Active(ctx, s.Client)
+	if err != nil {
+		t.Fatalf("error reporting activity: %v", err)
+	}
+	flush()
+	expectNone()
+
+	// Explicitly set presence is never overridden.
+	err = aa.Set(ctx, s.Client, presence.Status{Show: presence.DND})
+	if err != nil {
+		t.Fatalf("error setting presence: %v", err)
+	}
+	expect(presence.DND, "", time.Time{})
+	err = aa.Idle(ctx, s.Client, time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("error reporting idle: %v", err)
+	}
+	flush()
+	expectNone()
+
+	// Default status messages are translated if a language is set.
+	aa.XAStatus = ""
+	aa.Lang = "de"
+	err = aa.Set(ctx, s.Client, presence.Status{Status: "Working"})
+	if err != nil {
+		t.Fatalf("error setting presence: %v", err)
+	}
+	expect("", "Working", time.Time{})
+	err = aa.Idle(ctx, s.Client, since)
+	if err != nil {
+		t.Fatalf("error reporting idle: %v", err)
+	}
+	expect(presence.XA, "Länger abwesend", since)
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package presence
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handler tracks incoming presence in its Store.
+// The zero value is ready to use.
+type Handler struct {
+	Store
+
+	// Changed, if set, is called after the presence of a resource is updated.
+	// If the resource became unavailable the type of the status is
+	// stanza.UnavailablePresence, and if the JID is a bare JID all of its
+	// resources became unavailable.
+	Changed func(Status)
+
+	// Request, if set, is called when a new subscription request is received.
+	// The request remains pending in the store until it is removed using
+	// RemoveRequest.
+	Request func(stanza.Presence)
+}
+
+// Handle returns an option that registers a Handler for presence.
+//
+// Presence that only contains payloads with more specific handlers registered
+// on the multiplexer (such as presence from a multi-user chat) is not seen by
+// the handler.
+func Handle(h *Handler) mux.Option {
+	return func(m *mux.ServeMux) {
+		for _, typ := range []stanza.PresenceType{
+			stanza.AvailablePresence,
+			stanza.UnavailablePresence,
+			stanza.ErrorPresence,
+			stanza.SubscribePresence,
+			stanza.UnsubscribePresence,
+		} {
+			mux.Presence(typ, xml.Name{}, h)(m)
+		}
+	}
+}
+
+// HandlePresence satisfies mux.PresenceHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+//
+// The multiplexer may call HandlePresence once for each child of the presence,
+// so updates to the store are idempotent and the callbacks are only called if
+// the store changes.
+func (h *Handler) HandlePresence(p stanza.Presence, r xmlstream.TokenReadEncoder) error {
+	switch p.Type {
+	case stanza.AvailablePresence:
+		var st Status
+		err := xml.NewTokenDecoder(r).Decode(&st)
+		if err != nil {
+			return err
+		}
+		if h.Update(st) && h.Changed != nil {
+			h.Changed(st)
+		}
+	case stanza.UnavailablePresence:
+		var st Status
+		err := xml.NewTokenDecoder(r).Decode(&st)
+		if err != nil {
+			return err
+		}
+		if h.Remove(st.JID) && h.Changed != nil {
+			h.Changed(st)
+		}
+	case stanza.ErrorPresence:
+		// An error from the contact means that we will not receive any further
+		// presence from any of its resources.
+		st := Status{JID: p.From.Bare(), Type: stanza.UnavailablePresence}
+		if h.Remove(st.JID) && h.Changed != nil {
+			h.Changed(st)
+		}
+	case stanza.SubscribePresence:
+		if h.AddRequest(p) && h.Request != nil {
+			h.Request(p)
+		}
+	case stanza.UnsubscribePresence:
+		// The contact is no longer interested in our presence, so any request
+		// that they made is withdrawn.
+		h.RemoveRequest(p.From)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package presence tracks the availability of other entities and manages
+// presence subscriptions.
+//
+// A Handler registered on a multiplexer keeps a Store of the last presence
+// received from every available resource and of incoming subscription requests
+// that have not yet been answered.
+// Subscriptions are requested, approved, and canceled using the functions in
+// this package.
+package presence // import "mellium.im/xmpp/presence"
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/xtime"
+)
+
+// NSIdle is the namespace used by XEP-0319: Last User Interaction in Presence.
+const NSIdle = "urn:xmpp:idle:1"
+
+// Show is the availability sub-state of an available resource.
+type Show string
+
+// A list of possible values for Show.
+// The zero value indicates that the entity is available without any further
+// qualification.
+const (
+	Away Show = "away"
+	Chat Show = "chat"
+	DND  Show = "dnd"
+	XA   Show = "xa"
+)
+
+// Status is the presence of a single resource.
+type Status struct {
+	// JID is the address that the presence was received from.
+	JID jid.JID
+
+	// Type is either stanza.AvailablePresence or stanza.UnavailablePresence.
+	Type     stanza.PresenceType
+	Show     Show
+	Status   string
+	Priority int8
+
+	// Idle is the time of the last user interaction if the resource is idle as
+	// described in XEP-0319, or the zero time otherwise.
+	Idle time.Time
+
+	// Caps is the entity capabilities advertised by the resource (if any).
+	Caps disco.Caps
+}
+
+func (s Status) equal(o Status) bool {
+	return s.JID.Equal(o.JID) &&
+		s.Type == o.Type &&
+		s.Show == o.Show &&
+		s.Status == o.Status &&
+		s.Priority == o.Priority &&
+		s.Idle.Equal(o.Idle) &&
+		s.Caps.Hash == o.Caps.Hash &&
+		s.Caps.Node == o.Caps.Node &&
+		s.Caps.Ver == o.Caps.Ver
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+//
+// The JID is used as the from attribute of the presence if it is set.
+func (s Status) TokenReader() xml.TokenReader {
+	var inner []xml.TokenReader
+	if s.Show != "" {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(s.Show)),
+			xml.StartElement{Name: xml.Name{Local: "show"}},
+		))
+	}
+	if s.Status != "" {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(s.Status)),
+			xml.StartElement{Name: xml.Name{Local: "status"}},
+		))
+	}
+	if s.Priority != 0 {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(strconv.Itoa(int(s.Priority)))),
+			xml.StartElement{Name: xml.Name{Local: "priority"}},
+		))
+	}
+	if !s.Idle.IsZero() {
+		since, err := xtime.Time{Time: s.Idle}.MarshalXMLAttr(xml.Name{Local: "since"})
+		if err != nil {
+			panic(fmt.Errorf("presence: unreachable error reached while marshaling time: %w", err))
+		}
+		inner = append(inner, xmlstream.Wrap(nil, xml.StartElement{
+			Name: xml.Name{Space: NSIdle, Local: "idle"},
+			Attr: []xml.Attr{since},
+		}))
+	}
+	if s.Caps.Ver != "" {
+		inner = append(inner, s.Caps.TokenReader())
+	}
+	return stanza.Presence{
+		From: s.JID,
+		Type: s.Type,
+	}.Wrap(xmlstream.MultiReader(inner...))
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (s Status) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, s.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (s Status) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := s.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+// The start element must be a presence stanza.
+func (s *Status) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	p, err := stanza.NewPresence(start)
+	if err != nil {
+		return err
+	}
+	v := struct {
+		Show   Show `xml:"show"`
+		Status []struct {
+			Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"status"`
+		Priority int8 `xml:"priority"`
+		Idle     *struct {
+			Since xtime.Time `xml:"since,attr"`
+		} `xml:"urn:xmpp:idle:1 idle"`
+		Caps disco.Caps `xml:"http://jabber.org/protocol/caps c"`
+	}{}
+	err = d.DecodeElement(&v, &start)
+	if err != nil {
+		return err
+	}
+	*s = Status{
+		JID:      p.From,
+		Type:     p.Type,
+		Show:     v.Show,
+		Priority: v.Priority,
+		Caps:     v.Caps,
+	}
+	if v.Idle != nil {
+		s.Idle = v.Idle.Since.Time
+	}
+	// Prefer a status in the language of the stanza, falling back to the first
+	// one.
+	if len(v.Status) > 0 {
+		s.Status = v.Status[0].Value
+	}
+	for _, status := range v.Status {
+		if status.Lang == "" || status.Lang == p.Lang {
+			s.Status = status.Value
+			break
+		}
+	}
+	return nil
+}
+
+// Send broadcasts our own presence.
+// The JID of the status is ignored.
+func Send(ctx context.Context, s *xmpp.Session, st Status) error {
+	st.JID = jid.JID{}
+	return s.Send(ctx, st.TokenReader())
+}
+
+func sendType(ctx context.Context, s *xmpp.Session, typ stanza.PresenceType, to jid.JID) error {
+	return s.Send(ctx, stanza.Presence{
+		To:   to.Bare(),
+		Type: typ,
+	}.Wrap(nil))
+}
+
+// Subscribe requests a subscription to the presence of the bare JID to.
+func Subscribe(ctx context.Context, s *xmpp.Session, to jid.JID) error {
+	return sendType(ctx, s, stanza.SubscribePresence, to)
+}
+
+// Approve approves a subscription request from the bare JID to, or
+// pre-approves one if no request has been received yet.
+func Approve(ctx context.Context, s *xmpp.Session, to jid.JID) error {
+	return sendType(ctx, s, stanza.SubscribedPresence, to)
+}
+
+// Unsubscribe cancels our subscription to the presence of the bare JID to.
+func Unsubscribe(ctx context.Context, s *xmpp.Session, to jid.JID) error {
+	return sendType(ctx, s, stanza.UnsubscribePresence, to)
+}
+
+// Deny denies a subscription request from the bare JID to or revokes a
+// subscription that was previously approved.
+func Deny(ctx context.Context, s *xmpp.Session, to jid.JID) error {
+	return sendType(ctx, s, stanza.UnsubscribedPresence, to)
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package presence_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/crypto"
+	"mellium.im/xmpp/disco"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/presence"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xml.Marshaler       = presence.Status{}
+	_ xml.Unmarshaler     = (*presence.Status)(nil)
+	_ xmlstream.Marshaler = presence.Status{}
+	_ xmlstream.WriterTo  = presence.Status{}
+	_ mux.PresenceHandler = (*presence.Handler)(nil)
+)
+
+var marshalTestCases = []struct {
+	status presence.Status
+	xml    string
+}{
+	0: {
+		xml: `<presence></presence>`,
+	},
+	1: {
+		status: presence.Status{
+			JID:      jid.MustParse("juliet@example.com/balcony"),
+			Show:     presence.Away,
+			Status:   "Wherefore art thou?",
+			Priority: -1,
+			Caps: disco.Caps{
+				Hash: crypto.SHA1,
+				Node: "https://mellium.im",
+				Ver:  "abc",
+			},
+		},
+		xml: `<presence from="juliet@example.com/balcony"><show>away</show><status>Wherefore art thou?</status><priority>-1</priority><c xmlns="http://jabber.org/protocol/caps" hash="sha-1" node="https://mellium.im" ver="abc"></c></presence>`,
+	},
+	2: {
+		status: presence.Status{
+			JID:  jid.MustParse("juliet@example.com/balcony"),
+			Type: stanza.UnavailablePresence,
+		},
+		xml: `<presence type="unavailable" from="juliet@example.com/balcony"></presence>`,
+	},
+	3: {
+		status: presence.Status{
+			Show: presence.XA,
+			Idle: time.Date(2010, 10, 10, 10, 10, 10, 0, time.UTC),
+		},
+		xml: `<presence><show>xa</show><idle xmlns="urn:xmpp:idle:1" since="2010-10-10T10:10:10Z"></idle></presence>`,
+	},
+}
+
+func TestMarshal(t *testing.T) {
+	for i, tc := range marshalTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := xml.Marshal(tc.status)
+			if err != nil {
+				t.Fatalf("error marshaling: %v", err)
+			}
+			if s := string(out); s != tc.xml {
+				t.Fatalf("wrong output:\nwant=%s,\n got=%s", tc.xml, s)
+			}
+
+			var st presence.Status
+			err = xml.Unmarshal(out, &st)
+			if err != nil {
+				t.Fatalf("error unmarshaling: %v", err)
+			}
+			if !st.JID.Equal(tc.status.JID) || st.Type != tc.status.Type ||
+				st.Show != tc.status.Show || st.Status != tc.status.Status ||
+				st.Priority != tc.status.Priority || st.Caps != tc.status.Caps ||
+				!st.Idle.Equal(tc.status.Idle) {
+				t.Errorf("wrong status after round trip: want=%+v, got=%+v", tc.status, st)
+			}
+		})
+	}
+}
+
+func TestUnmarshalStatusLang(t *testing.T) {
+	const input = `<presence xml:lang="en"><status xml:lang="fr">Bonjour</status><status xml:lang="en">Hello</status></presence>`
+	var st presence.Status
+	err := xml.Unmarshal([]byte(input), &st)
+	if err != nil {
+		t.Fatalf("error unmarshaling: %v", err)
+	}
+	if st.Status != "Hello" {
+		t.Errorf("wrong status: want=%q, got=%q", "Hello", st.Status)
+	}
+}
+
+func handle(t *testing.T, m *mux.ServeMux, input string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(input))
+	tok, err := d.Token()
+	if err != nil {
+		t.Fatalf("error popping start token: %v", err)
+	}
+	start := tok.(xml.StartElement)
+	err = m.HandleXMPP(struct {
+		xml.TokenReader
+		xmlstream.Encoder
+	}{
+		TokenReader: d,
+		Encoder:     xml.NewEncoder(io.Discard),
+	}, &start)
+	if err != nil {
+		t.Fatalf("error handling presence: %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var changed []presence.Status
+	var requests []stanza.Presence
+	h := &presence.Handler{
+		Changed: func(st presence.Status) {
+			changed = append(changed, st)
+		},
+		Request: func(p stanza.Presence) {
+			requests = append(requests, p)
+		},
+	}
+	m := mux.New(stanza.NSClient, presence.Handle(h))
+	romeo := jid.MustParse("romeo@example.net")
+
+	handle(t, m, `<presence xmlns="jabber:client" from="romeo@example.net/orchard"><show>dnd</show><status>Busy</status><priority>5</priority></presence>`)
+	handle(t, m, `<presence xmlns="jabber:client" from="romeo@example.net/garden"><priority>1</priority></presence>`)
+	// Receiving the same presence again must not be reported as a change.
+	handle(t, m, `<presence xmlns="jabber:client" from="romeo@example.net/garden"><priority>1</priority></presence>`)
+	if len(changed) != 2 {
+		t.Fatalf("wrong number of changes: want=2, got=%d", len(changed))
+	}
+	st, ok := h.Get(romeo)
+	if !ok {
+		t.Fatalf("expected contact to be available")
+	}
+	if st.JID.Resourcepart() != "orchard" || st.Show != presence.DND || st.Status != "Busy" {
+		t.Errorf("wrong highest priority resource: %+v", st)
+	}
+	if resources := h.Resources(romeo); len(resources) != 2 || resources[1].JID.Resourcepart() != "garden" {
+		t.Errorf("wrong resources: %+v", resources)
+	}
+
+	handle(t, m, `<presence xmlns="jabber:client" type="unavailable" from="romeo@example.net/orchard"/>`)
+	st, ok = h.Get(romeo)
+	if !ok || st.JID.Resourcepart() != "garden" {
+		t.Errorf("expected remaining resource to be returned, got %+v (%t)", st, ok)
+	}
+	if last := changed[len(changed)-1]; last.Type != stanza.UnavailablePresence {
+		t.Errorf("expected unavailable change, got %+v", last)
+	}
+	handle(t, m, `<presence xmlns="jabber:client" type="error" from="romeo@example.net"><error type="cancel"><remote-server-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error></presence>`)
+	if h.Available(romeo) {
+		t.Errorf("expected error presence to make contact unavailable")
+	}
+	if len(changed) != 4 {
+		t.Errorf("wrong number of changes: want=4, got=%d", len(changed))
+	}
+
+	handle(t, m, `<presence xmlns="jabber:client" type="subscribe" from="mercutio@example.net"><nick xmlns="http://jabber.org/protocol/nick">Mercutio</nick><status>Hi</status></presence>`)
+	if len(requests) != 1 {
+		t.Fatalf("wrong number of requests: want=1, got=%d", len(requests))
+	}
+	if reqs := h.Requests(); len(reqs) != 1 || reqs[0].From.String() != "mercutio@example.net" {
+		t.Errorf("wrong pending requests: %+v", reqs)
+	}
+	handle(t, m, `<presence xmlns="jabber:client" type="unsubscribe" from="mercutio@example.net"/>`)
+	if reqs := h.Requests(); len(reqs) != 0 {
+		t.Errorf("expected request to be withdrawn, got %+v", reqs)
+	}
+}
+
+func TestSubscriptionFlows(t *testing.T) {
+	to := jid.MustParse("juliet@example.com/balcony")
+	types := make(chan stanza.PresenceType, 4)
+	h := mux.PresenceHandlerFunc(func(p stanza.Presence, _ xmlstream.TokenReadEncoder) error {
+		if !p.To.Equal(to.Bare()) {
+			t.Errorf("presence sent to wrong JID: want=%v, got=%v", to.Bare(), p.To)
+		}
+		types <- p.Type
+		return nil
+	})
+	s := xmpptest.NewClientServer(
+		xmpptest.ServerHandler(mux.New(stanza.NSClient,
+			mux.Presence(stanza.SubscribePresence, xml.Name{}, h),
+			mux.Presence(stanza.SubscribedPresence, xml.Name{}, h),
+			mux.Presence(stanza.UnsubscribePresence, xml.Name{}, h),
+			mux.Presence(stanza.UnsubscribedPresence, xml.Name{}, h),
+		)),
+	)
+	ctx := context.Background()
+	for _, f := range []func(context.Context) error{
+		func(ctx context.Context) error { return presence.Subscribe(ctx, s.Client, to) },
+		func(ctx context.Context) error { return presence.Approve(ctx, s.Client, to) },
+		func(ctx context.Context) error { return presence.Unsubscribe(ctx, s.Client, to) },
+		func(ctx context.Context) error { return presence.Deny(ctx, s.Client, to) },
+	} {
+		err := f(ctx)
+		if err != nil {
+			t.Fatalf("error sending presence: %v", err)
+		}
+	}
+	for _, want := range []stanza.PresenceType{
+		stanza.SubscribePresence,
+		stanza.SubscribedPresence,
+		stanza.UnsubscribePresence,
+		stanza.UnsubscribedPresence,
+	} {
+		if got := <-types; got != want {
+			t.Errorf("wrong presence type: want=%q, got=%q", want, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package presence
+
+import (
+	"sort"
+	"sync"
+
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Store is an in memory store of the presence of available resources and of
+// pending subscription requests.
+// The zero value is ready to use.
+type Store struct {
+	mu       sync.RWMutex
+	contacts map[string]map[string]Status
+	requests map[string]stanza.Presence
+}
+
+// Update records the presence of an available resource.
+// It reports whether the stored presence changed.
+func (s *Store) Update(st Status) (changed bool) {
+	st.Type = stanza.AvailablePresence
+	bare := st.JID.Bare().String()
+	res := st.JID.Resourcepart()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.contacts[bare][res]; ok && old.equal(st) {
+		return false
+	}
+	if s.contacts == nil {
+		s.contacts = make(map[string]map[string]Status)
+	}
+	resources, ok := s.contacts[bare]
+	if !ok {
+		resources = make(map[string]Status)
+		s.contacts[bare] = resources
+	}
+	resources[res] = st
+	return true
+}
+
+// Remove marks a resource as unavailable.
+// If j is a bare JID, all of its resources are removed.
+// It reports whether any resources were removed.
+func (s *Store) Remove(j jid.JID) (changed bool) {
+	bare := j.Bare().String()
+	res := j.Resourcepart()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resources, ok := s.contacts[bare]
+	if !ok {
+		return false
+	}
+	if res == "" {
+		delete(s.contacts, bare)
+		return true
+	}
+	if _, ok := resources[res]; !ok {
+		return false
+	}
+	delete(resources, res)
+	if len(resources) == 0 {
+		delete(s.contacts, bare)
+	}
+	return true
+}
+
+// Get returns the presence of a resource.
+// If j is a bare JID, the presence of the resource with the highest priority
+// is returned.
+func (s *Store) Get(j jid.JID) (Status, bool) {
+	if j.Resourcepart() != "" {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		st, ok := s.contacts[j.Bare().String()][j.Resourcepart()]
+		return st, ok
+	}
+	resources := s.Resources(j)
+	if len(resources) == 0 {
+		return Status{}, false
+	}
+	return resources[0], true
+}
+
+// Available reports whether any resource of the bare JID j, or the full JID j
+// itself, is available.
+func (s *Store) Available(j jid.JID) bool {
+	_, ok := s.Get(j)
+	return ok
+}
+
+// Resources returns the presence of all available resources of the bare JID
+// j ordered from the highest priority to the lowest.
+// Resources with the same priority are ordered by resourcepart.
+func (s *Store) Resources(j jid.JID) []Status {
+	s.mu.RLock()
+	resources := s.contacts[j.Bare().String()]
+	statuses := make([]Status, 0, len(resources))
+	for _, st := range resources {
+		statuses = append(statuses, st)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Priority != statuses[j].Priority {
+			return statuses[i].Priority > statuses[j].Priority
+		}
+		return statuses[i].JID.Resourcepart() < statuses[j].JID.Resourcepart()
+	})
+	return statuses
+}
+
+// Clear removes all presence and subscription requests from the store.
+// It should be called when the session is closed since no further unavailable
+// presence will be received.
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contacts = nil
+	s.requests = nil
+}
+
+// AddRequest records a subscription request.
+// It reports whether there was not already a pending request from the same
+// bare JID.
+func (s *Store) AddRequest(p stanza.Presence) (added bool) {
+	bare := p.From.Bare().String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.requests[bare]; ok {
+		return false
+	}
+	if s.requests == nil {
+		s.requests = make(map[string]stanza.Presence)
+	}
+	s.requests[bare] = p
+	return true
+}
+
+// RemoveRequest removes the pending subscription request from the bare JID j.
+// It should be called after the request has been approved or denied.
+func (s *Store) RemoveRequest(j jid.JID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.requests, j.Bare().String())
+}
+
+// Requests returns all pending subscription requests ordered by the JID that
+// sent them.
+func (s *Store) Requests() []stanza.Presence {
+	s.mu.RLock()
+	reqs := make([]stanza.Presence, 0, len(s.requests))
+	for _, p := range s.requests {
+		reqs = append(reqs, p)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].From.String() < reqs[j].From.String()
+	})
+	return reqs
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+
+	"mellium.im/sasl"
+)
+
+// ProfileClientModern returns the stream features needed by a client that
+// only connects to servers that support modern security practices.
+// The features are, in order: StartTLS requiring TLS 1.3 or greater, SASL
+// using the SCRAM-SHA-256-PLUS and SCRAM-SHA-256 mechanisms, and resource
+// binding.
+//
+// If cfg is nil a default config is used as with StartTLS.
+// If cfg is not nil, it is copied and the copy's minimum version is raised to
+// TLS 1.3 if necessary.
+// Identity and password are passed to SASL.
+func ProfileClientModern(cfg *tls.Config, identity, password string) []StreamFeature {
+	return []StreamFeature{
+		startTLSMinVersion(cfg, tls.VersionTLS13),
+		SASL(identity, password, sasl.ScramSha256Plus, sasl.ScramSha256),
+		BindResource(),
+	}
+}
+
+// ProfileClientLegacyCompat is like ProfileClientModern except that it allows
+// TLS 1.2 and falls back to SCRAM-SHA-1 and PLAIN authentication for servers
+// that do not support stronger mechanisms.
+// SASL is never negotiated before TLS so PLAIN does not expose the password
+// on an unencrypted connection.
+func ProfileClientLegacyCompat(cfg *tls.Config, identity, password string) []StreamFeature {
+	return []StreamFeature{
+		startTLSMinVersion(cfg, tls.VersionTLS12),
+		SASL(identity, password,
+			sasl.ScramSha256Plus, sasl.ScramSha1Plus,
+			sasl.ScramSha256, sasl.ScramSha1,
+			sasl.Plain),
+		BindResource(),
+	}
+}
+
+// ProfileS2S returns the stream features needed to secure a server-to-server
+// connection with StartTLS requiring TLS 1.2 or greater.
+// Authentication of the remote server is not included and features such as
+// those in the s2s package should be appended to the returned slice.
+//
+// When receiving connections cfg must contain the servers certificates.
+func ProfileS2S(cfg *tls.Config) []StreamFeature {
+	return []StreamFeature{
+		startTLSMinVersion(cfg, tls.VersionTLS12),
+	}
+}
+
+// startTLSMinVersion returns a StartTLS feature that does not negotiate TLS
+// versions lower than min.
+func startTLSMinVersion(cfg *tls.Config, min uint16) StreamFeature {
+	if cfg != nil {
+		cfg = cfg.Clone()
+		if cfg.MinVersion < min {
+			cfg.MinVersion = min
+		}
+		return StartTLS(cfg)
+	}
+
+	feature := StartTLS(nil)
+	feature.Negotiate = func(ctx context.Context, session *Session, data interface{}) (SessionState, io.ReadWriter, error) {
+		return StartTLS(&tls.Config{
+			ServerName: session.LocalAddr().Domain().String(),
+			MinVersion: min,
+		}).Negotiate(ctx, session, data)
+	}
+	return feature
+}
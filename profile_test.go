@@ -0,0 +1,68 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"crypto/tls"
+	"strconv"
+	"testing"
+
+	"mellium.im/xmpp"
+)
+
+var profileTests = [...]struct {
+	features   []xmpp.StreamFeature
+	names      []string
+	necessary  []xmpp.SessionState
+	prohibited []xmpp.SessionState
+}{
+	0: {
+		features:   xmpp.ProfileClientModern(nil, "", "pass"),
+		names:      []string{"starttls", "mechanisms", "bind"},
+		necessary:  []xmpp.SessionState{0, xmpp.Secure, xmpp.Authn},
+		prohibited: []xmpp.SessionState{xmpp.Secure, xmpp.Authn, xmpp.Ready},
+	},
+	1: {
+		features:   xmpp.ProfileClientLegacyCompat(&tls.Config{}, "", "pass"),
+		names:      []string{"starttls", "mechanisms", "bind"},
+		necessary:  []xmpp.SessionState{0, xmpp.Secure, xmpp.Authn},
+		prohibited: []xmpp.SessionState{xmpp.Secure, xmpp.Authn, xmpp.Ready},
+	},
+	2: {
+		features:   xmpp.ProfileS2S(&tls.Config{}),
+		names:      []string{"starttls"},
+		necessary:  []xmpp.SessionState{0},
+		prohibited: []xmpp.SessionState{xmpp.Secure},
+	},
+}
+
+func TestProfiles(t *testing.T) {
+	for i, tc := range profileTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if len(tc.features) != len(tc.names) {
+				t.Fatalf("wrong number of features: want=%d, got=%d", len(tc.names), len(tc.features))
+			}
+			for j, f := range tc.features {
+				if f.Name.Local != tc.names[j] {
+					t.Errorf("wrong feature at %d: want=%s, got=%s", j, tc.names[j], f.Name.Local)
+				}
+				if f.Necessary != tc.necessary[j] {
+					t.Errorf("wrong necessary bits for %s: want=%v, got=%v", f.Name.Local, tc.necessary[j], f.Necessary)
+				}
+				if f.Prohibited&tc.prohibited[j] != tc.prohibited[j] {
+					t.Errorf("wrong prohibited bits for %s: want=%v, got=%v", f.Name.Local, tc.prohibited[j], f.Prohibited)
+				}
+			}
+		})
+	}
+}
+
+func TestProfileDoesNotMutateConfig(t *testing.T) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS10}
+	xmpp.ProfileClientModern(cfg, "", "pass")
+	if cfg.MinVersion != tls.VersionTLS10 {
+		t.Errorf("profile modified the provided config")
+	}
+}
@@ -63,6 +63,7 @@ func getConfig(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node string,
 		xml.StartElement{Name: xml.Name{Space: NSOwner, Local: "pubsub"}},
 	), iq, &resp)
 
+	err = wrapErr(err)
 	if def {
 		return resp.Default.Data, err
 	}
@@ -79,11 +80,11 @@ func SetConfig(ctx context.Context, s *xmpp.Session, node string, cfg *form.Data
 func SetConfigIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node string, cfg *form.Data) error {
 	iq.Type = stanza.SetIQ
 	data, _ := cfg.Submit()
-	return s.UnmarshalIQElement(ctx, xmlstream.Wrap(
+	return wrapErr(s.UnmarshalIQElement(ctx, xmlstream.Wrap(
 		xmlstream.Wrap(
 			data,
 			xml.StartElement{Name: xml.Name{Local: "configure"}, Attr: []xml.Attr{{Name: xml.Name{Local: "node"}, Value: node}}},
 		),
 		xml.StartElement{Name: xml.Name{Space: NSOwner, Local: "pubsub"}},
-	), iq, nil)
+	), iq, nil))
 }
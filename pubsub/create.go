@@ -36,8 +36,8 @@ func CreateNodeIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node strin
 		))
 	}
 
-	return s.UnmarshalIQElement(ctx, xmlstream.Wrap(
+	return wrapErr(s.UnmarshalIQElement(ctx, xmlstream.Wrap(
 		payload,
 		xml.StartElement{Name: xml.Name{Space: NS, Local: "pubsub"}},
-	), iq, nil)
+	), iq, nil))
 }
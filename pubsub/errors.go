@@ -0,0 +1,86 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"errors"
+
+	"mellium.im/xmpp/stanza"
+)
+
+// Error is returned by the functions in this package when the pubsub service
+// responds with a stanza error that includes a pubsub specific condition.
+//
+// Errors can be compared against a specific condition using errors.Is:
+//
+//	if errors.Is(err, pubsub.CondItemForbidden) {
+//		…
+//	}
+//
+// The underlying stanza error can still be retrieved using errors.As.
+type Error struct {
+	Err       stanza.Error
+	Condition Condition
+
+	// Feature is the feature that is not supported if Condition is
+	// CondUnsupported and the feature is one of the known features.
+	// Otherwise it should be ignored.
+	Feature Feature
+}
+
+// Error satisfies the error interface by returning the underlying stanza
+// error's message.
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying stanza error.
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error has the condition target if target is a
+// Condition.
+func (e Error) Is(target error) bool {
+	cond, ok := target.(Condition)
+	return ok && cond == e.Condition
+}
+
+// Error satisfies the error interface for Condition so that it can be compared
+// against errors returned by this package using errors.Is.
+func (c Condition) Error() string {
+	return c.String()
+}
+
+// wrapErr converts stanza errors that contain a pubsub application-specific
+// condition into an Error.
+// Any other error is returned unchanged.
+func wrapErr(err error) error {
+	var stanzaErr stanza.Error
+	if !errors.As(err, &stanzaErr) || stanzaErr.AppCondition.XMLName.Space != NSErrors {
+		return err
+	}
+	e := Error{Err: stanzaErr}
+	for cond := CondNone; cond <= CondUnsupportedAccessModel; cond++ {
+		if cond.String() == stanzaErr.AppCondition.XMLName.Local {
+			e.Condition = cond
+			break
+		}
+	}
+	if e.Condition == CondUnsupported {
+		for _, a := range stanzaErr.AppCondition.Attr {
+			if a.Name.Local != "feature" {
+				continue
+			}
+			for f := FeatureAccessAuthorize; f <= FeatureSubscriptionNotifications; f++ {
+				if f.String() == a.Value {
+					e.Feature = f
+					break
+				}
+			}
+		}
+	}
+	return e
+}
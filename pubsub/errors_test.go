@@ -0,0 +1,57 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestErrorCondition(t *testing.T) {
+	script := xmpptest.NewScript(t)
+	script.Expect("iq[@type='set']/pubsub[@xmlns='http://jabber.org/protocol/pubsub']/subscribe[@node='princely_musings']").
+		Reply(`<iq type="error" id="{id}" from="{from}" to="{to}"><error type="cancel"><feature-not-implemented xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/><unsupported xmlns="http://jabber.org/protocol/pubsub#errors" feature="subscribe"/></error></iq>`)
+	script.Expect("iq[@type='set']/pubsub/unsubscribe").
+		Reply(`<iq type="error" id="{id}" from="{from}" to="{to}"><error type="cancel"><unexpected-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/><not-subscribed xmlns="http://jabber.org/protocol/pubsub#errors"/></error></iq>`)
+	script.Expect("iq[@type='set']/pubsub/unsubscribe").
+		Reply(`<iq type="error" id="{id}" from="{from}" to="{to}"><error type="cancel"><item-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error></iq>`)
+	s := script.ClientServer()
+
+	_, err := pubsub.Subscribe(context.Background(), s.Client, "princely_musings")
+	var pubsubErr pubsub.Error
+	if !errors.As(err, &pubsubErr) {
+		t.Fatalf("expected pubsub error, got %T(%[1]v)", err)
+	}
+	if pubsubErr.Condition != pubsub.CondUnsupported || pubsubErr.Feature != pubsub.FeatureSubscribe {
+		t.Errorf("wrong condition or feature: %v, %v", pubsubErr.Condition, pubsubErr.Feature)
+	}
+	if !errors.Is(err, pubsub.CondUnsupported) {
+		t.Errorf("expected error to match unsupported condition")
+	}
+	if !errors.Is(err, stanza.Error{Condition: stanza.FeatureNotImplemented}) {
+		t.Errorf("expected error to match underlying stanza error")
+	}
+
+	err = pubsub.Unsubscribe(context.Background(), s.Client, "princely_musings", "")
+	if !errors.Is(err, pubsub.CondNotSubscribed) {
+		t.Errorf("expected not subscribed condition, got %v", err)
+	}
+	if errors.Is(err, pubsub.CondUnsupported) {
+		t.Errorf("did not expect error to match a different condition")
+	}
+
+	err = pubsub.Unsubscribe(context.Background(), s.Client, "princely_musings", "")
+	if errors.As(err, &pubsubErr) {
+		t.Errorf("did not expect error without pubsub condition to be a pubsub error")
+	}
+	if !errors.Is(err, stanza.Error{Condition: stanza.ItemNotFound}) {
+		t.Errorf("expected stanza error to be returned unchanged, got %v", err)
+	}
+}
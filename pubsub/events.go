@@ -0,0 +1,164 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"encoding/xml"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// EventFunc is called for each event notification containing items published
+// to or retracted from a node.
+// The iterator is only valid until the function returns.
+type EventFunc func(msg stanza.Message, iter *EventIter) error
+
+// Events dispatches pubsub event notifications to functions registered for
+// the node that the event belongs to.
+// This is normally used to receive notifications about nodes subscribed to
+// with Subscribe or personal eventing (PEP) nodes such as those used for
+// avatars, nicknames, and bookmarks.
+//
+// Events other than publishing and retracting items, such as notifications
+// that a node has been deleted or purged, are ignored.
+// The zero value is ready to use.
+type Events struct {
+	mu    sync.RWMutex
+	nodes map[string]EventFunc
+}
+
+// HandleNode registers f to be called for events on node.
+// If node is empty, f is called for events on any node that does not have its
+// own function registered.
+// Registering a nil function removes any existing function for node.
+func (e *Events) HandleNode(node string, f EventFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if f == nil {
+		delete(e.nodes, node)
+		return
+	}
+	if e.nodes == nil {
+		e.nodes = make(map[string]EventFunc)
+	}
+	e.nodes[node] = f
+}
+
+func (e *Events) handler(node string) EventFunc {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if f, ok := e.nodes[node]; ok {
+		return f
+	}
+	return e.nodes[""]
+}
+
+// HandleEvents returns an option that registers the handler for use with a
+// multiplexer.
+func HandleEvents(e *Events) mux.Option {
+	return func(m *mux.ServeMux) {
+		eventName := xml.Name{Space: NSEvent, Local: "event"}
+		mux.Message(stanza.NormalMessage, eventName, e)(m)
+		mux.Message(stanza.HeadlineMessage, eventName, e)(m)
+	}
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (e *Events) HandleMessage(msg stanza.Message, r xmlstream.TokenReadEncoder) error {
+	// Pop the message start token.
+	_, err := r.Token()
+	if err != nil {
+		return err
+	}
+	iter := xmlstream.NewIter(r)
+	for iter.Next() {
+		start, payload := iter.Current()
+		if start == nil || start.Name.Space != NSEvent || start.Name.Local != "event" {
+			continue
+		}
+		events := xmlstream.NewIter(payload)
+		for events.Next() {
+			start, items := events.Current()
+			if start == nil || start.Name.Local != "items" {
+				continue
+			}
+			_, node := attr.Get(start.Attr, "node")
+			f := e.handler(node)
+			if f == nil {
+				continue
+			}
+			err = f(msg, &EventIter{
+				node: node,
+				iter: xmlstream.NewIter(items),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := events.Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// EventIter is an iterator over the items published to or retracted from a
+// node in an event notification.
+type EventIter struct {
+	node    string
+	iter    *xmlstream.Iter
+	id      string
+	retract bool
+	current xml.TokenReader
+}
+
+// Node returns the node that the items belong to.
+func (i *EventIter) Node() string {
+	return i.node
+}
+
+// Next returns true if there are more items to decode.
+func (i *EventIter) Next() bool {
+	for i.iter.Next() {
+		start, r := i.iter.Current()
+		if start == nil {
+			continue
+		}
+		switch start.Name.Local {
+		case "item", "retract":
+		default:
+			continue
+		}
+		_, i.id = attr.Get(start.Attr, "id")
+		i.retract = start.Name.Local == "retract"
+		i.current = xmlstream.Inner(r)
+		return true
+	}
+	return false
+}
+
+// Err returns the last error encountered by the iterator (if any).
+func (i *EventIter) Err() error {
+	return i.iter.Err()
+}
+
+// Item returns the ID and payload of the last item parsed by the iterator.
+// If the item was retracted or the notification does not include payloads
+// the reader will not return any tokens.
+func (i *EventIter) Item() (id string, r xml.TokenReader) {
+	return i.id, i.current
+}
+
+// Retracted reports whether the last item parsed by the iterator was
+// retracted from the node.
+func (i *EventIter) Retracted() bool {
+	return i.retract
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package pubsub_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+const eventMsg = `<message xmlns="jabber:client" from="juliet@example.com" to="romeo@example.net/orchard" type="headline">
+<event xmlns="http://jabber.org/protocol/pubsub#event">
+<items node="urn:xmpp:avatar:metadata">
+<item id="a"><metadata xmlns="urn:xmpp:avatar:metadata"/></item>
+<retract id="b"/>
+</items>
+<items node="urn:xmpp:nick">
+<item id="c"><nick xmlns="http://jabber.org/protocol/nick">Juliet</nick></item>
+</items>
+</event>
+</message>`
+
+type eventItem struct {
+	Node    string
+	ID      string
+	Retract bool
+	Payload string
+}
+
+func TestEvents(t *testing.T) {
+	var got []eventItem
+	collect := func(_ stanza.Message, iter *pubsub.EventIter) error {
+		for iter.Next() {
+			id, r := iter.Item()
+			var buf strings.Builder
+			e := xml.NewEncoder(&buf)
+			_, err := xmlstream.Copy(e, r)
+			if err != nil {
+				return err
+			}
+			if err = e.Flush(); err != nil {
+				return err
+			}
+			got = append(got, eventItem{
+				Node:    iter.Node(),
+				ID:      id,
+				Retract: iter.Retracted(),
+				Payload: buf.String(),
+			})
+		}
+		return iter.Err()
+	}
+	events := &pubsub.Events{}
+	events.HandleNode("urn:xmpp:avatar:metadata", collect)
+	m := mux.New(stanza.NSClient, pubsub.HandleEvents(events))
+
+	handle := func() error {
+		d := xml.NewDecoder(strings.NewReader(eventMsg))
+		tok, err := d.Token()
+		if err != nil {
+			t.Fatalf("error popping start token: %v", err)
+		}
+		start := tok.(xml.StartElement)
+		return m.HandleXMPP(struct {
+			xml.TokenReader
+			xmlstream.Encoder
+		}{
+			TokenReader: d,
+		}, &start)
+	}
+
+	err := handle()
+	if err != nil {
+		t.Fatalf("error handling event: %v", err)
+	}
+	want := []eventItem{
+		{Node: "urn:xmpp:avatar:metadata", ID: "a", Payload: `<metadata xmlns="urn:xmpp:avatar:metadata" xmlns="urn:xmpp:avatar:metadata"></metadata>`},
+		{Node: "urn:xmpp:avatar:metadata", ID: "b", Retract: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong items:\nwant=%+v,\n got=%+v", want, got)
+	}
+
+	// Register a fallback for all other nodes.
+	got = nil
+	events.HandleNode("", collect)
+	err = handle()
+	if err != nil {
+		t.Fatalf("error handling event: %v", err)
+	}
+	want = append(want, eventItem{Node: "urn:xmpp:nick", ID: "c", Payload: `<nick xmlns="http://jabber.org/protocol/nick" xmlns="http://jabber.org/protocol/nick">Juliet</nick>`})
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong items with fallback:\nwant=%+v,\n got=%+v", want, got)
+	}
+
+	errHandler := errors.New("handler error")
+	events.HandleNode("urn:xmpp:nick", func(stanza.Message, *pubsub.EventIter) error {
+		return errHandler
+	})
+	err = handle()
+	// The multiplexer collects errors from handlers so we can't use errors.Is.
+	if err == nil || !strings.Contains(err.Error(), errHandler.Error()) {
+		t.Fatalf("wrong error: want=%v, got=%v", errHandler, err)
+	}
+}
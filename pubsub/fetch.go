@@ -84,7 +84,7 @@ func FetchIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session, q Query) *Iter
 		if err != nil {
 			/* #nosec */
 			resp.Close()
-			return &Iter{err: err}
+			return &Iter{err: wrapErr(err)}
 		}
 	}
 
@@ -54,6 +54,7 @@ func PublishIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node, id stri
 		),
 		xml.StartElement{Name: xml.Name{Space: NS, Local: "pubsub"}},
 	), iq, &resp)
+	err = wrapErr(err)
 	if resp.Publish.Item.ID == "" {
 		return id, err
 	}
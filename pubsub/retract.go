@@ -29,7 +29,7 @@ func DeleteIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node, id strin
 			Value: "true",
 		})
 	}
-	return s.UnmarshalIQElement(ctx, xmlstream.Wrap(
+	return wrapErr(s.UnmarshalIQElement(ctx, xmlstream.Wrap(
 		xmlstream.Wrap(
 			xmlstream.Wrap(
 				nil,
@@ -38,5 +38,5 @@ func DeleteIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node, id strin
 			xml.StartElement{Name: xml.Name{Local: "retract"}, Attr: retractAttrs},
 		),
 		xml.StartElement{Name: xml.Name{Space: NS, Local: "pubsub"}},
-	), iq, nil)
+	), iq, nil))
 }
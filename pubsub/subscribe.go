@@ -0,0 +1,116 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// UnmarshalXMLAttr satisfies xml.UnmarshalerAttr.
+func (t *SubType) UnmarshalXMLAttr(attr xml.Attr) error {
+	for st := SubNone; st <= SubUnconfigured; st++ {
+		if st.String() == attr.Value {
+			*t = st
+			return nil
+		}
+	}
+	return fmt.Errorf("pubsub: unknown subscription type %q", attr.Value)
+}
+
+// MarshalXMLAttr satisfies xml.MarshalerAttr.
+func (t SubType) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: t.String()}, nil
+}
+
+// Subscription is the state of a subscription to a node.
+type Subscription struct {
+	XMLName xml.Name `xml:"subscription"`
+	Node    string   `xml:"node,attr,omitempty"`
+	JID     jid.JID  `xml:"jid,attr"`
+	SubID   string   `xml:"subid,attr,omitempty"`
+	Type    SubType  `xml:"subscription,attr"`
+}
+
+// Subscribe subscribes the bare JID of the session to a node so that
+// notifications are sent when items are published to or retracted from the
+// node.
+// Notifications can be received by registering an Events handler with a
+// multiplexer.
+func Subscribe(ctx context.Context, s *xmpp.Session, node string) (Subscription, error) {
+	return SubscribeIQ(ctx, s, stanza.IQ{}, node)
+}
+
+// SubscribeIQ is like Subscribe except that it allows modifying the IQ.
+// Changes to the IQ type will have no effect.
+func SubscribeIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node string) (Subscription, error) {
+	iq.Type = stanza.SetIQ
+	subscriber := s.LocalAddr().Bare()
+	resp := struct {
+		XMLName      xml.Name     `xml:"http://jabber.org/protocol/pubsub pubsub"`
+		Subscription Subscription `xml:"subscription"`
+	}{}
+	err := s.UnmarshalIQElement(ctx, xmlstream.Wrap(
+		xmlstream.Wrap(
+			nil,
+			xml.StartElement{Name: xml.Name{Local: "subscribe"}, Attr: []xml.Attr{
+				{Name: xml.Name{Local: "node"}, Value: node},
+				{Name: xml.Name{Local: "jid"}, Value: subscriber.String()},
+			}},
+		),
+		xml.StartElement{Name: xml.Name{Space: NS, Local: "pubsub"}},
+	), iq, &resp)
+	if err != nil {
+		return Subscription{}, wrapErr(err)
+	}
+	sub := resp.Subscription
+	// Services are not required to include the subscription in the response,
+	// in which case success means that we are subscribed.
+	if sub.XMLName.Local == "" {
+		sub.Type = SubSubscribed
+	}
+	if sub.Node == "" {
+		sub.Node = node
+	}
+	if sub.JID.Equal(jid.JID{}) {
+		sub.JID = subscriber
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes the session's subscription to a node.
+// If subID is not empty, only the subscription with that ID is removed.
+func Unsubscribe(ctx context.Context, s *xmpp.Session, node, subID string) error {
+	return UnsubscribeIQ(ctx, s, stanza.IQ{}, node, subID)
+}
+
+// UnsubscribeIQ is like Unsubscribe except that it allows modifying the IQ.
+// Changes to the IQ type will have no effect.
+func UnsubscribeIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, node, subID string) error {
+	iq.Type = stanza.SetIQ
+	unsubAttrs := []xml.Attr{
+		{Name: xml.Name{Local: "node"}, Value: node},
+		{Name: xml.Name{Local: "jid"}, Value: s.LocalAddr().Bare().String()},
+	}
+	if subID != "" {
+		unsubAttrs = append(unsubAttrs, xml.Attr{
+			Name:  xml.Name{Local: "subid"},
+			Value: subID,
+		})
+	}
+	return wrapErr(s.UnmarshalIQElement(ctx, xmlstream.Wrap(
+		xmlstream.Wrap(
+			nil,
+			xml.StartElement{Name: xml.Name{Local: "unsubscribe"}, Attr: unsubAttrs},
+		),
+		xml.StartElement{Name: xml.Name{Space: NS, Local: "pubsub"}},
+	), iq, nil))
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package pubsub_test
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+// replyWith returns a server handler that records the payload of an IQ and
+// responds with the provided payload.
+func replyWith(payload string, got chan<- string) xmpptest.Option {
+	return xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		var buf strings.Builder
+		e := xml.NewEncoder(&buf)
+		_, err = xmlstream.Copy(e, xmlstream.Inner(t))
+		if err != nil {
+			return err
+		}
+		if err = e.Flush(); err != nil {
+			return err
+		}
+		got <- buf.String()
+		var r xml.TokenReader
+		if payload != "" {
+			r = xml.NewDecoder(strings.NewReader(payload))
+		}
+		_, err = xmlstream.Copy(t, iq.Result(r))
+		return err
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	got := make(chan string, 1)
+	s := xmpptest.NewClientServer(replyWith(`<pubsub xmlns="http://jabber.org/protocol/pubsub"><subscription node="princely_musings" jid="test@example.net" subid="ba49252aaa4f5d320c24d3766f0bdcade78c78d3" subscription="unconfigured"/></pubsub>`, got))
+
+	sub, err := pubsub.Subscribe(context.Background(), s.Client, "princely_musings")
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+	const expected = `<pubsub xmlns="http://jabber.org/protocol/pubsub" xmlns="http://jabber.org/protocol/pubsub"><subscribe xmlns="http://jabber.org/protocol/pubsub" node="princely_musings" jid="test@example.net"></subscribe></pubsub>`
+	if req := <-got; req != expected {
+		t.Errorf("wrong request:\nwant=%s,\n got=%s", expected, req)
+	}
+	if sub.Node != "princely_musings" || sub.SubID != "ba49252aaa4f5d320c24d3766f0bdcade78c78d3" || sub.Type != pubsub.SubUnconfigured {
+		t.Errorf("wrong subscription: %+v", sub)
+	}
+	if j := sub.JID.String(); j != "test@example.net" {
+		t.Errorf("wrong JID: want=test@example.net, got=%s", j)
+	}
+}
+
+func TestSubscribeEmptyResponse(t *testing.T) {
+	got := make(chan string, 1)
+	s := xmpptest.NewClientServer(replyWith("", got))
+
+	sub, err := pubsub.Subscribe(context.Background(), s.Client, "princely_musings")
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+	<-got
+	if sub.Node != "princely_musings" || sub.Type != pubsub.SubSubscribed || !sub.JID.Equal(s.Client.LocalAddr().Bare()) {
+		t.Errorf("wrong subscription: %+v", sub)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	got := make(chan string, 1)
+	s := xmpptest.NewClientServer(replyWith("", got))
+
+	err := pubsub.Unsubscribe(context.Background(), s.Client, "princely_musings", "123")
+	if err != nil {
+		t.Fatalf("error unsubscribing: %v", err)
+	}
+	const expected = `<pubsub xmlns="http://jabber.org/protocol/pubsub" xmlns="http://jabber.org/protocol/pubsub"><unsubscribe xmlns="http://jabber.org/protocol/pubsub" node="princely_musings" jid="test@example.net" subid="123"></unsubscribe></pubsub>`
+	if req := <-got; req != expected {
+		t.Errorf("wrong request:\nwant=%s,\n got=%s", expected, req)
+	}
+}
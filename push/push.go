@@ -0,0 +1,112 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -vars=Feature:NS
+
+// Package push implements the client side of XEP-0357: Push Notifications.
+//
+// Push notifications let a server notify an app server, such as a gateway to a
+// mobile platforms push service, when a message arrives for a client that is
+// not currently connected.
+// The client registers the app server with its own server using Enable, and
+// the server then publishes a notification to the provided pubsub node
+// whenever the client should wake up.
+// The details of registering with the app server itself (and obtaining the JID,
+// node, and any secret that it requires) are specific to each app server and
+// are not covered by this package.
+package push // import "mellium.im/xmpp/push"
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"sort"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Namespaces used by this package, provided as a convenience.
+const (
+	NS = "urn:xmpp:push:0"
+
+	// NSPublishOptions is the FORM_TYPE of the publish options that are sent to
+	// the app server with each notification.
+	NSPublishOptions = "http://jabber.org/protocol/pubsub#publish-options"
+)
+
+// Service is a pubsub node on an app server that notifications are published
+// to.
+type Service struct {
+	JID  jid.JID
+	Node string
+}
+
+func (svc Service) tokenReader(local string, payload xml.TokenReader) xml.TokenReader {
+	attrs := []xml.Attr{{Name: xml.Name{Local: "jid"}, Value: svc.JID.String()}}
+	if svc.Node != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "node"}, Value: svc.Node})
+	}
+	return xmlstream.Wrap(payload, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: local},
+		Attr: attrs,
+	})
+}
+
+// PublishOptions returns a form containing the provided options, such as a
+// secret required by the app server, that can be passed to Enable.
+func PublishOptions(opts map[string]string) *form.Data {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := []form.Field{form.Hidden("FORM_TYPE", form.Value(NSPublishOptions))}
+	for _, k := range keys {
+		fields = append(fields, form.Text(k, form.Value(opts[k])))
+	}
+	return form.New(fields...)
+}
+
+// Enable asks the users server to send push notifications to svc.
+// If opts is not nil, it is submitted to the server and included with each
+// notification that it publishes.
+// If opts is missing the value of a required field, an error wrapping
+// form.ErrRequired is returned and nothing is sent.
+// For more information see PublishOptions.
+func Enable(ctx context.Context, s *xmpp.Session, svc Service, opts *form.Data) error {
+	return EnableIQ(ctx, s, stanza.IQ{}, svc, opts)
+}
+
+// EnableIQ is like Enable except that it allows modifying the IQ.
+// Changes to the IQ type will have no effect.
+func EnableIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, svc Service, opts *form.Data) error {
+	iq.Type = stanza.SetIQ
+	var payload xml.TokenReader
+	if opts != nil {
+		var ok bool
+		payload, ok = opts.Submit()
+		if !ok {
+			return fmt.Errorf("push: publish options: %w", form.ErrRequired)
+		}
+	}
+	return s.UnmarshalIQElement(ctx, svc.tokenReader("enable", payload), iq, nil)
+}
+
+// Disable asks the users server to stop sending push notifications to svc.
+// If the node is empty, notifications to all nodes on the app server are
+// disabled.
+func Disable(ctx context.Context, s *xmpp.Session, svc Service) error {
+	return DisableIQ(ctx, s, stanza.IQ{}, svc)
+}
+
+// DisableIQ is like Disable except that it allows modifying the IQ.
+// Changes to the IQ type will have no effect.
+func DisableIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, svc Service) error {
+	iq.Type = stanza.SetIQ
+	return s.UnmarshalIQElement(ctx, svc.tokenReader("disable", nil), iq, nil)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package push_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/form"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/push"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestEnableDisable(t *testing.T) {
+	var payloads []string
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(e xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			iq, err := stanza.NewIQ(*start)
+			if err != nil {
+				return err
+			}
+			var b strings.Builder
+			enc := xml.NewEncoder(&b)
+			// Remove the namespace attributes that the decoder reports so that they
+			// are not duplicated when the tokens are encoded.
+			_, err = xmlstream.Copy(enc, xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(xmlstream.Inner(e)))
+			if err != nil {
+				return err
+			}
+			err = enc.Flush()
+			if err != nil {
+				return err
+			}
+			payloads = append(payloads, b.String())
+			_, err = xmlstream.Copy(e, iq.Result(nil))
+			return err
+		}),
+	)
+	svc := push.Service{
+		JID:  jid.MustParse("push-5.client.example"),
+		Node: "yxs32uqsflafdk3iuqo",
+	}
+	err := push.Enable(context.Background(), cs.Client, svc, push.PublishOptions(map[string]string{
+		"secret": "eruio234vzxc2kla-91",
+	}))
+	if err != nil {
+		t.Fatalf("error enabling push: %v", err)
+	}
+	svc.Node = ""
+	err = push.Disable(context.Background(), cs.Client, svc)
+	if err != nil {
+		t.Fatalf("error disabling push: %v", err)
+	}
+
+	want := []string{
+		`<enable xmlns="urn:xmpp:push:0" jid="push-5.client.example" node="yxs32uqsflafdk3iuqo"><x xmlns="jabber:x:data" type="submit"><field xmlns="jabber:x:data" type="hidden" var="FORM_TYPE"><value xmlns="jabber:x:data">http://jabber.org/protocol/pubsub#publish-options</value></field><field xmlns="jabber:x:data" type="text-single" var="secret"><value xmlns="jabber:x:data">eruio234vzxc2kla-91</value></field></x></enable>`,
+		`<disable xmlns="urn:xmpp:push:0" jid="push-5.client.example"></disable>`,
+	}
+	if len(payloads) != len(want) {
+		t.Fatalf("wrong number of requests: want=%d, got=%d: %v", len(want), len(payloads), payloads)
+	}
+	for i, p := range payloads {
+		if p != want[i] {
+			t.Errorf("wrong payload %d:\nwant=%s,\n got=%s", i, want[i], p)
+		}
+	}
+}
+
+func TestEnableMissingRequired(t *testing.T) {
+	var requests int
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(e xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			requests++
+			iq, err := stanza.NewIQ(*start)
+			if err != nil {
+				return err
+			}
+			_, err = xmlstream.Copy(e, iq.Result(nil))
+			return err
+		}),
+	)
+	opts := form.New(form.Text("secret", form.Required))
+	err := push.Enable(context.Background(), cs.Client, push.Service{
+		JID: jid.MustParse("push-5.client.example"),
+	}, opts)
+	if !errors.Is(err, form.ErrRequired) {
+		t.Errorf("wrong error: want=%v, got=%v", form.ErrRequired, err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests to be sent, got %d", requests)
+	}
+}
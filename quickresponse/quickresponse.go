@@ -0,0 +1,290 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package quickresponse implements XEP-0439: Quick Response.
+//
+// Quick responses let an entity, normally a bot, suggest replies to a message
+// that the user can choose from instead of typing a response.
+// Supporting clients may show them as buttons.
+// There are two kinds of suggestion: responses, which are sent back as the
+// body of a normal message when selected, and actions, which are sent back
+// using their ID and do not have to be shown to the user.
+package quickresponse // import "mellium.im/xmpp/quickresponse"
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/stanza"
+)
+
+// NS is the namespace used by this package.
+const NS = "urn:xmpp:tmp:quick-response"
+
+// Response is a suggested reply to a message.
+// If the response is selected, its value is sent as the body of the reply.
+type Response struct {
+	XMLName xml.Name `xml:"urn:xmpp:tmp:quick-response response"`
+	Value   string   `xml:"value,attr"`
+	Label   string   `xml:"label,attr,omitempty"`
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (r Response) TokenReader() xml.TokenReader {
+	attrs := []xml.Attr{{Name: xml.Name{Local: "value"}, Value: r.Value}}
+	if r.Label != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "label"}, Value: r.Label})
+	}
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "response"},
+		Attr: attrs,
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (r Response) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, r.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (r Response) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := r.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// Action is a suggested action that can be taken in response to a message.
+// If the action is selected, its ID is sent back in the reply.
+type Action struct {
+	XMLName xml.Name `xml:"urn:xmpp:tmp:quick-response action"`
+	ID      string   `xml:"id,attr"`
+	Label   string   `xml:"label,attr,omitempty"`
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (a Action) TokenReader() xml.TokenReader {
+	attrs := []xml.Attr{{Name: xml.Name{Local: "id"}, Value: a.ID}}
+	if a.Label != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "label"}, Value: a.Label})
+	}
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "action"},
+		Attr: attrs,
+	})
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (a Action) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, a.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (a Action) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := a.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// Message is a message containing quick response suggestions.
+type Message struct {
+	stanza.Message
+
+	Body      string
+	Responses []Response
+	Actions   []Action
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (m Message) TokenReader() xml.TokenReader {
+	var inner []xml.TokenReader
+	if m.Body != "" {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(m.Body)),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		))
+	}
+	for _, r := range m.Responses {
+		inner = append(inner, r.TokenReader())
+	}
+	for _, a := range m.Actions {
+		inner = append(inner, a.TokenReader())
+	}
+	return m.Message.Wrap(xmlstream.MultiReader(inner...))
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (m Message) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, m.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (m Message) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := m.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+// The start element must be a message stanza.
+func (m *Message) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	msg, err := stanza.NewMessage(start)
+	if err != nil {
+		return err
+	}
+	v := struct {
+		Body      []string   `xml:"body"`
+		Responses []Response `xml:"urn:xmpp:tmp:quick-response response"`
+		Actions   []Action   `xml:"urn:xmpp:tmp:quick-response action"`
+	}{}
+	err = d.DecodeElement(&v, &start)
+	if err != nil {
+		return err
+	}
+	*m = Message{
+		Message:   msg,
+		Responses: v.Responses,
+		Actions:   v.Actions,
+	}
+	if len(v.Body) > 0 {
+		m.Body = v.Body[0]
+	}
+	return nil
+}
+
+// Selection is a reply to a message containing quick response suggestions.
+// It may be a response, in which case the body of the message is the value of
+// the selected response, or an action.
+type Selection struct {
+	stanza.Message
+
+	Body string
+
+	// ActionID is the ID of the selected action or empty if no action was
+	// selected.
+	ActionID string
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (s Selection) TokenReader() xml.TokenReader {
+	var inner []xml.TokenReader
+	if s.Body != "" {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(s.Body)),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		))
+	}
+	if s.ActionID != "" {
+		inner = append(inner, xmlstream.Wrap(nil, xml.StartElement{
+			Name: xml.Name{Space: NS, Local: "action-selected"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: s.ActionID}},
+		}))
+	}
+	return s.Message.Wrap(xmlstream.MultiReader(inner...))
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (s Selection) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, s.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (s Selection) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := s.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+// The start element must be a message stanza.
+func (s *Selection) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	msg, err := stanza.NewMessage(start)
+	if err != nil {
+		return err
+	}
+	v := struct {
+		Body     []string `xml:"body"`
+		Selected *struct {
+			ID string `xml:"id,attr"`
+		} `xml:"urn:xmpp:tmp:quick-response action-selected"`
+	}{}
+	err = d.DecodeElement(&v, &start)
+	if err != nil {
+		return err
+	}
+	*s = Selection{
+		Message: msg,
+	}
+	if len(v.Body) > 0 {
+		s.Body = v.Body[0]
+	}
+	if v.Selected != nil {
+		s.ActionID = v.Selected.ID
+	}
+	return nil
+}
+
+// Response returns the response that was selected from the provided
+// responses, if any.
+// Because a selected response is indistinguishable from a user typing the
+// same text, a reply is considered to be a response if its body matches the
+// value of one of the responses exactly.
+func (s Selection) Response(responses []Response) (Response, bool) {
+	if s.ActionID != "" {
+		return Response{}, false
+	}
+	for _, r := range responses {
+		if r.Value == s.Body {
+			return r, true
+		}
+	}
+	return Response{}, false
+}
+
+// Action returns the action that was selected from the provided actions, if
+// any.
+func (s Selection) Action(actions []Action) (Action, bool) {
+	if s.ActionID == "" {
+		return Action{}, false
+	}
+	for _, a := range actions {
+		if a.ID == s.ActionID {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// Send sends a message with quick response suggestions.
+func Send(ctx context.Context, s *xmpp.Session, m Message) error {
+	return s.Send(ctx, m.TokenReader())
+}
+
+// Respond replies to a message containing quick responses with the selected
+// response.
+// The reply is addressed using the provided message, which should normally be
+// the message containing the response with its to and from addresses swapped.
+func Respond(ctx context.Context, s *xmpp.Session, msg stanza.Message, r Response) error {
+	return s.Send(ctx, Selection{Message: msg, Body: r.Value}.TokenReader())
+}
+
+// Act replies to a message containing quick responses with the selected
+// action.
+// For more information see Respond.
+func Act(ctx context.Context, s *xmpp.Session, msg stanza.Message, a Action) error {
+	return s.Send(ctx, Selection{Message: msg, ActionID: a.ID}.TokenReader())
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package quickresponse_test
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/quickresponse"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xmlstream.Marshaler = quickresponse.Message{}
+	_ xmlstream.WriterTo  = quickresponse.Message{}
+	_ xml.Marshaler       = quickresponse.Message{}
+	_ xml.Unmarshaler     = (*quickresponse.Message)(nil)
+	_ xmlstream.Marshaler = quickresponse.Selection{}
+	_ xmlstream.WriterTo  = quickresponse.Selection{}
+	_ xml.Marshaler       = quickresponse.Selection{}
+	_ xml.Unmarshaler     = (*quickresponse.Selection)(nil)
+	_ xmlstream.Marshaler = quickresponse.Response{}
+	_ xmlstream.Marshaler = quickresponse.Action{}
+)
+
+var (
+	yes   = quickresponse.Response{Value: "yes", Label: "Yes"}
+	no    = quickresponse.Response{Value: "no"}
+	merge = quickresponse.Action{ID: "merge", Label: "Merge"}
+)
+
+var encodeTestCases = [...]struct {
+	v   interface{}
+	out string
+}{
+	0: {
+		v: quickresponse.Message{
+			Message:   stanza.Message{To: jid.MustParse("juliet@example.com"), Type: stanza.ChatMessage},
+			Body:      "Merge pull request?",
+			Responses: []quickresponse.Response{yes, no},
+			Actions:   []quickresponse.Action{merge},
+		},
+		out: `<message type="chat" to="juliet@example.com"><body>Merge pull request?</body><response xmlns="urn:xmpp:tmp:quick-response" value="yes" label="Yes"></response><response xmlns="urn:xmpp:tmp:quick-response" value="no"></response><action xmlns="urn:xmpp:tmp:quick-response" id="merge" label="Merge"></action></message>`,
+	},
+	1: {
+		v: quickresponse.Selection{
+			Message: stanza.Message{To: jid.MustParse("bot@example.com"), Type: stanza.ChatMessage},
+			Body:    "yes",
+		},
+		out: `<message type="chat" to="bot@example.com"><body>yes</body></message>`,
+	},
+	2: {
+		v: quickresponse.Selection{
+			Message:  stanza.Message{To: jid.MustParse("bot@example.com"), Type: stanza.ChatMessage},
+			ActionID: "merge",
+		},
+		out: `<message type="chat" to="bot@example.com"><action-selected xmlns="urn:xmpp:tmp:quick-response" id="merge"></action-selected></message>`,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for i, tc := range encodeTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := xml.Marshal(tc.v)
+			if err != nil {
+				t.Fatalf("error marshaling: %v", err)
+			}
+			if string(out) != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	const in = `<message xmlns="jabber:client" from="bot@example.com" type="chat"><body>Merge pull request?</body><response xmlns="urn:xmpp:tmp:quick-response" value="yes" label="Yes"/><response xmlns="urn:xmpp:tmp:quick-response" value="no"/><action xmlns="urn:xmpp:tmp:quick-response" id="merge" label="Merge"/></message>`
+	var msg quickresponse.Message
+	err := xml.Unmarshal([]byte(in), &msg)
+	if err != nil {
+		t.Fatalf("error unmarshaling: %v", err)
+	}
+	if msg.Body != "Merge pull request?" {
+		t.Errorf("wrong body: %q", msg.Body)
+	}
+	if !msg.From.Equal(jid.MustParse("bot@example.com")) || msg.Type != stanza.ChatMessage {
+		t.Errorf("wrong message: %+v", msg.Message)
+	}
+	for i := range msg.Responses {
+		msg.Responses[i].XMLName = xml.Name{}
+	}
+	for i := range msg.Actions {
+		msg.Actions[i].XMLName = xml.Name{}
+	}
+	if want := []quickresponse.Response{yes, no}; !reflect.DeepEqual(msg.Responses, want) {
+		t.Errorf("wrong responses: want=%+v, got=%+v", want, msg.Responses)
+	}
+	if want := []quickresponse.Action{merge}; !reflect.DeepEqual(msg.Actions, want) {
+		t.Errorf("wrong actions: want=%+v, got=%+v", want, msg.Actions)
+	}
+}
+
+var selectionTestCases = [...]struct {
+	in       string
+	response quickresponse.Response
+	action   quickresponse.Action
+}{
+	0: {
+		in:       `<message xmlns="jabber:client" type="chat"><body>yes</body></message>`,
+		response: yes,
+	},
+	1: {
+		in: `<message xmlns="jabber:client" type="chat"><body>maybe</body></message>`,
+	},
+	2: {
+		in:     `<message xmlns="jabber:client" type="chat"><action-selected xmlns="urn:xmpp:tmp:quick-response" id="merge"/></message>`,
+		action: merge,
+	},
+	3: {
+		// A selected action is never a response, even if the body matches.
+		in:     `<message xmlns="jabber:client" type="chat"><body>no</body><action-selected xmlns="urn:xmpp:tmp:quick-response" id="merge"/></message>`,
+		action: merge,
+	},
+	4: {
+		in: `<message xmlns="jabber:client" type="chat"><action-selected xmlns="urn:xmpp:tmp:quick-response" id="close"/></message>`,
+	},
+}
+
+func TestSelection(t *testing.T) {
+	for i, tc := range selectionTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var sel quickresponse.Selection
+			err := xml.Unmarshal([]byte(tc.in), &sel)
+			if err != nil {
+				t.Fatalf("error unmarshaling: %v", err)
+			}
+			r, ok := sel.Response([]quickresponse.Response{yes, no})
+			if ok != (tc.response != quickresponse.Response{}) || r != tc.response {
+				t.Errorf("wrong response: want=%+v, got=%+v (%t)", tc.response, r, ok)
+			}
+			a, ok := sel.Action([]quickresponse.Action{merge})
+			if ok != (tc.action != quickresponse.Action{}) || a != tc.action {
+				t.Errorf("wrong action: want=%+v, got=%+v (%t)", tc.action, a, ok)
+			}
+		})
+	}
+}
+
+func TestAct(t *testing.T) {
+	sent := make(chan quickresponse.Selection, 1)
+	s := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			var sel quickresponse.Selection
+			err := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r)).Decode(&sel)
+			if err != nil {
+				return err
+			}
+			sent <- sel
+			return nil
+		}),
+	)
+	defer s.Close()
+
+	err := quickresponse.Act(context.Background(), s.Client, stanza.Message{Type: stanza.ChatMessage}, merge)
+	if err != nil {
+		t.Fatalf("error sending action: %v", err)
+	}
+	select {
+	case sel := <-sent:
+		if a, ok := sel.Action([]quickresponse.Action{merge}); !ok || a != merge {
+			t.Errorf("wrong action received: want=%+v, got=%+v", merge, sel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for selection")
+	}
+}
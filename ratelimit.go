@@ -0,0 +1,204 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"encoding/xml"
+	"math"
+	"time"
+)
+
+// RateLimit throttles the rate at which Serve reads elements from the input
+// stream using a token bucket for the number of elements and another for their
+// size.
+// Once either bucket is empty, OnLimit is called when the next element starts
+// and the rest of the element is not read until enough time has passed for the
+// bucket to refill.
+// Because nothing is read from the connection while Serve is waiting, a sender
+// that continues to send data will eventually be blocked by the underlying
+// transport.
+//
+// Any top level element counts against the limit including stanzas, stream
+// management acks, and responses to IQs sent by the session, but whitespace
+// keepalives do not.
+type RateLimit struct {
+	// Stanzas is the number of elements per second that may be read.
+	// If Stanzas is zero the number of elements is not limited.
+	Stanzas float64
+
+	// Burst is the number of elements that may be read at once before the rate
+	// applies.
+	// If Burst is less than one it is set to Stanzas rounded up, or one if
+	// Stanzas is less than one.
+	Burst int
+
+	// Bytes is the number of bytes per second that may be read.
+	// The size of an element is the combined length of its names, attributes,
+	// and character data, so it is close to but not exactly the number of bytes
+	// read from the connection.
+	// If Bytes is zero the size of elements is not limited.
+	Bytes float64
+
+	// ByteBurst is the number of bytes that may be read at once before the rate
+	// applies.
+	// Elements are only counted once they have been read, so a single element
+	// may be larger than ByteBurst.
+	// If ByteBurst is less than one it is set to Bytes rounded up.
+	ByteBurst int
+
+	// OnLimit is called each time the limit is reached before waiting for the
+	// bucket to refill.
+	// The delay is the amount of time that Serve will wait before reading the
+	// next element.
+	// If OnLimit returns an error, Serve stops and the error is sent to the
+	// remote entity as if it had been returned from a handler.
+	// To end the session with a meaningful error, return a stream error such as
+	// stream.PolicyViolation.
+	OnLimit func(s *Session, delay time.Duration) error
+}
+
+// bucket is a token bucket that may go into debt.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+	b := float64(burst)
+	if b < 1 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+	return &bucket{
+		rate:   rate,
+		burst:  b,
+		tokens: b,
+		last:   now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// delay returns how long it will be until the bucket contains at least n
+// tokens.
+func (b *bucket) delay(now time.Time, n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *bucket) take(now time.Time, n float64) {
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	b.tokens -= n
+}
+
+// rateLimiter applies a RateLimit to a session.
+type rateLimiter struct {
+	s       *Session
+	stanzas *bucket
+	bytes   *bucket
+	onLimit func(*Session, time.Duration) error
+}
+
+func newRateLimiter(s *Session, l *RateLimit) *rateLimiter {
+	if l == nil || (l.Stanzas <= 0 && l.Bytes <= 0) {
+		return nil
+	}
+	now := time.Now()
+	return &rateLimiter{
+		s:       s,
+		stanzas: newBucket(l.Stanzas, l.Burst, now),
+		bytes:   newBucket(l.Bytes, l.ByteBurst, now),
+		onLimit: l.OnLimit,
+	}
+}
+
+// wait blocks until the element that has just been started may be read or the
+// input stream is closed.
+func (l *rateLimiter) wait() error {
+	if l == nil {
+		return nil
+	}
+	now := time.Now()
+	delay := l.stanzas.delay(now, 1)
+	// The size of the next element isn't known yet so just wait until any debt
+	// from previous elements has been paid off.
+	if d := l.bytes.delay(now, 0); d > delay {
+		delay = d
+	}
+	if delay <= 0 {
+		return nil
+	}
+	if l.onLimit != nil {
+		err := l.onLimit(l.s, delay)
+		if err != nil {
+			return err
+		}
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-l.s.in.ctx.Done():
+	}
+	return nil
+}
+
+// counter returns a token reader that counts the elements and bytes read from
+// r against the limit.
+func (l *rateLimiter) counter(r xml.TokenReader) xml.TokenReader {
+	if l == nil {
+		return r
+	}
+	return &limitReader{r: r, l: l}
+}
+
+type limitReader struct {
+	r     xml.TokenReader
+	l     *rateLimiter
+	depth int
+	size  int
+}
+
+func (r *limitReader) Token() (xml.Token, error) {
+	tok, err := r.r.Token()
+	switch t := tok.(type) {
+	case xml.StartElement:
+		r.depth++
+		r.size += len(t.Name.Local) + 2
+		for _, attr := range t.Attr {
+			r.size += len(attr.Name.Local) + len(attr.Value) + 4
+		}
+	case xml.EndElement:
+		r.depth--
+		r.size += len(t.Name.Local) + 3
+		if r.depth == 0 {
+			now := time.Now()
+			r.l.stanzas.take(now, 1)
+			r.l.bytes.take(now, float64(r.size))
+			r.size = 0
+		}
+	case xml.CharData:
+		if r.depth > 0 {
+			r.size += len(t)
+		}
+	}
+	return tok, err
+}
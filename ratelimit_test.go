@@ -0,0 +1,119 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/stream"
+)
+
+var rateLimitTests = [...]struct {
+	limit   xmpp.RateLimit
+	in      string
+	handled int
+	limited int
+	err     error
+}{
+	0: {
+		limit:   xmpp.RateLimit{Stanzas: 0.001, Burst: 2},
+		in:      `<message id="1"/><message id="2"/> <message id="3"/>`,
+		handled: 2,
+		limited: 1,
+		err:     stream.PolicyViolation,
+	},
+	1: {
+		limit:   xmpp.RateLimit{Bytes: 0.001, ByteBurst: 10},
+		in:      `<message id="1"><body>too long</body></message><message id="2"/>`,
+		handled: 1,
+		limited: 1,
+		err:     stream.PolicyViolation,
+	},
+	2: {
+		limit:   xmpp.RateLimit{Stanzas: 0.001, Burst: 3, Bytes: 0.001, ByteBurst: 1000},
+		in:      `<message id="1"/><message id="2"/><message id="3"/>`,
+		handled: 3,
+	},
+	3: {
+		in:      `<message id="1"/><message id="2"/><message id="3"/>`,
+		handled: 3,
+	},
+}
+
+func TestRateLimit(t *testing.T) {
+	for i, tc := range rateLimitTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			s := xmpptest.NewClientSession(0, struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(tc.in),
+				Writer: io.Discard,
+			})
+			var handled, limited int
+			tc.limit.OnLimit = func(*xmpp.Session, time.Duration) error {
+				limited++
+				return stream.PolicyViolation
+			}
+			err := s.ServeWithConfig(xmpp.HandlerFunc(func(xmlstream.TokenReadEncoder, *xml.StartElement) error {
+				handled++
+				return nil
+			}), xmpp.ServeConfig{RateLimit: &tc.limit})
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error: want=%v, got=%v", tc.err, err)
+			}
+			if handled != tc.handled {
+				t.Errorf("wrong number of elements handled: want=%d, got=%d", tc.handled, handled)
+			}
+			if limited != tc.limited {
+				t.Errorf("wrong number of calls to OnLimit: want=%d, got=%d", tc.limited, limited)
+			}
+		})
+	}
+}
+
+func TestRateLimitThrottle(t *testing.T) {
+	const n = 5
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(strings.Repeat(`<message/>`, n)),
+		Writer: io.Discard,
+	})
+	var delays int
+	start := time.Now()
+	err := s.ServeWithConfig(nil, xmpp.ServeConfig{
+		RateLimit: &xmpp.RateLimit{
+			Stanzas: 100,
+			Burst:   1,
+			OnLimit: func(_ *xmpp.Session, delay time.Duration) error {
+				if delay <= 0 || delay > 10*time.Millisecond {
+					t.Errorf("unexpected delay: %v", delay)
+				}
+				delays++
+				return nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if delays != n-1 {
+		t.Errorf("wrong number of delays: want=%d, got=%d", n-1, delays)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("reading was not throttled, took %v", elapsed)
+	}
+}
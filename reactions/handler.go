@@ -0,0 +1,219 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package reactions
+
+import (
+	"encoding/xml"
+	"sort"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle returns an option that registers a Handler for reactions in chat and
+// group chat messages.
+func Handle(h *Handler) mux.Option {
+	return func(m *mux.ServeMux) {
+		name := xml.Name{Space: NS, Local: "reactions"}
+		mux.Message(stanza.ChatMessage, name, h)(m)
+		mux.Message(stanza.GroupChatMessage, name, h)(m)
+	}
+}
+
+// Change is the difference between the reactions an entity had on a message and
+// the reactions that it has after an update.
+type Change struct {
+	// ID is the ID of the message that was reacted to.
+	ID string
+
+	// From is the entity that reacted.
+	// For chat messages it is the bare JID of the sender, for group chat
+	// messages it is the occupant JID.
+	From jid.JID
+
+	Added   []string
+	Removed []string
+}
+
+// Handler tracks reactions received in messages and reports changes to them.
+// The zero value is ready to use.
+type Handler struct {
+	// Changed, if set, is called after reactions are received with the reactions
+	// that were added and removed.
+	Changed func(msg stanza.Message, c Change)
+
+	// State stores the current reactions.
+	// If nil, a new State is created on first use.
+	State *State
+
+	once sync.Once
+}
+
+func (h *Handler) state() *State {
+	h.once.Do(func() {
+		if h.State == nil {
+			h.State = &State{}
+		}
+	})
+	return h.State
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+	// Pop the start message token.
+	_, err := t.Token()
+	if err != nil {
+		return err
+	}
+
+	iter := xmlstream.NewIter(t)
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		start, r := iter.Current()
+		if start == nil || start.Name.Space != NS || start.Name.Local != "reactions" {
+			continue
+		}
+		var reactions Reactions
+		d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r))
+		err = d.Decode(&reactions)
+		if err != nil {
+			return err
+		}
+		from := msg.From
+		if msg.Type != stanza.GroupChatMessage {
+			from = from.Bare()
+		}
+		added, removed := h.state().Update(from, reactions)
+		if h.Changed != nil && (len(added) > 0 || len(removed) > 0) {
+			h.Changed(msg, Change{
+				ID:      reactions.ID,
+				From:    from,
+				Added:   added,
+				Removed: removed,
+			})
+		}
+		return nil
+	}
+	return iter.Err()
+}
+
+// Count is a reaction along with the entities that reacted with it.
+type Count struct {
+	Reaction string
+	From     []jid.JID
+}
+
+// State aggregates the reactions on messages.
+// The zero value is ready to use and is safe for concurrent use by multiple
+// goroutines.
+type State struct {
+	mu sync.Mutex
+	// m maps message IDs to the reactions from each sender.
+	m map[string]map[string]senderReactions
+}
+
+type senderReactions struct {
+	from      jid.JID
+	reactions []string
+}
+
+// Update replaces the reactions that from has on the message with the provided
+// reactions and returns the reactions that were added and removed.
+func (s *State) Update(from jid.JID, r Reactions) (added, removed []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := from.String()
+	current := dedup(r.Reactions)
+	var prev []string
+	msgReactions := s.m[r.ID]
+	if msgReactions != nil {
+		prev = msgReactions[key].reactions
+	}
+	for _, reaction := range current {
+		if !contains(prev, reaction) {
+			added = append(added, reaction)
+		}
+	}
+	for _, reaction := range prev {
+		if !contains(current, reaction) {
+			removed = append(removed, reaction)
+		}
+	}
+
+	if len(current) == 0 {
+		if msgReactions != nil {
+			delete(msgReactions, key)
+			if len(msgReactions) == 0 {
+				delete(s.m, r.ID)
+			}
+		}
+		return added, removed
+	}
+	if msgReactions == nil {
+		if s.m == nil {
+			s.m = make(map[string]map[string]senderReactions)
+		}
+		msgReactions = make(map[string]senderReactions)
+		s.m[r.ID] = msgReactions
+	}
+	msgReactions[key] = senderReactions{from: from, reactions: current}
+	return added, removed
+}
+
+// Reactions returns the current reactions that from has on the message with
+// the provided ID.
+func (s *State) Reactions(id string, from jid.JID) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.m[id][from.String()].reactions...)
+}
+
+// Counts returns each reaction on the message with the provided ID along with
+// the entities that reacted with it.
+// Reactions are ordered from the most to the least popular.
+func (s *State) Counts(id string) []Count {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var counts []Count
+	idx := make(map[string]int)
+	for _, sender := range s.m[id] {
+		for _, reaction := range sender.reactions {
+			i, ok := idx[reaction]
+			if !ok {
+				i = len(counts)
+				idx[reaction] = i
+				counts = append(counts, Count{Reaction: reaction})
+			}
+			counts[i].From = append(counts[i].From, sender.from)
+		}
+	}
+	for _, c := range counts {
+		sort.Slice(c.From, func(i, j int) bool {
+			return c.From[i].String() < c.From[j].String()
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if len(counts[i].From) != len(counts[j].From) {
+			return len(counts[i].From) > len(counts[j].From)
+		}
+		return counts[i].Reaction < counts[j].Reaction
+	})
+	return counts
+}
+
+// Delete forgets all reactions to the message with the provided ID.
+func (s *State) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, id)
+}
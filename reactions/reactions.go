@@ -0,0 +1,113 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h *Handler"
+
+// Package reactions implements XEP-0444: Message Reactions.
+//
+// Each reactions element contains the full set of reactions that the sender
+// currently has on a message, so reactions are removed by sending the set
+// again without them.
+// The Handler keeps track of the reactions from each sender and reports which
+// ones were added and removed.
+package reactions // import "mellium.im/xmpp/reactions"
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/stanza"
+)
+
+// NS is the namespace used by this package.
+const NS = "urn:xmpp:reactions:0"
+
+// Reactions is the full set of reactions that an entity has on a message.
+type Reactions struct {
+	// ID is the ID of the message being reacted to.
+	// For group chat messages this is the stanza-id assigned by the room.
+	ID string
+
+	// Reactions is a list of reactions, each of which is normally a single
+	// emoji.
+	Reactions []string
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (r Reactions) TokenReader() xml.TokenReader {
+	inner := make([]xml.TokenReader, 0, len(r.Reactions))
+	for _, reaction := range r.Reactions {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(reaction)),
+			xml.StartElement{Name: xml.Name{Local: "reaction"}},
+		))
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{
+			Name: xml.Name{Space: NS, Local: "reactions"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: r.ID}},
+		},
+	)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (r Reactions) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, r.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (r Reactions) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := r.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+// Empty and duplicate reactions are ignored.
+func (r *Reactions) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	s := struct {
+		ID        string   `xml:"id,attr"`
+		Reactions []string `xml:"reaction"`
+	}{}
+	err := d.DecodeElement(&s, &start)
+	if err != nil {
+		return err
+	}
+	r.ID = s.ID
+	r.Reactions = dedup(s.Reactions)
+	return nil
+}
+
+// dedup removes empty and duplicate reactions while preserving their order.
+func dedup(in []string) []string {
+	var out []string
+	for _, reaction := range in {
+		if reaction == "" || contains(out, reaction) {
+			continue
+		}
+		out = append(out, reaction)
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Send sends the reactions wrapped in the provided message.
+// To remove all reactions from a message send an empty set of reactions.
+func Send(ctx context.Context, s *xmpp.Session, msg stanza.Message, r Reactions) error {
+	return s.Send(ctx, msg.Wrap(r.TokenReader()))
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package reactions_test
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/reactions"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xmlstream.Marshaler = reactions.Reactions{}
+	_ xmlstream.WriterTo  = reactions.Reactions{}
+)
+
+var encodingTestCases = []xmpptest.EncodingTestCase{
+	0: {
+		Value: &reactions.Reactions{ID: "744f6e18", Reactions: []string{"👋", "🐢"}},
+		XML:   `<reactions xmlns="urn:xmpp:reactions:0" id="744f6e18"><reaction>👋</reaction><reaction>🐢</reaction></reactions>`,
+	},
+	1: {
+		Value: &reactions.Reactions{ID: "744f6e18"},
+		XML:   `<reactions xmlns="urn:xmpp:reactions:0" id="744f6e18"></reactions>`,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	xmpptest.RunEncodingTests(t, encodingTestCases)
+}
+
+func TestUnmarshalDuplicates(t *testing.T) {
+	const in = `<reactions xmlns="urn:xmpp:reactions:0" id="1"><reaction>👋</reaction><reaction/><reaction>👋</reaction></reactions>`
+	var r reactions.Reactions
+	err := xml.Unmarshal([]byte(in), &r)
+	if err != nil {
+		t.Fatalf("error unmarshaling: %v", err)
+	}
+	if want := []string{"👋"}; !reflect.DeepEqual(r.Reactions, want) {
+		t.Errorf("wrong reactions: want=%v, got=%v", want, r.Reactions)
+	}
+}
+
+func TestState(t *testing.T) {
+	var (
+		romeo  = jid.MustParse("romeo@example.net")
+		juliet = jid.MustParse("juliet@example.com")
+		state  = &reactions.State{}
+	)
+	added, removed := state.Update(romeo, reactions.Reactions{ID: "1", Reactions: []string{"👋", "🐢"}})
+	if !reflect.DeepEqual(added, []string{"👋", "🐢"}) || removed != nil {
+		t.Errorf("wrong first update: added=%v, removed=%v", added, removed)
+	}
+	state.Update(juliet, reactions.Reactions{ID: "1", Reactions: []string{"🐢"}})
+	added, removed = state.Update(romeo, reactions.Reactions{ID: "1", Reactions: []string{"🐢", "❤"}})
+	if !reflect.DeepEqual(added, []string{"❤"}) || !reflect.DeepEqual(removed, []string{"👋"}) {
+		t.Errorf("wrong second update: added=%v, removed=%v", added, removed)
+	}
+
+	want := []reactions.Count{
+		{Reaction: "🐢", From: []jid.JID{juliet, romeo}},
+		{Reaction: "❤", From: []jid.JID{romeo}},
+	}
+	if counts := state.Counts("1"); !reflect.DeepEqual(counts, want) {
+		t.Errorf("wrong counts:\nwant=%+v,\n got=%+v", want, counts)
+	}
+
+	_, removed = state.Update(romeo, reactions.Reactions{ID: "1"})
+	if !reflect.DeepEqual(removed, []string{"🐢", "❤"}) {
+		t.Errorf("wrong reactions removed: %v", removed)
+	}
+	if r := state.Reactions("1", romeo); len(r) != 0 {
+		t.Errorf("expected reactions to be cleared, got %v", r)
+	}
+	state.Delete("1")
+	if counts := state.Counts("1"); len(counts) != 0 {
+		t.Errorf("expected no reactions after delete, got %v", counts)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	changes := make(chan reactions.Change, 2)
+	h := &reactions.Handler{
+		Changed: func(_ stanza.Message, c reactions.Change) {
+			changes <- c
+		},
+	}
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSClient, reactions.Handle(h))),
+	)
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	from := jid.MustParse("romeo@example.net/orchard")
+	msg := stanza.Message{
+		XMLName: xml.Name{Space: stanza.NSClient, Local: "message"},
+		From:    from,
+		Type:    stanza.ChatMessage,
+	}
+	for _, r := range [][]string{{"👋"}, {"🐢"}} {
+		err := reactions.Send(ctx, s.Server, msg, reactions.Reactions{ID: "1", Reactions: r})
+		if err != nil {
+			t.Fatalf("error sending reactions: %v", err)
+		}
+	}
+
+	want := []reactions.Change{
+		{ID: "1", From: from.Bare(), Added: []string{"👋"}},
+		{ID: "1", From: from.Bare(), Added: []string{"🐢"}, Removed: []string{"👋"}},
+	}
+	for _, w := range want {
+		select {
+		case c := <-changes:
+			if !reflect.DeepEqual(c, w) {
+				t.Errorf("wrong change: want=%+v, got=%+v", w, c)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for reactions")
+		}
+	}
+	if r := h.State.Reactions("1", from.Bare()); !reflect.DeepEqual(r, []string{"🐢"}) {
+		t.Errorf("wrong stored reactions: %v", r)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/internal/attr"
 	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/schema"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -27,6 +28,13 @@ const (
 	NS = "urn:xmpp:receipts"
 )
 
+func init() {
+	schema.Register(schema.Shape{
+		Name:  xml.Name{Space: NS, Local: "received"},
+		Attrs: []string{"id"},
+	})
+}
+
 // Requested is a type that can be added to messages to enable or disable
 // requesting a read receipt.
 //
@@ -156,7 +164,7 @@ func Handle(h *Handler) mux.Option {
 }
 
 // Handler listens for incoming message receipts and matches them to outgoing
-// messages sent with SendMessage or SendMessageElement.
+// messages sent with Send, SendElement, SendMessage, or SendMessageElement.
 // If Unhandled is set it is called for every receipt that cannot be matched to
 // a message sent through the handler.
 type Handler struct {
@@ -165,6 +173,49 @@ type Handler struct {
 	m         sync.Mutex
 }
 
+// Receipt is a pending message delivery receipt for a message sent using Send
+// or SendElement.
+type Receipt struct {
+	id string
+	c  chan struct{}
+	h  *Handler
+}
+
+// ID returns the ID of the message that the receipt is for.
+func (r *Receipt) ID() string {
+	return r.id
+}
+
+// Done returns a channel that is closed when the receipt is received.
+func (r *Receipt) Done() <-chan struct{} {
+	return r.c
+}
+
+// Wait blocks until the receipt is received or the context is canceled.
+// If the context is canceled before the receipt is received the context error
+// is returned but the receipt is still tracked and Wait may be called again.
+// To stop tracking the receipt, call Cancel.
+func (r *Receipt) Wait(ctx context.Context) error {
+	select {
+	case <-r.c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel stops tracking the receipt.
+// If the receipt is received later it is passed to the handlers Unhandled
+// function (if any) and the Done channel is never closed.
+// Calling Cancel after the receipt has been received has no effect.
+func (r *Receipt) Cancel() {
+	r.h.m.Lock()
+	defer r.h.m.Unlock()
+	if c, ok := r.h.sent[r.id]; ok && c == r.c {
+		delete(r.h.sent, r.id)
+	}
+}
+
 // HandleMessage implements mux.MessageHandler and responds to requests and
 // responses for message delivery receipts.
 func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
@@ -187,16 +238,12 @@ func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder
 			c, ok := h.sent[id]
 			if ok {
 				delete(h.sent, id)
-				h.m.Unlock()
-			} else {
-				h.m.Unlock()
-				if h.Unhandled != nil {
-					h.Unhandled(id)
-				}
-				return nil
+				close(c)
+			}
+			h.m.Unlock()
+			if !ok && h.Unhandled != nil {
+				h.Unhandled(id)
 			}
-
-			c <- struct{}{}
 			return nil
 		case "request":
 			msg.From, msg.To = msg.To, msg.From
@@ -213,56 +260,53 @@ func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder
 	return i.Err()
 }
 
-// SendMessage transmits the first element read from the provided token reader
-// over the session if the element is a message stanza, otherwise it returns an
+// Send transmits the first element read from the provided token reader over
+// the session if the element is a message stanza, otherwise it returns an
 // error.
-// SendMessage adds a request for a message receipt and an ID if one does not
-// already exist.
+// Send adds a request for a message receipt and an ID if one does not already
+// exist.
 //
-// If the context is closed before the message delivery receipt is received,
-// SendMessage immediately returns the context error.
-// Any response received at a later time will no be associated with the original
-// request, but can still be handled by the Handler.
-// If the returned error is nil, receipt of the message was successfully
-// acknowledged.
+// Unlike SendMessage, Send returns as soon as the message is sent and the
+// returned Receipt can be used to wait for the message delivery receipt.
 //
-// SendMessage is safe for concurrent use by multiple goroutines.
-func (h *Handler) SendMessage(ctx context.Context, s *xmpp.Session, r xml.TokenReader) error {
+// Send is safe for concurrent use by multiple goroutines.
+func (h *Handler) Send(ctx context.Context, s *xmpp.Session, r xml.TokenReader) (*Receipt, error) {
 	tok, err := r.Token()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	start := tok.(xml.StartElement)
-	if start.Name.Local != "message" || (start.Name.Space != stanza.NSServer && start.Name.Space != stanza.NSClient) {
-		return fmt.Errorf("expected a message type, got %v", start.Name)
+	start, ok := tok.(xml.StartElement)
+	if !ok || start.Name.Local != "message" || (start.Name.Space != stanza.NSServer && start.Name.Space != stanza.NSClient) {
+		return nil, fmt.Errorf("expected a message type, got %v", tok)
 	}
 	msg, err := stanza.NewMessage(start)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return h.SendMessageElement(ctx, s, xmlstream.Inner(r), msg)
+	return h.SendElement(ctx, s, xmlstream.Inner(r), msg)
 }
 
-// SendMessageElement is like SendMessage except that it wraps the payload in
-// the message element derived from msg.
-// For more information, see SendMessage.
+// SendElement is like Send except that it wraps the payload in the message
+// element derived from msg.
+// For more information, see Send.
 //
-// SendMessageElement is safe for concurrent use by multiple goroutines.
-func (h *Handler) SendMessageElement(ctx context.Context, s *xmpp.Session, payload xml.TokenReader, msg stanza.Message) error {
-	if h.sent == nil {
-		h.m.Lock()
-		h.sent = make(map[string]chan struct{})
-		h.m.Unlock()
-	}
-
+// SendElement is safe for concurrent use by multiple goroutines.
+func (h *Handler) SendElement(ctx context.Context, s *xmpp.Session, payload xml.TokenReader, msg stanza.Message) (*Receipt, error) {
 	if msg.ID == "" {
 		msg.ID = attr.RandomID()
 	}
 
-	c := make(chan struct{})
+	receipt := &Receipt{
+		id: msg.ID,
+		c:  make(chan struct{}),
+		h:  h,
+	}
 	h.m.Lock()
-	h.sent[msg.ID] = c
+	if h.sent == nil {
+		h.sent = make(map[string]chan struct{})
+	}
+	h.sent[msg.ID] = receipt.c
 	h.m.Unlock()
 
 	r := Requested(true).TokenReader()
@@ -270,18 +314,52 @@ func (h *Handler) SendMessageElement(ctx context.Context, s *xmpp.Session, paylo
 		r = xmlstream.MultiReader(payload, r)
 	}
 	err := s.SendElement(ctx, r, msg.StartElement())
+	if err != nil {
+		receipt.Cancel()
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// SendMessage transmits the first element read from the provided token reader
+// over the session if the element is a message stanza, otherwise it returns an
+// error.
+// SendMessage adds a request for a message receipt and an ID if one does not
+// already exist.
+//
+// If the context is closed before the message delivery receipt is received,
+// SendMessage immediately returns the context error.
+// Any response received at a later time will no be associated with the original
+// request, but can still be handled by the Handler.
+// If the returned error is nil, receipt of the message was successfully
+// acknowledged.
+//
+// SendMessage is safe for concurrent use by multiple goroutines.
+func (h *Handler) SendMessage(ctx context.Context, s *xmpp.Session, r xml.TokenReader) error {
+	receipt, err := h.Send(ctx, s, r)
 	if err != nil {
 		return err
 	}
+	return wait(ctx, receipt)
+}
 
-	select {
-	case <-c:
-		return nil
-	case <-ctx.Done():
-		h.m.Lock()
-		delete(h.sent, msg.ID)
-		h.m.Unlock()
-		close(c)
-		return ctx.Err()
+// SendMessageElement is like SendMessage except that it wraps the payload in
+// the message element derived from msg.
+// For more information, see SendMessage.
+//
+// SendMessageElement is safe for concurrent use by multiple goroutines.
+func (h *Handler) SendMessageElement(ctx context.Context, s *xmpp.Session, payload xml.TokenReader, msg stanza.Message) error {
+	receipt, err := h.SendElement(ctx, s, payload, msg)
+	if err != nil {
+		return err
+	}
+	return wait(ctx, receipt)
+}
+
+func wait(ctx context.Context, receipt *Receipt) error {
+	err := receipt.Wait(ctx)
+	if err != nil {
+		receipt.Cancel()
 	}
+	return err
 }
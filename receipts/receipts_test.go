@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/internal/xmpptest"
@@ -160,8 +161,6 @@ func TestClosedDoesNotPanic(t *testing.T) {
 	}
 }
 
-// TODO: find a way to test that SendMessageElement actually matches up the
-// response correctly (ie. don't timeout, use the test server).
 func TestRoundTrip(t *testing.T) {
 	h := &receipts.Handler{}
 
@@ -206,3 +205,93 @@ func TestRoundTrip(t *testing.T) {
 		t.Errorf("wrong output:\nwant=%s,\n got=%s", expected, out)
 	}
 }
+
+func TestSendAwait(t *testing.T) {
+	clientHandler := &receipts.Handler{}
+	var unhandled []string
+	clientHandler.Unhandled = func(id string) {
+		unhandled = append(unhandled, id)
+	}
+	s := xmpptest.NewClientServer(
+		xmpptest.ClientHandler(mux.New(stanza.NSClient, receipts.Handle(clientHandler))),
+		xmpptest.ServerHandler(mux.New(stanza.NSClient, receipts.Handle(&receipts.Handler{}))),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := clientHandler.SendElement(ctx, s.Client, nil, stanza.Message{
+		ID:   "123",
+		Type: stanza.ChatMessage,
+	})
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	if id := receipt.ID(); id != "123" {
+		t.Errorf("wrong ID: want=123, got=%s", id)
+	}
+	err = receipt.Wait(ctx)
+	if err != nil {
+		t.Fatalf("error waiting for receipt: %v", err)
+	}
+	select {
+	case <-receipt.Done():
+	default:
+		t.Errorf("expected done channel to be closed after receipt was received")
+	}
+
+	err = clientHandler.SendMessageElement(ctx, s.Client, nil, stanza.Message{
+		Type: stanza.ChatMessage,
+	})
+	if err != nil {
+		t.Fatalf("error sending message and waiting on receipt: %v", err)
+	}
+	if len(unhandled) != 0 {
+		t.Errorf("unexpected unhandled receipts: %v", unhandled)
+	}
+}
+
+func TestCancelReceipt(t *testing.T) {
+	h := &receipts.Handler{}
+	unhandled := make(chan string, 1)
+	h.Unhandled = func(id string) {
+		unhandled <- id
+	}
+	s := xmpptest.NewClientSession(0, &bytes.Buffer{})
+	receipt, err := h.SendElement(context.Background(), s, nil, stanza.Message{ID: "123"})
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = receipt.Wait(ctx)
+	if err != context.Canceled {
+		t.Fatalf("wrong error: want=%v, got=%v", context.Canceled, err)
+	}
+	receipt.Cancel()
+
+	msg := stanza.Message{
+		XMLName: xml.Name{Space: stanza.NSClient, Local: "message"},
+	}
+	r := msg.Wrap(xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Local: "received", Space: receipts.NS},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: "123"}},
+	}))
+	err = h.HandleMessage(msg, struct {
+		xml.TokenReader
+		xmlstream.Encoder
+	}{
+		TokenReader: r,
+		Encoder:     xml.NewEncoder(io.Discard),
+	})
+	if err != nil {
+		t.Fatalf("error handling receipt: %v", err)
+	}
+	if id := <-unhandled; id != "123" {
+		t.Errorf("wrong unhandled ID: want=123, got=%s", id)
+	}
+	select {
+	case <-receipt.Done():
+		t.Errorf("canceled receipt should not be marked as done")
+	default:
+	}
+}
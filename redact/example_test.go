@@ -0,0 +1,37 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package redact_test
+
+import (
+	"encoding/xml"
+	"log"
+	"os"
+	"strings"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/redact"
+)
+
+func Example() {
+	const msg = `<message xmlns="jabber:client" to="juliet@example.com"><body>Wherefore art thou?</body></message>`
+
+	// The decoder reports the namespace of each element in its name and as an
+	// attribute, so remove the attributes to avoid duplicating them when the
+	// tokens are encoded.
+	d := xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+		return attr.Name.Local == "xmlns"
+	})(xml.NewDecoder(strings.NewReader(msg)))
+
+	e := xml.NewEncoder(os.Stdout)
+	_, err := xmlstream.Copy(e, redact.Reader(d))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = e.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// <message xmlns="jabber:client" to="juliet@example.com"><body xmlns="jabber:client">[redacted]</body></message>
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package redact removes sensitive information from XML token streams.
+//
+// It is meant for use by logging and auditing code in deployments where
+// message contents and credentials must not be written to disk.
+// The redacting readers and writers in this package copy every token they
+// pass on so that the output remains valid after the underlying stream has
+// moved on.
+package redact // import "mellium.im/xmpp/redact"
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/ns"
+	"mellium.im/xmpp/stanza"
+)
+
+// DefaultPlaceholder is the character data that replaces the contents of
+// redacted elements if no other placeholder is configured.
+const DefaultPlaceholder = "[redacted]"
+
+// DefaultNames is a list of elements that commonly contain sensitive
+// information, including message bodies, SASL payloads, and passwords.
+// It is used by redactors that do not have any names configured.
+// To redact additional elements, make a copy of this list and append to it.
+var DefaultNames = []xml.Name{
+	{Space: stanza.NSClient, Local: "body"},
+	{Space: stanza.NSServer, Local: "body"},
+	{Space: "http://jabber.org/protocol/xhtml-im", Local: "html"},
+	{Space: ns.SASL, Local: "auth"},
+	{Space: ns.SASL, Local: "challenge"},
+	{Space: ns.SASL, Local: "response"},
+	{Space: ns.SASL, Local: "success"},
+	{Space: "jabber:iq:auth", Local: "password"},
+	{Space: "jabber:iq:auth", Local: "digest"},
+	{Space: "jabber:iq:register", Local: "password"},
+	{Space: "http://jabber.org/protocol/muc", Local: "password"},
+	{Space: "http://jabber.org/protocol/muc#user", Local: "password"},
+	{Space: "urn:xmpp:bookmarks:1", Local: "password"},
+}
+
+// Redactor replaces the contents of sensitive elements with a placeholder.
+// The start and end elements themselves, including their attributes, are kept
+// so that the structure of the stream is still visible.
+// Elements without any content are left empty.
+//
+// Elements are matched using their namespace, which is inherited from the
+// parent element if the token does not have a namespace of its own.
+type Redactor struct {
+	// Names is the list of elements whose contents should be redacted.
+	// If Names is nil, DefaultNames is used.
+	Names []xml.Name
+
+	// Placeholder replaces the contents of redacted elements.
+	// If Placeholder is empty, DefaultPlaceholder is used.
+	Placeholder string
+}
+
+// Reader returns a token reader that redacts tokens read from r.
+// It can be used as an xmlstream.Transformer.
+func Reader(r xml.TokenReader) xml.TokenReader {
+	return Redactor{}.Reader(r)
+}
+
+// Writer returns a token writer that redacts tokens before writing them to w.
+func Writer(w xmlstream.TokenWriter) xmlstream.TokenWriter {
+	return Redactor{}.Writer(w)
+}
+
+// Reader returns a token reader that redacts tokens read from r.
+// It can be used as an xmlstream.Transformer.
+func (rd Redactor) Reader(r xml.TokenReader) xml.TokenReader {
+	qr := &reader{r: r}
+	qr.w = rd.Writer(qr)
+	return qr
+}
+
+// Writer returns a token writer that redacts tokens before writing them to w.
+func (rd Redactor) Writer(w xmlstream.TokenWriter) xmlstream.TokenWriter {
+	names := rd.Names
+	if names == nil {
+		names = DefaultNames
+	}
+	placeholder := rd.Placeholder
+	if placeholder == "" {
+		placeholder = DefaultPlaceholder
+	}
+	return &writer{
+		w:           w,
+		names:       names,
+		placeholder: placeholder,
+	}
+}
+
+type writer struct {
+	w           xmlstream.TokenWriter
+	names       []xml.Name
+	placeholder string
+	spaces      []string
+	skip        int
+	skipped     bool
+}
+
+func (w *writer) EncodeToken(t xml.Token) error {
+	t = xml.CopyToken(t)
+	if w.skip > 0 {
+		switch t.(type) {
+		case xml.StartElement:
+			w.skip++
+		case xml.EndElement:
+			w.skip--
+			if w.skip == 0 {
+				if w.skipped {
+					err := w.w.EncodeToken(xml.CharData(w.placeholder))
+					if err != nil {
+						return err
+					}
+				}
+				return w.w.EncodeToken(t)
+			}
+		}
+		w.skipped = true
+		return nil
+	}
+
+	switch tok := t.(type) {
+	case xml.StartElement:
+		space := w.space(tok)
+		if w.match(xml.Name{Space: space, Local: tok.Name.Local}) {
+			w.skip = 1
+			w.skipped = false
+		} else {
+			w.spaces = append(w.spaces, space)
+		}
+	case xml.EndElement:
+		if len(w.spaces) > 0 {
+			w.spaces = w.spaces[:len(w.spaces)-1]
+		}
+	}
+	return w.w.EncodeToken(t)
+}
+
+// space returns the namespace of start, taking into account the namespace of
+// its parents.
+func (w *writer) space(start xml.StartElement) string {
+	if start.Name.Space != "" {
+		return start.Name.Space
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			return attr.Value
+		}
+	}
+	if len(w.spaces) > 0 {
+		return w.spaces[len(w.spaces)-1]
+	}
+	return ""
+}
+
+func (w *writer) match(name xml.Name) bool {
+	for _, n := range w.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reader pulls tokens through a redacting writer and queues the output.
+type reader struct {
+	r     xml.TokenReader
+	w     xmlstream.TokenWriter
+	queue []xml.Token
+	err   error
+}
+
+func (r *reader) EncodeToken(t xml.Token) error {
+	r.queue = append(r.queue, t)
+	return nil
+}
+
+func (r *reader) Token() (xml.Token, error) {
+	for len(r.queue) == 0 && r.err == nil {
+		tok, err := r.r.Token()
+		if tok != nil {
+			// Writing to the queue never fails.
+			/* #nosec */
+			r.w.EncodeToken(tok)
+		}
+		r.err = err
+	}
+	if len(r.queue) == 0 {
+		return nil, r.err
+	}
+	tok := r.queue[0]
+	r.queue = r.queue[1:]
+	return tok, nil
+}
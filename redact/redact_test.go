@@ -0,0 +1,103 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package redact_test
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/redact"
+)
+
+var redactTestCases = [...]struct {
+	redactor redact.Redactor
+	in       string
+	out      string
+}{
+	0: {
+		in:  `<message xmlns="jabber:client" to="juliet@example.com"><body>Wherefore art thou?</body><thread>123</thread></message>`,
+		out: `<message xmlns="jabber:client" to="juliet@example.com"><body xmlns="jabber:client">[redacted]</body><thread xmlns="jabber:client">123</thread></message>`,
+	},
+	1: {
+		// Empty elements are left empty.
+		in:  `<message xmlns="jabber:client"><body/></message>`,
+		out: `<message xmlns="jabber:client"><body xmlns="jabber:client"></body></message>`,
+	},
+	2: {
+		// Child elements are removed.
+		in:  `<message xmlns="jabber:client"><html xmlns="http://jabber.org/protocol/xhtml-im"><body xmlns="http://www.w3.org/1999/xhtml"><p>Wherefore art thou?</p></body></html></message>`,
+		out: `<message xmlns="jabber:client"><html xmlns="http://jabber.org/protocol/xhtml-im">[redacted]</html></message>`,
+	},
+	3: {
+		in:  `<auth xmlns="urn:ietf:params:xml:ns:xmpp-sasl" mechanism="PLAIN">AGp1bGlldABy</auth>`,
+		out: `<auth xmlns="urn:ietf:params:xml:ns:xmpp-sasl" mechanism="PLAIN">[redacted]</auth>`,
+	},
+	4: {
+		// Bodies in other namespaces are not redacted by default.
+		in:  `<message xmlns="jabber:client"><x xmlns="urn:example"><body>ok</body></x></message>`,
+		out: `<message xmlns="jabber:client"><x xmlns="urn:example"><body xmlns="urn:example">ok</body></x></message>`,
+	},
+	5: {
+		redactor: redact.Redactor{
+			Names:       []xml.Name{{Space: "urn:example", Local: "secret"}},
+			Placeholder: "***",
+		},
+		in:  `<message xmlns="jabber:client"><body>ok</body><secret xmlns="urn:example">hunter2</secret></message>`,
+		out: `<message xmlns="jabber:client"><body xmlns="jabber:client">ok</body><secret xmlns="urn:example">***</secret></message>`,
+	},
+}
+
+// removeXMLNS removes namespace attributes that would otherwise be duplicated
+// when decoded tokens are encoded again.
+var removeXMLNS = xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+	return attr.Name.Local == "xmlns"
+})
+
+func TestReader(t *testing.T) {
+	for i, tc := range redactTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var buf strings.Builder
+			e := xml.NewEncoder(&buf)
+			_, err := xmlstream.Copy(e, tc.redactor.Reader(removeXMLNS(xml.NewDecoder(strings.NewReader(tc.in)))))
+			if err != nil {
+				t.Fatalf("error copying tokens: %v", err)
+			}
+			if err = e.Flush(); err != nil {
+				t.Fatalf("error flushing: %v", err)
+			}
+			if s := buf.String(); s != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, s)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	// Tokens created by hand often do not have a namespace, so it must be
+	// inherited from the parent.
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	w := redact.Writer(e)
+	_, err := xmlstream.Copy(w, xmlstream.Wrap(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData("Wherefore art thou?")),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		),
+		xml.StartElement{Name: xml.Name{Local: "message"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "jabber:client"}}},
+	))
+	if err != nil {
+		t.Fatalf("error copying tokens: %v", err)
+	}
+	if err = e.Flush(); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	const want = `<message xmlns="jabber:client"><body>[redacted]</body></message>`
+	if s := buf.String(); s != want {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", want, s)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package retract
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+// Handle returns an option that registers a Handler for retractions in chat
+// and group chat messages.
+func Handle(h *Handler) mux.Option {
+	return func(m *mux.ServeMux) {
+		name := xml.Name{Space: NS, Local: "retract"}
+		mux.Message(stanza.ChatMessage, name, h)(m)
+		mux.Message(stanza.GroupChatMessage, name, h)(m)
+	}
+}
+
+// Handler reports retractions received in messages.
+//
+// Messages containing a retraction may also contain a fallback body meant for
+// clients that do not support retraction.
+// Because the multiplexer passes the message to the handlers for each of its
+// children, handlers that display message bodies should ignore the body of
+// any message that contains a fallback element in the NSFallback namespace with
+// a "for" attribute of NS.
+type Handler struct {
+	// Retracted, if set, is called when a retraction is received.
+	// Retractions of the users own messages should only be applied if they are
+	// from the same entity that sent the original message, while moderated
+	// retractions should only be applied if they are from the channel itself.
+	Retracted func(msg stanza.Message, r Retraction)
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+// It is used by the multiplexer and normally does not need to be called by the
+// user.
+func (h *Handler) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+	// Pop the start message token.
+	_, err := t.Token()
+	if err != nil {
+		return err
+	}
+
+	iter := xmlstream.NewIter(t)
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		start, r := iter.Current()
+		if start == nil || start.Name.Space != NS || start.Name.Local != "retract" {
+			continue
+		}
+		var retraction Retraction
+		d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), r))
+		err = d.Decode(&retraction)
+		if err != nil {
+			return err
+		}
+		if h.Retracted != nil {
+			h.Retracted(msg, retraction)
+		}
+		return nil
+	}
+	return iter.Err()
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+//go:generate go run ../internal/genfeature -receiver "h *Handler"
+
+// Package retract implements XEP-0424: Message Retraction and receiving
+// retractions made under XEP-0425: Moderated Message Retraction.
+//
+// Retractions sent with this package include a plain text body for clients
+// that do not support retraction along with a fallback indication so that
+// clients that do support it know not to display the body.
+// To ask a multi-user chat to retract another occupants message see the
+// Moderate method on muc.Channel.
+package retract // import "mellium.im/xmpp/retract"
+
+import (
+	"context"
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+// Namespaces used by this package, provided as a convenience.
+const (
+	NS         = `urn:xmpp:message-retract:1`
+	NSModerate = `urn:xmpp:message-moderate:1`
+	NSFallback = `urn:xmpp:fallback:0`
+	NSOccupant = `urn:xmpp:occupant-id:0`
+)
+
+// FallbackBody is the body sent along with retractions for clients that do not
+// support them.
+const FallbackBody = "/me retracted a previous message, but it's unsupported by your client."
+
+// Moderated contains information about the moderator that retracted a message
+// in a multi-user chat.
+type Moderated struct {
+	// By is the occupant JID of the moderator.
+	By jid.JID
+
+	// OccupantID is the stable occupant ID of the moderator, if the channel
+	// supports them.
+	OccupantID string
+}
+
+// Retraction is a request to retract a previously sent message.
+type Retraction struct {
+	// ID is the ID of the message being retracted.
+	// For retractions of the users own messages this is the origin ID (or
+	// message ID if the message did not have an origin ID), for moderated
+	// retractions it is the stanza ID assigned by the channel.
+	ID string
+
+	// Reason is an optional reason for the retraction.
+	Reason string
+
+	// Moderated is set if the message was retracted by a moderator.
+	Moderated *Moderated
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+func (r Retraction) TokenReader() xml.TokenReader {
+	var inner []xml.TokenReader
+	if r.Moderated != nil {
+		var occupant xml.TokenReader
+		if r.Moderated.OccupantID != "" {
+			occupant = xmlstream.Wrap(nil, xml.StartElement{
+				Name: xml.Name{Space: NSOccupant, Local: "occupant-id"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: r.Moderated.OccupantID}},
+			})
+		}
+		inner = append(inner, xmlstream.Wrap(occupant, xml.StartElement{
+			Name: xml.Name{Space: NSModerate, Local: "moderated"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "by"}, Value: r.Moderated.By.String()}},
+		}))
+	}
+	if r.Reason != "" {
+		inner = append(inner, xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(r.Reason)),
+			xml.StartElement{Name: xml.Name{Local: "reason"}},
+		))
+	}
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{
+			Name: xml.Name{Space: NS, Local: "retract"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: r.ID}},
+		},
+	)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (r Retraction) WriteXML(w xmlstream.TokenWriter) (int, error) {
+	return xmlstream.Copy(w, r.TokenReader())
+}
+
+// MarshalXML satisfies the xml.Marshaler interface.
+func (r Retraction) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := r.WriteXML(e)
+	if err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (r *Retraction) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	s := struct {
+		ID        string `xml:"id,attr"`
+		Reason    string `xml:"reason"`
+		Moderated *struct {
+			By       jid.JID `xml:"by,attr"`
+			Occupant struct {
+				ID string `xml:"id,attr"`
+			} `xml:"urn:xmpp:occupant-id:0 occupant-id"`
+		} `xml:"urn:xmpp:message-moderate:1 moderated"`
+	}{}
+	err := d.DecodeElement(&s, &start)
+	if err != nil {
+		return err
+	}
+	*r = Retraction{
+		ID:     s.ID,
+		Reason: s.Reason,
+	}
+	if s.Moderated != nil {
+		r.Moderated = &Moderated{
+			By:         s.Moderated.By,
+			OccupantID: s.Moderated.Occupant.ID,
+		}
+	}
+	return nil
+}
+
+// Send retracts the message with the provided ID by sending a retraction
+// wrapped in msg.
+// A body containing FallbackBody and a fallback indication are included for
+// clients that do not support retraction.
+// If the type of msg is not set, it is sent as a chat message.
+func Send(ctx context.Context, s *xmpp.Session, msg stanza.Message, id string) error {
+	if msg.Type == "" {
+		msg.Type = stanza.ChatMessage
+	}
+	return s.Send(ctx, msg.Wrap(xmlstream.MultiReader(
+		Retraction{ID: id}.TokenReader(),
+		xmlstream.Wrap(nil, xml.StartElement{
+			Name: xml.Name{Space: NSFallback, Local: "fallback"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "for"}, Value: NS}},
+		}),
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(FallbackBody)),
+			xml.StartElement{Name: xml.Name{Local: "body"}},
+		),
+		xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: "urn:xmpp:hints", Local: "store"}}),
+	)))
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package retract_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/retract"
+	"mellium.im/xmpp/stanza"
+)
+
+var (
+	_ xmlstream.Marshaler = retract.Retraction{}
+	_ xmlstream.WriterTo  = retract.Retraction{}
+)
+
+var encodingTestCases = []xmpptest.EncodingTestCase{
+	0: {
+		Value: &retract.Retraction{ID: "origin-id-1"},
+		XML:   `<retract xmlns="urn:xmpp:message-retract:1" id="origin-id-1"></retract>`,
+	},
+	1: {
+		Value: &retract.Retraction{
+			ID:     "stanza-id-1",
+			Reason: "Spam",
+			Moderated: &retract.Moderated{
+				By:         jid.MustParse("room@muc.example.com/macbeth"),
+				OccupantID: "dd72603d",
+			},
+		},
+		XML: `<retract xmlns="urn:xmpp:message-retract:1" id="stanza-id-1"><moderated xmlns="urn:xmpp:message-moderate:1" by="room@muc.example.com/macbeth"><occupant-id xmlns="urn:xmpp:occupant-id:0" id="dd72603d"></occupant-id></moderated><reason>Spam</reason></retract>`,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	xmpptest.RunEncodingTests(t, encodingTestCases)
+}
+
+func TestSend(t *testing.T) {
+	var out bytes.Buffer
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(""),
+		Writer: &out,
+	})
+	err := retract.Send(context.Background(), s, stanza.Message{
+		ID: "retract-message-1",
+		To: jid.MustParse("lord@capulet.example"),
+	}, "origin-id-1")
+	if err != nil {
+		t.Fatalf("error sending retraction: %v", err)
+	}
+	const expected = `<message xmlns="jabber:client" type="chat" to="lord@capulet.example" id="retract-message-1"><retract xmlns="urn:xmpp:message-retract:1" id="origin-id-1"></retract><fallback xmlns="urn:xmpp:fallback:0" for="urn:xmpp:message-retract:1"></fallback><body>/me retracted a previous message, but it&#39;s unsupported by your client.</body><store xmlns="urn:xmpp:hints"></store></message>`
+	if s := out.String(); s != expected {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", expected, s)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	const in = `<message xmlns="jabber:client" type="groupchat" from="room@muc.example.com" id="retraction-id-1"><retract id="stanza-id-1" xmlns="urn:xmpp:message-retract:1"><moderated by="room@muc.example.com/macbeth" xmlns="urn:xmpp:message-moderate:1"><occupant-id xmlns="urn:xmpp:occupant-id:0" id="dd72603d"/></moderated><reason>Spam</reason></retract></message>`
+	var got []retract.Retraction
+	h := &retract.Handler{
+		Retracted: func(msg stanza.Message, r retract.Retraction) {
+			if msg.ID != "retraction-id-1" {
+				t.Errorf("wrong message ID: %q", msg.ID)
+			}
+			got = append(got, r)
+		},
+	}
+	m := mux.New(stanza.NSClient, retract.Handle(h))
+	d := xml.NewDecoder(strings.NewReader(in))
+	tok, err := d.Token()
+	if err != nil {
+		t.Fatalf("error popping start token: %v", err)
+	}
+	start := tok.(xml.StartElement)
+	err = m.HandleXMPP(struct {
+		xml.TokenReader
+		xmlstream.Encoder
+	}{
+		TokenReader: d,
+	}, &start)
+	if err != nil {
+		t.Fatalf("error handling retraction: %v", err)
+	}
+	want := []retract.Retraction{{
+		ID:     "stanza-id-1",
+		Reason: "Spam",
+		Moderated: &retract.Moderated{
+			By:         jid.MustParse("room@muc.example.com/macbeth"),
+			OccupantID: "dd72603d",
+		},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong retractions:\nwant=%+v,\n got=%+v", want, got)
+	}
+}
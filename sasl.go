@@ -108,6 +108,7 @@ func negotiateServer(ctx context.Context, identity, password string, permissions
 		selected sasl.Mechanism
 		server   *sasl.Negotiator
 		resp     []byte
+		binding  ChannelBinding
 	)
 	for more := true; more; {
 		tok, err := d.Token()
@@ -209,6 +210,9 @@ func negotiateServer(ctx context.Context, identity, password string, permissions
 			}
 			decodedData = decodedData[:n]
 		}
+		if selection.XMLName.Local == "auth" {
+			binding = serverChannelBinding(selected.Name, decodedData)
+		}
 		more, resp, err = server.Step(decodedData)
 		switch err {
 		case nil:
@@ -255,6 +259,10 @@ func negotiateServer(ctx context.Context, identity, password string, permissions
 	}
 
 	// If there is no more, but there was no error, auth was successful!
+	session.setSASLInfo(SASLInfo{
+		Mechanism:      selected.Name,
+		ChannelBinding: binding,
+	})
 	var encodedResp []byte
 	if len(resp) >= 0 {
 		encodedResp = make([]byte, base64.StdEncoding.EncodedLen(len(resp)))
@@ -305,6 +313,12 @@ selectmechanism:
 		return mask, nil, errNoMechanisms
 	}
 
+	// On server-to-server connections the authorization identity used with
+	// EXTERNAL is the domain of the sending server (XEP-0178).
+	if identity == "" && selected.Name == SASLExternal.Name && session.State()&S2S == S2S {
+		identity = session.LocalAddr().Domainpart()
+	}
+
 	opts := []sasl.Option{
 		sasl.Credentials(func() ([]byte, []byte, []byte) {
 			return []byte(session.LocalAddr().Localpart()), []byte(password), []byte(identity)
@@ -428,6 +442,11 @@ selectmechanism:
 			return mask, nil, err
 		}
 	}
+	session.setSASLInfo(SASLInfo{
+		Mechanism:        selected.Name,
+		ChannelBinding:   clientChannelBinding(selected.Name, data.([]string), session.ConnectionState()),
+		RemoteMechanisms: data.([]string),
+	})
 	return Authn, session.Conn(), nil
 }
 
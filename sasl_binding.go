@@ -0,0 +1,120 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"strings"
+)
+
+// ChannelBinding is a type of channel binding that ties SASL authentication
+// to the underlying TLS connection.
+type ChannelBinding string
+
+// A list of channel binding types.
+const (
+	// NoChannelBinding indicates that channel binding was not used.
+	NoChannelBinding ChannelBinding = ""
+
+	// TLSUnique is the "tls-unique" channel binding defined in RFC 5929.
+	// It is used with TLS 1.2 and earlier.
+	TLSUnique ChannelBinding = "tls-unique"
+
+	// TLSExporter is the "tls-exporter" channel binding defined in RFC 9266.
+	// It is required when TLS 1.3 is used because TLS 1.3 does not provide
+	// tls-unique.
+	TLSExporter ChannelBinding = "tls-exporter"
+)
+
+// SASLInfo describes the result of SASL authentication.
+type SASLInfo struct {
+	// Mechanism is the name of the negotiated SASL mechanism.
+	Mechanism string
+
+	// ChannelBinding is the type of channel binding that was used, if any.
+	ChannelBinding ChannelBinding
+
+	// RemoteMechanisms is the list of mechanisms advertised by the receiving
+	// entity.
+	// It is only set on sessions that initiated authentication.
+	RemoteMechanisms []string
+}
+
+// Downgraded reports whether the receiving entity advertised a mechanism that
+// supports channel binding but authentication completed without it.
+// This can happen if the client was not configured with any channel binding
+// mechanisms, but it may also indicate that the connection is being
+// intercepted.
+// Clients may also want to remember whether a server has previously advertised
+// channel binding and warn the user if it stops doing so.
+func (i SASLInfo) Downgraded() bool {
+	if i.ChannelBinding != NoChannelBinding {
+		return false
+	}
+	for _, m := range i.RemoteMechanisms {
+		if strings.HasSuffix(m, "-PLUS") {
+			return true
+		}
+	}
+	return false
+}
+
+// SASLInfo returns information about the SASL mechanism and channel binding
+// that were used to authenticate the session.
+// If the session was not authenticated using SASL, the zero value is
+// returned.
+func (s *Session) SASLInfo() SASLInfo {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	info := s.saslInfo
+	info.RemoteMechanisms = append([]string(nil), info.RemoteMechanisms...)
+	return info
+}
+
+func (s *Session) setSASLInfo(info SASLInfo) {
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	s.saslInfo = info
+}
+
+// clientChannelBinding returns the channel binding type that will be used by
+// a client when negotiating mechanism over a connection with the provided
+// state.
+// This mirrors the choice made by the sasl package which uses tls-exporter for
+// TLS 1.3 and tls-unique for earlier versions.
+func clientChannelBinding(mechanism string, remote []string, state tls.ConnectionState) ChannelBinding {
+	if state.Version == 0 || !strings.HasSuffix(mechanism, "-PLUS") {
+		return NoChannelBinding
+	}
+	var offered bool
+	for _, m := range remote {
+		if m == mechanism {
+			offered = true
+			break
+		}
+	}
+	switch {
+	case !offered:
+		return NoChannelBinding
+	case state.Version >= tls.VersionTLS13:
+		return TLSExporter
+	}
+	return TLSUnique
+}
+
+// serverChannelBinding returns the channel binding type requested by a client
+// in the GS2 header at the start of its initial response, for example
+// "p=tls-exporter,,".
+func serverChannelBinding(mechanism string, initial []byte) ChannelBinding {
+	if !strings.HasSuffix(mechanism, "-PLUS") || !bytes.HasPrefix(initial, []byte("p=")) {
+		return NoChannelBinding
+	}
+	cb := initial[2:]
+	if idx := bytes.IndexByte(cb, ','); idx != -1 {
+		cb = cb[:idx]
+	}
+	return ChannelBinding(cb)
+}
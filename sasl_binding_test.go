@@ -0,0 +1,136 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"mellium.im/sasl"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+)
+
+// bindingPlus is a fake mechanism that sends the GS2 header for the channel
+// binding type that the sasl package would pick and succeeds immediately.
+var bindingPlus = sasl.Mechanism{
+	Name: "TEST-PLUS",
+	Start: func(n *sasl.Negotiator) (bool, []byte, interface{}, error) {
+		if n.TLSState().Version >= tls.VersionTLS13 {
+			return false, []byte("p=tls-exporter,,"), nil, nil
+		}
+		return false, []byte("p=tls-unique,,"), nil, nil
+	},
+	Next: func(n *sasl.Negotiator, challenge []byte, _ interface{}) (bool, []byte, interface{}, error) {
+		return false, nil, nil, nil
+	},
+}
+
+var bindingTestCases = [...]struct {
+	maxVersion uint16
+	client     []sasl.Mechanism
+	server     []sasl.Mechanism
+	mechanism  string
+	binding    xmpp.ChannelBinding
+	downgraded bool
+}{
+	0: {
+		maxVersion: tls.VersionTLS13,
+		client:     []sasl.Mechanism{sasl.Plain},
+		server:     []sasl.Mechanism{sasl.Plain},
+		mechanism:  "PLAIN",
+	},
+	1: {
+		maxVersion: tls.VersionTLS13,
+		client:     []sasl.Mechanism{sasl.Plain},
+		server:     []sasl.Mechanism{sasl.ScramSha256Plus, sasl.Plain},
+		mechanism:  "PLAIN",
+		downgraded: true,
+	},
+	2: {
+		maxVersion: tls.VersionTLS13,
+		client:     []sasl.Mechanism{bindingPlus, sasl.Plain},
+		server:     []sasl.Mechanism{bindingPlus, sasl.Plain},
+		mechanism:  "TEST-PLUS",
+		binding:    xmpp.TLSExporter,
+	},
+	3: {
+		maxVersion: tls.VersionTLS12,
+		client:     []sasl.Mechanism{bindingPlus, sasl.Plain},
+		server:     []sasl.Mechanism{bindingPlus, sasl.Plain},
+		mechanism:  "TEST-PLUS",
+		binding:    xmpp.TLSUnique,
+	},
+}
+
+func TestSASLInfo(t *testing.T) {
+	ca := issueCert(t, nil, nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	serverCert := issueCert(t, &ca, nil, []string{"example.com"})
+
+	for i, tc := range bindingTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			clientConn, serverConn := net.Pipe()
+			defer clientConn.Close()
+			defer serverConn.Close()
+
+			serverInfo := make(chan xmpp.SASLInfo, 1)
+			go func() {
+				feature := xmpp.SASLServer(func(*sasl.Negotiator) bool {
+					return true
+				}, tc.server...)
+				s, err := xmpp.ReceiveClientSession(ctx, jid.JID{}, tls.Server(serverConn, &tls.Config{
+					Certificates: []tls.Certificate{serverCert},
+					MaxVersion:   tc.maxVersion,
+				}), feature, xmpp.BindResource())
+				if err != nil {
+					t.Logf("error receiving session: %v", err)
+					return
+				}
+				serverInfo <- s.SASLInfo()
+			}()
+
+			s, err := xmpp.NewClientSession(ctx, jid.MustParse("juliet@example.com"), tls.Client(clientConn, &tls.Config{
+				ServerName: "example.com",
+				RootCAs:    roots,
+				MaxVersion: tc.maxVersion,
+			}), xmpp.SASL("", "test", tc.client...), xmpp.BindResource())
+			if err != nil {
+				t.Fatalf("error negotiating session: %v", err)
+			}
+			info := s.SASLInfo()
+			if info.Mechanism != tc.mechanism {
+				t.Errorf("wrong mechanism: want=%q, got=%q", tc.mechanism, info.Mechanism)
+			}
+			if info.ChannelBinding != tc.binding {
+				t.Errorf("wrong channel binding: want=%q, got=%q", tc.binding, info.ChannelBinding)
+			}
+			if d := info.Downgraded(); d != tc.downgraded {
+				t.Errorf("wrong value for downgraded: want=%t, got=%t", tc.downgraded, d)
+			}
+
+			select {
+			case info := <-serverInfo:
+				if info.Mechanism != tc.mechanism {
+					t.Errorf("wrong mechanism on server: want=%q, got=%q", tc.mechanism, info.Mechanism)
+				}
+				if info.ChannelBinding != tc.binding {
+					t.Errorf("wrong channel binding on server: want=%q, got=%q", tc.binding, info.ChannelBinding)
+				}
+			case <-ctx.Done():
+				t.Errorf("server session was never negotiated")
+			}
+		})
+	}
+}
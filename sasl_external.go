@@ -0,0 +1,138 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+
+	"mellium.im/sasl"
+	"mellium.im/xmpp/jid"
+	xmppx509 "mellium.im/xmpp/x509"
+)
+
+var (
+	errNoClientCert  = errors.New("xmpp: no verified client certificate")
+	errAmbiguousCert = errors.New("xmpp: client certificate does not identify a single address")
+	errCertMismatch  = errors.New("xmpp: authorization identity not found in client certificate")
+)
+
+// SASLExternal is a SASL mechanism that implements the EXTERNAL mechanism
+// defined in RFC 4422 using TLS client certificates as described in XEP-0178.
+// It may be used with SASL and SASLServer.
+//
+// When initiating a session the certificate is presented during the TLS
+// handshake (see ExternalClientConfig) and the identity passed to SASL, if any,
+// is sent as the authorization identity.
+// On server-to-server connections the authorization identity defaults to the
+// domain of the origin JID.
+//
+// When receiving a session the client certificate must have been verified
+// during the TLS handshake (see ExternalServerConfig).
+// If the client requested an authorization identity it must match one of the
+// XmppAddr identifiers in the certificate or, for a domain, one of its DNS
+// names.
+// If it did not request one, the only XmppAddr identifier in the certificate is
+// used or, if there are none, its only DNS name.
+// The permissions function is then called with a negotiator whose credentials
+// contain the authorization identity so that the application can check that it
+// may be used.
+//
+// Because the SASL feature is only advertised once the connection is secure
+// EXTERNAL will never be offered before TLS has been negotiated.
+var SASLExternal = sasl.Mechanism{
+	Name: "EXTERNAL",
+	Start: func(n *sasl.Negotiator) (bool, []byte, interface{}, error) {
+		_, _, identity := n.Credentials()
+		return false, identity, nil, nil
+	},
+	Next: func(n *sasl.Negotiator, challenge []byte, _ interface{}) (bool, []byte, interface{}, error) {
+		if n.State()&sasl.Receiving != sasl.Receiving || n.State()&sasl.StepMask != sasl.AuthTextSent {
+			return false, nil, nil, sasl.ErrTooManySteps
+		}
+		identity, err := certIdentity(n.TLSState(), string(challenge))
+		if err != nil {
+			return false, nil, nil, sasl.ErrAuthn
+		}
+		if !n.Permissions(sasl.Credentials(func() ([]byte, []byte, []byte) {
+			return nil, nil, []byte(identity.String())
+		})) {
+			return false, nil, nil, sasl.ErrAuthn
+		}
+		return false, nil, nil, nil
+	},
+}
+
+// certIdentity returns the address that the verified client certificate in
+// the connection state authenticates for the requested authorization identity.
+func certIdentity(state *tls.ConnectionState, authzid string) (jid.JID, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return jid.JID{}, errNoClientCert
+	}
+	leaf := state.VerifiedChains[0][0]
+	crt, err := xmppx509.FromCertificate(leaf)
+	if err != nil {
+		return jid.JID{}, err
+	}
+
+	if authzid == "" {
+		switch {
+		case len(crt.XMPPAddresses) == 1:
+			authzid = crt.XMPPAddresses[0]
+		case len(crt.XMPPAddresses) == 0 && len(leaf.DNSNames) == 1:
+			authzid = leaf.DNSNames[0]
+		default:
+			return jid.JID{}, errAmbiguousCert
+		}
+	}
+	j, err := jid.Parse(authzid)
+	if err != nil {
+		return jid.JID{}, err
+	}
+	for _, addr := range crt.XMPPAddresses {
+		certJID, err := jid.Parse(addr)
+		if err == nil && certJID.Equal(j) {
+			return j, nil
+		}
+	}
+	if j.Equal(j.Domain()) && leaf.VerifyHostname(j.Domainpart()) == nil {
+		return j, nil
+	}
+	return jid.JID{}, errCertMismatch
+}
+
+// ExternalClientConfig returns a copy of cfg that presents the provided
+// certificate when the server requests one so that it can be used to
+// authenticate with SASLExternal.
+// If cfg is nil, a new config is returned.
+func ExternalClientConfig(cfg *tls.Config, cert tls.Certificate) *tls.Config {
+	cfg = cloneTLSConfig(cfg)
+	cfg.Certificates = append(cfg.Certificates, cert)
+	return cfg
+}
+
+// ExternalServerConfig returns a copy of cfg that requests a certificate from
+// the client and verifies it using roots so that it can be used to
+// authenticate with SASLExternal.
+// Clients that do not present a certificate are still accepted so that they
+// can authenticate using other mechanisms.
+// Certificates must permit client authentication to be verified.
+// If cfg is nil, a new config is returned.
+func ExternalServerConfig(cfg *tls.Config, roots *x509.CertPool) *tls.Config {
+	cfg = cloneTLSConfig(cfg)
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	cfg.ClientCAs = roots
+	return cfg
+}
+
+func cloneTLSConfig(cfg *tls.Config) *tls.Config {
+	if cfg == nil {
+		return &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return cfg.Clone()
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"mellium.im/sasl"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+)
+
+var oidXMPPAddr = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 8, 5}
+
+// issueCert creates a certificate for the provided XmppAddr identifiers and
+// DNS names signed by the parent certificate and key.
+// If parent is nil a self signed CA certificate is created.
+func issueCert(t *testing.T, parent *tls.Certificate, xmppAddrs, dnsNames []string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	var names []asn1.RawValue
+	for _, addr := range xmppAddrs {
+		value, err := asn1.MarshalWithParams(addr, "utf8")
+		if err != nil {
+			t.Fatalf("error marshaling XmppAddr: %v", err)
+		}
+		explicit, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: value})
+		if err != nil {
+			t.Fatalf("error marshaling XmppAddr: %v", err)
+		}
+		oid, err := asn1.Marshal(oidXMPPAddr)
+		if err != nil {
+			t.Fatalf("error marshaling OID: %v", err)
+		}
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: append(oid, explicit...)})
+	}
+	for _, name := range dnsNames {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte(name)})
+	}
+	if len(names) > 0 {
+		san, err := asn1.Marshal(names)
+		if err != nil {
+			t.Fatalf("error marshaling SAN: %v", err)
+		}
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: san}}
+	}
+
+	signer, signerKey := tmpl, interface{}(key)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("error parsing certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+var externalTestCases = [...]struct {
+	s2s       bool
+	xmppAddrs []string
+	dnsNames  []string
+	identity  string
+	noCert    bool
+	want      string
+	err       bool
+}{
+	0: {
+		xmppAddrs: []string{"juliet@example.com"},
+		want:      "juliet@example.com",
+	},
+	1: {
+		xmppAddrs: []string{"juliet@example.com", "romeo@example.net"},
+		identity:  "romeo@example.net",
+		want:      "romeo@example.net",
+	},
+	2: {
+		// The identity must be one of the addresses in the certificate.
+		xmppAddrs: []string{"juliet@example.com"},
+		identity:  "romeo@example.net",
+		err:       true,
+	},
+	3: {
+		// Without an identity the certificate must contain a single address.
+		xmppAddrs: []string{"juliet@example.com", "romeo@example.net"},
+		err:       true,
+	},
+	4: {
+		xmppAddrs: []string{"juliet@example.com"},
+		noCert:    true,
+		err:       true,
+	},
+	5: {
+		// On S2S connections the domain is used as the identity and matched
+		// against the DNS names.
+		s2s:      true,
+		dnsNames: []string{"*.example.net", "example.com"},
+		want:     "example.com",
+	},
+	6: {
+		s2s:       true,
+		xmppAddrs: []string{"juliet@example.com"},
+		dnsNames:  []string{"example.net"},
+		err:       true,
+	},
+}
+
+func TestSASLExternal(t *testing.T) {
+	ca := issueCert(t, nil, nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	serverCert := issueCert(t, &ca, nil, []string{"example.org"})
+
+	for i, tc := range externalTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			clientCfg := &tls.Config{
+				ServerName: "example.org",
+				RootCAs:    roots,
+				MinVersion: tls.VersionTLS12,
+			}
+			if !tc.noCert {
+				clientCfg = xmpp.ExternalClientConfig(clientCfg, issueCert(t, &ca, tc.xmppAddrs, tc.dnsNames))
+			}
+			serverCfg := xmpp.ExternalServerConfig(&tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				MinVersion:   tls.VersionTLS12,
+			}, roots)
+
+			clientConn, serverConn := net.Pipe()
+			defer clientConn.Close()
+			defer serverConn.Close()
+
+			identities := make(chan string, 1)
+			go func() {
+				feature := xmpp.SASLServer(func(n *sasl.Negotiator) bool {
+					_, _, identity := n.Credentials()
+					identities <- string(identity)
+					return true
+				}, xmpp.SASLExternal)
+				// The receiving side does not know who the remote server is ahead of
+				// time so always receive a client session, the mechanism does not
+				// depend on the session type.
+				/* #nosec */
+				xmpp.ReceiveClientSession(ctx, jid.JID{}, tls.Server(serverConn, serverCfg), feature)
+				/* #nosec */
+				serverConn.Close()
+			}()
+
+			feature := xmpp.SASL(tc.identity, "", xmpp.SASLExternal)
+			var err error
+			if tc.s2s {
+				_, err = xmpp.NewServerSession(ctx, jid.MustParse("example.org"), jid.MustParse("example.com"), tls.Client(clientConn, clientCfg), feature)
+			} else {
+				_, err = xmpp.NewClientSession(ctx, jid.MustParse("juliet@example.com"), tls.Client(clientConn, clientCfg), feature)
+			}
+			/* #nosec */
+			clientConn.Close()
+			switch {
+			case tc.err && err == nil:
+				t.Fatalf("expected authentication to fail")
+			case !tc.err && err != nil:
+				t.Fatalf("error negotiating session: %v", err)
+			case tc.err:
+				return
+			}
+			select {
+			case identity := <-identities:
+				if identity != tc.want {
+					t.Errorf("wrong identity: want=%q, got=%q", tc.want, identity)
+				}
+			default:
+				t.Errorf("permissions were never checked")
+			}
+		})
+	}
+}
+
+func TestSASLExternalNoTLS(t *testing.T) {
+	n := sasl.NewServer(xmpp.SASLExternal, func(*sasl.Negotiator) bool { return true })
+	_, _, err := n.Step(nil)
+	if !errors.Is(err, sasl.ErrAuthn) {
+		t.Errorf("expected authentication error without TLS, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"encoding/xml"
+	"log"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/tokens"
+)
+
+// Handler returns a handler that validates each top level element it receives
+// against the shapes in reg before passing the element on to h.
+// If reg is nil, Default is used.
+//
+// If logger is nil, validation errors are returned from HandleXMPP without
+// calling h which normally ends the session.
+// Otherwise they are logged and h is still called.
+//
+// Because each element is buffered in memory before it is validated, Handler
+// is meant for testing and debugging and should not normally be used in
+// production.
+func Handler(h xmpp.Handler, reg *Registry, logger *log.Logger) xmpp.Handler {
+	if reg == nil {
+		reg = Default
+	}
+	return xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		inner, err := xmlstream.ReadAll(xmlstream.Inner(t))
+		if err != nil {
+			return err
+		}
+		toks := make([]xml.Token, 0, len(inner)+1)
+		toks = append(toks, inner...)
+		toks = append(toks, start.End())
+
+		err = reg.Validate(xmlstream.MultiReader(xmlstream.Token(start.Copy()), tokens.Reader(toks)))
+		if err != nil {
+			if logger == nil {
+				return err
+			}
+			logger.Print(err)
+		}
+
+		return h.HandleXMPP(struct {
+			xml.TokenReader
+			xmlstream.Encoder
+		}{
+			TokenReader: tokens.Reader(toks),
+			Encoder:     t,
+		}, start)
+	})
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package schema validates payloads against the shapes expected by the
+// packages that handle them.
+//
+// This is not a full XML schema language.
+// A Shape only lists the attributes and child elements that must be present
+// for a payload to be understood, which is enough to catch most interop bugs
+// such as missing IDs or children in the wrong namespace.
+// Packages that implement an extension register the shapes of their payloads,
+// normally in an init function, and applications can enable validation of
+// incoming payloads while debugging by wrapping their handler with Handler.
+//
+// Shapes only describe what a payload must contain.
+// To decode payloads into the types that represent them, see the extension
+// registry in the stanza package.
+package schema // import "mellium.im/xmpp/schema"
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Shape describes the attributes and children that a payload is required to
+// have.
+// Attributes and children that are not listed are allowed.
+type Shape struct {
+	// Name is the name of the element.
+	// If Name.Space is empty in a child shape, the namespace of the parent is
+	// used.
+	Name xml.Name
+
+	// Attrs is a list of the local names of required attributes.
+	Attrs []string
+
+	// Children is a list of required child elements.
+	// Each child must appear at least once and every child with a matching name
+	// is validated against the shape.
+	Children []Shape
+
+	// Text requires that the element contain character data other than
+	// whitespace.
+	Text bool
+}
+
+// Error is returned when a payload does not match its registered shape.
+type Error struct {
+	// Payload is the name of the payload that failed validation.
+	Payload xml.Name
+
+	// Path is the list of local names from the payload to the element that
+	// failed validation separated by "/".
+	Path string
+
+	// Reason describes how the element differed from its shape.
+	Reason string
+}
+
+// Error satisfies the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema: invalid {%s}%s payload at %s: %s", e.Payload.Space, e.Payload.Local, e.Path, e.Reason)
+}
+
+// Registry is a set of shapes indexed by the name of the element they
+// describe.
+// The zero value is an empty registry ready to use.
+type Registry struct {
+	mu     sync.RWMutex
+	shapes map[xml.Name]Shape
+}
+
+// Default is the registry used by the package level functions.
+var Default = &Registry{}
+
+// Register adds a shape to the default registry.
+func Register(s Shape) {
+	Default.Register(s)
+}
+
+// Register adds a shape to the registry, replacing any existing shape with the
+// same name.
+// Register panics if the name of the shape does not have both a namespace and
+// a local name.
+func (r *Registry) Register(s Shape) {
+	if s.Name.Space == "" || s.Name.Local == "" {
+		panic("schema: registered shape must have a namespace and local name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shapes == nil {
+		r.shapes = make(map[xml.Name]Shape)
+	}
+	r.shapes[s.Name] = s
+}
+
+// Lookup returns the shape registered for the provided name, if any.
+func (r *Registry) Lookup(name xml.Name) (Shape, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.shapes[name]
+	return s, ok
+}
+
+// Validate decodes an element from the token reader and validates it.
+// If the element itself has a registered shape it is validated, otherwise each
+// of its direct children that has a registered shape is validated.
+// This means that both stanzas and bare payloads may be passed to Validate.
+// Elements without any registered shapes are always valid.
+// If validation fails, the returned error will be of type *Error.
+func (r *Registry) Validate(tr xml.TokenReader) error {
+	var n node
+	err := xml.NewTokenDecoder(tr).Decode(&n)
+	if err != nil {
+		return err
+	}
+	return r.validateNode(n)
+}
+
+func (r *Registry) validateNode(n node) error {
+	if s, ok := r.Lookup(n.XMLName); ok {
+		return validate(n, s, n.XMLName, n.XMLName.Local)
+	}
+	for _, child := range n.Children {
+		s, ok := r.Lookup(child.XMLName)
+		if !ok {
+			continue
+		}
+		err := validate(child, s, child.XMLName, child.XMLName.Local)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// node is a generic element used during validation.
+type node struct {
+	XMLName  xml.Name
+	Attr     []xml.Attr `xml:",any,attr"`
+	Children []node     `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func validate(n node, s Shape, payload xml.Name, path string) error {
+	for _, name := range s.Attrs {
+		var found bool
+		for _, a := range n.Attr {
+			if a.Name.Local == name && a.Name.Space != "xmlns" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &Error{Payload: payload, Path: path, Reason: fmt.Sprintf("missing required attribute %q", name)}
+		}
+	}
+	if s.Text && strings.TrimSpace(n.Text) == "" {
+		return &Error{Payload: payload, Path: path, Reason: "missing required text"}
+	}
+	for _, childShape := range s.Children {
+		if childShape.Name.Space == "" {
+			childShape.Name.Space = s.Name.Space
+		}
+		var found bool
+		for _, child := range n.Children {
+			if child.XMLName != childShape.Name {
+				continue
+			}
+			found = true
+			err := validate(child, childShape, payload, path+"/"+child.XMLName.Local)
+			if err != nil {
+				return err
+			}
+		}
+		if !found {
+			return &Error{Payload: payload, Path: path, Reason: fmt.Sprintf("missing required child {%s}%s", childShape.Name.Space, childShape.Name.Local)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package schema_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/schema"
+)
+
+const ns = "urn:example"
+
+func newRegistry() *schema.Registry {
+	reg := &schema.Registry{}
+	reg.Register(schema.Shape{
+		Name:  xml.Name{Space: ns, Local: "query"},
+		Attrs: []string{"node"},
+		Children: []schema.Shape{{
+			Name:  xml.Name{Local: "item"},
+			Attrs: []string{"jid"},
+			Children: []schema.Shape{{
+				Name: xml.Name{Local: "name"},
+				Text: true,
+			}},
+		}},
+	})
+	return reg
+}
+
+var validateTestCases = []struct {
+	in   string
+	path string
+}{
+	0: {in: `<foo xmlns="urn:other"/>`},
+	1: {in: `<iq><foo xmlns="urn:other"/></iq>`},
+	2: {in: `<query xmlns="urn:example" node="n"><item jid="a"><name>A</name></item></query>`},
+	3: {in: `<iq><query xmlns="urn:example" node="n"><item jid="a"><name>A</name></item><other/></query></iq>`},
+	4: {in: `<query xmlns="urn:example"><item jid="a"><name>A</name></item></query>`, path: "query"},
+	5: {in: `<iq><query xmlns="urn:example" node="n"/></iq>`, path: "query"},
+	6: {in: `<query xmlns="urn:example" node="n"><item xmlns="urn:other" jid="a"><name>A</name></item></query>`, path: "query"},
+	7: {in: `<query xmlns="urn:example" node="n"><item jid="a"><name>A</name></item><item><name>B</name></item></query>`, path: "query/item"},
+	8: {in: `<query xmlns="urn:example" node="n"><item jid="a"><name> </name></item></query>`, path: "query/item/name"},
+	9: {in: `<query xmlns="urn:example" node="n" xmlns:node="urn:ns"><item jid="a"><name>A</name></item></query>`},
+}
+
+func TestValidate(t *testing.T) {
+	reg := newRegistry()
+	for i, tc := range validateTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := reg.Validate(xml.NewDecoder(strings.NewReader(tc.in)))
+			if tc.path == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var schemaErr *schema.Error
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("expected schema error, got %v", err)
+			}
+			if schemaErr.Path != tc.path {
+				t.Errorf("wrong path: want=%q, got=%q", tc.path, schemaErr.Path)
+			}
+			if schemaErr.Payload.Space != ns || schemaErr.Payload.Local != "query" {
+				t.Errorf("wrong payload name: %v", schemaErr.Payload)
+			}
+		})
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected registering a shape without a namespace to panic")
+		}
+	}()
+	(&schema.Registry{}).Register(schema.Shape{Name: xml.Name{Local: "foo"}})
+}
+
+func handle(h xmpp.Handler, in string) error {
+	d := xml.NewDecoder(strings.NewReader(in))
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	start := tok.(xml.StartElement)
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+	return h.HandleXMPP(struct {
+		xml.TokenReader
+		xmlstream.Encoder
+	}{
+		TokenReader: d,
+		Encoder:     e,
+	}, &start)
+}
+
+func TestHandler(t *testing.T) {
+	const invalid = `<iq><query xmlns="urn:example"/></iq>`
+	var called bool
+	var got string
+	h := xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		called = true
+		var buf strings.Builder
+		e := xml.NewEncoder(&buf)
+		_, err := xmlstream.Copy(e, xmlstream.MultiReader(xmlstream.Token(*start), r))
+		if err != nil {
+			return err
+		}
+		err = e.Flush()
+		got = buf.String()
+		return err
+	})
+
+	err := handle(schema.Handler(h, newRegistry(), nil), invalid)
+	var schemaErr *schema.Error
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected schema error in strict mode, got %v", err)
+	}
+	if called {
+		t.Fatalf("did not expect handler to be called for invalid payload in strict mode")
+	}
+
+	var logs strings.Builder
+	err = handle(schema.Handler(h, newRegistry(), log.New(&logs, "", 0)), invalid)
+	if err != nil {
+		t.Fatalf("unexpected error when logging: %v", err)
+	}
+	if !called {
+		t.Fatalf("expected handler to be called when logging")
+	}
+	// The encoder duplicates namespace attributes, so only check the structure.
+	if !strings.HasPrefix(got, `<iq><query xmlns="urn:example"`) || !strings.HasSuffix(got, `></query></iq>`) {
+		t.Errorf("handler received wrong element: %s", got)
+	}
+	if !strings.Contains(logs.String(), schemaErr.Error()) {
+		t.Errorf("expected error to be logged, got %q", logs.String())
+	}
+}
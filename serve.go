@@ -0,0 +1,346 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"encoding/xml"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/tokens"
+)
+
+// ServeConfig contains options that change how a session is served.
+// The zero value is the behavior of Serve.
+type ServeConfig struct {
+	// Concurrency is the number of handlers that may run at the same time.
+	// If Concurrency is greater than one, each top level element is decoded and
+	// buffered in its entirety before being handed off to one of Concurrency
+	// workers and the input stream is released so that the next element can be
+	// read while the handler runs.
+	// Elements are assigned to workers based on their "from" attribute so that
+	// elements from the same JID are always handled in the order in which they
+	// were received.
+	// Because the input lock is not held while the handler runs, handlers may
+	// use the session's send methods.
+	Concurrency int
+
+	// QueueLen is the number of buffered elements that may be waiting for each
+	// worker before reading from the input stream blocks.
+	// It is ignored if Concurrency is not greater than one.
+	QueueLen int
+
+	// Observers receive a copy of every top level element read from the input
+	// stream, including responses to IQs sent by the session, for logging or
+	// analytics.
+	// Each observer runs on its own goroutine and cannot write to the stream or
+	// affect how the element is handled.
+	Observers []Observer
+
+	// If set, RateLimit throttles how quickly elements are read from the input
+	// stream.
+	RateLimit *RateLimit
+}
+
+// Backpressure controls what happens when an observer is not keeping up with
+// the input stream.
+type Backpressure int
+
+const (
+	// ObserveDrop discards elements that arrive while the observer's queue is
+	// full.
+	ObserveDrop Backpressure = iota
+
+	// ObserveBlock stops reading from the input stream until there is room in
+	// the observer's queue.
+	// A slow observer will delay the handler and responses to sent IQs.
+	ObserveBlock
+)
+
+// DefaultObserverQueueLen is the queue length used by observers that do not
+// set QueueLen.
+const DefaultObserverQueueLen = 64
+
+// Observer receives copies of the top level elements read by Serve.
+type Observer struct {
+	// Observe is called with a token reader over a single complete element,
+	// including its start and end element.
+	// It is never called concurrently with itself.
+	Observe func(r xml.TokenReader)
+
+	// QueueLen is the number of copied elements that may be waiting for Observe
+	// to be called before the backpressure policy applies.
+	// If QueueLen is zero, DefaultObserverQueueLen is used.
+	// If QueueLen is negative, the queue is unbuffered and the policy applies
+	// whenever Observe is still running when the next element is read.
+	QueueLen int
+
+	// Policy is the backpressure policy used when the queue is full.
+	Policy Backpressure
+}
+
+// ServeWithConfig is like Serve except that it takes a config that can be used
+// to change how elements are dispatched to the handler.
+// See the documentation on Serve for more information.
+//
+// If handlers are run concurrently, ServeWithConfig does not return until all
+// handlers that are still running have returned, so handlers that wait on a
+// response from the remote entity should make sure to set a timeout.
+// Similarly, it does not return until every observer has been called for all
+// of the elements in its queue.
+func (s *Session) ServeWithConfig(h Handler, cfg ServeConfig) (err error) {
+	if h == nil {
+		h = nopHandler{}
+	}
+
+	var pool *servePool
+	if cfg.Concurrency > 1 {
+		pool = newServePool(s, h, cfg.Concurrency, cfg.QueueLen)
+	}
+	obs := newObserverSet(cfg.Observers)
+	limiter := newRateLimiter(s, cfg.RateLimit)
+
+	defer func() {
+		obs.close()
+		if pool != nil {
+			if e := pool.close(); err == nil {
+				err = e
+			}
+		}
+		s.closeInputStream()
+		e := s.Close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	for {
+		select {
+		case <-s.in.ctx.Done():
+			return s.in.ctx.Err()
+		default:
+		}
+		err := handleInputStream(s, h, pool, obs, limiter)
+		switch err {
+		case nil:
+			// No error and no sentinal error telling us to shut down; try again!
+		case io.EOF:
+			return nil
+		case errPoolClosed:
+			// One of the workers already sent an error and will report it when the
+			// pool is closed.
+			return nil
+		default:
+			return s.sendError(err)
+		}
+	}
+}
+
+// StanzasHandled returns the number of stanzas read from the input stream by
+// Serve or ServeWithConfig that have been handled, including responses to IQs
+// sent by the session.
+// When handlers are run concurrently, stanzas are counted as soon as they have
+// been queued for a worker.
+// Acknowledgement mechanisms such as stream management can compare the result
+// at two points in time to learn how many stanzas were handled in between.
+//
+// StanzasHandled is safe for concurrent use by multiple goroutines.
+func (s *Session) StanzasHandled() uint64 {
+	return s.handled.Load()
+}
+
+// errPoolClosed is returned when an element is dispatched to a pool after one
+// of its workers has failed.
+var errPoolClosed = errors.New("xmpp: serve pool closed")
+
+type servedElement struct {
+	start xml.StartElement
+	toks  []xml.Token
+}
+
+// servePool runs handlers for buffered elements on a fixed number of workers.
+type servePool struct {
+	s       *Session
+	h       Handler
+	workers []chan servedElement
+	wg      sync.WaitGroup
+	done    chan struct{}
+	errOnce sync.Once
+	err     error
+}
+
+func newServePool(s *Session, h Handler, n, queueLen int) *servePool {
+	if queueLen < 0 {
+		queueLen = 0
+	}
+	p := &servePool{
+		s:       s,
+		h:       h,
+		workers: make([]chan servedElement, n),
+		done:    make(chan struct{}),
+	}
+	for i := range p.workers {
+		c := make(chan servedElement, queueLen)
+		p.workers[i] = c
+		p.wg.Add(1)
+		go p.work(c)
+	}
+	return p
+}
+
+func (p *servePool) work(c <-chan servedElement) {
+	defer p.wg.Done()
+	for el := range c {
+		select {
+		case <-p.done:
+			// Drain the queue without handling anything else once the pool has
+			// failed so that the reader is never blocked.
+			continue
+		default:
+		}
+		err := handleElement(p.s, p.h, el.start, tokens.Reader(el.toks))
+		if err != nil {
+			p.fail(err)
+		}
+	}
+}
+
+// fail records the first error returned by a worker and sends it over the
+// stream.
+// Sending the error closes the output stream, which will eventually cause the
+// remote entity to close the input stream and stop the read loop.
+func (p *servePool) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = p.s.sendError(err)
+		close(p.done)
+	})
+}
+
+// dispatch queues an element on the worker responsible for its sender.
+func (p *servePool) dispatch(start xml.StartElement, toks []xml.Token) error {
+	_, from := attr.Get(start.Attr, "from")
+	h := fnv.New32a()
+	/* #nosec */
+	h.Write([]byte(from))
+	c := p.workers[h.Sum32()%uint32(len(p.workers))]
+	select {
+	case c <- servedElement{start: start, toks: toks}:
+		return nil
+	case <-p.done:
+		return errPoolClosed
+	}
+}
+
+// close stops all workers after any queued elements have been handled and
+// returns the first error encountered by a worker.
+func (p *servePool) close() error {
+	for _, c := range p.workers {
+		close(c)
+	}
+	p.wg.Wait()
+	return p.err
+}
+
+// observerSet copies elements to each observer's queue.
+type observerSet struct {
+	observers []Observer
+	queues    []chan []xml.Token
+	wg        sync.WaitGroup
+}
+
+func newObserverSet(observers []Observer) *observerSet {
+	if len(observers) == 0 {
+		return nil
+	}
+	o := &observerSet{
+		observers: observers,
+		queues:    make([]chan []xml.Token, len(observers)),
+	}
+	for i, obs := range observers {
+		queueLen := obs.QueueLen
+		switch {
+		case queueLen == 0:
+			queueLen = DefaultObserverQueueLen
+		case queueLen < 0:
+			queueLen = 0
+		}
+		c := make(chan []xml.Token, queueLen)
+		o.queues[i] = c
+		o.wg.Add(1)
+		go func(f func(xml.TokenReader)) {
+			defer o.wg.Done()
+			for toks := range c {
+				if f != nil {
+					f(tokens.Reader(toks))
+				}
+			}
+		}(obs.Observe)
+	}
+	return o
+}
+
+// send queues a buffered element on every observer according to its
+// backpressure policy.
+// The tokens are never modified after they are buffered, so every observer
+// shares the same copy.
+func (o *observerSet) send(toks []xml.Token) {
+	for i, c := range o.queues {
+		if o.observers[i].Policy == ObserveBlock {
+			c <- toks
+			continue
+		}
+		select {
+		case c <- toks:
+		default:
+		}
+	}
+}
+
+// close stops all observers after any queued elements have been observed.
+func (o *observerSet) close() {
+	if o == nil {
+		return
+	}
+	for _, c := range o.queues {
+		close(c)
+	}
+	o.wg.Wait()
+}
+
+// observeReader copies every token read from the underlying reader and sends
+// the complete element to the observers once its end element has been read.
+type observeReader struct {
+	r     xml.TokenReader
+	o     *observerSet
+	depth int
+	buf   []xml.Token
+}
+
+func (r *observeReader) Token() (xml.Token, error) {
+	tok, err := r.r.Token()
+	if tok == nil {
+		return tok, err
+	}
+	switch tok.(type) {
+	case xml.StartElement:
+		r.depth++
+	case xml.EndElement:
+		r.depth--
+	default:
+		if r.depth == 0 {
+			// Whitespace keepalives are not elements.
+			return tok, err
+		}
+	}
+	r.buf = append(r.buf, xml.CopyToken(tok))
+	if r.depth == 0 {
+		r.o.send(r.buf)
+		r.buf = nil
+	}
+	return tok, err
+}
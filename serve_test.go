@@ -0,0 +1,270 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/xmpptest"
+)
+
+func TestServeConcurrent(t *testing.T) {
+	for i, tc := range serveTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := &bytes.Buffer{}
+			in := strings.NewReader(tc.in)
+			rw := struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: in,
+				Writer: out,
+			}
+			var s *xmpp.Session
+			if tc.serverNS {
+				s = xmpptest.NewServerSession(tc.state, rw)
+			} else {
+				s = xmpptest.NewClientSession(tc.state, rw)
+			}
+
+			err := s.ServeWithConfig(tc.handler, xmpp.ServeConfig{Concurrency: 4})
+			switch {
+			case tc.errStringCmp && err.Error() != tc.err.Error():
+				t.Errorf("unexpected error: want=%v, got=%v", tc.err, err)
+			case !tc.errStringCmp && !errors.Is(err, tc.err):
+				t.Errorf("unexpected error: want=%v, got=%v", tc.err, err)
+			}
+			if s := out.String(); s != tc.out {
+				t.Errorf("unexpected output:\nwant=%s,\n got=%s", tc.out, s)
+			}
+		})
+	}
+}
+
+func TestServeConcurrentOrdering(t *testing.T) {
+	const in = `<message from="a@example.net" id="a1"/>
+<message from="a@example.net" id="a2"/>
+<message from="b@example.net" id="b1"/>
+<message from="a@example.net" id="a3"/>
+<message from="b@example.net" id="b2"/>`
+
+	bHandled := make(chan struct{})
+	var closeB sync.Once
+	var mu sync.Mutex
+	got := make(map[string][]string)
+	h := xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		_, from := attr.Get(start.Attr, "from")
+		_, id := attr.Get(start.Attr, "id")
+		if id == "a1" {
+			// Block the first handler for a until b has been handled to show that one
+			// slow sender does not block the others.
+			select {
+			case <-bHandled:
+			case <-time.After(5 * time.Second):
+				return errors.New("handler for b was blocked by handler for a")
+			}
+		}
+		mu.Lock()
+		got[from] = append(got[from], id)
+		mu.Unlock()
+		if from == "b@example.net" {
+			closeB.Do(func() { close(bHandled) })
+		}
+		return nil
+	})
+
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(in),
+		Writer: io.Discard,
+	})
+	err := s.ServeWithConfig(h, xmpp.ServeConfig{Concurrency: 8, QueueLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error serving: %v", err)
+	}
+	if a := strings.Join(got["a@example.net"], ","); a != "a1,a2,a3" {
+		t.Errorf("wrong order for a: want=a1,a2,a3, got=%s", a)
+	}
+	if b := strings.Join(got["b@example.net"], ","); b != "b1,b2" {
+		t.Errorf("wrong order for b: want=b1,b2, got=%s", b)
+	}
+}
+
+func TestServeObservers(t *testing.T) {
+	const in = `<message from="a@example.net" id="a1"><body>one</body></message> <iq type="get" id="a2"/><message id="a3"/>`
+
+	var mu sync.Mutex
+	var got [2]strings.Builder
+	observe := func(i int) func(xml.TokenReader) {
+		return func(r xml.TokenReader) {
+			mu.Lock()
+			defer mu.Unlock()
+			e := xml.NewEncoder(&got[i])
+			_, err := xmlstream.Copy(e, r)
+			if err != nil {
+				t.Errorf("error copying observed element: %v", err)
+			}
+			err = e.Flush()
+			if err != nil {
+				t.Errorf("error flushing observed element: %v", err)
+			}
+		}
+	}
+	var handled []string
+	h := xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		_, id := attr.Get(start.Attr, "id")
+		handled = append(handled, id)
+		return nil
+	})
+
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(in),
+		Writer: io.Discard,
+	})
+	err := s.ServeWithConfig(h, xmpp.ServeConfig{
+		Observers: []xmpp.Observer{
+			{Observe: observe(0), Policy: xmpp.ObserveBlock},
+			{Observe: observe(1), Policy: xmpp.ObserveBlock, QueueLen: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error serving: %v", err)
+	}
+	if ids := strings.Join(handled, ","); ids != "a1,a2,a3" {
+		t.Errorf("observers changed handled elements: want=a1,a2,a3, got=%s", ids)
+	}
+	const want = `<message xmlns="jabber:client" from="a@example.net" id="a1"><body xmlns="jabber:client">one</body></message><iq xmlns="jabber:client" type="get" id="a2"></iq><message xmlns="jabber:client" id="a3"></message>`
+	for i := range got {
+		if s := got[i].String(); s != want {
+			t.Errorf("wrong output for observer %d:\nwant=%s,\n got=%s", i, want, s)
+		}
+	}
+}
+
+func TestServeObserverDrop(t *testing.T) {
+	const in = `<message id="a1"/><message id="a2"/><message id="a3"/><message id="a4"/><message id="a5"/>`
+
+	release := make(chan struct{})
+	var got []string
+	obs := xmpp.Observer{
+		QueueLen: 1,
+		Observe: func(r xml.TokenReader) {
+			tok, err := r.Token()
+			if err != nil {
+				t.Errorf("error reading observed element: %v", err)
+				return
+			}
+			start := tok.(xml.StartElement)
+			_, id := attr.Get(start.Attr, "id")
+			got = append(got, id)
+			// Block until the last element is handled so that the queue fills up.
+			<-release
+		},
+	}
+	h := xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		if _, id := attr.Get(start.Attr, "id"); id == "a5" {
+			close(release)
+		}
+		return nil
+	})
+
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(in),
+		Writer: io.Discard,
+	})
+	err := s.ServeWithConfig(h, xmpp.ServeConfig{Observers: []xmpp.Observer{obs}})
+	if err != nil {
+		t.Fatalf("unexpected error serving: %v", err)
+	}
+	// The first element is always observed, but at least two of the elements
+	// received while the observer was blocked must have been dropped.
+	if len(got) == 0 || got[0] != "a1" || len(got) > 3 {
+		t.Errorf("unexpected observed elements: %v", got)
+	}
+}
+
+func TestServeObserverDefaultQueue(t *testing.T) {
+	const in = `<message id="a1"/><message id="a2"/><message id="a3"/><message id="a4"/><message id="a5"/>`
+
+	release := make(chan struct{})
+	var got []string
+	obs := xmpp.Observer{
+		Observe: func(r xml.TokenReader) {
+			tok, err := r.Token()
+			if err != nil {
+				t.Errorf("error reading observed element: %v", err)
+				return
+			}
+			start := tok.(xml.StartElement)
+			_, id := attr.Get(start.Attr, "id")
+			got = append(got, id)
+			<-release
+		},
+	}
+	h := xmpp.HandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		if _, id := attr.Get(start.Attr, "id"); id == "a5" {
+			close(release)
+		}
+		return nil
+	})
+
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(in),
+		Writer: io.Discard,
+	})
+	err := s.ServeWithConfig(h, xmpp.ServeConfig{Observers: []xmpp.Observer{obs}})
+	if err != nil {
+		t.Fatalf("unexpected error serving: %v", err)
+	}
+	// None of the elements should be dropped by the default queue even though
+	// the observer was blocked while they were read.
+	if ids := strings.Join(got, ","); ids != "a1,a2,a3,a4,a5" {
+		t.Errorf("unexpected observed elements: want=a1,a2,a3,a4,a5, got=%s", ids)
+	}
+}
+
+func TestStanzasHandled(t *testing.T) {
+	const in = `<message id="a1"/><other xmlns="urn:example"/> <iq type="get" id="a2"/><presence id="a3"/>`
+	for _, concurrency := range []int{0, 2} {
+		t.Run(strconv.Itoa(concurrency), func(t *testing.T) {
+			s := xmpptest.NewClientSession(0, struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(in),
+				Writer: io.Discard,
+			})
+			err := s.ServeWithConfig(nil, xmpp.ServeConfig{Concurrency: concurrency})
+			if err != nil {
+				t.Fatalf("unexpected error serving: %v", err)
+			}
+			if n := s.StanzasHandled(); n != 3 {
+				t.Errorf("wrong number of stanzas handled: want=3, got=%d", n)
+			}
+		})
+	}
+}
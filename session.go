@@ -7,6 +7,7 @@
 package xmpp
 
 import (
+	"container/list"
 	"context"
 	"crypto/tls"
 	"encoding/xml"
@@ -15,6 +16,7 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"mellium.im/xmlstream"
@@ -32,10 +34,21 @@ import (
 var (
 	ErrInputStreamClosed  = errors.New("xmpp: attempted to read token from closed stream")
 	ErrOutputStreamClosed = errors.New("xmpp: attempted to write token to closed stream")
+
+	// ErrIQCanceled is returned when waiting for the response to an IQ is
+	// canceled using CancelIQ.
+	ErrIQCanceled = errors.New("xmpp: IQ canceled")
+
+	// ErrEvicted is returned when waiting for the response to a stanza is
+	// stopped because the limits set with SetPendingLimits were exceeded.
+	ErrEvicted = errors.New("xmpp: evicted while waiting for response")
 )
 
 var errNotStart = errors.New("xmpp: SendElement did not begin with a StartElement")
 
+// jidCacheSize is the number of "from" addresses cached by each session.
+const jidCacheSize = 128
+
 // earlyCloser is a token reader that closes itself as soon as reading is
 // complete (io.EOF is reached). It is used to release the read lock as aquired
 // before starting a handler as soon as the handler finishes reading the element
@@ -123,12 +136,26 @@ const (
 
 	// S2S indicates that this is a server-to-server connection.
 	S2S
+
+	// Compressed indicates that stream compression has been negotiated (for
+	// instance, using XEP-0138: Stream Compression).
+	Compressed
 )
 
 type tokenReadChan struct {
 	stanzaName xml.Name
 	c          chan xmlstream.TokenReadCloser
 	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	elem       *list.Element
+}
+
+// sentStanza is an entry in the list of pending stanzas.
+// The cancel function is stored in the list and not looked up by ID when the
+// stanza is evicted because the ID may have been reused by a newer stanza.
+type sentStanza struct {
+	id     string
+	cancel context.CancelCauseFunc
 }
 
 // A Session represents an XMPP session comprising an input and an output XML
@@ -146,8 +173,40 @@ type Session struct {
 	// The negotiated features (by namespace) for the current session.
 	negotiated map[string]struct{}
 
+	// Every feature negotiated since the session was created, in order.
+	timeline []NegotiationStep
+
+	// Every features list sent or received since the session was created, in
+	// order.
+	featureLists []FeatureList
+
+	// The result of SASL authentication, if any.
+	saslInfo SASLInfo
+
 	sentStanzaMutex sync.Mutex
 	sentStanzas     map[string]tokenReadChan
+	// The sentStanzas from oldest to newest.
+	sentOrder     *list.List
+	iqPolicy      IQPolicy
+	pendingLimits PendingLimits
+
+	// Arbitrary values stored by handlers for the lifetime of the session.
+	values sync.Map
+
+	filterMutex sync.RWMutex
+	filters     []xmlstream.Transformer
+
+	// The number of stanzas read by Serve that have been handled.
+	handled atomic.Uint64
+
+	// An optional queue used to order outgoing elements.
+	sendQueue atomic.Pointer[sendQueue]
+
+	// An optional logger that receives complete elements.
+	logger XMLLogger
+
+	// Recently seen addresses from the "from" attribute of incoming stanzas.
+	jidCache *jid.Cache
 
 	in struct {
 		stream.Info
@@ -247,6 +306,8 @@ func negotiateSession(ctx context.Context, location, origin jid.JID, rw io.ReadW
 		features:    make(map[string]interface{}),
 		negotiated:  make(map[string]struct{}),
 		sentStanzas: make(map[string]tokenReadChan),
+		sentOrder:   list.New(),
+		jidCache:    jid.NewCache(jidCacheSize),
 		state:       state,
 		ws:          wsCtx != nil,
 	}
@@ -312,12 +373,16 @@ func negotiateSession(ctx context.Context, location, origin jid.JID, rw io.ReadW
 			}
 			s.in.d = xml.NewDecoder(s.conn)
 			s.out.e = xml.NewEncoder(s.conn)
+			s.wrapLogger()
 		}
 		s.state |= mask
 	}
 
 	s.in.d = intstream.Reader(s.in.d, s.ws)
-	se := &stanzaEncoder{TokenWriteFlusher: s.out.e, ns: s.out.Info.XMLNS}
+	se := &stanzaEncoder{
+		TokenWriteFlusher: &filterWriter{TokenWriteFlusher: s.out.e, s: s},
+		ns:                s.out.Info.XMLNS,
+	}
 	if s.out.Info.XMLNS == stanza.NSServer {
 		se.from = s.LocalAddr()
 	}
@@ -451,36 +516,11 @@ func ReceiveServerSession(ctx context.Context, location, origin jid.JID, rw io.R
 // Serve takes a lock on the input and output stream before calling the handler,
 // so the handler should not close over the session or use any of its send
 // methods or a deadlock will occur.
+// To run handlers concurrently and without holding the input lock, see
+// ServeWithConfig.
 // After Serve finishes running the handler, it flushes the output stream.
-func (s *Session) Serve(h Handler) (err error) {
-	if h == nil {
-		h = nopHandler{}
-	}
-
-	defer func() {
-		s.closeInputStream()
-		e := s.Close()
-		if err == nil {
-			err = e
-		}
-	}()
-
-	for {
-		select {
-		case <-s.in.ctx.Done():
-			return s.in.ctx.Err()
-		default:
-		}
-		err := handleInputStream(s, h)
-		switch err {
-		case nil:
-			// No error and no sentinal error telling us to shut down; try again!
-		case io.EOF:
-			return nil
-		default:
-			return s.sendError(err)
-		}
-	}
+func (s *Session) Serve(h Handler) error {
+	return s.ServeWithConfig(h, ServeConfig{})
 }
 
 // sendError transmits an error on the session. If the error is not a standard
@@ -543,12 +583,15 @@ func (r iqResponder) Close() error {
 	return nil
 }
 
-func handleInputStream(s *Session, handler Handler) (err error) {
+func handleInputStream(s *Session, handler Handler, pool *servePool, obs *observerSet, limiter *rateLimiter) (err error) {
 	discard := xmlstream.Discard()
 	rc := s.TokenReader()
 	/* #nosec */
 	defer rc.Close()
-	r := intstream.Reader(rc, s.ws)
+	r := limiter.counter(intstream.Reader(rc, s.ws))
+	if obs != nil {
+		r = &observeReader{r: r, o: obs}
+	}
 
 	tok, err := r.Token()
 	if err != nil {
@@ -567,16 +610,25 @@ func handleInputStream(s *Session, handler Handler) (err error) {
 		return fmt.Errorf("xmpp: stream in a bad state, expected start element or whitespace but got %T", tok)
 	}
 
+	err = limiter.wait()
+	if err != nil {
+		return err
+	}
+
 	// If this is a stanza, normalize the "from" attribute.
 	if stanza.Is(start.Name, s.in.XMLNS) {
 		for i, attr := range start.Attr {
 			if attr.Name.Local == "from" /*&& attr.Name.Space == start.Name.Space*/ {
-				local := s.LocalAddr().Bare().String()
-				// Try a direct comparison first to avoid expensive JID parsing.
-				// TODO: really we should be parsing the JID here in case the server
-				// is using a different version of PRECIS, stringprep, etc. and the
-				// canonical representation isn't the same.
-				if attr.Value == local {
+				local := s.LocalAddr().Bare()
+				// Try a direct comparison first to avoid JID parsing, then fall back to
+				// parsing the JID in case the server is using a different version of
+				// PRECIS, stringprep, etc. and the canonical representation isn't the
+				// same.
+				// Most stanzas are from a small number of contacts, so the parsed JIDs
+				// are cached to avoid normalizing the same addresses over and over.
+				if attr.Value == local.String() {
+					start.Attr[i].Value = ""
+				} else if from, err := s.jidCache.Parse(attr.Value); err == nil && from.Equal(local) {
 					start.Attr[i].Value = ""
 				}
 				break
@@ -584,7 +636,6 @@ func handleInputStream(s *Session, handler Handler) (err error) {
 		}
 	}
 
-	iqOk := isIQ(start.Name)
 	_, _, id, typ := getIDTyp(start.Attr)
 
 	if typ == string(stanza.ResultIQ) || typ == "error" {
@@ -607,17 +658,49 @@ func handleInputStream(s *Session, handler Handler) (err error) {
 			if err != nil {
 				return err
 			}
+			s.handled.Add(1)
 			return nil
 		}
 	}
 
+	isStanza := stanza.Is(start.Name, s.in.XMLNS)
+	if pool != nil {
+		// Buffer the entire element so that the input lock can be released before
+		// the handler runs on one of the pool's workers.
+		toks, err := xmlstream.ReadAll(xmlstream.InnerElement(r))
+		if err != nil {
+			return err
+		}
+		/* #nosec */
+		rc.Close()
+		err = pool.dispatch(start, toks)
+		if err == nil && isStanza {
+			s.handled.Add(1)
+		}
+		return err
+	}
+
+	err = handleElement(s, handler, start, earlyCloser{
+		r: xmlstream.InnerElement(r),
+		c: rc,
+	})
+	if err == nil && isStanza {
+		s.handled.Add(1)
+	}
+	return err
+}
+
+// handleElement calls the handler for a single top level element and writes
+// any default responses that are required.
+// The start element should already have been consumed from r.
+func handleElement(s *Session, handler Handler, start xml.StartElement, r xml.TokenReader) (err error) {
+	iqOk := isIQ(start.Name)
+	_, _, id, typ := getIDTyp(start.Attr)
+
 	w := &deferWriter{s: s}
 	defer w.Close()
 	rw := &responseChecker{
-		TokenReader: earlyCloser{
-			r: xmlstream.InnerElement(r),
-			c: rc,
-		},
+		TokenReader: r,
 		TokenWriter: w,
 		id:          id,
 	}
@@ -655,7 +738,7 @@ func handleInputStream(s *Session, handler Handler) (err error) {
 
 	// Advance to the end of the current element before attempting to read the
 	// next.
-	_, err = xmlstream.Copy(discard, rw)
+	_, err = xmlstream.Copy(xmlstream.Discard(), rw)
 	return err
 }
 
@@ -713,6 +796,9 @@ func (rw *responseChecker) EncodeElement(v interface{}, start xml.StartElement)
 // Feature checks if a feature with the given namespace was advertised
 // by the server for the current stream. If it was data will be the canonical
 // representation of the feature as returned by the feature's Parse function.
+// If the feature was advertised but is not handled by any of the stream
+// features used to negotiate the session, data will be a RawFeature containing
+// the features XML.
 func (s *Session) Feature(namespace string) (data interface{}, ok bool) {
 	data, ok = s.features[namespace]
 	return data, ok
@@ -941,11 +1027,6 @@ func (s *Session) SendElement(ctx context.Context, r xml.TokenReader, start xml.
 }
 
 func send(ctx context.Context, s *Session, r xml.TokenReader, start *xml.StartElement) error {
-	s.out.Lock()
-	defer s.out.Unlock()
-
-	defer setWriteDeadline(ctx, s.conn)()
-
 	if start == nil {
 		tok, err := r.Token()
 		if err != nil {
@@ -960,6 +1041,19 @@ func send(ctx context.Context, s *Session, r xml.TokenReader, start *xml.StartEl
 		r = xmlstream.Inner(r)
 	}
 
+	if sq := s.sendQueue.Load(); sq != nil {
+		err := sq.acquire(ctx, *start)
+		if err != nil {
+			return err
+		}
+		defer sq.release()
+	}
+
+	s.out.Lock()
+	defer s.out.Unlock()
+
+	defer setWriteDeadline(ctx, s.conn)()
+
 	err := s.out.e.EncodeToken(*start)
 	if err != nil {
 		return err
@@ -992,29 +1086,64 @@ func (s *Session) sendResp(ctx context.Context, id string, payload xml.TokenRead
 	c := make(chan xmlstream.TokenReadCloser)
 
 	s.sentStanzaMutex.Lock()
+	limits := s.pendingLimits
+	s.sentStanzaMutex.Unlock()
+	if limits.MaxAge > 0 {
+		var cancelAge context.CancelFunc
+		ctx, cancelAge = context.WithTimeoutCause(ctx, limits.MaxAge, ErrEvicted)
+		defer cancelAge()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	s.sentStanzaMutex.Lock()
+	if limits.MaxCount > 0 {
+		// Make room for the new stanza by evicting the oldest ones.
+		for s.sentOrder.Len() >= limits.MaxCount {
+			front := s.sentOrder.Front()
+			oldest := s.sentOrder.Remove(front).(sentStanza)
+			if old, ok := s.sentStanzas[oldest.id]; ok && old.elem == front {
+				delete(s.sentStanzas, oldest.id)
+			}
+			oldest.cancel(ErrEvicted)
+		}
+	}
+	elem := s.sentOrder.PushBack(sentStanza{id: id, cancel: cancel})
 	s.sentStanzas[id] = tokenReadChan{
 		stanzaName: start.Name,
 		c:          c,
 		ctx:        ctx,
+		cancel:     cancel,
+		elem:       elem,
 	}
 	s.sentStanzaMutex.Unlock()
 	defer func() {
 		s.sentStanzaMutex.Lock()
-		delete(s.sentStanzas, id)
+		// Only remove the entry if it hasn't already been evicted and replaced by
+		// another stanza with the same ID.
+		if sent, ok := s.sentStanzas[id]; ok && sent.elem == elem {
+			delete(s.sentStanzas, id)
+		}
+		s.sentOrder.Remove(elem)
 		s.sentStanzaMutex.Unlock()
 	}()
 
 	err := s.SendElement(ctx, payload, start)
-	if err != nil {
-		return nil, err
+	if err == nil {
+		select {
+		case rr := <-c:
+			return rr, nil
+		case <-ctx.Done():
+			err = context.Cause(ctx)
+		}
 	}
-
-	select {
-	case rr := <-c:
-		return rr, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	if errors.Is(context.Cause(ctx), ErrEvicted) {
+		if limits.Evicted != nil {
+			limits.Evicted(id)
+		}
+		return nil, ErrEvicted
 	}
+	return nil, err
 }
 
 // closeInputStream immediately marks the input stream as closed and cancels any
@@ -1086,16 +1215,8 @@ func (se *stanzaEncoder) EncodeToken(t xml.Token) error {
 		}
 
 		// For all start elements, regardless of depth, prevent duplicate xmlns
-		// attributes. See https://mellium.im/issue/75
-		attrs := tok.Attr[:0]
-		for _, attr := range tok.Attr {
-			if attr.Name.Local == "xmlns" && tok.Name.Space != "" {
-				continue
-			}
-			attrs = append(attrs, attr)
-		}
-		tok.Attr = attrs
-		t = tok
+		// attributes.
+		t = removeDupXMLNS(tok)
 	case xml.EndElement:
 		if se.depth == 1 && tok.Name.Space == "" && isStanzaEmptySpace(tok.Name) {
 			tok.Name.Space = se.ns
@@ -1107,6 +1228,20 @@ func (se *stanzaEncoder) EncodeToken(t xml.Token) error {
 	return se.TokenWriteFlusher.EncodeToken(t)
 }
 
+// removeDupXMLNS prevents duplicate xmlns attributes on start elements.
+// See https://mellium.im/issue/75
+func removeDupXMLNS(tok xml.StartElement) xml.StartElement {
+	attrs := tok.Attr[:0]
+	for _, attr := range tok.Attr {
+		if attr.Name.Local == "xmlns" && tok.Name.Space != "" {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	tok.Attr = attrs
+	return tok
+}
+
 // UpdateAddr sets the address used by the session.
 // If the Ready state bit is already set, UpdateAddr has no effect and ok will
 // be false.
@@ -1120,3 +1255,19 @@ func (s *Session) UpdateAddr(j jid.JID) (ok bool) {
 	s.out.Info.From = j
 	return true
 }
+
+// UpdateRemoteAddr sets the address of the remote entity.
+// It is normally used by receiving entities that restore a previously bound
+// address, for example when resuming a stream.
+// If the Ready state bit is already set, UpdateRemoteAddr has no effect and ok
+// will be false.
+func (s *Session) UpdateRemoteAddr(j jid.JID) (ok bool) {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if s.state&Ready == Ready {
+		return false
+	}
+	s.in.Info.From = j
+	s.out.Info.To = j
+	return true
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"encoding/xml"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/tokens"
+)
+
+// AddOutgoingFilter adds a transformer to the chain that is applied to every
+// stanza before it is written to the session.
+// This can be used to add payloads such as origin IDs or hints, to encrypt
+// stanzas, or to log all outgoing traffic without wrapping the session's
+// TokenWriter.
+//
+// Filters are applied in the order they were added and each filter is called
+// with a token reader over a single complete stanza, including its start and
+// end element.
+// By the time filters are called the stanza has already been normalized: it is
+// in the stream namespace and the "id" and "from" attributes have been added
+// if required.
+// To modify the stanza a filter returns a reader with different tokens, and to
+// drop the stanza entirely it returns a reader that does not return any
+// tokens.
+// If the reader returned by a filter returns an error, the stanza is not sent
+// and the error is returned by the method that was used to send it.
+//
+// Filters apply to stanzas sent with any of the methods on Session, including
+// responses written by handlers and tokens written with TokenWriter, but only
+// once the session has been negotiated.
+// Stream level elements such as stream errors are never filtered.
+// Dropping a stanza that expects a response, such as an IQ sent with SendIQ,
+// means that no response will be received.
+//
+// AddOutgoingFilter is safe for concurrent use by multiple goroutines.
+// A filter added while a stanza is being written is first applied to the next
+// stanza.
+func (s *Session) AddOutgoingFilter(f xmlstream.Transformer) {
+	s.filterMutex.Lock()
+	defer s.filterMutex.Unlock()
+	s.filters = append(s.filters, f)
+}
+
+func (s *Session) outgoingFilters() []xmlstream.Transformer {
+	s.filterMutex.RLock()
+	defer s.filterMutex.RUnlock()
+	return s.filters
+}
+
+// filterWriter buffers stanzas and runs them through the sessions outgoing
+// filters before writing them to the underlying TokenWriteFlusher.
+type filterWriter struct {
+	xmlstream.TokenWriteFlusher
+	s       *Session
+	depth   int
+	filters []xmlstream.Transformer
+	buf     []xml.Token
+}
+
+func (fw *filterWriter) EncodeToken(t xml.Token) error {
+	switch tok := t.(type) {
+	case xml.StartElement:
+		if fw.depth == 0 && isStanzaEmptySpace(tok.Name) {
+			fw.filters = fw.s.outgoingFilters()
+		}
+		fw.depth++
+	case xml.EndElement:
+		fw.depth--
+	}
+	if len(fw.filters) == 0 {
+		return fw.TokenWriteFlusher.EncodeToken(t)
+	}
+
+	fw.buf = append(fw.buf, xml.CopyToken(t))
+	if fw.depth > 0 {
+		return nil
+	}
+
+	// We have a complete stanza, filter and write it.
+	r := tokens.Reader(fw.buf)
+	filters := fw.filters
+	fw.buf = fw.buf[:0]
+	fw.filters = nil
+	stages := make([]xml.TokenReader, 0, len(filters))
+	for _, f := range filters {
+		r = f(r)
+		stages = append(stages, r)
+	}
+	// Read the entire filtered stanza first so that nothing is written if any of
+	// the filters fail.
+	out, err := xmlstream.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	// If a filter dropped the stanza, earlier filters may not have seen all of
+	// it. Drain them so that filters which track state across stanzas (such as
+	// those created by xmlstream.InsertFunc) always see complete elements.
+	for _, stage := range stages {
+		_, err = xmlstream.Copy(xmlstream.Discard(), stage)
+		if err != nil {
+			return err
+		}
+	}
+	for _, tok := range out {
+		if start, ok := tok.(xml.StartElement); ok {
+			tok = removeDupXMLNS(start)
+		}
+		err = fw.TokenWriteFlusher.EncodeToken(tok)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/stanza"
+)
+
+// dropPresence is a filter that drops all presence stanzas.
+func dropPresence(r xml.TokenReader) xml.TokenReader {
+	return xmlstream.ReaderFunc(func() (xml.Token, error) {
+		tok, err := r.Token()
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "presence" {
+			return nil, io.EOF
+		}
+		return tok, err
+	})
+}
+
+func TestOutgoingFilter(t *testing.T) {
+	var buf bytes.Buffer
+	s := xmpptest.NewClientSession(xmpp.Ready, &buf)
+	s.AddOutgoingFilter(xmlstream.InsertFunc(func(start xml.StartElement, level uint64, w xmlstream.TokenWriter) error {
+		if level != 1 || start.Name.Local != "message" {
+			return nil
+		}
+		_, err := xmlstream.Copy(w, xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "hint"}}))
+		return err
+	}))
+	s.AddOutgoingFilter(dropPresence)
+	buf.Reset()
+
+	ctx := context.Background()
+	err := s.Send(ctx, stanza.Message{ID: "1"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	err = s.Send(ctx, stanza.Presence{ID: "2"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error sending presence: %v", err)
+	}
+	err = s.Encode(ctx, stanza.IQ{ID: "3", Type: stanza.ResultIQ})
+	if err != nil {
+		t.Fatalf("error encoding IQ: %v", err)
+	}
+	w := s.TokenWriter()
+	_, err = xmlstream.Copy(w, stanza.Message{ID: "4"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error writing message: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("error closing token writer: %v", err)
+	}
+
+	const want = `<message xmlns="jabber:client" type="" id="1"><hint xmlns="urn:example"></hint></message>` +
+		`<iq xmlns="jabber:client" id="3" to="" type="result"></iq>` +
+		`<message xmlns="jabber:client" type="" id="4"><hint xmlns="urn:example"></hint></message>`
+	if out := buf.String(); out != want {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", want, out)
+	}
+}
+
+func TestOutgoingFilterError(t *testing.T) {
+	var buf bytes.Buffer
+	s := xmpptest.NewClientSession(xmpp.Ready, &buf)
+	s.AddOutgoingFilter(func(r xml.TokenReader) xml.TokenReader {
+		return xmlstream.MultiReader(xmlstream.Token(xml.StartElement{Name: xml.Name{Local: "message"}}), errReader{err: errExpected})
+	})
+	buf.Reset()
+
+	err := s.Send(context.Background(), stanza.Message{ID: "1"}.Wrap(nil))
+	if !errors.Is(err, errExpected) {
+		t.Errorf("wrong error: want=%v, got=%v", errExpected, err)
+	}
+	if strings.Contains(buf.String(), "message") {
+		t.Errorf("stanza should not have been written, got %s", buf.String())
+	}
+}
@@ -7,12 +7,15 @@ package xmpp
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/internal/attr"
 	"mellium.im/xmpp/internal/marshal"
+	"mellium.im/xmpp/internal/tokens"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -63,7 +66,7 @@ func (s *Session) SendIQ(ctx context.Context, r xml.TokenReader) (xmlstream.Toke
 
 	// If this an IQ of type "set" or "get" we expect a response.
 	if typ == string(stanza.GetIQ) || typ == string(stanza.SetIQ) {
-		return s.sendResp(ctx, id, xmlstream.Inner(r), start)
+		return s.sendIQResp(ctx, id, typ, xmlstream.Inner(r), start)
 	}
 
 	// If this is an IQ of type result or error, we don't expect a response so
@@ -71,6 +74,144 @@ func (s *Session) SendIQ(ctx context.Context, r xml.TokenReader) (xmlstream.Toke
 	return nil, s.SendElement(ctx, xmlstream.Inner(r), start)
 }
 
+// sendIQResp sends an IQ that expects a response while applying the sessions
+// IQPolicy.
+func (s *Session) sendIQResp(ctx context.Context, id, typ string, payload xml.TokenReader, start xml.StartElement) (xmlstream.TokenReadCloser, error) {
+	s.sentStanzaMutex.Lock()
+	policy := s.iqPolicy
+	s.sentStanzaMutex.Unlock()
+
+	retries := policy.Retries
+	if typ != string(stanza.GetIQ) || retries < 0 {
+		retries = 0
+	}
+	if policy.Timeout <= 0 {
+		return s.sendResp(ctx, id, payload, start)
+	}
+
+	// If we might have to send the IQ more than once, buffer the payload.
+	var toks []xml.Token
+	if retries > 0 {
+		var err error
+		toks, err = xmlstream.ReadAll(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; ; i++ {
+		if retries > 0 {
+			payload = tokens.Reader(toks)
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		resp, err := s.sendResp(attemptCtx, id, payload, start)
+		cancel()
+		if err == nil || i >= retries || ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+			return resp, err
+		}
+	}
+}
+
+// IQPolicy controls how long IQs that expect a response wait for one, and
+// whether they are resent if no response is received.
+type IQPolicy struct {
+	// Timeout is the maximum amount of time to wait for a response to each
+	// attempt at sending an IQ.
+	// If Timeout is zero only the context passed to SendIQ (or one of the
+	// related methods) limits how long to wait for a response.
+	Timeout time.Duration
+
+	// Retries is the number of times an IQ of type "get" is resent with the same
+	// ID if no response is received before the timeout.
+	// IQs of type "set" are never resent because they may not be idempotent.
+	// Retries is ignored if Timeout is zero.
+	Retries int
+}
+
+// SetIQPolicy sets the policy that is used when sending IQs that expect a
+// response.
+// The policy only applies to IQs sent after SetIQPolicy returns.
+//
+// SetIQPolicy is safe for concurrent use by multiple goroutines.
+func (s *Session) SetIQPolicy(p IQPolicy) {
+	s.sentStanzaMutex.Lock()
+	defer s.sentStanzaMutex.Unlock()
+	s.iqPolicy = p
+}
+
+// PendingLimits bounds the number of stanzas that can be waiting for a
+// response at once and how long each one can wait.
+// This prevents memory from growing without bound in long lived sessions that
+// send many requests to entities that never respond, for example components
+// that fan out IQs to peers that have gone offline.
+//
+// A stanza that exceeds the limits is evicted, and the call that sent it
+// returns ErrEvicted.
+// Any response received at a later time will be handled by the Serve handler.
+type PendingLimits struct {
+	// MaxCount is the maximum number of stanzas that can wait for a response at
+	// once.
+	// If sending a new stanza would exceed it, the oldest pending stanzas are
+	// evicted.
+	// If MaxCount is zero the number of pending stanzas is not limited.
+	MaxCount int
+
+	// MaxAge is the maximum amount of time that a stanza can wait for a
+	// response, regardless of the context it was sent with.
+	// Unlike the IQPolicy Timeout, IQs that are evicted because of their age
+	// are never resent.
+	// If MaxAge is zero, only the context limits how long to wait.
+	MaxAge time.Duration
+
+	// If set, Evicted is called with the ID of each stanza that is evicted from
+	// the goroutine that sent it.
+	Evicted func(id string)
+}
+
+// SetPendingLimits sets the limits that apply to stanzas waiting for a
+// response.
+// The limits are checked each time a stanza is sent, so changes to MaxAge and
+// Evicted only apply to stanzas sent after SetPendingLimits returns.
+//
+// SetPendingLimits is safe for concurrent use by multiple goroutines.
+func (s *Session) SetPendingLimits(l PendingLimits) {
+	s.sentStanzaMutex.Lock()
+	defer s.sentStanzaMutex.Unlock()
+	s.pendingLimits = l
+}
+
+// PendingIQs returns the IDs of all IQs that have been sent and are currently
+// waiting for a response.
+//
+// PendingIQs is safe for concurrent use by multiple goroutines.
+func (s *Session) PendingIQs() []string {
+	s.sentStanzaMutex.Lock()
+	defer s.sentStanzaMutex.Unlock()
+	var ids []string
+	for id, c := range s.sentStanzas {
+		if c.stanzaName.Local == "iq" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CancelIQ stops waiting for a response to the pending IQ with the provided ID
+// and causes the call that sent it to return ErrIQCanceled.
+// Any response received at a later time will be handled by the Serve handler.
+// If no IQ with the provided ID is pending, CancelIQ returns false.
+//
+// CancelIQ is safe for concurrent use by multiple goroutines.
+func (s *Session) CancelIQ(id string) bool {
+	s.sentStanzaMutex.Lock()
+	defer s.sentStanzaMutex.Unlock()
+	c, ok := s.sentStanzas[id]
+	if !ok || c.stanzaName.Local != "iq" {
+		return false
+	}
+	c.cancel(ErrIQCanceled)
+	return true
+}
+
 // SendIQElement is like SendIQ except that it wraps the payload in an
 // Info/Query (IQ) element.
 // For more information see SendIQ.
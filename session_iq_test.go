@@ -5,11 +5,18 @@
 package xmpp_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
 	"mellium.im/xmpp/internal/xmpptest"
 	"mellium.im/xmpp/ping"
 	"mellium.im/xmpp/stanza"
@@ -127,3 +134,227 @@ func TestInvalidStanzaErrorResponse(t *testing.T) {
 		t.Fatalf("wrong stanza in response: want=%v, got=%v", iqName, start.Name)
 	}
 }
+
+func TestIQPolicy(t *testing.T) {
+	for _, typ := range []stanza.IQType{stanza.GetIQ, stanza.SetIQ} {
+		t.Run(string(typ), func(t *testing.T) {
+			var out bytes.Buffer
+			s := xmpptest.NewClientSession(0, struct {
+				io.Reader
+				io.Writer
+			}{
+				Reader: strings.NewReader(""),
+				Writer: &out,
+			})
+			s.SetIQPolicy(xmpp.IQPolicy{
+				Timeout: 10 * time.Millisecond,
+				Retries: 2,
+			})
+			resp, err := s.SendIQ(context.Background(), stanza.IQ{
+				ID:   "123",
+				Type: typ,
+			}.Wrap(xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: ping.NS, Local: "ping"}})))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("wrong error: want=%v, got=%v", context.DeadlineExceeded, err)
+			}
+			if resp != nil {
+				t.Errorf("expected nil response on timeout")
+			}
+			sent := strings.Count(out.String(), `id="123"`)
+			expected := 1
+			if typ == stanza.GetIQ {
+				expected = 3
+			}
+			if sent != expected {
+				t.Errorf("wrong number of attempts: want=%d, got=%d", expected, sent)
+			}
+			if ids := s.PendingIQs(); len(ids) != 0 {
+				t.Errorf("expected no pending IQs, got %v", ids)
+			}
+		})
+	}
+}
+
+func TestCancelIQ(t *testing.T) {
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(""),
+		Writer: io.Discard,
+	})
+	if s.CancelIQ("123") {
+		t.Errorf("expected canceling unknown IQ to return false")
+	}
+
+	errC := make(chan error)
+	go func() {
+		_, err := s.SendIQ(context.Background(), stanza.IQ{
+			ID:   "123",
+			Type: stanza.GetIQ,
+		}.Wrap(nil))
+		errC <- err
+	}()
+	for {
+		ids := s.PendingIQs()
+		if len(ids) == 1 && ids[0] == "123" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !s.CancelIQ("123") {
+		t.Fatalf("expected canceling pending IQ to return true")
+	}
+	if err := <-errC; !errors.Is(err, xmpp.ErrIQCanceled) {
+		t.Errorf("wrong error: want=%v, got=%v", xmpp.ErrIQCanceled, err)
+	}
+}
+
+func TestPendingLimitsCount(t *testing.T) {
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(""),
+		Writer: io.Discard,
+	})
+	evicted := make(chan string, 2)
+	s.SetPendingLimits(xmpp.PendingLimits{
+		MaxCount: 2,
+		Evicted: func(id string) {
+			evicted <- id
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errC := make(chan error, 3)
+	for i, id := range []string{"1", "2", "3"} {
+		go func(id string) {
+			_, err := s.SendIQ(ctx, stanza.IQ{
+				ID:   id,
+				Type: stanza.GetIQ,
+			}.Wrap(nil))
+			errC <- err
+		}(id)
+		// Wait for each IQ to be pending so that the order is deterministic.
+		for len(s.PendingIQs()) != i+1 && i < 2 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := <-errC; !errors.Is(err, xmpp.ErrEvicted) {
+		t.Errorf("wrong error: want=%v, got=%v", xmpp.ErrEvicted, err)
+	}
+	if id := <-evicted; id != "1" {
+		t.Errorf("wrong IQ evicted: want=1, got=%s", id)
+	}
+	for len(s.PendingIQs()) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	ids := s.PendingIQs()
+	sort.Strings(ids)
+	if len(ids) != 2 || ids[0] != "2" || ids[1] != "3" {
+		t.Errorf("wrong pending IQs: want=[2 3], got=%v", ids)
+	}
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errC; !errors.Is(err, context.Canceled) {
+			t.Errorf("wrong error: want=%v, got=%v", context.Canceled, err)
+		}
+	}
+	select {
+	case id := <-evicted:
+		t.Errorf("unexpected eviction of canceled IQ %s", id)
+	default:
+	}
+}
+
+func TestPendingLimitsAge(t *testing.T) {
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(""),
+		Writer: io.Discard,
+	})
+	var evicted string
+	s.SetPendingLimits(xmpp.PendingLimits{
+		MaxAge: 10 * time.Millisecond,
+		Evicted: func(id string) {
+			evicted = id
+		},
+	})
+	// The IQ policy should not resend IQs that were evicted.
+	s.SetIQPolicy(xmpp.IQPolicy{
+		Timeout: time.Second,
+		Retries: 2,
+	})
+	_, err := s.SendIQ(context.Background(), stanza.IQ{
+		ID:   "123",
+		Type: stanza.GetIQ,
+	}.Wrap(nil))
+	if !errors.Is(err, xmpp.ErrEvicted) {
+		t.Errorf("wrong error: want=%v, got=%v", xmpp.ErrEvicted, err)
+	}
+	if evicted != "123" {
+		t.Errorf("wrong IQ evicted: want=123, got=%q", evicted)
+	}
+	if ids := s.PendingIQs(); len(ids) != 0 {
+		t.Errorf("expected no pending IQs, got %v", ids)
+	}
+}
+
+func TestPendingLimitsDuplicateID(t *testing.T) {
+	s := xmpptest.NewClientSession(0, struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(""),
+		Writer: io.Discard,
+	})
+	s.SetPendingLimits(xmpp.PendingLimits{
+		MaxCount: 2,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type result struct {
+		i   int
+		err error
+	}
+	errC := make(chan result, 3)
+	for i, id := range []string{"1", "1", "2"} {
+		go func(i int, id string) {
+			_, err := s.SendIQ(ctx, stanza.IQ{
+				ID:   id,
+				Type: stanza.GetIQ,
+			}.Wrap(nil))
+			errC <- result{i: i, err: err}
+		}(i, id)
+		// Wait for each IQ to be pending so that the order is deterministic.
+		// Both IQs with the same ID are only listed once, so give the second one
+		// time to be sent.
+		for len(s.PendingIQs()) != 1 && i < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		if i == 1 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The oldest IQ should be evicted, not the newer one that reused its ID.
+	select {
+	case r := <-errC:
+		if r.i != 0 || !errors.Is(r.err, xmpp.ErrEvicted) {
+			t.Errorf("wrong IQ evicted: want=0 (%v), got=%d (%v)", xmpp.ErrEvicted, r.i, r.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the oldest IQ to be evicted")
+	}
+	ids := s.PendingIQs()
+	sort.Strings(ids)
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("wrong pending IQs: want=[1 2], got=%v", ids)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"container/heap"
+	"context"
+	"encoding/xml"
+	"errors"
+	"sync"
+)
+
+// ErrSendQueueFull is returned when a stanza is sent while the send queue is
+// at its maximum depth.
+// It indicates that the remote entity is not reading data as fast as it is
+// being sent and that the caller should slow down or try again later.
+var ErrSendQueueFull = errors.New("xmpp: send queue is full")
+
+// Priority is the priority of an outgoing element in the send queue.
+// Elements with a higher priority are written before elements with a lower
+// priority, elements with the same priority are written in the order in which
+// they were sent.
+type Priority int
+
+// A list of priorities used by DefaultPriority.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// DefaultPriority is the priority function used if SendQueue.Priority is nil.
+// It gives IQs (including pings) and elements that are not stanzas (such as
+// stream management acks) high priority, presence normal priority, and
+// messages low priority so that bulk messages do not hold up requests.
+func DefaultPriority(start xml.StartElement) Priority {
+	switch {
+	case !isStanzaEmptySpace(start.Name) || start.Name.Local == "iq":
+		return PriorityHigh
+	case start.Name.Local == "message":
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// SendQueue configures a queue that orders elements waiting to be written to
+// the session.
+// Without a queue, concurrent calls to Send and related methods wait for the
+// output stream in no particular order.
+//
+// The queue applies to Send, SendElement, and the methods built on top of them
+// such as SendIQ and SendMessage.
+// Encode and EncodeElement do not use the queue.
+type SendQueue struct {
+	// MaxDepth is the maximum number of elements that may be waiting to be
+	// written at once.
+	// If an element is sent while the queue is full ErrSendQueueFull is
+	// returned instead of waiting.
+	// If MaxDepth is zero the depth of the queue is not limited.
+	MaxDepth int
+
+	// Priority returns the priority of an element from its start element.
+	// If Priority is nil, DefaultPriority is used.
+	Priority func(start xml.StartElement) Priority
+}
+
+// SetSendQueue enables a send queue with the provided configuration for
+// elements sent after SetSendQueue returns.
+// If q is nil, the queue is disabled.
+//
+// SetSendQueue is safe for concurrent use by multiple goroutines.
+func (s *Session) SetSendQueue(q *SendQueue) {
+	if q == nil {
+		s.sendQueue.Store(nil)
+		return
+	}
+	sq := &sendQueue{cfg: *q}
+	if sq.cfg.Priority == nil {
+		sq.cfg.Priority = DefaultPriority
+	}
+	s.sendQueue.Store(sq)
+}
+
+// SendQueueLen returns the number of elements that are currently waiting to
+// be written to the session, not including any element that is being written.
+// It is always zero if no send queue has been configured.
+//
+// SendQueueLen is safe for concurrent use by multiple goroutines.
+func (s *Session) SendQueueLen() int {
+	sq := s.sendQueue.Load()
+	if sq == nil {
+		return 0
+	}
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	return len(sq.waiting)
+}
+
+// sendTicket is an element waiting in the send queue.
+type sendTicket struct {
+	prio  Priority
+	seq   uint64
+	index int
+	ready chan struct{}
+}
+
+// ticketHeap is a heap of tickets ordered by priority and then by the order in
+// which they were added.
+type ticketHeap []*sendTicket
+
+func (h ticketHeap) Len() int { return len(h) }
+
+func (h ticketHeap) Less(i, j int) bool {
+	if h[i].prio != h[j].prio {
+		return h[i].prio > h[j].prio
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h ticketHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *ticketHeap) Push(x interface{}) {
+	t := x.(*sendTicket)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *ticketHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
+
+// sendQueue grants access to the output stream to one sender at a time, handing
+// it off to the waiting sender with the highest priority when the current
+// sender is done.
+type sendQueue struct {
+	cfg SendQueue
+
+	mu      sync.Mutex
+	busy    bool
+	seq     uint64
+	waiting ticketHeap
+}
+
+// acquire blocks until the caller may write an element with the provided start
+// element or until ctx is canceled.
+// If it returns nil, release must be called when the element has been written.
+func (q *sendQueue) acquire(ctx context.Context, start xml.StartElement) error {
+	q.mu.Lock()
+	if !q.busy {
+		q.busy = true
+		q.mu.Unlock()
+		return nil
+	}
+	if q.cfg.MaxDepth > 0 && len(q.waiting) >= q.cfg.MaxDepth {
+		q.mu.Unlock()
+		return ErrSendQueueFull
+	}
+	t := &sendTicket{
+		prio:  q.cfg.Priority(start),
+		seq:   q.seq,
+		ready: make(chan struct{}),
+	}
+	q.seq++
+	heap.Push(&q.waiting, t)
+	q.mu.Unlock()
+
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	q.mu.Lock()
+	if t.index >= 0 {
+		heap.Remove(&q.waiting, t.index)
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+	q.mu.Unlock()
+	// We were handed the output stream at the same time as the context was
+	// canceled so pass it on to the next sender.
+	q.release()
+	return ctx.Err()
+}
+
+// release hands the output stream to the next waiting sender, if any.
+func (q *sendQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) == 0 {
+		q.busy = false
+		return
+	}
+	t := heap.Pop(&q.waiting).(*sendTicket)
+	close(t.ready)
+}
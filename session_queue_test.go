@@ -0,0 +1,150 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+)
+
+// gateConn blocks writes until the gate is opened and records what was written.
+type gateConn struct {
+	started chan struct{}
+	gate    chan struct{}
+	once    sync.Once
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (c *gateConn) Read([]byte) (int, error) {
+	select {}
+}
+
+func (c *gateConn) Write(p []byte) (int, error) {
+	c.once.Do(func() { close(c.started) })
+	<-c.gate
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
+
+func waitQueueLen(t *testing.T, s *xmpp.Session, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.SendQueueLen() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for send queue length %d, got %d", n, s.SendQueueLen())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSendQueue(t *testing.T) {
+	conn := &gateConn{
+		started: make(chan struct{}),
+		gate:    make(chan struct{}),
+	}
+	s := xmpptest.NewClientSession(0, conn)
+	s.SetSendQueue(&xmpp.SendQueue{MaxDepth: 3})
+
+	var wg sync.WaitGroup
+	send := func(el string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Send(context.Background(), xml.NewDecoder(strings.NewReader(el)))
+			if err != nil {
+				t.Errorf("error sending %s: %v", el, err)
+			}
+		}()
+	}
+
+	// The first message is being written and holds up the rest.
+	send(`<message id="1"/>`)
+	<-conn.started
+
+	send(`<message id="2"/>`)
+	waitQueueLen(t, s, 1)
+	send(`<presence id="3"/>`)
+	waitQueueLen(t, s, 2)
+	send(`<iq id="4" type="get"/>`)
+	waitQueueLen(t, s, 3)
+
+	err := s.Send(context.Background(), xml.NewDecoder(strings.NewReader(`<message id="5"/>`)))
+	if !errors.Is(err, xmpp.ErrSendQueueFull) {
+		t.Errorf("wrong error sending to full queue: want=%v, got=%v", xmpp.ErrSendQueueFull, err)
+	}
+
+	close(conn.gate)
+	wg.Wait()
+
+	out := conn.buf.String()
+	var ids []string
+	d := xml.NewDecoder(strings.NewReader(out))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "id" {
+					ids = append(ids, attr.Value)
+				}
+			}
+		}
+	}
+	if got := strings.Join(ids, ","); got != "1,4,3,2" {
+		t.Errorf("wrong send order: want=1,4,3,2, got=%s (%s)", got, out)
+	}
+}
+
+func TestSendQueueCancel(t *testing.T) {
+	conn := &gateConn{
+		started: make(chan struct{}),
+		gate:    make(chan struct{}),
+	}
+	s := xmpptest.NewClientSession(0, conn)
+	s.SetSendQueue(&xmpp.SendQueue{})
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- s.Send(context.Background(), xml.NewDecoder(strings.NewReader(`<message id="1"/>`)))
+	}()
+	<-conn.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for s.SendQueueLen() != 1 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	err := s.Send(ctx, xml.NewDecoder(strings.NewReader(`<message id="2"/>`)))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error: want=%v, got=%v", context.Canceled, err)
+	}
+	if l := s.SendQueueLen(); l != 0 {
+		t.Errorf("canceled element left in queue, got length %d", l)
+	}
+
+	close(conn.gate)
+	if err := <-errC; err != nil {
+		t.Errorf("error sending first message: %v", err)
+	}
+	if out := conn.buf.String(); strings.Contains(out, `id="2"`) {
+		t.Errorf("canceled element was written: %s", out)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/tokens"
+)
+
+// ErrScheduleCanceled is reported by a scheduled send that was canceled before
+// the stanza was sent.
+var ErrScheduleCanceled = errors.New("xmpp: scheduled send canceled")
+
+// Scheduled is a handle to a stanza that will be sent at a later time.
+type Scheduled struct {
+	at    time.Time
+	timer *time.Timer
+	stop  func() bool
+	done  chan struct{}
+
+	mu      sync.Mutex
+	started bool
+	err     error
+}
+
+// ScheduleSend buffers the first element read from r and sends it at the
+// provided time.
+// If the time is in the past, the element is sent immediately on another
+// goroutine.
+// This can be used for reminders or to spread a large number of stanzas over a
+// period of time.
+//
+// The element is sent using Send, so outgoing filters are applied at the time
+// it is sent and not when it is scheduled.
+// Scheduled stanzas are only kept in memory: if the session is closed before
+// the time is reached sending fails and the error is reported by the handle.
+//
+// The context is used when sending the stanza.
+// If it is canceled or its deadline passes before the stanza is sent, the
+// scheduled send is canceled and the context's error is reported by the
+// handle.
+//
+// ScheduleSend is safe for concurrent use by multiple goroutines.
+func (s *Session) ScheduleSend(ctx context.Context, at time.Time, r xml.TokenReader) (*Scheduled, error) {
+	tok, err := r.Token()
+	if err != nil {
+		return nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, fmt.Errorf("xmpp: expected start element, got %T", tok)
+	}
+	inner, err := xmlstream.ReadAll(xmlstream.InnerElement(r))
+	if err != nil {
+		return nil, err
+	}
+	toks := append([]xml.Token{start.Copy()}, inner...)
+
+	sc := &Scheduled{
+		at:   at,
+		done: make(chan struct{}),
+	}
+	// Hold the lock until both the timer and the context callback are set up
+	// so that neither of them can run before the other exists.
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.timer = time.AfterFunc(time.Until(at), func() {
+		sc.mu.Lock()
+		if sc.err != nil {
+			sc.mu.Unlock()
+			return
+		}
+		sc.started = true
+		sc.mu.Unlock()
+
+		err := s.Send(ctx, tokens.Reader(toks))
+		sc.stop()
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		sc.err = err
+		close(sc.done)
+	})
+	sc.stop = context.AfterFunc(ctx, func() {
+		sc.cancel(ctx.Err())
+	})
+	return sc, nil
+}
+
+// At returns the time at which the stanza is scheduled to be sent.
+func (sc *Scheduled) At() time.Time {
+	return sc.at
+}
+
+// Cancel prevents the stanza from being sent.
+// It returns false if the stanza has already been sent, is being sent, or was
+// already canceled.
+func (sc *Scheduled) Cancel() bool {
+	return sc.cancel(ErrScheduleCanceled)
+}
+
+func (sc *Scheduled) cancel(err error) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.started || sc.err != nil {
+		return false
+	}
+	sc.timer.Stop()
+	sc.stop()
+	sc.err = err
+	close(sc.done)
+	return true
+}
+
+// Done returns a channel that is closed when the stanza has been sent, sending
+// has failed, or the send was canceled.
+func (sc *Scheduled) Done() <-chan struct{} {
+	return sc.done
+}
+
+// Err returns the error encountered while sending the stanza, the context's
+// error if the context passed to ScheduleSend ended first, or
+// ErrScheduleCanceled if it was canceled.
+// It returns nil if the stanza was sent or has not been sent yet.
+func (sc *Scheduled) Err() error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.err
+}
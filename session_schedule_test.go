@@ -0,0 +1,104 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/stanza"
+)
+
+func TestScheduleSend(t *testing.T) {
+	received := make(chan string, 3)
+	s := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(_ xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		_, id := attr.Get(start.Attr, "id")
+		received <- id
+		return nil
+	}))
+
+	later, err := s.Client.ScheduleSend(context.Background(), time.Now().Add(time.Hour), stanza.Message{ID: "later"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error scheduling later message: %v", err)
+	}
+	soon, err := s.Client.ScheduleSend(context.Background(), time.Now().Add(10*time.Millisecond), stanza.Message{ID: "soon"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error scheduling soon message: %v", err)
+	}
+	past, err := s.Client.ScheduleSend(context.Background(), time.Now().Add(-time.Hour), stanza.Message{ID: "past"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error scheduling past message: %v", err)
+	}
+
+	for _, sc := range []*xmpp.Scheduled{past, soon} {
+		select {
+		case <-sc.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for message scheduled at %v", sc.At())
+		}
+		if err := sc.Err(); err != nil {
+			t.Errorf("unexpected error sending message: %v", err)
+		}
+		if sc.Cancel() {
+			t.Errorf("expected cancel to fail after message was sent")
+		}
+	}
+	if !later.Cancel() {
+		t.Errorf("expected cancel to succeed before message was sent")
+	}
+	<-later.Done()
+	if err := later.Err(); !errors.Is(err, xmpp.ErrScheduleCanceled) {
+		t.Errorf("wrong error after cancel: want=%v, got=%v", xmpp.ErrScheduleCanceled, err)
+	}
+	if later.Cancel() {
+		t.Errorf("expected second cancel to fail")
+	}
+
+	got := map[string]bool{<-received: true, <-received: true}
+	if !got["past"] || !got["soon"] {
+		t.Errorf("wrong messages received: %v", got)
+	}
+	select {
+	case id := <-received:
+		t.Errorf("unexpected message received: %s", id)
+	default:
+	}
+}
+
+func TestScheduleSendNotStart(t *testing.T) {
+	s := xmpptest.NewClientServer()
+	_, err := s.Client.ScheduleSend(context.Background(), time.Now(), xmlstream.Token(xml.CharData("test")))
+	if err == nil {
+		t.Errorf("expected error when scheduling tokens that are not an element")
+	}
+}
+
+func TestScheduleSendContext(t *testing.T) {
+	s := xmpptest.NewClientServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	sc, err := s.Client.ScheduleSend(ctx, time.Now().Add(time.Hour), stanza.Message{ID: "later"}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error scheduling message: %v", err)
+	}
+	cancel()
+	select {
+	case <-sc.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for scheduled send to be canceled")
+	}
+	if err := sc.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error after canceling context: want=%v, got=%v", context.Canceled, err)
+	}
+	if sc.Cancel() {
+		t.Errorf("expected cancel to fail after the context was canceled")
+	}
+}
@@ -129,7 +129,7 @@ var negotiateTests = [...]negotiateTestCase{
 		}),
 		in:  `<stream:stream id='316732270768047465' version='1.0' xml:lang='en' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:client'><stream:features><other/></stream:features>`,
 		out: `<?xml version="1.0" encoding="UTF-8"?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>`,
-		err: errors.New("xmpp: features advertised out of order"),
+		err: errors.New("xmpp: none of the advertised stream features could be negotiated: {jabber:client}other (not supported)"),
 	},
 	3: {
 		negotiator: xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
@@ -169,6 +169,66 @@ func TestNegotiator(t *testing.T) {
 	}
 }
 
+func TestUnknownFeature(t *testing.T) {
+	const (
+		nsRosterVer = "urn:xmpp:features:rosterver"
+		raw         = `<ver xmlns="urn:xmpp:features:rosterver"><optional xmlns="urn:xmpp:features:rosterver"></optional></ver>`
+	)
+	var hooked []string
+	negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+		return xmpp.StreamConfig{
+			Features: []xmpp.StreamFeature{readyFeature},
+			FeatureHook: func(_ context.Context, _ *xmpp.Session, f xmpp.RawFeature) error {
+				hooked = append(hooked, f.Start.Name.Space)
+				return nil
+			},
+		}
+	})
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(`<stream:stream id='316732270768047465' version='1.0' xml:lang='en' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:server'><stream:features><ver xmlns='urn:xmpp:features:rosterver'><optional/></ver><ready xmlns='urn:example'/></stream:features>`),
+		Writer: io.Discard,
+	}
+	session, err := xmpp.NewSession(context.Background(), jid.JID{}, jid.JID{}, rw, xmpp.S2S, negotiator)
+	if err != nil {
+		t.Fatalf("error negotiating session: %v", err)
+	}
+	if len(hooked) != 1 || hooked[0] != nsRosterVer {
+		t.Errorf("wrong features passed to hook: want=[%s], got=%v", nsRosterVer, hooked)
+	}
+	if data, ok := session.Feature("urn:example"); !ok || data != nil {
+		t.Errorf("wrong data for supported feature: want=(<nil>, true), got=(%v, %t)", data, ok)
+	}
+	data, ok := session.Feature(nsRosterVer)
+	if !ok {
+		t.Fatalf("unknown feature was not recorded")
+	}
+	f, ok := data.(xmpp.RawFeature)
+	if !ok {
+		t.Fatalf("wrong type for unknown feature data: %T", data)
+	}
+	// Read the feature twice to make sure the tokens are not consumed.
+	for i := 0; i < 2; i++ {
+		var buf strings.Builder
+		e := xml.NewEncoder(&buf)
+		_, err = xmlstream.Copy(e, xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+			return attr.Name.Local == "xmlns"
+		})(f.TokenReader()))
+		if err != nil {
+			t.Fatalf("error encoding feature: %v", err)
+		}
+		err = e.Flush()
+		if err != nil {
+			t.Fatalf("error flushing feature: %v", err)
+		}
+		if out := buf.String(); out != raw {
+			t.Errorf("wrong feature XML: want=%s, got=%s", raw, out)
+		}
+	}
+}
+
 const invalidIQ = `<iq xmlns="jabber:client" type="error" id="1234"><error type="cancel"><service-unavailable xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></service-unavailable></error></iq>`
 
 var failHandler xmpp.HandlerFunc = func(r xmlstream.TokenReadEncoder, t *xml.StartElement) error {
@@ -0,0 +1,50 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+// Value returns the value associated with key on the session or nil if no
+// value has been set.
+//
+// Values are a way for handlers and packages to store state for the lifetime of
+// a single session (such as negotiated features, user preferences, or joined
+// rooms) without keeping a global map keyed by session.
+// Like the keys used for context values, a key should be an unexported type
+// defined by the package that uses it to avoid collisions, for example:
+//
+//	type prefsKey struct{}
+//
+//	session.SetValue(prefsKey{}, prefs)
+//
+// It is safe to call Value, SetValue, and LoadOrStoreValue concurrently.
+func (s *Session) Value(key interface{}) interface{} {
+	v, _ := s.values.Load(key)
+	return v
+}
+
+// SetValue associates val with key on the session, replacing any existing
+// value.
+// If val is nil the key is removed.
+// The key must be comparable.
+//
+// For more information see Value.
+func (s *Session) SetValue(key, val interface{}) {
+	if val == nil {
+		s.values.Delete(key)
+		return
+	}
+	s.values.Store(key, val)
+}
+
+// LoadOrStoreValue returns the existing value for key if one is present.
+// Otherwise it stores and returns val.
+// The loaded result is true if the value was loaded and false if it was
+// stored.
+// This allows state to be lazily initialized by concurrent handlers without any
+// extra locking.
+//
+// For more information see Value.
+func (s *Session) LoadOrStoreValue(key, val interface{}) (actual interface{}, loaded bool) {
+	return s.values.LoadOrStore(key, val)
+}
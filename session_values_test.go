@@ -0,0 +1,75 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+)
+
+type testKey struct{}
+
+type otherKey string
+
+func TestSessionValues(t *testing.T) {
+	s := xmpptest.NewClientSession(xmpp.Ready, nil)
+
+	if v := s.Value(testKey{}); v != nil {
+		t.Fatalf("expected no value on new session, got %v", v)
+	}
+	s.SetValue(testKey{}, "foo")
+	s.SetValue(otherKey("test"), "bar")
+	if v := s.Value(testKey{}); v != "foo" {
+		t.Errorf("wrong value: want=foo, got=%v", v)
+	}
+	if v := s.Value(otherKey("test")); v != "bar" {
+		t.Errorf("wrong value for other key: want=bar, got=%v", v)
+	}
+	if v := s.Value("test"); v != nil {
+		t.Errorf("keys of different types should not collide, got %v", v)
+	}
+
+	s.SetValue(testKey{}, nil)
+	if v := s.Value(testKey{}); v != nil {
+		t.Errorf("expected value to be removed, got %v", v)
+	}
+
+	other := xmpptest.NewClientSession(xmpp.Ready, nil)
+	if v := other.Value(otherKey("test")); v != nil {
+		t.Errorf("values should not be shared between sessions, got %v", v)
+	}
+}
+
+func TestSessionLoadOrStoreValue(t *testing.T) {
+	s := xmpptest.NewClientSession(xmpp.Ready, nil)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stored := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, loaded := s.LoadOrStoreValue(testKey{}, strconv.Itoa(i))
+			if !loaded {
+				mu.Lock()
+				stored++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if stored != 1 {
+		t.Errorf("expected the value to be stored exactly once, got %d", stored)
+	}
+	actual, loaded := s.LoadOrStoreValue(testKey{}, "new")
+	if !loaded || actual != s.Value(testKey{}) {
+		t.Errorf("expected existing value to be loaded, got %v, %t", actual, loaded)
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package sm
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/stream"
+)
+
+// Server implements the receiving entity side of stream management.
+type Server struct {
+	// Store is used to persist the state of suspended streams.
+	// If Store is nil clients may still enable stream management to acknowledge
+	// stanzas, but resumption is not offered.
+	Store Store
+
+	// Max is the longest time after which a suspended stream can be resumed.
+	// It is advertised to clients and used to set the expiration time of the
+	// state passed to the store.
+	// If Max is zero, no maximum is advertised and the state does not expire.
+	Max time.Duration
+
+	// Authorize is called before resuming a suspended stream to check that the
+	// session resuming it belongs to the same entity that the stream belonged to,
+	// normally by comparing the identity that the session authenticated as with
+	// the address stored in the state.
+	// The remote address of the session alone is not enough because it may be
+	// chosen by the client.
+	// If Authorize is nil resumption is not offered and requests to resume a
+	// stream are rejected.
+	Authorize func(s *xmpp.Session, st State) bool
+}
+
+// resumable reports whether streams can be suspended and resumed.
+func (srv *Server) resumable() bool {
+	return srv.Store != nil && srv.Authorize != nil
+}
+
+// Feature returns a stream feature that advertises support for stream
+// management and handles requests to resume suspended streams in place of
+// resource binding.
+// It is only advertised once the session is authenticated and may only be
+// used by receiving entities.
+func (srv *Server) Feature() xmpp.StreamFeature {
+	return xmpp.StreamFeature{
+		Name:       xml.Name{Space: NS, Local: "sm"},
+		Necessary:  xmpp.Authn,
+		Prohibited: xmpp.Ready,
+		List: func(ctx context.Context, e xmlstream.TokenWriter, start xml.StartElement) (bool, error) {
+			_, err := xmlstream.Copy(e, xmlstream.Wrap(nil, start))
+			return false, err
+		},
+		Parse: func(ctx context.Context, d *xml.Decoder, start *xml.StartElement) (bool, interface{}, error) {
+			return false, nil, d.Skip()
+		},
+		Negotiate: func(ctx context.Context, session *xmpp.Session, _ interface{}) (xmpp.SessionState, io.ReadWriter, error) {
+			if session.State()&xmpp.Received != xmpp.Received {
+				return 0, nil, errors.New("sm: resuming streams is only supported by receiving entities")
+			}
+			return srv.resume(ctx, session)
+		},
+	}
+}
+
+func (srv *Server) resume(ctx context.Context, s *xmpp.Session) (xmpp.SessionState, io.ReadWriter, error) {
+	r := s.TokenReader()
+	/* #nosec */
+	defer r.Close()
+	w := s.TokenWriter()
+	/* #nosec */
+	defer w.Close()
+
+	d := xml.NewTokenDecoder(r)
+	tok, err := d.Token()
+	if err != nil {
+		return 0, nil, err
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok {
+		return 0, nil, stream.BadFormat
+	}
+	req := struct {
+		H      uint32 `xml:"h,attr"`
+		PrevID string `xml:"previd,attr"`
+	}{}
+	err = d.DecodeElement(&req, &start)
+	if err != nil {
+		return 0, nil, err
+	}
+	if start.Name.Local != "resume" || !srv.resumable() {
+		return 0, nil, writeFailed(w, stanza.UnexpectedRequest)
+	}
+
+	st, err := srv.Store.Load(ctx, req.PrevID)
+	switch {
+	case errors.Is(err, ErrUnknownSession):
+		return 0, nil, writeFailed(w, stanza.ItemNotFound)
+	case err != nil:
+		return 0, nil, err
+	case !st.Expires.IsZero() && time.Now().After(st.Expires):
+		err = srv.Store.Delete(ctx, req.PrevID)
+		if err != nil {
+			return 0, nil, err
+		}
+		return 0, nil, writeFailed(w, stanza.ItemNotFound)
+	case !srv.Authorize(s, st):
+		return 0, nil, writeFailed(w, stanza.ItemNotFound)
+	}
+	// The count wraps around at 2^32, so a value of h lower than the number of
+	// stanzas already acknowledged results in a count that is too high.
+	acked := req.H - st.Acked
+	if int64(acked) > int64(len(st.Queue)) {
+		// The client acknowledged stanzas that were never sent.
+		return 0, nil, writeFailed(w, stanza.BadRequest)
+	}
+	err = srv.Store.Delete(ctx, req.PrevID)
+	if err != nil {
+		return 0, nil, err
+	}
+	// Resuming the stream continues the session with the address that was bound
+	// to the old stream.
+	if !st.Addr.Equal(jid.JID{}) {
+		s.UpdateRemoteAddr(st.Addr)
+	}
+
+	queue := st.Queue[acked:]
+	_, err = xmlstream.Copy(w, xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "resumed"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "h"}, Value: strconv.FormatUint(uint64(st.Handled), 10)},
+			{Name: xml.Name{Local: "previd"}, Value: req.PrevID},
+		},
+	}))
+	if err != nil {
+		return 0, nil, err
+	}
+	// Stanzas that were not acknowledged are sent again.
+	// They stay in the queue until the client acknowledges them.
+	for _, b := range queue {
+		_, err = xmlstream.Copy(w, rawReader{d: xml.NewDecoder(bytes.NewReader(b))})
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Only enable stream management once the unacknowledged stanzas have been
+	// replayed so that they are not added to the queue a second time.
+	strm := getStream(s)
+	strm.mu.Lock()
+	defer strm.mu.Unlock()
+	strm.enabled = true
+	strm.id = req.PrevID
+	strm.handled = st.Handled
+	strm.base = s.StanzasHandled()
+	strm.acked = req.H
+	strm.queue = queue
+	strm.resumed = &st
+	return xmpp.Ready, nil, nil
+}
+
+// Resumed returns the state that the stream was resumed from.
+// If the stream was not resumed, ok is false.
+func Resumed(s *xmpp.Session) (st State, ok bool) {
+	v := s.Value(streamKey{})
+	if v == nil {
+		return st, false
+	}
+	strm := v.(*managed)
+	strm.mu.Lock()
+	defer strm.mu.Unlock()
+	if strm.resumed == nil {
+		return st, false
+	}
+	return *strm.resumed, true
+}
+
+// Handler returns a handler that handles stream management requests on s and
+// passes all other elements to h.
+// It should be passed to the sessions Serve method.
+func (srv *Server) Handler(s *xmpp.Session, h xmpp.Handler) xmpp.Handler {
+	return handler{srv: srv, s: s, st: getStream(s), h: h}
+}
+
+// Suspend saves the state of the stream to the store if the client has enabled
+// resumption.
+// It should be called once the session is no longer being served and returns
+// the ID of the stream, or an empty string if the stream cannot be resumed.
+func (srv *Server) Suspend(ctx context.Context, s *xmpp.Session) (string, error) {
+	if !srv.resumable() {
+		return "", nil
+	}
+	v := s.Value(streamKey{})
+	if v == nil {
+		return "", nil
+	}
+	strm := v.(*managed)
+	strm.mu.Lock()
+	defer strm.mu.Unlock()
+	if strm.id == "" {
+		return "", nil
+	}
+	st := State{
+		Addr:    s.RemoteAddr(),
+		Handled: strm.h(s),
+		Acked:   strm.acked,
+		Queue:   strm.queue,
+	}
+	if srv.Max > 0 {
+		st.Expires = time.Now().Add(srv.Max)
+	}
+	return strm.id, srv.Store.Save(ctx, strm.id, st)
+}
+
+type handler struct {
+	srv *Server
+	s   *xmpp.Session
+	st  *managed
+	h   xmpp.Handler
+}
+
+func (h handler) HandleXMPP(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	if start.Name.Space != NS {
+		if h.h == nil {
+			return nil
+		}
+		return h.h.HandleXMPP(t, start)
+	}
+
+	switch start.Name.Local {
+	case "enable":
+		return h.enable(t, start)
+	case "r":
+		h.st.mu.Lock()
+		if !h.st.enabled {
+			h.st.mu.Unlock()
+			return nil
+		}
+		count := h.st.h(h.s)
+		h.st.mu.Unlock()
+		_, err := xmlstream.Copy(t, ack(count))
+		return err
+	case "a":
+		_, v := attr.Get(start.Attr, "h")
+		count, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return stream.BadFormat
+		}
+		h.st.mu.Lock()
+		defer h.st.mu.Unlock()
+		if !h.st.enabled {
+			return nil
+		}
+		acked := uint32(count) - h.st.acked
+		if int64(acked) > int64(len(h.st.queue)) {
+			return handledCountTooHigh(uint32(count), h.st.acked+uint32(len(h.st.queue)))
+		}
+		h.st.acked = uint32(count)
+		h.st.queue = h.st.queue[acked:]
+	}
+	return nil
+}
+
+func (h handler) enable(t xmlstream.Encoder, start *xml.StartElement) error {
+	h.st.mu.Lock()
+	defer h.st.mu.Unlock()
+	if h.st.enabled {
+		return writeFailed(t, stanza.UnexpectedRequest)
+	}
+	h.st.enabled = true
+	h.st.base = h.s.StanzasHandled()
+
+	var attrs []xml.Attr
+	_, resume := attr.Get(start.Attr, "resume")
+	if (resume == "true" || resume == "1") && h.srv.resumable() {
+		h.st.id = attr.RandomID()
+		attrs = append(attrs,
+			xml.Attr{Name: xml.Name{Local: "id"}, Value: h.st.id},
+			xml.Attr{Name: xml.Name{Local: "resume"}, Value: "true"},
+		)
+		if h.srv.Max > 0 {
+			attrs = append(attrs, xml.Attr{
+				Name:  xml.Name{Local: "max"},
+				Value: strconv.FormatInt(int64(h.srv.Max/time.Second), 10),
+			})
+		}
+	}
+	_, err := xmlstream.Copy(t, xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "enabled"},
+		Attr: attrs,
+	}))
+	return err
+}
+
+func ack(h uint32) xml.TokenReader {
+	return xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "a"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "h"}, Value: strconv.FormatUint(uint64(h), 10)}},
+	})
+}
+
+func writeFailed(w xmlstream.TokenWriter, cond stanza.Condition) error {
+	_, err := xmlstream.Copy(w, xmlstream.Wrap(
+		xmlstream.Wrap(nil, xml.StartElement{Name: xml.Name{Space: stanza.NSError, Local: string(cond)}}),
+		xml.StartElement{Name: xml.Name{Space: NS, Local: "failed"}},
+	))
+	if err != nil {
+		return err
+	}
+	if f, ok := w.(xmlstream.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func handledCountTooHigh(h, sent uint32) error {
+	return stream.UndefinedCondition.ApplicationError(xmlstream.Wrap(nil, xml.StartElement{
+		Name: xml.Name{Space: NS, Local: "handled-count-too-high"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "h"}, Value: strconv.FormatUint(uint64(h), 10)},
+			{Name: xml.Name{Local: "send-count"}, Value: strconv.FormatUint(uint64(sent), 10)},
+		},
+	}))
+}
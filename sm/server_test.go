@@ -0,0 +1,282 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package sm_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/sm"
+	"mellium.im/xmpp/stanza"
+)
+
+const queuedMsg = `<message xmlns="jabber:client" to="test@example.net" type="chat" id="1"><body>Wherefore art thou?</body></message>`
+
+func alwaysAuthorize(*xmpp.Session, sm.State) bool { return true }
+
+// authenticatedAs returns an authorization function for sessions that
+// authenticated as j.
+func authenticatedAs(j jid.JID) func(*xmpp.Session, sm.State) bool {
+	return func(_ *xmpp.Session, st sm.State) bool {
+		return st.Addr.Bare().Equal(j)
+	}
+}
+
+func TestResume(t *testing.T) {
+	store := &sm.MemoryStore{}
+	err := store.Save(context.Background(), "abc", sm.State{
+		Addr:    jid.MustParse("juliet@example.net/balcony"),
+		Handled: 5,
+		Acked:   1,
+		Queue: [][]byte{
+			[]byte(`<message xmlns="jabber:client" type="chat"><body>Acked</body></message>`),
+			[]byte(queuedMsg),
+		},
+	})
+	if err != nil {
+		t.Fatalf("error saving state: %v", err)
+	}
+	err = store.Save(context.Background(), "old", sm.State{
+		Expires: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("error saving state: %v", err)
+	}
+	srv := &sm.Server{Store: store, Authorize: authenticatedAs(jid.MustParse("juliet@example.net"))}
+	mismatched := &sm.Server{Store: store, Authorize: authenticatedAs(jid.MustParse("mallory@example.net"))}
+
+	xmpptest.RunFeatureTests(t, []xmpptest.FeatureTestCase{
+		0: {
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: mismatched.Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='2' previd='abc'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><item-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></item-not-found></failed>`,
+		},
+		1: {
+			// Resumption is never allowed without a way to authorize it.
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: (&sm.Server{Store: store}).Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='2' previd='abc'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><unexpected-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></unexpected-request></failed>`,
+		},
+		2: {
+			// h is lower than the number of stanzas that were already acknowledged.
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: srv.Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='0' previd='abc'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><bad-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></bad-request></failed>`,
+		},
+		3: {
+			// h is higher than the number of stanzas that were sent.
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: srv.Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='4' previd='abc'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><bad-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></bad-request></failed>`,
+		},
+		4: {
+			State:      xmpp.Received | xmpp.Authn,
+			Feature:    srv.Feature(),
+			In:         `<resume xmlns='urn:xmpp:sm:3' h='2' previd='abc'/>`,
+			Out:        `<resumed xmlns="urn:xmpp:sm:3" h="5" previd="abc"></resumed>` + queuedMsg,
+			FinalState: xmpp.Ready,
+		},
+		5: {
+			// The state is removed from the store once it has been resumed.
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: srv.Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='2' previd='abc'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><item-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></item-not-found></failed>`,
+		},
+		6: {
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: srv.Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='0' previd='old'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><item-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></item-not-found></failed>`,
+		},
+		7: {
+			State:   xmpp.Received | xmpp.Authn,
+			Feature: (&sm.Server{}).Feature(),
+			In:      `<resume xmlns='urn:xmpp:sm:3' h='0' previd='abc'/>`,
+			Out:     `<failed xmlns="urn:xmpp:sm:3"><unexpected-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></unexpected-request></failed>`,
+		},
+	})
+	if _, err := store.Load(context.Background(), "old"); !errors.Is(err, sm.ErrUnknownSession) {
+		t.Errorf("expected expired state to be deleted, got: %v", err)
+	}
+}
+
+func TestResumeState(t *testing.T) {
+	ctx := context.Background()
+	addr := jid.MustParse("juliet@example.net/balcony")
+	store := &sm.MemoryStore{}
+	// The count of acknowledged stanzas wraps around.
+	err := store.Save(ctx, "abc", sm.State{
+		Addr:    addr,
+		Handled: 5,
+		Acked:   1<<32 - 1,
+		Queue: [][]byte{
+			[]byte(`<message xmlns="jabber:client" type="chat"><body>Acked</body></message>`),
+			[]byte(queuedMsg),
+		},
+	})
+	if err != nil {
+		t.Fatalf("error saving state: %v", err)
+	}
+	srv := &sm.Server{Store: store, Authorize: alwaysAuthorize}
+
+	var out strings.Builder
+	negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+		return xmpp.StreamConfig{
+			Features: []xmpp.StreamFeature{srv.Feature()},
+		}
+	})
+	s, err := xmpp.NewSession(ctx, jid.MustParse("example.net"), jid.MustParse("juliet@example.net"), struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: strings.NewReader(`<stream:stream from='juliet@example.net' to='example.net' version='1.0' xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams'><resume xmlns='urn:xmpp:sm:3' h='0' previd='abc'/>`),
+		Writer: &out,
+	}, xmpp.Received|xmpp.Authn, negotiator)
+	if err != nil {
+		t.Fatalf("error resuming stream: %v", err)
+	}
+	if want := `<resumed xmlns="urn:xmpp:sm:3" h="5" previd="abc"></resumed>` + queuedMsg; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("wrong output:\nwant suffix=%s,\n got=%s", want, out.String())
+	}
+	st, ok := sm.Resumed(s)
+	if !ok || st.Handled != 5 || !st.Addr.Equal(addr) {
+		t.Errorf("wrong resumed state: ok=%t, state=%+v", ok, st)
+	}
+	if !s.RemoteAddr().Equal(addr) {
+		t.Errorf("address was not restored: want=%v, got=%v", addr, s.RemoteAddr())
+	}
+
+	// Suspending the resumed stream again keeps the restored address.
+	id, err := srv.Suspend(ctx, s)
+	if err != nil {
+		t.Fatalf("error suspending stream: %v", err)
+	}
+	st, err = store.Load(ctx, id)
+	if err != nil {
+		t.Fatalf("error loading state: %v", err)
+	}
+	if !st.Addr.Equal(addr) || st.Acked != 0 || len(st.Queue) != 1 {
+		t.Errorf("wrong suspended state: %+v", st)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := &sm.MemoryStore{}
+	srv := &sm.Server{Store: store, Max: time.Minute, Authorize: alwaysAuthorize}
+
+	recv := make(chan string, 10)
+	var serverHandler xmpp.Handler
+	cs := xmpptest.NewClientServer(
+		xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			return serverHandler.HandleXMPP(t, start)
+		}),
+		xmpptest.ClientHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+			var b strings.Builder
+			e := xml.NewEncoder(&b)
+			_, err := xmlstream.Copy(e, xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+				return attr.Name.Local == "xmlns"
+			})(xmlstream.MultiReader(xmlstream.Token(*start), t)))
+			if err != nil {
+				return err
+			}
+			err = e.Flush()
+			if err != nil {
+				return err
+			}
+			recv <- b.String()
+			return nil
+		}),
+	)
+	serverHandler = srv.Handler(cs.Server, xmpp.HandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		return nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sendRaw := func(s string) {
+		t.Helper()
+		err := cs.Client.Send(ctx, xml.NewDecoder(strings.NewReader(s)))
+		if err != nil {
+			t.Fatalf("error sending %s: %v", s, err)
+		}
+	}
+	expect := func(prefix string) string {
+		t.Helper()
+		select {
+		case got := <-recv:
+			if !strings.HasPrefix(got, prefix) {
+				t.Fatalf("wrong element: want prefix %q, got %q", prefix, got)
+			}
+			return got
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %q", prefix)
+		}
+		return ""
+	}
+
+	// Stanzas sent before stream management is enabled are not queued.
+	err := cs.Server.Send(ctx, stanza.Message{Type: stanza.ChatMessage}.Wrap(nil))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	expect(`<message`)
+
+	sendRaw(`<enable xmlns='urn:xmpp:sm:3' resume='true'/>`)
+	enabled := expect(`<enabled xmlns="urn:xmpp:sm:3" id="`)
+	if !strings.Contains(enabled, `resume="true" max="60"`) {
+		t.Errorf("resumption not enabled: %s", enabled)
+	}
+
+	to := jid.MustParse("test@example.net")
+	for i := 0; i < 2; i++ {
+		err = cs.Server.Send(ctx, stanza.Message{To: to, Type: stanza.ChatMessage}.Wrap(nil))
+		if err != nil {
+			t.Fatalf("error sending message: %v", err)
+		}
+		expect(`<message`)
+	}
+	// The test server expects stanzas in the server namespace.
+	sendRaw(`<message xmlns='jabber:server' type='chat'/>`)
+	sendRaw(`<r xmlns='urn:xmpp:sm:3'/>`)
+	expect(`<a xmlns="urn:xmpp:sm:3" h="1">`)
+	sendRaw(`<a xmlns='urn:xmpp:sm:3' h='1'/>`)
+	sendRaw(`<r xmlns='urn:xmpp:sm:3'/>`)
+	expect(`<a xmlns="urn:xmpp:sm:3" h="1">`)
+
+	id, err := srv.Suspend(ctx, cs.Server)
+	if err != nil {
+		t.Fatalf("error suspending stream: %v", err)
+	}
+	st, err := store.Load(ctx, id)
+	if err != nil {
+		t.Fatalf("error loading state: %v", err)
+	}
+	if st.Handled != 1 || st.Acked != 1 || len(st.Queue) != 1 {
+		t.Errorf("wrong state: handled=%d, acked=%d, queued=%d", st.Handled, st.Acked, len(st.Queue))
+	}
+	if !st.Addr.Equal(cs.Server.RemoteAddr()) {
+		t.Errorf("wrong address: want=%v, got=%v", cs.Server.RemoteAddr(), st.Addr)
+	}
+	if d := time.Until(st.Expires); d <= 0 || d > time.Minute {
+		t.Errorf("wrong expiration: %v", st.Expires)
+	}
+	if want := `<message xmlns="jabber:server" type="chat" to="test@example.net"`; !strings.HasPrefix(string(st.Queue[0]), want) {
+		t.Errorf("wrong queued stanza: want prefix %q, got %q", want, st.Queue[0])
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package sm implements XEP-0198: Stream Management for servers.
+//
+// Stream management lets the entities on either end of a stream acknowledge
+// the stanzas they have handled so that stanzas lost when a connection drops
+// can be detected and sent again.
+// If the client asks for it, the server can also keep the state of the stream
+// around after the connection is lost so that the client can resume the
+// session on a new connection, which is useful for mobile clients with
+// unreliable connectivity.
+//
+// This package currently only implements the receiving entity (server) side
+// of stream management.
+// A Server advertises stream management during negotiation with the stream
+// feature returned by its Feature method, handles requests from the client by
+// wrapping the handler passed to the sessions Serve method with its Handler
+// method, and saves the state of the stream to a Store with its Suspend method
+// once the connection has been lost so that it can be resumed later.
+package sm // import "mellium.im/xmpp/sm"
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"mellium.im/xmpp/jid"
+)
+
+// NS is the namespace used by this package, provided as a convenience.
+const NS = "urn:xmpp:sm:3"
+
+// ErrUnknownSession is returned by a Store when no state is stored for a
+// session.
+var ErrUnknownSession = errors.New("sm: no such session")
+
+// State is the state of a suspended stream that must be kept for the stream to
+// be resumed.
+type State struct {
+	// Addr is the remote address of the session that was suspended.
+	Addr jid.JID
+
+	// Handled is the number of stanzas from the client that were handled before
+	// the stream was suspended.
+	Handled uint32
+
+	// Acked is the number of stanzas sent to the client that it has acknowledged.
+	Acked uint32
+
+	// Queue contains every stanza that was sent to the client but not yet
+	// acknowledged, serialized as XML, in the order they were sent.
+	Queue [][]byte
+
+	// If Expires is not the zero time, the stream can no longer be resumed after
+	// it has passed.
+	Expires time.Time
+}
+
+// Store persists the state of suspended streams until they are resumed.
+//
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Store interface {
+	// Save stores the state of the suspended stream with the given ID.
+	Save(ctx context.Context, id string, st State) error
+
+	// Load returns the state of the stream with the given ID or an error
+	// wrapping ErrUnknownSession if no state is stored.
+	Load(ctx context.Context, id string) (State, error)
+
+	// Delete removes the state of the stream with the given ID.
+	// Deleting a stream that does not exist is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store that keeps suspended streams in memory.
+// The zero value is an empty store ready to use.
+type MemoryStore struct {
+	mu sync.Mutex
+	m  map[string]State
+}
+
+// Save implements Store.
+func (m *MemoryStore) Save(_ context.Context, id string, st State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[string]State)
+	}
+	m.m[id] = st
+	return nil
+}
+
+// Load implements Store.
+func (m *MemoryStore) Load(_ context.Context, id string) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.m[id]
+	if !ok {
+		return st, ErrUnknownSession
+	}
+	return st, nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, id)
+	return nil
+}
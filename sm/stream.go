@@ -0,0 +1,113 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package sm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sync"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/tokens"
+)
+
+type streamKey struct{}
+
+// managed is the stream management state of a single session.
+type managed struct {
+	filterOnce sync.Once
+
+	mu      sync.Mutex
+	enabled bool
+	id      string
+	// The number of stanzas handled before counting started (eg. on a previous
+	// connection) and the sessions handled count when counting started.
+	handled uint32
+	base    uint64
+	// The number of stanzas acknowledged by the client and the stanzas sent since
+	// then.
+	acked uint32
+	queue [][]byte
+	// The state that the stream was resumed from, if any.
+	resumed *State
+}
+
+func getStream(s *xmpp.Session) *managed {
+	v, _ := s.LoadOrStoreValue(streamKey{}, &managed{})
+	st := v.(*managed)
+	st.filterOnce.Do(func() {
+		s.AddOutgoingFilter(st.filter)
+	})
+	return st
+}
+
+// h returns the number of stanzas handled.
+// It must be called with the lock held.
+func (st *managed) h(s *xmpp.Session) uint32 {
+	return st.handled + uint32(s.StanzasHandled()-st.base)
+}
+
+// filter copies every outgoing stanza into the queue once stream management
+// has been enabled.
+func (st *managed) filter(r xml.TokenReader) xml.TokenReader {
+	st.mu.Lock()
+	enabled := st.enabled
+	st.mu.Unlock()
+	if !enabled {
+		return r
+	}
+
+	toks, err := xmlstream.ReadAll(r)
+	if err != nil {
+		return errReader(err)
+	}
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+	for _, tok := range toks {
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Space != "" {
+			// The encoder adds the xmlns attribute itself, so remove any existing
+			// one to avoid duplicating it.
+			attrs := make([]xml.Attr, 0, len(start.Attr))
+			for _, attr := range start.Attr {
+				if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					continue
+				}
+				attrs = append(attrs, attr)
+			}
+			start.Attr = attrs
+			tok = start
+		}
+		err = e.EncodeToken(tok)
+		if err != nil {
+			return errReader(err)
+		}
+	}
+	err = e.Flush()
+	if err != nil {
+		return errReader(err)
+	}
+
+	st.mu.Lock()
+	st.queue = append(st.queue, buf.Bytes())
+	st.mu.Unlock()
+	return tokens.Reader(toks)
+}
+
+func errReader(err error) xml.TokenReader {
+	return xmlstream.ReaderFunc(func() (xml.Token, error) {
+		return nil, err
+	})
+}
+
+// rawReader returns the raw tokens from a decoder so that stored stanzas can be
+// replayed with their original namespace declarations.
+type rawReader struct {
+	d *xml.Decoder
+}
+
+func (r rawReader) Token() (xml.Token, error) {
+	return r.d.RawToken()
+}
@@ -0,0 +1,330 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package stanza
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/ns"
+	"mellium.im/xmpp/jid"
+)
+
+// Errors returned when building stanzas.
+var (
+	ErrInvalidType = errors.New("stanza: invalid stanza type")
+	ErrInvalidText = errors.New("stanza: text contains characters that are not allowed in XML")
+	ErrInvalidShow = errors.New("stanza: invalid presence show value")
+)
+
+// nsOOB is the namespace used by XEP-0066: Out of Band Data.
+const nsOOB = "jabber:x:oob"
+
+// validText reports whether s is valid UTF-8 consisting only of characters
+// that are allowed in XML 1.0.
+func validText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r == 0x09 || r == 0x0A || r == 0x0D:
+		case r >= 0x20 && r <= 0xD7FF:
+		case r >= 0xE000 && r <= 0xFFFD:
+		case r >= 0x10000 && r <= 0x10FFFF:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// textElement returns an element with the provided name and lang containing
+// text.
+func textElement(local, lang, text string) xml.TokenReader {
+	start := xml.StartElement{Name: xml.Name{Local: local}}
+	if lang != "" {
+		start.Attr = []xml.Attr{{Name: xml.Name{Space: ns.XML, Local: "lang"}, Value: lang}}
+	}
+	return xmlstream.Wrap(xmlstream.Token(xml.CharData(text)), start)
+}
+
+// builder contains the payloads and first error shared by the stanza builders.
+type builder struct {
+	payloads []xml.TokenReader
+	err      error
+}
+
+func (b *builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *builder) text(local, lang, text string) {
+	if !validText(text) {
+		b.setErr(fmt.Errorf("%w in %s", ErrInvalidText, local))
+		return
+	}
+	b.payloads = append(b.payloads, textElement(local, lang, text))
+}
+
+// MessageBuilder assembles a message stanza along with common payloads.
+// Each method returns the builder so that calls can be chained and the first
+// error encountered is returned when Build is called.
+//
+// The zero value is not ready for use, use NewMessageBuilder instead.
+type MessageBuilder struct {
+	msg Message
+	builder
+}
+
+// NewMessageBuilder returns a builder for a message of type NormalMessage.
+func NewMessageBuilder() *MessageBuilder {
+	return &MessageBuilder{
+		msg: Message{
+			XMLName: xml.Name{Local: "message"},
+			Type:    NormalMessage,
+		},
+	}
+}
+
+// Namespace sets the namespace of the stanza, for example NSServer.
+// Normally the namespace is left empty and is set by the session when the
+// stanza is sent.
+func (b *MessageBuilder) Namespace(space string) *MessageBuilder {
+	b.msg.XMLName.Space = space
+	return b
+}
+
+// ID sets the ID of the message.
+func (b *MessageBuilder) ID(id string) *MessageBuilder {
+	b.msg.ID = id
+	return b
+}
+
+// To sets the recipient of the message.
+func (b *MessageBuilder) To(to jid.JID) *MessageBuilder {
+	b.msg.To = to
+	return b
+}
+
+// From sets the sender of the message.
+func (b *MessageBuilder) From(from jid.JID) *MessageBuilder {
+	b.msg.From = from
+	return b
+}
+
+// Lang sets the default language of the message.
+func (b *MessageBuilder) Lang(lang string) *MessageBuilder {
+	b.msg.Lang = lang
+	return b
+}
+
+// Type sets the type of the message.
+// If typ is not one of the message types defined in this package, Build
+// returns ErrInvalidType.
+func (b *MessageBuilder) Type(typ MessageType) *MessageBuilder {
+	switch typ {
+	case NormalMessage, ChatMessage, ErrorMessage, GroupChatMessage, HeadlineMessage:
+		b.msg.Type = typ
+	default:
+		b.setErr(fmt.Errorf("%w %q for message", ErrInvalidType, typ))
+	}
+	return b
+}
+
+// Body adds a body to the message.
+// It may be called more than once with different languages set using
+// BodyLang.
+func (b *MessageBuilder) Body(body string) *MessageBuilder {
+	return b.BodyLang("", body)
+}
+
+// BodyLang adds a body with the provided language to the message.
+func (b *MessageBuilder) BodyLang(lang, body string) *MessageBuilder {
+	b.text("body", lang, body)
+	return b
+}
+
+// Subject adds a subject to the message.
+func (b *MessageBuilder) Subject(subject string) *MessageBuilder {
+	b.text("subject", "", subject)
+	return b
+}
+
+// Thread adds a thread ID to the message.
+// If parent is not empty it is added as the ID of the parent thread.
+func (b *MessageBuilder) Thread(id, parent string) *MessageBuilder {
+	if !validText(id) || !validText(parent) {
+		b.setErr(fmt.Errorf("%w in thread", ErrInvalidText))
+		return b
+	}
+	start := xml.StartElement{Name: xml.Name{Local: "thread"}}
+	if parent != "" {
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: "parent"}, Value: parent}}
+	}
+	b.payloads = append(b.payloads, xmlstream.Wrap(xmlstream.Token(xml.CharData(id)), start))
+	return b
+}
+
+// OOB adds a link to out of band data as defined by XEP-0066: Out of Band Data.
+// If desc is not empty it is added as a description of the data.
+func (b *MessageBuilder) OOB(url, desc string) *MessageBuilder {
+	if !validText(url) || !validText(desc) {
+		b.setErr(fmt.Errorf("%w in out of band data", ErrInvalidText))
+		return b
+	}
+	inner := []xml.TokenReader{textElement("url", "", url)}
+	if desc != "" {
+		inner = append(inner, textElement("desc", "", desc))
+	}
+	b.payloads = append(b.payloads, xmlstream.Wrap(
+		xmlstream.MultiReader(inner...),
+		xml.StartElement{Name: xml.Name{Space: nsOOB, Local: "x"}},
+	))
+	return b
+}
+
+// OriginID adds an origin ID to the message.
+func (b *MessageBuilder) OriginID(id string) *MessageBuilder {
+	b.payloads = append(b.payloads, OriginID{ID: id}.TokenReader())
+	return b
+}
+
+// Payload adds an arbitrary payload to the message.
+func (b *MessageBuilder) Payload(r xml.TokenReader) *MessageBuilder {
+	b.payloads = append(b.payloads, r)
+	return b
+}
+
+// Build returns the message or the first error encountered while building it.
+// The returned token reader may only be read once.
+func (b *MessageBuilder) Build() (xml.TokenReader, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.msg.Wrap(xmlstream.MultiReader(b.payloads...)), nil
+}
+
+// PresenceBuilder assembles a presence stanza along with common payloads.
+// Each method returns the builder so that calls can be chained and the first
+// error encountered is returned when Build is called.
+//
+// The zero value is not ready for use, use NewPresenceBuilder instead.
+type PresenceBuilder struct {
+	p Presence
+	builder
+}
+
+// NewPresenceBuilder returns a builder for an available presence.
+func NewPresenceBuilder() *PresenceBuilder {
+	return &PresenceBuilder{
+		p: Presence{
+			XMLName: xml.Name{Local: "presence"},
+			Type:    AvailablePresence,
+		},
+	}
+}
+
+// Namespace sets the namespace of the stanza, for example NSServer.
+// Normally the namespace is left empty and is set by the session when the
+// stanza is sent.
+func (b *PresenceBuilder) Namespace(space string) *PresenceBuilder {
+	b.p.XMLName.Space = space
+	return b
+}
+
+// ID sets the ID of the presence.
+func (b *PresenceBuilder) ID(id string) *PresenceBuilder {
+	b.p.ID = id
+	return b
+}
+
+// To sets the recipient of the presence.
+func (b *PresenceBuilder) To(to jid.JID) *PresenceBuilder {
+	b.p.To = to
+	return b
+}
+
+// From sets the sender of the presence.
+func (b *PresenceBuilder) From(from jid.JID) *PresenceBuilder {
+	b.p.From = from
+	return b
+}
+
+// Lang sets the default language of the presence.
+func (b *PresenceBuilder) Lang(lang string) *PresenceBuilder {
+	b.p.Lang = lang
+	return b
+}
+
+// Type sets the type of the presence.
+// If typ is not one of the presence types defined in this package, Build
+// returns ErrInvalidType.
+func (b *PresenceBuilder) Type(typ PresenceType) *PresenceBuilder {
+	switch typ {
+	case AvailablePresence, ErrorPresence, ProbePresence, SubscribePresence,
+		SubscribedPresence, UnavailablePresence, UnsubscribePresence,
+		UnsubscribedPresence:
+		b.p.Type = typ
+	default:
+		b.setErr(fmt.Errorf("%w %q for presence", ErrInvalidType, typ))
+	}
+	return b
+}
+
+// Show adds a show element to the presence.
+// If show is not one of "away", "chat", "dnd", or "xa", Build returns
+// ErrInvalidShow.
+func (b *PresenceBuilder) Show(show string) *PresenceBuilder {
+	switch show {
+	case "away", "chat", "dnd", "xa":
+		b.payloads = append(b.payloads, textElement("show", "", show))
+	default:
+		b.setErr(fmt.Errorf("%w %q", ErrInvalidShow, show))
+	}
+	return b
+}
+
+// Status adds a status message to the presence.
+// It may be called more than once with different languages set using
+// StatusLang.
+func (b *PresenceBuilder) Status(status string) *PresenceBuilder {
+	return b.StatusLang("", status)
+}
+
+// StatusLang adds a status message with the provided language to the
+// presence.
+func (b *PresenceBuilder) StatusLang(lang, status string) *PresenceBuilder {
+	b.text("status", lang, status)
+	return b
+}
+
+// Priority adds a priority to the presence.
+func (b *PresenceBuilder) Priority(priority int8) *PresenceBuilder {
+	b.payloads = append(b.payloads, textElement("priority", "", strconv.Itoa(int(priority))))
+	return b
+}
+
+// Payload adds an arbitrary payload to the presence.
+func (b *PresenceBuilder) Payload(r xml.TokenReader) *PresenceBuilder {
+	b.payloads = append(b.payloads, r)
+	return b
+}
+
+// Build returns the presence or the first error encountered while building
+// it.
+// The returned token reader may only be read once.
+func (b *PresenceBuilder) Build() (xml.TokenReader, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.p.Wrap(xmlstream.MultiReader(b.payloads...)), nil
+}
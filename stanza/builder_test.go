@@ -0,0 +1,112 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package stanza_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+var builderTestCases = [...]struct {
+	build func() (xml.TokenReader, error)
+	out   string
+	err   error
+}{
+	0: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewMessageBuilder().Build()
+		},
+		out: `<message type="normal"></message>`,
+	},
+	1: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewMessageBuilder().
+				To(jid.MustParse("juliet@example.com")).
+				ID("123").
+				Type(stanza.ChatMessage).
+				Body("1 < 2 & 3 > 2").
+				BodyLang("de", "Hallo").
+				Subject("Test").
+				Thread("t1", "t0").
+				OOB("https://example.com/a.png", "A picture").
+				Build()
+		},
+		out: `<message type="chat" to="juliet@example.com" id="123"><body>1 &lt; 2 &amp; 3 &gt; 2</body><body xml:lang="de">Hallo</body><subject>Test</subject><thread parent="t0">t1</thread><x xmlns="jabber:x:oob"><url>https://example.com/a.png</url><desc>A picture</desc></x></message>`,
+	},
+	2: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewMessageBuilder().Type("bad").Body("test").Build()
+		},
+		err: stanza.ErrInvalidType,
+	},
+	3: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewMessageBuilder().Body("bad \x00 body").Build()
+		},
+		err: stanza.ErrInvalidText,
+	},
+	4: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewPresenceBuilder().
+				Show("away").
+				Status("Out to lunch").
+				Priority(-1).
+				Build()
+		},
+		out: `<presence><show>away</show><status>Out to lunch</status><priority>-1</priority></presence>`,
+	},
+	5: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewPresenceBuilder().Type(stanza.UnavailablePresence).Build()
+		},
+		out: `<presence type="unavailable"></presence>`,
+	},
+	6: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewPresenceBuilder().Show("busy").Build()
+		},
+		err: stanza.ErrInvalidShow,
+	},
+	7: {
+		build: func() (xml.TokenReader, error) {
+			return stanza.NewPresenceBuilder().Type("bad").Build()
+		},
+		err: stanza.ErrInvalidType,
+	},
+}
+
+func TestBuilder(t *testing.T) {
+	for i, tc := range builderTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r, err := tc.build()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: want=%v, got=%v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			var buf strings.Builder
+			e := xml.NewEncoder(&buf)
+			_, err = xmlstream.Copy(e, r)
+			if err != nil {
+				t.Fatalf("error encoding stanza: %v", err)
+			}
+			err = e.Flush()
+			if err != nil {
+				t.Fatalf("error flushing stanza: %v", err)
+			}
+			if out := buf.String(); out != tc.out {
+				t.Errorf("wrong output:\nwant=%s,\n got=%s", tc.out, out)
+			}
+		})
+	}
+}
@@ -16,6 +16,9 @@ import (
 // For example, when you joing a chat and request history, a delay might be
 // added to indicate that the chat messages were sent in the past and are not
 // live.
+//
+// To find the delay on a received stanza, including legacy delays sent by
+// older servers, see the delay package.
 type Delay struct {
 	From   jid.JID
 	Stamp  time.Time
@@ -24,13 +27,15 @@ type Delay struct {
 
 // TokenReader satisfies the xmlstream.Marshaler interface.
 func (d Delay) TokenReader() xml.TokenReader {
-	return xmlstream.Wrap(xmlstream.Token(xml.CharData(d.Reason)), xml.StartElement{
+	start := xml.StartElement{
 		Name: xml.Name{Space: NSDelay, Local: "delay"},
-		Attr: []xml.Attr{
-			{Name: xml.Name{Local: "from"}, Value: d.From.String()},
-			{Name: xml.Name{Local: "stamp"}, Value: d.Stamp.UTC().Format(time.RFC3339Nano)},
-		},
-	})
+	}
+	// The from attribute is optional and must be a valid JID if present.
+	if !d.From.Equal(jid.JID{}) {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "from"}, Value: d.From.String()})
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "stamp"}, Value: d.Stamp.UTC().Format(time.RFC3339Nano)})
+	return xmlstream.Wrap(xmlstream.Token(xml.CharData(d.Reason)), start)
 }
 
 // WriteXML satisfies the xmlstream.WriterTo interface.
@@ -16,7 +16,7 @@ import (
 var marshalDelayTestCases = []xmpptest.EncodingTestCase{
 	0: {
 		Value:       &stanza.Delay{},
-		XML:         `<delay xmlns="urn:xmpp:delay" from="" stamp="0001-01-01T00:00:00Z"></delay>`,
+		XML:         `<delay xmlns="urn:xmpp:delay" stamp="0001-01-01T00:00:00Z"></delay>`,
 		NoUnmarshal: true,
 	},
 	1: {
@@ -54,4 +54,21 @@
 //	        Type: stanza.GetIQ,
 //	    }.Wrap(start)
 //	}
+//
+// # Extensions
+//
+// Packages that implement payloads may register them using RegisterExtension.
+// Stanzas can then be decoded along with any registered payloads using
+// DecodeMessage, DecodePresence, and DecodeIQ without defining a struct for
+// every combination of payloads:
+//
+//	msg, exts, err := stanza.DecodeMessage(r)
+//	for _, ext := range exts {
+//	    switch e := ext.(type) {
+//	    case *stanza.OriginID:
+//	        …
+//	    case *oob.Data:
+//	        …
+//	    }
+//	}
 package stanza // import "mellium.im/xmpp/stanza"
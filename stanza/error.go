@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io"
 	"sort"
+	"strconv"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/internal/ns"
@@ -247,6 +248,51 @@ const (
 	UnexpectedRequest Condition = "unexpected-request"
 )
 
+// AppCondition is an application-specific condition element that provides
+// more detailed information about an error than the defined conditions.
+// Extensions such as Multi-User Chat and Publish-Subscribe define their own
+// application-specific conditions.
+type AppCondition struct {
+	XMLName xml.Name
+	Attr    []xml.Attr
+
+	// Inner contains any tokens inside of the element such as character data or
+	// child elements.
+	Inner []xml.Token
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface for AppCondition.
+func (c AppCondition) TokenReader() xml.TokenReader {
+	inner := c.Inner
+	return xmlstream.Wrap(
+		xmlstream.ReaderFunc(func() (xml.Token, error) {
+			if len(inner) == 0 {
+				return nil, io.EOF
+			}
+			tok := inner[0]
+			inner = inner[1:]
+			return tok, nil
+		}),
+		xml.StartElement{Name: c.XMLName, Attr: c.Attr},
+	)
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface for AppCondition.
+func (c *AppCondition) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	c.XMLName = start.Name
+	c.Attr = nil
+	for _, a := range start.Attr {
+		// The namespace is already part of the name, don't duplicate it.
+		if a.Name.Space == "" && a.Name.Local == "xmlns" {
+			continue
+		}
+		c.Attr = append(c.Attr, a)
+	}
+	var err error
+	c.Inner, err = xmlstream.ReadAll(xmlstream.Inner(d))
+	return err
+}
+
 // Error is an implementation of error intended to be marshalable and
 // unmarshalable as XML.
 //
@@ -255,12 +301,20 @@ const (
 // Normally there will just be one with an empty language (eg. "": "Some
 // error").
 // The keys are not validated to make sure they comply with BCP 47.
+//
+// If AppCondition has a name it is included in the error after the defined
+// condition and text, followed by any elements in Payload.
+// When unmarshaling, the first element that is not in the NSError namespace is
+// used as the application-specific condition and any other unknown elements are
+// kept in Payload so that they are not lost if the error is marshaled again.
 type Error struct {
-	XMLName   xml.Name
-	By        jid.JID
-	Type      ErrorType
-	Condition Condition
-	Text      map[string]string
+	XMLName      xml.Name
+	By           jid.JID
+	Type         ErrorType
+	Condition    Condition
+	Text         map[string]string
+	AppCondition AppCondition
+	Payload      []AppCondition
 }
 
 // Wrap wraps the payload in an error.
@@ -313,6 +367,15 @@ func (se Error) Wrap(payload xml.TokenReader) xml.TokenReader {
 		se.Condition = UndefinedCondition
 	}
 
+	var appCond xml.TokenReader
+	if se.AppCondition.XMLName.Local != "" {
+		appCond = se.AppCondition.TokenReader()
+	}
+	extra := make([]xml.TokenReader, 0, len(se.Payload))
+	for _, el := range se.Payload {
+		extra = append(extra, el.TokenReader())
+	}
+
 	return xmlstream.Wrap(
 		xmlstream.MultiReader(
 			xmlstream.Wrap(
@@ -322,6 +385,8 @@ func (se Error) Wrap(payload xml.TokenReader) xml.TokenReader {
 				},
 			),
 			xmlstream.MultiReader(text...),
+			appCond,
+			xmlstream.MultiReader(extra...),
 			payload,
 		),
 		start,
@@ -332,6 +397,8 @@ func (se Error) Wrap(payload xml.TokenReader) xml.TokenReader {
 // It compares the condition and type fields.
 // If either is empty it is treated as a wildcard.
 // If both are empty the comparison is true if target is also of type Error.
+// If the target has an application-specific condition, its name must also
+// match.
 //
 // For more information see the errors package.
 func (se Error) Is(target error) bool {
@@ -339,6 +406,9 @@ func (se Error) Is(target error) bool {
 	if !ok {
 		return false
 	}
+	if err.AppCondition.XMLName.Local != "" && err.AppCondition.XMLName != se.AppCondition.XMLName {
+		return false
+	}
 
 	switch {
 	case err.Type == "" && err.Condition == "":
@@ -392,12 +462,11 @@ func (se Error) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
 // UnmarshalXML satisfies the xml.Unmarshaler interface for StanzaError.
 func (se *Error) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	decoded := struct {
-		Condition []struct {
-			XMLName xml.Name
-		} `xml:",any"`
-		Type ErrorType `xml:"type,attr"`
-		By   jid.JID   `xml:"by,attr"`
-		Text []struct {
+		Condition []AppCondition `xml:",any"`
+		Type      ErrorType      `xml:"type,attr"`
+		By        jid.JID        `xml:"by,attr"`
+		Code      string         `xml:"code,attr"`
+		Text      []struct {
 			Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
 			Data string `xml:",chardata"`
 		} `xml:"urn:ietf:params:xml:ns:xmpp-stanzas text"`
@@ -405,12 +474,30 @@ func (se *Error) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	if err := d.DecodeElement(&decoded, &start); err != nil {
 		return err
 	}
-	se.Type = decoded.Type
-	se.By = decoded.By
+	*se = Error{
+		Type: decoded.Type,
+		By:   decoded.By,
+	}
 	for _, cond := range decoded.Condition {
-		if cond.XMLName.Space == NSError {
+		switch {
+		case cond.XMLName.Space == NSError && se.Condition == "":
 			se.Condition = Condition(cond.XMLName.Local)
-			break
+		case cond.XMLName.Space != NSError && se.AppCondition.XMLName.Local == "":
+			se.AppCondition = cond
+		default:
+			se.Payload = append(se.Payload, cond)
+		}
+	}
+	// Errors from old servers and components may only include a legacy error
+	// code.
+	if se.Condition == "" && decoded.Code != "" {
+		if code, err := strconv.Atoi(decoded.Code); err == nil {
+			if cond, typ, ok := LegacyCondition(code); ok {
+				se.Condition = cond
+				if se.Type == "" {
+					se.Type = typ
+				}
+			}
 		}
 	}
 
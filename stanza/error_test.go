@@ -93,6 +93,24 @@ var cmpTests = [...]struct {
 		err:    stanza.Error{Condition: stanza.Forbidden},
 		target: stanza.Error{Type: stanza.Continue, Condition: stanza.Forbidden},
 	},
+	13: {
+		err:    stanza.Error{Condition: stanza.Conflict, AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example", Local: "foo"}}},
+		target: stanza.Error{Condition: stanza.Conflict},
+		is:     true,
+	},
+	14: {
+		err:    stanza.Error{Condition: stanza.Conflict, AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example", Local: "foo"}}},
+		target: stanza.Error{AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example", Local: "foo"}}},
+		is:     true,
+	},
+	15: {
+		err:    stanza.Error{Condition: stanza.Conflict, AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example", Local: "foo"}}},
+		target: stanza.Error{Condition: stanza.Conflict, AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example", Local: "bar"}}},
+	},
+	16: {
+		err:    stanza.Error{Condition: stanza.Conflict},
+		target: stanza.Error{AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example", Local: "foo"}}},
+	},
 }
 
 func TestCmp(t *testing.T) {
@@ -174,10 +192,79 @@ var errorEncodingTests = []xmpptest.EncodingTestCase{
 		}},
 	},
 	12: {
-		Value:     &stanza.Error{Type: stanza.Continue, Condition: stanza.ServiceUnavailable, Text: simpleText},
+		Value: &stanza.Error{
+			Type:         stanza.Continue,
+			Condition:    stanza.ServiceUnavailable,
+			Text:         simpleText,
+			AppCondition: stanza.AppCondition{XMLName: xml.Name{Space: "urn:example:errors", Local: "foo"}},
+			Payload:      []stanza.AppCondition{{XMLName: xml.Name{Space: "urn:example:errors", Local: "bar"}}},
+		},
 		XML:       `<error type="continue"><foo xmlns="urn:example:errors"/><service-unavailable xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></service-unavailable><bar xmlns="urn:example:errors"/><text xmlns="urn:ietf:params:xml:ns:xmpp-stanzas">test</text></error>`,
 		NoMarshal: true,
 	},
+	13: {
+		Value:     &stanza.Error{Type: stanza.Cancel, Condition: stanza.ItemNotFound},
+		XML:       `<error code="404"/>`,
+		NoMarshal: true,
+	},
+	14: {
+		Value:     &stanza.Error{Type: stanza.Modify, Condition: stanza.ServiceUnavailable, Text: simpleText},
+		XML:       `<error code="503" type="modify"><text xmlns="urn:ietf:params:xml:ns:xmpp-stanzas">test</text></error>`,
+		NoMarshal: true,
+	},
+	15: {
+		Value:     &stanza.Error{Type: stanza.Auth, Condition: stanza.Forbidden},
+		XML:       `<error code="401" type="auth"><forbidden xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error>`,
+		NoMarshal: true,
+	},
+	16: {
+		Value:     &stanza.Error{},
+		XML:       `<error code="402"/>`,
+		NoMarshal: true,
+	},
+	17: {
+		Value:     &stanza.Error{},
+		XML:       `<error code="abc"/>`,
+		NoMarshal: true,
+	},
+	18: {
+		Value: &stanza.Error{
+			Type:      stanza.Cancel,
+			Condition: stanza.FeatureNotImplemented,
+			AppCondition: stanza.AppCondition{
+				XMLName: xml.Name{Space: "http://jabber.org/protocol/pubsub#errors", Local: "unsupported"},
+				Attr:    []xml.Attr{{Name: xml.Name{Local: "feature"}, Value: "publish"}},
+			},
+		},
+		XML: `<error type="cancel"><feature-not-implemented xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></feature-not-implemented><unsupported xmlns="http://jabber.org/protocol/pubsub#errors" feature="publish"></unsupported></error>`,
+	},
+	19: {
+		Value: &stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.BadRequest,
+			Text:      simpleText,
+			AppCondition: stanza.AppCondition{
+				XMLName: xml.Name{Space: "urn:example:errors", Local: "limit"},
+				Inner:   []xml.Token{xml.CharData("10")},
+			},
+		},
+		XML: `<error type="modify"><bad-request xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></bad-request><text xmlns="urn:ietf:params:xml:ns:xmpp-stanzas">test</text><limit xmlns="urn:example:errors">10</limit></error>`,
+	},
+	20: {
+		Value: &stanza.Error{
+			Type:      stanza.Modify,
+			Condition: stanza.NotAcceptable,
+			AppCondition: stanza.AppCondition{
+				XMLName: xml.Name{Space: "urn:example:errors", Local: "limit"},
+				Inner:   []xml.Token{xml.CharData("10")},
+			},
+			Payload: []stanza.AppCondition{{
+				XMLName: xml.Name{Space: "urn:xmpp:http:upload:0", Local: "retry"},
+				Attr:    []xml.Attr{{Name: xml.Name{Local: "stamp"}, Value: "2017-12-03T23:42:05Z"}},
+			}},
+		},
+		XML: `<error type="modify"><not-acceptable xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"></not-acceptable><limit xmlns="urn:example:errors">10</limit><retry xmlns="urn:xmpp:http:upload:0" stamp="2017-12-03T23:42:05Z"></retry></error>`,
+	},
 }
 
 func TestEncodeError(t *testing.T) {
@@ -203,3 +290,21 @@ func TestWrapError(t *testing.T) {
 		t.Errorf("wrong output: want=%v, got=%v", expected, out)
 	}
 }
+
+func TestUnmarshalErrorReuse(t *testing.T) {
+	var stanzaErr stanza.Error
+	err := xml.Unmarshal([]byte(`<error type="modify"><not-acceptable xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/><text xmlns="urn:ietf:params:xml:ns:xmpp-stanzas">test</text><limit xmlns="urn:example:errors">10</limit><retry xmlns="urn:xmpp:http:upload:0"/></error>`), &stanzaErr)
+	if err != nil {
+		t.Fatalf("error unmarshaling first error: %v", err)
+	}
+	err = xml.Unmarshal([]byte(`<error type="cancel"><item-not-found xmlns="urn:ietf:params:xml:ns:xmpp-stanzas"/></error>`), &stanzaErr)
+	if err != nil {
+		t.Fatalf("error unmarshaling second error: %v", err)
+	}
+	if stanzaErr.Condition != stanza.ItemNotFound || stanzaErr.Type != stanza.Cancel {
+		t.Errorf("wrong condition or type: want=%s/%s, got=%s/%s", stanza.ItemNotFound, stanza.Cancel, stanzaErr.Condition, stanzaErr.Type)
+	}
+	if stanzaErr.Text != nil || stanzaErr.AppCondition.XMLName.Local != "" || stanzaErr.Payload != nil {
+		t.Errorf("fields from the first error were kept: %+v", stanzaErr)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package stanza
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	extensionsMu sync.RWMutex
+	extensions   = make(map[xml.Name]func() interface{})
+)
+
+func init() {
+	RegisterExtension(xml.Name{Space: NSDelay, Local: "delay"}, func() interface{} { return &Delay{} })
+	RegisterExtension(xml.Name{Space: NSSid, Local: "stanza-id"}, func() interface{} { return &ID{} })
+	RegisterExtension(xml.Name{Space: NSSid, Local: "origin-id"}, func() interface{} { return &OriginID{} })
+}
+
+// RegisterExtension makes a stanza payload available to the decode functions
+// in this package.
+// When a child element with the provided name is found, f is called and the
+// element is unmarshaled into the value that it returns using encoding/xml, so
+// f should normally return a pointer to a new value.
+//
+// RegisterExtension is normally called from the init function of the package
+// implementing the payload.
+// If it is called twice with the same name or if f is nil, it panics.
+func RegisterExtension(name xml.Name, f func() interface{}) {
+	if f == nil {
+		panic("stanza: RegisterExtension called with nil factory")
+	}
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	if _, dup := extensions[name]; dup {
+		panic(fmt.Sprintf("stanza: RegisterExtension called twice for {%s}%s", name.Space, name.Local))
+	}
+	extensions[name] = f
+}
+
+// Extensions returns the names of all registered payloads.
+func Extensions() []xml.Name {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	names := make([]xml.Name, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	return names
+}
+
+func extension(name xml.Name) (func() interface{}, bool) {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	f, ok := extensions[name]
+	return f, ok
+}
+
+// DecodeExtensions decodes the child elements of a stanza into the values
+// returned by the functions registered with RegisterExtension, in the order in
+// which they appear.
+// Child elements that do not have a registered extension are skipped.
+// r should be positioned just after the stanza start element and is read
+// until the end of the stanza.
+func DecodeExtensions(r xml.TokenReader) ([]interface{}, error) {
+	d := xml.NewTokenDecoder(r)
+	var exts []interface{}
+	for {
+		tok, err := d.Token()
+		switch {
+		case err == io.EOF:
+			return exts, nil
+		case err != nil:
+			return exts, err
+		}
+		var start xml.StartElement
+		switch t := tok.(type) {
+		case xml.StartElement:
+			start = t
+		case xml.EndElement:
+			return exts, nil
+		default:
+			continue
+		}
+		f, ok := extension(start.Name)
+		if !ok {
+			err = d.Skip()
+			if err != nil {
+				return exts, err
+			}
+			continue
+		}
+		v := f()
+		err = d.DecodeElement(v, &start)
+		if err != nil {
+			return exts, err
+		}
+		exts = append(exts, v)
+	}
+}
+
+// nextStart returns the first start element read from r.
+func nextStart(r xml.TokenReader) (xml.StartElement, error) {
+	for {
+		tok, err := r.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// DecodeMessage reads a message stanza from r and decodes its payloads using
+// DecodeExtensions.
+func DecodeMessage(r xml.TokenReader) (Message, []interface{}, error) {
+	start, err := nextStart(r)
+	if err != nil {
+		return Message{}, nil, err
+	}
+	msg, err := NewMessage(start)
+	if err != nil {
+		return msg, nil, err
+	}
+	exts, err := DecodeExtensions(r)
+	return msg, exts, err
+}
+
+// DecodeIQ reads an IQ stanza from r and decodes its payloads using
+// DecodeExtensions.
+func DecodeIQ(r xml.TokenReader) (IQ, []interface{}, error) {
+	start, err := nextStart(r)
+	if err != nil {
+		return IQ{}, nil, err
+	}
+	iq, err := NewIQ(start)
+	if err != nil {
+		return iq, nil, err
+	}
+	exts, err := DecodeExtensions(r)
+	return iq, exts, err
+}
+
+// DecodePresence reads a presence stanza from r and decodes its payloads using
+// DecodeExtensions.
+func DecodePresence(r xml.TokenReader) (Presence, []interface{}, error) {
+	start, err := nextStart(r)
+	if err != nil {
+		return Presence{}, nil, err
+	}
+	p, err := NewPresence(start)
+	if err != nil {
+		return p, nil, err
+	}
+	exts, err := DecodeExtensions(r)
+	return p, exts, err
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package stanza_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/stanza"
+)
+
+type testExt struct {
+	XMLName xml.Name `xml:"urn:example:ext ext"`
+	Value   string   `xml:",chardata"`
+}
+
+var extName = xml.Name{Space: "urn:example:ext", Local: "ext"}
+
+func init() {
+	stanza.RegisterExtension(extName, func() interface{} { return &testExt{} })
+}
+
+func TestRegisterExtensionDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected registering an extension twice to panic")
+		}
+	}()
+	stanza.RegisterExtension(extName, func() interface{} { return &testExt{} })
+}
+
+func TestExtensions(t *testing.T) {
+	var found bool
+	for _, name := range stanza.Extensions() {
+		if name == extName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("registered extension %v not found", extName)
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	const input = `<message xmlns="jabber:client" to="juliet@example.com" type="chat">` +
+		`<body>Wherefore art thou?</body>` +
+		`<ext xmlns="urn:example:ext">one</ext>` +
+		`<origin-id xmlns="urn:xmpp:sid:0" id="abc"/>` +
+		`<unknown xmlns="urn:example:unknown"><ext xmlns="urn:example:ext">nested</ext></unknown>` +
+		`<ext xmlns="urn:example:ext">two</ext>` +
+		`</message>`
+	msg, exts, err := stanza.DecodeMessage(xml.NewDecoder(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("error decoding message: %v", err)
+	}
+	if msg.Type != stanza.ChatMessage || msg.To.String() != "juliet@example.com" {
+		t.Errorf("wrong message decoded: %+v", msg)
+	}
+	if len(exts) != 3 {
+		t.Fatalf("wrong number of extensions: want=3, got=%d: %+v", len(exts), exts)
+	}
+	if ext, ok := exts[0].(*testExt); !ok || ext.Value != "one" {
+		t.Errorf("wrong first extension: %+v", exts[0])
+	}
+	if id, ok := exts[1].(*stanza.OriginID); !ok || id.ID != "abc" {
+		t.Errorf("wrong second extension: %+v", exts[1])
+	}
+	if ext, ok := exts[2].(*testExt); !ok || ext.Value != "two" {
+		t.Errorf("wrong third extension: %+v", exts[2])
+	}
+}
+
+func TestDecodeIQ(t *testing.T) {
+	const input = `<iq xmlns="jabber:client" id="123" type="set"><ext xmlns="urn:example:ext">val</ext></iq>`
+	iq, exts, err := stanza.DecodeIQ(xml.NewDecoder(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("error decoding IQ: %v", err)
+	}
+	if iq.ID != "123" || iq.Type != stanza.SetIQ {
+		t.Errorf("wrong IQ decoded: %+v", iq)
+	}
+	if len(exts) != 1 {
+		t.Fatalf("wrong number of extensions: want=1, got=%d", len(exts))
+	}
+	if ext, ok := exts[0].(*testExt); !ok || ext.Value != "val" {
+		t.Errorf("wrong extension: %+v", exts[0])
+	}
+}
+
+func TestDecodePresence(t *testing.T) {
+	const input = `<presence xmlns="jabber:client" type="unavailable"><delay xmlns="urn:xmpp:delay" stamp="2002-09-10T23:08:25Z"/></presence>`
+	p, exts, err := stanza.DecodePresence(xml.NewDecoder(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("error decoding presence: %v", err)
+	}
+	if p.Type != stanza.UnavailablePresence {
+		t.Errorf("wrong presence decoded: %+v", p)
+	}
+	if len(exts) != 1 {
+		t.Fatalf("wrong number of extensions: want=1, got=%d", len(exts))
+	}
+	if d, ok := exts[0].(*stanza.Delay); !ok || d.Stamp.Year() != 2002 {
+		t.Errorf("wrong extension: %+v", exts[0])
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package stanza
+
+type legacyError struct {
+	cond Condition
+	typ  ErrorType
+}
+
+// legacyCodes maps legacy error codes to conditions and types as defined in
+// XEP-0086: Error Condition Mappings.
+var legacyCodes = map[int]legacyError{
+	302: {cond: Redirect, typ: Modify},
+	400: {cond: BadRequest, typ: Modify},
+	401: {cond: NotAuthorized, typ: Auth},
+	403: {cond: Forbidden, typ: Auth},
+	404: {cond: ItemNotFound, typ: Cancel},
+	405: {cond: NotAllowed, typ: Cancel},
+	406: {cond: NotAcceptable, typ: Modify},
+	407: {cond: RegistrationRequired, typ: Auth},
+	408: {cond: RemoteServerTimeout, typ: Wait},
+	409: {cond: Conflict, typ: Cancel},
+	500: {cond: InternalServerError, typ: Wait},
+	501: {cond: FeatureNotImplemented, typ: Cancel},
+	502: {cond: ServiceUnavailable, typ: Wait},
+	503: {cond: ServiceUnavailable, typ: Cancel},
+	504: {cond: RemoteServerTimeout, typ: Wait},
+	510: {cond: ServiceUnavailable, typ: Cancel},
+}
+
+// LegacyCondition returns the condition and error type that correspond to a
+// legacy numeric error code as defined in XEP-0086: Error Condition Mappings.
+// If the code is not known, ok will be false.
+//
+// Code 402 (payment required) is not mapped because its condition was removed
+// in RFC 6120.
+//
+// Error codes are translated automatically when unmarshaling an Error that
+// does not have a defined condition so most users will not need to call this
+// function.
+func LegacyCondition(code int) (cond Condition, typ ErrorType, ok bool) {
+	e, ok := legacyCodes[code]
+	return e.cond, e.typ, ok
+}
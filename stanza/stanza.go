@@ -6,6 +6,8 @@ package stanza
 
 import (
 	"encoding/xml"
+	"fmt"
+	"io"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/internal/attr"
@@ -111,3 +113,120 @@ func AddOriginID(r xml.TokenReader, stanzaNS string) xml.TokenReader {
 		return nil
 	})(r)
 }
+
+// InsertOriginID returns a transformer that adds an origin ID containing a
+// random UUID to any message that is not an error and that does not already
+// have one.
+// It is meant to be used as an outgoing filter on a session so that messages
+// can be reliably identified in archives and by other clients even if the
+// server or a multi-user chat changes their "id" attribute.
+// If stanzaNS is the empty string, messages in any namespace are matched.
+func InsertOriginID(stanzaNS string) xmlstream.Transformer {
+	return AppendToMessage(stanzaNS, xml.Name{Space: NSSid, Local: "origin-id"}, func() xml.TokenReader {
+		return OriginID{ID: attr.UUID()}.TokenReader()
+	})
+}
+
+// AppendToMessage returns a transformer that appends the element returned by f
+// as the last child of any message that is not an error and that does not
+// already have a direct child with the given name.
+// The function f is called once for each message that is modified.
+// If stanzaNS is the empty string, messages in any namespace are matched.
+func AppendToMessage(stanzaNS string, name xml.Name, f func() xml.TokenReader) xmlstream.Transformer {
+	return func(r xml.TokenReader) xml.TokenReader {
+		var (
+			depth int
+			isMsg bool
+			skip  bool
+			inner xml.TokenReader
+		)
+		return xmlstream.ReaderFunc(func() (xml.Token, error) {
+			if inner != nil {
+				tok, err := inner.Token()
+				if err == io.EOF {
+					inner = nil
+					err = nil
+				}
+				if tok != nil || err != nil {
+					return tok, err
+				}
+			}
+
+			tok, err := r.Token()
+			if tok == nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				depth++
+				switch {
+				case depth == 1:
+					isMsg = t.Name.Local == "message" && (stanzaNS == "" || t.Name.Space == stanzaNS)
+					_, typ := attr.Get(t.Attr, "type")
+					skip = typ == string(ErrorMessage)
+				case depth == 2 && t.Name == name:
+					skip = true
+				}
+			case xml.EndElement:
+				depth--
+				if depth == 0 && isMsg && !skip {
+					inner = xmlstream.MultiReader(f(), xmlstream.Token(t))
+					return inner.Token()
+				}
+			}
+			return tok, err
+		})
+	}
+}
+
+// UnmarshalIDs reads a stanza from r and returns any stanza IDs and the origin
+// ID found in its direct children.
+// To find the stanza ID assigned by a particular entity, such as the users
+// archive or a multi-user chat, see IDBy.
+func UnmarshalIDs(r xml.TokenReader) (ids []ID, origin OriginID, err error) {
+	tok, err := r.Token()
+	if err != nil {
+		return nil, origin, err
+	}
+	if _, ok := tok.(xml.StartElement); !ok {
+		return nil, origin, fmt.Errorf("stanza: expected start element, got %T", tok)
+	}
+	iter := xmlstream.NewIter(r)
+	/* #nosec */
+	defer iter.Close()
+	for iter.Next() {
+		start, inner := iter.Current()
+		if start == nil || start.Name.Space != NSSid {
+			continue
+		}
+		d := xml.NewTokenDecoder(xmlstream.MultiReader(xmlstream.Token(*start), inner))
+		switch start.Name.Local {
+		case "stanza-id":
+			var id ID
+			err = d.Decode(&id)
+			if err != nil {
+				return nil, origin, err
+			}
+			ids = append(ids, id)
+		case "origin-id":
+			err = d.Decode(&origin)
+			if err != nil {
+				return nil, origin, err
+			}
+		}
+	}
+	return ids, origin, iter.Err()
+}
+
+// IDBy returns the stanza ID in ids that was assigned by the provided entity.
+// Because any entity can add a stanza ID to a stanza, only IDs added by an
+// entity that is trusted to do so (normally the users own bare JID for archived
+// messages or the bare JID of a multi-user chat) should be used.
+func IDBy(ids []ID, by jid.JID) (ID, bool) {
+	for _, id := range ids {
+		if id.By.Equal(by) {
+			return id, true
+		}
+	}
+	return ID{}, false
+}
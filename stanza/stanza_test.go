@@ -81,3 +81,66 @@ func TestMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertOriginID(t *testing.T) {
+	for i, in := range []string{
+		`<message xmlns="jabber:client"><body>Hi</body></message>`,
+		`<message xmlns="jabber:client"><origin-id xmlns="urn:xmpp:sid:0" id="abc"/></message>`,
+		`<message xmlns="jabber:client" type="error"></message>`,
+		`<presence xmlns="jabber:client"></presence>`,
+	} {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			r := stanza.InsertOriginID(stanza.NSClient)(xml.NewDecoder(strings.NewReader(in)))
+			ids := 0
+			var origin string
+			for {
+				tok, err := r.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("error reading tokens: %v", err)
+				}
+				if start, ok := tok.(xml.StartElement); ok && start.Name.Space == stanza.NSSid && start.Name.Local == "origin-id" {
+					ids++
+					origin = start.Attr[len(start.Attr)-1].Value
+				}
+			}
+			switch i {
+			case 0:
+				if ids != 1 || len(origin) != 36 {
+					t.Errorf("expected one UUID origin ID, got %d: %q", ids, origin)
+				}
+			case 1:
+				if ids != 1 || origin != "abc" {
+					t.Errorf("existing origin ID was modified, got %d: %q", ids, origin)
+				}
+			default:
+				if ids != 0 {
+					t.Errorf("unexpected origin ID added")
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalIDs(t *testing.T) {
+	const in = `<message xmlns="jabber:client"><stanza-id xmlns="urn:xmpp:sid:0" id="1" by="room@muc.example.net"/><body>Hi</body><stanza-id xmlns="urn:xmpp:sid:0" id="2" by="juliet@example.com"/><origin-id xmlns="urn:xmpp:sid:0" id="abc"/></message>`
+	ids, origin, err := stanza.UnmarshalIDs(xml.NewDecoder(strings.NewReader(in)))
+	if err != nil {
+		t.Fatalf("error unmarshaling IDs: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("wrong number of stanza IDs: %v", ids)
+	}
+	if origin.ID != "abc" {
+		t.Errorf("wrong origin ID: want=abc, got=%s", origin.ID)
+	}
+	id, ok := stanza.IDBy(ids, jid.MustParse("juliet@example.com"))
+	if !ok || id.ID != "2" {
+		t.Errorf("wrong stanza ID for archive: %+v", id)
+	}
+	if _, ok = stanza.IDBy(ids, jid.MustParse("mallory@example.com")); ok {
+		t.Errorf("found stanza ID for untrusted entity")
+	}
+}
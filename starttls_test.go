@@ -17,6 +17,7 @@ import (
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/internal/ns"
 	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
 )
 
 // There is no room for variation on the starttls feature negotiation, so step
@@ -185,3 +186,47 @@ func TestNegotiateClient(t *testing.T) {
 		})
 	}
 }
+
+// If StartTLS is in the list of features to negotiate but the first features
+// list does not advertise it, the client must try it anyways to prevent
+// downgrade attacks.
+// Wrapping the connection (eg. to tee the output or coalesce writes) must not
+// change this.
+func TestStartTLSNotAdvertised(t *testing.T) {
+	for _, tc := range []struct {
+		tee, coalesce bool
+	}{
+		{},
+		{tee: true},
+		{coalesce: true},
+		{tee: true, coalesce: true},
+	} {
+		t.Run(fmt.Sprintf("tee=%t,coalesce=%t", tc.tee, tc.coalesce), func(t *testing.T) {
+			var teeOut bytes.Buffer
+			negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+				cfg := xmpp.StreamConfig{
+					Features: []xmpp.StreamFeature{xmpp.StartTLS(&tls.Config{})},
+				}
+				if tc.tee {
+					cfg.TeeOut = &teeOut
+				}
+				if tc.coalesce {
+					cfg.Coalesce = &xmpp.WriteCoalescing{}
+				}
+				return cfg
+			})
+			var out bytes.Buffer
+			rw := nopRWC{
+				strings.NewReader(`<stream:stream id='316732270768047465' version='1.0' xml:lang='en' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:client'><stream:features></stream:features>`),
+				&out,
+			}
+			// Negotiation is expected to fail once the client has sent the
+			// starttls element because the input ends.
+			/* #nosec */
+			xmpp.NewSession(context.Background(), jid.MustParse("example.net"), jid.MustParse("me@example.net"), rw, 0, negotiator)
+			if !strings.Contains(out.String(), `<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>`) {
+				t.Errorf("expected StartTLS to be negotiated even though it was not advertised, got output: %q", out.String())
+			}
+		})
+	}
+}
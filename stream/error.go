@@ -8,9 +8,11 @@ import (
 	"encoding/xml"
 	"io"
 	"net"
+	"strings"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp/internal/ns"
+	"mellium.im/xmpp/internal/tokens"
 )
 
 // A list of stream errors defined in RFC 6120 §4.9.3
@@ -175,7 +177,10 @@ func SeeOtherHostError(addr net.Addr) Error {
 // Error represents an unrecoverable stream-level error that may include
 // character data or arbitrary inner XML.
 type Error struct {
-	Err  string
+	Err string
+
+	// Text contains human readable descriptions of the error, normally one for
+	// each language.
 	Text []struct {
 		Lang  string
 		Value string
@@ -185,9 +190,38 @@ type Error struct {
 	// This should only be used by see-other-host errors.
 	Content string
 
+	// Payload contains any application-specific elements included in the error.
+	// When unmarshaling, any elements that are not in the NSError namespace are
+	// kept here so that they are not lost if the error is marshaled again.
+	// Unlike ApplicationError errors with a Payload may be marshaled more than
+	// once.
+	Payload []xml.Token
+
 	payload xml.TokenReader
 }
 
+// SeeOtherHost returns the host and port (if any) that the client should
+// connect to instead of the current server if s is a see-other-host error.
+// IPv6 literals are returned without the surrounding brackets.
+// If s is not a see-other-host error or it does not include a host, ok is
+// false.
+func (s Error) SeeOtherHost() (host, port string, ok bool) {
+	if s.Err != SeeOtherHost.Err {
+		return "", "", false
+	}
+	addr := strings.TrimSpace(s.Content)
+	if addr == "" {
+		return "", "", false
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// There was no port, or this is a raw IPv6 address.
+		host = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		port = ""
+	}
+	return host, port, true
+}
+
 // Is will be used by errors.Is when comparing errors.
 // For more information see the errors package.
 func (s Error) Is(err error) bool {
@@ -277,6 +311,16 @@ func (s *Error) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			if err = d.Skip(); err != nil {
 				return err
 			}
+		default:
+			toks, err := xmlstream.ReadAll(removeXMLNS(xmlstream.MultiReader(
+				xmlstream.Token(start),
+				xmlstream.Inner(d),
+				xmlstream.Token(start.End()),
+			)))
+			if err != nil {
+				return err
+			}
+			s.Payload = append(s.Payload, toks...)
 		}
 	}
 }
@@ -322,6 +366,12 @@ func (s Error) TokenReader() xml.TokenReader {
 			),
 		)
 	}
+	if len(s.Payload) > 0 {
+		inner = xmlstream.MultiReader(
+			inner,
+			tokens.Reader(s.Payload),
+		)
+	}
 	return xmlstream.Wrap(
 		inner,
 		xml.StartElement{
@@ -330,6 +380,13 @@ func (s Error) TokenReader() xml.TokenReader {
 	)
 }
 
+// removeXMLNS removes namespace declarations from elements that already have
+// their namespace set so that they are not duplicated when the elements are
+// marshaled again.
+var removeXMLNS = xmlstream.RemoveAttr(func(start xml.StartElement, attr xml.Attr) bool {
+	return start.Name.Space != "" && attr.Name.Space == "" && attr.Name.Local == "xmlns"
+})
+
 // ApplicationError returns a copy of the Error with the provided application
 // level error included alongside the error condition.
 // Multiple, chained, calls to ApplicationError will  replace the payload each
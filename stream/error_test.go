@@ -88,6 +88,14 @@ var marshalTests = [...]struct {
 		}}},
 		xml: `<error xmlns="http://etherx.jabber.org/streams"><undefined-condition xmlns="urn:ietf:params:xml:ns:xmpp-streams"></undefined-condition><text xmlns="urn:ietf:params:xml:ns:xmpp-streams" xml:lang="en">some value</text><text xmlns="urn:ietf:params:xml:ns:xmpp-streams">some error</text></error>`,
 	},
+	7: {
+		se: stream.Error{Err: "undefined-condition", Payload: []xml.Token{
+			xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "limit"}},
+			xml.CharData("10"),
+			xml.EndElement{Name: xml.Name{Space: "urn:example", Local: "limit"}},
+		}},
+		xml: `<error xmlns="http://etherx.jabber.org/streams"><undefined-condition xmlns="urn:ietf:params:xml:ns:xmpp-streams"></undefined-condition><limit xmlns="urn:example">10</limit></error>`,
+	},
 }
 
 func TestMarshal(t *testing.T) {
@@ -142,6 +150,23 @@ var unmarshalTests = [...]struct {
 		se:  stream.Error{Err: "see-other-host", Content: "127.0.0.1"},
 		err: false,
 	},
+	4: {
+		xml: `<error xmlns="http://etherx.jabber.org/streams"><undefined-condition xmlns="urn:ietf:params:xml:ns:xmpp-streams"></undefined-condition><text xmlns="urn:ietf:params:xml:ns:xmpp-streams">test</text><limit xmlns="urn:example"><max>10</max></limit></error>`,
+		se: stream.Error{
+			Err: "undefined-condition",
+			Text: []struct {
+				Lang  string
+				Value string
+			}{{Value: "test"}},
+			Payload: []xml.Token{
+				xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "limit"}, Attr: []xml.Attr{}},
+				xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "max"}, Attr: []xml.Attr{}},
+				xml.CharData("10"),
+				xml.EndElement{Name: xml.Name{Space: "urn:example", Local: "max"}},
+				xml.EndElement{Name: xml.Name{Space: "urn:example", Local: "limit"}},
+			},
+		},
+	},
 }
 
 func TestUnmarshal(t *testing.T) {
@@ -162,6 +187,9 @@ func TestUnmarshal(t *testing.T) {
 			case test.se.Content != s.Content:
 				t.Errorf("expected Content `%#v` but got `%#v`", test.se.Content, s.Content)
 				return
+			case !reflect.DeepEqual(test.se.Payload, s.Payload):
+				t.Errorf("invalid value for Payload:\nwant=%+v\n got=%+v", test.se.Payload, s.Payload)
+				return
 			case err != nil:
 				return
 			case s.Err != test.se.Err:
@@ -172,6 +200,31 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+var seeOtherHostTests = [...]struct {
+	se   stream.Error
+	host string
+	port string
+	ok   bool
+}{
+	0: {se: stream.SeeOtherHostError(&net.TCPAddr{IP: net.ParseIP("::1"), Port: 5222}), host: "::1", port: "5222", ok: true},
+	1: {se: stream.SeeOtherHostError(&net.IPAddr{IP: net.ParseIP("::1")}), host: "::1", ok: true},
+	2: {se: stream.Error{Err: "see-other-host", Content: " example.net "}, host: "example.net", ok: true},
+	3: {se: stream.Error{Err: "see-other-host", Content: "example.net:5223"}, host: "example.net", port: "5223", ok: true},
+	4: {se: stream.Error{Err: "see-other-host"}},
+	5: {se: stream.Error{Err: "host-gone", Content: "example.net"}},
+}
+
+func TestSeeOtherHost(t *testing.T) {
+	for i, tc := range seeOtherHostTests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			host, port, ok := tc.se.SeeOtherHost()
+			if host != tc.host || port != tc.port || ok != tc.ok {
+				t.Errorf("wrong result: want=(%q, %q, %t), got=(%q, %q, %t)", tc.host, tc.port, tc.ok, host, port, ok)
+			}
+		})
+	}
+}
+
 func TestErrorReturnsCondition(t *testing.T) {
 	if stream.RestrictedXML.Error() != "restricted-xml" {
 		t.Error("error should return the error condition")
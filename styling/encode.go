@@ -0,0 +1,391 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package styling
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// Errors returned by the Encoder.
+var (
+	// ErrInvalidNesting is returned when a span or block is started or ended
+	// somewhere that the styling rules do not allow, for example a span that is
+	// already open or a block quote inside of a preformatted text block.
+	ErrInvalidNesting = errors.New("styling: invalid nesting")
+
+	// ErrUnrepresentable is returned when text cannot be written at the current
+	// position without changing its meaning, for example a span that is empty,
+	// that starts or ends with whitespace, that contains a newline, or that
+	// contains its own styling directive.
+	ErrUnrepresentable = errors.New("styling: text cannot be represented")
+)
+
+// wordJoiner is an invisible character that is inserted before characters that
+// would otherwise be parsed as a styling directive.
+// Since it is not whitespace, a directive following it can never start a span
+// or block.
+const wordJoiner = "\u2060"
+
+var spanDirectives = map[Style]byte{
+	SpanEmph:   '_',
+	SpanStrong: '*',
+	SpanStrike: '~',
+	SpanPre:    '`',
+}
+
+// An Encoder writes styled text to an output stream.
+// It is the inverse of the Decoder and takes care of producing directives that
+// will be parsed as intended, returning an error if that is not possible.
+//
+// Message styling does not define an escape mechanism, so any characters in
+// plain text that would otherwise be parsed as the start of a span or block are
+// preceded by U+2060 WORD JOINER, an invisible character that stops them from
+// being treated as a styling directive.
+//
+// Encoder implements Renderer so a styled document may also be re-encoded by
+// passing a Decoder and Encoder to Render.
+// Any directives passed to the Directive method are ignored and the Encoder
+// writes its own.
+type Encoder struct {
+	w     io.Writer
+	buf   []byte
+	quote uint
+	pre   bool
+	spans []Style
+
+	lineStart      bool
+	contentStart   bool
+	pendingNewline bool
+	startOK        bool
+	lastSpace      bool
+	spanEmpty      bool
+	quoteEmpty     bool
+	fenceRun       int
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:            w,
+		lineStart:    true,
+		contentStart: true,
+		startOK:      true,
+	}
+}
+
+func (e *Encoder) flush() error {
+	if len(e.buf) == 0 {
+		return nil
+	}
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+	return err
+}
+
+// beginLine writes any newline that is still owed by a previous block and the
+// block quote markers at the start of a line.
+func (e *Encoder) beginLine() {
+	if e.pendingNewline {
+		e.pendingNewline = false
+		e.newline()
+	}
+	if !e.lineStart {
+		return
+	}
+	e.lineStart = false
+	e.quoteEmpty = false
+	if e.quote > 0 {
+		e.buf = append(e.buf, bytes.Repeat([]byte{'>'}, int(e.quote))...)
+		e.buf = append(e.buf, ' ')
+	}
+}
+
+func (e *Encoder) newline() {
+	e.buf = append(e.buf, '\n')
+	e.lineStart = true
+	e.contentStart = true
+	e.startOK = true
+	e.lastSpace = true
+	e.fenceRun = 0
+}
+
+// endLine moves the encoder to the start of a new line if it is not already at
+// one.
+func (e *Encoder) endLine() {
+	if e.pendingNewline {
+		e.pendingNewline = false
+		e.newline()
+	}
+	if !e.lineStart {
+		e.newline()
+	}
+}
+
+func (e *Encoder) inSpan(style Style) bool {
+	for _, s := range e.spans {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Encoder) spanDirective(b byte) bool {
+	for _, s := range e.spans {
+		if spanDirectives[s] == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Text writes plain text, escaping any characters that would otherwise be
+// parsed as styling directives.
+// Newlines may not be written inside of a span.
+// If an error is returned, any text before the character that caused it has
+// already been written.
+func (e *Encoder) Text(data []byte) error {
+	err := e.text(data)
+	if flushErr := e.flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func (e *Encoder) text(data []byte) error {
+	inPreSpan := e.inSpan(SpanPre)
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		c := data[:size]
+		data = data[size:]
+
+		if r == '\n' {
+			if len(e.spans) > 0 {
+				return ErrUnrepresentable
+			}
+			if e.lineStart && e.quote > 0 {
+				e.beginLine()
+			}
+			e.pendingNewline = false
+			e.newline()
+			continue
+		}
+
+		e.beginLine()
+		switch {
+		case e.pre:
+			// Nothing is parsed inside preformatted text blocks except for the
+			// closing fence, which cannot be escaped.
+			if r == '`' && e.fenceRun >= 0 {
+				e.fenceRun++
+				if e.fenceRun == len(fence) {
+					return ErrUnrepresentable
+				}
+			} else {
+				e.fenceRun = -1
+			}
+		case len(e.spans) > 0 && e.spanEmpty && isSpace(r):
+			return ErrUnrepresentable
+		case len(c) == 1 && e.spanDirective(c[0]):
+			return ErrUnrepresentable
+		case inPreSpan:
+			// Inline preformatted text can not contain child spans, so there is
+			// nothing to escape.
+		case e.contentStart && (r == '>' || (e.quote > 0 && isSpace(r))):
+			// A block quote marker at the start of a line would start a new quote
+			// and whitespace after the markers would be consumed by them.
+			e.buf = append(e.buf, wordJoiner...)
+		case e.startOK && len(c) == 1 && (r == '*' || r == '_' || r == '~' || r == '`'):
+			e.buf = append(e.buf, wordJoiner...)
+		}
+		e.buf = append(e.buf, c...)
+		e.contentStart = false
+		e.spanEmpty = false
+		e.lastSpace = isSpace(r)
+		e.startOK = e.lastSpace
+	}
+	return nil
+}
+
+// StartSpan writes the start of an inline span.
+// Style must be one of SpanEmph, SpanStrong, SpanStrike, or SpanPre.
+// Spans must be started at the start of a line, after whitespace, or directly
+// after another styling directive and may not be nested in a span of the same
+// style or in an inline preformatted span.
+func (e *Encoder) StartSpan(style Style) error {
+	b, ok := spanDirectives[style]
+	switch {
+	case !ok || e.pre || e.inSpan(style) || e.inSpan(SpanPre):
+		return ErrInvalidNesting
+	case !e.startOK && !e.pendingNewline:
+		return ErrUnrepresentable
+	}
+	e.beginLine()
+	e.buf = append(e.buf, b)
+	e.spans = append(e.spans, style)
+	e.contentStart = false
+	e.spanEmpty = true
+	e.startOK = true
+	e.lastSpace = false
+	return e.flush()
+}
+
+// EndSpan writes the end of the innermost open span, which must have the given
+// style.
+// Spans must not be empty or end with whitespace.
+func (e *Encoder) EndSpan(style Style) error {
+	if len(e.spans) == 0 || e.spans[len(e.spans)-1] != style {
+		return ErrInvalidNesting
+	}
+	if e.spanEmpty || e.lastSpace {
+		return ErrUnrepresentable
+	}
+	e.spans = e.spans[:len(e.spans)-1]
+	e.buf = append(e.buf, spanDirectives[style])
+	e.startOK = true
+	e.lastSpace = false
+	return e.flush()
+}
+
+// StartBlock starts a new block quote or preformatted text block, beginning a
+// new line first if necessary.
+// Style must be either BlockQuote or BlockPre.
+// If the block is a preformatted text block, info is written after the opening
+// fence and may not contain a newline.
+// Lines of text inside of a preformatted text block may not start with a
+// fence (```) since it cannot be escaped.
+// Blocks cannot be started inside of a span or preformatted text block.
+func (e *Encoder) StartBlock(style Style, info []byte) error {
+	switch {
+	case style != BlockQuote && style != BlockPre,
+		e.pre, len(e.spans) > 0:
+		return ErrInvalidNesting
+	case bytes.IndexByte(info, '\n') != -1:
+		return ErrUnrepresentable
+	}
+	e.endLine()
+	if style == BlockQuote {
+		e.quote++
+		e.quoteEmpty = true
+		return e.flush()
+	}
+	e.beginLine()
+	e.buf = append(e.buf, fence...)
+	e.buf = append(e.buf, info...)
+	e.newline()
+	e.pre = true
+	return e.flush()
+}
+
+// EndBlock ends the innermost open block, which must have the given style.
+// Any open spans must be ended first.
+func (e *Encoder) EndBlock(style Style) error {
+	switch {
+	case len(e.spans) > 0:
+		return ErrInvalidNesting
+	case style == BlockPre && e.pre:
+		e.endLine()
+		e.beginLine()
+		e.buf = append(e.buf, fence...)
+		e.pre = false
+		e.lineStart = false
+		e.pendingNewline = true
+		return e.flush()
+	case style == BlockQuote && !e.pre && e.quote > 0:
+		if e.quoteEmpty {
+			// Write the markers for empty quotes so that they are not lost.
+			e.beginLine()
+		}
+		e.endLine()
+		e.quote--
+		return e.flush()
+	}
+	return ErrInvalidNesting
+}
+
+// Directive implements Renderer.
+// It does nothing since the Encoder writes its own styling directives.
+func (e *Encoder) Directive(Style, []byte) error {
+	return nil
+}
+
+// Close ends any open blocks.
+// It does not close the underlying writer.
+// If any spans are still open, Close returns ErrInvalidNesting.
+func (e *Encoder) Close() error {
+	if len(e.spans) > 0 {
+		return ErrInvalidNesting
+	}
+	if e.pre {
+		err := e.EndBlock(BlockPre)
+		if err != nil {
+			return err
+		}
+	}
+	e.quote = 0
+	return e.flush()
+}
+
+func (e *Encoder) span(style Style, s string) error {
+	err := e.StartSpan(style)
+	if err != nil {
+		return err
+	}
+	err = e.Text([]byte(s))
+	if err != nil {
+		return err
+	}
+	return e.EndSpan(style)
+}
+
+// Emph writes s as emphasized text.
+func (e *Encoder) Emph(s string) error {
+	return e.span(SpanEmph, s)
+}
+
+// Strong writes s as text with strong emphasis.
+func (e *Encoder) Strong(s string) error {
+	return e.span(SpanStrong, s)
+}
+
+// Strike writes s as struck through text.
+func (e *Encoder) Strike(s string) error {
+	return e.span(SpanStrike, s)
+}
+
+// Pre writes s as inline preformatted text.
+func (e *Encoder) Pre(s string) error {
+	return e.span(SpanPre, s)
+}
+
+// BlockQuote writes s as a block quote nested one level deeper than the
+// current position.
+func (e *Encoder) BlockQuote(s string) error {
+	err := e.StartBlock(BlockQuote, nil)
+	if err != nil {
+		return err
+	}
+	err = e.Text([]byte(s))
+	if err != nil {
+		return err
+	}
+	return e.EndBlock(BlockQuote)
+}
+
+// PreBlock writes s as a preformatted text block with the given info string.
+func (e *Encoder) PreBlock(info, s string) error {
+	err := e.StartBlock(BlockPre, []byte(info))
+	if err != nil {
+		return err
+	}
+	err = e.Text([]byte(s))
+	if err != nil {
+		return err
+	}
+	return e.EndBlock(BlockPre)
+}
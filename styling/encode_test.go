@@ -0,0 +1,267 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package styling_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/styling"
+)
+
+var _ styling.Renderer = (*styling.Encoder)(nil)
+
+const wj = "\u2060"
+
+var encodeTestCases = [...]struct {
+	f   func(e *styling.Encoder) error
+	out string
+	err error
+}{
+	0: {
+		f: func(e *styling.Encoder) error {
+			err := e.Text([]byte("a "))
+			if err != nil {
+				return err
+			}
+			err = e.Strong("b")
+			if err != nil {
+				return err
+			}
+			err = e.Text([]byte(" "))
+			if err != nil {
+				return err
+			}
+			return e.Emph("c d")
+		},
+		out: "a *b* _c d_",
+	},
+	1: {
+		f: func(e *styling.Encoder) error {
+			return e.Text([]byte("*not strong* a*b _x ~y~ `z`\n> not quoted\n```"))
+		},
+		out: wj + "*not strong* a*b " + wj + "_x " + wj + "~y~ " + wj + "`z`\n" + wj + "> not quoted\n" + wj + "```",
+	},
+	2: {
+		f: func(e *styling.Encoder) error {
+			err := e.StartSpan(styling.SpanStrong)
+			if err != nil {
+				return err
+			}
+			err = e.Text([]byte("a "))
+			if err != nil {
+				return err
+			}
+			err = e.Emph("b")
+			if err != nil {
+				return err
+			}
+			return e.EndSpan(styling.SpanStrong)
+		},
+		out: "*a _b_*",
+	},
+	3: {
+		f: func(e *styling.Encoder) error {
+			err := e.Text([]byte("before"))
+			if err != nil {
+				return err
+			}
+			err = e.BlockQuote("one\n  two")
+			if err != nil {
+				return err
+			}
+			return e.Text([]byte("after"))
+		},
+		out: "before\n> one\n> " + wj + "  two\nafter",
+	},
+	4: {
+		f: func(e *styling.Encoder) error {
+			err := e.StartBlock(styling.BlockQuote, nil)
+			if err != nil {
+				return err
+			}
+			err = e.Text([]byte("a\n"))
+			if err != nil {
+				return err
+			}
+			err = e.BlockQuote("b")
+			if err != nil {
+				return err
+			}
+			err = e.PreBlock("go", "*x*\n")
+			if err != nil {
+				return err
+			}
+			return e.Close()
+		},
+		out: "> a\n>> b\n> ```go\n> *x*\n> ```",
+	},
+	5: {
+		f: func(e *styling.Encoder) error {
+			err := e.PreBlock("", "x")
+			if err != nil {
+				return err
+			}
+			return e.Pre("`y")
+		},
+		err: styling.ErrUnrepresentable,
+		out: "```\nx\n```\n`",
+	},
+	6: {
+		f: func(e *styling.Encoder) error {
+			return e.Strong("a*b")
+		},
+		out: "*a",
+		err: styling.ErrUnrepresentable,
+	},
+	7: {
+		f: func(e *styling.Encoder) error {
+			return e.Strong(" a")
+		},
+		out: "*",
+		err: styling.ErrUnrepresentable,
+	},
+	8: {
+		f: func(e *styling.Encoder) error {
+			return e.Emph("a ")
+		},
+		out: "_a ",
+		err: styling.ErrUnrepresentable,
+	},
+	9: {
+		f: func(e *styling.Encoder) error {
+			return e.Strike("a\nb")
+		},
+		out: "~a",
+		err: styling.ErrUnrepresentable,
+	},
+	10: {
+		f: func(e *styling.Encoder) error {
+			err := e.Text([]byte("a"))
+			if err != nil {
+				return err
+			}
+			return e.Strong("b")
+		},
+		out: "a",
+		err: styling.ErrUnrepresentable,
+	},
+	11: {
+		f: func(e *styling.Encoder) error {
+			err := e.StartSpan(styling.SpanStrong)
+			if err != nil {
+				return err
+			}
+			return e.StartSpan(styling.SpanStrong)
+		},
+		out: "*",
+		err: styling.ErrInvalidNesting,
+	},
+	12: {
+		f: func(e *styling.Encoder) error {
+			err := e.StartSpan(styling.SpanPre)
+			if err != nil {
+				return err
+			}
+			return e.StartSpan(styling.SpanEmph)
+		},
+		out: "`",
+		err: styling.ErrInvalidNesting,
+	},
+	13: {
+		f: func(e *styling.Encoder) error {
+			err := e.StartSpan(styling.SpanEmph)
+			if err != nil {
+				return err
+			}
+			return e.StartBlock(styling.BlockQuote, nil)
+		},
+		out: "_",
+		err: styling.ErrInvalidNesting,
+	},
+	14: {
+		f: func(e *styling.Encoder) error {
+			return e.PreBlock("", "a\n```\n")
+		},
+		out: "```\na\n``",
+		err: styling.ErrUnrepresentable,
+	},
+	15: {
+		f: func(e *styling.Encoder) error {
+			return e.EndBlock(styling.BlockQuote)
+		},
+		err: styling.ErrInvalidNesting,
+	},
+	16: {
+		f: func(e *styling.Encoder) error {
+			return e.Pre("*a* _b_")
+		},
+		out: "`*a* _b_`",
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for i, tc := range encodeTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var out strings.Builder
+			err := tc.f(styling.NewEncoder(&out))
+			if !errors.Is(err, tc.err) {
+				t.Errorf("wrong error: want=%v, got=%v", tc.err, err)
+			}
+			if s := out.String(); s != tc.out {
+				t.Errorf("wrong output:\nwant=%q,\n got=%q", tc.out, s)
+			}
+		})
+	}
+}
+
+func TestEncodeEscapedText(t *testing.T) {
+	const in = "*a* _b_ ~c~ `d`\n> e\n```\nf\n```"
+	var out strings.Builder
+	err := styling.NewEncoder(&out).Text([]byte(in))
+	if err != nil {
+		t.Fatalf("error encoding text: %v", err)
+	}
+	d := styling.NewDecoder(strings.NewReader(out.String()))
+	for d.Next() {
+		tok := d.Token()
+		if s := d.Style(); s != 0 {
+			t.Errorf("escaped text was styled: style=%v, data=%q", s, tok.Data)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, tc := range decoderTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var encoded strings.Builder
+			e := styling.NewEncoder(&encoded)
+			err := styling.Render(e, styling.NewDecoder(strings.NewReader(tc.input)))
+			if err != nil {
+				t.Fatalf("error encoding: %v", err)
+			}
+			err = e.Close()
+			if err != nil {
+				t.Fatalf("error closing encoder: %v", err)
+			}
+
+			var want, got strings.Builder
+			err = styling.Render(styling.NewHTMLRenderer(&want), styling.NewDecoder(strings.NewReader(tc.input)))
+			if err != nil {
+				t.Fatalf("error rendering input: %v", err)
+			}
+			err = styling.Render(styling.NewHTMLRenderer(&got), styling.NewDecoder(strings.NewReader(encoded.String())))
+			if err != nil {
+				t.Fatalf("error rendering encoded output: %v", err)
+			}
+			// Ignore any characters that were inserted to escape directives.
+			if want.String() != strings.ReplaceAll(got.String(), wj, "") {
+				t.Errorf("round trip changed the document:\nencoded=%q\nwant=%q,\n got=%q", encoded.String(), want.String(), got.String())
+			}
+		})
+	}
+}
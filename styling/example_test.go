@@ -62,3 +62,40 @@ but *most* people shorten it.`)
 	// <em><code>_</code>Twelfth Night, or What You Will<code>_</code></em>
 	// but <strong><code>*</code>most<code>*</code></strong> people shorten it.
 }
+
+func ExampleRender() {
+	r := strings.NewReader(`> The full title is
+> _Twelfth Night, or What You Will_
+but *most* people shorten it.`)
+
+	var out strings.Builder
+	err := styling.Render(styling.NewHTMLRenderer(&out), styling.NewDecoder(r))
+	if err != nil {
+		fmt.Printf("error rendering: %v\n", err)
+		return
+	}
+	fmt.Println(out.String())
+
+	// Output:
+	// <blockquote>The full title is<br>
+	// <em>Twelfth Night, or What You Will</em></blockquote>but <strong>most</strong> people shorten it.
+}
+
+func ExampleEncoder() {
+	var out strings.Builder
+	e := styling.NewEncoder(&out)
+
+	// Errors are ignored to keep the example short.
+	e.BlockQuote("The full title is")
+	e.Emph("Twelfth Night, or What You Will")
+	e.Text([]byte("\nbut "))
+	e.Strong("most")
+	e.Text([]byte(" people shorten it."))
+	e.Close()
+	fmt.Println(out.String())
+
+	// Output:
+	// > The full title is
+	// _Twelfth Night, or What You Will_
+	// but *most* people shorten it.
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package styling
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+)
+
+// HTMLRenderer is a Renderer that writes an HTML fragment.
+// All text is escaped, block quotes are written as nested blockquote elements,
+// pre blocks as pre elements, and newlines outside of pre blocks as line
+// breaks.
+type HTMLRenderer struct {
+	// Directives causes styling directives to be written wrapped in a span
+	// with the class "directive" instead of being omitted.
+	Directives bool
+
+	w              io.Writer
+	pre            bool
+	pendingNewline bool
+}
+
+// NewHTMLRenderer returns a renderer that writes HTML to w.
+func NewHTMLRenderer(w io.Writer) *HTMLRenderer {
+	return &HTMLRenderer{w: w}
+}
+
+func (h *HTMLRenderer) write(s string) error {
+	_, err := io.WriteString(h.w, s)
+	return err
+}
+
+// flush writes any newline that was held back from the end of the previous
+// text.
+// Newlines are held back so that the newline at the end of a line does not
+// result in an extra line break when it is immediately followed by the end of
+// a block.
+func (h *HTMLRenderer) flush() error {
+	if !h.pendingNewline {
+		return nil
+	}
+	h.pendingNewline = false
+	if h.pre {
+		return h.write("\n")
+	}
+	return h.write("<br>\n")
+}
+
+// StartBlock implements Renderer.
+func (h *HTMLRenderer) StartBlock(style Style, info []byte) error {
+	err := h.flush()
+	if err != nil {
+		return err
+	}
+	if style == BlockPre {
+		h.pre = true
+		if len(info) > 0 {
+			return h.write(`<pre data-info="` + template.HTMLEscapeString(string(info)) + `">`)
+		}
+		return h.write("<pre>")
+	}
+	return h.write("<blockquote>")
+}
+
+// EndBlock implements Renderer.
+func (h *HTMLRenderer) EndBlock(style Style) error {
+	h.pendingNewline = false
+	if style == BlockPre {
+		h.pre = false
+		return h.write("</pre>")
+	}
+	return h.write("</blockquote>")
+}
+
+var spanTags = map[Style]string{
+	SpanEmph:   "em",
+	SpanStrong: "strong",
+	SpanStrike: "s",
+	SpanPre:    "code",
+}
+
+// StartSpan implements Renderer.
+func (h *HTMLRenderer) StartSpan(style Style) error {
+	err := h.flush()
+	if err != nil {
+		return err
+	}
+	return h.write("<" + spanTags[style] + ">")
+}
+
+// EndSpan implements Renderer.
+func (h *HTMLRenderer) EndSpan(style Style) error {
+	return h.write("</" + spanTags[style] + ">")
+}
+
+// Directive implements Renderer.
+func (h *HTMLRenderer) Directive(style Style, data []byte) error {
+	if !h.Directives {
+		return nil
+	}
+	err := h.flush()
+	if err != nil {
+		return err
+	}
+	trimmed := bytes.TrimSuffix(data, []byte{'\n'})
+	err = h.write(`<span class="directive">` + template.HTMLEscapeString(string(trimmed)) + `</span>`)
+	h.pendingNewline = len(trimmed) < len(data)
+	return err
+}
+
+// Text implements Renderer.
+func (h *HTMLRenderer) Text(data []byte) error {
+	err := h.flush()
+	if err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx == -1 {
+			return h.write(template.HTMLEscapeString(string(data)))
+		}
+		err = h.write(template.HTMLEscapeString(string(data[:idx])))
+		if err != nil {
+			return err
+		}
+		h.pendingNewline = true
+		data = data[idx+1:]
+		if len(data) > 0 {
+			err = h.flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package styling
+
+import (
+	"io"
+)
+
+// Renderer is used by Render to output a styled document.
+//
+// StartBlock and EndBlock are called with BlockQuote or BlockPre and always
+// nest properly: a pre block is always closed before the block quote that
+// contains it and nested block quotes are closed from the innermost outward.
+// If a block is a pre block, info is the info string that followed the opening
+// fence (if any).
+// StartSpan and EndSpan are called with one of SpanEmph, SpanStrong,
+// SpanStrike, or SpanPre and also nest properly.
+// Directive is called with the styling directive bit (for example
+// SpanStrongStart or BlockQuoteStart) and the characters that make up the
+// directive so that the renderer can decide whether to show them or not.
+// Text is called for all other text, including newlines.
+type Renderer interface {
+	StartBlock(style Style, info []byte) error
+	EndBlock(style Style) error
+	StartSpan(style Style) error
+	EndSpan(style Style) error
+	Directive(style Style, data []byte) error
+	Text(data []byte) error
+}
+
+var spanStarts = [...]struct {
+	start, end, span Style
+}{
+	{start: SpanEmphStart, end: SpanEmphEnd, span: SpanEmph},
+	{start: SpanStrongStart, end: SpanStrongEnd, span: SpanStrong},
+	{start: SpanStrikeStart, end: SpanStrikeEnd, span: SpanStrike},
+	{start: SpanPreStart, end: SpanPreEnd, span: SpanPre},
+}
+
+type renderState struct {
+	r     Renderer
+	quote uint
+	pre   bool
+	spans []Style
+}
+
+func (s *renderState) closeSpans() error {
+	for len(s.spans) > 0 {
+		err := s.r.EndSpan(s.spans[len(s.spans)-1])
+		if err != nil {
+			return err
+		}
+		s.spans = s.spans[:len(s.spans)-1]
+	}
+	return nil
+}
+
+func (s *renderState) closePre() error {
+	if !s.pre {
+		return nil
+	}
+	s.pre = false
+	return s.r.EndBlock(BlockPre)
+}
+
+// setQuote opens or closes block quotes until the quote level matches level.
+func (s *renderState) setQuote(level uint) error {
+	if level == s.quote {
+		return nil
+	}
+	err := s.closeSpans()
+	if err != nil {
+		return err
+	}
+	for s.quote > level {
+		err = s.closePre()
+		if err != nil {
+			return err
+		}
+		err = s.r.EndBlock(BlockQuote)
+		if err != nil {
+			return err
+		}
+		s.quote--
+	}
+	for s.quote < level {
+		err = s.r.StartBlock(BlockQuote, nil)
+		if err != nil {
+			return err
+		}
+		s.quote++
+	}
+	return nil
+}
+
+// Render reads tokens from the decoder and calls the methods of r to output
+// them, keeping track of the nesting of blocks and spans so that r does not
+// have to.
+// Any errors returned by r stop rendering and are returned.
+// Reaching the end of the input is not an error.
+func Render(r Renderer, d *Decoder) error {
+	s := &renderState{r: r}
+	for d.Next() {
+		tok := d.Token()
+		style := d.Style()
+		level := d.Quote()
+
+		var err error
+		switch {
+		case style&BlockQuoteEnd == BlockQuoteEnd:
+			// Quote reports the level of the quote being ended.
+			err = s.setQuote(level - 1)
+		case style&BlockQuoteStart == BlockQuoteStart:
+			err = s.setQuote(level)
+			if err == nil {
+				err = r.Directive(BlockQuoteStart, tok.Data)
+			}
+		case style&BlockPreStart == BlockPreStart:
+			err = s.setQuote(level)
+			if err == nil {
+				s.pre = true
+				err = r.StartBlock(BlockPre, tok.Info)
+			}
+			if err == nil {
+				err = r.Directive(BlockPreStart, tok.Data)
+			}
+		case style&BlockPreEnd == BlockPreEnd:
+			err = s.setQuote(level)
+			if err == nil {
+				err = r.Directive(BlockPreEnd, tok.Data)
+			}
+			if err == nil {
+				err = s.closePre()
+			}
+		case style&SpanStartDirective != 0:
+			err = s.setQuote(level)
+			for _, span := range spanStarts {
+				if err != nil || style&span.start == 0 {
+					continue
+				}
+				s.spans = append(s.spans, span.span)
+				err = r.StartSpan(span.span)
+				if err == nil {
+					err = r.Directive(span.start, tok.Data)
+				}
+			}
+		case style&SpanEndDirective != 0:
+			err = s.setQuote(level)
+			for _, span := range spanStarts {
+				if err != nil || style&span.end == 0 {
+					continue
+				}
+				err = r.Directive(span.end, tok.Data)
+				if err == nil && len(s.spans) > 0 {
+					s.spans = s.spans[:len(s.spans)-1]
+					err = r.EndSpan(span.span)
+				}
+			}
+		default:
+			err = s.setQuote(level)
+			if err == nil {
+				err = r.Text(tok.Data)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Close anything left open at the end of the document (for example, a pre
+	// block without a closing fence).
+	err := s.closeSpans()
+	if err != nil {
+		return err
+	}
+	err = s.closePre()
+	if err != nil {
+		return err
+	}
+	err = s.setQuote(0)
+	if err != nil {
+		return err
+	}
+	if err = d.Err(); err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package styling_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp/styling"
+)
+
+var _ styling.Renderer = (*styling.HTMLRenderer)(nil)
+
+var htmlTestCases = [...]struct {
+	in         string
+	out        string
+	directives bool
+}{
+	0: {
+		in:  "one\nand <two>",
+		out: "one<br>\nand &lt;two&gt;",
+	},
+	1: {
+		in:  "a *b _c_ d* ~e~ `f *g*`",
+		out: "a <strong>b <em>c</em> d</strong> <s>e</s> <code>f *g*</code>",
+	},
+	2: {
+		in:  "> quote *b*\n>> nested\n> back\nplain",
+		out: "<blockquote>quote <strong>b</strong><br>\n<blockquote>nested</blockquote>back</blockquote>plain",
+	},
+	3: {
+		in:  "```go\nif a < b {\n}\n```\nafter",
+		out: "<pre data-info=\"go\">if a &lt; b {\n}</pre>after",
+	},
+	4: {
+		// Unterminated pre blocks inside of quotes are closed before the quote.
+		in:  "> ```\n> pre\nafter",
+		out: "<blockquote><pre>pre</pre></blockquote>after",
+	},
+	5: {
+		in:  ">> deep\nnone",
+		out: "<blockquote><blockquote>deep</blockquote></blockquote>none",
+	},
+	6: {
+		in:         "> *b*\n```\nx\n```",
+		out:        "<blockquote><span class=\"directive\">&gt; </span><strong><span class=\"directive\">*</span>b<span class=\"directive\">*</span></strong></blockquote><pre><span class=\"directive\">```</span>\nx\n<span class=\"directive\">```</span></pre>",
+		directives: true,
+	},
+	7: {
+		in:  "```\nunterminated",
+		out: "<pre>unterminated</pre>",
+	},
+}
+
+func TestRenderHTML(t *testing.T) {
+	for i, tc := range htmlTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var buf strings.Builder
+			r := styling.NewHTMLRenderer(&buf)
+			r.Directives = tc.directives
+			err := styling.Render(r, styling.NewDecoder(strings.NewReader(tc.in)))
+			if err != nil {
+				t.Fatalf("error rendering: %v", err)
+			}
+			if s := buf.String(); s != tc.out {
+				t.Errorf("wrong output:\nwant=%q,\n got=%q", tc.out, s)
+			}
+		})
+	}
+}
+
+type errWriter struct{}
+
+var errWrite = errors.New("write error")
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestRenderError(t *testing.T) {
+	err := styling.Render(styling.NewHTMLRenderer(errWriter{}), styling.NewDecoder(strings.NewReader("> *quote*")))
+	if !errors.Is(err, errWrite) {
+		t.Errorf("wrong error: want=%v, got=%v", errWrite, err)
+	}
+}
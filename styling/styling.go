@@ -8,10 +8,13 @@
 // Package styling implements XEP-0393: Message Styling, a simple styling
 // language.
 //
-// This package does not convert message styling documents into a format usable
-// by any other rendering engine (ie. HTML or LaTeX), instead it tokenizes the
-// input and provides you with a bitmask of styles that should be applied to
-// each token.
+// At its core this package tokenizes the input and provides you with a bitmask
+// of styles that should be applied to each token.
+// To convert message styling documents into a format usable by other rendering
+// engines (ie. HTML or LaTeX) the Render function can be used with a Renderer
+// that keeps track of the nesting of blocks and spans for you.
+// An HTML renderer is provided by this package.
+// To produce styled text programmatically an Encoder can be used.
 //
 // # Format
 //
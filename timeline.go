@@ -0,0 +1,166 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NegotiationStep records information about a single stream feature that was
+// negotiated while establishing a session.
+// It is meant to aid in debugging slow logins or misbehaving remote entities.
+type NegotiationStep struct {
+	// Feature is the name of the stream feature that was negotiated.
+	Feature xml.Name
+
+	// Start is the time at which negotiation of the feature began and Duration
+	// is how long it took.
+	Start    time.Time
+	Duration time.Duration
+
+	// Mask contains the state bits that were set by negotiating the feature.
+	Mask SessionState
+
+	// Restart is true if negotiating the feature caused the stream to be
+	// restarted.
+	Restart bool
+
+	// Err is any error returned while negotiating the feature.
+	Err error
+}
+
+// NegotiationTimeline returns a list of every stream feature that has been
+// negotiated on the session, in the order in which they were negotiated.
+func (s *Session) NegotiationTimeline() []NegotiationStep {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	timeline := make([]NegotiationStep, len(s.timeline))
+	copy(timeline, s.timeline)
+	return timeline
+}
+
+// StreamRestarts returns the number of times the stream has been restarted
+// as the result of negotiating a stream feature.
+func (s *Session) StreamRestarts() int {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	var n int
+	for _, step := range s.timeline {
+		if step.Restart {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Session) recordStep(step NegotiationStep) {
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	s.timeline = append(s.timeline, step)
+}
+
+// FeatureList records a stream features list that was sent or received while
+// establishing a session.
+type FeatureList struct {
+	// Received is true if the list was advertised by the remote entity and false
+	// if it was sent by the session.
+	Received bool
+
+	// State is the state of the session when the list was advertised.
+	State SessionState
+
+	// Features contains the features in the list in the order in which they were
+	// advertised.
+	Features []AdvertisedFeature
+}
+
+// AdvertisedFeature is a stream feature from a FeatureList.
+type AdvertisedFeature struct {
+	Name xml.Name
+
+	// Required is true if the feature was advertised as mandatory-to-negotiate.
+	// It is always false for features that are not supported.
+	Required bool
+
+	// Skipped is a short explanation of why the feature could not be selected
+	// for negotiation, or the empty string if it could be.
+	Skipped string
+}
+
+// FeatureLists returns every stream features list that has been sent or
+// received on the session, in the order in which they were advertised.
+// Along with NegotiationTimeline it can be used to find out why a remote
+// entity failed to negotiate a session.
+func (s *Session) FeatureLists() []FeatureList {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	lists := make([]FeatureList, len(s.featureLists))
+	copy(lists, s.featureLists)
+	return lists
+}
+
+func (s *Session) recordFeatures(list FeatureList) {
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	s.featureLists = append(s.featureLists, list)
+}
+
+// NegotiationError is returned when the remote entity advertises stream
+// features that the session supports but none of them can be negotiated, for
+// example because they were advertised in the wrong order.
+type NegotiationError struct {
+	// List is the features list that could not be negotiated.
+	List FeatureList
+
+	// Timeline contains the features that were negotiated before the list was
+	// received.
+	Timeline []NegotiationStep
+}
+
+// Error satisfies the error interface.
+// It includes the reason each feature was skipped.
+func (e *NegotiationError) Error() string {
+	var buf strings.Builder
+	buf.WriteString("xmpp: none of the advertised stream features could be negotiated")
+	for i, f := range e.List.Features {
+		if i == 0 {
+			buf.WriteString(": ")
+		} else {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "{%s}%s (%s)", f.Name.Space, f.Name.Local, f.Skipped)
+	}
+	return buf.String()
+}
+
+// skipReason returns the reason that feature cannot be selected for
+// negotiation in the given state or the empty string if it can be.
+func skipReason(feature StreamFeature, state SessionState, negotiated bool) string {
+	switch {
+	case state&feature.Necessary != feature.Necessary:
+		return "requires " + stateNames(feature.Necessary&^state)
+	case state&feature.Prohibited != 0:
+		return "prohibited by " + stateNames(feature.Prohibited&state)
+	case negotiated:
+		return "already negotiated"
+	case feature.Negotiate == nil:
+		return "informational"
+	}
+	return ""
+}
+
+// stateNames returns the names of the bits set in state separated by "|".
+func stateNames(state SessionState) string {
+	var names []string
+	for bit := SessionState(1); bit != 0; bit <<= 1 {
+		if state&bit != 0 {
+			names = append(names, bit.String())
+		}
+	}
+	return strings.Join(names, "|")
+}
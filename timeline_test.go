@@ -0,0 +1,128 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package xmpp_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/ns"
+	"mellium.im/xmpp/jid"
+)
+
+func TestNegotiationTimeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientConn, serverConn := net.Pipe()
+	clientJID := jid.MustParse("me@example.net")
+	negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+		return xmpp.StreamConfig{
+			Features: []xmpp.StreamFeature{xmpp.BindResource()},
+		}
+	})
+	serverC := make(chan *xmpp.Session, 1)
+	go func() {
+		s, err := xmpp.ReceiveSession(ctx, serverConn, xmpp.Secure|xmpp.Authn, negotiator)
+		if err != nil {
+			t.Errorf("error receiving session: %v", err)
+		}
+		serverC <- s
+	}()
+	s, err := xmpp.NewSession(ctx, clientJID.Domain(), clientJID, clientConn, xmpp.Secure|xmpp.Authn, negotiator)
+	if err != nil {
+		t.Fatalf("error negotiating session: %v", err)
+	}
+	serverSession := <-serverC
+
+	for _, sess := range []*xmpp.Session{s, serverSession} {
+		if sess == nil {
+			continue
+		}
+		timeline := sess.NegotiationTimeline()
+		if len(timeline) != 1 {
+			t.Fatalf("wrong number of steps: want=1, got=%d: %+v", len(timeline), timeline)
+		}
+		step := timeline[0]
+		if step.Feature.Space != ns.Bind || step.Feature.Local != "bind" {
+			t.Errorf("wrong feature: want={%s bind}, got=%v", ns.Bind, step.Feature)
+		}
+		if step.Err != nil {
+			t.Errorf("unexpected error: %v", step.Err)
+		}
+		if step.Restart {
+			t.Errorf("did not expect bind to restart the stream")
+		}
+		if step.Mask&xmpp.Ready != xmpp.Ready {
+			t.Errorf("expected bind to set the ready bit, got %v", step.Mask)
+		}
+		if step.Start.IsZero() || step.Duration < 0 {
+			t.Errorf("invalid timing: start=%v, duration=%v", step.Start, step.Duration)
+		}
+		if n := sess.StreamRestarts(); n != 0 {
+			t.Errorf("wrong number of restarts: want=0, got=%d", n)
+		}
+		want := []xmpp.FeatureList{{
+			Received: sess == s,
+			State:    sess.State() &^ xmpp.Ready,
+			Features: []xmpp.AdvertisedFeature{{
+				Name:     xml.Name{Space: ns.Bind, Local: "bind"},
+				Required: true,
+			}},
+		}}
+		if lists := sess.FeatureLists(); !reflect.DeepEqual(lists, want) {
+			t.Errorf("wrong feature lists:\nwant=%+v,\n got=%+v", want, lists)
+		}
+	}
+}
+
+func TestNegotiationError(t *testing.T) {
+	negotiator := xmpp.NewNegotiator(func(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+		return xmpp.StreamConfig{
+			Features: []xmpp.StreamFeature{xmpp.BindResource()},
+		}
+	})
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{
+		// Resource binding is advertised before the session is authenticated.
+		Reader: strings.NewReader(`<stream:stream id='1' version='1.0' xmlns:stream='http://etherx.jabber.org/streams' xmlns='jabber:client'><stream:features><ver xmlns='urn:xmpp:features:rosterver'/><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'/></stream:features>`),
+		Writer: io.Discard,
+	}
+	s, err := xmpp.NewSession(context.Background(), jid.JID{}, jid.JID{}, rw, xmpp.Secure, negotiator)
+	var negotiationErr *xmpp.NegotiationError
+	if !errors.As(err, &negotiationErr) {
+		t.Fatalf("wrong error type: want=%T, got=%T (%[2]v)", negotiationErr, err)
+	}
+	want := xmpp.FeatureList{
+		Received: true,
+		State:    xmpp.Secure,
+		Features: []xmpp.AdvertisedFeature{{
+			Name:    xml.Name{Space: "urn:xmpp:features:rosterver", Local: "ver"},
+			Skipped: "not supported",
+		}, {
+			Name:     xml.Name{Space: ns.Bind, Local: "bind"},
+			Required: true,
+			Skipped:  "requires Authn",
+		}},
+	}
+	if !reflect.DeepEqual(negotiationErr.List, want) {
+		t.Errorf("wrong features list in error:\nwant=%+v,\n got=%+v", want, negotiationErr.List)
+	}
+	if lists := s.FeatureLists(); !reflect.DeepEqual(lists, []xmpp.FeatureList{want}) {
+		t.Errorf("wrong features lists recorded on session: %+v", lists)
+	}
+	const errMsg = "xmpp: none of the advertised stream features could be negotiated: {urn:xmpp:features:rosterver}ver (not supported), {urn:ietf:params:xml:ns:xmpp-bind}bind (requires Authn)"
+	if err.Error() != errMsg {
+		t.Errorf("wrong error message:\nwant=%s,\n got=%s", errMsg, err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/aesgcm"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/oob"
+	"mellium.im/xmpp/stanza"
+)
+
+// UploadEncrypted encrypts the file read from r with a new random key,
+// requests a slot for it from the upload service to, and uploads the
+// ciphertext using client.
+// The returned URL uses the "aesgcm" scheme and contains the key needed to
+// decrypt the file as described in XEP-0454: OMEMO Media sharing.
+// If client is nil, http.DefaultClient is used.
+//
+// The entire file is read into memory to encrypt it.
+// To avoid revealing anything about the file to the upload service other than
+// its size, the slot is requested with a random file name and the type
+// "application/octet-stream".
+//
+// Anyone that sees the returned URL can decrypt the file so it must only be
+// shared over an end-to-end encrypted channel.
+func UploadEncrypted(ctx context.Context, r io.Reader, to jid.JID, s *xmpp.Session, client *http.Client) (*url.URL, error) {
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	key, err := aesgcm.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := key.Seal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := GetSlot(ctx, File{
+		Name: attr.RandomID(),
+		Size: len(ciphertext),
+		Type: encryptedType,
+	}, to, s)
+	if err != nil {
+		return nil, err
+	}
+	if slot.PutURL == nil || slot.GetURL == nil {
+		return nil, fmt.Errorf("upload: slot from %v is missing a URL", to)
+	}
+	req, err := slot.Put(ctx, bytes.NewReader(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(ciphertext))
+	req.Header.Set("Content-Type", encryptedType)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	/* #nosec */
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("upload: error uploading file: %s", resp.Status)
+	}
+	return key.URL(slot.GetURL), nil
+}
+
+// encryptedType is the content type used for all encrypted files so that the
+// type of the original file is not revealed to the upload service.
+const encryptedType = "application/octet-stream"
+
+// ErrNoEncryption is returned by SendEncryptedFile if no end-to-end encryption
+// layer is provided.
+var ErrNoEncryption = errors.New("upload: the key for an encrypted file must be sent end-to-end encrypted")
+
+// SendEncryptedFile is like UploadEncrypted except that it also sends the URL
+// to the recipient of msg in the body of the message and in an out-of-band
+// data element.
+// If the type of msg is not set, it is sent as a chat message.
+//
+// The URL contains the key needed to decrypt the file, so the message must be
+// end-to-end encrypted.
+// Before it is sent the message is passed through encrypt, which must return
+// the message encrypted for the recipient (for example, using OMEMO).
+// If encrypt is nil, ErrNoEncryption is returned and nothing is uploaded.
+func SendEncryptedFile(ctx context.Context, r io.Reader, to jid.JID, s *xmpp.Session, client *http.Client, msg stanza.Message, encrypt xmlstream.Transformer) (*url.URL, error) {
+	if encrypt == nil {
+		return nil, ErrNoEncryption
+	}
+	u, err := UploadEncrypted(ctx, r, to, s, client)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Type == "" {
+		msg.Type = stanza.ChatMessage
+	}
+	err = s.Send(ctx, encrypt(oob.Data{URL: u.String()}.Message(msg)))
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
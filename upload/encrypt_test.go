@@ -0,0 +1,146 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/upload"
+)
+
+func TestSendEncryptedFile(t *testing.T) {
+	uploaded := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("wrong method: want=%s, got=%s", http.MethodPut, r.Method)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Basic Zm9vOmJhcg==" {
+			t.Errorf("wrong authorization header: %q", auth)
+		}
+		if typ := r.Header.Get("Content-Type"); typ != "application/octet-stream" {
+			t.Errorf("wrong content type: want=application/octet-stream, got=%q", typ)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error reading upload: %v", err)
+		}
+		uploaded <- body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	plaintext := "To be, or not to be, that is the question"
+	size := strconv.Itoa(len(plaintext) + 16)
+	script := xmpptest.NewScript(t)
+	// The name and type of the file are not revealed to the upload service.
+	script.Expect("iq[@type='get']/request[@xmlns='" + upload.NS + "'][@size='" + size + "'][@content-type='application/octet-stream']").
+		ReplyFunc(func(el xmpptest.Element) string {
+			if name := el.Children[0].Get("filename"); name == "" || strings.Contains(name, "hamlet") {
+				t.Errorf("wrong file name sent to the upload service: %q", name)
+			}
+			return `<iq type="result" id="` + el.Get("id") + `" from="` + el.Get("to") + `" to="` + el.Get("from") + `"><slot xmlns="urn:xmpp:http:upload:0"><put url="` + srv.URL + `/put/hamlet.txt"><header name="Authorization">Basic Zm9vOmJhcg==</header></put><get url="` + srv.URL + `/get/hamlet.txt"></get></slot></iq>`
+		})
+	bodies := make(chan string, 1)
+	script.Expect("message[@type='chat'][@to='ophelia@example.net']").
+		ReplyFunc(func(el xmpptest.Element) string {
+			for _, child := range el.Children {
+				if child.XMLName.Local == "body" {
+					bodies <- child.Text
+				}
+			}
+			return ""
+		})
+	s := script.ClientServer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var encrypted bool
+	u, err := upload.SendEncryptedFile(ctx,
+		strings.NewReader(plaintext),
+		jid.MustParse("upload.example.net"),
+		s.Client,
+		srv.Client(),
+		stanza.Message{To: jid.MustParse("ophelia@example.net")},
+		func(r xml.TokenReader) xml.TokenReader {
+			// A real implementation would encrypt the message here.
+			encrypted = true
+			return r
+		},
+	)
+	if err != nil {
+		t.Fatalf("error sending file: %v", err)
+	}
+	if !encrypted {
+		t.Errorf("message was not passed through the encryption layer")
+	}
+	err = script.Wait(ctx)
+	if err != nil {
+		t.Fatalf("error waiting for script: %v", err)
+	}
+
+	if u.Scheme != "aesgcm" {
+		t.Errorf("wrong scheme: want=aesgcm, got=%s", u.Scheme)
+	}
+	if !strings.HasSuffix(u.Path, "/get/hamlet.txt") {
+		t.Errorf("wrong path: %s", u.Path)
+	}
+	if body := <-bodies; body != u.String() {
+		t.Errorf("wrong message body: want=%s, got=%s", u, body)
+	}
+
+	// Decrypt the uploaded file using the key from the URL.
+	keyData, err := hex.DecodeString(u.Fragment)
+	if err != nil {
+		t.Fatalf("error decoding key: %v", err)
+	}
+	if len(keyData) != 44 {
+		t.Fatalf("wrong key length: want=44, got=%d", len(keyData))
+	}
+	block, err := aes.NewCipher(keyData[12:])
+	if err != nil {
+		t.Fatalf("error creating cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("error creating AEAD: %v", err)
+	}
+	out, err := aead.Open(nil, keyData[:12], <-uploaded, nil)
+	if err != nil {
+		t.Fatalf("error decrypting upload: %v", err)
+	}
+	if !bytes.Equal(out, []byte(plaintext)) {
+		t.Errorf("wrong plaintext: want=%q, got=%q", plaintext, out)
+	}
+}
+
+func TestSendEncryptedFileNoEncryption(t *testing.T) {
+	_, err := upload.SendEncryptedFile(context.Background(),
+		strings.NewReader("To be, or not to be"),
+		jid.MustParse("upload.example.net"),
+		nil,
+		nil,
+		stanza.Message{To: jid.MustParse("ophelia@example.net")},
+		nil,
+	)
+	if !errors.Is(err, upload.ErrNoEncryption) {
+		t.Errorf("wrong error: want=%v, got=%v", upload.ErrNoEncryption, err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
 	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/oob"
 	"mellium.im/xmpp/stanza"
 )
 
@@ -85,6 +86,12 @@ type Slot struct {
 	// The only valid headers are "Authorization", "Cookie", and "Expires".
 	// All other headers will be ignored.
 	Header http.Header
+
+	// File is the file that the slot was requested for.
+	// It is set by GetSlot and is used by Uploader to make sure that the
+	// uploaded data matches the size and type that the server agreed to.
+	// It is not part of the slot's XML representation.
+	File File
 }
 
 func allowedHeader(name string) bool {
@@ -99,6 +106,9 @@ func (s Slot) Put(ctx context.Context, body io.Reader) (*http.Request, error) {
 		return nil, err
 	}
 	headers := s.Header.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
 	for name := range headers {
 		if !allowedHeader(http.CanonicalHeaderKey(name)) {
 			headers.Del(name)
@@ -108,6 +118,19 @@ func (s Slot) Put(ctx context.Context, body io.Reader) (*http.Request, error) {
 	return req, nil
 }
 
+// OOB returns out of band data that points to the uploaded file.
+// It can be used to send the file to another entity once the upload is
+// complete:
+//
+//	err := oob.Send(ctx, session, msg, slot.OOB(""))
+func (s Slot) OOB(desc string) oob.Data {
+	var link string
+	if s.GetURL != nil {
+		link = s.GetURL.String()
+	}
+	return oob.Data{URL: link, Desc: desc}
+}
+
 func marshalHeaders(h http.Header) xml.TokenReader {
 	var headers []xml.TokenReader
 	for name, vals := range h {
@@ -236,5 +259,6 @@ func GetSlotIQ(ctx context.Context, f File, iq stanza.IQ, s *xmpp.Session) (Slot
 	iq.Type = stanza.GetIQ
 	var slot Slot
 	err := s.UnmarshalIQElement(ctx, f.TokenReader(), iq, &slot)
+	slot.File = f
 	return slot, err
 }
@@ -0,0 +1,282 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by Uploader.
+var (
+	ErrNoPutURL = errors.New("upload: slot has no put URL")
+	ErrNoSize   = errors.New("upload: slot has no file size")
+	ErrSize     = errors.New("upload: data does not match the requested file size")
+)
+
+// Defaults used when the corresponding fields of Uploader are not set.
+const (
+	DefaultMinBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// StatusError is returned by Uploader when the HTTP server responds with an
+// unexpected status code.
+type StatusError struct {
+	StatusCode int
+}
+
+// Error satisfies the error interface.
+func (e StatusError) Error() string {
+	return fmt.Sprintf("upload: unexpected HTTP status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// temporary reports whether retrying the request may succeed.
+func (e StatusError) temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+}
+
+// Uploader uploads files to slots.
+// The zero value uploads the entire file in a single request and does not
+// retry if the request fails.
+type Uploader struct {
+	// Client is the HTTP client used to make requests.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Retries is the number of times the upload will be retried after a network
+	// error or a server error that may be temporary.
+	// Retries are delayed starting at MinBackoff and doubling after each
+	// consecutive failure up to MaxBackoff.
+	Retries    int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Resumable indicates that the server supports resuming uploads.
+	// When an upload fails, the uploader sends an empty put request with the
+	// header "Content-Range: bytes */<size>" and expects a 308 response with a
+	// "Range" header indicating how much of the file was received.
+	// The upload is then continued from that point using a Content-Range header
+	// instead of starting over.
+	// If the server does not support resumable uploads, setting Resumable may
+	// cause the file to be overwritten with an empty file.
+	Resumable bool
+
+	// ChunkSize, if greater than zero, splits the upload into multiple put
+	// requests of at most ChunkSize bytes each using the Content-Range header.
+	// Servers are expected to respond to each chunk but the last with a 308
+	// status code.
+	// It should only be used if Resumable is also set.
+	ChunkSize int64
+
+	// Progress, if set, is called as data is sent with the number of bytes of
+	// the file that have been sent so far and the size of the file.
+	// If an upload is restarted the number of bytes sent may go down.
+	// Progress may be called from a goroutine other than the one that called
+	// Upload, but it is never called concurrently with itself.
+	Progress func(sent, total int64)
+}
+
+// Upload sends the contents of r to the slot.
+// Exactly the number of bytes in the file that the slot was requested for are
+// read from r and the content type is set to the type of the file, since
+// servers are free to reject uploads that do not match the original request.
+// If r contains less data, or the size of the file is not known, an error is
+// returned.
+//
+// Because failed uploads may be restarted, r is an io.ReaderAt (for example
+// *os.File) and not a stream.
+func (u Uploader) Upload(ctx context.Context, slot Slot, r io.ReaderAt) error {
+	if slot.PutURL == nil {
+		return ErrNoPutURL
+	}
+	if slot.File.Size <= 0 {
+		return ErrNoSize
+	}
+	total := int64(slot.File.Size)
+	var offset int64
+	retries := 0
+	minBackoff := u.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	maxBackoff := u.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	backoff := minBackoff
+	for {
+		end := total
+		if u.ChunkSize > 0 && total-offset > u.ChunkSize {
+			end = offset + u.ChunkSize
+		}
+		next, err := u.put(ctx, slot, r, offset, end)
+		if err == nil {
+			if next >= total {
+				return nil
+			}
+			offset = next
+			retries = 0
+			backoff = minBackoff
+			continue
+		}
+		if !u.temporary(ctx, err) || retries >= u.Retries {
+			return err
+		}
+		retries++
+		timer := time.NewTimer(min(backoff, maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		offset = 0
+		if u.Resumable {
+			offset, err = u.received(ctx, slot)
+			if err != nil {
+				return err
+			}
+			if offset >= total {
+				return nil
+			}
+		}
+	}
+}
+
+func (u Uploader) client() *http.Client {
+	if u.Client == nil {
+		return http.DefaultClient
+	}
+	return u.Client
+}
+
+func (u Uploader) temporary(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrSize) {
+		return false
+	}
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.temporary()
+	}
+	// Any other error came from the transport and is assumed to be a network
+	// error that may go away.
+	return true
+}
+
+// put uploads the bytes in [offset, end) and returns the offset at which the
+// next request should start.
+func (u Uploader) put(ctx context.Context, slot Slot, r io.ReaderAt, offset, end int64) (int64, error) {
+	total := int64(slot.File.Size)
+	body := &progressReader{
+		r:        io.NewSectionReader(r, offset, end-offset),
+		sent:     offset,
+		total:    total,
+		progress: u.Progress,
+	}
+	req, err := slot.Put(ctx, body)
+	if err != nil {
+		return offset, err
+	}
+	req.ContentLength = end - offset
+	if req.ContentLength == 0 {
+		req.Body = http.NoBody
+	}
+	if slot.File.Type != "" {
+		req.Header.Set("Content-Type", slot.File.Type)
+	}
+	if offset > 0 || end < total {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, end-1, total))
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return offset, err
+	}
+	/* #nosec */
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPermanentRedirect:
+		// The server has accepted the chunk but expects more data.
+		if n, ok := parseRange(resp.Header.Get("Range")); ok {
+			return n, nil
+		}
+		return end, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return end, nil
+	}
+	return offset, StatusError{StatusCode: resp.StatusCode}
+}
+
+// received asks the server how much of the file it has received.
+func (u Uploader) received(ctx context.Context, slot Slot) (int64, error) {
+	req, err := slot.Put(ctx, http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = 0
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", slot.File.Size))
+	resp, err := u.client().Do(req)
+	if err != nil {
+		// The status check is only an optimization, so if it fails start over.
+		return 0, nil
+	}
+	/* #nosec */
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPermanentRedirect:
+		n, _ := parseRange(resp.Header.Get("Range"))
+		return n, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// The upload had already completed.
+		return int64(slot.File.Size), nil
+	}
+	return 0, nil
+}
+
+// parseRange returns the offset after the last byte in a range header of the
+// form "bytes=0-N".
+func parseRange(h string) (int64, bool) {
+	rng, ok := strings.CutPrefix(h, "bytes=0-")
+	if !ok {
+		return 0, false
+	}
+	last, err := strconv.ParseInt(rng, 10, 64)
+	if err != nil || last < 0 {
+		return 0, false
+	}
+	return last + 1, true
+}
+
+// progressReader reports the number of bytes read and returns ErrSize if the
+// underlying reader ends early.
+type progressReader struct {
+	r        *io.SectionReader
+	read     int64
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 && p.progress != nil {
+		p.progress(p.sent+p.read, p.total)
+	}
+	if err == io.EOF && p.read < p.r.Size() {
+		return n, ErrSize
+	}
+	return n, err
+}
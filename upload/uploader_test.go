@@ -0,0 +1,202 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mellium.im/xmpp/upload"
+)
+
+const uploadData = "Wherefore art thou Romeo?"
+
+// resumableServer is an HTTP server that supports resumable uploads.
+// The first failAfter requests only store part of the body and then fail.
+type resumableServer struct {
+	mu        sync.Mutex
+	data      []byte
+	ranges    []string
+	failAfter int
+	status    int
+}
+
+func (s *resumableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	rng := r.Header.Get("Content-Range")
+	s.ranges = append(s.ranges, rng)
+	if rng == fmt.Sprintf("bytes */%d", len(uploadData)) {
+		if len(s.data) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.data)-1))
+		}
+		w.WriteHeader(http.StatusPermanentRedirect)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "text/plain" {
+		http.Error(w, "wrong content type "+ct, http.StatusBadRequest)
+		return
+	}
+	var start int
+	if rng != "" {
+		_, err := fmt.Sscanf(rng, "bytes %d-", &start)
+		if err != nil || start != len(s.data) {
+			http.Error(w, "bad range "+rng, http.StatusBadRequest)
+			return
+		}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.failAfter > 0 {
+		s.failAfter--
+		s.data = append(s.data, body[:len(body)/2]...)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	s.data = append(s.data, body...)
+	if len(s.data) < len(uploadData) {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.data)-1))
+		w.WriteHeader(http.StatusPermanentRedirect)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newSlot(t *testing.T, h http.Handler) upload.Slot {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return upload.Slot{
+		PutURL: mustParseURL(srv.URL + "/file.txt"),
+		File:   upload.File{Name: "file.txt", Size: len(uploadData), Type: "text/plain"},
+	}
+}
+
+func TestUpload(t *testing.T) {
+	srv := &resumableServer{}
+	slot := newSlot(t, srv)
+	var sent, total int64
+	err := upload.Uploader{
+		Progress: func(s, t int64) {
+			sent, total = s, t
+		},
+	}.Upload(context.Background(), slot, strings.NewReader(uploadData))
+	if err != nil {
+		t.Fatalf("error uploading: %v", err)
+	}
+	if string(srv.data) != uploadData {
+		t.Errorf("wrong data uploaded: want=%q, got=%q", uploadData, srv.data)
+	}
+	if sent != int64(len(uploadData)) || total != int64(len(uploadData)) {
+		t.Errorf("wrong progress: sent=%d, total=%d", sent, total)
+	}
+}
+
+func TestUploadResume(t *testing.T) {
+	srv := &resumableServer{failAfter: 1}
+	slot := newSlot(t, srv)
+	err := upload.Uploader{
+		Retries:    1,
+		MinBackoff: time.Millisecond,
+		Resumable:  true,
+	}.Upload(context.Background(), slot, strings.NewReader(uploadData))
+	if err != nil {
+		t.Fatalf("error uploading: %v", err)
+	}
+	if string(srv.data) != uploadData {
+		t.Errorf("wrong data uploaded: want=%q, got=%q", uploadData, srv.data)
+	}
+	half := len(uploadData) / 2
+	want := fmt.Sprintf(",bytes */%d,bytes %d-%d/%[1]d", len(uploadData), half, len(uploadData)-1)
+	if got := strings.Join(srv.ranges, ","); got != want {
+		t.Errorf("wrong ranges: want=%q, got=%q", want, got)
+	}
+}
+
+func TestUploadChunks(t *testing.T) {
+	srv := &resumableServer{}
+	slot := newSlot(t, srv)
+	err := upload.Uploader{
+		Resumable: true,
+		ChunkSize: 10,
+	}.Upload(context.Background(), slot, strings.NewReader(uploadData))
+	if err != nil {
+		t.Fatalf("error uploading: %v", err)
+	}
+	if string(srv.data) != uploadData {
+		t.Errorf("wrong data uploaded: want=%q, got=%q", uploadData, srv.data)
+	}
+	const want = "bytes 0-9/25,bytes 10-19/25,bytes 20-24/25"
+	if got := strings.Join(srv.ranges, ","); got != want {
+		t.Errorf("wrong ranges: want=%q, got=%q", want, got)
+	}
+}
+
+func TestUploadErrors(t *testing.T) {
+	t.Run("retries exhausted", func(t *testing.T) {
+		srv := &resumableServer{failAfter: 2}
+		err := upload.Uploader{Retries: 1, MinBackoff: time.Millisecond}.Upload(context.Background(), newSlot(t, srv), strings.NewReader(uploadData))
+		var statusErr upload.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("wrong error: %v", err)
+		}
+	})
+	t.Run("permanent", func(t *testing.T) {
+		srv := &resumableServer{status: http.StatusForbidden}
+		err := upload.Uploader{Retries: 5}.Upload(context.Background(), newSlot(t, srv), strings.NewReader(uploadData))
+		var statusErr upload.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+			t.Errorf("wrong error: %v", err)
+		}
+		if len(srv.ranges) != 0 {
+			t.Errorf("permanent error should not be retried")
+		}
+	})
+	t.Run("short", func(t *testing.T) {
+		srv := &resumableServer{}
+		err := upload.Uploader{Retries: 5}.Upload(context.Background(), newSlot(t, srv), bytes.NewReader([]byte("short")))
+		if !errors.Is(err, upload.ErrSize) {
+			t.Errorf("wrong error: want=%v, got=%v", upload.ErrSize, err)
+		}
+	})
+	t.Run("canceled during backoff", func(t *testing.T) {
+		srv := &resumableServer{failAfter: 1}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := upload.Uploader{Retries: 1, MinBackoff: time.Minute}.Upload(ctx, newSlot(t, srv), strings.NewReader(uploadData))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("wrong error: want=%v, got=%v", context.DeadlineExceeded, err)
+		}
+	})
+	t.Run("no size", func(t *testing.T) {
+		slot := newSlot(t, &resumableServer{})
+		slot.File.Size = 0
+		err := upload.Uploader{}.Upload(context.Background(), slot, strings.NewReader(uploadData))
+		if !errors.Is(err, upload.ErrNoSize) {
+			t.Errorf("wrong error: want=%v, got=%v", upload.ErrNoSize, err)
+		}
+	})
+	t.Run("no URL", func(t *testing.T) {
+		err := upload.Uploader{}.Upload(context.Background(), upload.Slot{}, strings.NewReader(uploadData))
+		if !errors.Is(err, upload.ErrNoPutURL) {
+			t.Errorf("wrong error: want=%v, got=%v", upload.ErrNoPutURL, err)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package uri
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"mellium.im/xmpp/jid"
+)
+
+// Characters other than the unreserved characters that are allowed unescaped in
+// each part of an XMPP URI as defined in RFC 5122 §2.2.
+const (
+	nodeAllow   = "!$()*+,;="
+	domainAllow = "[]:"
+	resAllow    = "!$&'()*+,:;="
+	queryAllow  = "!$'()*,:@/?"
+)
+
+// New returns a URI that identifies the address to and, if action is not
+// empty, a query component with the action followed by the parameters sorted
+// by key.
+// Any characters that are not allowed in the address or query are
+// percent-encoded.
+func New(to jid.JID, action string, params url.Values) *URI {
+	return build(jid.JID{}, to, action, params)
+}
+
+// NewAuth is like New except that it includes an authentication address that
+// should be used to perform the action.
+func NewAuth(auth, to jid.JID, action string, params url.Values) *URI {
+	return build(auth, to, action, params)
+}
+
+// Message returns a URI with the "message" action defined in XEP-0147.
+// If subject or body are empty they are not included.
+func Message(to jid.JID, subject, body string) *URI {
+	params := make(url.Values)
+	if subject != "" {
+		params.Set("subject", subject)
+	}
+	if body != "" {
+		params.Set("body", body)
+	}
+	return New(to, "message", params)
+}
+
+// Join returns a URI with the "join" action for joining the multi-user chat
+// room.
+// If password is empty it is not included.
+func Join(room jid.JID, password string) *URI {
+	params := make(url.Values)
+	if password != "" {
+		params.Set("password", password)
+	}
+	return New(room, "join", params)
+}
+
+// Roster returns a URI with the "roster" action for adding the address to the
+// roster.
+// If name or group are empty they are not included.
+func Roster(to jid.JID, name, group string) *URI {
+	params := make(url.Values)
+	if name != "" {
+		params.Set("name", name)
+	}
+	if group != "" {
+		params.Set("group", group)
+	}
+	return New(to, "roster", params)
+}
+
+func build(auth, to jid.JID, action string, params url.Values) *URI {
+	var b strings.Builder
+	if action != "" {
+		escape(&b, action, queryAllow)
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range params[k] {
+				b.WriteByte(';')
+				escape(&b, k, queryAllow)
+				b.WriteByte('=')
+				escape(&b, v, queryAllow)
+			}
+		}
+	}
+
+	uri := &URI{
+		ToAddr:   to,
+		AuthAddr: auth.Bare(),
+		Action:   action,
+	}
+	raw := uri.marshal(b.String(), "")
+
+	// We just escaped everything ourselves so the URL should always parse, if it
+	// doesn't it's a bug in this package.
+	u, err := url.Parse(raw)
+	if err != nil {
+		panic(fmt.Errorf("uri: built invalid URI %q: %w", raw, err))
+	}
+	uri.URL = u
+	return uri
+}
+
+// marshal assembles a URI from the addresses and the already escaped query and
+// fragment.
+// The url package is not used for this because it escapes characters in the
+// authentication address that are allowed by RFC 5122.
+func (u *URI) marshal(rawQuery, rawFragment string) string {
+	var b strings.Builder
+	b.WriteString("xmpp:")
+	if !u.AuthAddr.Equal(jid.JID{}) {
+		b.WriteString("//")
+		writeJID(&b, u.AuthAddr)
+		b.WriteByte('/')
+	}
+	writeJID(&b, u.ToAddr)
+	if rawQuery != "" {
+		b.WriteByte('?')
+		b.WriteString(rawQuery)
+	}
+	if rawFragment != "" {
+		b.WriteByte('#')
+		b.WriteString(rawFragment)
+	}
+	return b.String()
+}
+
+func writeJID(b *strings.Builder, j jid.JID) {
+	if local := j.Localpart(); local != "" {
+		escape(b, local, nodeAllow)
+		b.WriteByte('@')
+	}
+	escape(b, j.Domainpart(), domainAllow)
+	if res := j.Resourcepart(); res != "" {
+		b.WriteByte('/')
+		escape(b, res, resAllow)
+	}
+}
+
+// escape percent-encodes any byte in s that is not an unreserved character or
+// in allow.
+func escape(b *strings.Builder, s, allow string) {
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c < 0x80 && strings.IndexByte(allow, c) != -1:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		}
+	}
+}
+
+// MarshalText satisfies encoding.TextMarshaler by returning the URI with all
+// non-ASCII characters percent-encoded.
+// For the IRI form use String.
+func (u *URI) MarshalText() ([]byte, error) {
+	if u.URL == nil {
+		return []byte(u.marshal("", "")), nil
+	}
+	return []byte(u.marshal(u.RawQuery, u.EscapedFragment())), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler by parsing the text as a
+// URI or IRI.
+func (u *URI) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*u = *parsed
+	return nil
+}
+
+// Query parses the query component of the URI and returns the values.
+// Unlike the method on url.URL which it shadows, pairs may be separated by ";"
+// as described in XEP-0147 as well as by "&".
+// Malformed pairs are discarded.
+func (u *URI) Query() url.Values {
+	v := make(url.Values)
+	query := u.RawQuery
+	for query != "" {
+		var pair string
+		idx := strings.IndexAny(query, ";&")
+		if idx == -1 {
+			pair, query = query, ""
+		} else {
+			pair, query = query[:idx], query[idx+1:]
+		}
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			continue
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+		v[key] = append(v[key], value)
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package uri_test
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/uri"
+)
+
+var (
+	_ encoding.TextMarshaler   = (*uri.URI)(nil)
+	_ encoding.TextUnmarshaler = (*uri.URI)(nil)
+)
+
+var buildTests = [...]struct {
+	u      *uri.URI
+	raw    string
+	iri    string
+	values url.Values
+}{
+	0: {
+		u:   uri.New(jid.MustParse("feste@example.net"), "", nil),
+		raw: "xmpp:feste@example.net",
+	},
+	1: {
+		u:   uri.New(jid.MustParse("node@example.com/repulsive !#\"$%&'()*+,-./:;<=>?@[\\]^_`{|}~resource"), "", nil),
+		raw: "xmpp:node@example.com/repulsive%20!%23%22$%25&'()*+,-.%2F:;%3C=%3E%3F%40%5B%5C%5D%5E_%60%7B%7C%7D~resource",
+		iri: "xmpp:node@example.com/repulsive !#\"$%&'()*+,-./:;<=>?@[\\]^_`{|}~resource",
+	},
+	2: {
+		u:   uri.NewAuth(jid.MustParse("nasty!#$%()*+,-.;=?[\\]^_`{|}~node@example.com/ignored"), jid.MustParse("node@example.com"), "", nil),
+		raw: "xmpp://nasty!%23$%25()*+,-.;=%3F%5B%5C%5D%5E_%60%7B%7C%7D~node@example.com/node@example.com",
+		iri: "xmpp://nasty!#$%()*+,-.;=?[\\]^_`{|}~node@example.com/node@example.com",
+	},
+	3: {
+		u:      uri.Message(jid.MustParse("romeo@montague.net"), "Test Message", "Here's a test; it's 1+1=2 & more"),
+		raw:    "xmpp:romeo@montague.net?message;body=Here's%20a%20test%3B%20it's%201%2B1%3D2%20%26%20more;subject=Test%20Message",
+		iri:    "xmpp:romeo@montague.net?message;body=Here's a test; it's 1+1=2 & more;subject=Test Message",
+		values: url.Values{"message": {""}, "body": {"Here's a test; it's 1+1=2 & more"}, "subject": {"Test Message"}},
+	},
+	4: {
+		u:      uri.Join(jid.MustParse("darkcave@chat.shakespeare.lit"), "cauldronburn"),
+		raw:    "xmpp:darkcave@chat.shakespeare.lit?join;password=cauldronburn",
+		values: url.Values{"join": {""}, "password": {"cauldronburn"}},
+	},
+	5: {
+		u:      uri.Join(jid.MustParse("darkcave@chat.shakespeare.lit"), ""),
+		raw:    "xmpp:darkcave@chat.shakespeare.lit?join",
+		values: url.Values{"join": {""}},
+	},
+	6: {
+		u:      uri.Roster(jid.MustParse("romeo@montague.net"), "Romeo", "Friends"),
+		raw:    "xmpp:romeo@montague.net?roster;group=Friends;name=Romeo",
+		values: url.Values{"roster": {""}, "group": {"Friends"}, "name": {"Romeo"}},
+	},
+	7: {
+		u:   uri.New(jid.MustParse("example.org/Dürst"), "", nil),
+		raw: "xmpp:example.org/D%C3%BCrst",
+		iri: "xmpp:example.org/Dürst",
+	},
+}
+
+func TestBuild(t *testing.T) {
+	for i, tc := range buildTests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			text, err := tc.u.MarshalText()
+			if err != nil {
+				t.Fatalf("error marshaling: %v", err)
+			}
+			if string(text) != tc.raw {
+				t.Errorf("wrong URI:\nwant=%s\n got=%s", tc.raw, text)
+			}
+			if tc.iri == "" {
+				tc.iri = tc.raw
+			}
+			if iri := tc.u.String(); iri != tc.iri {
+				t.Errorf("wrong IRI:\nwant=%s\n got=%s", tc.iri, iri)
+			}
+
+			var parsed uri.URI
+			err = parsed.UnmarshalText(text)
+			if err != nil {
+				t.Fatalf("error parsing built URI: %v", err)
+			}
+			if !parsed.ToAddr.Equal(tc.u.ToAddr) {
+				t.Errorf("wrong recipient after round trip: want=%v, got=%v", tc.u.ToAddr, parsed.ToAddr)
+			}
+			if !parsed.AuthAddr.Equal(tc.u.AuthAddr) {
+				t.Errorf("wrong auth address after round trip: want=%v, got=%v", tc.u.AuthAddr, parsed.AuthAddr)
+			}
+			if parsed.Action != tc.u.Action {
+				t.Errorf("wrong action after round trip: want=%q, got=%q", tc.u.Action, parsed.Action)
+			}
+			if tc.values == nil {
+				tc.values = url.Values{}
+			}
+			if values := parsed.Query(); !reflect.DeepEqual(values, tc.values) {
+				t.Errorf("wrong query after round trip: want=%v, got=%v", tc.values, values)
+			}
+		})
+	}
+}
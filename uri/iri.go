@@ -42,9 +42,6 @@ type URI struct {
 	Action string
 }
 
-// TODO: encoding and escaping, see
-// https://tools.ietf.org/html/rfc5122#section-2.7.2
-
 // Parse parses rawuri into a URI structure.
 func Parse(rawuri string) (*URI, error) {
 	u, err := url.Parse(rawuri)
@@ -92,9 +89,15 @@ func Parse(rawuri string) (*URI, error) {
 		}
 	}
 
-	for k, v := range u.Query() {
-		if len(v) == 0 || len(v) == 1 && v[0] == "" {
-			uri.Action = k
+	// Query components may be separated by ";" as in XEP-0147 or by "&".
+	for _, pair := range strings.FieldsFunc(u.RawQuery, func(r rune) bool {
+		return r == ';' || r == '&'
+	}) {
+		if key, value, _ := strings.Cut(pair, "="); value == "" {
+			uri.Action, err = url.QueryUnescape(key)
+			if err != nil {
+				return nil, err
+			}
 			break
 		}
 	}
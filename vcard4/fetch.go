@@ -0,0 +1,87 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package vcard4
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+)
+
+// Fetch requests the vCard of the given user.
+// If the user has not published a vCard4 or the server does not support it,
+// Fetch falls back to requesting the legacy vcard-temp.
+func Fetch(ctx context.Context, s *xmpp.Session, j jid.JID) (VCard, error) {
+	return FetchIQ(ctx, stanza.IQ{To: j}, s)
+}
+
+// FetchIQ is like Fetch except that it allows modifying the IQ.
+// The IQ should be addressed to the bare JID of the user.
+// Changing the type of the provided IQ has no effect.
+func FetchIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session) (VCard, error) {
+	v, found, err := fetchPEP(ctx, iq, s)
+	var stanzaErr stanza.Error
+	switch {
+	case err == nil && found:
+		return v, nil
+	case err != nil && !errors.As(err, &stanzaErr):
+		return v, err
+	}
+	return FetchLegacyIQ(ctx, iq, s)
+}
+
+func fetchPEP(ctx context.Context, iq stanza.IQ, s *xmpp.Session) (VCard, bool, error) {
+	var v VCard
+	iter := pubsub.FetchIQ(ctx, iq, s, pubsub.Query{
+		Node: NSNode,
+		Item: ItemID,
+	})
+	/* #nosec */
+	defer iter.Close()
+	if iter.Next() {
+		_, r := iter.Item()
+		err := xml.NewTokenDecoder(r).Decode(&v)
+		return v, err == nil, err
+	}
+	return v, false, iter.Err()
+}
+
+// FetchLegacy requests the legacy vcard-temp of the given user without first
+// checking for a vCard4.
+func FetchLegacy(ctx context.Context, s *xmpp.Session, j jid.JID) (VCard, error) {
+	return FetchLegacyIQ(ctx, stanza.IQ{To: j}, s)
+}
+
+// FetchLegacyIQ is like FetchLegacy except that it allows modifying the IQ.
+// Changing the type of the provided IQ has no effect.
+func FetchLegacyIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session) (VCard, error) {
+	iq.Type = stanza.GetIQ
+	var v VCard
+	err := s.UnmarshalIQElement(ctx, xmlstream.Wrap(
+		nil,
+		xml.StartElement{Name: xml.Name{Space: NSLegacy, Local: "vCard"}},
+	), iq, &v)
+	return v, err
+}
+
+// Publish stores the vCard in the user's PEP node, replacing any existing
+// vCard.
+func Publish(ctx context.Context, s *xmpp.Session, v VCard) error {
+	return PublishIQ(ctx, s, stanza.IQ{}, v)
+}
+
+// PublishIQ is like Publish except that it allows modifying the IQ.
+// Changes to the IQ type will have no effect.
+func PublishIQ(ctx context.Context, s *xmpp.Session, iq stanza.IQ, v VCard) error {
+	iq.Type = stanza.SetIQ
+	_, err := pubsub.PublishIQ(ctx, s, iq, NSNode, ItemID, v.TokenReader())
+	return err
+}
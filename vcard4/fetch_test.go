@@ -0,0 +1,120 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package vcard4_test
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/pubsub"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/vcard4"
+)
+
+// serve returns a server handler that responds to vCard4 requests with
+// pubsubResp, or with an error if it is empty, and to legacy requests with
+// legacyResp.
+// The names of the requested payloads are recorded.
+func serve(pubsubResp, legacyResp string, got *[]string) xmpptest.Option {
+	return xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		tok, err := t.Token()
+		if err != nil {
+			return err
+		}
+		payload := tok.(xml.StartElement)
+		*got = append(*got, payload.Name.Space)
+		var resp xml.TokenReader
+		switch payload.Name.Space {
+		case pubsub.NS:
+			if pubsubResp == "" {
+				resp = iq.Error(stanza.Error{Type: stanza.Cancel, Condition: stanza.ItemNotFound})
+			} else {
+				resp = iq.Result(xml.NewDecoder(strings.NewReader(pubsubResp)))
+			}
+		case vcard4.NSLegacy:
+			resp = iq.Result(xml.NewDecoder(strings.NewReader(legacyResp)))
+		default:
+			resp = iq.Error(stanza.Error{Type: stanza.Cancel, Condition: stanza.FeatureNotImplemented})
+		}
+		_, err = xmlstream.Copy(t, resp)
+		return err
+	})
+}
+
+func TestFetch(t *testing.T) {
+	var got []string
+	s := xmpptest.NewClientServer(serve(`<pubsub xmlns="http://jabber.org/protocol/pubsub"><items node="urn:xmpp:vcard4"><item id="current">`+romeoXML+`</item></items></pubsub>`, "", &got))
+	v, err := vcard4.Fetch(context.Background(), s.Client, s.Server.LocalAddr())
+	if err != nil {
+		t.Fatalf("error fetching vCard: %v", err)
+	}
+	if !reflect.DeepEqual(v, romeo) {
+		t.Errorf("wrong vCard:\nwant=%+v,\n got=%+v", romeo, v)
+	}
+	if s := strings.Join(got, ","); s != pubsub.NS {
+		t.Errorf("wrong requests: want=%s, got=%s", pubsub.NS, s)
+	}
+}
+
+func TestFetchFallback(t *testing.T) {
+	for name, pubsubResp := range map[string]string{
+		"error": "",
+		"empty": `<pubsub xmlns="http://jabber.org/protocol/pubsub"><items node="urn:xmpp:vcard4"/></pubsub>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			s := xmpptest.NewClientServer(serve(pubsubResp, `<vCard xmlns="vcard-temp"><FN>Juliet Capulet</FN></vCard>`, &got))
+			v, err := vcard4.Fetch(context.Background(), s.Client, s.Server.LocalAddr())
+			if err != nil {
+				t.Fatalf("error fetching vCard: %v", err)
+			}
+			if v.FullName != "Juliet Capulet" {
+				t.Errorf("wrong vCard: %+v", v)
+			}
+			if s, want := strings.Join(got, ","), pubsub.NS+","+vcard4.NSLegacy; s != want {
+				t.Errorf("wrong requests: want=%s, got=%s", want, s)
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var buf strings.Builder
+	e := xml.NewEncoder(&buf)
+	s := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(r xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+		iq, err := stanza.NewIQ(*start)
+		if err != nil {
+			return err
+		}
+		_, err = xmlstream.Copy(e, xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+			return attr.Name.Local == "xmlns"
+		})(xmlstream.Inner(r)))
+		if err != nil {
+			return err
+		}
+		if err = e.Flush(); err != nil {
+			return err
+		}
+		_, err = xmlstream.Copy(r, iq.Result(nil))
+		return err
+	}))
+	err := vcard4.Publish(context.Background(), s.Client, vcard4.VCard{FullName: "Romeo Montague"})
+	if err != nil {
+		t.Fatalf("error publishing vCard: %v", err)
+	}
+	const want = `<pubsub xmlns="http://jabber.org/protocol/pubsub"><publish xmlns="http://jabber.org/protocol/pubsub" node="urn:xmpp:vcard4"><item xmlns="http://jabber.org/protocol/pubsub" id="current"><vcard xmlns="urn:ietf:params:xml:ns:vcard-4.0"><fn xmlns="urn:ietf:params:xml:ns:vcard-4.0"><text xmlns="urn:ietf:params:xml:ns:vcard-4.0">Romeo Montague</text></fn></vcard></item></publish></pubsub>`
+	if s := buf.String(); s != want {
+		t.Errorf("wrong request:\nwant=%s,\n got=%s", want, s)
+	}
+}
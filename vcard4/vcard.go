@@ -0,0 +1,177 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package vcard4 implements publishing and retrieving profile information.
+//
+// Profiles are stored as vCards (RFC 6350) in the XML format defined by
+// RFC 6351 using a personal eventing (PEP) node as described in XEP-0292:
+// vCard4 Over XMPP.
+// Many servers and older clients only support the legacy vcard-temp format
+// from XEP-0054: vcard-temp, so when fetching a profile this package falls
+// back to vcard-temp and converts the result if no vCard4 is available.
+//
+// Only a small number of common properties are supported.
+package vcard4 // import "mellium.im/xmpp/vcard4"
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"mellium.im/xmlstream"
+)
+
+// Namespaces used by this package.
+const (
+	NS       = "urn:ietf:params:xml:ns:vcard-4.0"
+	NSNode   = "urn:xmpp:vcard4"
+	NSLegacy = "vcard-temp"
+)
+
+// ItemID is the ID of the pubsub item containing the current vCard.
+const ItemID = "current"
+
+// VCard is a profile containing information about a user.
+type VCard struct {
+	// FullName is the formatted name of the user.
+	FullName string
+	// Nickname is a casual name for the user.
+	Nickname string
+	// Photo is a URI pointing to an image of the user.
+	// Photos converted from legacy vCards that contain image data use data URIs.
+	Photo string
+	// URLs are websites associated with the user.
+	URLs []string
+	// Org is the name of the organization to which the user belongs.
+	Org string
+}
+
+func textProp(name, text string) xml.TokenReader {
+	if text == "" {
+		return nil
+	}
+	return xmlstream.Wrap(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(text)),
+			xml.StartElement{Name: xml.Name{Local: "text"}},
+		),
+		xml.StartElement{Name: xml.Name{Local: name}},
+	)
+}
+
+func uriProp(name, uri string) xml.TokenReader {
+	if uri == "" {
+		return nil
+	}
+	return xmlstream.Wrap(
+		xmlstream.Wrap(
+			xmlstream.Token(xml.CharData(uri)),
+			xml.StartElement{Name: xml.Name{Local: "uri"}},
+		),
+		xml.StartElement{Name: xml.Name{Local: name}},
+	)
+}
+
+// TokenReader satisfies the xmlstream.Marshaler interface.
+// The vCard is always encoded in the vCard4 format.
+func (v VCard) TokenReader() xml.TokenReader {
+	props := []xml.TokenReader{
+		textProp("fn", v.FullName),
+		textProp("nickname", v.Nickname),
+		uriProp("photo", v.Photo),
+	}
+	for _, u := range v.URLs {
+		props = append(props, uriProp("url", u))
+	}
+	props = append(props, textProp("org", v.Org))
+	return xmlstream.Wrap(
+		xmlstream.MultiReader(props...),
+		xml.StartElement{Name: xml.Name{Space: NS, Local: "vcard"}},
+	)
+}
+
+// WriteXML satisfies the xmlstream.WriterTo interface.
+// It is like MarshalXML except it writes tokens to w.
+func (v VCard) WriteXML(w xmlstream.TokenWriter) (n int, err error) {
+	return xmlstream.Copy(w, v.TokenReader())
+}
+
+// MarshalXML implements xml.Marshaler.
+func (v VCard) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	_, err := v.WriteXML(e)
+	return err
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+// It can decode both vCard4 and legacy vcard-temp elements.
+func (v *VCard) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if start.Name.Space == NSLegacy {
+		return v.unmarshalLegacy(d, start)
+	}
+
+	type text struct {
+		Text string `xml:"text"`
+	}
+	type uri struct {
+		URI string `xml:"uri"`
+	}
+	data := struct {
+		FN       []text `xml:"fn"`
+		Nickname []text `xml:"nickname"`
+		Photo    []uri  `xml:"photo"`
+		URL      []uri  `xml:"url"`
+		Org      []text `xml:"org"`
+	}{}
+	err := d.DecodeElement(&data, &start)
+	if err != nil {
+		return err
+	}
+	*v = VCard{}
+	if len(data.FN) > 0 {
+		v.FullName = data.FN[0].Text
+	}
+	if len(data.Nickname) > 0 {
+		v.Nickname = data.Nickname[0].Text
+	}
+	if len(data.Photo) > 0 {
+		v.Photo = data.Photo[0].URI
+	}
+	for _, u := range data.URL {
+		v.URLs = append(v.URLs, u.URI)
+	}
+	if len(data.Org) > 0 {
+		v.Org = data.Org[0].Text
+	}
+	return nil
+}
+
+func (v *VCard) unmarshalLegacy(d *xml.Decoder, start xml.StartElement) error {
+	data := struct {
+		FN       string `xml:"FN"`
+		Nickname string `xml:"NICKNAME"`
+		Photo    struct {
+			Type   string `xml:"TYPE"`
+			BinVal string `xml:"BINVAL"`
+			ExtVal string `xml:"EXTVAL"`
+		} `xml:"PHOTO"`
+		URL []string `xml:"URL"`
+		Org struct {
+			Name string `xml:"ORGNAME"`
+		} `xml:"ORG"`
+	}{}
+	err := d.DecodeElement(&data, &start)
+	if err != nil {
+		return err
+	}
+	*v = VCard{
+		FullName: data.FN,
+		Nickname: data.Nickname,
+		Photo:    data.Photo.ExtVal,
+		URLs:     data.URL,
+		Org:      data.Org.Name,
+	}
+	if binVal := strings.Join(strings.Fields(data.Photo.BinVal), ""); binVal != "" {
+		v.Photo = "data:" + data.Photo.Type + ";base64," + binVal
+	}
+	return nil
+}
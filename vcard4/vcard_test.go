@@ -0,0 +1,85 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package vcard4_test
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"mellium.im/xmpp/vcard4"
+)
+
+var romeo = vcard4.VCard{
+	FullName: "Romeo Montague",
+	Nickname: "Romeo",
+	Photo:    "https://example.net/romeo.png",
+	URLs:     []string{"https://example.net/", "https://example.com/romeo"},
+	Org:      "House of Montague",
+}
+
+const romeoXML = `<vcard xmlns="urn:ietf:params:xml:ns:vcard-4.0"><fn><text>Romeo Montague</text></fn><nickname><text>Romeo</text></nickname><photo><uri>https://example.net/romeo.png</uri></photo><url><uri>https://example.net/</uri></url><url><uri>https://example.com/romeo</uri></url><org><text>House of Montague</text></org></vcard>`
+
+func TestMarshal(t *testing.T) {
+	out, err := xml.Marshal(romeo)
+	if err != nil {
+		t.Fatalf("error marshaling: %v", err)
+	}
+	if string(out) != romeoXML {
+		t.Errorf("wrong output:\nwant=%s,\n got=%s", romeoXML, out)
+	}
+	out, err = xml.Marshal(vcard4.VCard{})
+	if err != nil {
+		t.Fatalf("error marshaling empty vCard: %v", err)
+	}
+	const empty = `<vcard xmlns="urn:ietf:params:xml:ns:vcard-4.0"></vcard>`
+	if string(out) != empty {
+		t.Errorf("wrong output for empty vCard:\nwant=%s,\n got=%s", empty, out)
+	}
+}
+
+var unmarshalTestCases = [...]struct {
+	in  string
+	out vcard4.VCard
+}{
+	0: {in: romeoXML, out: romeo},
+	1: {
+		in: `<vCard xmlns="vcard-temp">
+  <FN>Juliet Capulet</FN>
+  <NICKNAME>Jules</NICKNAME>
+  <URL>https://example.com/</URL>
+  <ORG><ORGNAME>House of Capulet</ORGNAME><ORGUNIT>Verona</ORGUNIT></ORG>
+  <PHOTO><TYPE>image/png</TYPE><BINVAL>iVBO
+    Rw0K</BINVAL></PHOTO>
+</vCard>`,
+		out: vcard4.VCard{
+			FullName: "Juliet Capulet",
+			Nickname: "Jules",
+			Photo:    "data:image/png;base64,iVBORw0K",
+			URLs:     []string{"https://example.com/"},
+			Org:      "House of Capulet",
+		},
+	},
+	2: {
+		in:  `<vCard xmlns="vcard-temp"><PHOTO><EXTVAL>https://example.com/juliet.png</EXTVAL></PHOTO></vCard>`,
+		out: vcard4.VCard{Photo: "https://example.com/juliet.png"},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	for i, tc := range unmarshalTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var v vcard4.VCard
+			err := xml.Unmarshal([]byte(tc.in), &v)
+			if err != nil {
+				t.Fatalf("error unmarshaling: %v", err)
+			}
+			if !reflect.DeepEqual(v, tc.out) {
+				t.Errorf("wrong vCard:\nwant=%+v,\n got=%+v", tc.out, v)
+			}
+		})
+	}
+}
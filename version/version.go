@@ -2,14 +2,15 @@
 // Use of this source code is governed by the BSD 2-clause
 // license that can be found in the LICENSE file.
 
-//go:generate go run ../internal/genfeature
+//go:generate go run ../internal/genfeature -receiver "h Handler"
 
-// Package version queries a remote entity for software version info.
+// Package version queries and responds to requests for software version info.
 package version // import "mellium.im/xmpp/version"
 
 import (
 	"context"
 	"encoding/xml"
+	"runtime/debug"
 
 	"mellium.im/xmlstream"
 	"mellium.im/xmpp"
@@ -82,18 +83,47 @@ func GetIQ(ctx context.Context, iq stanza.IQ, s *xmpp.Session) (Query, error) {
 	return query, err
 }
 
-// Handle returns an option that registers a Handler for software version requests.
-func Handle(q Query) mux.Option {
-	return mux.IQ(stanza.GetIQ, xml.Name{Local: "query", Space: NS}, handler{q})
+// Handle returns an option that registers a Handler for software version
+// requests.
+func Handle(h Handler) mux.Option {
+	return mux.IQ(stanza.GetIQ, xml.Name{Local: "query", Space: NS}, h)
 }
 
-// handler responds to software version requests.
-type handler struct {
-	Query Query
+// Handler responds to software version requests.
+//
+// If Name or Version are empty they are taken from the build information of
+// the running program (the path and version of the main module).
+// Revealing the operating system may help an attacker target the host, so OS
+// is only sent if it is set explicitly.
+type Handler struct {
+	Name    string
+	Version string
+	OS      string
 }
 
-// HandleIQ responds to software version requests.
-func (h handler) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
-	_, err := xmlstream.Copy(t, iq.Result(h.Query.TokenReader()))
+// Query returns the payload that will be sent in response to software version
+// requests.
+func (h Handler) Query() Query {
+	q := Query{
+		Name:    h.Name,
+		Version: h.Version,
+		OS:      h.OS,
+	}
+	if q.Name == "" || q.Version == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			if q.Name == "" {
+				q.Name = info.Main.Path
+			}
+			if q.Version == "" {
+				q.Version = info.Main.Version
+			}
+		}
+	}
+	return q
+}
+
+// HandleIQ implements mux.IQHandler.
+func (h Handler) HandleIQ(iq stanza.IQ, t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+	_, err := xmlstream.Copy(t, iq.Result(h.Query().TokenReader()))
 	return err
 }
@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	"mellium.im/xmlstream"
+	"mellium.im/xmpp/disco/info"
 	"mellium.im/xmpp/internal/xmpptest"
 	"mellium.im/xmpp/jid"
 	"mellium.im/xmpp/mux"
@@ -23,6 +24,8 @@ import (
 var (
 	_ xmlstream.Marshaler = (*version.Query)(nil)
 	_ xmlstream.WriterTo  = (*version.Query)(nil)
+	_ mux.IQHandler       = version.Handler{}
+	_ info.FeatureIter    = version.Handler{}
 )
 
 var marshalTests = [...]struct {
@@ -79,7 +82,11 @@ func TestGet(t *testing.T) {
 		Version: "ver",
 		OS:      "os",
 	}
-	m := mux.New(stanza.NSClient, version.Handle(query))
+	m := mux.New(stanza.NSClient, version.Handle(version.Handler{
+		Name:    query.Name,
+		Version: query.Version,
+		OS:      query.OS,
+	}))
 	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(m))
 
 	resp, err := version.Get(context.Background(), cs.Client, jid.JID{})
@@ -95,3 +102,32 @@ func TestGet(t *testing.T) {
 		t.Errorf("unexpected response: want=%v, got=%v", query, resp)
 	}
 }
+
+func TestHandlerQuery(t *testing.T) {
+	q := version.Handler{}.Query()
+	if q.OS != "" {
+		t.Errorf("expected OS to be omitted by default, got %s", q.OS)
+	}
+	want := version.Query{Name: "name", Version: "ver", OS: "os"}
+	q = version.Handler{Name: want.Name, Version: want.Version, OS: want.OS}.Query()
+	if q != want {
+		t.Errorf("wrong query: want=%v, got=%v", want, q)
+	}
+}
+
+func TestHandleFeature(t *testing.T) {
+	m := mux.New(stanza.NSClient, version.Handle(version.Handler{}))
+	var found bool
+	err := m.ForFeatures("", func(f info.Feature) error {
+		if f == version.Feature {
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error iterating over features: %v", err)
+	}
+	if !found {
+		t.Errorf("expected %s to be advertised", version.NS)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+)
+
+const (
+	// SignatureHeader is the HTTP header that contains the signature of requests
+	// made by a Forwarder.
+	SignatureHeader = "X-Signature-256"
+
+	sigPrefix = "sha256="
+
+	// DefaultTimeout is the default time that a Forwarder waits for a webhook to
+	// respond.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Sign returns the signature of body using secret.
+// The signature is the hex encoded HMAC-SHA256 of the body, prefixed with
+// "sha256=".
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	/* #nosec */
+	mac.Write(body)
+	return sigPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether sig is a valid signature of body using secret.
+// It is meant to be used by services that receive requests from a Forwarder.
+func Verify(secret, body []byte, sig string) bool {
+	if !strings.HasPrefix(sig, sigPrefix) {
+		return false
+	}
+	mac, err := hex.DecodeString(sig[len(sigPrefix):])
+	if err != nil {
+		return false
+	}
+	h := hmac.New(sha256.New, secret)
+	/* #nosec */
+	h.Write(body)
+	return hmac.Equal(mac, h.Sum(nil))
+}
+
+// Handle returns an option that registers a Forwarder for all non-error
+// messages that have a body.
+func Handle(f *Forwarder) mux.Option {
+	return func(m *mux.ServeMux) {
+		body := xml.Name{Local: "body"}
+		for _, typ := range []stanza.MessageType{
+			stanza.NormalMessage,
+			stanza.ChatMessage,
+			stanza.GroupChatMessage,
+			stanza.HeadlineMessage,
+		} {
+			mux.Message(typ, body, f)(m)
+		}
+	}
+}
+
+// Forwarder posts received messages to a webhook as a JSON encoded Message.
+//
+// Messages are forwarded while the message is being handled, so a slow webhook
+// will delay handling of further stanzas (up to the configured timeout) unless
+// the session is served with multiple workers.
+// Any response status other than 2xx is treated as an error.
+type Forwarder struct {
+	// URL is the webhook that messages are posted to.
+	URL string
+
+	// Secret is used to sign requests.
+	// If Secret is empty, requests are not signed.
+	Secret []byte
+
+	// Client is used to make requests.
+	// If Client is nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Timeout limits the time spent forwarding each received message.
+	// If Timeout is zero, DefaultTimeout is used.
+	Timeout time.Duration
+
+	// Filter selects the messages to forward.
+	// If Filter is nil, all messages are forwarded.
+	Filter func(Message) bool
+
+	// Error is called with any error that occurs while forwarding a message.
+	// Errors are not returned from the handler because they are not a problem
+	// with the stream and should not end the session.
+	// If Error is nil, errors are ignored.
+	Error func(error)
+}
+
+// HandleMessage satisfies mux.MessageHandler.
+func (f *Forwarder) HandleMessage(msg stanza.Message, t xmlstream.TokenReadEncoder) error {
+	body := struct {
+		stanza.Message
+		Body string `xml:"body"`
+	}{}
+	err := xml.NewTokenDecoder(t).Decode(&body)
+	if err != nil {
+		return err
+	}
+
+	m := Message{
+		ID:   msg.ID,
+		Type: msg.Type,
+		To:   msg.To.String(),
+		From: msg.From.String(),
+		Lang: msg.Lang,
+		Body: body.Body,
+	}
+	if f.Filter != nil && !f.Filter(m) {
+		return nil
+	}
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err = f.Forward(ctx, m)
+	if err != nil && f.Error != nil {
+		f.Error(err)
+	}
+	return nil
+}
+
+// Forward posts m to the webhook.
+func (f *Forwarder) Forward(ctx context.Context, m Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(f.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(f.Secret, data))
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	/* #nosec */
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: unexpected response status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+// Package webhook implements an HTTP gateway for sending and receiving
+// messages.
+//
+// The Gateway type is an http.Handler that lets other services send messages
+// through an existing session (which may be a client or a component) by making
+// a POST request to /v1/message.
+// In the other direction, a Forwarder can be registered on a mux to post
+// received messages to a webhook URL.
+// Requests made by a Forwarder may be signed with a shared secret so that the
+// receiving service can check where they came from using Verify.
+//
+// Both directions use the same JSON representation of a message, the Message
+// type:
+//
+//	POST /v1/message HTTP/1.1
+//	Content-Type: application/json
+//
+//	{"to":"juliet@example.com","body":"Art thou not Romeo, and a Montague?"}
+package webhook // import "mellium.im/xmpp/webhook"
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/internal/attr"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/stanza"
+)
+
+const (
+	// Path is the path on which the gateway accepts messages.
+	Path = "/v1/message"
+
+	// DefaultMaxBytes is the default limit on the size of request bodies
+	// accepted by the gateway.
+	DefaultMaxBytes = 64 << 10
+)
+
+// Message is the JSON representation of a message.
+type Message struct {
+	ID   string             `json:"id,omitempty"`
+	Type stanza.MessageType `json:"type,omitempty"`
+	To   string             `json:"to,omitempty"`
+	From string             `json:"from,omitempty"`
+	Lang string             `json:"lang,omitempty"`
+	Body string             `json:"body"`
+}
+
+// Response is the JSON body returned by the gateway after a message is sent.
+type Response struct {
+	// ID is the ID of the message stanza that was sent.
+	ID string `json:"id"`
+}
+
+// Gateway is an http.Handler that sends messages over a session.
+//
+// Messages are sent by making a POST request with a JSON encoded Message as the
+// body to Path.
+// The "to" and "body" fields are required.
+// If the type is empty, a message of type "chat" is sent and if the ID is
+// empty a random ID is generated.
+// The "from" field is passed through to the stanza unchanged, it is only useful
+// for sessions (such as components) that are allowed to set their own from
+// address.
+// Because of this, and because anyone that can make requests can send messages
+// as the session, requests are only accepted if Authorize is set.
+//
+// If the message is sent, the gateway responds with 202 Accepted and a
+// JSON encoded Response.
+type Gateway struct {
+	// Session is the session used to send messages.
+	Session *xmpp.Session
+
+	// Authorize is called with every request before it is handled and may
+	// return an error to reject it, for example if a bearer token is missing.
+	// If Authorize is nil, all requests are rejected.
+	Authorize func(r *http.Request) error
+
+	// MaxBytes is the maximum size of a request body.
+	// Requests with larger bodies are rejected with a 413 Request Entity Too
+	// Large status.
+	// If MaxBytes is zero, DefaultMaxBytes is used.
+	MaxBytes int64
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (g Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != Path {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if g.Authorize == nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if err := g.Authorize(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	maxBytes := g.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	var m Message
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes)).Decode(&m)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "webhook: error decoding message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	msg, err := m.stanza()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = g.Session.Send(r.Context(), msg.Wrap(xmlstream.Wrap(
+		xmlstream.Token(xml.CharData(m.Body)),
+		xml.StartElement{Name: xml.Name{Local: "body"}},
+	)))
+	if err != nil {
+		http.Error(w, "webhook: error sending message: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	/* #nosec */
+	json.NewEncoder(w).Encode(Response{ID: msg.ID})
+}
+
+var (
+	errNoTo   = errors.New("webhook: message is missing a recipient")
+	errNoBody = errors.New("webhook: message is missing a body")
+	errType   = errors.New("webhook: invalid message type")
+)
+
+func (m Message) stanza() (stanza.Message, error) {
+	if m.To == "" {
+		return stanza.Message{}, errNoTo
+	}
+	if m.Body == "" {
+		return stanza.Message{}, errNoBody
+	}
+	msg := stanza.Message{
+		ID:   m.ID,
+		Type: m.Type,
+		Lang: m.Lang,
+	}
+	switch msg.Type {
+	case "":
+		msg.Type = stanza.ChatMessage
+	case stanza.NormalMessage, stanza.ChatMessage, stanza.GroupChatMessage, stanza.HeadlineMessage:
+	default:
+		return msg, errType
+	}
+	if msg.ID == "" {
+		msg.ID = attr.RandomID()
+	}
+	var err error
+	msg.To, err = jid.Parse(m.To)
+	if err != nil {
+		return msg, err
+	}
+	if m.From != "" {
+		msg.From, err = jid.Parse(m.From)
+	}
+	return msg, err
+}
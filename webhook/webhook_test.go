@@ -0,0 +1,330 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package webhook_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp/internal/xmpptest"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/webhook"
+)
+
+var (
+	_ http.Handler       = webhook.Gateway{}
+	_ mux.MessageHandler = (*webhook.Forwarder)(nil)
+)
+
+var gatewayTestCases = [...]struct {
+	method string
+	path   string
+	body   string
+	auth   bool
+	noAuth bool
+	code   int
+	out    string
+
+	maxBytes int64
+}{
+	0: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"id":"123","to":"juliet@example.com","body":"Art thou not Romeo, and a Montague?"}`,
+		code:   http.StatusAccepted,
+		out:    `<message xmlns="jabber:client" type="chat" to="juliet@example.com" id="123"><body xmlns="jabber:client">Art thou not Romeo, and a Montague?</body></message>`,
+	},
+	1: {
+		method: http.MethodGet,
+		path:   webhook.Path,
+		code:   http.StatusMethodNotAllowed,
+	},
+	2: {
+		method: http.MethodPost,
+		path:   "/v2/message",
+		body:   `{"to":"juliet@example.com","body":"Hi"}`,
+		code:   http.StatusNotFound,
+	},
+	3: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"to":"juliet@example.com"}`,
+		code:   http.StatusBadRequest,
+	},
+	4: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"body":"Hi"}`,
+		code:   http.StatusBadRequest,
+	},
+	5: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"to":"@","body":"Hi"}`,
+		code:   http.StatusBadRequest,
+	},
+	6: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"to":"juliet@example.com","body":"Hi","type":"error"}`,
+		code:   http.StatusBadRequest,
+	},
+	7: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"to":`,
+		code:   http.StatusBadRequest,
+	},
+	8: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"to":"juliet@example.com","body":"Hi"}`,
+		auth:   true,
+		code:   http.StatusUnauthorized,
+	},
+	9: {
+		method: http.MethodPost,
+		path:   webhook.Path,
+		body:   `{"to":"juliet@example.com","body":"Hi"}`,
+		noAuth: true,
+		code:   http.StatusForbidden,
+	},
+	10: {
+		method:   http.MethodPost,
+		path:     webhook.Path,
+		body:     `{"to":"juliet@example.com","body":"Art thou not Romeo, and a Montague?"}`,
+		code:     http.StatusRequestEntityTooLarge,
+		maxBytes: 16,
+	},
+}
+
+func TestGateway(t *testing.T) {
+	for i, tc := range gatewayTestCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			received := make(chan string, 1)
+			cs := xmpptest.NewClientServer(xmpptest.ServerHandlerFunc(func(t xmlstream.TokenReadEncoder, start *xml.StartElement) error {
+				var buf bytes.Buffer
+				e := xml.NewEncoder(&buf)
+				r := xmlstream.MultiReader(xmlstream.Token(*start), xmlstream.Inner(t), xmlstream.Token(start.End()))
+				// Prevent duplicate xmlns attributes. See https://mellium.im/issue/75
+				r = xmlstream.RemoveAttr(func(_ xml.StartElement, attr xml.Attr) bool {
+					return attr.Name.Local == "xmlns"
+				})(r)
+				_, err := xmlstream.Copy(e, r)
+				if err != nil {
+					return err
+				}
+				err = e.Flush()
+				received <- buf.String()
+				return err
+			}))
+			defer cs.Close()
+
+			g := webhook.Gateway{
+				Session: cs.Client,
+				Authorize: func(*http.Request) error {
+					if tc.auth {
+						return errors.New("unauthorized")
+					}
+					return nil
+				},
+			}
+			g.MaxBytes = tc.maxBytes
+			if tc.noAuth {
+				g.Authorize = nil
+			}
+			rec := httptest.NewRecorder()
+			g.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			if rec.Code != tc.code {
+				t.Fatalf("wrong status code: want=%d, got=%d (%s)", tc.code, rec.Code, rec.Body)
+			}
+			if tc.out == "" {
+				return
+			}
+
+			var resp webhook.Response
+			err := json.NewDecoder(rec.Body).Decode(&resp)
+			if err != nil {
+				t.Fatalf("error decoding response: %v", err)
+			}
+			if resp.ID != "123" {
+				t.Errorf("wrong ID in response: want=123, got=%s", resp.ID)
+			}
+			select {
+			case out := <-received:
+				if out != tc.out {
+					t.Errorf("wrong stanza:\nwant=%s,\n got=%s", tc.out, out)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for stanza")
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"body":"Hi"}`)
+	sig := webhook.Sign(secret, body)
+	if !strings.HasPrefix(sig, "sha256=") {
+		t.Errorf("expected sha256= prefix, got %s", sig)
+	}
+	if !webhook.Verify(secret, body, sig) {
+		t.Errorf("expected signature to verify")
+	}
+	if webhook.Verify([]byte("other"), body, sig) {
+		t.Errorf("signature should not verify with a different secret")
+	}
+	if webhook.Verify(secret, []byte(`{}`), sig) {
+		t.Errorf("signature should not verify with a different body")
+	}
+	if webhook.Verify(secret, body, strings.TrimPrefix(sig, "sha256=")) {
+		t.Errorf("signature without a prefix should not verify")
+	}
+}
+
+func TestForward(t *testing.T) {
+	secret := []byte("secret")
+	type request struct {
+		body []byte
+		sig  string
+	}
+	requests := make(chan request, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		requests <- request{body: body, sig: r.Header.Get(webhook.SignatureHeader)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	f := &webhook.Forwarder{
+		URL:    srv.URL,
+		Secret: secret,
+		Client: srv.Client(),
+		Filter: func(m webhook.Message) bool {
+			return m.Type == stanza.ChatMessage
+		},
+		Error: func(err error) {
+			errs <- err
+		},
+	}
+	m := mux.New(stanza.NSClient, webhook.Handle(f))
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(m))
+	defer cs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, msg := range []string{
+		`<message xmlns="jabber:client" type="headline" id="1" to="example.net"><body>Skipped</body></message>`,
+		`<message xmlns="jabber:client" type="chat" id="2" to="example.net" from="test@example.net/r"><active xmlns="http://jabber.org/protocol/chatstates"/><body>Hi</body></message>`,
+	} {
+		err := cs.Client.Send(ctx, xml.NewDecoder(strings.NewReader(msg)))
+		if err != nil {
+			t.Fatalf("error sending message: %v", err)
+		}
+	}
+
+	var req request
+	select {
+	case req = <-requests:
+	case err := <-errs:
+		t.Fatalf("error forwarding message: %v", err)
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for webhook request")
+	}
+	if !webhook.Verify(secret, req.body, req.sig) {
+		t.Errorf("invalid signature %q", req.sig)
+	}
+	var got webhook.Message
+	err := json.Unmarshal(req.body, &got)
+	if err != nil {
+		t.Fatalf("error decoding webhook request: %v", err)
+	}
+	want := webhook.Message{
+		ID:   "2",
+		Type: stanza.ChatMessage,
+		To:   "example.net",
+		From: "test@example.net/r",
+		Body: "Hi",
+	}
+	if got != want {
+		t.Errorf("wrong message forwarded: want=%+v, got=%+v", want, got)
+	}
+	select {
+	case req = <-requests:
+		t.Errorf("unexpected second request: %s", req.body)
+	default:
+	}
+}
+
+func TestForwardError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	f := &webhook.Forwarder{URL: srv.URL, Client: srv.Client()}
+	err := f.Forward(context.Background(), webhook.Message{Body: "Hi"})
+	if err == nil {
+		t.Errorf("expected error for non-2xx response")
+	}
+}
+
+func TestForwardTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	errs := make(chan error, 1)
+	f := &webhook.Forwarder{
+		URL:     srv.URL,
+		Client:  srv.Client(),
+		Timeout: 10 * time.Millisecond,
+		Error: func(err error) {
+			errs <- err
+		},
+	}
+	m := mux.New(stanza.NSClient, webhook.Handle(f))
+	cs := xmpptest.NewClientServer(xmpptest.ServerHandler(m))
+	defer cs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := cs.Client.Send(ctx, xml.NewDecoder(strings.NewReader(`<message xmlns="jabber:client" type="chat" id="1" to="example.net"><body>Hi</body></message>`)))
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("wrong error: want=%v, got=%v", context.DeadlineExceeded, err)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for the webhook request to be canceled")
+	}
+}
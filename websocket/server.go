@@ -0,0 +1,71 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+)
+
+var errNoProtocol = errors.New("websocket: client did not request the " + WSProtocol + " subprotocol")
+
+// NewHandler returns an http.Handler that accepts WebSocket connections using
+// the XMPP subprotocol and calls f with each connection.
+// For more information see the Server type.
+func NewHandler(f func(net.Conn)) http.Handler {
+	return Server{Handler: f}
+}
+
+// Server is an http.Handler that completes the WebSocket handshake described
+// in RFC 7395 and passes the resulting connection to a handler.
+// Handshakes from clients that do not request the "xmpp" subprotocol are
+// rejected.
+//
+// The connection may be passed to ReceiveSession or any of the session
+// negotiation functions in the xmpp package along with a Negotiator from this
+// package.
+// If the handshake request was received over TLS, the connection is considered
+// secure by ReceiveSession.
+// Callers that use xmpp.ReceiveSession directly must set xmpp.Secure
+// themselves.
+type Server struct {
+	// Handler is called with each connection after the handshake is completed.
+	// The connection is closed when Handler returns.
+	Handler func(net.Conn)
+
+	// CheckOrigin is called with the handshake request and may return an error
+	// to reject the connection, for example if the Origin header is not a
+	// trusted web application.
+	// If CheckOrigin is nil, all origins are accepted.
+	CheckOrigin func(r *http.Request) error
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Server{
+		Handshake: s.handshake,
+		Handler: func(conn *websocket.Conn) {
+			s.Handler(conn)
+		},
+	}.ServeHTTP(w, r)
+}
+
+func (s Server) handshake(cfg *websocket.Config, r *http.Request) error {
+	if s.CheckOrigin != nil {
+		if err := s.CheckOrigin(r); err != nil {
+			return err
+		}
+	}
+	for _, proto := range cfg.Protocol {
+		if proto == WSProtocol {
+			cfg.Protocol = []string{WSProtocol}
+			return nil
+		}
+	}
+	return errNoProtocol
+}
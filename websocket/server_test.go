@@ -0,0 +1,213 @@
+// Copyright 2026 The Mellium Contributors.
+// Use of this source code is governed by the BSD 2-clause
+// license that can be found in the LICENSE file.
+
+package websocket_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mellium.im/xmlstream"
+	"mellium.im/xmpp"
+	"mellium.im/xmpp/jid"
+	"mellium.im/xmpp/mux"
+	"mellium.im/xmpp/ping"
+	"mellium.im/xmpp/stanza"
+	"mellium.im/xmpp/websocket"
+)
+
+func streamConfig(*xmpp.Session, *xmpp.StreamConfig) xmpp.StreamConfig {
+	return xmpp.StreamConfig{
+		Features: []xmpp.StreamFeature{xmpp.BindResource()},
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	serverErr := make(chan error, 1)
+	srv := httptest.NewServer(websocket.NewHandler(func(conn net.Conn) {
+		// Skip authentication so that only resource binding is negotiated.
+		session, err := xmpp.ReceiveSession(ctx, conn, xmpp.Secure|xmpp.Authn, websocket.Negotiator(streamConfig))
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		serverErr <- session.Serve(mux.New(stanza.NSClient, ping.Handle()))
+	}))
+	defer srv.Close()
+
+	d := websocket.Dialer{
+		Origin:        srv.URL,
+		InsecureNoTLS: true,
+	}
+	conn, err := d.DialDirect(ctx, strings.Replace(srv.URL, "http", "ws", 1))
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	j := jid.MustParse("juliet@example.net")
+	session, err := xmpp.NewSession(ctx, j.Domain(), j, conn, xmpp.Secure|xmpp.Authn, websocket.Negotiator(streamConfig))
+	if err != nil {
+		t.Fatalf("error negotiating session: %v", err)
+	}
+	go func() {
+		/* #nosec */
+		session.Serve(nil)
+	}()
+	err = ping.Send(ctx, session, j.Domain())
+	if err != nil {
+		t.Fatalf("error sending ping: %v", err)
+	}
+	err = session.Close()
+	if err != nil {
+		t.Fatalf("error closing session: %v", err)
+	}
+	if err = <-serverErr; err != nil {
+		t.Fatalf("error from server: %v", err)
+	}
+}
+
+func TestServerRejectsProtocol(t *testing.T) {
+	srv := httptest.NewServer(websocket.NewHandler(func(net.Conn) {
+		t.Errorf("handler should not be called")
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", "chat")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error making request: %v", err)
+	}
+	/* #nosec */
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("wrong status: want=%d, got=%d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func TestServerCheckOrigin(t *testing.T) {
+	srv := httptest.NewServer(websocket.Server{
+		Handler: func(net.Conn) {
+			t.Errorf("handler should not be called")
+		},
+		CheckOrigin: func(r *http.Request) error {
+			return http.ErrNoCookie
+		},
+	})
+	defer srv.Close()
+
+	d := websocket.Dialer{
+		Origin:        srv.URL,
+		InsecureNoTLS: true,
+	}
+	_, err := d.DialDirect(context.Background(), strings.Replace(srv.URL, "http", "ws", 1))
+	if err == nil {
+		t.Fatalf("expected handshake to fail")
+	}
+}
+
+// readyFeature is a required feature that completes negotiation as soon as the
+// client selects it.
+var readyFeature = xmpp.StreamFeature{
+	Name: xml.Name{Space: "urn:example", Local: "ready"},
+	List: func(ctx context.Context, e xmlstream.TokenWriter, start xml.StartElement) (bool, error) {
+		err := e.EncodeToken(start)
+		if err != nil {
+			return true, err
+		}
+		return true, e.EncodeToken(start.End())
+	},
+	Parse: func(ctx context.Context, d *xml.Decoder, start *xml.StartElement) (bool, interface{}, error) {
+		return true, nil, d.Skip()
+	},
+	Negotiate: func(ctx context.Context, session *xmpp.Session, data interface{}) (xmpp.SessionState, io.ReadWriter, error) {
+		if session.State()&xmpp.Received == xmpp.Received {
+			r := session.TokenReader()
+			defer r.Close()
+			d := xml.NewTokenDecoder(r)
+			if _, err := d.Token(); err != nil {
+				return xmpp.Ready, nil, err
+			}
+			return xmpp.Ready, nil, d.Skip()
+		}
+		start := xml.StartElement{Name: xml.Name{Space: "urn:example", Local: "ready"}}
+		w := session.TokenWriter()
+		defer w.Close()
+		_, err := xmlstream.Copy(w, xmlstream.Wrap(nil, start))
+		if err != nil {
+			return xmpp.Ready, nil, err
+		}
+		return xmpp.Ready, nil, w.Flush()
+	},
+}
+
+func TestServerTLS(t *testing.T) {
+	for i, tc := range [...]struct {
+		newServer func(http.Handler) *httptest.Server
+		secure    bool
+	}{
+		0: {newServer: httptest.NewServer},
+		1: {newServer: httptest.NewTLSServer, secure: true},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			serverState := make(chan xmpp.SessionState, 1)
+			srv := tc.newServer(websocket.NewHandler(func(conn net.Conn) {
+				session, err := websocket.ReceiveSession(ctx, conn, readyFeature)
+				if err != nil {
+					t.Errorf("error receiving session: %v", err)
+					close(serverState)
+					return
+				}
+				serverState <- session.State()
+				/* #nosec */
+				session.Close()
+			}))
+			defer srv.Close()
+
+			d := websocket.Dialer{
+				Origin:        srv.URL,
+				InsecureNoTLS: !tc.secure,
+			}
+			if tc.secure {
+				d.TLSConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig
+			}
+			conn, err := d.DialDirect(ctx, strings.Replace(srv.URL, "http", "ws", 1))
+			if err != nil {
+				t.Fatalf("error dialing: %v", err)
+			}
+			session, err := websocket.NewSession(ctx, jid.MustParse("juliet@example.net"), conn, readyFeature)
+			if err != nil {
+				t.Fatalf("error negotiating session: %v", err)
+			}
+			if secure := session.State()&xmpp.Secure == xmpp.Secure; secure != tc.secure {
+				t.Errorf("wrong client security: want=%t, got=%t", tc.secure, secure)
+			}
+			if secure := <-serverState&xmpp.Secure == xmpp.Secure; secure != tc.secure {
+				t.Errorf("wrong server security: want=%t, got=%t", tc.secure, secure)
+			}
+			/* #nosec */
+			session.Close()
+		})
+	}
+}
@@ -31,7 +31,7 @@ func NewSession(ctx context.Context, addr jid.JID, rw io.ReadWriter, features ..
 		}
 	})
 	var mask xmpp.SessionState
-	if wsConn, ok := rw.(*websocket.Conn); ok && wsConn.LocalAddr().(*websocket.Addr).Scheme == "wss" {
+	if isSecure(rw) {
 		mask |= xmpp.Secure
 	}
 	return xmpp.NewSession(ctx, addr.Domain(), addr, rw, mask, n)
@@ -47,12 +47,29 @@ func ReceiveSession(ctx context.Context, rw io.ReadWriter, features ...xmpp.Stre
 		}
 	})
 	var mask xmpp.SessionState
-	if wsConn, ok := rw.(*websocket.Conn); ok && wsConn.LocalAddr().(*websocket.Addr).Scheme == "wss" {
+	if isSecure(rw) {
 		mask |= xmpp.Secure
 	}
 	return xmpp.ReceiveSession(ctx, rw, mask, n)
 }
 
+// isSecure reports whether rw is a WebSocket connection that was made over
+// TLS.
+// On the server side this is true if the handshake request was received over
+// TLS, on the client side if the location has the wss scheme.
+func isSecure(rw io.ReadWriter) bool {
+	wsConn, ok := rw.(*websocket.Conn)
+	if !ok {
+		return false
+	}
+	if wsConn.IsServerConn() {
+		req := wsConn.Request()
+		return req != nil && req.TLS != nil
+	}
+	cfg := wsConn.Config()
+	return cfg != nil && cfg.Location != nil && cfg.Location.Scheme == "wss"
+}
+
 // NewClient performs the WebSocket handshake on rwc and then attempts to
 // establish an XMPP session on top of it.
 // Location is the WebSocket location and addr is the actual JID expected at the